@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// verifyAudit implements the verify-audit subcommand, which checks the hash chain and signature of an
+// exported audit bundle and returns the process exit code
+func verifyAudit(args []string) int {
+	flags := flag.NewFlagSet("verify-audit", flag.ContinueOnError)
+	signer := flags.String("signer", "", "the Stellar public key of the audit signing key, which must have signed the bundle (required)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: verify-audit -signer G... bundle.json")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 || *signer == "" {
+		flags.Usage()
+		return 2
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read bundle: %v\n", err)
+		return 2
+	}
+	var bundle models.AuditBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to decode bundle: %v\n", err)
+		return 2
+	}
+
+	if err := services.VerifyAuditBundle(bundle, *signer); err != nil {
+		fmt.Fprintf(os.Stderr, "audit bundle INVALID: %v\n", err)
+		return 1
+	}
+	fmt.Printf("audit bundle valid: %d entries from %s to %s signed by %s\n",
+		len(bundle.Entries), bundle.From.Format("2006-01-02T15:04:05Z07:00"), bundle.To.Format("2006-01-02T15:04:05Z07:00"), bundle.Signer)
+	return 0
+}
@@ -0,0 +1,337 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// AdminController handles operator-only HTTP requests
+type AdminController struct {
+	Service *services.WalletService
+}
+
+// NewAdminController creates a new AdminController instance
+func NewAdminController(service *services.WalletService) *AdminController {
+	return &AdminController{Service: service}
+}
+
+// RequireAdmin rejects requests whose X-Admin-Key header does not match the configured admin API key
+func (ctrl *AdminController) RequireAdmin(c *gin.Context) {
+	key := ctrl.Service.Config.AdminAPIKey
+	if key == "" {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "admin API is disabled"})
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader("X-Admin-Key")), []byte(key)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid admin key"})
+		return
+	}
+	c.Next()
+}
+
+// GetSLOStatus handles GET /api/v1/admin/slo
+func (ctrl *AdminController) GetSLOStatus(c *gin.Context) {
+	status := ctrl.Service.SLO.Status()
+	c.JSON(http.StatusOK, models.SLOStatusResponse{
+		ThresholdSeconds:      status.Threshold.Seconds(),
+		Target:                status.Target,
+		WindowSeconds:         status.Window.Seconds(),
+		Samples:               status.Samples,
+		WithinThreshold:       status.WithinTarget,
+		Compliance:            status.Compliance,
+		Breached:              status.Breached,
+		TotalRecorded:         status.TotalRecorded,
+		AverageLatencySeconds: status.AverageLatency.Seconds(),
+		LastLatencySeconds:    status.LastLatency.Seconds(),
+		LastTransactionHash:   status.LastHash,
+	})
+}
+
+// ListTransferReviews handles GET /api/v1/admin/transfer-reviews
+func (ctrl *AdminController) ListTransferReviews(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.Service.ListTransferReviews())
+}
+
+// ApproveTransferReview handles POST /api/v1/admin/transfer-reviews/:id/approve
+func (ctrl *AdminController) ApproveTransferReview(c *gin.Context) {
+	ctrl.decideTransferReview(c, ctrl.Service.ApproveTransferReview)
+}
+
+// RejectTransferReview handles POST /api/v1/admin/transfer-reviews/:id/reject
+func (ctrl *AdminController) RejectTransferReview(c *gin.Context) {
+	ctrl.decideTransferReview(c, ctrl.Service.RejectTransferReview)
+}
+
+func (ctrl *AdminController) decideTransferReview(c *gin.Context, decide func(string) (*models.TransferReviewResponse, error)) {
+	response, err := decide(c.Param("id"))
+	if err != nil {
+		switch err.Error() {
+		case "transfer review not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "transfer review already decided":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeactivateWallet handles POST /api/v1/admin/wallets/:public_key/deactivate
+func (ctrl *AdminController) DeactivateWallet(c *gin.Context) {
+	var req models.DeactivateWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	// Admin requests authenticate no tenant, so the service only signs for the wallet with its secret key
+	response, err := ctrl.Service.DeactivateWallet("", c.Param("public_key"), req)
+	if err != nil {
+		switch {
+		case err.Error() == "invalid public key format", strings.HasPrefix(err.Error(), "invalid wallet secret key"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "wallet is already deactivated":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreWallet handles POST /api/v1/admin/wallets/:public_key/restore
+func (ctrl *AdminController) RestoreWallet(c *gin.Context) {
+	response, err := ctrl.Service.RestoreWallet(c.Param("public_key"))
+	if err != nil {
+		switch err.Error() {
+		case "invalid public key format":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "wallet is not deactivated":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// FreezeWallet handles POST /api/v1/admin/wallets/:public_key/freeze
+func (ctrl *AdminController) FreezeWallet(c *gin.Context) {
+	var req models.FreezeWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.FreezeWallet(c.Param("public_key"), req)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid public key"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "wallet is already frozen":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UnfreezeWallet handles POST /api/v1/admin/wallets/:public_key/unfreeze
+func (ctrl *AdminController) UnfreezeWallet(c *gin.Context) {
+	response, err := ctrl.Service.UnfreezeWallet(c.Param("public_key"))
+	if err != nil {
+		switch err.Error() {
+		case "invalid public key format":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "wallet is not frozen":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetWalletFreeze handles GET /api/v1/admin/wallets/:public_key/freeze
+func (ctrl *AdminController) GetWalletFreeze(c *gin.Context) {
+	response, err := ctrl.Service.GetWalletFreeze(c.Param("public_key"))
+	if err != nil {
+		if err.Error() == "invalid public key format" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeSponsorship handles POST /api/v1/admin/wallets/:public_key/sponsorship/revoke
+func (ctrl *AdminController) RevokeSponsorship(c *gin.Context) {
+	var req models.RevokeSponsorshipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.RevokeSponsorship(c.Param("public_key"), req)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "wallet not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "wallet has"), strings.HasPrefix(err.Error(), "new sponsor has"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetWalletDeactivation handles GET /api/v1/admin/wallets/:public_key/deactivation
+func (ctrl *AdminController) GetWalletDeactivation(c *gin.Context) {
+	response, err := ctrl.Service.GetWalletDeactivation(c.Param("public_key"))
+	if err != nil {
+		switch err.Error() {
+		case "invalid public key format":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "wallet deactivation not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSpendLimits handles GET /api/v1/admin/wallets/:public_key/spend-limits
+func (ctrl *AdminController) GetSpendLimits(c *gin.Context) {
+	response, err := ctrl.Service.GetSpendLimits(c.Param("public_key"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetSpendLimits handles PUT /api/v1/admin/wallets/:public_key/spend-limits
+func (ctrl *AdminController) SetSpendLimits(c *gin.Context) {
+	var req models.SpendLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	response, err := ctrl.Service.SetSpendLimits(c.Param("public_key"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetSpendLimits handles DELETE /api/v1/admin/wallets/:public_key/spend-limits
+func (ctrl *AdminController) ResetSpendLimits(c *gin.Context) {
+	response, err := ctrl.Service.ResetSpendLimits(c.Param("public_key"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ListManagedWallets handles GET /api/v1/admin/wallets?tenant_id=...&label=...&metadata[key]=value
+func (ctrl *AdminController) ListManagedWallets(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.Service.ListManagedWallets(c.Query("tenant_id"), c.QueryArray("label"), c.QueryMap("metadata")))
+}
+
+// GetWalletTransfers handles GET /api/v1/admin/wallets/:public_key/transfers
+func (ctrl *AdminController) GetWalletTransfers(c *gin.Context) {
+	response, err := ctrl.Service.WalletTransfers(c.Param("public_key"))
+	if err != nil {
+		if err.Error() == "invalid public key format" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetQueueStatus handles GET /api/v1/admin/queues
+func (ctrl *AdminController) GetQueueStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.Service.QueueStatus())
+}
+
+// GetOutboxStatus handles GET /api/v1/admin/outbox
+func (ctrl *AdminController) GetOutboxStatus(c *gin.Context) {
+	if ctrl.Service.Outbox == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "event outbox not enabled"})
+		return
+	}
+	c.JSON(http.StatusOK, ctrl.Service.Outbox.Status())
+}
+
+// AuditAdminRequests records every admin API request and its outcome in the audit log
+func (ctrl *AdminController) AuditAdminRequests(c *gin.Context) {
+	c.Next()
+	ctrl.Service.Audit.Record("admin", "admin."+strings.ToLower(c.Request.Method), c.Request.URL.Path, map[string]string{
+		"status":    strconv.Itoa(c.Writer.Status()),
+		"client_ip": c.ClientIP(),
+	})
+}
+
+// ExportAudit handles GET /api/v1/admin/audit/export?from=...&to=..., taking RFC 3339 timestamps or dates
+func (ctrl *AdminController) ExportAudit(c *gin.Context) {
+	from, err := parseAuditTime(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from: " + err.Error()})
+		return
+	}
+	to := time.Now().UTC()
+	if c.Query("to") != "" {
+		if to, err = parseAuditTime(c.Query("to")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to: " + err.Error()})
+			return
+		}
+	}
+
+	response, err := ctrl.Service.ExportAudit(from, to)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid range"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "audit signing key is not configured":
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func parseAuditTime(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,17 @@
+package controllers
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed adminui/index.html
+var adminUI []byte
+
+// AdminUI handles GET /admin, serving the embedded admin dashboard. The page itself is static; every
+// request it makes goes through the admin API with the operator's admin key.
+func AdminUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", adminUI)
+}
@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// AnchorController handles HTTP requests made to third-party anchors on behalf of wallets
+type AnchorController struct {
+	Service *services.AnchorService
+}
+
+// NewAnchorController creates a new AnchorController instance
+func NewAnchorController(service *services.AnchorService) *AnchorController {
+	return &AnchorController{Service: service}
+}
+
+// writeAnchorError maps an anchor service error to its HTTP status; failures of the anchor itself are 502s,
+// and anchors waiting on the customer's KYC information are 409s
+func writeAnchorError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "authenticated tenant required":
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "invalid"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "customer information"):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "failed to fetch"), strings.HasPrefix(err.Error(), "anchor "):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+	}
+}
+
+// Authenticate handles POST /api/v1/wallets/:public_key/anchors/auth
+func (ctrl *AnchorController) Authenticate(c *gin.Context) {
+	var req models.AnchorAuthRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	response, err := ctrl.Service.Authenticate(authenticatedTenantID(c), c.Param("public_key"), req.HomeDomain)
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Deposit handles POST /api/v1/wallets/:public_key/anchors/sep6/deposits
+func (ctrl *AnchorController) Deposit(c *gin.Context) {
+	var req models.AnchorDepositRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	response, err := ctrl.Service.Deposit(authenticatedTenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// Withdraw handles POST /api/v1/wallets/:public_key/anchors/sep6/withdrawals
+func (ctrl *AnchorController) Withdraw(c *gin.Context) {
+	var req models.AnchorWithdrawRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	response, err := ctrl.Service.Withdraw(authenticatedTenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// InteractiveDeposit handles POST /api/v1/wallets/:public_key/anchors/sep24/deposits
+func (ctrl *AnchorController) InteractiveDeposit(c *gin.Context) {
+	var req models.AnchorInteractiveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	response, err := ctrl.Service.InteractiveDeposit(authenticatedTenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// InteractiveWithdraw handles POST /api/v1/wallets/:public_key/anchors/sep24/withdrawals
+func (ctrl *AnchorController) InteractiveWithdraw(c *gin.Context) {
+	var req models.AnchorInteractiveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	response, err := ctrl.Service.InteractiveWithdraw(authenticatedTenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// SendQuote handles GET /api/v1/wallets/:public_key/anchors/sep31/quote
+func (ctrl *AnchorController) SendQuote(c *gin.Context) {
+	var req models.AnchorSendQuoteRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query: " + err.Error()})
+		return
+	}
+	response, err := ctrl.Service.SendQuote(authenticatedTenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Send handles POST /api/v1/wallets/:public_key/anchors/sep31/sends
+func (ctrl *AnchorController) Send(c *gin.Context) {
+	var req models.AnchorSendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	response, err := ctrl.Service.Send(authenticatedTenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// Prices handles GET /api/v1/wallets/:public_key/anchors/sep38/prices
+func (ctrl *AnchorController) Prices(c *gin.Context) {
+	var req models.AnchorPricesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query: " + err.Error()})
+		return
+	}
+	response, err := ctrl.Service.Prices(authenticatedTenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Price handles GET /api/v1/wallets/:public_key/anchors/sep38/price
+func (ctrl *AnchorController) Price(c *gin.Context) {
+	var req models.AnchorPriceRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query: " + err.Error()})
+		return
+	}
+	response, err := ctrl.Service.Price(authenticatedTenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateQuote handles POST /api/v1/wallets/:public_key/anchors/sep38/quotes
+func (ctrl *AnchorController) CreateQuote(c *gin.Context) {
+	var req models.AnchorQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	response, err := ctrl.Service.CreateQuote(authenticatedTenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListQuotes handles GET /api/v1/wallets/:public_key/anchors/sep38/quotes
+func (ctrl *AnchorController) ListQuotes(c *gin.Context) {
+	response, err := ctrl.Service.ListQuotes(authenticatedTenantID(c), c.Param("public_key"))
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetQuote handles GET /api/v1/wallets/:public_key/anchors/sep38/quotes/:id
+func (ctrl *AnchorController) GetQuote(c *gin.Context) {
+	response, err := ctrl.Service.GetQuote(authenticatedTenantID(c), c.Param("public_key"), c.Param("id"))
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ListTransactions handles GET /api/v1/wallets/:public_key/anchors/transactions
+func (ctrl *AnchorController) ListTransactions(c *gin.Context) {
+	response, err := ctrl.Service.ListTransactions(authenticatedTenantID(c), c.Param("public_key"))
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTransaction handles GET /api/v1/wallets/:public_key/anchors/transactions/:id
+func (ctrl *AnchorController) GetTransaction(c *gin.Context) {
+	response, err := ctrl.Service.GetTransaction(authenticatedTenantID(c), c.Param("public_key"), c.Param("id"))
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// AssetController handles asset-related HTTP requests
+type AssetController struct {
+	Service *services.AssetService
+}
+
+// NewAssetController creates a new AssetController instance
+func NewAssetController(service *services.AssetService) *AssetController {
+	return &AssetController{Service: service}
+}
+
+// GetAssetMetadata handles GET /api/v1/assets/:code/:issuer
+func (ctrl *AssetController) GetAssetMetadata(c *gin.Context) {
+	response, err := ctrl.Service.GetAssetMetadata(c.Param("code"), c.Param("issuer"))
+	if err != nil {
+		switch err.Error() {
+		case "invalid asset code", "invalid issuer public key":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "issuer has no home domain", "asset not listed in issuer stellar.toml":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// DepositController handles deposit HTTP requests
+type DepositController struct {
+	Service *services.DepositService
+}
+
+// NewDepositController creates a new DepositController instance
+func NewDepositController(service *services.DepositService) *DepositController {
+	return &DepositController{Service: service}
+}
+
+// ListDeposits handles GET /api/v1/deposits
+func (ctrl *DepositController) ListDeposits(c *gin.Context) {
+	response, err := ctrl.Service.ListDeposits(tenantID(c), c.Query("wallet"), c.Query("since"))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
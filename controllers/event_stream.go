@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+	"golang.org/x/net/websocket"
+)
+
+// EventStreamController streams wallet events to clients over WebSocket
+type EventStreamController struct {
+	Service *services.WalletService
+}
+
+// NewEventStreamController creates a new EventStreamController instance
+func NewEventStreamController(service *services.WalletService) *EventStreamController {
+	return &EventStreamController{Service: service}
+}
+
+// StreamEvents handles GET /api/v1/events/stream. The connection is upgraded to a WebSocket on which the
+// client sends StreamRequest messages to subscribe to and unsubscribe from its tenant's wallets, and
+// receives StreamMessage messages: the events of every subscribed wallet, multiplexed, and the outcome of
+// each request. Wallets given as wallet query parameters are subscribed on connect.
+func (ctrl *EventStreamController) StreamEvents(c *gin.Context) {
+	tenant := tenantID(c)
+	initial := c.QueryArray("wallet")
+	// API clients authenticate with the tenant header rather than a browser origin, so no origin is required
+	server := websocket.Server{Handler: func(conn *websocket.Conn) {
+		ctrl.serve(conn, tenant, initial)
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// serve relays events to one connection until either side closes it
+func (ctrl *EventStreamController) serve(conn *websocket.Conn, tenant string, initial []string) {
+	defer conn.Close()
+	sub := ctrl.Service.SubscribeEvents(tenant)
+	defer sub.Close()
+
+	var writeMu sync.Mutex
+	send := func(message models.StreamMessage) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return websocket.JSON.Send(conn, message)
+	}
+	handle := func(req models.StreamRequest) models.StreamMessage {
+		switch req.Action {
+		case models.StreamSubscribe:
+			watched, err := sub.Watch(req.Wallets)
+			if err != nil {
+				return models.StreamMessage{Type: models.StreamMessageError, Error: err.Error()}
+			}
+			return models.StreamMessage{Type: models.StreamMessageSubscribed, Wallets: watched}
+		case models.StreamUnsubscribe:
+			return models.StreamMessage{Type: models.StreamMessageUnsubscribed, Wallets: sub.Unwatch(req.Wallets)}
+		}
+		return models.StreamMessage{Type: models.StreamMessageError, Error: "invalid action: must be subscribe or unsubscribe"}
+	}
+
+	if len(initial) > 0 {
+		if send(handle(models.StreamRequest{Action: models.StreamSubscribe, Wallets: initial})) != nil {
+			return
+		}
+	}
+	go func() {
+		// Closing the subscription ends the event loop below once the client goes away
+		defer sub.Close()
+		for {
+			var req models.StreamRequest
+			err := websocket.JSON.Receive(conn, &req)
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				if send(models.StreamMessage{Type: models.StreamMessageError, Error: "invalid request: " + err.Error()}) != nil {
+					return
+				}
+				continue
+			}
+			if err != nil {
+				return
+			}
+			if send(handle(req)) != nil {
+				return
+			}
+		}
+	}()
+
+	for event := range sub.Events {
+		if send(models.StreamMessage{Type: models.StreamMessageEvent, Event: &event}) != nil {
+			return
+		}
+	}
+	if sub.Dropped() {
+		send(models.StreamMessage{Type: models.StreamMessageError, Error: "subscription dropped: the client fell too far behind"})
+	}
+}
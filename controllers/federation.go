@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// FederationController handles the federation server and wallet federation name HTTP requests
+type FederationController struct {
+	Service *services.FederationService
+}
+
+// NewFederationController creates a new FederationController instance
+func NewFederationController(service *services.FederationService) *FederationController {
+	return &FederationController{Service: service}
+}
+
+// writeFederationError maps a federation service error to its HTTP status
+func writeFederationError(c *gin.Context, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "federation name already taken"):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+	}
+}
+
+// Resolve handles GET /federation?q=...&type=name|id, the SEP-2 federation endpoint. It is public and, as
+// SEP-2 requires, readable from any origin; errors carry a detail field.
+func (ctrl *FederationController) Resolve(c *gin.Context) {
+	c.Header("Access-Control-Allow-Origin", "*")
+	record, err := ctrl.Service.Resolve(c.Query("q"), c.Query("type"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid"):
+			status = http.StatusBadRequest
+		case strings.HasSuffix(err.Error(), "not found"):
+			status = http.StatusNotFound
+		case strings.HasPrefix(err.Error(), "federation request type not supported"):
+			status = http.StatusNotImplemented
+		}
+		c.JSON(status, gin.H{"detail": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
+
+// SetName handles POST /api/v1/wallets/:public_key/federation-names
+func (ctrl *FederationController) SetName(c *gin.Context) {
+	var req models.FederationNameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	response, err := ctrl.Service.SetName(tenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		writeFederationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ListNames handles GET /api/v1/wallets/:public_key/federation-names
+func (ctrl *FederationController) ListNames(c *gin.Context) {
+	response, err := ctrl.Service.ListNames(tenantID(c), c.Param("public_key"))
+	if err != nil {
+		writeFederationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RemoveName handles DELETE /api/v1/wallets/:public_key/federation-names/:name
+func (ctrl *FederationController) RemoveName(c *gin.Context) {
+	if err := ctrl.Service.RemoveName(tenantID(c), c.Param("public_key"), c.Param("name")); err != nil {
+		writeFederationError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// InvoiceController handles invoice HTTP requests
+type InvoiceController struct {
+	Service *services.InvoiceService
+}
+
+// NewInvoiceController creates a new InvoiceController instance
+func NewInvoiceController(service *services.InvoiceService) *InvoiceController {
+	return &InvoiceController{Service: service}
+}
+
+// CreateInvoice handles POST /api/v1/invoices
+func (ctrl *InvoiceController) CreateInvoice(c *gin.Context) {
+	var req models.CreateInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.CreateInvoice(tenantID(c), req)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "asset not permitted"), err.Error() == "wallet is deactivated":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "failed"):
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		default:
+			if status, code, ok := amountErrorCode(err); ok {
+				c.JSON(status, gin.H{"error": err.Error(), "code": code})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListInvoices handles GET /api/v1/invoices
+func (ctrl *InvoiceController) ListInvoices(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.Service.ListInvoices(tenantID(c)))
+}
+
+// GetInvoice handles GET /api/v1/invoices/:id
+func (ctrl *InvoiceController) GetInvoice(c *gin.Context) {
+	response, err := ctrl.Service.GetInvoice(tenantID(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// JobController handles asynchronous wallet creation and transfer HTTP requests
+type JobController struct {
+	Service *services.JobService
+}
+
+// NewJobController creates a new JobController instance
+func NewJobController(service *services.JobService) *JobController {
+	return &JobController{Service: service}
+}
+
+// CreateWallet handles POST /api/v1/wallets/create/async
+func (ctrl *JobController) CreateWallet(c *gin.Context) {
+	var req models.CreateWalletRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+			return
+		}
+	}
+
+	response, err := ctrl.Service.EnqueueCreateWallet(tenantID(c), req)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, response)
+}
+
+// TransferFunds handles POST /api/v1/wallets/transfer/async
+func (ctrl *JobController) TransferFunds(c *gin.Context) {
+	var req models.TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	req.Device = models.DeviceInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		DeviceID:  c.GetHeader("X-Device-ID"),
+	}
+
+	response, err := ctrl.Service.EnqueueTransfer(tenantID(c), req)
+	if err != nil {
+		if err.Error() == "job queue is full" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		writeTransferError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, response)
+}
+
+// GetJob handles GET /api/v1/jobs/:id
+func (ctrl *JobController) GetJob(c *gin.Context) {
+	response, err := ctrl.Service.GetJob(tenantID(c), c.Param("id"))
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// KYCController handles HTTP requests for wallets' KYC information and its submission to anchors
+type KYCController struct {
+	Service *services.KYCService
+}
+
+// NewKYCController creates a new KYCController instance
+func NewKYCController(service *services.KYCService) *KYCController {
+	return &KYCController{Service: service}
+}
+
+// GetCustomer handles GET /api/v1/wallets/:public_key/kyc
+func (ctrl *KYCController) GetCustomer(c *gin.Context) {
+	response, err := ctrl.Service.GetCustomer(authenticatedTenantID(c), c.Param("public_key"))
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetFields handles PUT /api/v1/wallets/:public_key/kyc
+func (ctrl *KYCController) SetFields(c *gin.Context) {
+	var req models.KYCFieldsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	response, err := ctrl.Service.SetFields(authenticatedTenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UploadFile handles PUT /api/v1/wallets/:public_key/kyc/files/:field, a multipart upload of the document
+// in the "file" field
+func (ctrl *KYCController) UploadFile(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing KYC document file"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read KYC document: " + err.Error()})
+		return
+	}
+	defer file.Close()
+
+	response, err := ctrl.Service.UploadFile(authenticatedTenantID(c), c.Param("public_key"), c.Param("field"), header.Filename,
+		header.Header.Get("Content-Type"), file)
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteCustomer handles DELETE /api/v1/wallets/:public_key/kyc
+func (ctrl *KYCController) DeleteCustomer(c *gin.Context) {
+	if err := ctrl.Service.DeleteCustomer(authenticatedTenantID(c), c.Param("public_key")); err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Submit handles POST /api/v1/wallets/:public_key/kyc/anchors
+func (ctrl *KYCController) Submit(c *gin.Context) {
+	var req models.KYCSubmitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	response, err := ctrl.Service.Submit(authenticatedTenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RefreshStatus handles GET /api/v1/wallets/:public_key/kyc/anchors/:home_domain
+func (ctrl *KYCController) RefreshStatus(c *gin.Context) {
+	response, err := ctrl.Service.RefreshStatus(authenticatedTenantID(c), c.Param("public_key"), c.Param("home_domain"))
+	if err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteFromAnchor handles DELETE /api/v1/wallets/:public_key/kyc/anchors/:home_domain
+func (ctrl *KYCController) DeleteFromAnchor(c *gin.Context) {
+	if err := ctrl.Service.DeleteFromAnchor(authenticatedTenantID(c), c.Param("public_key"), c.Param("home_domain")); err != nil {
+		writeAnchorError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
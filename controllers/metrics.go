@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// MetricsController exposes service metrics in the Prometheus text format
+type MetricsController struct {
+	Service *services.WalletService
+}
+
+// NewMetricsController creates a new MetricsController instance
+func NewMetricsController(service *services.WalletService) *MetricsController {
+	return &MetricsController{Service: service}
+}
+
+// GetMetrics handles GET /metrics
+func (ctrl *MetricsController) GetMetrics(c *gin.Context) {
+	slo := ctrl.Service.SLO.Status()
+	breached := 0
+	if slo.Breached {
+		breached = 1
+	}
+
+	var b strings.Builder
+	writeMetric(&b, "stellar_ledger_inclusion_latency_seconds_avg", "gauge", "Average submission-to-ledger latency over the SLO window", slo.AverageLatency.Seconds())
+	writeMetric(&b, "stellar_ledger_inclusion_total", "counter", "Transactions included in a ledger since startup", float64(slo.TotalRecorded))
+	writeMetric(&b, "stellar_ledger_inclusion_slo_compliance", "gauge", "Fraction of transactions in the SLO window included within the latency threshold", slo.Compliance)
+	writeMetric(&b, "stellar_ledger_inclusion_slo_target", "gauge", "Target fraction of transactions included within the latency threshold", slo.Target)
+	writeMetric(&b, "stellar_ledger_inclusion_slo_breached", "gauge", "Whether the latency SLO is currently breached", float64(breached))
+
+	retries := ctrl.Service.SubmitRetryStats()
+	writeMetric(&b, "stellar_submission_retries_total", "counter", "Transaction resubmissions after transient Horizon failures since startup", float64(retries.Retries))
+	writeMetric(&b, "stellar_submission_resequenced_total", "counter", "Transactions rebuilt with a fresh sequence number after tx_bad_seq since startup", float64(retries.Resequenced))
+	writeMetric(&b, "stellar_submission_retries_exhausted_total", "counter", "Submissions that still failed transiently after the last retry since startup", float64(retries.Exhausted))
+
+	if monitor := ctrl.Service.MasterBalance; monitor != nil {
+		statuses := monitor.Statuses()
+		balances := make(map[string]float64, len(statuses))
+		thresholds := make(map[string]float64, len(statuses))
+		low := make(map[string]float64, len(statuses))
+		for _, status := range statuses {
+			balances[status.Asset], _ = strconv.ParseFloat(status.Balance, 64)
+			thresholds[status.Asset], _ = strconv.ParseFloat(status.Threshold, 64)
+			if status.Low {
+				low[status.Asset] = 1
+			} else {
+				low[status.Asset] = 0
+			}
+		}
+		writeAssetMetric(&b, "stellar_master_balance", "Master account balance by asset at the last check", balances)
+		writeAssetMetric(&b, "stellar_master_balance_warning_threshold", "Master account balance below which operators are alerted", thresholds)
+		writeAssetMetric(&b, "stellar_master_balance_low", "Whether the master account balance is below its warning threshold", low)
+	}
+
+	if outbox := ctrl.Service.Outbox; outbox != nil {
+		status := outbox.Status()
+		backlogged := 0
+		if status.Backlogged {
+			backlogged = 1
+		}
+		writeMetric(&b, "stellar_outbox_pending", "gauge", "Events staged in the outbox and not yet relayed", float64(status.Pending))
+		writeMetric(&b, "stellar_outbox_failing", "gauge", "Pending outbox events whose last publication failed", float64(status.Failing))
+		writeMetric(&b, "stellar_outbox_oldest_age_seconds", "gauge", "Age of the oldest pending outbox event", status.OldestAgeSeconds)
+		writeMetric(&b, "stellar_outbox_relayed_total", "counter", "Outbox events relayed since startup", float64(status.Relayed))
+		writeMetric(&b, "stellar_outbox_backlogged", "gauge", "Whether the oldest pending outbox event is older than the backlog limit", float64(backlogged))
+	}
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4", []byte(b.String()))
+}
+
+func writeMetric(b *strings.Builder, name, kind, help string, value float64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", name, help, name, kind, name, value)
+}
+
+// writeAssetMetric writes a gauge with one sample per asset, ordered by asset
+func writeAssetMetric(b *strings.Builder, name, help string, values map[string]float64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	assets := make([]string, 0, len(values))
+	for asset := range values {
+		assets = append(assets, asset)
+	}
+	sort.Strings(assets)
+	for _, asset := range assets {
+		fmt.Fprintf(b, "%s{asset=%q} %g\n", name, asset, values[asset])
+	}
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// NotificationController handles notification preference HTTP requests
+type NotificationController struct {
+	Service *services.NotificationService
+}
+
+// NewNotificationController creates a new NotificationController instance
+func NewNotificationController(service *services.NotificationService) *NotificationController {
+	return &NotificationController{Service: service}
+}
+
+// GetPreferences handles GET /api/v1/wallets/:public_key/notification-preferences
+func (ctrl *NotificationController) GetPreferences(c *gin.Context) {
+	response, err := ctrl.Service.GetPreferences(tenantID(c), c.Param("public_key"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdatePreferences handles PUT /api/v1/wallets/:public_key/notification-preferences
+func (ctrl *NotificationController) UpdatePreferences(c *gin.Context) {
+	var req models.NotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.UpdatePreferences(tenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		if err.Error() == "invalid public key format" ||
+			strings.HasPrefix(err.Error(), "unknown event type") ||
+			strings.HasPrefix(err.Error(), "notification channel not available") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
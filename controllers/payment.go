@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// PaymentController handles path payment HTTP requests
+type PaymentController struct {
+	Service *services.WalletService
+}
+
+// NewPaymentController creates a new PaymentController instance
+func NewPaymentController(service *services.WalletService) *PaymentController {
+	return &PaymentController{Service: service}
+}
+
+// PathPaymentStrictSend handles POST /api/v1/payments/path/strict-send
+func (ctrl *PaymentController) PathPaymentStrictSend(c *gin.Context) {
+	var req models.StrictSendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	req.Device = models.DeviceInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		DeviceID:  c.GetHeader("X-Device-ID"),
+	}
+
+	response, err := ctrl.Service.PathPaymentStrictSend(req)
+	if err != nil {
+		pathPaymentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// PathPaymentStrictReceive handles POST /api/v1/payments/path/strict-receive
+func (ctrl *PaymentController) PathPaymentStrictReceive(c *gin.Context) {
+	var req models.StrictReceiveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	req.Device = models.DeviceInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		DeviceID:  c.GetHeader("X-Device-ID"),
+	}
+
+	response, err := ctrl.Service.PathPaymentStrictReceive(req)
+	if err != nil {
+		pathPaymentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetQuote handles GET /api/v1/quotes
+func (ctrl *PaymentController) GetQuote(c *gin.Context) {
+	response, err := ctrl.Service.Quote(models.QuoteRequest{
+		From:               c.Query("from"),
+		To:                 c.Query("to"),
+		Amount:             c.Query("amount"),
+		Mode:               c.Query("mode"),
+		MaxSlippagePercent: c.Query("max_slippage_percent"),
+		IncludePools:       c.Query("include_pools") == "true",
+	})
+	if err != nil {
+		pathPaymentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// pathPaymentError writes the HTTP response for a failed path payment
+func pathPaymentError(c *gin.Context, err error) {
+	if strings.HasPrefix(err.Error(), "asset not permitted") || err.Error() == "sender wallet is deactivated" ||
+		strings.HasPrefix(err.Error(), "wallet is frozen") || strings.HasPrefix(err.Error(), "payment flagged") {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if status, code, ok := amountErrorCode(err); ok {
+		c.JSON(status, gin.H{"error": err.Error(), "code": code})
+		return
+	}
+	var destErr *services.DestinationError
+	if errors.As(err, &destErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": destErr.Code})
+		return
+	}
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "recipient requires a memo"):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": "memo_required"})
+	case err.Error() == "no payment path found", strings.HasPrefix(err.Error(), "insufficient balance"):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "spend limit exceeded"):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": "spend_limit_exceeded"})
+	default:
+		c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+	}
+}
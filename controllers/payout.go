@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// PayoutController handles bulk payout HTTP requests
+type PayoutController struct {
+	Service *services.PayoutService
+}
+
+// NewPayoutController creates a new PayoutController instance
+func NewPayoutController(service *services.PayoutService) *PayoutController {
+	return &PayoutController{Service: service}
+}
+
+// CreatePayoutBatch handles POST /api/v1/payouts, a multipart upload of a destination,amount[,asset] CSV
+// in the "file" field
+func (ctrl *PayoutController) CreatePayoutBatch(c *gin.Context) {
+	var req models.CreatePayoutRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing payout CSV file"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read payout CSV: " + err.Error()})
+		return
+	}
+	defer file.Close()
+
+	response, err := ctrl.Service.CreatePayoutBatch(tenantID(c), authenticatedTenantID(c) != "", req, file)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "sender wallet is deactivated", strings.HasPrefix(err.Error(), "wallet is frozen"):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusAccepted, response)
+}
+
+// GetPayoutBatch handles GET /api/v1/payouts/:batch_id
+func (ctrl *PayoutController) GetPayoutBatch(c *gin.Context) {
+	response, err := ctrl.Service.GetPayoutBatch(tenantID(c), c.Param("batch_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// PoolController handles pooled sub-account HTTP requests
+type PoolController struct {
+	Service *services.PoolService
+}
+
+// NewPoolController creates a new PoolController instance
+func NewPoolController(service *services.PoolService) *PoolController {
+	return &PoolController{Service: service}
+}
+
+// CreateSubAccount handles POST /api/v1/pool/sub-accounts
+func (ctrl *PoolController) CreateSubAccount(c *gin.Context) {
+	var req models.CreateSubAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.CreateSubAccount(tenantID(c), req)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "wallet is deactivated":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "sub-account already exists"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListSubAccounts handles GET /api/v1/pool/sub-accounts
+func (ctrl *PoolController) ListSubAccounts(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.Service.ListSubAccounts(tenantID(c), c.Query("pool_wallet")))
+}
+
+// GetSubAccount handles GET /api/v1/pool/sub-accounts/:address
+func (ctrl *PoolController) GetSubAccount(c *gin.Context) {
+	response, err := ctrl.Service.GetSubAccount(tenantID(c), c.Param("address"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Transfer handles POST /api/v1/pool/sub-accounts/:address/transfer
+func (ctrl *PoolController) Transfer(c *gin.Context) {
+	var req models.SubAccountTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	req.Device = models.DeviceInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		DeviceID:  c.GetHeader("X-Device-ID"),
+	}
+
+	response, err := ctrl.Service.Transfer(authenticatedTenantID(c), c.Param("address"), req)
+	if err != nil {
+		if status, code, ok := amountErrorCode(err); ok {
+			c.JSON(status, gin.H{"error": err.Error(), "code": code})
+			return
+		}
+		var destErr *services.DestinationError
+		if errors.As(err, &destErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": destErr.Code})
+			return
+		}
+		switch {
+		case err.Error() == "pool transfers require an authenticated tenant":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "recipient requires a memo"):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": "memo_required"})
+		case err.Error() == "sub-account not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "asset not permitted"), err.Error() == "wallet is deactivated",
+			strings.HasPrefix(err.Error(), "wallet is frozen"), strings.HasPrefix(err.Error(), "payment flagged"):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "insufficient balance"):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "insufficient sub-account balance"):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": "insufficient_balance"})
+		case strings.HasPrefix(err.Error(), "spend limit exceeded"):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": "spend_limit_exceeded"})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// PushController handles push notification device HTTP requests
+type PushController struct {
+	Service *services.PushService
+}
+
+// NewPushController creates a new PushController instance
+func NewPushController(service *services.PushService) *PushController {
+	return &PushController{Service: service}
+}
+
+// writePushError maps a push service error to its HTTP status
+func writePushError(c *gin.Context, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case strings.HasSuffix(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "push device limit reached"):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "push notifications are not configured"):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+	}
+}
+
+// RegisterDevice handles POST /api/v1/wallets/:public_key/devices
+func (ctrl *PushController) RegisterDevice(c *gin.Context) {
+	var req models.PushDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	response, err := ctrl.Service.RegisterDevice(tenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		writePushError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListDevices handles GET /api/v1/wallets/:public_key/devices
+func (ctrl *PushController) ListDevices(c *gin.Context) {
+	response, err := ctrl.Service.ListDevices(tenantID(c), c.Param("public_key"))
+	if err != nil {
+		writePushError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UnregisterDevice handles DELETE /api/v1/wallets/:public_key/devices/:id
+func (ctrl *PushController) UnregisterDevice(c *gin.Context) {
+	if err := ctrl.Service.UnregisterDevice(tenantID(c), c.Param("public_key"), c.Param("id")); err != nil {
+		writePushError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
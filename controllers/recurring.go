@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// RecurringController handles recurring payment HTTP requests
+type RecurringController struct {
+	Service *services.RecurringService
+}
+
+// NewRecurringController creates a new RecurringController instance
+func NewRecurringController(service *services.RecurringService) *RecurringController {
+	return &RecurringController{Service: service}
+}
+
+// CreatePlan handles POST /api/v1/recurring-payments
+func (ctrl *RecurringController) CreatePlan(c *gin.Context) {
+	var req models.CreateRecurringPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.CreatePlan(tenantID(c), authenticatedTenantID(c) != "", req)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "asset not permitted") {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if status, code, ok := amountErrorCode(err); ok {
+			c.JSON(status, gin.H{"error": err.Error(), "code": code})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListPlans handles GET /api/v1/recurring-payments
+func (ctrl *RecurringController) ListPlans(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.Service.ListPlans(tenantID(c)))
+}
+
+// GetPlan handles GET /api/v1/recurring-payments/:id
+func (ctrl *RecurringController) GetPlan(c *gin.Context) {
+	response, err := ctrl.Service.GetPlan(tenantID(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CancelPlan handles POST /api/v1/recurring-payments/:id/cancel
+func (ctrl *RecurringController) CancelPlan(c *gin.Context) {
+	response, err := ctrl.Service.CancelPlan(tenantID(c), c.Param("id"))
+	if err != nil {
+		if err.Error() == "recurring plan not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// RefundController handles refund-related HTTP requests
+type RefundController struct {
+	Service *services.RefundService
+}
+
+// NewRefundController creates a new RefundController instance
+func NewRefundController(service *services.RefundService) *RefundController {
+	return &RefundController{Service: service}
+}
+
+// RequestRefund handles POST /api/v1/refunds
+func (ctrl *RefundController) RequestRefund(c *gin.Context) {
+	var req models.CreateRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.RequestRefund(tenantID(c), authenticatedTenantID(c) != "", req)
+	if err != nil {
+		refundError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// RefundPayment handles POST /api/v1/payments/:hash/refund
+func (ctrl *RefundController) RefundPayment(c *gin.Context) {
+	var req models.RefundPaymentRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+			return
+		}
+	}
+
+	response, err := ctrl.Service.RefundPayment(tenantID(c), authenticatedTenantID(c) != "", c.Param("hash"), req)
+	if err != nil {
+		refundError(c, err)
+		return
+	}
+	if response.State == models.RefundRequested {
+		c.JSON(http.StatusAccepted, response)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetRefund handles GET /api/v1/refunds/:id
+func (ctrl *RefundController) GetRefund(c *gin.Context) {
+	response, err := ctrl.Service.GetRefund(c.Param("id"))
+	if err != nil {
+		refundError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ApproveRefund handles POST /api/v1/admin/refunds/:id/approve
+func (ctrl *RefundController) ApproveRefund(c *gin.Context) {
+	response, err := ctrl.Service.ApproveRefund(c.Param("id"))
+	if err != nil {
+		refundError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RejectRefund handles POST /api/v1/admin/refunds/:id/reject
+func (ctrl *RefundController) RejectRefund(c *gin.Context) {
+	response, err := ctrl.Service.RejectRefund(c.Param("id"))
+	if err != nil {
+		refundError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ExecuteRefund handles POST /api/v1/refunds/:id/execute
+func (ctrl *RefundController) ExecuteRefund(c *gin.Context) {
+	var req models.ExecuteRefundRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+			return
+		}
+	}
+
+	response, err := ctrl.Service.ExecuteRefund(tenantID(c), authenticatedTenantID(c) != "", c.Param("id"), req)
+	if err != nil {
+		refundError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func refundError(c *gin.Context, err error) {
+	var destErr *services.DestinationError
+	if errors.As(err, &destErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": destErr.Code})
+		return
+	}
+	switch {
+	case err.Error() == "refund not found" || err.Error() == "original payment not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "invalid amount") || strings.HasPrefix(err.Error(), "invalid wallet secret key"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case strings.HasSuffix(err.Error(), "not permitted by policy") || err.Error() == "refund window has expired" ||
+		err.Error() == "refund exceeds refundable amount":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "invalid refund state transition"):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "payment flagged"):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "recipient requires a memo"):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": "memo_required"})
+	case strings.HasPrefix(err.Error(), "spend limit exceeded"):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": "spend_limit_exceeded"})
+	case strings.HasPrefix(err.Error(), "insufficient balance"):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+	}
+}
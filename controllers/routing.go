@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// RoutingController handles inbound payment routing HTTP requests
+type RoutingController struct {
+	Service *services.RoutingService
+}
+
+// NewRoutingController creates a new RoutingController instance
+func NewRoutingController(service *services.RoutingService) *RoutingController {
+	return &RoutingController{Service: service}
+}
+
+// GetRules handles GET /api/v1/routing-rules
+func (ctrl *RoutingController) GetRules(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.Service.GetRules(tenantID(c)))
+}
+
+// SetRules handles PUT /api/v1/routing-rules
+func (ctrl *RoutingController) SetRules(c *gin.Context) {
+	var req models.RoutingRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.SetRules(authenticatedTenantID(c), req)
+	if err != nil {
+		if err.Error() == "routing rules require an authenticated tenant" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ListExecutions handles GET /api/v1/routing-rules/executions
+func (ctrl *RoutingController) ListExecutions(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.Service.ListExecutions(tenantID(c)))
+}
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// SandboxController handles developer sandbox HTTP requests
+type SandboxController struct {
+	Service *services.SandboxService
+}
+
+// NewSandboxController creates a new SandboxController instance
+func NewSandboxController(service *services.SandboxService) *SandboxController {
+	return &SandboxController{Service: service}
+}
+
+// GetSandbox handles GET /api/v1/sandbox
+func (ctrl *SandboxController) GetSandbox(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.Service.GetSandbox())
+}
+
+// ResetSandbox handles POST /api/v1/admin/sandbox/reset
+func (ctrl *SandboxController) ResetSandbox(c *gin.Context) {
+	if err := ctrl.Service.Reset(); err != nil {
+		c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		return
+	}
+	c.JSON(http.StatusOK, ctrl.Service.GetSandbox())
+}
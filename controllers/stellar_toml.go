@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// StellarTomlController serves the operator's stellar.toml
+type StellarTomlController struct {
+	Service *services.StellarTomlService
+}
+
+// NewStellarTomlController creates a new StellarTomlController instance
+func NewStellarTomlController(service *services.StellarTomlService) *StellarTomlController {
+	return &StellarTomlController{Service: service}
+}
+
+// GetStellarToml handles GET /.well-known/stellar.toml. SEP-1 requires the file to be readable from any
+// origin; clients revalidate with If-None-Match once their cached copy expires.
+func (ctrl *StellarTomlController) GetStellarToml(c *gin.Context) {
+	body, etag := ctrl.Service.Render()
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(ctrl.Service.MaxAge().Seconds())))
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", body)
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// tenantAuthenticatedKey marks a request whose tenant presented its API key
+const tenantAuthenticatedKey = "tenant_authenticated"
+
+// tenantID returns the tenant a request acts on behalf of, taken from the X-Tenant-ID header
+func tenantID(c *gin.Context) string {
+	if id := c.GetHeader("X-Tenant-ID"); id != "" {
+		return id
+	}
+	return services.DefaultTenantID
+}
+
+// authenticatedTenantID returns the request's tenant if it presented the tenant's API key, or "" otherwise.
+// Only an authenticated tenant may have the service sign with the custodied keys of its wallets.
+func authenticatedTenantID(c *gin.Context) string {
+	if c.GetBool(tenantAuthenticatedKey) {
+		return tenantID(c)
+	}
+	return ""
+}
+
+// AuthenticateTenant binds a request's tenant to a credential: when tenant API keys are configured, every
+// request must present its tenant's key as "Authorization: Bearer <key>". With no keys configured requests
+// pass through unauthenticated.
+func AuthenticateTenant(keys map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(keys) == 0 {
+			c.Next()
+			return
+		}
+		key, ok := keys[tenantID(c)]
+		token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || !found || subtle.ConstantTimeCompare([]byte(token), []byte(key)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid tenant credentials"})
+			return
+		}
+		c.Set(tenantAuthenticatedKey, true)
+		c.Next()
+	}
+}
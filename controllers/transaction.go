@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+// TransactionController handles HTTP requests of non-custodial clients that sign transactions themselves
+type TransactionController struct {
+	Service *services.WalletService
+}
+
+// NewTransactionController creates a new TransactionController instance
+func NewTransactionController(service *services.WalletService) *TransactionController {
+	return &TransactionController{Service: service}
+}
+
+// BuildTransaction handles POST /api/v1/transactions/build
+func (ctrl *TransactionController) BuildTransaction(c *gin.Context) {
+	var req models.BuildTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.BuildUnsignedTransaction(req)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "asset not permitted"):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case err.Error() == "source account not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SubmitTransaction handles POST /api/v1/transactions/submit
+func (ctrl *TransactionController) SubmitTransaction(c *gin.Context) {
+	var req models.SubmitTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.SubmitSignedTransaction(req)
+	if err != nil {
+		var txErr *services.TransactionError
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "source account not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "wallet is frozen"):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.As(err, &txErr):
+			// The network rejected the client's transaction; the result codes say why
+			c.JSON(http.StatusUnprocessableEntity, transactionErrorBody(err))
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RecoverTransaction handles POST /api/v1/admin/transactions/:hash/recover
+func (ctrl *TransactionController) RecoverTransaction(c *gin.Context) {
+	response, err := ctrl.Service.RecoverTransaction(c.Param("hash"))
+	if err != nil {
+		var txErr *services.TransactionError
+		switch {
+		case err.Error() == "invalid transaction hash":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "transaction not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case err.Error() == "transaction is already confirmed", strings.HasPrefix(err.Error(), "transaction was rejected"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "wallet is frozen"):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.As(err, &txErr):
+			c.JSON(http.StatusUnprocessableEntity, transactionErrorBody(err))
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTransactionStatus handles GET /api/v1/transactions/:hash
+func (ctrl *TransactionController) GetTransactionStatus(c *gin.Context) {
+	response, err := ctrl.Service.GetTransactionStatus(c.Param("hash"))
+	if err != nil {
+		switch err.Error() {
+		case "invalid transaction hash":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "transaction not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -1,7 +1,10 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/saif727/stellar-wallet-backend/models"
@@ -20,23 +23,278 @@ func NewWalletController(service *services.WalletService) *WalletController {
 
 // CreateWallet handles POST /api/v1/wallets/create
 func (ctrl *WalletController) CreateWallet(c *gin.Context) {
-	response, err := ctrl.Service.CreateWallet()
+	var req models.CreateWalletRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+			return
+		}
+	}
+
+	response, err := ctrl.Service.CreateWallet(tenantID(c), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if strings.HasPrefix(err.Error(), "trustline limit for") || strings.HasPrefix(err.Error(), "invalid trustline limit") ||
+			strings.HasPrefix(err.Error(), "invalid friendbot") || strings.HasPrefix(err.Error(), "invalid profile") ||
+			strings.HasPrefix(err.Error(), "invalid home_domain") || strings.HasPrefix(err.Error(), "invalid labels") ||
+			strings.HasPrefix(err.Error(), "invalid metadata") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
 		return
 	}
 	c.JSON(http.StatusOK, response)
 }
 
-// GetWalletDetails handles GET /api/v1/wallets/:public_key
+// EstimateWalletCreation handles GET /api/v1/wallets/create/estimate
+func (ctrl *WalletController) EstimateWalletCreation(c *gin.Context) {
+	response, err := ctrl.Service.EstimateWalletCreation(c.Query("profile"))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid profile") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetWalletChanges handles GET /api/v1/wallets/changes
+func (ctrl *WalletController) GetWalletChanges(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit: must be between 1 and 1000"})
+		return
+	}
+
+	response, err := ctrl.Service.WalletChanges(tenantID(c), c.Query("since"), limit)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "cursor has expired"):
+			c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetWalletDetails handles GET /api/v1/wallets/:public_key; include_claimable_balances=true lists the
+// claimable balances awaiting the wallet
 func (ctrl *WalletController) GetWalletDetails(c *gin.Context) {
 	publicKey := c.Param("public_key")
-	response, err := ctrl.Service.GetWalletDetails(publicKey)
+	response, err := ctrl.Service.WalletDetails(publicKey, models.WalletDetailsOptions{
+		IncludeClaimableBalances: c.Query("include_claimable_balances") == "true",
+	})
 	if err != nil {
 		if err.Error() == "invalid public key format" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetWalletPayments handles GET /api/v1/wallets/:public_key/payments
+func (ctrl *WalletController) GetWalletPayments(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit: must be between 1 and 200"})
+		return
+	}
+
+	response, err := ctrl.Service.WalletPayments(c.Param("public_key"), models.PaymentHistoryFilter{
+		Asset:        c.Query("asset"),
+		Direction:    c.Query("direction"),
+		Counterparty: c.Query("counterparty"),
+		From:         c.Query("from"),
+		To:           c.Query("to"),
+		MinAmount:    c.Query("min_amount"),
+		MaxAmount:    c.Query("max_amount"),
+		Cursor:       c.Query("cursor"),
+		Limit:        limit,
+	})
+	if err != nil {
+		writeActivityError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetWalletOperations handles GET /api/v1/wallets/:public_key/operations
+func (ctrl *WalletController) GetWalletOperations(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit: must be between 1 and 200"})
+		return
+	}
+
+	response, err := ctrl.Service.WalletOperations(c.Param("public_key"), c.QueryArray("type"), c.Query("cursor"), limit)
+	if err != nil {
+		writeActivityError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetWalletEffects handles GET /api/v1/wallets/:public_key/effects
+func (ctrl *WalletController) GetWalletEffects(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit: must be between 1 and 200"})
+		return
+	}
+
+	response, err := ctrl.Service.WalletEffects(c.Param("public_key"), c.QueryArray("type"), c.Query("cursor"), limit)
+	if err != nil {
+		writeActivityError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetPortfolio handles GET /api/v1/wallets/:public_key/portfolio?currency=USD
+func (ctrl *WalletController) GetPortfolio(c *gin.Context) {
+	response, err := ctrl.Service.WalletPortfolio(c.Param("public_key"), c.DefaultQuery("currency", "USD"))
+	if err != nil {
+		if err.Error() == "price source is not configured" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		writeActivityError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SearchPayments handles GET /api/v1/search/payments?memo=...|external_id=..., optionally narrowed by
+// wallet and since
+func (ctrl *WalletController) SearchPayments(c *gin.Context) {
+	response, err := ctrl.Service.SearchPayments(tenantID(c), models.PaymentSearchQuery{
+		Memo:       c.Query("memo"),
+		ExternalID: c.Query("external_id"),
+		Wallet:     c.Query("wallet"),
+		Since:      c.Query("since"),
+	})
+	if err != nil {
+		writeActivityError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// writeActivityError maps a payment history, payment search, balance history, statement, operations or effects
+// error to its HTTP response
+func writeActivityError(c *gin.Context, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err.Error() == "wallet not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+	}
+}
+
+// GetBalanceHistory handles GET /api/v1/wallets/:public_key/balance-history
+func (ctrl *WalletController) GetBalanceHistory(c *gin.Context) {
+	response, err := ctrl.Service.BalanceHistory(c.Param("public_key"), c.Query("from"), c.Query("to"), c.Query("asset"))
+	if err != nil {
+		writeActivityError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetStatement handles GET /api/v1/wallets/:public_key/statements?month=YYYY-MM, answering with JSON or,
+// for format=csv or format=pdf, a downloadable document
+func (ctrl *WalletController) GetStatement(c *gin.Context) {
+	format := c.DefaultQuery("format", models.StatementJSON)
+	if format != models.StatementJSON && format != models.StatementCSV && format != models.StatementPDF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format: must be json, csv or pdf"})
+		return
+	}
+
+	statement, err := ctrl.Service.WalletStatement(c.Param("public_key"), c.Query("month"))
+	if err != nil {
+		writeActivityError(c, err)
+		return
+	}
+	filename := "statement-" + statement.PublicKey + "-" + statement.Month + "." + format
+	switch format {
+	case models.StatementCSV:
+		body, err := services.StatementCSV(statement)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", body)
+	case models.StatementPDF:
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+		c.Data(http.StatusOK, "application/pdf", services.StatementPDF(statement))
+	default:
+		c.JSON(http.StatusOK, statement)
+	}
+}
+
+// GetSponsorship handles GET /api/v1/wallets/:public_key/sponsorship
+func (ctrl *WalletController) GetSponsorship(c *gin.Context) {
+	response, err := ctrl.Service.GetSponsorship(c.Param("public_key"))
+	if err != nil {
+		switch err.Error() {
+		case "invalid public key format":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "wallet not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetWalletReserve handles GET /api/v1/wallets/:public_key/reserve
+func (ctrl *WalletController) GetWalletReserve(c *gin.Context) {
+	response, err := ctrl.Service.GetWalletReserve(c.Param("public_key"))
+	if err != nil {
+		switch err.Error() {
+		case "invalid public key format":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "wallet not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetWalletMetadata handles PUT /api/v1/wallets/:public_key/metadata
+func (ctrl *WalletController) SetWalletMetadata(c *gin.Context) {
+	var req models.WalletMetadataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.SetWalletMetadata(c.Param("public_key"), req)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "wallet not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
 		}
 		return
 	}
@@ -51,14 +309,464 @@ func (ctrl *WalletController) TransferFunds(c *gin.Context) {
 		return
 	}
 
+	req.Device = models.DeviceInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		DeviceID:  c.GetHeader("X-Device-ID"),
+	}
+
 	response, err := ctrl.Service.TransferFunds(req)
 	if err != nil {
-		if err.Error() == "invalid sender secret key" || err.Error() == "invalid recipient public key" || err.Error() == "invalid amount: must be a positive number" {
+		writeTransferError(c, err)
+		return
+	}
+	if response.Status == models.TransferPendingReview {
+		c.JSON(http.StatusAccepted, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SimulateTransfer handles POST /api/v1/wallets/transfer/simulate
+func (ctrl *WalletController) SimulateTransfer(c *gin.Context) {
+	var req models.TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.SimulateTransfer(req)
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SplitTransfer handles POST /api/v1/wallets/transfer/split
+func (ctrl *WalletController) SplitTransfer(c *gin.Context) {
+	var req models.SplitTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	req.Device = models.DeviceInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		DeviceID:  c.GetHeader("X-Device-ID"),
+	}
+
+	response, err := ctrl.Service.SplitTransfer(req)
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// writeTransferError maps errors shared by the transfer endpoints to their HTTP responses
+func writeTransferError(c *gin.Context, err error) {
+	if status, code, ok := amountErrorCode(err); ok {
+		c.JSON(status, gin.H{"error": err.Error(), "code": code})
+		return
+	}
+	var destErr *services.DestinationError
+	if errors.As(err, &destErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":                        err.Error(),
+			"code":                         destErr.Code,
+			"claimable_fallback_available": destErr.ClaimableFallbackAvailable,
+		})
+		return
+	}
+	msg := err.Error()
+	switch {
+	case strings.HasPrefix(msg, "asset not permitted"), msg == "sender wallet is deactivated", strings.HasPrefix(msg, "wallet is frozen"),
+		strings.HasPrefix(msg, "split transfer flagged"):
+		c.JSON(http.StatusForbidden, gin.H{"error": msg})
+	case strings.HasPrefix(msg, "invalid memo"), strings.HasPrefix(msg, "invalid split"), strings.HasPrefix(msg, "invalid external_id"),
+		strings.HasPrefix(msg, "invalid callback_url"), strings.HasPrefix(msg, "invalid timeout_seconds"),
+		strings.HasPrefix(msg, "invalid max_ledger"), strings.HasPrefix(msg, "invalid extra_signers"),
+		msg == "invalid sender secret key", msg == "invalid recipient public key",
+		strings.HasPrefix(msg, "invalid amount"), strings.HasPrefix(msg, "invalid source asset"),
+		strings.HasPrefix(msg, "invalid destination asset"), msg == "invalid max slippage: must be between 0 and 100",
+		msg == "invalid claim predicate: windows must not be negative":
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+	case strings.HasPrefix(msg, "external_id "):
+		c.JSON(http.StatusConflict, gin.H{"error": msg})
+	case strings.HasPrefix(msg, "recipient requires a memo"):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": msg, "code": "memo_required"})
+	case strings.HasPrefix(msg, "spend limit exceeded"):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": msg, "code": "spend_limit_exceeded"})
+	case strings.HasPrefix(msg, "insufficient XLM above reserve"):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": msg, "code": "below_reserve"})
+	case strings.HasPrefix(msg, "fee sponsorship budget exhausted"):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": msg, "code": "fee_budget_exhausted"})
+	case msg == "no payment path found", strings.HasPrefix(msg, "insufficient balance"):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": msg})
+	case msg == "sender account not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": msg})
+	default:
+		c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+	}
+}
+
+// CloseWallet handles POST /api/v1/wallets/:public_key/close
+func (ctrl *WalletController) CloseWallet(c *gin.Context) {
+	var req models.CloseWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.CloseWallet(authenticatedTenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		switch {
+		case err.Error() == "invalid public key format" || err.Error() == "invalid destination public key" ||
+			strings.HasPrefix(err.Error(), "invalid wallet secret key"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "wallet holds") || strings.HasPrefix(err.Error(), "wallet has") ||
+			strings.HasPrefix(err.Error(), "wallet is deactivated") || strings.HasPrefix(err.Error(), "wallet is frozen"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// MergeWallet handles POST /api/v1/wallets/:public_key/merge
+func (ctrl *WalletController) MergeWallet(c *gin.Context) {
+	var req models.MergeWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.MergeWallet(authenticatedTenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "wallet holds") || strings.HasPrefix(err.Error(), "wallet has") ||
+			strings.HasPrefix(err.Error(), "wallet is deactivated") || strings.HasPrefix(err.Error(), "wallet is frozen"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// FundWallet handles POST /api/v1/wallets/:public_key/fund
+func (ctrl *WalletController) FundWallet(c *gin.Context) {
+	response, err := ctrl.Service.FundWallet(c.Param("public_key"))
+	if err != nil {
+		switch {
+		case err.Error() == "invalid public key format", strings.HasPrefix(err.Error(), "friendbot funding is only available"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "wallet already exists"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ActivateWallet handles POST /api/v1/wallets/activate
+func (ctrl *WalletController) ActivateWallet(c *gin.Context) {
+	var req models.ActivateWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.ActivateWallet(tenantID(c), req)
+	if err != nil {
+		switch {
+		case err.Error() == "invalid public key format", strings.HasPrefix(err.Error(), "invalid trustline_limit"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "wallet already exists":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetAccountOptions handles POST /api/v1/wallets/:public_key/options
+func (ctrl *WalletController) SetAccountOptions(c *gin.Context) {
+	var req models.AccountOptionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.SetAccountOptions(authenticatedTenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "account would be locked"), err.Error() == "wallet flags are immutable",
+			strings.HasPrefix(err.Error(), "wallet is deactivated") || strings.HasPrefix(err.Error(), "wallet is frozen"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetAccountThresholds handles GET /api/v1/wallets/:public_key/thresholds
+func (ctrl *WalletController) GetAccountThresholds(c *gin.Context) {
+	response, err := ctrl.Service.GetAccountThresholds(c.Param("public_key"))
+	if err != nil {
+		switch err.Error() {
+		case "invalid public key format":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "wallet not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetAccountThresholds handles PUT /api/v1/wallets/:public_key/thresholds
+func (ctrl *WalletController) SetAccountThresholds(c *gin.Context) {
+	var req models.AccountThresholdsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.SetAccountThresholds(authenticatedTenantID(c), c.Param("public_key"), req)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "account would be locked"),
+			strings.HasPrefix(err.Error(), "wallet is deactivated") || strings.HasPrefix(err.Error(), "wallet is frozen"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetDataEntry handles PUT /api/v1/wallets/:public_key/data/:name
+func (ctrl *WalletController) SetDataEntry(c *gin.Context) {
+	var req models.DataEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.SetDataEntry(authenticatedTenantID(c), c.Param("public_key"), c.Param("name"), req)
+	if err != nil {
+		writeDataEntryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteDataEntry handles DELETE /api/v1/wallets/:public_key/data/:name
+func (ctrl *WalletController) DeleteDataEntry(c *gin.Context) {
+	var req models.DeleteDataEntryRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+			return
+		}
+	}
+
+	response, err := ctrl.Service.DeleteDataEntry(authenticatedTenantID(c), c.Param("public_key"), c.Param("name"), req)
+	if err != nil {
+		writeDataEntryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func writeDataEntryError(c *gin.Context, err error) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err.Error() == "data entry not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "wallet is deactivated"), strings.HasPrefix(err.Error(), "wallet is frozen"):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+	}
+}
+
+// GetTrustPolicy handles GET /api/v1/wallets/:public_key/trust-policy
+func (ctrl *WalletController) GetTrustPolicy(c *gin.Context) {
+	response, err := ctrl.Service.GetTrustPolicy(c.Param("public_key"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetTrustPolicy handles PUT /api/v1/wallets/:public_key/trust-policy
+func (ctrl *WalletController) SetTrustPolicy(c *gin.Context) {
+	var req models.TrustPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.SetTrustPolicy(c.Param("public_key"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetArchivedTransaction handles GET /api/v1/archive/transactions/:hash
+func (ctrl *WalletController) GetArchivedTransaction(c *gin.Context) {
+	response, err := ctrl.Service.GetArchivedTransaction(c.Param("hash"))
+	if err != nil {
+		switch err.Error() {
+		case "invalid transaction hash":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "archived transaction not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "transaction archive is not configured":
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// FeeBumpTransaction handles POST /api/v1/transactions/:hash/fee-bump
+func (ctrl *WalletController) FeeBumpTransaction(c *gin.Context) {
+	var req models.FeeBumpRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+			return
+		}
+	}
+
+	response, err := ctrl.Service.FeeBumpTransaction(c.Param("hash"), req)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "transaction not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case err.Error() == "transaction is already confirmed", strings.HasPrefix(err.Error(), "transaction has expired"),
+			err.Error() == "transaction already offers the maximum fee bump base fee":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "wallet is frozen"):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ListClaimableBalances handles GET /api/v1/wallets/:public_key/claimable-balances
+func (ctrl *WalletController) ListClaimableBalances(c *gin.Context) {
+	response, err := ctrl.Service.ListClaimableBalances(c.Param("public_key"))
+	if err != nil {
+		if err.Error() == "invalid public key format" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ClaimBalance handles POST /api/v1/wallets/:public_key/claimable-balances/:balance_id/claim
+func (ctrl *WalletController) ClaimBalance(c *gin.Context) {
+	var req models.ClaimBalanceRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+			return
+		}
+	}
+
+	response, err := ctrl.Service.ClaimBalance(authenticatedTenantID(c), c.Param("public_key"), c.Param("balance_id"), req)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "asset not permitted") || strings.HasPrefix(err.Error(), "wallet is frozen") {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "invalid wallet secret key") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		switch err.Error() {
+		case "invalid public key format":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "claimable balance not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "wallet is not a claimant of this balance":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
 		}
 		return
 	}
 	c.JSON(http.StatusOK, response)
 }
+
+// amountErrorCode maps transfer amount policy errors to an HTTP status and a machine-readable error code
+func amountErrorCode(err error) (int, string, bool) {
+	switch {
+	case err.Error() == "invalid amount: below the minimum representable unit of 0.0000001":
+		return http.StatusBadRequest, "amount_below_stroop", true
+	case err.Error() == "invalid amount: exceeds 7 decimal places of stroop precision":
+		return http.StatusBadRequest, "amount_precision_exceeded", true
+	case err.Error() == "invalid amount: exceeds the maximum of 922337203685.4775807":
+		return http.StatusBadRequest, "amount_too_large", true
+	case strings.HasPrefix(err.Error(), "amount below minimum transfer"):
+		return http.StatusUnprocessableEntity, "amount_below_minimum", true
+	}
+	return 0, "", false
+}
+
+// transactionErrorBody returns the error response body, adding Horizon's result codes, their translation
+// and their attribution to request items when a composed transaction was rejected
+func transactionErrorBody(err error) gin.H {
+	body := gin.H{"error": err.Error()}
+	var txErr *services.TransactionError
+	if errors.As(err, &txErr) {
+		body["result_codes"] = gin.H{"transaction": txErr.TransactionCode, "operations": txErr.OperationCodes}
+		if explanation := services.ExplainError(err); explanation != nil {
+			body["code"] = explanation.Code
+			body["explanation"] = explanation.Explanation
+			body["remediation"] = explanation.Remediation
+		}
+		if len(txErr.Items) > 0 {
+			body["items"] = txErr.Items
+		}
+	}
+	return body
+}
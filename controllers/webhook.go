@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+	"github.com/saif727/stellar-wallet-backend/webhookverify"
+)
+
+// WebhookController handles webhook integration HTTP requests
+type WebhookController struct {
+	Service *services.WebhookService
+}
+
+// NewWebhookController creates a new WebhookController instance
+func NewWebhookController(service *services.WebhookService) *WebhookController {
+	return &WebhookController{Service: service}
+}
+
+// VerifySignature handles POST /api/v1/webhooks/verify, letting integrators in any language check their
+// signature verification against ours
+func (ctrl *WebhookController) VerifySignature(c *gin.Context) {
+	var req models.WebhookVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	tolerance := time.Duration(req.ToleranceSeconds) * time.Second
+	err := webhookverify.Verify(req.Secret, []byte(req.Payload), req.Timestamp, req.Signature, tolerance)
+	if err == nil {
+		c.JSON(http.StatusOK, models.WebhookVerifyResponse{Valid: true})
+		return
+	}
+
+	response := models.WebhookVerifyResponse{Error: err.Error()}
+	if err == webhookverify.ErrSignatureMismatch {
+		seconds, _ := strconv.ParseInt(req.Timestamp, 10, 64)
+		response.ExpectedSignature = webhookverify.Sign(req.Secret, time.Unix(seconds, 0), []byte(req.Payload))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateSubscription handles POST /api/v1/webhooks/subscriptions
+func (ctrl *WebhookController) CreateSubscription(c *gin.Context) {
+	var req models.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	response, err := ctrl.Service.CreateSubscription(tenantID(c), req)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "webhook subscription limit reached"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListSubscriptions handles GET /api/v1/webhooks/subscriptions
+func (ctrl *WebhookController) ListSubscriptions(c *gin.Context) {
+	response, err := ctrl.Service.ListSubscriptions(tenantID(c), c.Query("wallet"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteSubscription handles DELETE /api/v1/webhooks/subscriptions/:id
+func (ctrl *WebhookController) DeleteSubscription(c *gin.Context) {
+	if err := ctrl.Service.DeleteSubscription(tenantID(c), c.Param("id")); err != nil {
+		if err.Error() == "webhook subscription not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RotateSecret handles POST /api/v1/webhooks/subscriptions/:id/rotate-secret
+func (ctrl *WebhookController) RotateSecret(c *gin.Context) {
+	response, err := ctrl.Service.RotateSecret(tenantID(c), c.Param("id"))
+	if err != nil {
+		if err.Error() == "webhook subscription not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ListDeliveries handles GET /api/v1/webhooks/deliveries
+func (ctrl *WebhookController) ListDeliveries(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit: must be between 1 and 500"})
+		return
+	}
+	response, err := ctrl.Service.ListDeliveries(tenantID(c), c.Query("subscription_id"), c.Query("event_id"), limit)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ReplayEvent handles POST /api/v1/webhooks/subscriptions/:id/events/:event_id/replay
+func (ctrl *WebhookController) ReplayEvent(c *gin.Context) {
+	response, err := ctrl.Service.ReplayEvent(tenantID(c), c.Param("id"), c.Param("event_id"))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "webhook subscription not found") || strings.HasPrefix(err.Error(), "webhook event not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		return
+	}
+	c.JSON(http.StatusAccepted, response)
+}
+
+// ListDeadLetters handles GET /api/v1/webhooks/dead-letters
+func (ctrl *WebhookController) ListDeadLetters(c *gin.Context) {
+	response, err := ctrl.Service.ListDeadLetters(tenantID(c), c.Query("subscription_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RedriveDeadLetter handles POST /api/v1/webhooks/dead-letters/:id/redrive
+func (ctrl *WebhookController) RedriveDeadLetter(c *gin.Context) {
+	response, err := ctrl.Service.RedriveDeadLetter(tenantID(c), c.Param("id"))
+	if err != nil {
+		switch err.Error() {
+		case "dead letter not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "webhook subscription no longer exists":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, transactionErrorBody(err))
+		}
+		return
+	}
+	c.JSON(http.StatusAccepted, response)
+}
@@ -1,17 +1,29 @@
 package main
 
 import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/saif727/stellar-wallet-backend/controllers"
+	"github.com/saif727/stellar-wallet-backend/models"
 	"github.com/saif727/stellar-wallet-backend/services"
+	"github.com/stellar/go/amount"
 	"github.com/stellar/go/clients/horizonclient"
 	"github.com/stellar/go/txnbuild"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "verify-audit" {
+		os.Exit(verifyAudit(os.Args[2:]))
+	}
+
 	// Load configuration from environment variables
 	config := services.Config{
 		Network:      os.Getenv("STELLAR_NETWORK"),
@@ -22,6 +34,257 @@ func main() {
 		},
 	}
 
+	// Notification channels default to webhook only
+	config.NotificationChannels = []string{"webhook"}
+	if channels := os.Getenv("NOTIFICATION_CHANNELS"); channels != "" {
+		config.NotificationChannels = strings.Split(channels, ",")
+	}
+	if defaults := os.Getenv("TENANT_NOTIFICATION_DEFAULTS"); defaults != "" {
+		if err := json.Unmarshal([]byte(defaults), &config.TenantNotificationDefaults); err != nil {
+			log.Fatalf("Invalid TENANT_NOTIFICATION_DEFAULTS: %v", err)
+		}
+	}
+
+	// Claimable balance sweeping is disabled unless an interval is configured
+	if interval := os.Getenv("CLAIMABLE_SWEEP_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			log.Fatalf("Invalid CLAIMABLE_SWEEP_INTERVAL: %v", err)
+		}
+		config.ClaimableSweepInterval = d
+	}
+	if allowlist := os.Getenv("CLAIMABLE_ASSET_ALLOWLIST"); allowlist != "" {
+		config.ClaimableAssetAllowlist = strings.Split(allowlist, ",")
+	}
+	config.AutoTrustIncoming = os.Getenv("AUTO_TRUST_INCOMING") != "false"
+
+	// Inbound payment routing and invoice settlement are disabled unless a watch interval is configured
+	if interval := os.Getenv("PAYMENT_WATCH_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			log.Fatalf("Invalid PAYMENT_WATCH_INTERVAL: %v", err)
+		}
+		config.PaymentWatchInterval = d
+	}
+	// Deposit ingestion is disabled unless an interval is configured
+	if interval := os.Getenv("DEPOSIT_INGEST_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			log.Fatalf("Invalid DEPOSIT_INGEST_INTERVAL: %v", err)
+		}
+		config.DepositIngestInterval = d
+	}
+	// Anchor deposits and withdrawals, and KYC customers anchors are processing, are refreshed from their
+	// anchors every 30s unless configured
+	config.AnchorPollInterval = 30 * time.Second
+	if interval := os.Getenv("ANCHOR_POLL_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			log.Fatalf("Invalid ANCHOR_POLL_INTERVAL: %v", err)
+		}
+		config.AnchorPollInterval = d
+	}
+	// Per-tenant deposit webhook URLs, e.g. {"acme":"https://acme.example/deposits"}
+	if webhooks := os.Getenv("TENANT_DEPOSIT_WEBHOOKS"); webhooks != "" {
+		if err := json.Unmarshal([]byte(webhooks), &config.TenantDepositWebhooks); err != nil {
+			log.Fatalf("Invalid TENANT_DEPOSIT_WEBHOOKS: %v", err)
+		}
+	}
+	// Balances are snapshotted daily unless configured otherwise; 0 disables snapshots
+	config.BalanceSnapshotInterval = 24 * time.Hour
+	if interval := os.Getenv("BALANCE_SNAPSHOT_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			log.Fatalf("Invalid BALANCE_SNAPSHOT_INTERVAL: %v", err)
+		}
+		config.BalanceSnapshotInterval = d
+	}
+	if attempts := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); attempts != "" {
+		n, err := strconv.Atoi(attempts)
+		if err != nil || n < 1 {
+			log.Fatalf("Invalid WEBHOOK_MAX_ATTEMPTS: %s", attempts)
+		}
+		config.WebhookMaxAttempts = n
+	}
+	// The history ingester follows the network every few seconds once HISTORY_DATABASE_URL is set
+	config.HistoryIngestInterval = 5 * time.Second
+	if interval := os.Getenv("HISTORY_INGEST_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid HISTORY_INGEST_INTERVAL: %s", interval)
+		}
+		config.HistoryIngestInterval = d
+	}
+	config.RecurringChargeInterval = time.Minute
+	if interval := os.Getenv("RECURRING_CHARGE_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			log.Fatalf("Invalid RECURRING_CHARGE_INTERVAL: %v", err)
+		}
+		config.RecurringChargeInterval = d
+	}
+	config.JobWorkers = 4
+	if workers := os.Getenv("JOB_WORKERS"); workers != "" {
+		n, err := strconv.Atoi(workers)
+		if err != nil || n <= 0 {
+			log.Fatalf("Invalid JOB_WORKERS: %s", workers)
+		}
+		config.JobWorkers = n
+	}
+
+	// Ledger inclusion latency SLO, defaulting to 95% within 10s over the last hour
+	if threshold := os.Getenv("SLO_LATENCY_THRESHOLD"); threshold != "" {
+		d, err := time.ParseDuration(threshold)
+		if err != nil {
+			log.Fatalf("Invalid SLO_LATENCY_THRESHOLD: %v", err)
+		}
+		config.SLOLatencyThreshold = d
+	}
+	if target := os.Getenv("SLO_TARGET"); target != "" {
+		f, err := strconv.ParseFloat(target, 64)
+		if err != nil {
+			log.Fatalf("Invalid SLO_TARGET: %v", err)
+		}
+		config.SLOTarget = f
+	}
+	if window := os.Getenv("SLO_WINDOW"); window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil {
+			log.Fatalf("Invalid SLO_WINDOW: %v", err)
+		}
+		config.SLOWindow = d
+	}
+	config.AdminAPIKey = os.Getenv("ADMIN_API_KEY")
+	// Tenant API keys, as tenant:key pairs separated by commas
+	if keys := os.Getenv("TENANT_API_KEYS"); keys != "" {
+		config.TenantAPIKeys = make(map[string]string)
+		for _, pair := range strings.Split(keys, ",") {
+			tenant, key, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			if !ok || tenant == "" || key == "" {
+				log.Fatalf("Invalid TENANT_API_KEYS: %q is not tenant:key", pair)
+			}
+			config.TenantAPIKeys[tenant] = key
+		}
+	}
+	config.AuditSigningSecret = os.Getenv("AUDIT_SIGNING_SECRET")
+	config.CallbackSecret = os.Getenv("TRANSFER_CALLBACK_SECRET")
+	config.PreviousCallbackSecret = os.Getenv("TRANSFER_CALLBACK_PREVIOUS_SECRET")
+	if len(config.TenantDepositWebhooks) > 0 && config.CallbackSecret == "" {
+		log.Fatalf("TENANT_DEPOSIT_WEBHOOKS requires TRANSFER_CALLBACK_SECRET")
+	}
+	config.SponsorWalletReserves = os.Getenv("SPONSOR_WALLET_RESERVES") == "true"
+	config.FeeSponsorshipPolicy = services.FeeSponsorshipOff
+	if policy := os.Getenv("FEE_SPONSORSHIP_POLICY"); policy != "" {
+		if policy != services.FeeSponsorshipOff && policy != services.FeeSponsorshipAuto && policy != services.FeeSponsorshipAlways {
+			log.Fatalf("Invalid FEE_SPONSORSHIP_POLICY: %s", policy)
+		}
+		config.FeeSponsorshipPolicy = policy
+	}
+	if budget := os.Getenv("FEE_SPONSORSHIP_DAILY_BUDGET"); budget != "" {
+		if stroops, err := amount.ParseInt64(budget); err != nil || stroops <= 0 {
+			log.Fatalf("Invalid FEE_SPONSORSHIP_DAILY_BUDGET: %s", budget)
+		}
+		config.FeeSponsorshipDailyBudget = budget
+	}
+	if policies := os.Getenv("TENANT_REFUND_POLICIES"); policies != "" {
+		if err := json.Unmarshal([]byte(policies), &config.TenantRefundPolicies); err != nil {
+			log.Fatalf("Invalid TENANT_REFUND_POLICIES: %v", err)
+		}
+	}
+	// Developer sandbox tenant, reset nightly from friendbot
+	config.SandboxEnabled = os.Getenv("SANDBOX_ENABLED") == "true"
+	if config.SandboxEnabled && config.Network != "testnet" {
+		log.Fatalf("SANDBOX_ENABLED requires STELLAR_NETWORK=testnet")
+	}
+	config.FriendbotFunding = os.Getenv("FRIENDBOT_FUNDING") == "true"
+	if config.FriendbotFunding && config.Network != "testnet" {
+		log.Fatalf("FRIENDBOT_FUNDING requires STELLAR_NETWORK=testnet")
+	}
+	config.WalletHomeDomain = os.Getenv("WALLET_HOME_DOMAIN")
+	if len(config.WalletHomeDomain) > 32 {
+		log.Fatalf("Invalid WALLET_HOME_DOMAIN: must be at most 32 bytes")
+	}
+	if count := os.Getenv("SANDBOX_WALLET_COUNT"); count != "" {
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			log.Fatalf("Invalid SANDBOX_WALLET_COUNT: %v", err)
+		}
+		config.SandboxWalletCount = n
+	}
+	// Transfers scoring above the threshold are held for manual review
+	config.FraudScoreThreshold = 0.8
+	if threshold := os.Getenv("FRAUD_SCORE_THRESHOLD"); threshold != "" {
+		f, err := strconv.ParseFloat(threshold, 64)
+		if err != nil {
+			log.Fatalf("Invalid FRAUD_SCORE_THRESHOLD: %v", err)
+		}
+		config.FraudScoreThreshold = f
+	}
+	// Operator asset policy applied to transfers and trustlines
+	if allowlist := os.Getenv("ASSET_ALLOWLIST"); allowlist != "" {
+		config.AssetAllowlist = strings.Split(allowlist, ",")
+	}
+	if blocklist := os.Getenv("ASSET_BLOCKLIST"); blocklist != "" {
+		config.AssetBlocklist = strings.Split(blocklist, ",")
+	}
+	// Per-asset minimum transfer amounts, e.g. {"native":"0.01"}
+	if minimums := os.Getenv("MIN_TRANSFER_AMOUNTS"); minimums != "" {
+		if err := json.Unmarshal([]byte(minimums), &config.MinTransferAmounts); err != nil {
+			log.Fatalf("Invalid MIN_TRANSFER_AMOUNTS: %v", err)
+		}
+		for asset, minimum := range config.MinTransferAmounts {
+			if _, err := amount.ParseInt64(minimum); err != nil {
+				log.Fatalf("Invalid MIN_TRANSFER_AMOUNTS entry for %s: %v", asset, err)
+			}
+		}
+	}
+	// Default per-wallet spend limits by asset, e.g. {"native":{"per_transaction":"1000","daily":"5000"}}
+	if limits := os.Getenv("SPEND_LIMITS"); limits != "" {
+		var parsed map[string]models.SpendLimit
+		if err := json.Unmarshal([]byte(limits), &parsed); err != nil {
+			log.Fatalf("Invalid SPEND_LIMITS: %v", err)
+		}
+		normalized, err := services.NormalizeSpendLimits(parsed)
+		if err != nil {
+			log.Fatalf("Invalid SPEND_LIMITS: %v", err)
+		}
+		config.SpendLimits = normalized
+	}
+	// Tenants whose internal transfers are netted on-chain periodically
+	if tenants := os.Getenv("INTERNAL_SETTLEMENT_TENANTS"); tenants != "" {
+		config.InternalSettlementTenants = strings.Split(tenants, ",")
+	}
+	config.NetSettlementInterval = time.Hour
+	if interval := os.Getenv("NET_SETTLEMENT_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			log.Fatalf("Invalid NET_SETTLEMENT_INTERVAL: %v", err)
+		}
+		config.NetSettlementInterval = d
+	}
+	// Base fees follow a percentile of recently charged fees, capped per operation
+	if percentile := os.Getenv("FEE_PERCENTILE"); percentile != "" {
+		n, err := strconv.Atoi(percentile)
+		if err != nil || !services.ValidFeePercentile(n) {
+			log.Fatalf("Invalid FEE_PERCENTILE: %s", percentile)
+		}
+		config.FeePercentile = n
+	}
+	if maxFee := os.Getenv("MAX_BASE_FEE"); maxFee != "" {
+		n, err := strconv.ParseInt(maxFee, 10, 64)
+		if err != nil || n < 100 {
+			log.Fatalf("Invalid MAX_BASE_FEE: %s", maxFee)
+		}
+		config.MaxBaseFee = n
+	}
+	if interval := os.Getenv("FEE_STATS_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			log.Fatalf("Invalid FEE_STATS_INTERVAL: %v", err)
+		}
+		config.FeeStatsInterval = d
+	}
+
 	// Set Horizon client based on network
 	if config.Network == "testnet" {
 		config.HorizonClient = horizonclient.DefaultTestNetClient
@@ -31,18 +294,539 @@ func main() {
 
 	// Initialize service and controller
 	walletService := services.NewWalletService(config)
+	walletService.Archive = archiveStore()
+	walletService.Audit.Store = walletService.Archive
+	if count := os.Getenv("CHANNEL_ACCOUNTS"); count != "" {
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid CHANNEL_ACCOUNTS: %s", count)
+		}
+		if err := walletService.ProvisionChannels(n); err != nil {
+			log.Fatalf("Failed to provision channel accounts: %v", err)
+		}
+	}
+	if url := os.Getenv("FRAUD_SCORER_URL"); url != "" {
+		walletService.FraudScorer = &services.HTTPFraudScorer{URL: url}
+	}
+	// Portfolio prices come from PRICE_SOURCE_URL or, failing that, a fixed STATIC_PRICES table,
+	// e.g. {"USD":{"native":"0.11"}}
+	if url := os.Getenv("PRICE_SOURCE_URL"); url != "" {
+		walletService.PriceSource = &services.HTTPPriceSource{URL: url}
+	} else if table := os.Getenv("STATIC_PRICES"); table != "" {
+		var prices services.StaticPriceSource
+		if err := json.Unmarshal([]byte(table), &prices); err != nil {
+			log.Fatalf("Invalid STATIC_PRICES: %v", err)
+		}
+		walletService.PriceSource = prices
+	}
+	var historyIngester *services.HistoryIngester
+	if databaseURL := os.Getenv("HISTORY_DATABASE_URL"); databaseURL != "" {
+		store, err := services.NewHistoryStore(databaseURL)
+		if err != nil {
+			log.Fatalf("Failed to open history store: %v", err)
+		}
+		walletService.History = store
+		historyIngester = services.NewHistoryIngester(walletService, store)
+	}
+	// Operator alerts are logged and, when SMTP_HOST is set, emailed to EMAIL_TO along with large incoming
+	// payments over EMAIL_LARGE_PAYMENT_THRESHOLDS (e.g. {"native":"10000"}) and failed transactions
+	alerter := services.MultiAlerter{services.LogAlerter{}}
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		smtpConfig := services.SMTPConfig{
+			Host:     host,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("EMAIL_FROM"),
+		}
+		if to := os.Getenv("EMAIL_TO"); to != "" {
+			smtpConfig.To = strings.Split(to, ",")
+		}
+		if port := os.Getenv("SMTP_PORT"); port != "" {
+			n, err := strconv.Atoi(port)
+			if err != nil || n <= 0 {
+				log.Fatalf("Invalid SMTP_PORT: %s", port)
+			}
+			smtpConfig.Port = n
+		}
+		var largePayments map[string]string
+		if thresholds := os.Getenv("EMAIL_LARGE_PAYMENT_THRESHOLDS"); thresholds != "" {
+			if err := json.Unmarshal([]byte(thresholds), &largePayments); err != nil {
+				log.Fatalf("Invalid EMAIL_LARGE_PAYMENT_THRESHOLDS: %v", err)
+			}
+		}
+		emailNotifier, err := services.NewEmailNotifier(smtpConfig, largePayments, os.Getenv("EMAIL_TEMPLATE_DIR"))
+		if err != nil {
+			log.Fatalf("Failed to configure email alerts: %v", err)
+		}
+		emailNotifier.Registry = walletService.Registry
+		walletService.Events.Subscribe(emailNotifier.HandleEvent)
+		alerter = append(alerter, emailNotifier)
+	}
+	// Alerts are also posted to SLACK_WEBHOOK_URL and DISCORD_WEBHOOK_URL, batched every ALERT_BATCH_INTERVAL
+	var chatAlerters []*services.ChatAlerter
+	batchInterval := time.Minute
+	if value := os.Getenv("ALERT_BATCH_INTERVAL"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid ALERT_BATCH_INTERVAL: %s", value)
+		}
+		batchInterval = d
+	}
+	for kind, variable := range map[string]string{services.ChatSlack: "SLACK_WEBHOOK_URL", services.ChatDiscord: "DISCORD_WEBHOOK_URL"} {
+		if webhookURL := os.Getenv(variable); webhookURL != "" {
+			chatAlerter, err := services.NewChatAlerter(kind, webhookURL, batchInterval)
+			if err != nil {
+				log.Fatalf("Invalid %s: %v", variable, err)
+			}
+			chatAlerters = append(chatAlerters, chatAlerter)
+			alerter = append(alerter, chatAlerter)
+		}
+	}
+	// ALERT_WEBHOOK_URL receives every alert as a signed models.OperatorAlert
+	if webhookURL := os.Getenv("ALERT_WEBHOOK_URL"); webhookURL != "" {
+		if config.CallbackSecret == "" {
+			log.Fatalf("ALERT_WEBHOOK_URL requires TRANSFER_CALLBACK_SECRET")
+		}
+		alerter = append(alerter, services.WebhookAlerter{URL: webhookURL, Secret: config.CallbackSecret})
+	}
+	walletService.SLO.Alerter = alerter
+	walletService.Submissions.Alerter = alerter
+	horizonInterval := 30 * time.Second
+	if value := os.Getenv("HORIZON_HEALTH_INTERVAL"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid HORIZON_HEALTH_INTERVAL: %s", value)
+		}
+		horizonInterval = d
+	}
+	horizonHealthMonitor := services.NewHorizonHealthMonitor(walletService, horizonInterval, alerter)
+	// The master account's XLM is watched by default, warning below 100 XLM; MASTER_USDC_BALANCE_WARNING adds
+	// USDC and a warning of 0 turns an asset off
+	masterThresholds := map[string]int64{}
+	for asset, variable := range map[string]string{
+		"native": "MASTER_BALANCE_WARNING",
+		config.USDCAsset.Code + ":" + config.USDCAsset.Issuer: "MASTER_USDC_BALANCE_WARNING",
+	} {
+		warning := os.Getenv(variable)
+		if warning == "" && asset == "native" {
+			warning = "100"
+		}
+		if warning == "" || warning == "0" {
+			continue
+		}
+		threshold, err := amount.ParseInt64(warning)
+		if err != nil || threshold <= 0 {
+			log.Fatalf("Invalid %s: %s", variable, warning)
+		}
+		masterThresholds[asset] = threshold
+	}
+	masterInterval := 5 * time.Minute
+	if value := os.Getenv("MASTER_BALANCE_CHECK_INTERVAL"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid MASTER_BALANCE_CHECK_INTERVAL: %s", value)
+		}
+		masterInterval = d
+	}
+	if len(masterThresholds) > 0 {
+		walletService.MasterBalance = services.NewMasterBalanceMonitor(walletService, masterThresholds, masterInterval, alerter)
+	}
 	walletController := controllers.NewWalletController(walletService)
+	assetService := services.NewAssetService(config)
+	assetController := controllers.NewAssetController(assetService)
+	notificationService := services.NewNotificationService(config)
+	notificationController := controllers.NewNotificationController(notificationService)
+	refundService := services.NewRefundService(walletService)
+	refundController := controllers.NewRefundController(refundService)
+	payoutService := services.NewPayoutService(walletService)
+	routingService := services.NewRoutingService(walletService)
+	recurringService := services.NewRecurringService(walletService)
+	invoiceService := services.NewInvoiceService(walletService)
+	invoiceController := controllers.NewInvoiceController(invoiceService)
+	poolService := services.NewPoolService(walletService)
+	poolController := controllers.NewPoolController(poolService)
+	depositService := services.NewDepositService(walletService)
+	depositController := controllers.NewDepositController(depositService)
+	recurringController := controllers.NewRecurringController(recurringService)
+	routingController := controllers.NewRoutingController(routingService)
+	payoutController := controllers.NewPayoutController(payoutService)
+	sandboxService := services.NewSandboxService(walletService, notificationService, refundService)
+	sandboxController := controllers.NewSandboxController(sandboxService)
+	adminController := controllers.NewAdminController(walletService)
+	metricsController := controllers.NewMetricsController(walletService)
+	webhookService := services.NewWebhookService(walletService)
+	webhookController := controllers.NewWebhookController(webhookService)
+	// Push notifications are sent through FCM with a service account key at FCM_CREDENTIALS_FILE and through
+	// APNs with a .p8 signing key at APNS_KEY_FILE
+	pushSenders := map[string]services.PushSender{}
+	if path := os.Getenv("FCM_CREDENTIALS_FILE"); path != "" {
+		credentials, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Failed to read FCM_CREDENTIALS_FILE: %v", err)
+		}
+		sender, err := services.NewFCMSender(credentials)
+		if err != nil {
+			log.Fatalf("Failed to configure FCM: %v", err)
+		}
+		pushSenders[models.PushPlatformFCM] = sender
+	}
+	if path := os.Getenv("APNS_KEY_FILE"); path != "" {
+		signingKey, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Failed to read APNS_KEY_FILE: %v", err)
+		}
+		sender, err := services.NewAPNsSender(signingKey, os.Getenv("APNS_KEY_ID"), os.Getenv("APNS_TEAM_ID"),
+			os.Getenv("APNS_TOPIC"), os.Getenv("APNS_PRODUCTION") == "true")
+		if err != nil {
+			log.Fatalf("Failed to configure APNs: %v", err)
+		}
+		pushSenders[models.PushPlatformAPNs] = sender
+	}
+	pushService := services.NewPushService(walletService, pushSenders)
+	pushController := controllers.NewPushController(pushService)
+	anchorService := services.NewAnchorService(walletService)
+	// FEDERATION_DOMAIN serves federation names of managed wallets, name*FEDERATION_DOMAIN, at /federation
+	var federationController *controllers.FederationController
+	if domain := os.Getenv("FEDERATION_DOMAIN"); domain != "" {
+		federationService, err := services.NewFederationService(walletService, domain)
+		if err != nil {
+			log.Fatalf("Invalid FEDERATION_DOMAIN: %v", err)
+		}
+		walletService.Federation = federationService
+		federationController = controllers.NewFederationController(federationService)
+	}
+	// STELLAR_TOML_CONFIG names a JSON file describing the operator, its SEP endpoints and issued
+	// currencies; when set, /.well-known/stellar.toml is generated from it and the service configuration
+	var stellarTomlController *controllers.StellarTomlController
+	if path := os.Getenv("STELLAR_TOML_CONFIG"); path != "" {
+		tomlConfig, err := services.LoadStellarTomlConfig(path)
+		if err != nil {
+			log.Fatalf("Failed to load STELLAR_TOML_CONFIG: %v", err)
+		}
+		stellarTomlService, err := services.NewStellarTomlService(walletService, tomlConfig)
+		if err != nil {
+			log.Fatalf("Failed to configure stellar.toml: %v", err)
+		}
+		stellarTomlController = controllers.NewStellarTomlController(stellarTomlService)
+	}
+	anchorController := controllers.NewAnchorController(anchorService)
+	kycService := services.NewKYCService(anchorService)
+	kycService.Store = kycStore()
+	anchorService.KYC = kycService
+	kycController := controllers.NewKYCController(kycService)
+	// EVENT_OUTBOX=true stages deposit and transfer events in a persisted outbox, relayed every
+	// EVENT_OUTBOX_INTERVAL and reported backlogged once its oldest event is older than EVENT_OUTBOX_MAX_AGE
+	if os.Getenv("EVENT_OUTBOX") == "true" {
+		if walletService.Archive == nil {
+			log.Fatalf("EVENT_OUTBOX requires ARCHIVE_BACKEND")
+		}
+		outboxInterval, outboxMaxAge := 5*time.Second, 5*time.Minute
+		if value := os.Getenv("EVENT_OUTBOX_INTERVAL"); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				log.Fatalf("Invalid EVENT_OUTBOX_INTERVAL: %s", value)
+			}
+			outboxInterval = d
+		}
+		if value := os.Getenv("EVENT_OUTBOX_MAX_AGE"); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				log.Fatalf("Invalid EVENT_OUTBOX_MAX_AGE: %s", value)
+			}
+			outboxMaxAge = d
+		}
+		walletService.Outbox = services.NewOutbox(walletService, outboxInterval, outboxMaxAge, alerter)
+	}
+	// EVENT_BUS publishes every domain event to Kafka, through a REST proxy at EVENT_BUS_URL, or to a NATS
+	// server at EVENT_BUS_URL, on EVENT_BUS_TOPIC
+	var eventRelay *services.EventRelay
+	if kind := os.Getenv("EVENT_BUS"); kind != "" {
+		topic := os.Getenv("EVENT_BUS_TOPIC")
+		if topic == "" {
+			topic = "stellar.wallet.events"
+		}
+		var broker services.EventBroker
+		var err error
+		switch kind {
+		case "kafka":
+			broker, err = services.NewKafkaBroker(os.Getenv("EVENT_BUS_URL"), topic)
+		case "nats":
+			broker, err = services.NewNATSBroker(os.Getenv("EVENT_BUS_URL"), topic)
+		default:
+			log.Fatalf("Invalid EVENT_BUS: %s (must be kafka or nats)", kind)
+		}
+		if err != nil {
+			log.Fatalf("Failed to configure event bus: %v", err)
+		}
+		eventRelay = services.NewEventRelay(walletService, broker)
+		if walletService.Outbox != nil {
+			walletService.Outbox.Broker = broker
+		}
+	}
+	eventStreamController := controllers.NewEventStreamController(walletService)
+	transactionController := controllers.NewTransactionController(walletService)
+	paymentController := controllers.NewPaymentController(walletService)
+	jobService := services.NewJobService(walletService)
+	jobController := controllers.NewJobController(jobService)
+
+	// Start background workers
+	go walletService.Fees.Run(context.Background())
+	if config.ClaimableSweepInterval > 0 {
+		sweeper := services.NewClaimableBalanceSweeper(walletService, config.ClaimableSweepInterval)
+		go sweeper.Run(context.Background())
+	}
+	if config.PaymentWatchInterval > 0 {
+		watcher := services.NewPaymentWatcher(walletService, routingService, invoiceService, poolService, config.PaymentWatchInterval)
+		go watcher.Run(context.Background())
+	}
+	go recurringService.Run(context.Background(), config.RecurringChargeInterval)
+	if config.BalanceSnapshotInterval > 0 {
+		snapshotter := services.NewBalanceSnapshotter(walletService, config.BalanceSnapshotInterval)
+		go snapshotter.Run(context.Background())
+	}
+	if config.DepositIngestInterval > 0 {
+		go depositService.Run(context.Background(), config.DepositIngestInterval)
+	}
+	if config.AnchorPollInterval > 0 {
+		go anchorService.Run(context.Background(), config.AnchorPollInterval)
+		go kycService.Run(context.Background(), config.AnchorPollInterval)
+	}
+	if historyIngester != nil {
+		go historyIngester.Run(context.Background(), config.HistoryIngestInterval)
+	}
+	if walletService.MasterBalance != nil {
+		go walletService.MasterBalance.Run(context.Background())
+	}
+	go horizonHealthMonitor.Run(context.Background())
+	for _, chatAlerter := range chatAlerters {
+		go chatAlerter.Run(context.Background())
+	}
+	go jobService.Run(context.Background(), config.JobWorkers)
+	if eventRelay != nil {
+		go eventRelay.Run(context.Background())
+	}
+	if walletService.Outbox != nil {
+		go walletService.Outbox.Run(context.Background())
+	}
+	if len(config.InternalSettlementTenants) > 0 {
+		settler := services.NewNetSettler(walletService, config.NetSettlementInterval)
+		go settler.Run(context.Background())
+	}
+	if config.SandboxEnabled {
+		go sandboxService.Run(context.Background())
+	}
 
 	// Initialize Gin router
 	router := gin.Default()
+	// Tenant routes; webhook signature verification stays public, as it is called by webhook consumers
+	// rather than tenants
+	api := router.Group("", controllers.AuthenticateTenant(config.TenantAPIKeys))
 
 	// Define routes
-	router.POST("/api/v1/wallets/create", walletController.CreateWallet)
-	router.GET("/api/v1/wallets/:public_key", walletController.GetWalletDetails)
-	router.POST("/api/v1/wallets/transfer", walletController.TransferFunds)
+	if stellarTomlController != nil {
+		router.GET("/.well-known/stellar.toml", stellarTomlController.GetStellarToml)
+	}
+	api.POST("/api/v1/wallets/create", walletController.CreateWallet)
+	api.POST("/api/v1/wallets/create/async", jobController.CreateWallet)
+	api.POST("/api/v1/wallets/activate", walletController.ActivateWallet)
+	api.GET("/api/v1/wallets/create/estimate", walletController.EstimateWalletCreation)
+	api.GET("/api/v1/wallets/changes", walletController.GetWalletChanges)
+	api.GET("/api/v1/wallets/:public_key", walletController.GetWalletDetails)
+	api.GET("/api/v1/wallets/:public_key/payments", walletController.GetWalletPayments)
+	api.GET("/api/v1/wallets/:public_key/balance-history", walletController.GetBalanceHistory)
+	api.GET("/api/v1/wallets/:public_key/statements", walletController.GetStatement)
+	api.GET("/api/v1/wallets/:public_key/portfolio", walletController.GetPortfolio)
+	api.GET("/api/v1/wallets/:public_key/operations", walletController.GetWalletOperations)
+	api.GET("/api/v1/wallets/:public_key/effects", walletController.GetWalletEffects)
+	api.GET("/api/v1/wallets/:public_key/sponsorship", walletController.GetSponsorship)
+	api.GET("/api/v1/wallets/:public_key/reserve", walletController.GetWalletReserve)
+	api.PUT("/api/v1/wallets/:public_key/metadata", walletController.SetWalletMetadata)
+	api.POST("/api/v1/wallets/transfer", walletController.TransferFunds)
+	api.POST("/api/v1/wallets/transfer/simulate", walletController.SimulateTransfer)
+	api.POST("/api/v1/wallets/transfer/split", walletController.SplitTransfer)
+	api.POST("/api/v1/wallets/transfer/async", jobController.TransferFunds)
+	api.GET("/api/v1/jobs/:id", jobController.GetJob)
+	api.POST("/api/v1/wallets/:public_key/close", walletController.CloseWallet)
+	api.POST("/api/v1/wallets/:public_key/merge", walletController.MergeWallet)
+	api.POST("/api/v1/wallets/:public_key/fund", walletController.FundWallet)
+	api.POST("/api/v1/wallets/:public_key/options", walletController.SetAccountOptions)
+	api.GET("/api/v1/wallets/:public_key/thresholds", walletController.GetAccountThresholds)
+	api.PUT("/api/v1/wallets/:public_key/thresholds", walletController.SetAccountThresholds)
+	api.PUT("/api/v1/wallets/:public_key/data/:name", walletController.SetDataEntry)
+	api.DELETE("/api/v1/wallets/:public_key/data/:name", walletController.DeleteDataEntry)
+	api.GET("/api/v1/wallets/:public_key/claimable-balances", walletController.ListClaimableBalances)
+	api.POST("/api/v1/wallets/:public_key/claimable-balances/:balance_id/claim", walletController.ClaimBalance)
+	api.GET("/api/v1/wallets/:public_key/trust-policy", walletController.GetTrustPolicy)
+	api.PUT("/api/v1/wallets/:public_key/trust-policy", walletController.SetTrustPolicy)
+	api.GET("/api/v1/wallets/:public_key/notification-preferences", notificationController.GetPreferences)
+	api.POST("/api/v1/wallets/:public_key/devices", pushController.RegisterDevice)
+	api.GET("/api/v1/wallets/:public_key/devices", pushController.ListDevices)
+	api.DELETE("/api/v1/wallets/:public_key/devices/:id", pushController.UnregisterDevice)
+	api.POST("/api/v1/wallets/:public_key/anchors/auth", anchorController.Authenticate)
+	api.POST("/api/v1/wallets/:public_key/anchors/sep6/deposits", anchorController.Deposit)
+	api.POST("/api/v1/wallets/:public_key/anchors/sep6/withdrawals", anchorController.Withdraw)
+	api.POST("/api/v1/wallets/:public_key/anchors/sep24/deposits", anchorController.InteractiveDeposit)
+	api.POST("/api/v1/wallets/:public_key/anchors/sep24/withdrawals", anchorController.InteractiveWithdraw)
+	api.GET("/api/v1/wallets/:public_key/anchors/sep31/quote", anchorController.SendQuote)
+	api.POST("/api/v1/wallets/:public_key/anchors/sep31/sends", anchorController.Send)
+	api.GET("/api/v1/wallets/:public_key/anchors/sep38/prices", anchorController.Prices)
+	api.GET("/api/v1/wallets/:public_key/anchors/sep38/price", anchorController.Price)
+	api.POST("/api/v1/wallets/:public_key/anchors/sep38/quotes", anchorController.CreateQuote)
+	api.GET("/api/v1/wallets/:public_key/anchors/sep38/quotes", anchorController.ListQuotes)
+	api.GET("/api/v1/wallets/:public_key/anchors/sep38/quotes/:id", anchorController.GetQuote)
+	api.GET("/api/v1/wallets/:public_key/anchors/transactions", anchorController.ListTransactions)
+	api.GET("/api/v1/wallets/:public_key/anchors/transactions/:id", anchorController.GetTransaction)
+	api.GET("/api/v1/wallets/:public_key/kyc", kycController.GetCustomer)
+	api.PUT("/api/v1/wallets/:public_key/kyc", kycController.SetFields)
+	api.DELETE("/api/v1/wallets/:public_key/kyc", kycController.DeleteCustomer)
+	api.PUT("/api/v1/wallets/:public_key/kyc/files/:field", kycController.UploadFile)
+	api.POST("/api/v1/wallets/:public_key/kyc/anchors", kycController.Submit)
+	api.GET("/api/v1/wallets/:public_key/kyc/anchors/:home_domain", kycController.RefreshStatus)
+	api.DELETE("/api/v1/wallets/:public_key/kyc/anchors/:home_domain", kycController.DeleteFromAnchor)
+	if federationController != nil {
+		router.GET("/federation", federationController.Resolve)
+		api.POST("/api/v1/wallets/:public_key/federation-names", federationController.SetName)
+		api.GET("/api/v1/wallets/:public_key/federation-names", federationController.ListNames)
+		api.DELETE("/api/v1/wallets/:public_key/federation-names/:name", federationController.RemoveName)
+	}
+	api.PUT("/api/v1/wallets/:public_key/notification-preferences", notificationController.UpdatePreferences)
+	api.POST("/api/v1/payments/path/strict-send", paymentController.PathPaymentStrictSend)
+	api.POST("/api/v1/payments/path/strict-receive", paymentController.PathPaymentStrictReceive)
+	api.POST("/api/v1/payments/:hash/refund", refundController.RefundPayment)
+	api.GET("/api/v1/quotes", paymentController.GetQuote)
+	api.GET("/api/v1/assets/:code/:issuer", assetController.GetAssetMetadata)
+	api.GET("/api/v1/archive/transactions/:hash", walletController.GetArchivedTransaction)
+	api.POST("/api/v1/transactions/build", transactionController.BuildTransaction)
+	api.POST("/api/v1/transactions/submit", transactionController.SubmitTransaction)
+	api.GET("/api/v1/transactions/:hash", transactionController.GetTransactionStatus)
+	api.POST("/api/v1/transactions/:hash/fee-bump", walletController.FeeBumpTransaction)
+	router.POST("/api/v1/webhooks/verify", webhookController.VerifySignature)
+	api.POST("/api/v1/webhooks/subscriptions", webhookController.CreateSubscription)
+	api.GET("/api/v1/webhooks/subscriptions", webhookController.ListSubscriptions)
+	api.DELETE("/api/v1/webhooks/subscriptions/:id", webhookController.DeleteSubscription)
+	api.POST("/api/v1/webhooks/subscriptions/:id/rotate-secret", webhookController.RotateSecret)
+	api.POST("/api/v1/webhooks/subscriptions/:id/events/:event_id/replay", webhookController.ReplayEvent)
+	api.GET("/api/v1/webhooks/deliveries", webhookController.ListDeliveries)
+	api.GET("/api/v1/webhooks/dead-letters", webhookController.ListDeadLetters)
+	api.POST("/api/v1/webhooks/dead-letters/:id/redrive", webhookController.RedriveDeadLetter)
+	api.POST("/api/v1/payouts", payoutController.CreatePayoutBatch)
+	api.GET("/api/v1/payouts/:batch_id", payoutController.GetPayoutBatch)
+	api.GET("/api/v1/routing-rules", routingController.GetRules)
+	api.PUT("/api/v1/routing-rules", routingController.SetRules)
+	api.GET("/api/v1/routing-rules/executions", routingController.ListExecutions)
+	api.POST("/api/v1/invoices", invoiceController.CreateInvoice)
+	api.GET("/api/v1/invoices", invoiceController.ListInvoices)
+	api.GET("/api/v1/invoices/:id", invoiceController.GetInvoice)
+	api.GET("/api/v1/events/stream", eventStreamController.StreamEvents)
+	api.GET("/api/v1/deposits", depositController.ListDeposits)
+	api.GET("/api/v1/search/payments", walletController.SearchPayments)
+	api.POST("/api/v1/pool/sub-accounts", poolController.CreateSubAccount)
+	api.GET("/api/v1/pool/sub-accounts", poolController.ListSubAccounts)
+	api.GET("/api/v1/pool/sub-accounts/:address", poolController.GetSubAccount)
+	api.POST("/api/v1/pool/sub-accounts/:address/transfer", poolController.Transfer)
+	api.POST("/api/v1/recurring-payments", recurringController.CreatePlan)
+	api.GET("/api/v1/recurring-payments", recurringController.ListPlans)
+	api.GET("/api/v1/recurring-payments/:id", recurringController.GetPlan)
+	api.POST("/api/v1/recurring-payments/:id/cancel", recurringController.CancelPlan)
+	api.POST("/api/v1/refunds", refundController.RequestRefund)
+	api.GET("/api/v1/refunds/:id", refundController.GetRefund)
+	api.POST("/api/v1/refunds/:id/execute", refundController.ExecuteRefund)
+	if config.SandboxEnabled {
+		api.GET("/api/v1/sandbox", sandboxController.GetSandbox)
+	}
+	router.GET("/metrics", adminController.RequireAdmin, metricsController.GetMetrics)
+	router.GET("/admin", adminController.RequireAdmin, controllers.AdminUI)
+
+	admin := router.Group("/api/v1/admin", adminController.RequireAdmin, adminController.AuditAdminRequests)
+	admin.GET("/audit/export", adminController.ExportAudit)
+	admin.GET("/slo", adminController.GetSLOStatus)
+	admin.GET("/transfer-reviews", adminController.ListTransferReviews)
+	admin.POST("/transfer-reviews/:id/approve", adminController.ApproveTransferReview)
+	admin.POST("/transfer-reviews/:id/reject", adminController.RejectTransferReview)
+	admin.GET("/queues", adminController.GetQueueStatus)
+	admin.GET("/outbox", adminController.GetOutboxStatus)
+	admin.GET("/wallets", adminController.ListManagedWallets)
+	admin.GET("/wallets/:public_key/transfers", adminController.GetWalletTransfers)
+	admin.GET("/wallets/:public_key/deactivation", adminController.GetWalletDeactivation)
+	admin.POST("/wallets/:public_key/deactivate", adminController.DeactivateWallet)
+	admin.POST("/wallets/:public_key/restore", adminController.RestoreWallet)
+	admin.GET("/wallets/:public_key/freeze", adminController.GetWalletFreeze)
+	admin.POST("/wallets/:public_key/freeze", adminController.FreezeWallet)
+	admin.POST("/wallets/:public_key/unfreeze", adminController.UnfreezeWallet)
+	admin.POST("/wallets/:public_key/sponsorship/revoke", adminController.RevokeSponsorship)
+	admin.GET("/wallets/:public_key/spend-limits", adminController.GetSpendLimits)
+	admin.PUT("/wallets/:public_key/spend-limits", adminController.SetSpendLimits)
+	admin.DELETE("/wallets/:public_key/spend-limits", adminController.ResetSpendLimits)
+	admin.POST("/transactions/:hash/recover", transactionController.RecoverTransaction)
+	admin.POST("/refunds/:id/approve", refundController.ApproveRefund)
+	admin.POST("/refunds/:id/reject", refundController.RejectRefund)
+	if config.SandboxEnabled {
+		admin.POST("/sandbox/reset", sandboxController.ResetSandbox)
+	}
 
 	// Run the server
 	if err := router.Run(":8080"); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// archiveStore configures transaction archival from ARCHIVE_BACKEND ("s3" or "local"); it returns nil when
+// archival is disabled
+func archiveStore() services.ArchiveStore {
+	switch os.Getenv("ARCHIVE_BACKEND") {
+	case "s3":
+		store := &services.S3ArchiveStore{
+			Endpoint:  os.Getenv("ARCHIVE_S3_ENDPOINT"),
+			Region:    os.Getenv("ARCHIVE_S3_REGION"),
+			Bucket:    os.Getenv("ARCHIVE_S3_BUCKET"),
+			AccessKey: os.Getenv("ARCHIVE_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("ARCHIVE_S3_SECRET_KEY"),
+		}
+		retentionDays := 7*365 + 2
+		if days := os.Getenv("ARCHIVE_RETENTION_DAYS"); days != "" {
+			n, err := strconv.Atoi(days)
+			if err != nil {
+				log.Fatalf("Invalid ARCHIVE_RETENTION_DAYS: %v", err)
+			}
+			retentionDays = n
+		}
+		transitionDays, _ := strconv.Atoi(os.Getenv("ARCHIVE_TRANSITION_DAYS"))
+		if err := store.EnsureLifecycle(retentionDays, transitionDays, os.Getenv("ARCHIVE_STORAGE_CLASS")); err != nil {
+			log.Printf("Failed to apply archive lifecycle policy: %v", err)
+		}
+		return store
+	case "local":
+		return &services.LocalArchiveStore{Dir: os.Getenv("ARCHIVE_DIR")}
+	}
+	return nil
+}
+
+// kycStore configures KYC storage from KYC_BACKEND ("s3" or "local"), kept apart from the transaction archive
+// and encrypted with KYC_ENCRYPTION_KEY, a hex-encoded 32-byte key; it returns nil to keep KYC information
+// in memory
+func kycStore() services.KYCStore {
+	var store services.KYCStore
+	switch os.Getenv("KYC_BACKEND") {
+	case "s3":
+		store = &services.S3ArchiveStore{
+			Endpoint:  os.Getenv("KYC_S3_ENDPOINT"),
+			Region:    os.Getenv("KYC_S3_REGION"),
+			Bucket:    os.Getenv("KYC_S3_BUCKET"),
+			AccessKey: os.Getenv("KYC_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("KYC_S3_SECRET_KEY"),
+		}
+	case "local":
+		store = &services.LocalArchiveStore{Dir: os.Getenv("KYC_DIR")}
+	default:
+		return nil
+	}
+	key, err := hex.DecodeString(os.Getenv("KYC_ENCRYPTION_KEY"))
+	if err != nil {
+		log.Fatalf("Invalid KYC_ENCRYPTION_KEY: %v", err)
+	}
+	encrypted, err := services.NewEncryptedStore(store, key)
+	if err != nil {
+		log.Fatalf("Invalid KYC_ENCRYPTION_KEY: %v", err)
+	}
+	return encrypted
+}
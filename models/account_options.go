@@ -0,0 +1,80 @@
+package models
+
+// Account flags accepted by the account options endpoint
+const (
+	AccountFlagAuthRequired        = "auth_required"
+	AccountFlagAuthRevocable       = "auth_revocable"
+	AccountFlagAuthClawbackEnabled = "auth_clawback_enabled"
+)
+
+// AccountOptionsRequest represents the request body for configuring a wallet's account. Omitted fields are
+// left unchanged.
+type AccountOptionsRequest struct {
+	// SecretKey signs on behalf of the wallet; it may be omitted for a managed wallet when the request is
+	// authenticated as the wallet's tenant
+	SecretKey string `json:"secret_key"`
+	// HomeDomain sets the account's home domain; an empty string clears it
+	HomeDomain *string `json:"home_domain,omitempty"`
+	// SetFlags and ClearFlags name auth_required, auth_revocable or auth_clawback_enabled
+	SetFlags   []string `json:"set_flags,omitempty"`
+	ClearFlags []string `json:"clear_flags,omitempty"`
+	// MasterWeight and the thresholds are between 0 and 255
+	MasterWeight    *int `json:"master_weight,omitempty"`
+	LowThreshold    *int `json:"low_threshold,omitempty"`
+	MediumThreshold *int `json:"medium_threshold,omitempty"`
+	HighThreshold   *int `json:"high_threshold,omitempty"`
+}
+
+// AccountFlags are the authorization flags of an account
+type AccountFlags struct {
+	AuthRequired        bool `json:"auth_required"`
+	AuthRevocable       bool `json:"auth_revocable"`
+	AuthImmutable       bool `json:"auth_immutable"`
+	AuthClawbackEnabled bool `json:"auth_clawback_enabled"`
+}
+
+// AccountThresholds are the signature weights an account requires per operation category
+type AccountThresholds struct {
+	Low    int `json:"low"`
+	Medium int `json:"medium"`
+	High   int `json:"high"`
+}
+
+// AccountThresholdsRequest represents the request body for updating a wallet's master key weight and
+// thresholds. Omitted fields are left unchanged.
+type AccountThresholdsRequest struct {
+	// SecretKey signs on behalf of the wallet; it may be omitted for a managed wallet when the request is
+	// authenticated as the wallet's tenant
+	SecretKey       string `json:"secret_key"`
+	MasterWeight    *int   `json:"master_weight,omitempty"`
+	LowThreshold    *int   `json:"low_threshold,omitempty"`
+	MediumThreshold *int   `json:"medium_threshold,omitempty"`
+	HighThreshold   *int   `json:"high_threshold,omitempty"`
+}
+
+// AccountSignerWeight is one of an account's signers and its weight
+type AccountSignerWeight struct {
+	Key    string `json:"key"`
+	Type   string `json:"type"`
+	Weight int    `json:"weight"`
+}
+
+// AccountThresholdsResponse represents a wallet's signers, their total weight and its thresholds
+type AccountThresholdsResponse struct {
+	PublicKey       string                `json:"public_key"`
+	TransactionHash string                `json:"transaction_hash,omitempty"`
+	MasterWeight    int                   `json:"master_weight"`
+	Thresholds      AccountThresholds     `json:"thresholds"`
+	Signers         []AccountSignerWeight `json:"signers"`
+	TotalWeight     int                   `json:"total_weight"`
+}
+
+// AccountOptionsResponse represents a wallet's account configuration after an update
+type AccountOptionsResponse struct {
+	PublicKey       string            `json:"public_key"`
+	TransactionHash string            `json:"transaction_hash"`
+	HomeDomain      string            `json:"home_domain"`
+	Flags           AccountFlags      `json:"flags"`
+	MasterWeight    int               `json:"master_weight"`
+	Thresholds      AccountThresholds `json:"thresholds"`
+}
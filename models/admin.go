@@ -0,0 +1,39 @@
+package models
+
+// SLOStatusResponse represents the API response for the ledger inclusion latency SLO
+type SLOStatusResponse struct {
+	ThresholdSeconds      float64 `json:"threshold_seconds"`
+	Target                float64 `json:"target"`
+	WindowSeconds         float64 `json:"window_seconds"`
+	Samples               int     `json:"samples"`
+	WithinThreshold       int     `json:"within_threshold"`
+	Compliance            float64 `json:"compliance"`
+	Breached              bool    `json:"breached"`
+	TotalRecorded         int64   `json:"total_recorded"`
+	AverageLatencySeconds float64 `json:"average_latency_seconds"`
+	LastLatencySeconds    float64 `json:"last_latency_seconds"`
+	LastTransactionHash   string  `json:"last_transaction_hash,omitempty"`
+}
+
+// ManagedWalletResponse represents a wallet custodied by the service, as listed in the admin API
+type ManagedWalletResponse struct {
+	PublicKey   string `json:"public_key"`
+	TenantID    string `json:"tenant_id"`
+	Deactivated bool   `json:"deactivated"`
+	// Labels and Metadata are the client's annotations of the wallet
+	Labels   []string          `json:"labels,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// QueueStatusResponse summarizes the work waiting in the service's background queues
+type QueueStatusResponse struct {
+	PendingTransferReviews int `json:"pending_transfer_reviews"`
+	// UnsettledInternalLegs is the number of on-chain payments the next net settlement would submit
+	UnsettledInternalLegs         int     `json:"unsettled_internal_legs"`
+	NetSettlementIntervalSeconds  float64 `json:"net_settlement_interval_seconds"`
+	ClaimableSweepIntervalSeconds float64 `json:"claimable_sweep_interval_seconds"`
+	SLOBreached                   bool    `json:"slo_breached"`
+	// OutboxPending and OutboxOldestAgeSeconds describe the transactional outbox backlog, when it is enabled
+	OutboxPending          int     `json:"outbox_pending"`
+	OutboxOldestAgeSeconds float64 `json:"outbox_oldest_age_seconds"`
+}
@@ -0,0 +1,311 @@
+package models
+
+import "time"
+
+// AnchorAuthRequest represents the request body for authenticating a wallet with an anchor (SEP-10)
+type AnchorAuthRequest struct {
+	// HomeDomain is the anchor's domain, where its stellar.toml is published
+	HomeDomain string `json:"home_domain" binding:"required"`
+}
+
+// AnchorAuthResponse carries the JWT an anchor issued to a wallet, for its SEP-6, SEP-24, SEP-31 and
+// SEP-38 APIs
+type AnchorAuthResponse struct {
+	Wallet     string `json:"wallet"`
+	HomeDomain string `json:"home_domain"`
+	Token      string `json:"token"`
+	// ExpiresAt is the token's expiry, when the token states one
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Anchor protocols an anchor transaction runs over
+const (
+	AnchorProtocolSEP6  = "sep6"
+	AnchorProtocolSEP24 = "sep24"
+	AnchorProtocolSEP31 = "sep31"
+)
+
+// Anchor transaction kinds
+const (
+	AnchorKindDeposit    = "deposit"
+	AnchorKindWithdrawal = "withdrawal"
+	// AnchorKindSend is a SEP-31 payment to a receiving anchor, which pays out to the receiver off-chain
+	AnchorKindSend = "send"
+)
+
+// Anchor transaction statuses (SEP-6, SEP-24 and SEP-31) after which the anchor no longer changes it
+const (
+	AnchorStatusCompleted = "completed"
+	AnchorStatusRefunded  = "refunded"
+	AnchorStatusExpired   = "expired"
+	AnchorStatusError     = "error"
+	AnchorStatusNoMarket  = "no_market"
+	AnchorStatusTooSmall  = "too_small"
+	AnchorStatusTooLarge  = "too_large"
+	// AnchorStatusPendingSender is when a SEP-31 sender must send the receiving anchor its payment
+	AnchorStatusPendingSender = "pending_sender"
+	// AnchorStatusIncomplete is a SEP-24 transaction whose user has not finished the anchor's interactive flow
+	AnchorStatusIncomplete = "incomplete"
+	// AnchorStatusPendingUserTransferStart is when a withdrawing wallet must send the anchor its payment
+	AnchorStatusPendingUserTransferStart = "pending_user_transfer_start"
+)
+
+// AnchorDepositRequest represents the request body for starting a SEP-6 deposit into a wallet
+type AnchorDepositRequest struct {
+	// Asset is CODE:ISSUER; the anchor is the issuer's home domain unless HomeDomain is set
+	Asset      string `json:"asset" binding:"required"`
+	Amount     string `json:"amount,omitempty"`
+	Type       string `json:"type,omitempty"`
+	HomeDomain string `json:"home_domain,omitempty"`
+	// QuoteID, when set, is a sep6 firm quote buying Asset: the deposit is made in the quote's sell asset
+	// and amount, and converted at its rate
+	QuoteID string `json:"quote_id,omitempty"`
+	// Fields are passed to the anchor as additional request parameters, e.g. lang or country_code
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// AnchorWithdrawRequest represents the request body for starting a SEP-6 withdrawal from a wallet. The
+// service sends the anchor the wallet's payment once the anchor is ready for it.
+type AnchorWithdrawRequest struct {
+	Asset      string `json:"asset" binding:"required"`
+	Amount     string `json:"amount" binding:"required"`
+	Type       string `json:"type,omitempty"`
+	Dest       string `json:"dest,omitempty"`
+	DestExtra  string `json:"dest_extra,omitempty"`
+	HomeDomain string `json:"home_domain,omitempty"`
+	// QuoteID, when set, is a sep6 firm quote selling Amount of Asset: the anchor pays out the quote's buy
+	// asset at its rate
+	QuoteID string            `json:"quote_id,omitempty"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// AnchorInteractiveRequest represents the request body for starting a SEP-24 interactive deposit into or
+// withdrawal from a wallet. The user finishes it in the anchor's web flow, at the returned interactive URL.
+type AnchorInteractiveRequest struct {
+	Asset string `json:"asset" binding:"required"`
+	// Amount prefills the amount in the anchor's flow. It is required for a withdrawal, which pays the
+	// amount the user settles on there but never more than Amount.
+	Amount     string `json:"amount,omitempty"`
+	Lang       string `json:"lang,omitempty"`
+	HomeDomain string `json:"home_domain,omitempty"`
+	// Fields are SEP-9 customer fields passed to the anchor to prefill its flow, e.g. email_address
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// AnchorSendQuoteRequest represents the query of a SEP-31 send quote
+type AnchorSendQuoteRequest struct {
+	HomeDomain string `form:"home_domain" binding:"required"`
+	Asset      string `form:"asset" binding:"required"`
+	Amount     string `form:"amount"`
+}
+
+// AnchorSendQuoteResponse is what a receiving anchor charges to pay out an asset over SEP-31, and the
+// customer information it needs
+type AnchorSendQuoteResponse struct {
+	HomeDomain string `json:"home_domain"`
+	Asset      string `json:"asset"`
+	// Amount, Fee and AmountAfterFee are set when the quote was requested for an amount; Fee is estimated
+	// from the anchor's fixed and percentage fees
+	Amount         string `json:"amount,omitempty"`
+	Fee            string `json:"fee,omitempty"`
+	AmountAfterFee string `json:"amount_after_fee,omitempty"`
+	MinAmount      string `json:"min_amount,omitempty"`
+	MaxAmount      string `json:"max_amount,omitempty"`
+	// QuotesRequired is set when the anchor only accepts sends at a SEP-38 firm quote's rate
+	QuotesSupported bool     `json:"quotes_supported"`
+	QuotesRequired  bool     `json:"quotes_required"`
+	FundingMethods  []string `json:"funding_methods,omitempty"`
+	// SenderTypes and ReceiverTypes are the SEP-12 customer types the anchor accepts
+	SenderTypes   []string `json:"sender_types,omitempty"`
+	ReceiverTypes []string `json:"receiver_types,omitempty"`
+}
+
+// AnchorSendRequest represents the request body for sending a SEP-31 cross-border payment from a wallet.
+// The wallet's stored KYC information registers it as the sender unless SenderID names a customer the
+// anchor already knows; ReceiverFields register the receiver unless ReceiverID does.
+type AnchorSendRequest struct {
+	HomeDomain     string            `json:"home_domain" binding:"required"`
+	Asset          string            `json:"asset" binding:"required"`
+	Amount         string            `json:"amount" binding:"required"`
+	SenderType     string            `json:"sender_type,omitempty"`
+	ReceiverType   string            `json:"receiver_type,omitempty"`
+	SenderID       string            `json:"sender_id,omitempty"`
+	ReceiverID     string            `json:"receiver_id,omitempty"`
+	ReceiverFields map[string]string `json:"receiver_fields,omitempty"`
+	FundingMethod  string            `json:"funding_method,omitempty"`
+	// QuoteID, when set, is a sep31 firm quote selling Amount of Asset, which the receiver is paid out at
+	QuoteID string `json:"quote_id,omitempty"`
+}
+
+// Contexts of SEP-38 quotes, naming the anchor flow a quote is for
+const (
+	AnchorQuoteContextSEP6  = "sep6"
+	AnchorQuoteContextSEP31 = "sep31"
+)
+
+// AnchorPricesRequest represents the query of the indicative prices an anchor buys assets at for an amount
+// of an asset (SEP-38). Assets are SEP-38 assets such as iso4217:USD, or Stellar assets as CODE:ISSUER.
+type AnchorPricesRequest struct {
+	HomeDomain         string `form:"home_domain" binding:"required"`
+	SellAsset          string `form:"sell_asset" binding:"required"`
+	SellAmount         string `form:"sell_amount" binding:"required"`
+	SellDeliveryMethod string `form:"sell_delivery_method"`
+	BuyDeliveryMethod  string `form:"buy_delivery_method"`
+	CountryCode        string `form:"country_code"`
+}
+
+// AnchorPrice is the indicative price of an asset an anchor buys, in units of the sold asset
+type AnchorPrice struct {
+	Asset    string `json:"asset"`
+	Price    string `json:"price"`
+	Decimals int    `json:"decimals"`
+}
+
+// AnchorPricesResponse lists the assets an anchor buys for an amount of an asset, at indicative prices
+type AnchorPricesResponse struct {
+	HomeDomain string        `json:"home_domain"`
+	SellAsset  string        `json:"sell_asset"`
+	SellAmount string        `json:"sell_amount"`
+	BuyAssets  []AnchorPrice `json:"buy_assets"`
+}
+
+// AnchorPriceRequest represents the query of an indicative SEP-38 price for converting one asset into
+// another; exactly one of SellAmount and BuyAmount is set
+type AnchorPriceRequest struct {
+	HomeDomain         string `form:"home_domain" binding:"required"`
+	Context            string `form:"context" binding:"required"`
+	SellAsset          string `form:"sell_asset" binding:"required"`
+	BuyAsset           string `form:"buy_asset" binding:"required"`
+	SellAmount         string `form:"sell_amount"`
+	BuyAmount          string `form:"buy_amount"`
+	SellDeliveryMethod string `form:"sell_delivery_method"`
+	BuyDeliveryMethod  string `form:"buy_delivery_method"`
+	CountryCode        string `form:"country_code"`
+}
+
+// AnchorQuoteRequest represents the request body for a firm SEP-38 quote, which the anchor honours until it
+// expires; exactly one of SellAmount and BuyAmount is set
+type AnchorQuoteRequest struct {
+	HomeDomain         string `json:"home_domain" binding:"required"`
+	Context            string `json:"context" binding:"required"`
+	SellAsset          string `json:"sell_asset" binding:"required"`
+	BuyAsset           string `json:"buy_asset" binding:"required"`
+	SellAmount         string `json:"sell_amount,omitempty"`
+	BuyAmount          string `json:"buy_amount,omitempty"`
+	SellDeliveryMethod string `json:"sell_delivery_method,omitempty"`
+	BuyDeliveryMethod  string `json:"buy_delivery_method,omitempty"`
+	CountryCode        string `json:"country_code,omitempty"`
+	// ExpireAfter asks the anchor to keep the quote open at least until then
+	ExpireAfter *time.Time `json:"expire_after,omitempty"`
+}
+
+// AnchorQuoteFeeDetail is one part of a SEP-38 quote's fee
+type AnchorQuoteFeeDetail struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Amount      string `json:"amount"`
+}
+
+// AnchorQuoteFee is the fee an anchor charges for a conversion, in Asset
+type AnchorQuoteFee struct {
+	Total   string                 `json:"total"`
+	Asset   string                 `json:"asset"`
+	Details []AnchorQuoteFeeDetail `json:"details,omitempty"`
+}
+
+// AnchorPriceResponse is an anchor's indicative price for a conversion. TotalPrice includes the fee; Price
+// does not.
+type AnchorPriceResponse struct {
+	HomeDomain string         `json:"home_domain"`
+	Context    string         `json:"context"`
+	SellAsset  string         `json:"sell_asset"`
+	SellAmount string         `json:"sell_amount"`
+	BuyAsset   string         `json:"buy_asset"`
+	BuyAmount  string         `json:"buy_amount"`
+	Price      string         `json:"price"`
+	TotalPrice string         `json:"total_price"`
+	Fee        AnchorQuoteFee `json:"fee"`
+}
+
+// AnchorQuote is a firm SEP-38 quote an anchor gave a wallet. Its ID is passed as quote_id to the SEP-6 or
+// SEP-31 flow of its context, which may use it once, before it expires.
+type AnchorQuote struct {
+	ID         string `json:"id"`
+	TenantID   string `json:"tenant_id"`
+	Wallet     string `json:"wallet"`
+	HomeDomain string `json:"home_domain"`
+	Context    string `json:"context"`
+	// AnchorQuoteID is the anchor's ID of the quote
+	AnchorQuoteID string         `json:"anchor_quote_id"`
+	SellAsset     string         `json:"sell_asset"`
+	SellAmount    string         `json:"sell_amount"`
+	BuyAsset      string         `json:"buy_asset"`
+	BuyAmount     string         `json:"buy_amount"`
+	Price         string         `json:"price"`
+	TotalPrice    string         `json:"total_price"`
+	Fee           AnchorQuoteFee `json:"fee"`
+	ExpiresAt     time.Time      `json:"expires_at"`
+	// TransactionID is the anchor transaction the quote was used for
+	TransactionID string    `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AnchorQuotesResponse lists a wallet's firm anchor quotes, newest first
+type AnchorQuotesResponse struct {
+	Quotes []AnchorQuote `json:"quotes"`
+}
+
+// AnchorInstruction is one of the instructions an anchor gives for making an off-chain deposit
+type AnchorInstruction struct {
+	Value       string `json:"value"`
+	Description string `json:"description"`
+}
+
+// AnchorTransaction is a deposit or withdrawal a wallet runs with an anchor, as last reported by the anchor
+type AnchorTransaction struct {
+	ID         string `json:"id"`
+	TenantID   string `json:"tenant_id"`
+	Wallet     string `json:"wallet"`
+	HomeDomain string `json:"home_domain"`
+	Protocol   string `json:"protocol"`
+	Kind       string `json:"kind"`
+	Asset      string `json:"asset"`
+	Amount     string `json:"amount,omitempty"`
+	// AnchorTransactionID is the anchor's ID of the transaction
+	AnchorTransactionID string `json:"anchor_transaction_id"`
+	Status              string `json:"status"`
+	Message             string `json:"message,omitempty"`
+	MoreInfoURL         string `json:"more_info_url,omitempty"`
+	// InteractiveURL is the anchor's web flow a SEP-24 user finishes the transaction in; it can be opened once
+	InteractiveURL string `json:"interactive_url,omitempty"`
+	AmountIn       string `json:"amount_in,omitempty"`
+	AmountOut      string `json:"amount_out,omitempty"`
+	AmountFee      string `json:"amount_fee,omitempty"`
+	// How and Instructions tell the user how to make an off-chain deposit
+	How          string                       `json:"how,omitempty"`
+	Instructions map[string]AnchorInstruction `json:"instructions,omitempty"`
+	// WithdrawAnchorAccount and WithdrawMemo are where a withdrawal's or SEP-31 send's payment is sent
+	WithdrawAnchorAccount string `json:"withdraw_anchor_account,omitempty"`
+	WithdrawMemo          string `json:"withdraw_memo,omitempty"`
+	WithdrawMemoType      string `json:"withdraw_memo_type,omitempty"`
+	// PaymentSubmittedAt is when the wallet's payment to the anchor was submitted; PaymentTransactionHash
+	// is its hash, and PaymentError is set if it failed. A payment is never submitted twice, so one
+	// submitted with neither a hash nor an error was interrupted by a restart and needs reconciling.
+	PaymentSubmittedAt     *time.Time `json:"payment_submitted_at,omitempty"`
+	PaymentTransactionHash string     `json:"payment_transaction_hash,omitempty"`
+	PaymentError           string     `json:"payment_error,omitempty"`
+	// StellarTransactionID is the anchor's on-chain transaction, e.g. a deposit's payment to the wallet
+	StellarTransactionID string `json:"stellar_transaction_id,omitempty"`
+	// SenderID and ReceiverID are a SEP-31 send's SEP-12 customers at the receiving anchor
+	SenderID   string `json:"sender_id,omitempty"`
+	ReceiverID string `json:"receiver_id,omitempty"`
+	// QuoteID is the firm quote the transaction converts at
+	QuoteID   string    `json:"quote_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AnchorTransactionsResponse lists a wallet's anchor transactions, newest first
+type AnchorTransactionsResponse struct {
+	Transactions []AnchorTransaction `json:"transactions"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ArchivedTransaction represents the retained artifacts of a submitted transaction
+type ArchivedTransaction struct {
+	Hash        string          `json:"hash"`
+	EnvelopeXDR string          `json:"envelope_xdr"`
+	ResultXDR   string          `json:"result_xdr,omitempty"`
+	Successful  bool            `json:"successful"`
+	Ledger      int32           `json:"ledger,omitempty"`
+	Receipt     json.RawMessage `json:"receipt,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	// Pending is set from just before submission until its outcome is known; a record left pending means
+	// the service stopped or lost Horizon's response mid-submission
+	Pending bool `json:"pending,omitempty"`
+	// Operations holds each operation's ID and effects, or its result code if the transaction failed
+	Operations []OperationResult `json:"operations,omitempty"`
+	ArchivedAt time.Time         `json:"archived_at"`
+}
+
+// FeeBumpRequest represents the optional request body for fee-bumping a stuck transaction
+type FeeBumpRequest struct {
+	// BaseFee is the per-operation fee in stroops the master account offers; it defaults to the p95 of
+	// recent max fees from Horizon fee stats
+	BaseFee int64 `json:"base_fee"`
+}
+
+// FeeBumpResponse represents the API response for a fee-bump resubmission
+type FeeBumpResponse struct {
+	InnerTransactionHash   string `json:"inner_transaction_hash"`
+	FeeBumpTransactionHash string `json:"fee_bump_transaction_hash"`
+	FeeAccount             string `json:"fee_account"`
+	BaseFee                int64  `json:"base_fee_stroops"`
+	// MaxFee is the most the master account pays for the fee bump, in XLM
+	MaxFee string `json:"max_fee"`
+	Ledger int32  `json:"ledger"`
+}
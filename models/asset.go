@@ -0,0 +1,17 @@
+package models
+
+// AssetMetadataResponse represents the API response for asset metadata resolved from the issuer's stellar.toml
+type AssetMetadataResponse struct {
+	Code                   string `json:"code"`
+	Issuer                 string `json:"issuer"`
+	HomeDomain             string `json:"home_domain"`
+	Name                   string `json:"name,omitempty"`
+	Description            string `json:"description,omitempty"`
+	DisplayDecimals        int    `json:"display_decimals"`
+	Image                  string `json:"image,omitempty"`
+	Status                 string `json:"status,omitempty"`
+	OrgName                string `json:"org_name,omitempty"`
+	IsAssetAnchored        bool   `json:"is_asset_anchored"`
+	AnchorAsset            string `json:"anchor_asset,omitempty"`
+	RedemptionInstructions string `json:"redemption_instructions,omitempty"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// AuditEntry is one tamper-evident audit log record. Hash covers the entry and PrevHash, chaining every
+// entry to all entries before it.
+type AuditEntry struct {
+	Sequence int64             `json:"sequence"`
+	Time     time.Time         `json:"time"`
+	Actor    string            `json:"actor"`
+	Action   string            `json:"action"`
+	Subject  string            `json:"subject,omitempty"`
+	Details  map[string]string `json:"details,omitempty"`
+	PrevHash string            `json:"prev_hash"`
+	Hash     string            `json:"hash"`
+}
+
+// AuditBundle is a signed export of the audit entries in a time range
+type AuditBundle struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// PrevHash is the hash of the entry preceding the first exported entry, anchoring the range in the chain
+	PrevHash string       `json:"prev_hash"`
+	Entries  []AuditEntry `json:"entries"`
+	// Signer is the Stellar public key whose Ed25519 signature over the bundle digest is in Signature (base64)
+	Signer    string `json:"signer"`
+	Signature string `json:"signature"`
+}
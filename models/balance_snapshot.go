@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// BalanceSnapshot is a wallet's balances at one point in time
+type BalanceSnapshot struct {
+	TakenAt time.Time `json:"taken_at"`
+	// Balances maps "native", CODE:ISSUER or pool:<id> to the balance held
+	Balances map[string]string `json:"balances"`
+}
+
+// BalanceHistoryResponse represents a wallet's balance snapshots, oldest first
+type BalanceHistoryResponse struct {
+	PublicKey string            `json:"public_key"`
+	Snapshots []BalanceSnapshot `json:"snapshots"`
+}
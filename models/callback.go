@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Transfer callback events
+const (
+	CallbackTransferCompleted = "transfer.completed"
+	CallbackTransferFailed    = "transfer.failed"
+	CallbackTransferRejected  = "transfer.rejected"
+)
+
+// TransferCallback is the signed payload posted to a transfer's callback_url. It carries the
+// X-Webhook-Timestamp and X-Webhook-Signature headers checked by the webhookverify package.
+type TransferCallback struct {
+	ID    string `json:"id"`
+	Event string `json:"event"`
+	// Status is the final transfer status: completed, internal, failed or rejected
+	Status             string    `json:"status"`
+	ExternalID         string    `json:"external_id,omitempty"`
+	ReviewID           string    `json:"review_id,omitempty"`
+	TransactionHash    string    `json:"transaction_hash,omitempty"`
+	InternalTransferID string    `json:"internal_transfer_id,omitempty"`
+	From               string    `json:"from"`
+	To                 string    `json:"to"`
+	Amount             string    `json:"amount"`
+	SourceAsset        string    `json:"source_asset"`
+	DestinationAsset   string    `json:"destination_asset"`
+	Error              string    `json:"error,omitempty"`
+	CreatedAt          time.Time `json:"created_at"`
+
+	// ErrorDetail translates the result codes of a transaction the network rejected
+	ErrorDetail *ResultExplanation `json:"error_detail,omitempty"`
+}
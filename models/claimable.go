@@ -0,0 +1,34 @@
+package models
+
+import "encoding/json"
+
+// ClaimableBalanceClaimant is an account that may claim a balance, with its claim predicate as Horizon reports it
+type ClaimableBalanceClaimant struct {
+	Destination string          `json:"destination"`
+	Predicate   json.RawMessage `json:"predicate"`
+}
+
+// ClaimableBalanceResponse represents a claimable balance awaiting a wallet
+type ClaimableBalanceResponse struct {
+	ID        string                     `json:"id"`
+	Asset     string                     `json:"asset"`
+	Amount    string                     `json:"amount"`
+	Sponsor   string                     `json:"sponsor,omitempty"`
+	Claimants []ClaimableBalanceClaimant `json:"claimants"`
+}
+
+// ClaimBalanceRequest represents the optional request body for claiming a balance
+type ClaimBalanceRequest struct {
+	// SecretKey signs on behalf of the wallet; it may be omitted for a managed wallet when the request is
+	// authenticated as the wallet's tenant
+	SecretKey string `json:"secret_key"`
+}
+
+// ClaimBalanceResponse represents the API response for claiming a balance
+type ClaimBalanceResponse struct {
+	BalanceID       string `json:"balance_id"`
+	Asset           string `json:"asset"`
+	Amount          string `json:"amount"`
+	TransactionHash string `json:"transaction_hash"`
+	Message         string `json:"message"`
+}
@@ -0,0 +1,50 @@
+package models
+
+// CloseWalletRequest represents the request body for closing a wallet
+type CloseWalletRequest struct {
+	// Destination receives the wallet's remaining non-XLM balances
+	Destination string `json:"destination" binding:"required"`
+	// SecretKey signs on behalf of the wallet; it may be omitted for a managed wallet when the request is
+	// authenticated as the wallet's tenant
+	SecretKey string `json:"secret_key"`
+}
+
+// SweptBalance is an asset balance moved out of a wallet
+type SweptBalance struct {
+	Asset  string `json:"asset"`
+	Amount string `json:"amount"`
+	To     string `json:"to"`
+	// Operations are the results of the transaction operations that moved this balance
+	Operations []OperationResult `json:"operations,omitempty"`
+}
+
+// CloseWalletResponse represents the API response for closing a wallet
+type CloseWalletResponse struct {
+	PublicKey       string         `json:"public_key"`
+	TransactionHash string         `json:"transaction_hash"`
+	Swept           []SweptBalance `json:"swept"`
+	MergedInto      string         `json:"merged_into"`
+	Message         string         `json:"message"`
+}
+
+// MergeWalletRequest represents the request body for merging a wallet into another account
+type MergeWalletRequest struct {
+	// Destination receives the wallet's asset balances and its remaining XLM
+	Destination string `json:"destination" binding:"required"`
+	// SecretKey signs on behalf of the wallet; it may be omitted for a managed wallet when the request is
+	// authenticated as the wallet's tenant
+	SecretKey string `json:"secret_key"`
+}
+
+// MergeWalletResponse represents the API response for merging a wallet
+type MergeWalletResponse struct {
+	PublicKey       string `json:"public_key"`
+	TransactionHash string `json:"transaction_hash"`
+	MergedInto      string `json:"merged_into"`
+	// ReclaimedXLM is the XLM balance, reserves included, credited to the destination by the merge
+	ReclaimedXLM       string         `json:"reclaimed_xlm"`
+	CancelledOffers    int            `json:"cancelled_offers"`
+	RemovedDataEntries []string       `json:"removed_data_entries"`
+	Swept              []SweptBalance `json:"swept"`
+	Message            string         `json:"message"`
+}
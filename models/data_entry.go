@@ -0,0 +1,35 @@
+package models
+
+// DataEntry is a key/value entry stored on a wallet's account
+type DataEntry struct {
+	Name string `json:"name"`
+	// Value is the entry decoded as text; it is empty when the value is not valid UTF-8
+	Value string `json:"value,omitempty"`
+	// ValueBase64 is the raw value as stored on the ledger
+	ValueBase64 string `json:"value_base64"`
+}
+
+// DataEntryRequest represents the request body for setting a data entry; exactly one of Value and
+// ValueBase64 is given, and the decoded value is at most 64 bytes
+type DataEntryRequest struct {
+	// SecretKey signs on behalf of the wallet; it may be omitted for a managed wallet when the request is
+	// authenticated as the wallet's tenant
+	SecretKey   string `json:"secret_key"`
+	Value       string `json:"value,omitempty"`
+	ValueBase64 string `json:"value_base64,omitempty"`
+}
+
+// DeleteDataEntryRequest represents the optional request body for deleting a data entry
+type DeleteDataEntryRequest struct {
+	// SecretKey signs on behalf of the wallet; it may be omitted for a managed wallet when the request is
+	// authenticated as the wallet's tenant
+	SecretKey string `json:"secret_key"`
+}
+
+// DataEntryResponse represents the API response for setting or deleting a data entry
+type DataEntryResponse struct {
+	PublicKey       string `json:"public_key"`
+	TransactionHash string `json:"transaction_hash"`
+	DataEntry
+	Deleted bool `json:"deleted,omitempty"`
+}
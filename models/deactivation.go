@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Wallet deactivation states
+const (
+	DeactivationActive   = "deactivated"
+	DeactivationRestored = "restored"
+)
+
+// DeactivateWalletRequest represents the request body for deactivating a wallet
+type DeactivateWalletRequest struct {
+	// Reason records why the wallet is held, e.g. a compliance case reference
+	Reason string `json:"reason" binding:"required"`
+	// SecretKey signs on behalf of the wallet
+	SecretKey string `json:"secret_key" binding:"required"`
+}
+
+// WalletDeactivationResponse represents a wallet's deactivation and the balances held in quarantine
+type WalletDeactivationResponse struct {
+	PublicKey              string         `json:"public_key"`
+	TenantID               string         `json:"tenant_id"`
+	State                  string         `json:"state"`
+	Reason                 string         `json:"reason"`
+	QuarantineAccount      string         `json:"quarantine_account"`
+	Quarantined            []SweptBalance `json:"quarantined"`
+	TransactionHash        string         `json:"transaction_hash"`
+	RestoreTransactionHash string         `json:"restore_transaction_hash,omitempty"`
+	DeactivatedAt          time.Time      `json:"deactivated_at"`
+	RestoredAt             *time.Time     `json:"restored_at,omitempty"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// CallbackDepositReceived is the event of the webhook posted for each new deposit
+const CallbackDepositReceived = "deposit.received"
+
+// Deposit is a payment a custodied wallet received, recorded once per Horizon operation
+type Deposit struct {
+	// ID is the Horizon operation ID of the payment, so a deposit is never credited twice
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+	Wallet   string `json:"wallet"`
+	// Type is the Horizon operation type: payment, a path payment or create_account
+	Type   string `json:"type"`
+	From   string `json:"from"`
+	Asset  string `json:"asset"`
+	Amount string `json:"amount"`
+	// Memo and MemoType identify the user to credit on shared deposit addresses; MuxedID does for muxed ones
+	Memo            string    `json:"memo,omitempty"`
+	MemoType        string    `json:"memo_type,omitempty"`
+	MuxedID         string    `json:"muxed_id,omitempty"`
+	TransactionHash string    `json:"transaction_hash"`
+	CreatedAt       time.Time `json:"created_at"`
+	DetectedAt      time.Time `json:"detected_at"`
+}
+
+// DepositsResponse represents a tenant's deposits, newest first
+type DepositsResponse struct {
+	Deposits []Deposit `json:"deposits"`
+}
+
+// DepositCallback is the signed payload posted to a tenant's deposit webhook. It carries the
+// X-Webhook-Timestamp and X-Webhook-Signature headers checked by the webhookverify package; receivers
+// should deduplicate on Deposit.ID, as delivery is at least once.
+type DepositCallback struct {
+	ID        string    `json:"id"`
+	Event     string    `json:"event"`
+	Deposit   Deposit   `json:"deposit"`
+	CreatedAt time.Time `json:"created_at"`
+}
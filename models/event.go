@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// Event represents a domain event concerning a wallet
+type Event struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	PublicKey string            `json:"public_key"`
+	Data      map[string]string `json:"data,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Event stream actions a client can send
+const (
+	StreamSubscribe   = "subscribe"
+	StreamUnsubscribe = "unsubscribe"
+)
+
+// Event stream message types the server sends
+const (
+	StreamMessageEvent        = "event"
+	StreamMessageSubscribed   = "subscribed"
+	StreamMessageUnsubscribed = "unsubscribed"
+	StreamMessageError        = "error"
+)
+
+// StreamRequest is a message a client sends on the event stream to change its subscriptions
+type StreamRequest struct {
+	Action  string   `json:"action"`
+	Wallets []string `json:"wallets"`
+}
+
+// StreamMessage is a message the server sends on the event stream: an event for a subscribed wallet, or
+// the outcome of a StreamRequest with the wallets now subscribed
+type StreamMessage struct {
+	Type    string   `json:"type"`
+	Event   *Event   `json:"event,omitempty"`
+	Wallets []string `json:"wallets,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// EventSchemaVersion is the version of EventEnvelope published to the external event bus; it changes only
+// when a field is removed or changes meaning
+const EventSchemaVersion = 1
+
+// EventEnvelope is a domain event as published to the external event bus
+type EventEnvelope struct {
+	SchemaVersion int               `json:"schema_version"`
+	ID            string            `json:"id"`
+	Type          string            `json:"type"`
+	Source        string            `json:"source"`
+	TenantID      string            `json:"tenant_id,omitempty"`
+	Wallet        string            `json:"wallet"`
+	Data          map[string]string `json:"data,omitempty"`
+	OccurredAt    time.Time         `json:"occurred_at"`
+}
+
+// OutboxEntry is an event staged in the transactional outbox, waiting to be delivered to in-process
+// subscribers such as webhooks and published to the external event bus
+type OutboxEntry struct {
+	Envelope EventEnvelope `json:"envelope"`
+	// Dispatch is true until the event has been delivered to in-process subscribers
+	Dispatch bool `json:"dispatch"`
+	// Publish is true until the event has been published to the external event bus
+	Publish bool `json:"publish"`
+	// Attempts and LastError record failed publications; NextAttemptAt is when publication is retried
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty"`
+	StagedAt      time.Time `json:"staged_at"`
+	NextAttemptAt time.Time `json:"next_attempt_at,omitempty"`
+}
+
+// OutboxStatusResponse reports the transactional outbox's backlog. Entries lists the oldest pending events,
+// up to 100.
+type OutboxStatusResponse struct {
+	Pending int `json:"pending"`
+	// Failing counts pending events whose last publication attempt failed
+	Failing          int           `json:"failing"`
+	OldestStagedAt   *time.Time    `json:"oldest_staged_at,omitempty"`
+	OldestAgeSeconds float64       `json:"oldest_age_seconds"`
+	Relayed          uint64        `json:"relayed"`
+	Backlogged       bool          `json:"backlogged"`
+	Entries          []OutboxEntry `json:"entries"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// FederationNameRequest represents the request body for giving a wallet a federation name (SEP-2)
+type FederationNameRequest struct {
+	// Name is the part before the "*" of the federation address, e.g. alice in alice*example.com
+	Name string `json:"name" binding:"required"`
+	// Memo and MemoType, when set, are returned with the name so payments to it carry them, e.g. to credit
+	// a user of a shared deposit wallet
+	Memo     string `json:"memo,omitempty"`
+	MemoType string `json:"memo_type,omitempty"`
+}
+
+// FederationName is a federation address served for one of a tenant's wallets
+type FederationName struct {
+	Name           string    `json:"name"`
+	StellarAddress string    `json:"stellar_address"`
+	Wallet         string    `json:"wallet"`
+	TenantID       string    `json:"tenant_id"`
+	Memo           string    `json:"memo,omitempty"`
+	MemoType       string    `json:"memo_type,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// FederationNamesResponse lists a wallet's federation names
+type FederationNamesResponse struct {
+	Names []FederationName `json:"names"`
+}
+
+// FederationRecord is a federation server response: the account and memo a federation address resolves to
+type FederationRecord struct {
+	StellarAddress string `json:"stellar_address,omitempty"`
+	AccountID      string `json:"account_id"`
+	MemoType       string `json:"memo_type,omitempty"`
+	Memo           string `json:"memo,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// FreezeWalletRequest represents the request body for freezing a wallet
+type FreezeWalletRequest struct {
+	// Reason records why the wallet is frozen, e.g. a compliance case reference
+	Reason string `json:"reason" binding:"required"`
+}
+
+// WalletFreezeResponse represents a wallet's freeze state. A frozen wallet keeps its balances, but the
+// service signs nothing for it and refuses its outgoing transfers.
+type WalletFreezeResponse struct {
+	PublicKey  string     `json:"public_key"`
+	Frozen     bool       `json:"frozen"`
+	Reason     string     `json:"reason,omitempty"`
+	FrozenAt   *time.Time `json:"frozen_at,omitempty"`
+	UnfrozenAt *time.Time `json:"unfrozen_at,omitempty"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Invoice statuses
+const (
+	InvoicePending = "pending"
+	InvoicePaid    = "paid"
+	InvoiceExpired = "expired"
+)
+
+// CreateInvoiceRequest represents the request body for creating an invoice
+type CreateInvoiceRequest struct {
+	// Wallet is the custodied wallet that receives the payment
+	Wallet string `json:"wallet" binding:"required"`
+	Amount string `json:"amount" binding:"required"`
+	// Asset is "native" or CODE:ISSUER; it defaults to USDC
+	Asset string `json:"asset"`
+	// Memo is the text memo payers must attach; one is generated when neither it nor UseMuxedID is set
+	Memo string `json:"memo"`
+	// UseMuxedID identifies the invoice by a dedicated muxed (M...) destination instead of a memo
+	UseMuxedID bool       `json:"use_muxed_id"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// InvoiceResponse represents an invoice and its settlement state
+type InvoiceResponse struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+	Wallet   string `json:"wallet"`
+	// Destination is the address payers send to: the wallet itself or its muxed address for this invoice
+	Destination string     `json:"destination"`
+	MuxedID     string     `json:"muxed_id,omitempty"`
+	Memo        string     `json:"memo,omitempty"`
+	Amount      string     `json:"amount"`
+	Asset       string     `json:"asset"`
+	Received    string     `json:"received"`
+	Status      string     `json:"status"`
+	PaymentURI  string     `json:"payment_uri"`
+	Payments    []string   `json:"payments"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	PaidAt      *time.Time `json:"paid_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
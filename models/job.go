@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Job types
+const (
+	JobCreateWallet = "wallet.create"
+	JobTransfer     = "transfer"
+)
+
+// Job statuses, in the order a job moves through them; a held transfer ends in JobPendingReview
+const (
+	JobQueued        = "queued"
+	JobSubmitting    = "submitting"
+	JobConfirmed     = "confirmed"
+	JobPendingReview = "pending_review"
+	JobFailed        = "failed"
+)
+
+// JobResponse represents an asynchronous wallet creation or transfer and its progress
+type JobResponse struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id,omitempty"`
+	Type     string `json:"type"`
+	Status   string `json:"status"`
+	// Attempts counts submissions so far; transient Horizon failures are retried
+	Attempts        int    `json:"attempts"`
+	TransactionHash string `json:"transaction_hash,omitempty"`
+	Error           string `json:"error,omitempty"`
+	// ErrorDetail translates the result codes of a transaction the network rejected
+	ErrorDetail *ResultExplanation `json:"error_detail,omitempty"`
+	// Wallet or Transfer holds the result once the job is confirmed
+	Wallet    *WalletResponse   `json:"wallet,omitempty"`
+	Transfer  *TransferResponse `json:"transfer,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// SEP-12 customer statuses an anchor reports
+const (
+	KYCStatusAccepted   = "ACCEPTED"
+	KYCStatusProcessing = "PROCESSING"
+	KYCStatusNeedsInfo  = "NEEDS_INFO"
+	KYCStatusRejected   = "REJECTED"
+)
+
+// KYCFieldsRequest represents the request body for setting a wallet's SEP-9 customer fields
+type KYCFieldsRequest struct {
+	// Fields maps SEP-9 field names, e.g. first_name or email_address, to their values; an empty value
+	// removes the field. Binary fields such as photo_id_front are uploaded as files instead.
+	Fields map[string]string `json:"fields" binding:"required"`
+}
+
+// KYCSubmitRequest represents the request body for submitting a wallet's customer fields to an anchor
+type KYCSubmitRequest struct {
+	HomeDomain string `json:"home_domain" binding:"required"`
+	// Type is the anchor's SEP-12 customer type, e.g. sep31-sender; empty uses the anchor's default
+	Type string `json:"type,omitempty"`
+	// Fields limits the submission to these fields and files; empty submits everything stored
+	Fields []string `json:"fields,omitempty"`
+}
+
+// KYCFile describes a document uploaded for a binary SEP-9 field
+type KYCFile struct {
+	Field       string    `json:"field"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+}
+
+// KYCProvidedField is an anchor's verdict on a field it was given
+type KYCProvidedField struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// KYCAnchorCustomer is a wallet's customer record at one anchor, as last reported by the anchor
+type KYCAnchorCustomer struct {
+	HomeDomain string `json:"home_domain"`
+	// CustomerID is the anchor's ID of the customer
+	CustomerID string `json:"customer_id"`
+	Type       string `json:"type,omitempty"`
+	Status     string `json:"status"`
+	Message    string `json:"message,omitempty"`
+	// MissingFields lists the fields the anchor still needs
+	MissingFields  []string                    `json:"missing_fields,omitempty"`
+	ProvidedFields map[string]KYCProvidedField `json:"provided_fields,omitempty"`
+	SubmittedAt    time.Time                   `json:"submitted_at"`
+	CheckedAt      time.Time                   `json:"checked_at"`
+}
+
+// KYCCustomer is the SEP-9 customer information stored for a wallet and its status at each anchor it was
+// submitted to
+type KYCCustomer struct {
+	Wallet   string             `json:"wallet"`
+	TenantID string             `json:"tenant_id"`
+	Fields   map[string]string  `json:"fields"`
+	Files    map[string]KYCFile `json:"files"`
+	// Anchors maps each anchor's home domain to the customer's record there
+	Anchors   map[string]KYCAnchorCustomer `json:"anchors"`
+	UpdatedAt time.Time                    `json:"updated_at"`
+}
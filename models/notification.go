@@ -0,0 +1,57 @@
+package models
+
+// Event types that can trigger wallet notifications
+const (
+	EventWalletCreated     = "wallet.created"
+	EventPaymentReceived   = "payment.received"
+	EventPaymentSent       = "payment.sent"
+	EventTransactionFailed = "transaction.failed"
+
+	EventRecurringChargeSucceeded = "recurring.charge_succeeded"
+	EventRecurringChargeFailed    = "recurring.charge_failed"
+
+	EventInvoicePaid = "invoice.paid"
+
+	// EventAnchorTransactionUpdated is an anchor deposit or withdrawal changing status
+	EventAnchorTransactionUpdated = "anchor.transaction_updated"
+	// EventKYCStatusChanged is an anchor changing a wallet's SEP-12 customer status
+	EventKYCStatusChanged = "kyc.status_changed"
+)
+
+// Event types not configurable for notifications, delivered to event stream subscribers and the external
+// event bus
+const (
+	EventBalanceChanged       = "balance.changed"
+	EventTransactionConfirmed = "transaction.confirmed"
+	EventTransferSubmitted    = "transfer.submitted"
+	EventTransferConfirmed    = "transfer.confirmed"
+	EventDepositDetected      = "deposit.detected"
+)
+
+// Notification channels an event can be delivered through
+const (
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+	ChannelSMS     = "sms"
+	ChannelNone    = "none"
+)
+
+// EventTypes lists every event type a wallet can configure notifications for
+var EventTypes = []string{
+	EventWalletCreated, EventPaymentReceived, EventPaymentSent, EventTransactionFailed,
+	EventRecurringChargeSucceeded, EventRecurringChargeFailed, EventInvoicePaid, EventAnchorTransactionUpdated,
+	EventKYCStatusChanged,
+}
+
+// NotificationPreferencesRequest represents the request body for updating a wallet's notification preferences
+type NotificationPreferencesRequest struct {
+	Preferences map[string]string `json:"preferences" binding:"required"`
+}
+
+// NotificationPreferencesResponse represents the effective notification preferences of a wallet
+type NotificationPreferencesResponse struct {
+	PublicKey         string            `json:"public_key"`
+	TenantID          string            `json:"tenant_id"`
+	Preferences       map[string]string `json:"preferences"`
+	AvailableChannels []string          `json:"available_channels"`
+}
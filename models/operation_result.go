@@ -0,0 +1,24 @@
+package models
+
+import "encoding/json"
+
+// OperationResult is the outcome of one operation of a submitted transaction, in application order
+type OperationResult struct {
+	Index       int    `json:"index"`
+	OperationID string `json:"operation_id,omitempty"`
+	Type        string `json:"type,omitempty"`
+	// ResultCode is Horizon's result code for the operation when the transaction failed
+	ResultCode string            `json:"result_code,omitempty"`
+	Effects    []json.RawMessage `json:"effects,omitempty"`
+}
+
+// ResultExplanation translates a Stellar result code into a stable error code for clients to branch on,
+// with an explanation and a suggested remediation for people
+type ResultExplanation struct {
+	// Code is stable across releases, e.g. insufficient_balance for op_underfunded
+	Code string `json:"code"`
+	// ResultCode is the Stellar result code that was translated
+	ResultCode  string `json:"result_code"`
+	Explanation string `json:"explanation"`
+	Remediation string `json:"remediation"`
+}
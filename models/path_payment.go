@@ -0,0 +1,53 @@
+package models
+
+// StrictSendRequest represents the request body for a path payment that spends an exact source amount
+type StrictSendRequest struct {
+	FromSecretKey    string `json:"from_secret_key" binding:"required"`
+	ToPublicKey      string `json:"to_public_key" binding:"required"`
+	SourceAsset      string `json:"source_asset" binding:"required"`
+	SendAmount       string `json:"send_amount" binding:"required"`
+	DestinationAsset string `json:"destination_asset" binding:"required"`
+	// DestMin is the least the recipient must receive; when omitted it is derived from the best quoted
+	// path less MaxSlippagePercent
+	DestMin            string `json:"dest_min"`
+	MaxSlippagePercent string `json:"max_slippage_percent"`
+	// Memo is attached to the transaction; MemoType is "text" (the default), "id" or "hash" (hex encoded).
+	// Recipients that require a memo under SEP-29 are refused a payment without one.
+	Memo     string `json:"memo,omitempty"`
+	MemoType string `json:"memo_type,omitempty"`
+
+	// Device describes the client that initiated the payment; it is filled from request headers
+	Device DeviceInfo `json:"-"`
+}
+
+// StrictReceiveRequest represents the request body for a path payment that delivers an exact destination amount
+type StrictReceiveRequest struct {
+	FromSecretKey    string `json:"from_secret_key" binding:"required"`
+	ToPublicKey      string `json:"to_public_key" binding:"required"`
+	SourceAsset      string `json:"source_asset" binding:"required"`
+	DestinationAsset string `json:"destination_asset" binding:"required"`
+	DestAmount       string `json:"dest_amount" binding:"required"`
+	// SendMax caps what the sender may spend; when omitted it is derived from the best quoted path plus
+	// MaxSlippagePercent
+	SendMax            string `json:"send_max"`
+	MaxSlippagePercent string `json:"max_slippage_percent"`
+	// Memo and MemoType are as in StrictSendRequest
+	Memo     string `json:"memo,omitempty"`
+	MemoType string `json:"memo_type,omitempty"`
+
+	// Device describes the client that initiated the payment; it is filled from request headers
+	Device DeviceInfo `json:"-"`
+}
+
+// PathPaymentResponse represents the API response for a path payment
+type PathPaymentResponse struct {
+	TransactionHash  string   `json:"transaction_hash"`
+	SourceAsset      string   `json:"source_asset"`
+	DestinationAsset string   `json:"destination_asset"`
+	SendAmount       string   `json:"send_amount,omitempty"`
+	DestMin          string   `json:"dest_min,omitempty"`
+	DestAmount       string   `json:"dest_amount,omitempty"`
+	SendMax          string   `json:"send_max,omitempty"`
+	Path             []string `json:"path"`
+	Message          string   `json:"message"`
+}
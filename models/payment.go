@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// Payment directions relative to a wallet
+const (
+	DirectionSent     = "sent"
+	DirectionReceived = "received"
+)
+
+// PaymentRecord represents a payment, path payment or account creation touching a wallet
+type PaymentRecord struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Direction string `json:"direction"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	// Counterparty is the other side of the payment: the recipient of sent payments, the sender of received ones
+	Counterparty    string    `json:"counterparty"`
+	Asset           string    `json:"asset"`
+	Amount          string    `json:"amount"`
+	TransactionHash string    `json:"transaction_hash"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// PaymentHistoryFilter narrows a wallet's payment history; empty fields match everything
+type PaymentHistoryFilter struct {
+	// Asset is "native" or CODE:ISSUER
+	Asset string
+	// Direction is DirectionSent or DirectionReceived
+	Direction    string
+	Counterparty string
+	// From and To bound the payment time as RFC 3339 timestamps, inclusive
+	From string
+	To   string
+	// MinAmount and MaxAmount bound the payment amount, inclusive
+	MinAmount string
+	MaxAmount string
+	// Cursor resumes after the last payment of a previous page
+	Cursor string
+	Limit  int
+}
+
+// PaymentHistoryResponse represents a page of a wallet's payment history, newest first
+type PaymentHistoryResponse struct {
+	Payments []PaymentRecord `json:"payments"`
+	// NextCursor continues the history after this page; it is empty once the history is exhausted
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// OperationRecord represents any operation touching a wallet, with its type-specific fields in Details
+type OperationRecord struct {
+	ID              string    `json:"id"`
+	Type            string    `json:"type"`
+	SourceAccount   string    `json:"source_account"`
+	TransactionHash string    `json:"transaction_hash"`
+	Successful      bool      `json:"successful"`
+	CreatedAt       time.Time `json:"created_at"`
+	// Details holds Horizon's fields for the operation type, e.g. trustor and limit for change_trust
+	Details map[string]interface{} `json:"details"`
+}
+
+// OperationsResponse represents a page of a wallet's operations, newest first
+type OperationsResponse struct {
+	Operations []OperationRecord `json:"operations"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// EffectRecord represents a change an operation made to a wallet's account, such as a trustline or signer
+// change or a trade, with its type-specific fields in Details
+type EffectRecord struct {
+	ID          string                 `json:"id"`
+	Type        string                 `json:"type"`
+	Account     string                 `json:"account"`
+	OperationID string                 `json:"operation_id"`
+	CreatedAt   time.Time              `json:"created_at"`
+	Details     map[string]interface{} `json:"details"`
+}
+
+// EffectsResponse represents a page of a wallet's effects, newest first
+type EffectsResponse struct {
+	Effects    []EffectRecord `json:"effects"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
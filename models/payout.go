@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Payout batch statuses
+const (
+	PayoutBatchQueued              = "queued"
+	PayoutBatchProcessing          = "processing"
+	PayoutBatchCompleted           = "completed"
+	PayoutBatchCompletedWithErrors = "completed_with_errors"
+	PayoutBatchFailed              = "failed"
+)
+
+// Payout row statuses
+const (
+	PayoutRowInvalid   = "invalid"
+	PayoutRowQueued    = "queued"
+	PayoutRowSucceeded = "succeeded"
+	PayoutRowFailed    = "failed"
+)
+
+// CreatePayoutRequest represents the form fields accompanying a payout CSV upload
+type CreatePayoutRequest struct {
+	FromPublicKey string `form:"from_public_key" binding:"required"`
+	// FromSecretKey signs the payouts; it may be omitted for a managed wallet when the request is
+	// authenticated as the wallet's tenant
+	FromSecretKey string `form:"from_secret_key"`
+}
+
+// PayoutRow is one line of a payout CSV and its outcome
+type PayoutRow struct {
+	Line            int    `json:"line"`
+	Destination     string `json:"destination"`
+	Amount          string `json:"amount"`
+	Asset           string `json:"asset"`
+	Status          string `json:"status"`
+	Error           string `json:"error,omitempty"`
+	TransactionHash string `json:"transaction_hash,omitempty"`
+	OperationID     string `json:"operation_id,omitempty"`
+	ResultCode      string `json:"result_code,omitempty"`
+	// ErrorDetail translates the result code of a failed row
+	ErrorDetail *ResultExplanation `json:"error_detail,omitempty"`
+}
+
+// PayoutBatchResponse represents a bulk payout batch and the status of each of its rows
+type PayoutBatchResponse struct {
+	ID           string      `json:"id"`
+	TenantID     string      `json:"tenant_id"`
+	From         string      `json:"from"`
+	Status       string      `json:"status"`
+	TotalRows    int         `json:"total_rows"`
+	InvalidRows  int         `json:"invalid_rows"`
+	Succeeded    int         `json:"succeeded"`
+	Failed       int         `json:"failed"`
+	Transactions []string    `json:"transactions"`
+	Rows         []PayoutRow `json:"rows"`
+	CreatedAt    time.Time   `json:"created_at"`
+	CompletedAt  *time.Time  `json:"completed_at,omitempty"`
+}
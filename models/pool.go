@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Pooled sub-account transfer statuses
+const (
+	SubAccountTransferSubmitted = "submitted"
+	SubAccountTransferInternal  = "internal"
+)
+
+// CreateSubAccountRequest represents the request body for allocating a muxed sub-account on a pooled wallet
+type CreateSubAccountRequest struct {
+	// PoolWallet is the custodied wallet whose account holds the funds of all its sub-accounts
+	PoolWallet string `json:"pool_wallet" binding:"required"`
+	// UserID is the tenant's identifier for the user the sub-account belongs to
+	UserID string `json:"user_id" binding:"required"`
+}
+
+// SubAccountResponse represents a muxed sub-account of a pooled wallet and its balances
+type SubAccountResponse struct {
+	// Address is the muxed (M...) address the user receives payments on
+	Address    string `json:"address"`
+	MuxedID    string `json:"muxed_id"`
+	PoolWallet string `json:"pool_wallet"`
+	TenantID   string `json:"tenant_id"`
+	UserID     string `json:"user_id"`
+	// Balances maps "native" or CODE:ISSUER to the amount attributed to the sub-account
+	Balances  map[string]string `json:"balances"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// SubAccountTransferRequest represents the request body for a transfer out of a sub-account
+type SubAccountTransferRequest struct {
+	// Destination is a G... account or an M... address; a sub-account of the same pool is credited without a transaction
+	Destination string `json:"destination" binding:"required"`
+	Amount      string `json:"amount" binding:"required"`
+	// Asset is "native" or CODE:ISSUER; it defaults to USDC
+	Asset string `json:"asset"`
+	// Memo and MemoType are attached to an on-chain transfer, as in TransferRequest
+	Memo     string `json:"memo,omitempty"`
+	MemoType string `json:"memo_type,omitempty"`
+
+	// Device describes the client that initiated the transfer; it is filled from request headers
+	Device DeviceInfo `json:"-"`
+}
+
+// SubAccountTransferResponse represents the result of a transfer out of a sub-account
+type SubAccountTransferResponse struct {
+	Status          string `json:"status"`
+	From            string `json:"from"`
+	Destination     string `json:"destination"`
+	Amount          string `json:"amount"`
+	Asset           string `json:"asset"`
+	TransactionHash string `json:"transaction_hash,omitempty"`
+	// Balance is the sub-account's remaining balance in the asset
+	Balance string `json:"balance"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// PortfolioAsset is one balance of a wallet's portfolio and its value in the portfolio's currency. Price and
+// Value are empty, and Error says why, when the asset could not be priced.
+type PortfolioAsset struct {
+	Asset   string `json:"asset"`
+	Balance string `json:"balance"`
+	Price   string `json:"price,omitempty"`
+	Value   string `json:"value,omitempty"`
+	// Share is the asset's percentage of the priced total
+	Share string `json:"share,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// PortfolioResponse represents a wallet's balances valued in a fiat currency
+type PortfolioResponse struct {
+	PublicKey string           `json:"public_key"`
+	Currency  string           `json:"currency"`
+	Total     string           `json:"total"`
+	Assets    []PortfolioAsset `json:"assets"`
+	// Complete is false when some assets could not be priced and so are missing from Total
+	Complete bool      `json:"complete"`
+	ValuedAt time.Time `json:"valued_at"`
+}
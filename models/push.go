@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Push notification platforms a device token can belong to
+const (
+	PushPlatformFCM  = "fcm"
+	PushPlatformAPNs = "apns"
+)
+
+// PushDeviceRequest represents the request body for linking a device to a wallet for push notifications
+type PushDeviceRequest struct {
+	// Platform is fcm for Firebase Cloud Messaging or apns for Apple Push Notification service
+	Platform string `json:"platform" binding:"required"`
+	Token    string `json:"token" binding:"required"`
+}
+
+// PushDevice represents a device token linked to a wallet
+type PushDevice struct {
+	ID        string    `json:"id"`
+	Wallet    string    `json:"wallet"`
+	TenantID  string    `json:"tenant_id"`
+	Platform  string    `json:"platform"`
+	Token     string    `json:"token"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PushDevicesResponse lists the devices linked to a wallet
+type PushDevicesResponse struct {
+	Devices []PushDevice `json:"devices"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Quote modes: spend an exact source amount, or deliver an exact destination amount
+const (
+	QuoteModeSend    = "send"
+	QuoteModeReceive = "receive"
+)
+
+// QuoteRequest represents the query of the quotes endpoint
+type QuoteRequest struct {
+	// From and To are "native", XLM, CODE:ISSUER or a bare code of USDC or an allowlisted asset
+	From   string
+	To     string
+	Amount string
+	// Mode is "send" (the default), where Amount is spent in From, or "receive", where Amount is delivered in To
+	Mode               string
+	MaxSlippagePercent string
+	// IncludePools also quotes the AMM liquidity pool holding exactly From and To, if there is one
+	IncludePools bool
+}
+
+// QuoteResponse represents an indicative cross-currency quote from Horizon path finding
+type QuoteResponse struct {
+	From              string `json:"from"`
+	To                string `json:"to"`
+	Mode              string `json:"mode"`
+	SourceAmount      string `json:"source_amount"`
+	DestinationAmount string `json:"destination_amount"`
+	// Rate is the amount of To received per unit of From
+	Rate string `json:"rate"`
+	// Path lists the intermediate assets between From and To; it is empty for a direct conversion
+	Path []string `json:"path"`
+	// PriceImpactPercent estimates how much worse Rate is than the rate of a marginal amount
+	PriceImpactPercent string `json:"price_impact_percent"`
+	// SlippagePercent is the tolerance DestMin or SendMax allows; passing them to the matching path payment
+	// endpoint locks the quote in
+	SlippagePercent string              `json:"slippage_percent"`
+	DestMin         string              `json:"dest_min,omitempty"`
+	SendMax         string              `json:"send_max,omitempty"`
+	LiquidityPool   *LiquidityPoolQuote `json:"liquidity_pool,omitempty"`
+	QuotedAt        time.Time           `json:"quoted_at"`
+	ExpiresAt       time.Time           `json:"expires_at"`
+}
+
+// LiquidityPoolQuote is the constant-product quote of a single AMM pool between the two assets
+type LiquidityPoolQuote struct {
+	PoolID            string `json:"pool_id"`
+	FeeBP             uint32 `json:"fee_bp"`
+	SourceAmount      string `json:"source_amount"`
+	DestinationAmount string `json:"destination_amount"`
+	Rate              string `json:"rate"`
+}
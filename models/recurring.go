@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// Recurring payment plan statuses
+const (
+	RecurringActive    = "active"
+	RecurringCancelled = "cancelled"
+	RecurringCompleted = "completed"
+)
+
+// Recurring charge statuses
+const (
+	ChargeSucceeded = "succeeded"
+	ChargeRetrying  = "retrying"
+	ChargeFailed    = "failed"
+)
+
+// Named recurring payment intervals; any Go duration of at least a minute is also accepted
+const (
+	IntervalDaily   = "daily"
+	IntervalWeekly  = "weekly"
+	IntervalMonthly = "monthly"
+)
+
+// CreateRecurringPlanRequest represents the request body for creating a recurring payment plan
+type CreateRecurringPlanRequest struct {
+	// Wallet is the custodied wallet the plan pays from
+	Wallet string `json:"wallet" binding:"required"`
+	// SecretKey is the wallet's secret key, required unless the request is authenticated as the wallet's
+	// tenant; it only authorizes the plan and is not stored
+	SecretKey   string `json:"secret_key,omitempty"`
+	Destination string `json:"destination" binding:"required"`
+	Amount      string `json:"amount" binding:"required"`
+	// Asset is "native" or CODE:ISSUER; it defaults to USDC
+	Asset    string `json:"asset"`
+	Interval string `json:"interval" binding:"required"`
+	// StartAt is the first charge time; it defaults to now
+	StartAt *time.Time `json:"start_at"`
+	// EndAt, when set, stops the plan before any charge scheduled after it
+	EndAt *time.Time `json:"end_at"`
+}
+
+// RecurringCharge records one billing cycle of a recurring payment plan
+type RecurringCharge struct {
+	Cycle           int        `json:"cycle"`
+	ScheduledAt     time.Time  `json:"scheduled_at"`
+	Status          string     `json:"status"`
+	Attempts        int        `json:"attempts"`
+	TransactionHash string     `json:"transaction_hash,omitempty"`
+	Error           string     `json:"error,omitempty"`
+	ChargedAt       *time.Time `json:"charged_at,omitempty"`
+
+	// ErrorDetail translates the result codes of a charge the network rejected
+	ErrorDetail *ResultExplanation `json:"error_detail,omitempty"`
+}
+
+// RecurringPlanResponse represents a recurring payment plan and its charge history
+type RecurringPlanResponse struct {
+	ID           string            `json:"id"`
+	TenantID     string            `json:"tenant_id"`
+	Wallet       string            `json:"wallet"`
+	Destination  string            `json:"destination"`
+	Amount       string            `json:"amount"`
+	Asset        string            `json:"asset"`
+	Interval     string            `json:"interval"`
+	Status       string            `json:"status"`
+	NextChargeAt *time.Time        `json:"next_charge_at,omitempty"`
+	EndAt        *time.Time        `json:"end_at,omitempty"`
+	Charges      []RecurringCharge `json:"charges"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
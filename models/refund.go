@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Refund states, in the order a refund moves through them
+const (
+	RefundRequested = "requested"
+	RefundApproved  = "approved"
+	RefundRejected  = "rejected"
+	RefundExecuted  = "executed"
+	RefundSettled   = "settled"
+)
+
+// Parties that may initiate a refund
+const (
+	RefundInitiatorSender    = "sender"
+	RefundInitiatorRecipient = "recipient"
+	RefundInitiatorOperator  = "operator"
+)
+
+// CreateRefundRequest represents the request body for requesting a refund of a payment
+type CreateRefundRequest struct {
+	TransactionHash string `json:"transaction_hash" binding:"required"`
+	// Amount to refund; empty refunds the remaining refundable amount in full
+	Amount    string `json:"amount"`
+	Initiator string `json:"initiator" binding:"required"`
+	Reason    string `json:"reason"`
+	// FromSecretKey proves control of the original recipient's wallet; it may be omitted for managed wallets
+	// of the authenticated tenant
+	FromSecretKey string `json:"from_secret_key"`
+}
+
+// RefundPaymentRequest represents the request body for refunding a payment directly by its transaction hash
+type RefundPaymentRequest struct {
+	// Amount to refund; empty refunds the remaining refundable amount in full
+	Amount string `json:"amount"`
+	Reason string `json:"reason"`
+	// FromSecretKey signs the refund on behalf of the original recipient; it may be omitted for managed wallets
+	FromSecretKey string `json:"from_secret_key"`
+}
+
+// ExecuteRefundRequest represents the request body for executing an approved refund
+type ExecuteRefundRequest struct {
+	// FromSecretKey signs the refund on behalf of the original recipient; it may be omitted for managed wallets
+	FromSecretKey string `json:"from_secret_key"`
+}
+
+// RefundResponse represents a refund and its current state
+type RefundResponse struct {
+	ID                      string    `json:"id"`
+	TenantID                string    `json:"tenant_id"`
+	State                   string    `json:"state"`
+	OriginalTransactionHash string    `json:"original_transaction_hash"`
+	OriginalAmount          string    `json:"original_amount"`
+	Asset                   string    `json:"asset"`
+	Amount                  string    `json:"amount"`
+	From                    string    `json:"from"`
+	To                      string    `json:"to"`
+	Initiator               string    `json:"initiator"`
+	Reason                  string    `json:"reason,omitempty"`
+	RefundTransactionHash   string    `json:"refund_transaction_hash,omitempty"`
+	CreatedAt               time.Time `json:"created_at"`
+	UpdatedAt               time.Time `json:"updated_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// WalletReserveResponse breaks down the XLM a wallet must keep locked under current network parameters
+type WalletReserveResponse struct {
+	PublicKey string `json:"public_key"`
+	// BaseReserve is the network's reserve per ledger entry, in XLM
+	BaseReserve   string `json:"base_reserve"`
+	SubentryCount int32  `json:"subentry_count"`
+	// NumSponsoring and NumSponsored adjust the reserve for entries the wallet pays for others, or others
+	// pay for the wallet
+	NumSponsoring uint32 `json:"num_sponsoring"`
+	NumSponsored  uint32 `json:"num_sponsored"`
+	// MinimumBalance is (2 + subentries + sponsoring - sponsored) base reserves
+	MinimumBalance string `json:"minimum_balance"`
+	NativeBalance  string `json:"native_balance"`
+	// Available is the XLM the wallet can send: its balance less the minimum balance and selling liabilities
+	Available string `json:"available"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Transfer review statuses
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+// TransferReviewResponse represents a transfer held for manual review
+type TransferReviewResponse struct {
+	ID               string    `json:"id"`
+	Status           string    `json:"status"`
+	Score            float64   `json:"score"`
+	Reasons          []string  `json:"reasons,omitempty"`
+	From             string    `json:"from"`
+	To               string    `json:"to"`
+	Amount           string    `json:"amount"`
+	SourceAsset      string    `json:"source_asset"`
+	DestinationAsset string    `json:"destination_asset"`
+	TransactionHash  string    `json:"transaction_hash,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	DecidedAt        time.Time `json:"decided_at,omitempty"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Inbound payment routing actions
+const (
+	RoutingActionForward = "forward"
+	RoutingActionConvert = "convert"
+	RoutingActionBounce  = "bounce"
+)
+
+// RoutingRule is a tenant rule applied to payments received by its managed wallets
+type RoutingRule struct {
+	ID string `json:"id"`
+	// Wallet limits the rule to one wallet; empty applies it to every wallet of the tenant
+	Wallet string `json:"wallet,omitempty"`
+	// Asset limits the rule to payments in one asset ("native" or CODE:ISSUER); empty matches any asset
+	Asset  string `json:"asset,omitempty"`
+	Action string `json:"action"`
+	// Percent and Destination configure forward rules: Percent of each payment is sent to Destination
+	Percent     float64 `json:"percent,omitempty"`
+	Destination string  `json:"destination,omitempty"`
+	// TargetAsset configures convert rules: the rest of each payment is converted into TargetAsset
+	TargetAsset string `json:"target_asset,omitempty"`
+	// AllowedAssets configures bounce rules: payments in any other asset are returned to their sender
+	AllowedAssets []string `json:"allowed_assets,omitempty"`
+}
+
+// RoutingRulesRequest represents the request body for replacing a tenant's routing rules
+type RoutingRulesRequest struct {
+	Rules []RoutingRule `json:"rules"`
+}
+
+// RoutingRulesResponse represents a tenant's routing rules, evaluated in order
+type RoutingRulesResponse struct {
+	TenantID string        `json:"tenant_id"`
+	Rules    []RoutingRule `json:"rules"`
+}
+
+// RoutingExecution records one action a routing rule took on an incoming payment
+type RoutingExecution struct {
+	ID              string    `json:"id"`
+	TenantID        string    `json:"tenant_id"`
+	RuleID          string    `json:"rule_id"`
+	Action          string    `json:"action"`
+	Wallet          string    `json:"wallet"`
+	PaymentID       string    `json:"payment_id"`
+	Asset           string    `json:"asset"`
+	Amount          string    `json:"amount"`
+	Destination     string    `json:"destination,omitempty"`
+	TargetAsset     string    `json:"target_asset,omitempty"`
+	TransactionHash string    `json:"transaction_hash,omitempty"`
+	Error           string    `json:"error,omitempty"`
+	ExecutedAt      time.Time `json:"executed_at"`
+
+	// ErrorDetail translates the result codes of an execution the network rejected
+	ErrorDetail *ResultExplanation `json:"error_detail,omitempty"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// SandboxWallet represents a pre-funded testnet wallet shared with sandbox users
+type SandboxWallet struct {
+	PublicKey string `json:"public_key"`
+	SecretKey string `json:"secret_key"`
+}
+
+// SandboxResponse documents how to use the developer sandbox tenant
+type SandboxResponse struct {
+	TenantID    string          `json:"tenant_id"`
+	Header      string          `json:"header"`
+	Network     string          `json:"network"`
+	Wallets     []SandboxWallet `json:"wallets"`
+	LastResetAt *time.Time      `json:"last_reset_at,omitempty"`
+	NextResetAt time.Time       `json:"next_reset_at"`
+	Message     string          `json:"message"`
+}
@@ -0,0 +1,37 @@
+package models
+
+// Payment search match kinds
+const (
+	SearchMatchMemo       = "memo"
+	SearchMatchExternalID = "external_id"
+)
+
+// PaymentSearchQuery represents the filters of a payment search; Memo or ExternalID is required
+type PaymentSearchQuery struct {
+	// Memo matches the text or ID memo of the payment's transaction exactly
+	Memo string
+	// ExternalID matches the external_id a transfer was submitted with
+	ExternalID string
+	// Wallet limits the search to one of the tenant's wallets
+	Wallet string
+	// Since bounds how far back memos are searched, as an RFC 3339 timestamp; it defaults to 30 days ago
+	Since string
+}
+
+// PaymentSearchResult is a payment found by a search, seen from the managed wallet it touched
+type PaymentSearchResult struct {
+	Wallet     string        `json:"wallet"`
+	MatchedOn  string        `json:"matched_on"`
+	Memo       string        `json:"memo,omitempty"`
+	MemoType   string        `json:"memo_type,omitempty"`
+	ExternalID string        `json:"external_id,omitempty"`
+	Payment    PaymentRecord `json:"payment"`
+}
+
+// PaymentSearchResponse represents the payments matching a search, newest first
+type PaymentSearchResponse struct {
+	Results []PaymentSearchResult `json:"results"`
+	// Truncated is set when the search stopped at the result limit or the per-wallet scan limit before
+	// reaching Since, so older matches may exist
+	Truncated bool `json:"truncated"`
+}
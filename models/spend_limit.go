@@ -0,0 +1,32 @@
+package models
+
+// SpendLimit caps what a wallet may send of one asset, measured in the asset debited from it; empty amounts
+// are unlimited
+type SpendLimit struct {
+	PerTransaction string `json:"per_transaction,omitempty"`
+	// Daily and Monthly cap the total sent per UTC calendar day and month
+	Daily   string `json:"daily,omitempty"`
+	Monthly string `json:"monthly,omitempty"`
+}
+
+// SpendLimitsRequest represents the request body for overriding a wallet's spend limits
+type SpendLimitsRequest struct {
+	// Limits maps "native" or CODE:ISSUER to the wallet's limits for that asset
+	Limits map[string]SpendLimit `json:"limits" binding:"required"`
+}
+
+// SpendUsage is a wallet's spending of one asset in the current day and month
+type SpendUsage struct {
+	Asset          string `json:"asset"`
+	SpentToday     string `json:"spent_today"`
+	SpentThisMonth string `json:"spent_this_month"`
+}
+
+// SpendLimitsResponse represents a wallet's effective spend limits and current usage
+type SpendLimitsResponse struct {
+	PublicKey string                `json:"public_key"`
+	Limits    map[string]SpendLimit `json:"limits"`
+	Usage     []SpendUsage          `json:"usage"`
+	// Default is true when the wallet uses the service-wide limits rather than an admin override
+	Default bool `json:"default"`
+}
@@ -0,0 +1,40 @@
+package models
+
+// SplitShare is one recipient of a split transfer, receiving either a percentage of the total or a fixed amount
+type SplitShare struct {
+	Destination string `json:"destination" binding:"required"`
+	// Percent is the share of the total, e.g. "97" or "2.5"
+	Percent string `json:"percent,omitempty"`
+	Amount  string `json:"amount,omitempty"`
+}
+
+// SplitTransferRequest represents the request body for splitting one debit across several recipients
+type SplitTransferRequest struct {
+	FromSecretKey string `json:"from_secret_key" binding:"required"`
+	// Amount is the total debited from the sender; fixed shares plus percentage shares must add up to it
+	Amount string `json:"amount" binding:"required"`
+	// Asset is "native" or CODE:ISSUER; it defaults to USDC
+	Asset    string       `json:"asset,omitempty"`
+	Splits   []SplitShare `json:"splits" binding:"required"`
+	Memo     string       `json:"memo,omitempty"`
+	MemoType string       `json:"memo_type,omitempty"`
+
+	// Device describes the client that initiated the transfer; it is filled from request headers
+	Device DeviceInfo `json:"-"`
+}
+
+// SplitResult is the amount delivered to one recipient of a split transfer
+type SplitResult struct {
+	Destination string `json:"destination"`
+	Amount      string `json:"amount"`
+	OperationID string `json:"operation_id,omitempty"`
+}
+
+// SplitTransferResponse represents the API response for a split transfer
+type SplitTransferResponse struct {
+	TransactionHash string        `json:"transaction_hash"`
+	Asset           string        `json:"asset"`
+	Amount          string        `json:"amount"`
+	Splits          []SplitResult `json:"splits"`
+	Message         string        `json:"message"`
+}
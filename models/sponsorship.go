@@ -0,0 +1,49 @@
+package models
+
+// Ledger entry types reported by the sponsorship endpoints
+const (
+	SponsoredEntryAccount   = "account"
+	SponsoredEntryTrustline = "trustline"
+	SponsoredEntrySigner    = "signer"
+)
+
+// SponsoredEntry is one of a wallet's ledger entries and the account paying its reserve
+type SponsoredEntry struct {
+	// Type is account, trustline or signer
+	Type string `json:"type"`
+	// ID is the wallet's public key for the account entry, CODE:ISSUER or the pool ID for a trustline and
+	// the signer key for a signer
+	ID string `json:"id"`
+	// Sponsor is the account paying the entry's reserve; it is empty when the wallet pays its own
+	Sponsor string `json:"sponsor,omitempty"`
+}
+
+// SponsorshipStatusResponse represents the reserve sponsorship of a wallet's ledger entries
+type SponsorshipStatusResponse struct {
+	PublicKey string `json:"public_key"`
+	// NumSponsored counts the wallet's entries whose reserves another account pays; NumSponsoring counts
+	// the entries of other accounts whose reserves the wallet pays
+	NumSponsored  uint32           `json:"num_sponsored"`
+	NumSponsoring uint32           `json:"num_sponsoring"`
+	Entries       []SponsoredEntry `json:"entries"`
+}
+
+// RevokeSponsorshipRequest represents the request body for ending the master account's sponsorship of a
+// wallet's entries
+type RevokeSponsorshipRequest struct {
+	// Entries selects the entries by type and ID; when empty every entry the master account sponsors is
+	// revoked
+	Entries []SponsoredEntry `json:"entries,omitempty"`
+	// NewSponsorSecretKey, when set, transfers the sponsorship to that account instead of leaving the
+	// wallet to pay the reserves itself
+	NewSponsorSecretKey string `json:"new_sponsor_secret_key,omitempty"`
+}
+
+// RevokeSponsorshipResponse represents the API response for revoking or transferring a sponsorship
+type RevokeSponsorshipResponse struct {
+	PublicKey       string `json:"public_key"`
+	TransactionHash string `json:"transaction_hash"`
+	// NewSponsor is the account now paying the entries' reserves; it is empty when the wallet pays them
+	NewSponsor string           `json:"new_sponsor,omitempty"`
+	Entries    []SponsoredEntry `json:"entries"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Statement formats
+const (
+	StatementJSON = "json"
+	StatementCSV  = "csv"
+	StatementPDF  = "pdf"
+)
+
+// StatementAssetSummary totals a statement's payments in one asset
+type StatementAssetSummary struct {
+	Asset    string `json:"asset"`
+	Received string `json:"received"`
+	Sent     string `json:"sent"`
+	Net      string `json:"net"`
+	// EndingBalance is the balance held at BalancesAsOf; empty when no balance is known for the period end
+	EndingBalance string `json:"ending_balance,omitempty"`
+}
+
+// Statement represents a wallet's activity over one calendar month (UTC)
+type Statement struct {
+	PublicKey   string                  `json:"public_key"`
+	Month       string                  `json:"month"`
+	PeriodStart time.Time               `json:"period_start"`
+	PeriodEnd   time.Time               `json:"period_end"`
+	Assets      []StatementAssetSummary `json:"assets"`
+	// FeesPaid is the XLM the wallet paid in network fees, over FeeTransactions transactions
+	FeesPaid        string `json:"fees_paid"`
+	FeeTransactions int    `json:"fee_transactions"`
+	// BalancesAsOf is when the ending balances were observed: the last balance snapshot of the period, or
+	// now for the current month
+	BalancesAsOf *time.Time      `json:"balances_as_of,omitempty"`
+	Payments     []PaymentRecord `json:"payments"`
+	GeneratedAt  time.Time       `json:"generated_at"`
+}
@@ -0,0 +1,144 @@
+package models
+
+import "time"
+
+// Operation types accepted by the transaction build endpoint
+const (
+	OperationCreateAccount = "create_account"
+	OperationPayment       = "payment"
+	OperationChangeTrust   = "change_trust"
+	OperationAccountMerge  = "account_merge"
+	OperationManageData    = "manage_data"
+)
+
+// TransactionOperation is one operation of a transaction to build
+type TransactionOperation struct {
+	Type string `json:"type" binding:"required"`
+	// SourceAccount overrides the transaction's source for this operation
+	SourceAccount string `json:"source_account,omitempty"`
+
+	// Destination is the recipient of a payment, the account to create, or the account merged into
+	Destination string `json:"destination,omitempty"`
+	// Amount is the payment amount, or the starting balance in XLM of a created account
+	Amount string `json:"amount,omitempty"`
+	// Asset is "native" or CODE:ISSUER for payments and trustlines
+	Asset string `json:"asset,omitempty"`
+	// Limit is the trustline limit; empty means the maximum and "0" removes the trustline
+	Limit string `json:"limit,omitempty"`
+
+	// Name and Value are the key and value of a data entry; an empty Value deletes the entry
+	Name  string `json:"name,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// TransactionPreconditions are the optional conditions under which a transaction built by the service is
+// valid, for protocols such as HTLCs and coordinated multisig
+type TransactionPreconditions struct {
+	// TimeoutSeconds bounds how long the transaction stays valid (defaults to 300, at most 3600)
+	TimeoutSeconds int64 `json:"timeout_seconds,omitempty"`
+	// MinLedger and MaxLedger bound the ledgers that may include the transaction; MaxLedger is exclusive
+	// and 0 leaves it unbounded
+	MinLedger uint32 `json:"min_ledger,omitempty"`
+	MaxLedger uint32 `json:"max_ledger,omitempty"`
+	// MinSequenceAge and MinSequenceLedgerGap hold the transaction until the source account's sequence
+	// number has gone unchanged for that many seconds or ledgers
+	MinSequenceAge       uint64 `json:"min_sequence_age,omitempty"`
+	MinSequenceLedgerGap uint32 `json:"min_sequence_ledger_gap,omitempty"`
+	// ExtraSigners lists up to two signer keys (G..., T..., X... or P...) that must sign the transaction
+	// besides its source account
+	ExtraSigners []string `json:"extra_signers,omitempty"`
+}
+
+// BuildTransactionRequest represents the request body for building an unsigned transaction
+type BuildTransactionRequest struct {
+	SourcePublicKey string                 `json:"source_public_key" binding:"required"`
+	Operations      []TransactionOperation `json:"operations" binding:"required"`
+	// Memo is attached to the transaction; MemoType is "text" (the default), "id" or "hash" (hex encoded)
+	Memo     string `json:"memo,omitempty"`
+	MemoType string `json:"memo_type,omitempty"`
+	// TransactionPreconditions bound when the transaction is valid; TimeoutSeconds is how long the client
+	// has to sign and submit
+	TransactionPreconditions
+	// BaseFee is the per-operation fee in stroops (defaults to the service's current fee strategy)
+	BaseFee int64 `json:"base_fee,omitempty"`
+}
+
+// BuildTransactionResponse represents an unsigned transaction for the client to sign
+type BuildTransactionResponse struct {
+	EnvelopeXDR       string `json:"envelope_xdr"`
+	Hash              string `json:"hash"`
+	NetworkPassphrase string `json:"network_passphrase"`
+	SourcePublicKey   string `json:"source_public_key"`
+	Sequence          int64  `json:"sequence,string"`
+	Operations        int    `json:"operations"`
+	BaseFee           int64  `json:"base_fee_stroops"`
+	// MaxFee is the most the transaction can be charged, in XLM
+	MaxFee    string    `json:"max_fee"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SubmitTransactionRequest represents the request body for submitting a transaction signed by the client
+type SubmitTransactionRequest struct {
+	EnvelopeXDR string `json:"envelope_xdr" binding:"required"`
+}
+
+// SubmitTransactionResponse represents the normalized result of a submitted transaction
+type SubmitTransactionResponse struct {
+	Status          string `json:"status"`
+	TransactionHash string `json:"transaction_hash"`
+	SourcePublicKey string `json:"source_public_key"`
+	Sequence        int64  `json:"sequence,string"`
+	Ledger          int32  `json:"ledger"`
+	// FeeCharged is the fee the network charged, in XLM
+	FeeCharged string `json:"fee_charged"`
+	// SubmissionRetries is the number of times the envelope was resubmitted after transient Horizon failures
+	SubmissionRetries int `json:"submission_retries,omitempty"`
+}
+
+// Statuses reported by the transaction status endpoint
+const (
+	TransactionPending = "pending"
+	TransactionSuccess = "success"
+	TransactionFailed  = "failed"
+)
+
+// Outcomes of recovering a transaction whose submission result was lost
+const (
+	RecoveryConfirmed   = "confirmed"
+	RecoveryResubmitted = "resubmitted"
+	RecoveryRebuilt     = "rebuilt"
+	RecoveryExpired     = "expired"
+)
+
+// RecoverTransactionResponse represents the outcome of recovering a transaction
+type RecoverTransactionResponse struct {
+	Hash   string `json:"hash"`
+	Status string `json:"status"`
+	// TransactionHash is the transaction now on the ledger: Hash itself, or the replacement it was rebuilt as
+	TransactionHash string `json:"transaction_hash,omitempty"`
+	Ledger          int32  `json:"ledger,omitempty"`
+	Message         string `json:"message"`
+}
+
+// TransactionStatusResponse represents the state of a transaction on the network
+type TransactionStatusResponse struct {
+	Hash string `json:"hash"`
+	// Status is pending, success or failed
+	Status string `json:"status"`
+	// Source is "horizon" when the network knows the transaction, or "local" when only this service's
+	// record of its submission does
+	Source          string     `json:"source"`
+	SourcePublicKey string     `json:"source_public_key,omitempty"`
+	Ledger          int32      `json:"ledger,omitempty"`
+	CreatedAt       *time.Time `json:"created_at,omitempty"`
+	// FeeCharged is the fee the network charged, in XLM
+	FeeCharged string `json:"fee_charged,omitempty"`
+	// ResultCode is the transaction's result code, e.g. tx_success or tx_failed
+	ResultCode string `json:"result_code,omitempty"`
+	// Operations carry each operation's result code, e.g. op_underfunded
+	Operations []OperationResult `json:"operations,omitempty"`
+	// Explanation translates the result codes of a failed transaction
+	Explanation *ResultExplanation `json:"explanation,omitempty"`
+	// ExpiresAt is when a pending transaction stops being valid for inclusion
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// Trust policy modes controlling which incoming assets a wallet automatically trusts
+const (
+	TrustPolicyAllowlist = "allowlist"
+	TrustPolicyAll       = "all"
+	TrustPolicyNone      = "none"
+)
+
+// TrustPolicyRequest represents the request body for setting a wallet's automatic trustline policy
+type TrustPolicyRequest struct {
+	Mode string `json:"mode" binding:"required"`
+	// Assets lists CODE:ISSUER assets trusted automatically in allowlist mode
+	Assets []string `json:"assets"`
+}
+
+// TrustPolicyResponse represents a wallet's effective automatic trustline policy
+type TrustPolicyResponse struct {
+	PublicKey string   `json:"public_key"`
+	Mode      string   `json:"mode"`
+	Assets    []string `json:"assets"`
+	Default   bool     `json:"default"`
+}
@@ -4,31 +4,304 @@ package models
 type WalletResponse struct {
 	PublicKey string `json:"public_key"`
 	SecretKey string `json:"secret_key"`
+	// Sponsored is set when the master account sponsors the wallet's account and trustline reserves
+	Sponsored bool   `json:"sponsored,omitempty"`
 	Message   string `json:"message"`
+	// FriendbotFunded is set when the wallet's XLM came from the testnet friendbot
+	FriendbotFunded bool `json:"friendbot_funded,omitempty"`
+	// Profile is the creation profile the wallet was created with
+	Profile string `json:"profile,omitempty"`
+	// HomeDomain is the home domain set on the new account
+	HomeDomain string            `json:"home_domain,omitempty"`
+	Labels     []string          `json:"labels,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// Wallet creation profiles: whether CreateWallet also adds the USDC trustline and pays the USDC grant
+const (
+	WalletProfileCreate          = "create"
+	WalletProfileCreateTrust     = "create_trust"
+	WalletProfileCreateTrustFund = "create_trust_fund"
+)
+
+// CreateWalletRequest represents the optional request body for the create-wallet endpoint
+type CreateWalletRequest struct {
+	// TrustlineLimits maps CODE:ISSUER to the trustline limit to set instead of the default maximum
+	TrustlineLimits map[string]string `json:"trustline_limits"`
+	// Friendbot funds the wallet's XLM from the testnet friendbot instead of the master account
+	Friendbot bool `json:"friendbot,omitempty"`
+	// Profile is create, create_trust or create_trust_fund (the default): whether the wallet also
+	// trusts USDC and receives the USDC grant
+	Profile string `json:"profile,omitempty"`
+	// HomeDomain is set on the new account in the creation transaction, overriding the configured default
+	HomeDomain string `json:"home_domain,omitempty"`
+	// Labels and Metadata annotate the wallet for the client, e.g. with user_id, department or purpose
+	Labels   []string          `json:"labels,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// WalletMetadataRequest represents the request body for replacing a wallet's labels and metadata
+type WalletMetadataRequest struct {
+	Labels   []string          `json:"labels"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// WalletMetadataResponse represents a wallet's labels and metadata
+type WalletMetadataResponse struct {
+	PublicKey string            `json:"public_key"`
+	Labels    []string          `json:"labels"`
+	Metadata  map[string]string `json:"metadata"`
+}
+
+// FundWalletResponse represents the API response for funding an account from the testnet friendbot
+type FundWalletResponse struct {
+	PublicKey       string `json:"public_key"`
+	TransactionHash string `json:"transaction_hash"`
+	Message         string `json:"message"`
+}
+
+// ActivateWalletRequest represents the request body for creating an account for a key pair generated by
+// the client, which keeps the secret key
+type ActivateWalletRequest struct {
+	PublicKey string `json:"public_key" binding:"required"`
+	// Trustline asks for a USDC trustline whose reserve the master account sponsors; the client signs it
+	Trustline bool `json:"trustline,omitempty"`
+	// TrustlineLimit is the limit of that trustline instead of the default maximum
+	TrustlineLimit string `json:"trustline_limit,omitempty"`
+}
+
+// ActivateWalletResponse represents the API response for activating a client-held account
+type ActivateWalletResponse struct {
+	PublicKey       string `json:"public_key"`
+	TransactionHash string `json:"transaction_hash"`
+	StartingBalance string `json:"starting_balance"`
+	// Trustline is the sponsored trustline transaction, signed by the master account, for the client to
+	// sign and submit to POST /api/v1/transactions/submit
+	Trustline *BuildTransactionResponse `json:"trustline,omitempty"`
+	Message   string                    `json:"message"`
+}
+
+// Balance represents a single asset balance held by a wallet
+type Balance struct {
+	AssetType string `json:"asset_type"`
+	AssetCode string `json:"asset_code,omitempty"`
+	Issuer    string `json:"issuer,omitempty"`
+	Balance   string `json:"balance"`
+	Limit     string `json:"limit,omitempty"`
+	// Available is the balance minus selling liabilities and, for XLM, the account's minimum reserve
+	Available          string `json:"available"`
+	SellingLiabilities string `json:"selling_liabilities,omitempty"`
+	BuyingLiabilities  string `json:"buying_liabilities,omitempty"`
+	// PendingSettlement is the net amount of internal transfers not yet settled on-chain, already reflected in Available
+	PendingSettlement string `json:"pending_settlement,omitempty"`
+	// LiquidityPool is set for liquidity_pool_shares balances, where Balance is the number of pool shares
+	LiquidityPool *LiquidityPoolShare `json:"liquidity_pool,omitempty"`
+}
+
+// LiquidityPoolShare describes a wallet's stake in an AMM liquidity pool
+type LiquidityPoolShare struct {
+	PoolID      string                 `json:"pool_id"`
+	FeeBP       uint32                 `json:"fee_bp"`
+	TotalShares string                 `json:"total_shares"`
+	Reserves    []LiquidityPoolReserve `json:"reserves"`
+}
+
+// LiquidityPoolReserve is one reserve asset of a pool and the amount redeemable with the wallet's shares
+type LiquidityPoolReserve struct {
+	Asset       string `json:"asset"`
+	PoolAmount  string `json:"pool_amount"`
+	ShareAmount string `json:"share_amount"`
 }
 
 // WalletDetailsResponse represents the API response for wallet details
 type WalletDetailsResponse struct {
 	PublicKey string `json:"public_key"`
-	Exists    bool   `json:"exists"`
-	Balances  []struct {
-		AssetType string `json:"asset_type"`
-		AssetCode string `json:"asset_code,omitempty"`
-		Issuer    string `json:"issuer,omitempty"`
-		Balance   string `json:"balance"`
-	} `json:"balances"`
-	SequenceNumber int64 `json:"sequence_number"`
+	// MuxedAddress and MuxedID are set when the lookup used an M... address; PublicKey is then its base account
+	MuxedAddress   string    `json:"muxed_address,omitempty"`
+	MuxedID        string    `json:"muxed_id,omitempty"`
+	Exists         bool      `json:"exists"`
+	Balances       []Balance `json:"balances"`
+	SequenceNumber int64     `json:"sequence_number"`
+	// Data lists the account's data entries, sorted by name
+	Data []DataEntry `json:"data,omitempty"`
+	// Labels and Metadata are the client's annotations of a managed wallet
+	Labels   []string          `json:"labels,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// PendingClaimableBalances counts the claimable balances the wallet is a claimant of, which exist even
+	// before the account is funded; ClaimableBalances lists them when requested
+	PendingClaimableBalances int                        `json:"pending_claimable_balances"`
+	ClaimableBalances        []ClaimableBalanceResponse `json:"claimable_balances,omitempty"`
+}
+
+// WalletDetailsOptions selects the optional parts of a WalletDetailsResponse
+type WalletDetailsOptions struct {
+	IncludeClaimableBalances bool
 }
 
 // TransferRequest represents the request body for the transfer endpoint
 type TransferRequest struct {
 	FromSecretKey string `json:"from_secret_key" binding:"required"`
-	ToPublicKey   string `json:"to_public_key" binding:"required"`
-	Amount        string `json:"amount" binding:"required"`
+	// ToPublicKey is a G... or M... address, or a federation address such as alice*example.com
+	ToPublicKey string `json:"to_public_key" binding:"required"`
+	Amount      string `json:"amount" binding:"required"`
+
+	// SourceAsset is the asset debited from the sender, as "native" or CODE:ISSUER (defaults to USDC); "any"
+	// lets the service choose among the sender's balances, combining several when no single one suffices
+	SourceAsset string `json:"source_asset,omitempty"`
+	// DestinationAsset is the asset the recipient receives (defaults to SourceAsset); when it differs,
+	// Amount is the exact amount delivered and the transfer is routed as a path payment
+	DestinationAsset string `json:"destination_asset,omitempty"`
+	// MaxSlippagePercent bounds the extra source spend over the quoted path (defaults to 1)
+	MaxSlippagePercent string `json:"max_slippage_percent,omitempty"`
+
+	// Memo is attached to the transaction; MemoType is "text" (the default), "id" or "hash" (hex encoded).
+	// A federation address's memo is used when Memo is empty, and Memo must match it otherwise.
+	Memo     string `json:"memo,omitempty"`
+	MemoType string `json:"memo_type,omitempty"`
+
+	// ExternalID is the client's reference for the transfer; a repeat submission with the same ID returns
+	// the original result instead of paying again
+	ExternalID string `json:"external_id,omitempty"`
+	// CallbackURL receives a signed TransferCallback once the transfer completes or fails
+	CallbackURL string `json:"callback_url,omitempty"`
+
+	// ClaimableFallback sends a claimable balance instead of failing when the recipient lacks the trustline
+	ClaimableFallback bool `json:"claimable_fallback,omitempty"`
+	// ClaimableAfterSeconds delays when the recipient may claim the fallback balance
+	ClaimableAfterSeconds int64 `json:"claimable_after_seconds,omitempty"`
+	// ReclaimAfterSeconds lets the sender reclaim an unclaimed fallback balance after this window
+	ReclaimAfterSeconds int64 `json:"reclaim_after_seconds,omitempty"`
+
+	// TransactionPreconditions bound when the transfer's transaction is valid. Extra signers must be
+	// wallets the service holds keys for, as it signs the transaction itself.
+	TransactionPreconditions
+
+	// Device describes the client that initiated the transfer; it is filled from request headers
+	Device DeviceInfo `json:"-"`
 }
 
+// DeviceInfo describes the client device a request originated from
+type DeviceInfo struct {
+	IPAddress string `json:"ip_address,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	DeviceID  string `json:"device_id,omitempty"`
+}
+
+// Transfer statuses reported in TransferResponse; TransferFailed is only reported in callbacks
+const (
+	TransferCompleted     = "completed"
+	TransferPendingReview = "pending_review"
+	TransferInternal      = "internal"
+	TransferFailed        = "failed"
+)
+
+// SourceAssetAny selects the sender's balances to spend automatically
+const SourceAssetAny = "any"
+
+// How a completed transfer reached its recipient
+const (
+	DeliveredAsPayment          = "payment"
+	DeliveredAsPathPayment      = "path_payment"
+	DeliveredAsClaimableBalance = "claimable_balance"
+)
+
 // TransferResponse represents the API response for the transfer endpoint
 type TransferResponse struct {
-	TransactionHash string `json:"transaction_hash"`
-	Message         string `json:"message"`
+	Status             string `json:"status"`
+	ReviewID           string `json:"review_id,omitempty"`
+	InternalTransferID string `json:"internal_transfer_id,omitempty"`
+	TransactionHash    string `json:"transaction_hash,omitempty"`
+	Message            string `json:"message"`
+	SourceAsset        string `json:"source_asset"`
+	DestinationAsset   string `json:"destination_asset"`
+	DestinationMuxedID string `json:"destination_muxed_id,omitempty"`
+	// FederationAddress is the federation address the recipient was given as, resolved to its account
+	FederationAddress  string `json:"federation_address,omitempty"`
+	SendMax            string `json:"send_max,omitempty"`
+	DeliveredAs        string `json:"delivered_as,omitempty"`
+	ClaimableBalanceID string `json:"claimable_balance_id,omitempty"`
+	// SubmissionRetries is the number of times the transaction was resubmitted after transient Horizon failures
+	SubmissionRetries int `json:"submission_retries,omitempty"`
+	// Sources lists each balance spent when an auto-selected transfer combines several
+	Sources []TransferSource `json:"sources,omitempty"`
+	// ExternalID echoes the request's external_id; Replayed is set when the result is that of an earlier
+	// submission with the same ID
+	ExternalID string `json:"external_id,omitempty"`
+	Replayed   bool   `json:"replayed,omitempty"`
+	// FeeSponsored is set when the master account paid the network fee through a fee bump
+	FeeSponsored bool `json:"fee_sponsored,omitempty"`
+}
+
+// TransferSource is the part of a transfer delivered from one of the sender's balances
+type TransferSource struct {
+	Asset string `json:"asset"`
+	// Amount is the amount of the destination asset this balance delivers
+	Amount string `json:"amount"`
+	// SendMax is the most of Asset spent; it is empty when Asset is the destination asset
+	SendMax string `json:"send_max,omitempty"`
+}
+
+// ProjectedBalance is a balance before and after a simulated transfer
+type ProjectedBalance struct {
+	Asset     string `json:"asset"`
+	Current   string `json:"current"`
+	Projected string `json:"projected"`
+}
+
+// TransferSimulation represents the API response for a transfer dry run
+type TransferSimulation struct {
+	// Valid is true when no problems were found, so the transfer is expected to succeed if submitted now
+	Valid    bool     `json:"valid"`
+	Problems []string `json:"problems"`
+
+	SourceAsset      string           `json:"source_asset"`
+	DestinationAsset string           `json:"destination_asset"`
+	DeliveredAs      string           `json:"delivered_as"`
+	SendMax          string           `json:"send_max,omitempty"`
+	Sources          []TransferSource `json:"sources,omitempty"`
+	// SettlesInternally is set when the transfer would be booked on the internal ledger instead of
+	// submitted; the projection still describes the on-chain payment
+	SettlesInternally bool `json:"settles_internally,omitempty"`
+	// MemoRequired is set when the recipient requires a memo under SEP-29
+	MemoRequired bool `json:"memo_required"`
+
+	// EnvelopeXDR is the unsigned transaction that would be submitted
+	EnvelopeXDR string `json:"envelope_xdr"`
+	Operations  int    `json:"operations"`
+	BaseFee     int64  `json:"base_fee_stroops"`
+	// Fee is the most the transaction can be charged, in XLM
+	Fee string `json:"fee"`
+
+	SenderBalances    []ProjectedBalance `json:"sender_balances"`
+	RecipientBalances []ProjectedBalance `json:"recipient_balances"`
+	// SenderMinimumBalance is the XLM the sender must keep locked now and after the transfer, which grows
+	// when the sender sponsors a claimable balance
+	SenderMinimumBalance          string `json:"sender_minimum_balance"`
+	ProjectedSenderMinimumBalance string `json:"projected_sender_minimum_balance"`
+}
+
+// WalletCreationEstimate breaks down what creating a wallet consumes under current network parameters
+type WalletCreationEstimate struct {
+	// StartingBalance is the XLM the master account sends to the new wallet
+	StartingBalance string `json:"starting_balance"`
+	// AccountReserve and TrustlineReserve are the XLM the new wallet must keep locked for the account
+	// and its USDC trustline
+	AccountReserve   string `json:"account_reserve"`
+	TrustlineReserve string `json:"trustline_reserve"`
+	// NetworkFee is the maximum fee the creation transaction pays, in XLM
+	NetworkFee string `json:"network_fee"`
+	// TotalXLM is what the master account spends: the starting balance plus the network fee
+	TotalXLM string `json:"total_xlm"`
+	// SponsoredReserve, set when wallet reserves are sponsored, is the XLM the master account keeps
+	// locked for the wallet's account and trustline reserves instead of sending them
+	SponsoredReserve string `json:"sponsored_reserve,omitempty"`
+	USDCGrant        string `json:"usdc_grant"`
+	USDCAsset        string `json:"usdc_asset"`
+	Operations       int    `json:"operations"`
+	// Profile is the creation profile the estimate is for
+	Profile string `json:"profile"`
+	// BaseReserve and BaseFee are the network parameters of the latest ledger, in stroops
+	BaseReserve int32 `json:"base_reserve_stroops"`
+	BaseFee     int32 `json:"base_fee_stroops"`
+	Ledger      int32 `json:"ledger"`
 }
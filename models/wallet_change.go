@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Wallet change feed entry types
+const (
+	WalletChangeCreated = "created"
+	WalletChangeUpdated = "updated"
+	WalletChangeClosed  = "closed"
+)
+
+// WalletChange is one entry of the wallet registry change feed
+type WalletChange struct {
+	// Cursor orders the feed; pass the last cursor seen as since to resume after it
+	Cursor    string `json:"cursor"`
+	Type      string `json:"type"`
+	PublicKey string `json:"public_key"`
+	TenantID  string `json:"tenant_id"`
+	// Reason describes what changed for updated wallets, e.g. deactivated or trust_policy_updated
+	Reason     string    `json:"reason,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// WalletChangesResponse represents a page of the wallet registry change feed
+type WalletChangesResponse struct {
+	Changes    []WalletChange `json:"changes"`
+	NextCursor string         `json:"next_cursor"`
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookVerifyRequest represents the request body for checking a webhook signature
+type WebhookVerifyRequest struct {
+	Secret string `json:"secret" binding:"required"`
+	// Payload is the raw webhook request body exactly as received
+	Payload   string `json:"payload"`
+	Timestamp string `json:"timestamp" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+	// ToleranceSeconds overrides the default timestamp tolerance of five minutes
+	ToleranceSeconds int `json:"tolerance_seconds"`
+}
+
+// WebhookVerifyResponse reports whether a webhook signature is valid
+type WebhookVerifyResponse struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+	// ExpectedSignature is the signature we would send for the payload and timestamp
+	ExpectedSignature string `json:"expected_signature,omitempty"`
+}
+
+// WebhookSubscriptionRequest represents the request body for subscribing a URL to events
+type WebhookSubscriptionRequest struct {
+	URL string `json:"url" binding:"required"`
+	// EventTypes are the events delivered, e.g. payment.received or wallet.created
+	EventTypes []string `json:"event_types" binding:"required"`
+	// Wallet limits the subscription to one of the tenant's wallets; it covers all of them when empty
+	Wallet string `json:"wallet,omitempty"`
+	// Assets, "native" or CODE:ISSUER, limit events that carry an asset to those assets
+	Assets []string `json:"assets,omitempty"`
+	// MinAmount drops events that carry an amount below it
+	MinAmount string `json:"min_amount,omitempty"`
+	// Secret sets the subscription's signing secret, at least 32 characters; one is generated when empty
+	Secret string `json:"secret,omitempty"`
+}
+
+// WebhookSubscription represents a URL subscribed to a tenant's or wallet's events
+type WebhookSubscription struct {
+	ID         string   `json:"id"`
+	TenantID   string   `json:"tenant_id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Wallet     string   `json:"wallet,omitempty"`
+	Assets     []string `json:"assets,omitempty"`
+	MinAmount  string   `json:"min_amount,omitempty"`
+	// Secret signs the subscription's deliveries; it is only returned when the subscription is created or
+	// its secret is rotated
+	Secret string `json:"secret,omitempty"`
+	// PreviousSecret, until PreviousSecretExpiresAt, signs deliveries alongside Secret after a rotation; it
+	// is never returned
+	PreviousSecret          string     `json:"previous_secret,omitempty"`
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty"`
+	CreatedAt               time.Time  `json:"created_at"`
+}
+
+// WebhookSubscriptionsResponse lists a tenant's webhook subscriptions
+type WebhookSubscriptionsResponse struct {
+	Subscriptions []WebhookSubscription `json:"subscriptions"`
+}
+
+// WebhookDelivery is the body posted to a subscribed URL. ID is the event's ID, shared by every delivery
+// and retry of the event, so receivers can dedupe on it.
+type WebhookDelivery struct {
+	ID             string            `json:"id"`
+	SubscriptionID string            `json:"subscription_id"`
+	Type           string            `json:"type"`
+	TenantID       string            `json:"tenant_id"`
+	Wallet         string            `json:"wallet"`
+	Data           map[string]string `json:"data,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// WebhookDeadLetter is a webhook delivery that failed every attempt. Body is the delivery exactly as it was
+// posted, so a redrive carries the same event ID.
+type WebhookDeadLetter struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscription_id"`
+	TenantID       string          `json:"tenant_id"`
+	URL            string          `json:"url"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Body           json.RawMessage `json:"body"`
+	Attempts       int             `json:"attempts"`
+	LastError      string          `json:"last_error"`
+	FailedAt       time.Time       `json:"failed_at"`
+}
+
+// WebhookDeadLettersResponse lists a tenant's dead-lettered webhook deliveries, newest first
+type WebhookDeadLettersResponse struct {
+	DeadLetters []WebhookDeadLetter `json:"dead_letters"`
+}
+
+// WebhookRedriveResponse acknowledges a dead-lettered delivery queued for redelivery
+type WebhookRedriveResponse struct {
+	ID             string `json:"id"`
+	SubscriptionID string `json:"subscription_id"`
+	EventID        string `json:"event_id"`
+	Status         string `json:"status"`
+}
+
+// WebhookRedriveQueued is the status of a redriven delivery; it is dead-lettered again if it keeps failing
+const WebhookRedriveQueued = "queued"
+
+// Webhook delivery triggers, recording why a delivery attempt was made
+const (
+	WebhookTriggerEvent   = "event"
+	WebhookTriggerRedrive = "redrive"
+	WebhookTriggerReplay  = "replay"
+)
+
+// WebhookDeliveryAttempt is one logged attempt to post a delivery to a subscription. StatusCode is 0 when the
+// receiver could not be reached.
+type WebhookDeliveryAttempt struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscription_id"`
+	TenantID       string          `json:"tenant_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	URL            string          `json:"url"`
+	Trigger        string          `json:"trigger"`
+	Attempt        int             `json:"attempt"`
+	Payload        json.RawMessage `json:"payload"`
+	StatusCode     int             `json:"status_code"`
+	LatencyMS      int64           `json:"latency_ms"`
+	Success        bool            `json:"success"`
+	Error          string          `json:"error,omitempty"`
+	AttemptedAt    time.Time       `json:"attempted_at"`
+}
+
+// WebhookDeliveryLogResponse lists logged delivery attempts, newest first
+type WebhookDeliveryLogResponse struct {
+	Attempts []WebhookDeliveryAttempt `json:"attempts"`
+}
+
+// WebhookReplayResponse acknowledges an event queued for delivery again to a subscription
+type WebhookReplayResponse struct {
+	SubscriptionID string `json:"subscription_id"`
+	EventID        string `json:"event_id"`
+	Status         string `json:"status"`
+}
+
+// OperatorAlert is the body posted to the operator alert webhook
+type OperatorAlert struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	RaisedAt time.Time `json:"raised_at"`
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/protocols/horizon/effects"
+	"github.com/stellar/go/protocols/horizon/operations"
+)
+
+// operationBaseFields and effectBaseFields are the Horizon fields mapped onto OperationRecord and
+// EffectRecord, and so left out of their Details
+var (
+	operationBaseFields = []string{"_links", "id", "paging_token", "transaction_successful", "source_account", "type", "type_i", "created_at", "transaction_hash", "transaction"}
+	effectBaseFields    = []string{"_links", "id", "paging_token", "account", "type", "type_i", "created_at"}
+)
+
+// activityDetails returns the fields of a Horizon record other than the given base fields
+func activityDetails(record interface{}, baseFields []string) map[string]interface{} {
+	details := map[string]interface{}{}
+	raw, err := json.Marshal(record)
+	if err != nil || json.Unmarshal(raw, &details) != nil {
+		return map[string]interface{}{}
+	}
+	for _, field := range baseFields {
+		delete(details, field)
+	}
+	return details
+}
+
+// effectCreatedAt returns when an effect happened; the effects.Effect interface does not expose it
+func effectCreatedAt(effect effects.Effect) time.Time {
+	var base effects.Base
+	if raw, err := json.Marshal(effect); err == nil {
+		_ = json.Unmarshal(raw, &base)
+	}
+	return base.LedgerCloseTime
+}
+
+// operationRecord normalizes a Horizon operation into an OperationRecord
+func operationRecord(op operations.Operation) models.OperationRecord {
+	base := op.GetBase()
+	return models.OperationRecord{
+		ID:              base.ID,
+		Type:            base.Type,
+		SourceAccount:   base.SourceAccount,
+		TransactionHash: base.TransactionHash,
+		Successful:      base.TransactionSuccessful,
+		CreatedAt:       base.LedgerCloseTime,
+		Details:         activityDetails(op, operationBaseFields),
+	}
+}
+
+// checkActivityRequest validates the wallet and page size of an operations or effects request
+func checkActivityRequest(publicKey string, limit *int) error {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return errors.New("invalid public key format")
+	}
+	if *limit == 0 {
+		*limit = defaultPaymentHistoryLimit
+	}
+	if *limit < 0 || *limit > maxPaymentHistoryLimit {
+		return errors.New("invalid limit: must be between 1 and 200")
+	}
+	return nil
+}
+
+// activityError maps a Horizon error for a wallet's operations or effects
+func activityError(what string, err error) error {
+	if herr, ok := err.(*horizonclient.Error); ok && herr.Response.StatusCode == http.StatusNotFound {
+		return errors.New("wallet not found")
+	}
+	return errors.New("failed to fetch " + what + ": " + err.Error())
+}
+
+// WalletOperations returns a page of every operation touching a wallet, newest first, optionally only of
+// the given Horizon operation types. Pages are scanned like WalletPayments, or the local history store is
+// queried for backfilled wallets.
+func (s *WalletService) WalletOperations(publicKey string, types []string, cursor string, limit int) (*models.OperationsResponse, error) {
+	if err := checkActivityRequest(publicKey, &limit); err != nil {
+		return nil, err
+	}
+	if s.historyReady(publicKey) {
+		return s.History.operations(publicKey, types, cursor, limit)
+	}
+
+	response := &models.OperationsResponse{Operations: []models.OperationRecord{}}
+	for page := 0; page < maxPaymentHistoryPages; page++ {
+		ops, err := s.Config.HorizonClient.Operations(horizonclient.OperationRequest{
+			ForAccount:    publicKey,
+			Cursor:        cursor,
+			Order:         horizonclient.OrderDesc,
+			Limit:         maxPaymentHistoryLimit,
+			IncludeFailed: true,
+		})
+		if err != nil {
+			return nil, activityError("operations", err)
+		}
+		for _, op := range ops.Embedded.Records {
+			cursor = op.PagingToken()
+			if len(types) > 0 && !slices.Contains(types, op.GetType()) {
+				continue
+			}
+			response.Operations = append(response.Operations, operationRecord(op))
+			if len(response.Operations) == limit {
+				response.NextCursor = cursor
+				return response, nil
+			}
+		}
+		if len(ops.Embedded.Records) < maxPaymentHistoryLimit {
+			return response, nil
+		}
+	}
+	response.NextCursor = cursor
+	return response, nil
+}
+
+// WalletEffects returns a page of the effects operations had on a wallet's account, newest first,
+// optionally only of the given Horizon effect types such as trustline_created, signer_updated or trade.
+// Pages are scanned like WalletPayments.
+func (s *WalletService) WalletEffects(publicKey string, types []string, cursor string, limit int) (*models.EffectsResponse, error) {
+	if err := checkActivityRequest(publicKey, &limit); err != nil {
+		return nil, err
+	}
+
+	response := &models.EffectsResponse{Effects: []models.EffectRecord{}}
+	for pages := 0; pages < maxPaymentHistoryPages; pages++ {
+		page, err := s.Config.HorizonClient.Effects(horizonclient.EffectRequest{
+			ForAccount: publicKey,
+			Cursor:     cursor,
+			Order:      horizonclient.OrderDesc,
+			Limit:      maxPaymentHistoryLimit,
+		})
+		if err != nil {
+			return nil, activityError("effects", err)
+		}
+		for _, effect := range page.Embedded.Records {
+			cursor = effect.PagingToken()
+			if len(types) > 0 && !slices.Contains(types, effect.GetType()) {
+				continue
+			}
+			// Effect IDs are the zero-padded operation ID followed by the effect's index within it
+			operationID, _, _ := strings.Cut(effect.GetID(), "-")
+			operationID = strings.TrimLeft(operationID, "0")
+			response.Effects = append(response.Effects, models.EffectRecord{
+				ID:          effect.GetID(),
+				Type:        effect.GetType(),
+				Account:     effect.GetAccount(),
+				OperationID: operationID,
+				CreatedAt:   effectCreatedAt(effect),
+				Details:     activityDetails(effect, effectBaseFields),
+			})
+			if len(response.Effects) == limit {
+				response.NextCursor = cursor
+				return response, nil
+			}
+		}
+		if len(page.Embedded.Records) < maxPaymentHistoryLimit {
+			return response, nil
+		}
+	}
+	response.NextCursor = cursor
+	return response, nil
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// maxHomeDomainLength is the protocol limit on an account's home domain
+const maxHomeDomainLength = 32
+
+// accountFlags maps the flag names of the account options endpoint to txnbuild flags
+var accountFlags = map[string]txnbuild.AccountFlag{
+	models.AccountFlagAuthRequired:        txnbuild.AuthRequired,
+	models.AccountFlagAuthRevocable:       txnbuild.AuthRevocable,
+	models.AccountFlagAuthClawbackEnabled: txnbuild.AuthClawbackEnabled,
+}
+
+// threshold validates an optional weight or threshold from the request
+func threshold(field string, value *int) (*txnbuild.Threshold, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if *value < 0 || *value > 255 {
+		return nil, errors.New("invalid " + field + ": must be between 0 and 255")
+	}
+	t := txnbuild.Threshold(*value)
+	return &t, nil
+}
+
+// checkThresholdsReachable refuses thresholds the account's signers could not meet. Any unreachable
+// threshold bricks the operations it guards: a medium threshold above the signers' total weight blocks
+// payments even when the high threshold is met. The service signs managed wallets with the master key
+// alone, so for those the master weight must meet every threshold by itself.
+func checkThresholdsReachable(masterWeight, otherWeight int, thresholds models.AccountThresholds, managed bool) error {
+	weight := masterWeight + otherWeight
+	levels := []struct {
+		name  string
+		value int
+	}{{"low", thresholds.Low}, {"medium", thresholds.Medium}, {"high", thresholds.High}}
+	if weight == 0 {
+		return errors.New("account would be locked: signer weights total 0")
+	}
+	for _, level := range levels {
+		if weight < level.value {
+			return errors.New("account would be locked: signer weights total " + strconv.Itoa(weight) + ", below the " + level.name + " threshold of " + strconv.Itoa(level.value))
+		}
+		if managed && masterWeight < level.value {
+			return errors.New("account would be locked: the master key of a managed wallet must meet the " + level.name + " threshold")
+		}
+	}
+	return nil
+}
+
+// SetAccountOptions sets a wallet's home domain, authorization flags, master weight and thresholds. Changes
+// that would leave the account's signers unable to reach its high threshold, and so lock it, are refused.
+// tenantID is the authenticated tenant, or empty when the request must supply the wallet's secret key.
+func (s *WalletService) SetAccountOptions(tenantID, publicKey string, req models.AccountOptionsRequest) (*models.AccountOptionsResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	walletKP, err := s.authorizedSigner(tenantID, publicKey, req.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	if s.isDeactivated(publicKey) {
+		return nil, errors.New("wallet is deactivated; restore it before changing its options")
+	}
+
+	op := &txnbuild.SetOptions{HomeDomain: req.HomeDomain}
+	if req.HomeDomain != nil && len(*req.HomeDomain) > maxHomeDomainLength {
+		return nil, errors.New("invalid home_domain: must be at most 32 bytes")
+	}
+	if op.MasterWeight, err = threshold("master_weight", req.MasterWeight); err != nil {
+		return nil, err
+	}
+	if op.LowThreshold, err = threshold("low_threshold", req.LowThreshold); err != nil {
+		return nil, err
+	}
+	if op.MediumThreshold, err = threshold("medium_threshold", req.MediumThreshold); err != nil {
+		return nil, err
+	}
+	if op.HighThreshold, err = threshold("high_threshold", req.HighThreshold); err != nil {
+		return nil, err
+	}
+	setting := make(map[string]bool)
+	for _, name := range req.SetFlags {
+		flag, ok := accountFlags[name]
+		if !ok {
+			return nil, errors.New("invalid set_flags: unknown flag " + name)
+		}
+		setting[name] = true
+		op.SetFlags = append(op.SetFlags, flag)
+	}
+	clearing := make(map[string]bool)
+	for _, name := range req.ClearFlags {
+		flag, ok := accountFlags[name]
+		if !ok {
+			return nil, errors.New("invalid clear_flags: unknown flag " + name)
+		}
+		if setting[name] {
+			return nil, errors.New("invalid flags: " + name + " is both set and cleared")
+		}
+		clearing[name] = true
+		op.ClearFlags = append(op.ClearFlags, flag)
+	}
+	if op.HomeDomain == nil && op.MasterWeight == nil && op.LowThreshold == nil && op.MediumThreshold == nil &&
+		op.HighThreshold == nil && len(op.SetFlags) == 0 && len(op.ClearFlags) == 0 {
+		return nil, errors.New("invalid request: no options to change")
+	}
+
+	account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: publicKey})
+	if err != nil {
+		return nil, errors.New("failed to fetch wallet account details: " + err.Error())
+	}
+
+	// Project the account as it will be once the options apply
+	result := &models.AccountOptionsResponse{
+		PublicKey:  publicKey,
+		HomeDomain: account.HomeDomain,
+		Flags: models.AccountFlags{
+			AuthRequired:        account.Flags.AuthRequired,
+			AuthRevocable:       account.Flags.AuthRevocable,
+			AuthImmutable:       account.Flags.AuthImmutable,
+			AuthClawbackEnabled: account.Flags.AuthClawbackEnabled,
+		},
+		Thresholds: models.AccountThresholds{
+			Low:    int(account.Thresholds.LowThreshold),
+			Medium: int(account.Thresholds.MedThreshold),
+			High:   int(account.Thresholds.HighThreshold),
+		},
+	}
+	otherWeight := 0
+	for _, signer := range account.Signers {
+		if signer.Key == publicKey {
+			result.MasterWeight = int(signer.Weight)
+		} else {
+			otherWeight += int(signer.Weight)
+		}
+	}
+	if req.HomeDomain != nil {
+		result.HomeDomain = *req.HomeDomain
+	}
+	if req.MasterWeight != nil {
+		result.MasterWeight = *req.MasterWeight
+	}
+	if req.LowThreshold != nil {
+		result.Thresholds.Low = *req.LowThreshold
+	}
+	if req.MediumThreshold != nil {
+		result.Thresholds.Medium = *req.MediumThreshold
+	}
+	if req.HighThreshold != nil {
+		result.Thresholds.High = *req.HighThreshold
+	}
+	if len(op.SetFlags) > 0 || len(op.ClearFlags) > 0 {
+		if account.Flags.AuthImmutable {
+			return nil, errors.New("wallet flags are immutable")
+		}
+		flags := map[string]*bool{
+			models.AccountFlagAuthRequired:        &result.Flags.AuthRequired,
+			models.AccountFlagAuthRevocable:       &result.Flags.AuthRevocable,
+			models.AccountFlagAuthClawbackEnabled: &result.Flags.AuthClawbackEnabled,
+		}
+		for name := range setting {
+			*flags[name] = true
+		}
+		for name := range clearing {
+			*flags[name] = false
+		}
+		if result.Flags.AuthClawbackEnabled && !result.Flags.AuthRevocable {
+			return nil, errors.New("invalid flags: auth_clawback_enabled requires auth_revocable")
+		}
+	}
+
+	_, managed := s.Registry.Get(publicKey)
+	if err := checkThresholdsReachable(result.MasterWeight, otherWeight, result.Thresholds, managed); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.buildTransaction(publicKey, txnbuild.TransactionParams{
+		Operations:    []txnbuild.Operation{op},
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+	}, walletKP)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.submitTransaction(tx, walletKP)
+	if err != nil {
+		return nil, err
+	}
+	result.TransactionHash = resp.Hash
+	s.Registry.RecordUpdate(publicKey, "account_options_updated")
+	s.Audit.Record("wallet:"+publicKey, "wallet.options_updated", publicKey, map[string]string{
+		"transaction_hash": resp.Hash,
+	})
+	return result, nil
+}
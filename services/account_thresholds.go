@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+)
+
+// accountThresholds reports an account's signers and thresholds, with masterWeight, when not negative,
+// standing in for the weight Horizon reports for the master key
+func accountThresholds(account hProtocol.Account, masterWeight int, thresholds *models.AccountThresholds) *models.AccountThresholdsResponse {
+	response := &models.AccountThresholdsResponse{
+		PublicKey: account.AccountID,
+		Thresholds: models.AccountThresholds{
+			Low:    int(account.Thresholds.LowThreshold),
+			Medium: int(account.Thresholds.MedThreshold),
+			High:   int(account.Thresholds.HighThreshold),
+		},
+		Signers: []models.AccountSignerWeight{},
+	}
+	if thresholds != nil {
+		response.Thresholds = *thresholds
+	}
+	for _, signer := range account.Signers {
+		weight := int(signer.Weight)
+		if signer.Key == account.AccountID {
+			if masterWeight >= 0 {
+				weight = masterWeight
+			}
+			response.MasterWeight = weight
+		}
+		response.Signers = append(response.Signers, models.AccountSignerWeight{Key: signer.Key, Type: signer.Type, Weight: weight})
+		response.TotalWeight += weight
+	}
+	return response
+}
+
+// GetAccountThresholds returns a wallet's master key weight, other signers and thresholds
+func (s *WalletService) GetAccountThresholds(publicKey string) (*models.AccountThresholdsResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: publicKey})
+	if err != nil {
+		if herr, ok := err.(*horizonclient.Error); ok && herr.Response.StatusCode == http.StatusNotFound {
+			return nil, errors.New("wallet not found")
+		}
+		return nil, errors.New("failed to fetch wallet account details: " + err.Error())
+	}
+	return accountThresholds(account, -1, nil), nil
+}
+
+// SetAccountThresholds updates a wallet's master key weight and thresholds with the account options
+// guardrails, which refuse any threshold the signers could not reach
+func (s *WalletService) SetAccountThresholds(tenantID, publicKey string, req models.AccountThresholdsRequest) (*models.AccountThresholdsResponse, error) {
+	if req.MasterWeight == nil && req.LowThreshold == nil && req.MediumThreshold == nil && req.HighThreshold == nil {
+		return nil, errors.New("invalid request: no weight or threshold to change")
+	}
+	result, err := s.SetAccountOptions(tenantID, publicKey, models.AccountOptionsRequest{
+		SecretKey:       req.SecretKey,
+		MasterWeight:    req.MasterWeight,
+		LowThreshold:    req.LowThreshold,
+		MediumThreshold: req.MediumThreshold,
+		HighThreshold:   req.HighThreshold,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Horizon may not have ingested the change yet, so the weights come from the applied options
+	account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: publicKey})
+	if err != nil {
+		return nil, errors.New("failed to fetch wallet account details: " + err.Error())
+	}
+	response := accountThresholds(account, result.MasterWeight, &result.Thresholds)
+	response.TransactionHash = result.TransactionHash
+	return response, nil
+}
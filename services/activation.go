@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// ActivateWallet creates the account of a key pair the client generated itself, funded with the wallet
+// starting balance from the master account. The service never holds the secret key, so the wallet is not
+// added to the registry and nothing the account itself must sign is submitted: a requested trustline comes
+// back as a transaction sponsored and signed by the master account, for the client to sign and submit.
+func (s *WalletService) ActivateWallet(tenantID string, req models.ActivateWalletRequest) (*models.ActivateWalletResponse, error) {
+	if _, err := keypair.ParseAddress(req.PublicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	if req.TrustlineLimit != "" {
+		if !req.Trustline {
+			return nil, errors.New("invalid trustline_limit: requires trustline")
+		}
+		if _, err := parseAmount("trustline_limit", req.TrustlineLimit); err != nil {
+			return nil, err
+		}
+	}
+	if _, managed := s.Registry.Get(req.PublicKey); managed {
+		return nil, errors.New("wallet already exists")
+	}
+	_, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: req.PublicKey})
+	if err == nil {
+		return nil, errors.New("wallet already exists")
+	}
+	if herr, ok := err.(*horizonclient.Error); !ok || herr.Response.StatusCode != http.StatusNotFound {
+		return nil, errors.New("failed to fetch wallet account details: " + err.Error())
+	}
+
+	masterKP, err := keypair.ParseFull(s.Config.MasterSecret)
+	if err != nil {
+		return nil, errors.New("invalid master secret key: " + err.Error())
+	}
+	// Sponsoring the account's own reserve would need the client's signature, so it is always funded in full
+	resp, err := s.submitMasterOperations(masterKP, []txnbuild.Operation{&txnbuild.CreateAccount{
+		Destination:   req.PublicKey,
+		Amount:        walletStartingBalance,
+		SourceAccount: masterKP.Address(),
+	}}, masterKP)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.ActivateWalletResponse{
+		PublicKey:       req.PublicKey,
+		TransactionHash: resp.Hash,
+		StartingBalance: walletStartingBalance,
+		Message:         "Wallet activated successfully. Hash: " + resp.Hash,
+	}
+	if req.Trustline {
+		// An account created in ledger L starts at sequence L<<32, so the trustline transaction can be built
+		// without waiting for Horizon to ingest the new account
+		response.Trustline, err = s.sponsoredTrustline(masterKP, req.PublicKey, req.TrustlineLimit, int64(resp.Ledger)<<32)
+		if err != nil {
+			return nil, errors.New("wallet activated, but " + err.Error())
+		}
+		response.Message = "Wallet activated successfully; sign and submit the trustline transaction to trust USDC. Hash: " + resp.Hash
+	}
+	s.Audit.Record("tenant:"+tenantID, "wallet.activated", req.PublicKey, map[string]string{
+		"transaction_hash": resp.Hash,
+		"trustline":        strconv.FormatBool(req.Trustline),
+	})
+	return response, nil
+}
+
+// sponsoredTrustline builds a USDC trustline transaction from the account, with its reserve sponsored by
+// the master account and signed by it
+func (s *WalletService) sponsoredTrustline(masterKP *keypair.Full, publicKey, limit string, sequence int64) (*models.BuildTransactionResponse, error) {
+	line, err := s.Config.USDCAsset.ToChangeTrustAsset()
+	if err != nil {
+		return nil, errors.New("failed to create USDC trustline asset: " + err.Error())
+	}
+	baseFee := s.Fees.BaseFee()
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount: &txnbuild.SimpleAccount{AccountID: publicKey, Sequence: sequence},
+		Operations: []txnbuild.Operation{
+			&txnbuild.BeginSponsoringFutureReserves{SponsoredID: publicKey, SourceAccount: masterKP.Address()},
+			&txnbuild.ChangeTrust{Line: line, Limit: limit},
+			&txnbuild.EndSponsoringFutureReserves{},
+		},
+		BaseFee:              baseFee,
+		Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(defaultTransactionTimeout)},
+		IncrementSequenceNum: true,
+	})
+	if err != nil {
+		return nil, errors.New("failed to build trustline transaction: " + err.Error())
+	}
+	if tx, err = tx.Sign(s.networkPassphrase(), masterKP); err != nil {
+		return nil, errors.New("failed to sign trustline transaction: " + err.Error())
+	}
+	envelope, err := tx.Base64()
+	if err != nil {
+		return nil, errors.New("failed to encode trustline transaction: " + err.Error())
+	}
+	hash, err := tx.HashHex(s.networkPassphrase())
+	if err != nil {
+		return nil, errors.New("failed to hash trustline transaction: " + err.Error())
+	}
+	return &models.BuildTransactionResponse{
+		EnvelopeXDR:       envelope,
+		Hash:              hash,
+		NetworkPassphrase: s.networkPassphrase(),
+		SourcePublicKey:   publicKey,
+		Sequence:          tx.SequenceNumber(),
+		Operations:        len(tx.Operations()),
+		BaseFee:           baseFee,
+		MaxFee:            amount.StringFromInt64(tx.MaxFee()),
+		ExpiresAt:         time.Unix(tx.Timebounds().MaxTime, 0).UTC(),
+	}, nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"log"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+)
+
+// adminTransferLimit is how many recent transfers the admin API returns per wallet
+const adminTransferLimit = 50
+
+// ListManagedWallets returns every wallet the service custodies, optionally limited to one tenant and to
+// wallets carrying all of the given labels and metadata values
+func (s *WalletService) ListManagedWallets(tenantID string, labels []string, metadata map[string]string) []models.ManagedWalletResponse {
+	publicKeys := s.Registry.PublicKeys()
+	if tenantID != "" {
+		publicKeys = s.Registry.TenantPublicKeys(tenantID)
+	}
+	wallets := make([]models.ManagedWalletResponse, 0, len(publicKeys))
+	for _, publicKey := range publicKeys {
+		if !s.matchesMetadata(publicKey, labels, metadata) {
+			continue
+		}
+		tenant, _ := s.Registry.TenantOf(publicKey)
+		walletLabels, walletMetadata, _ := s.Registry.Metadata(publicKey)
+		wallets = append(wallets, models.ManagedWalletResponse{
+			PublicKey:   publicKey,
+			TenantID:    tenant,
+			Deactivated: s.isDeactivated(publicKey),
+			Labels:      walletLabels,
+			Metadata:    walletMetadata,
+		})
+	}
+	return wallets
+}
+
+// WalletTransfers returns a wallet's most recent payments, newest first
+func (s *WalletService) WalletTransfers(publicKey string) ([]models.PaymentRecord, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	payments, err := s.Config.HorizonClient.Payments(horizonclient.OperationRequest{
+		ForAccount: publicKey,
+		Order:      horizonclient.OrderDesc,
+		Limit:      adminTransferLimit,
+	})
+	if err != nil {
+		return nil, errors.New("failed to fetch payments: " + err.Error())
+	}
+	records := []models.PaymentRecord{}
+	for _, op := range payments.Embedded.Records {
+		if record, ok := paymentRecord(op, publicKey); ok {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// QueueStatus summarizes pending transfer reviews, unsettled internal transfers and worker schedules
+func (s *WalletService) QueueStatus() models.QueueStatusResponse {
+	status := models.QueueStatusResponse{
+		NetSettlementIntervalSeconds:  s.Config.NetSettlementInterval.Seconds(),
+		ClaimableSweepIntervalSeconds: s.Config.ClaimableSweepInterval.Seconds(),
+		SLOBreached:                   s.SLO.Status().Breached,
+	}
+	for _, review := range s.ListTransferReviews() {
+		if review.Status == models.ReviewPending {
+			status.PendingTransferReviews++
+		}
+	}
+
+	if positions, err := s.internalPositions(); err != nil {
+		log.Printf("queue status: %v", err)
+	} else {
+		for _, wallets := range positions {
+			status.UnsettledInternalLegs += len(netSettlementLegs(wallets))
+		}
+	}
+
+	if s.Outbox != nil {
+		outbox := s.Outbox.Status()
+		status.OutboxPending, status.OutboxOldestAgeSeconds = outbox.Pending, outbox.OldestAgeSeconds
+	}
+	return status
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+)
+
+// Alerter delivers operational alerts to operators
+type Alerter interface {
+	Alert(title, message string)
+}
+
+// LogAlerter writes alerts to the application log
+type LogAlerter struct{}
+
+// Alert logs the alert
+func (LogAlerter) Alert(title, message string) {
+	log.Printf("ALERT %s: %s", title, message)
+}
+
+// MultiAlerter delivers each alert to every Alerter it holds
+type MultiAlerter []Alerter
+
+// Alert forwards the alert to each Alerter in turn
+func (m MultiAlerter) Alert(title, message string) {
+	for _, alerter := range m {
+		alerter.Alert(title, message)
+	}
+}
+
+// WebhookAlerter posts alerts as signed JSON to an operator webhook, in the background
+type WebhookAlerter struct {
+	URL    string
+	Secret string
+}
+
+// Alert posts the alert, retrying like a transfer callback
+func (a WebhookAlerter) Alert(title, message string) {
+	body, err := json.Marshal(models.OperatorAlert{ID: newID(), Title: title, Message: message, RaisedAt: time.Now().UTC()})
+	if err != nil {
+		log.Printf("alert webhook: failed to encode alert: %v", err)
+		return
+	}
+	go func() {
+		if err := deliverSignedWith(a.URL, []string{a.Secret}, body, maxCallbackAttempts, nil); err != nil {
+			log.Printf("alert webhook %s failed: %v", a.URL, err)
+		}
+	}()
+}
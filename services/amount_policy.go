@@ -0,0 +1,65 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+
+	"github.com/stellar/go/amount"
+)
+
+// Reasons an amount is rejected; for a transfer's amount controllers map these to dedicated error codes
+const (
+	amountNotPositive = "must be a positive number"
+	amountPrecision   = "exceeds 7 decimal places of stroop precision"
+	amountBelowStroop = "below the minimum representable unit of 0.0000001"
+	amountTooLarge    = "exceeds the maximum of 922337203685.4775807"
+
+	errAmountBelowMinimum = "amount below minimum transfer of "
+)
+
+// decimalAmount is the only amount format accepted: plain decimal digits with an optional fractional part,
+// without a sign, exponent or grouping. The length bound keeps big.Rat parsing cheap.
+var decimalAmount = regexp.MustCompile(`^[0-9]{1,32}(\.[0-9]{1,32})?$`)
+
+// parseAmount strictly parses a positive amount of an asset into stroops. Amounts finer than a stroop or
+// beyond the int64 range of the protocol are rejected rather than rounded or clamped; errors name field.
+func parseAmount(field, value string) (int64, error) {
+	invalid := func(reason string) error {
+		return errors.New("invalid " + field + ": " + reason)
+	}
+	if !decimalAmount.MatchString(value) {
+		return 0, invalid(amountNotPositive)
+	}
+	parsed, ok := new(big.Rat).SetString(value)
+	if !ok || parsed.Sign() <= 0 {
+		return 0, invalid(amountNotPositive)
+	}
+	stroops := parsed.Mul(parsed, big.NewRat(amount.One, 1))
+	if stroops.Cmp(big.NewRat(1, 1)) < 0 {
+		return 0, invalid(amountBelowStroop)
+	}
+	if !stroops.IsInt() {
+		return 0, invalid(amountPrecision)
+	}
+	if !stroops.Num().IsInt64() {
+		return 0, invalid(amountTooLarge)
+	}
+	return stroops.Num().Int64(), nil
+}
+
+// checkTransferAmount validates a transfer amount against Stellar's stroop precision and the operator's
+// per-asset minimum, so that unrepresentable amounts are rejected before Horizon sees them
+func (s *WalletService) checkTransferAmount(value, asset string) error {
+	stroops, err := parseAmount("amount", value)
+	if err != nil {
+		return err
+	}
+
+	if minimum, ok := s.Config.MinTransferAmounts[asset]; ok {
+		if minStroops, err := amount.ParseInt64(minimum); err == nil && stroops < minStroops {
+			return errors.New(errAmountBelowMinimum + minimum + " for " + asset)
+		}
+	}
+	return nil
+}
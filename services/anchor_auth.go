@@ -0,0 +1,294 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/stellartoml"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+const (
+	// anchorTomlCacheTTL is how long an anchor's stellar.toml is reused before refetching
+	anchorTomlCacheTTL = time.Hour
+	// anchorTokenExpiryMargin is how long before its expiry a cached anchor token is replaced
+	anchorTokenExpiryMargin = time.Minute
+)
+
+// errAnchorUnauthenticated refuses to act with a wallet's custodied key for a request that did not
+// authenticate its tenant
+var errAnchorUnauthenticated = errors.New("authenticated tenant required")
+
+// homeDomainPattern matches a domain, with an optional port as some test anchors use
+var homeDomainPattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*(:[0-9]{1,5})?$`)
+
+type anchorTomlCacheEntry struct {
+	toml      *stellartoml.Response
+	expiresAt time.Time
+	// quoteServer is the ANCHOR_QUOTE_SERVER, read once a SEP-38 quote is first requested
+	quoteServer string
+}
+
+// AnchorService works with third-party anchors on behalf of custodied wallets, authenticating them with
+// SEP-10 and caching the anchors' stellar.toml files and the JWTs they issue. Deposits and withdrawals
+// started with anchors are tracked until the anchor finishes them.
+type AnchorService struct {
+	Wallets    *WalletService
+	TomlClient stellartoml.ClientInterface
+	Client     *http.Client
+	// KYC, when set, registers wallets with anchors as SEP-31 senders from their stored KYC information
+	KYC *KYCService
+
+	mu     sync.Mutex
+	tomls  map[string]anchorTomlCacheEntry
+	tokens map[string]models.AnchorAuthResponse
+
+	// txMu guards the anchor transactions, loaded from the archive store the first time they are needed
+	txMu         sync.Mutex
+	txLoaded     bool
+	transactions map[string]models.AnchorTransaction
+
+	// quoteMu guards the firm SEP-38 quotes, loaded from the archive store the first time they are needed
+	quoteMu      sync.Mutex
+	quotesLoaded bool
+	quotes       map[string]models.AnchorQuote
+}
+
+// NewAnchorService creates a new AnchorService instance
+func NewAnchorService(wallets *WalletService) *AnchorService {
+	return &AnchorService{
+		Wallets:    wallets,
+		TomlClient: stellartoml.DefaultClient,
+		Client:     &http.Client{Timeout: 30 * time.Second},
+		tomls:      make(map[string]anchorTomlCacheEntry),
+		tokens:     make(map[string]models.AnchorAuthResponse),
+	}
+}
+
+// anchorToml returns an anchor's stellar.toml, checking it is published for this service's network
+func (s *AnchorService) anchorToml(homeDomain string) (*stellartoml.Response, error) {
+	if !homeDomainPattern.MatchString(homeDomain) {
+		return nil, errors.New("invalid home domain: " + homeDomain)
+	}
+	homeDomain = strings.ToLower(homeDomain)
+	s.mu.Lock()
+	entry, ok := s.tomls[homeDomain]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.toml, nil
+	}
+
+	toml, err := s.TomlClient.GetStellarToml(homeDomain)
+	if err != nil {
+		return nil, errors.New("failed to fetch anchor stellar.toml: " + err.Error())
+	}
+	if toml.NetworkPassphrase != "" && toml.NetworkPassphrase != s.Wallets.networkPassphrase() {
+		return nil, errors.New("invalid anchor: its stellar.toml is for another network")
+	}
+	s.mu.Lock()
+	s.tomls[homeDomain] = anchorTomlCacheEntry{toml: toml, expiresAt: time.Now().Add(anchorTomlCacheTTL)}
+	s.mu.Unlock()
+	return toml, nil
+}
+
+// ownedWallet returns the keypair of a wallet custodied for the tenant. Anchors are called with the wallet's
+// custodied key, so tenantID must be the authenticated tenant; it is empty when the request is not.
+func (s *AnchorService) ownedWallet(tenantID, publicKey string) (*keypair.Full, error) {
+	if tenantID == "" {
+		return nil, errAnchorUnauthenticated
+	}
+	kp, err := s.Wallets.authorizedSigner(tenantID, publicKey, "")
+	if err != nil {
+		return nil, errors.New("wallet not found")
+	}
+	return kp, nil
+}
+
+// Authenticate returns a JWT the anchor at homeDomain issued to one of a tenant's wallets, reusing a cached
+// token until shortly before it expires
+func (s *AnchorService) Authenticate(tenantID, publicKey, homeDomain string) (*models.AnchorAuthResponse, error) {
+	kp, err := s.ownedWallet(tenantID, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return s.authenticate(kp, homeDomain)
+}
+
+// authenticate returns a cached or fresh anchor JWT for a wallet
+func (s *AnchorService) authenticate(kp *keypair.Full, homeDomain string) (*models.AnchorAuthResponse, error) {
+	toml, err := s.anchorToml(homeDomain)
+	if err != nil {
+		return nil, err
+	}
+	homeDomain = strings.ToLower(homeDomain)
+	cacheKey := kp.Address() + "@" + homeDomain
+	s.mu.Lock()
+	cached, ok := s.tokens[cacheKey]
+	s.mu.Unlock()
+	if ok && cached.ExpiresAt != nil && time.Now().Add(anchorTokenExpiryMargin).Before(*cached.ExpiresAt) {
+		return &cached, nil
+	}
+
+	token, err := s.exchangeChallenge(kp, homeDomain, toml)
+	if err != nil {
+		return nil, err
+	}
+	response := models.AnchorAuthResponse{Wallet: kp.Address(), HomeDomain: homeDomain, Token: token, ExpiresAt: jwtExpiry(token)}
+	if response.ExpiresAt != nil {
+		s.mu.Lock()
+		s.tokens[cacheKey] = response
+		s.mu.Unlock()
+	}
+	s.Wallets.Audit.Record("wallet:"+kp.Address(), "anchor.authenticated", homeDomain, nil)
+	return &response, nil
+}
+
+// exchangeChallenge runs the SEP-10 flow: it fetches a challenge transaction, checks it is a well-formed
+// challenge for this wallet signed by the anchor's SIGNING_KEY, signs it with the wallet and exchanges it
+// for a JWT
+func (s *AnchorService) exchangeChallenge(kp *keypair.Full, homeDomain string, toml *stellartoml.Response) (string, error) {
+	if toml.WebAuthEndpoint == "" || toml.SigningKey == "" {
+		return "", errors.New("invalid anchor: its stellar.toml has no WEB_AUTH_ENDPOINT or SIGNING_KEY")
+	}
+	endpoint, err := url.Parse(toml.WebAuthEndpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return "", errors.New("invalid anchor: WEB_AUTH_ENDPOINT must be an https URL")
+	}
+
+	query := url.Values{"account": {kp.Address()}, "home_domain": {homeDomain}}
+	var challenge struct {
+		Transaction       string `json:"transaction"`
+		NetworkPassphrase string `json:"network_passphrase"`
+	}
+	if err := s.anchorRequest(http.MethodGet, toml.WebAuthEndpoint+"?"+query.Encode(), "", nil, &challenge); err != nil {
+		return "", errors.New("failed to fetch SEP-10 challenge: " + err.Error())
+	}
+	passphrase := s.Wallets.networkPassphrase()
+	if challenge.NetworkPassphrase != "" && challenge.NetworkPassphrase != passphrase {
+		return "", errors.New("anchor sent an invalid SEP-10 challenge: issued for another network")
+	}
+	tx, clientAccount, _, _, err := txnbuild.ReadChallengeTx(challenge.Transaction, toml.SigningKey, passphrase, endpoint.Host, []string{homeDomain})
+	if err != nil {
+		return "", errors.New("anchor sent an invalid SEP-10 challenge: " + err.Error())
+	}
+	if clientAccount != kp.Address() {
+		return "", errors.New("anchor sent an invalid SEP-10 challenge: issued for another account")
+	}
+	signed, err := tx.Sign(passphrase, kp)
+	if err != nil {
+		return "", errors.New("failed to sign SEP-10 challenge: " + err.Error())
+	}
+	signedXDR, err := signed.Base64()
+	if err != nil {
+		return "", errors.New("failed to encode SEP-10 challenge: " + err.Error())
+	}
+
+	var result struct {
+		Token string `json:"token"`
+	}
+	if err := s.anchorRequest(http.MethodPost, toml.WebAuthEndpoint, "", map[string]string{"transaction": signedXDR}, &result); err != nil {
+		return "", errors.New("anchor rejected SEP-10 challenge: " + err.Error())
+	}
+	if result.Token == "" {
+		return "", errors.New("anchor rejected SEP-10 challenge: no token returned")
+	}
+	return result.Token, nil
+}
+
+// anchorRequest calls an anchor API with an optional JWT and JSON body, decoding the JSON response into out.
+// Anchor errors are returned with the anchor's error message.
+func (s *AnchorService) anchorRequest(method, endpoint, token string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequest(method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return s.doAnchorRequest(req, token, out)
+}
+
+// doAnchorRequest sends a prepared anchor API request with an optional JWT, decoding the JSON response into
+// out
+func (s *AnchorService) doAnchorRequest(req *http.Request, token string, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &anchorResponseError{Status: resp.Status, StatusCode: resp.StatusCode, Body: data}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return errors.New("invalid response: " + err.Error())
+	}
+	return nil
+}
+
+// anchorResponseError is an anchor's error response; some, like SEP-6's customer information requests,
+// carry details in the body
+type anchorResponseError struct {
+	Status     string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *anchorResponseError) Error() string {
+	var anchorErr struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(e.Body, &anchorErr) == nil && anchorErr.Error != "" {
+		return e.Status + ": " + anchorErr.Error
+	}
+	return e.Status
+}
+
+// jwtExpiry reads the exp claim of a JWT without verifying it, which only the issuing anchor can do
+func jwtExpiry(token string) *time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if json.Unmarshal(payload, &claims) != nil || claims.Exp == 0 {
+		return nil
+	}
+	expiry := time.Unix(claims.Exp, 0).UTC()
+	return &expiry
+}
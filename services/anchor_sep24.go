@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/stellartoml"
+)
+
+// sep24ReservedFields are request fields the service sets itself, which Fields may not override
+var sep24ReservedFields = map[string]bool{
+	"asset_code": true, "asset_issuer": true, "account": true, "amount": true, "lang": true,
+	"claimable_balance_supported": true,
+}
+
+// sep24Server returns an anchor's SEP-24 TRANSFER_SERVER_0024
+func sep24Server(toml *stellartoml.Response) (string, error) {
+	if toml.TransferServer0024 == "" {
+		return "", errors.New("invalid anchor: its stellar.toml has no TRANSFER_SERVER_0024")
+	}
+	return strings.TrimSuffix(toml.TransferServer0024, "/"), nil
+}
+
+// InteractiveDeposit starts a SEP-24 deposit into one of a tenant's wallets. The returned transaction's
+// interactive URL is where the user continues with the anchor; it is tracked until the anchor completes it.
+func (s *AnchorService) InteractiveDeposit(tenantID, publicKey string, req models.AnchorInteractiveRequest) (*models.AnchorTransaction, error) {
+	return s.startInteractive(tenantID, publicKey, models.AnchorKindDeposit, req)
+}
+
+// InteractiveWithdraw starts a SEP-24 withdrawal from one of a tenant's wallets. Once the user finishes the
+// anchor's flow and the anchor names the account and memo to pay, the wallet's payment is sent.
+func (s *AnchorService) InteractiveWithdraw(tenantID, publicKey string, req models.AnchorInteractiveRequest) (*models.AnchorTransaction, error) {
+	return s.startInteractive(tenantID, publicKey, models.AnchorKindWithdrawal, req)
+}
+
+// startInteractive creates a SEP-24 interactive session for a wallet and records its transaction
+func (s *AnchorService) startInteractive(tenantID, publicKey, kind string, req models.AnchorInteractiveRequest) (*models.AnchorTransaction, error) {
+	kp, err := s.ownedWallet(tenantID, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	asset, err := parseAsset(req.Asset)
+	if err != nil {
+		return nil, errors.New("invalid asset: " + err.Error())
+	}
+	if req.Amount != "" {
+		if stroops, err := amount.ParseInt64(req.Amount); err != nil || stroops <= 0 {
+			return nil, errors.New("invalid amount: " + req.Amount)
+		}
+	} else if kind == models.AnchorKindWithdrawal {
+		return nil, errors.New("invalid amount: required for withdrawals, which pay at most this amount")
+	}
+	body := make(map[string]string, len(req.Fields)+6)
+	for key, value := range req.Fields {
+		if sep24ReservedFields[key] {
+			return nil, errors.New("invalid fields: " + key + " is set by the service")
+		}
+		body[key] = value
+	}
+	body["asset_code"] = sep6AssetCode(asset)
+	if !asset.IsNative() {
+		body["asset_issuer"] = asset.GetIssuer()
+	}
+	body["account"] = publicKey
+	if req.Amount != "" {
+		body["amount"] = req.Amount
+	}
+	if req.Lang != "" {
+		body["lang"] = req.Lang
+	}
+	path := "/transactions/withdraw/interactive"
+	if kind == models.AnchorKindDeposit {
+		path = "/transactions/deposit/interactive"
+		body["claimable_balance_supported"] = "true"
+	}
+
+	homeDomain, err := s.anchorForAsset(asset, req.HomeDomain)
+	if err != nil {
+		return nil, err
+	}
+	toml, err := s.anchorToml(homeDomain)
+	if err != nil {
+		return nil, err
+	}
+	server, err := sep24Server(toml)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := s.authenticate(kp, homeDomain)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Type string `json:"type"`
+		URL  string `json:"url"`
+		ID   string `json:"id"`
+	}
+	if err := s.anchorRequest(http.MethodPost, server+path, auth.Token, body, &response); err != nil {
+		return nil, errors.New("anchor rejected the request: " + err.Error())
+	}
+	if response.ID == "" || response.URL == "" {
+		return nil, errors.New("anchor rejected the request: no transaction id or interactive url returned")
+	}
+
+	now := time.Now().UTC()
+	tx := models.AnchorTransaction{
+		ID:                  newID(),
+		TenantID:            tenantID,
+		Wallet:              publicKey,
+		HomeDomain:          homeDomain,
+		Protocol:            models.AnchorProtocolSEP24,
+		Kind:                kind,
+		Asset:               assetString(asset),
+		Amount:              req.Amount,
+		AnchorTransactionID: response.ID,
+		Status:              models.AnchorStatusIncomplete,
+		InteractiveURL:      response.URL,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	if err := s.putTransaction(tx); err != nil {
+		return nil, err
+	}
+	s.Wallets.Audit.Record("tenant:"+tenantID, "anchor."+kind+"_started", homeDomain, map[string]string{
+		"wallet":                publicKey,
+		"asset":                 tx.Asset,
+		"protocol":              tx.Protocol,
+		"anchor_transaction_id": response.ID,
+	})
+	return &tx, nil
+}
@@ -0,0 +1,182 @@
+package services_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+	"github.com/saif727/stellar-wallet-backend/testsupport"
+	"github.com/stellar/go/clients/stellartoml"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/txnbuild"
+)
+
+// fakeAnchor serves SEP-10 challenges and the SEP-24 withdraw and transaction endpoints, reporting status
+// as the state of its only transaction
+type fakeAnchor struct {
+	*httptest.Server
+	signer *keypair.Full
+
+	mu     sync.Mutex
+	status map[string]string
+}
+
+func newFakeAnchor(t *testing.T) *fakeAnchor {
+	t.Helper()
+	a := &fakeAnchor{signer: testsupport.NewKeypair()}
+	a.Server = httptest.NewTLSServer(http.HandlerFunc(a.serve))
+	t.Cleanup(a.Close)
+	return a
+}
+
+// homeDomain is the anchor's home domain, which is also its web auth domain
+func (a *fakeAnchor) homeDomain() string {
+	return strings.TrimPrefix(a.URL, "https://")
+}
+
+// setStatus replaces the status the anchor reports for its transaction
+func (a *fakeAnchor) setStatus(status map[string]string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.status = status
+}
+
+// GetStellarToml serves the anchor's stellar.toml for any domain
+func (a *fakeAnchor) GetStellarToml(string) (*stellartoml.Response, error) {
+	return &stellartoml.Response{
+		NetworkPassphrase:  network.TestNetworkPassphrase,
+		SigningKey:         a.signer.Address(),
+		WebAuthEndpoint:    a.URL + "/auth",
+		TransferServer0024: a.URL + "/sep24",
+	}, nil
+}
+
+// GetStellarTomlByAddress is not used by the anchor service
+func (a *fakeAnchor) GetStellarTomlByAddress(string) (*stellartoml.Response, error) {
+	return nil, http.ErrNotSupported
+}
+
+func (a *fakeAnchor) serve(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/auth":
+		challenge, err := txnbuild.BuildChallengeTx(a.signer.Seed(), r.URL.Query().Get("account"), a.homeDomain(),
+			r.URL.Query().Get("home_domain"), network.TestNetworkPassphrase, 5*time.Minute, nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		envelope, _ := challenge.Base64()
+		json.NewEncoder(w).Encode(map[string]string{"transaction": envelope, "network_passphrase": network.TestNetworkPassphrase})
+	case r.Method == http.MethodPost && r.URL.Path == "/auth":
+		json.NewEncoder(w).Encode(map[string]string{"token": "test-token"})
+	case r.Method == http.MethodPost && r.URL.Path == "/sep24/transactions/withdraw/interactive":
+		json.NewEncoder(w).Encode(map[string]string{"type": "interactive_customer_info_needed", "url": a.URL + "/flow", "id": "anchor-tx"})
+	case r.Method == http.MethodGet && r.URL.Path == "/sep24/transaction":
+		a.mu.Lock()
+		status := a.status
+		a.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"transaction": status})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestInteractiveWithdrawPayment(t *testing.T) {
+	anchorAccount, otherAccount := testsupport.NewKeypair().Address(), testsupport.NewKeypair().Address()
+	tests := []struct {
+		name string
+		// statuses are reported by the anchor on successive polls
+		statuses    []map[string]string
+		wantPayment string
+		wantErr     string
+	}{
+		{
+			name:        "pays the amount the user settled on",
+			statuses:    []map[string]string{{"amount_in": "20", "withdraw_anchor_account": anchorAccount}},
+			wantPayment: "20",
+		},
+		{
+			name:     "refuses more than the requested amount",
+			statuses: []map[string]string{{"amount_in": "30", "withdraw_anchor_account": anchorAccount}},
+			wantErr:  "more than the requested 25",
+		},
+		{
+			name:     "refuses an invalid anchor account",
+			statuses: []map[string]string{{"amount_in": "20", "withdraw_anchor_account": "GINVALID"}},
+			wantErr:  "invalid anchor account",
+		},
+		{
+			name: "keeps paying the account first named",
+			statuses: []map[string]string{
+				{"amount_in": "30", "withdraw_anchor_account": anchorAccount},
+				{"amount_in": "20", "withdraw_anchor_account": otherAccount},
+			},
+			wantPayment: "20",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			horizon, wallets := newFundedService(t, nil)
+			usdc := wallets.Config.USDCAsset
+			wallet := newTenantWallet(t, horizon, wallets, "acme", "50")
+			for _, account := range []string{anchorAccount, otherAccount} {
+				horizon.SetAccount(testsupport.NewAccount(account, 1,
+					testsupport.NativeBalance("10"), testsupport.CreditBalance(usdc, "0")))
+			}
+			created := len(horizon.Submitted())
+
+			anchor := newFakeAnchor(t)
+			service := services.NewAnchorService(wallets)
+			service.TomlClient, service.Client = anchor, anchor.Client()
+
+			started, err := service.InteractiveWithdraw("acme", wallet.Address(), models.AnchorInteractiveRequest{
+				Asset:      usdc.Code + ":" + usdc.Issuer,
+				Amount:     "25",
+				HomeDomain: anchor.homeDomain(),
+			})
+			if err != nil {
+				t.Fatalf("InteractiveWithdraw() error = %v", err)
+			}
+			for _, status := range tt.statuses {
+				status["id"], status["status"] = "anchor-tx", models.AnchorStatusPendingUserTransferStart
+				anchor.setStatus(status)
+				service.Poll()
+			}
+
+			tx, err := service.GetTransaction("acme", wallet.Address(), started.ID)
+			if err != nil {
+				t.Fatalf("GetTransaction() error = %v", err)
+			}
+			if tx.WithdrawAnchorAccount != tt.statuses[0]["withdraw_anchor_account"] {
+				t.Errorf("anchor account = %s, want the first one named, %s", tx.WithdrawAnchorAccount, tt.statuses[0]["withdraw_anchor_account"])
+			}
+			var payments []*txnbuild.Payment
+			for _, envelope := range horizon.Submitted()[created:] {
+				payments = append(payments, decodePayments(t, envelope)...)
+			}
+			if tt.wantErr != "" {
+				if !strings.Contains(tx.PaymentError, tt.wantErr) {
+					t.Errorf("payment error = %q, want %q", tx.PaymentError, tt.wantErr)
+				}
+				if len(payments) != 0 || tx.PaymentSubmittedAt != nil {
+					t.Errorf("paid %d payments, want none", len(payments))
+				}
+				return
+			}
+			if tx.PaymentTransactionHash == "" || tx.PaymentError != "" {
+				t.Errorf("payment hash = %q, error = %q, want a hash", tx.PaymentTransactionHash, tx.PaymentError)
+			}
+			if len(payments) != 1 || payments[0].Destination != anchorAccount || !sameAmount(payments[0].Amount, tt.wantPayment) {
+				t.Fatalf("payments = %v, want one of %s to the anchor", payments, tt.wantPayment)
+			}
+		})
+	}
+}
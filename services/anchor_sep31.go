@@ -0,0 +1,335 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/stellartoml"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// sep31AssetInfo is how a receiving anchor's GET /info describes an asset it accepts
+type sep31AssetInfo struct {
+	Enabled         bool        `json:"enabled"`
+	QuotesSupported bool        `json:"quotes_supported"`
+	QuotesRequired  bool        `json:"quotes_required"`
+	FeeFixed        json.Number `json:"fee_fixed"`
+	FeePercent      json.Number `json:"fee_percent"`
+	MinAmount       json.Number `json:"min_amount"`
+	MaxAmount       json.Number `json:"max_amount"`
+	FundingMethods  []string    `json:"funding_methods"`
+	SEP12           struct {
+		Sender struct {
+			Types map[string]json.RawMessage `json:"types"`
+		} `json:"sender"`
+		Receiver struct {
+			Types map[string]json.RawMessage `json:"types"`
+		} `json:"receiver"`
+	} `json:"sep12"`
+}
+
+// sep31Server returns an anchor's SEP-31 DIRECT_PAYMENT_SERVER
+func sep31Server(toml *stellartoml.Response) (string, error) {
+	if toml.DirectPaymentServer == "" {
+		return "", errors.New("invalid anchor: its stellar.toml has no DIRECT_PAYMENT_SERVER")
+	}
+	return strings.TrimSuffix(toml.DirectPaymentServer, "/"), nil
+}
+
+// sep31Session returns a receiving anchor's SEP-31 server, a JWT for the wallet and the anchor's terms for
+// the asset
+func (s *AnchorService) sep31Session(kp *keypair.Full, homeDomain string, asset txnbuild.Asset) (string, string, *sep31AssetInfo, error) {
+	toml, err := s.anchorToml(homeDomain)
+	if err != nil {
+		return "", "", nil, err
+	}
+	server, err := sep31Server(toml)
+	if err != nil {
+		return "", "", nil, err
+	}
+	auth, err := s.authenticate(kp, homeDomain)
+	if err != nil {
+		return "", "", nil, err
+	}
+	var info struct {
+		Receive map[string]sep31AssetInfo `json:"receive"`
+	}
+	if err := s.anchorRequest(http.MethodGet, server+"/info", auth.Token, nil, &info); err != nil {
+		return "", "", nil, errors.New("failed to fetch anchor SEP-31 info: " + err.Error())
+	}
+	assetInfo, ok := info.Receive[sep6AssetCode(asset)]
+	if !ok || !assetInfo.Enabled {
+		return "", "", nil, errors.New("invalid asset: the anchor does not receive " + assetString(asset) + " over SEP-31")
+	}
+	return server, auth.Token, &assetInfo, nil
+}
+
+// anchorAmount converts an amount from an anchor's JSON to stroops; ok is false when it is absent or invalid
+func anchorAmount(value json.Number) (int64, bool) {
+	if value == "" {
+		return 0, false
+	}
+	stroops, err := amount.ParseInt64(value.String())
+	return stroops, err == nil
+}
+
+// customerType picks the SEP-12 customer type of a sender or receiver: the requested one, or the anchor's
+// only type
+func customerType(role, requested string, types map[string]json.RawMessage) (string, error) {
+	if requested != "" || len(types) == 0 {
+		return requested, nil
+	}
+	if len(types) == 1 {
+		for name := range types {
+			return name, nil
+		}
+	}
+	return "", errors.New("invalid request: " + role + "_type is required, the anchor accepts " + strings.Join(slices.Sorted(maps.Keys(types)), ", "))
+}
+
+// SendQuote returns what a receiving anchor charges to pay out an asset sent over SEP-31, estimating the fee
+// for an amount when one is given
+func (s *AnchorService) SendQuote(tenantID, publicKey string, req models.AnchorSendQuoteRequest) (*models.AnchorSendQuoteResponse, error) {
+	kp, err := s.ownedWallet(tenantID, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	asset, err := parseAsset(req.Asset)
+	if err != nil {
+		return nil, errors.New("invalid asset: " + err.Error())
+	}
+	var stroops int64
+	if req.Amount != "" {
+		if stroops, err = amount.ParseInt64(req.Amount); err != nil || stroops <= 0 {
+			return nil, errors.New("invalid amount: " + req.Amount)
+		}
+	}
+	homeDomain := strings.ToLower(req.HomeDomain)
+	_, _, info, err := s.sep31Session(kp, homeDomain, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.AnchorSendQuoteResponse{
+		HomeDomain:      homeDomain,
+		Asset:           assetString(asset),
+		QuotesSupported: info.QuotesSupported,
+		QuotesRequired:  info.QuotesRequired,
+		FundingMethods:  info.FundingMethods,
+		SenderTypes:     slices.Sorted(maps.Keys(info.SEP12.Sender.Types)),
+		ReceiverTypes:   slices.Sorted(maps.Keys(info.SEP12.Receiver.Types)),
+	}
+	if minimum, ok := anchorAmount(info.MinAmount); ok {
+		response.MinAmount = amount.StringFromInt64(minimum)
+	}
+	if maximum, ok := anchorAmount(info.MaxAmount); ok {
+		response.MaxAmount = amount.StringFromInt64(maximum)
+	}
+	if stroops > 0 {
+		fee, _ := anchorAmount(info.FeeFixed)
+		if percent, err := info.FeePercent.Float64(); err == nil {
+			fee += int64(math.Round(float64(stroops) * percent / 100))
+		}
+		response.Amount = amount.StringFromInt64(stroops)
+		response.Fee = amount.StringFromInt64(fee)
+		response.AmountAfterFee = amount.StringFromInt64(max(stroops-fee, 0))
+	}
+	return response, nil
+}
+
+// Send sends a SEP-31 cross-border payment from one of a tenant's wallets: it registers the sender and
+// receiver with the receiving anchor over SEP-12, creates the anchor transaction, and pays the anchor with the
+// memo it requires once the anchor is ready for the payment. The transaction is tracked until the anchor has
+// paid out the receiver, at the rate of the firm SEP-38 quote the request names, if any.
+func (s *AnchorService) Send(tenantID, publicKey string, req models.AnchorSendRequest) (*models.AnchorTransaction, error) {
+	kp, err := s.ownedWallet(tenantID, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	asset, err := parseAsset(req.Asset)
+	if err != nil {
+		return nil, errors.New("invalid asset: " + err.Error())
+	}
+	stroops, err := amount.ParseInt64(req.Amount)
+	if err != nil || stroops <= 0 {
+		return nil, errors.New("invalid amount: " + req.Amount)
+	}
+	homeDomain := strings.ToLower(req.HomeDomain)
+	var quote *models.AnchorQuote
+	if req.QuoteID != "" {
+		if quote, err = s.openQuote(publicKey, req.QuoteID, models.AnchorQuoteContextSEP31); err != nil {
+			return nil, err
+		}
+		if quote.HomeDomain != homeDomain {
+			return nil, errors.New("invalid quote_id: the quote is from " + quote.HomeDomain)
+		}
+		if quote.SellAsset != sep38StellarAsset(asset) {
+			return nil, errors.New("invalid quote_id: the quote does not sell " + assetString(asset))
+		}
+		if !sameAmount(req.Amount, quote.SellAmount) {
+			return nil, errors.New("invalid amount: the quote sells " + quote.SellAmount)
+		}
+	}
+	server, token, info, err := s.sep31Session(kp, homeDomain, asset)
+	if err != nil {
+		return nil, err
+	}
+	if quote == nil && info.QuotesRequired {
+		return nil, errors.New("invalid request: quote_id is required, the anchor only accepts " + assetString(asset) + " at a SEP-38 quote's rate")
+	}
+	if quote != nil && !info.QuotesSupported && !info.QuotesRequired {
+		return nil, errors.New("invalid quote_id: the anchor does not accept quotes for " + assetString(asset))
+	}
+	if minimum, ok := anchorAmount(info.MinAmount); ok && stroops < minimum {
+		return nil, errors.New("invalid amount: the anchor's minimum is " + amount.StringFromInt64(minimum))
+	}
+	if maximum, ok := anchorAmount(info.MaxAmount); ok && maximum > 0 && stroops > maximum {
+		return nil, errors.New("invalid amount: the anchor's maximum is " + amount.StringFromInt64(maximum))
+	}
+
+	senderID, receiverID := req.SenderID, req.ReceiverID
+	if senderID == "" {
+		senderType, err := customerType("sender", req.SenderType, info.SEP12.Sender.Types)
+		if err != nil {
+			return nil, err
+		}
+		if senderID, err = s.senderID(tenantID, publicKey, homeDomain, senderType); err != nil {
+			return nil, err
+		}
+	}
+	if receiverID == "" {
+		if len(req.ReceiverFields) == 0 || s.KYC == nil {
+			return nil, errors.New("invalid request: receiver_id or receiver_fields is required")
+		}
+		receiverType, err := customerType("receiver", req.ReceiverType, info.SEP12.Receiver.Types)
+		if err != nil {
+			return nil, err
+		}
+		if receiverID, err = s.KYC.registerReceiver(kp, homeDomain, receiverType, req.ReceiverFields); err != nil {
+			return nil, err
+		}
+	}
+
+	body := map[string]string{
+		"amount":      amount.StringFromInt64(stroops),
+		"asset_code":  sep6AssetCode(asset),
+		"sender_id":   senderID,
+		"receiver_id": receiverID,
+	}
+	if !asset.IsNative() {
+		body["asset_issuer"] = asset.GetIssuer()
+	}
+	if req.FundingMethod != "" {
+		body["funding_method"] = req.FundingMethod
+	}
+	if quote != nil {
+		body["quote_id"] = quote.AnchorQuoteID
+		body["destination_asset"] = quote.BuyAsset
+	}
+	var response struct {
+		ID               string `json:"id"`
+		StellarAccountID string `json:"stellar_account_id"`
+		StellarMemoType  string `json:"stellar_memo_type"`
+		StellarMemo      string `json:"stellar_memo"`
+	}
+	err = s.anchorRequest(http.MethodPost, server+"/transactions", token, body, &response)
+	var anchorErr *anchorResponseError
+	if errors.As(err, &anchorErr) && anchorErr.StatusCode == http.StatusBadRequest {
+		var problem struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(anchorErr.Body, &problem) == nil && problem.Error == "customer_info_needed" {
+			return nil, errors.New("customer information required by anchor: check the sender's and receiver's KYC status")
+		}
+	}
+	if err != nil {
+		return nil, errors.New("anchor rejected the payment: " + err.Error())
+	}
+	if response.ID == "" {
+		return nil, errors.New("anchor rejected the payment: no transaction id returned")
+	}
+	if response.StellarAccountID != "" {
+		if _, err := keypair.ParseAddress(response.StellarAccountID); err != nil {
+			return nil, errors.New("anchor returned an invalid payment account: " + response.StellarAccountID)
+		}
+	}
+
+	now := time.Now().UTC()
+	tx := models.AnchorTransaction{
+		ID:                    newID(),
+		TenantID:              tenantID,
+		Wallet:                publicKey,
+		HomeDomain:            homeDomain,
+		Protocol:              models.AnchorProtocolSEP31,
+		Kind:                  models.AnchorKindSend,
+		Asset:                 assetString(asset),
+		Amount:                req.Amount,
+		AnchorTransactionID:   response.ID,
+		Status:                models.AnchorStatusPendingSender,
+		WithdrawAnchorAccount: response.StellarAccountID,
+		WithdrawMemo:          response.StellarMemo,
+		WithdrawMemoType:      response.StellarMemoType,
+		SenderID:              senderID,
+		ReceiverID:            receiverID,
+		CreatedAt:             now,
+		UpdatedAt:             now,
+	}
+	if quote != nil {
+		tx.QuoteID = quote.ID
+	}
+	if err := s.putTransaction(tx); err != nil {
+		return nil, err
+	}
+	if quote != nil {
+		if err := s.useQuote(quote.ID, tx.ID); err != nil {
+			return nil, err
+		}
+	}
+	s.Wallets.Audit.Record("tenant:"+tenantID, "anchor.send_started", homeDomain, map[string]string{
+		"wallet":                publicKey,
+		"asset":                 tx.Asset,
+		"amount":                tx.Amount,
+		"anchor_transaction_id": response.ID,
+	})
+	// Anchors implementing SEP-31 v2 name the account to pay only through the transaction endpoint
+	if tx.WithdrawAnchorAccount == "" {
+		if err := s.refreshTransaction(tx); err != nil {
+			log.Printf("anchor send %s: %v", tx.ID, err)
+		}
+		if updated, ok, err := s.transaction(tx.ID); err == nil && ok {
+			return &updated, nil
+		}
+		return &tx, nil
+	}
+	return s.payAnchor(kp, tx.ID)
+}
+
+// senderID returns the wallet's SEP-12 customer ID at a receiving anchor, submitting its stored KYC
+// information to register it there first if needed
+func (s *AnchorService) senderID(tenantID, publicKey, homeDomain, senderType string) (string, error) {
+	if s.KYC == nil {
+		return "", errors.New("invalid request: sender_id is required")
+	}
+	customer, err := s.KYC.GetCustomer(tenantID, publicKey)
+	if err != nil {
+		return "", err
+	}
+	if record, ok := customer.Anchors[homeDomain]; ok && record.CustomerID != "" && (senderType == "" || record.Type == senderType) {
+		return record.CustomerID, nil
+	}
+	record, err := s.KYC.Submit(tenantID, publicKey, models.KYCSubmitRequest{HomeDomain: homeDomain, Type: senderType})
+	if err != nil {
+		return "", err
+	}
+	return record.CustomerID, nil
+}
@@ -0,0 +1,455 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/stellartoml"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+const anchorQuotesKey = "anchors/quotes.json"
+
+// iso4217AssetPattern matches a SEP-38 off-chain fiat asset
+var iso4217AssetPattern = regexp.MustCompile(`^iso4217:[A-Z]{3}$`)
+
+// sep38Contexts are the anchor flows quotes can be requested for
+var sep38Contexts = map[string]bool{
+	models.AnchorQuoteContextSEP6:  true,
+	models.AnchorQuoteContextSEP31: true,
+}
+
+// sep38Price is a SEP-38 price or firm quote as an anchor returns it
+type sep38Price struct {
+	ID         string                `json:"id"`
+	ExpiresAt  time.Time             `json:"expires_at"`
+	TotalPrice string                `json:"total_price"`
+	Price      string                `json:"price"`
+	SellAsset  string                `json:"sell_asset"`
+	SellAmount string                `json:"sell_amount"`
+	BuyAsset   string                `json:"buy_asset"`
+	BuyAmount  string                `json:"buy_amount"`
+	Fee        models.AnchorQuoteFee `json:"fee"`
+}
+
+// sep38StellarAsset is how SEP-38 names a Stellar asset
+func sep38StellarAsset(asset txnbuild.Asset) string {
+	if asset.IsNative() {
+		return "stellar:native"
+	}
+	return "stellar:" + asset.GetCode() + ":" + asset.GetIssuer()
+}
+
+// sep38Asset converts a requested asset to its SEP-38 name: an iso4217 asset as is, or a Stellar asset given
+// as native or CODE:ISSUER, with or without the stellar: prefix
+func sep38Asset(value string) (string, error) {
+	if iso4217AssetPattern.MatchString(value) {
+		return value, nil
+	}
+	if strings.HasPrefix(value, "iso4217:") {
+		return "", errors.New("invalid asset: " + value + " is not an ISO 4217 currency")
+	}
+	asset, err := parseAsset(strings.TrimPrefix(value, "stellar:"))
+	if err != nil {
+		return "", errors.New("invalid asset: " + err.Error())
+	}
+	return sep38StellarAsset(asset), nil
+}
+
+// sameAmount reports whether two amounts are equal to the stroop
+func sameAmount(a, b string) bool {
+	x, errA := amount.ParseInt64(a)
+	y, errB := amount.ParseInt64(b)
+	return errA == nil && errB == nil && x == y
+}
+
+// sep38Params validates a price or quote request and builds its SEP-38 parameters
+func sep38Params(req models.AnchorQuoteRequest) (map[string]string, error) {
+	if !sep38Contexts[req.Context] {
+		return nil, errors.New("invalid context: " + req.Context + ", must be sep6 or sep31")
+	}
+	sellAsset, err := sep38Asset(req.SellAsset)
+	if err != nil {
+		return nil, err
+	}
+	buyAsset, err := sep38Asset(req.BuyAsset)
+	if err != nil {
+		return nil, err
+	}
+	if sellAsset == buyAsset {
+		return nil, errors.New("invalid request: sell_asset and buy_asset are the same asset")
+	}
+	if (req.SellAmount == "") == (req.BuyAmount == "") {
+		return nil, errors.New("invalid request: exactly one of sell_amount and buy_amount is required")
+	}
+	params := map[string]string{"context": req.Context, "sell_asset": sellAsset, "buy_asset": buyAsset}
+	for key, value := range map[string]string{
+		"sell_amount":          req.SellAmount,
+		"buy_amount":           req.BuyAmount,
+		"sell_delivery_method": req.SellDeliveryMethod,
+		"buy_delivery_method":  req.BuyDeliveryMethod,
+		"country_code":         req.CountryCode,
+	} {
+		if value != "" {
+			params[key] = value
+		}
+	}
+	for _, key := range []string{"sell_amount", "buy_amount"} {
+		if value, ok := params[key]; ok {
+			if stroops, err := amount.ParseInt64(value); err != nil || stroops <= 0 {
+				return nil, errors.New("invalid amount: " + value)
+			}
+		}
+	}
+	return params, nil
+}
+
+// sep38Server returns an anchor's SEP-38 ANCHOR_QUOTE_SERVER. stellartoml.Response has no field for it, so
+// it is read from the anchor's stellar.toml directly, and cached for as long as the rest of the file.
+func (s *AnchorService) sep38Server(homeDomain string) (string, error) {
+	if _, err := s.anchorToml(homeDomain); err != nil {
+		return "", err
+	}
+	homeDomain = strings.ToLower(homeDomain)
+	s.mu.Lock()
+	entry := s.tomls[homeDomain]
+	s.mu.Unlock()
+	if entry.quoteServer != "" {
+		return entry.quoteServer, nil
+	}
+
+	resp, err := s.Client.Get("https://" + homeDomain + stellartoml.WellKnownPath)
+	if err != nil {
+		return "", errors.New("failed to fetch anchor stellar.toml: " + err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New("failed to fetch anchor stellar.toml: " + resp.Status)
+	}
+	var fields struct {
+		AnchorQuoteServer string `toml:"ANCHOR_QUOTE_SERVER"`
+	}
+	if _, err := toml.NewDecoder(io.LimitReader(resp.Body, stellartoml.StellarTomlMaxSize)).Decode(&fields); err != nil {
+		return "", errors.New("failed to fetch anchor stellar.toml: " + err.Error())
+	}
+	if fields.AnchorQuoteServer == "" {
+		return "", errors.New("invalid anchor: its stellar.toml has no ANCHOR_QUOTE_SERVER")
+	}
+	server := strings.TrimSuffix(fields.AnchorQuoteServer, "/")
+	s.mu.Lock()
+	if cached, ok := s.tomls[homeDomain]; ok {
+		cached.quoteServer = server
+		s.tomls[homeDomain] = cached
+	}
+	s.mu.Unlock()
+	return server, nil
+}
+
+// sep38Session returns an anchor's quote server and a JWT for the wallet
+func (s *AnchorService) sep38Session(kp *keypair.Full, homeDomain string) (string, string, error) {
+	server, err := s.sep38Server(homeDomain)
+	if err != nil {
+		return "", "", err
+	}
+	auth, err := s.authenticate(kp, homeDomain)
+	if err != nil {
+		return "", "", err
+	}
+	return server, auth.Token, nil
+}
+
+// Prices returns the assets an anchor buys for an amount of an asset, at indicative SEP-38 prices
+func (s *AnchorService) Prices(tenantID, publicKey string, req models.AnchorPricesRequest) (*models.AnchorPricesResponse, error) {
+	kp, err := s.ownedWallet(tenantID, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	sellAsset, err := sep38Asset(req.SellAsset)
+	if err != nil {
+		return nil, err
+	}
+	if stroops, err := amount.ParseInt64(req.SellAmount); err != nil || stroops <= 0 {
+		return nil, errors.New("invalid amount: " + req.SellAmount)
+	}
+	homeDomain := strings.ToLower(req.HomeDomain)
+	server, token, err := s.sep38Session(kp, homeDomain)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{"sell_asset": {sellAsset}, "sell_amount": {req.SellAmount}}
+	for key, value := range map[string]string{
+		"sell_delivery_method": req.SellDeliveryMethod,
+		"buy_delivery_method":  req.BuyDeliveryMethod,
+		"country_code":         req.CountryCode,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	var response struct {
+		BuyAssets []models.AnchorPrice `json:"buy_assets"`
+	}
+	if err := s.anchorRequest(http.MethodGet, server+"/prices?"+query.Encode(), token, nil, &response); err != nil {
+		return nil, errors.New("failed to fetch anchor prices: " + err.Error())
+	}
+	if response.BuyAssets == nil {
+		response.BuyAssets = []models.AnchorPrice{}
+	}
+	return &models.AnchorPricesResponse{
+		HomeDomain: homeDomain,
+		SellAsset:  sellAsset,
+		SellAmount: req.SellAmount,
+		BuyAssets:  response.BuyAssets,
+	}, nil
+}
+
+// Price returns an anchor's indicative SEP-38 price for converting one asset into another. The anchor is not
+// bound by it; CreateQuote asks for a firm quote.
+func (s *AnchorService) Price(tenantID, publicKey string, req models.AnchorPriceRequest) (*models.AnchorPriceResponse, error) {
+	kp, err := s.ownedWallet(tenantID, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	params, err := sep38Params(models.AnchorQuoteRequest{
+		Context:            req.Context,
+		SellAsset:          req.SellAsset,
+		BuyAsset:           req.BuyAsset,
+		SellAmount:         req.SellAmount,
+		BuyAmount:          req.BuyAmount,
+		SellDeliveryMethod: req.SellDeliveryMethod,
+		BuyDeliveryMethod:  req.BuyDeliveryMethod,
+		CountryCode:        req.CountryCode,
+	})
+	if err != nil {
+		return nil, err
+	}
+	homeDomain := strings.ToLower(req.HomeDomain)
+	server, token, err := s.sep38Session(kp, homeDomain)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	var price sep38Price
+	if err := s.anchorRequest(http.MethodGet, server+"/price?"+query.Encode(), token, nil, &price); err != nil {
+		return nil, errors.New("failed to fetch anchor price: " + err.Error())
+	}
+	return &models.AnchorPriceResponse{
+		HomeDomain: homeDomain,
+		Context:    req.Context,
+		SellAsset:  params["sell_asset"],
+		SellAmount: price.SellAmount,
+		BuyAsset:   params["buy_asset"],
+		BuyAmount:  price.BuyAmount,
+		Price:      price.Price,
+		TotalPrice: price.TotalPrice,
+		Fee:        price.Fee,
+	}, nil
+}
+
+// CreateQuote requests a firm SEP-38 quote from an anchor and stores it, so its ID can be passed to a SEP-6
+// or SEP-31 flow of the quote's context before it expires
+func (s *AnchorService) CreateQuote(tenantID, publicKey string, req models.AnchorQuoteRequest) (*models.AnchorQuote, error) {
+	kp, err := s.ownedWallet(tenantID, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	params, err := sep38Params(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.ExpireAfter != nil {
+		if !req.ExpireAfter.After(time.Now()) {
+			return nil, errors.New("invalid request: expire_after must be in the future")
+		}
+		params["expire_after"] = req.ExpireAfter.UTC().Format(time.RFC3339)
+	}
+	homeDomain := strings.ToLower(req.HomeDomain)
+	server, token, err := s.sep38Session(kp, homeDomain)
+	if err != nil {
+		return nil, err
+	}
+	var response sep38Price
+	if err := s.anchorRequest(http.MethodPost, server+"/quote", token, params, &response); err != nil {
+		return nil, errors.New("anchor rejected the quote request: " + err.Error())
+	}
+	if response.ID == "" || response.ExpiresAt.IsZero() {
+		return nil, errors.New("anchor rejected the quote request: no quote id or expiry returned")
+	}
+	if (response.SellAsset != "" && response.SellAsset != params["sell_asset"]) ||
+		(response.BuyAsset != "" && response.BuyAsset != params["buy_asset"]) {
+		return nil, errors.New("anchor returned a quote for other assets")
+	}
+
+	quote := models.AnchorQuote{
+		ID:            newID(),
+		TenantID:      tenantID,
+		Wallet:        publicKey,
+		HomeDomain:    homeDomain,
+		Context:       req.Context,
+		AnchorQuoteID: response.ID,
+		SellAsset:     params["sell_asset"],
+		SellAmount:    response.SellAmount,
+		BuyAsset:      params["buy_asset"],
+		BuyAmount:     response.BuyAmount,
+		Price:         response.Price,
+		TotalPrice:    response.TotalPrice,
+		Fee:           response.Fee,
+		ExpiresAt:     response.ExpiresAt.UTC(),
+		CreatedAt:     time.Now().UTC(),
+	}
+	if err := s.putQuote(quote); err != nil {
+		return nil, err
+	}
+	s.Wallets.Audit.Record("tenant:"+tenantID, "anchor.quote_created", homeDomain, map[string]string{
+		"wallet":          publicKey,
+		"context":         quote.Context,
+		"sell_asset":      quote.SellAsset,
+		"sell_amount":     quote.SellAmount,
+		"buy_asset":       quote.BuyAsset,
+		"buy_amount":      quote.BuyAmount,
+		"anchor_quote_id": response.ID,
+	})
+	return &quote, nil
+}
+
+// loadQuotesLocked reads the firm quotes from the archive store the first time they are needed; s.quoteMu
+// must be held
+func (s *AnchorService) loadQuotesLocked() error {
+	if s.quotesLoaded {
+		return nil
+	}
+	quotes := make(map[string]models.AnchorQuote)
+	if s.Wallets.Archive != nil {
+		data, err := s.Wallets.Archive.Get(anchorQuotesKey)
+		switch {
+		case errors.Is(err, errArchiveNotFound):
+		case err != nil:
+			return errors.New("failed to read anchor quotes: " + err.Error())
+		default:
+			if err := json.Unmarshal(data, &quotes); err != nil {
+				return errors.New("failed to decode anchor quotes: " + err.Error())
+			}
+		}
+	}
+	s.quotes = quotes
+	s.quotesLoaded = true
+	return nil
+}
+
+// putQuote persists a new or updated firm quote
+func (s *AnchorService) putQuote(quote models.AnchorQuote) error {
+	s.quoteMu.Lock()
+	defer s.quoteMu.Unlock()
+	return s.putQuoteLocked(quote)
+}
+
+// putQuoteLocked persists a new or updated firm quote; s.quoteMu must be held
+func (s *AnchorService) putQuoteLocked(quote models.AnchorQuote) error {
+	if err := s.loadQuotesLocked(); err != nil {
+		return err
+	}
+	quotes := make(map[string]models.AnchorQuote, len(s.quotes)+1)
+	for id, existing := range s.quotes {
+		quotes[id] = existing
+	}
+	quotes[quote.ID] = quote
+	if s.Wallets.Archive != nil {
+		data, err := json.Marshal(quotes)
+		if err != nil {
+			return errors.New("failed to encode anchor quotes: " + err.Error())
+		}
+		if err := s.Wallets.Archive.Put(anchorQuotesKey, data); err != nil {
+			return errors.New("failed to persist anchor quotes: " + err.Error())
+		}
+	}
+	s.quotes = quotes
+	return nil
+}
+
+// ListQuotes returns the firm quotes of one of a tenant's wallets, newest first
+func (s *AnchorService) ListQuotes(tenantID, publicKey string) (*models.AnchorQuotesResponse, error) {
+	if _, err := s.ownedWallet(tenantID, publicKey); err != nil {
+		return nil, err
+	}
+	s.quoteMu.Lock()
+	defer s.quoteMu.Unlock()
+	if err := s.loadQuotesLocked(); err != nil {
+		return nil, err
+	}
+	quotes := []models.AnchorQuote{}
+	for _, quote := range s.quotes {
+		if quote.Wallet == publicKey {
+			quotes = append(quotes, quote)
+		}
+	}
+	sort.Slice(quotes, func(i, j int) bool { return quotes[i].CreatedAt.After(quotes[j].CreatedAt) })
+	return &models.AnchorQuotesResponse{Quotes: quotes}, nil
+}
+
+// GetQuote returns one of a wallet's firm quotes
+func (s *AnchorService) GetQuote(tenantID, publicKey, id string) (*models.AnchorQuote, error) {
+	if _, err := s.ownedWallet(tenantID, publicKey); err != nil {
+		return nil, err
+	}
+	s.quoteMu.Lock()
+	defer s.quoteMu.Unlock()
+	if err := s.loadQuotesLocked(); err != nil {
+		return nil, err
+	}
+	quote, ok := s.quotes[id]
+	if !ok || quote.Wallet != publicKey {
+		return nil, errors.New("anchor quote not found")
+	}
+	return &quote, nil
+}
+
+// openQuote returns a wallet's firm quote for a flow of the given context, checking it is unused and has not
+// expired
+func (s *AnchorService) openQuote(publicKey, id, context string) (*models.AnchorQuote, error) {
+	s.quoteMu.Lock()
+	defer s.quoteMu.Unlock()
+	if err := s.loadQuotesLocked(); err != nil {
+		return nil, err
+	}
+	quote, ok := s.quotes[id]
+	switch {
+	case !ok || quote.Wallet != publicKey:
+		return nil, errors.New("invalid quote_id: no quote " + id)
+	case quote.Context != context:
+		return nil, errors.New("invalid quote_id: the quote is for " + quote.Context + " flows")
+	case quote.TransactionID != "":
+		return nil, errors.New("invalid quote_id: the quote was used by anchor transaction " + quote.TransactionID)
+	case !time.Now().Before(quote.ExpiresAt):
+		return nil, errors.New("invalid quote_id: the quote expired at " + quote.ExpiresAt.Format(time.RFC3339))
+	}
+	return &quote, nil
+}
+
+// useQuote records the anchor transaction a firm quote was used for, so it is not used again
+func (s *AnchorService) useQuote(id, transactionID string) error {
+	s.quoteMu.Lock()
+	defer s.quoteMu.Unlock()
+	if err := s.loadQuotesLocked(); err != nil {
+		return err
+	}
+	quote, ok := s.quotes[id]
+	if !ok {
+		return errors.New("anchor quote not found")
+	}
+	quote.TransactionID = transactionID
+	return s.putQuoteLocked(quote)
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/clients/stellartoml"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// sep6ReservedFields are request parameters the service sets itself, which Fields may not override
+var sep6ReservedFields = map[string]bool{
+	"asset_code": true, "account": true, "amount": true, "type": true, "dest": true, "dest_extra": true,
+	"memo": true, "memo_type": true, "claimable_balance_supported": true, "quote_id": true, "source_asset": true,
+	"destination_asset": true,
+}
+
+// sep6Response is an anchor's answer to a SEP-6 deposit or withdraw request
+type sep6Response struct {
+	ID           string                              `json:"id"`
+	How          string                              `json:"how"`
+	Instructions map[string]models.AnchorInstruction `json:"instructions"`
+	AccountID    string                              `json:"account_id"`
+	MemoType     string                              `json:"memo_type"`
+	Memo         string                              `json:"memo"`
+}
+
+// sep6CustomerInfo is the body of the 403 a SEP-6 anchor answers with when it needs KYC information first
+type sep6CustomerInfo struct {
+	Type        string   `json:"type"`
+	Fields      []string `json:"fields"`
+	Status      string   `json:"status"`
+	MoreInfoURL string   `json:"more_info_url"`
+}
+
+// anchorForAsset finds the anchor of an asset: the given home domain, or else the home domain set on the
+// asset's issuing account
+func (s *AnchorService) anchorForAsset(asset txnbuild.Asset, homeDomain string) (string, error) {
+	if homeDomain != "" {
+		return strings.ToLower(homeDomain), nil
+	}
+	if asset.IsNative() {
+		return "", errors.New("invalid request: home_domain is required for native assets")
+	}
+	issuer, err := s.Wallets.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: asset.GetIssuer()})
+	if err != nil {
+		return "", errors.New("failed to fetch asset issuer: " + err.Error())
+	}
+	if issuer.HomeDomain == "" {
+		return "", errors.New("invalid request: the asset's issuer sets no home domain, set home_domain")
+	}
+	return strings.ToLower(issuer.HomeDomain), nil
+}
+
+// sep6Server returns an anchor's SEP-6 TRANSFER_SERVER
+func sep6Server(toml *stellartoml.Response) (string, error) {
+	if toml.TransferServer == "" {
+		return "", errors.New("invalid anchor: its stellar.toml has no TRANSFER_SERVER")
+	}
+	return strings.TrimSuffix(toml.TransferServer, "/"), nil
+}
+
+// sep6AssetCode is how SEP-6 names an asset
+func sep6AssetCode(asset txnbuild.Asset) string {
+	if asset.IsNative() {
+		return "native"
+	}
+	return asset.GetCode()
+}
+
+// sep6Query builds the parameters of a SEP-6 request from the caller's extra fields and the service's own
+func sep6Query(fields map[string]string, params map[string]string) (url.Values, error) {
+	query := url.Values{}
+	for key, value := range fields {
+		if sep6ReservedFields[key] {
+			return nil, errors.New("invalid fields: " + key + " is set by the service")
+		}
+		query.Set(key, value)
+	}
+	for key, value := range params {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	return query, nil
+}
+
+// sep6Start sends a SEP-6 deposit or withdraw request for a wallet, authenticating it with the anchor first.
+// Anchors that need KYC information first answer with the SEP-12 fields to provide.
+func (s *AnchorService) sep6Start(kp *keypair.Full, homeDomain, path string, query url.Values) (*sep6Response, error) {
+	toml, err := s.anchorToml(homeDomain)
+	if err != nil {
+		return nil, err
+	}
+	server, err := sep6Server(toml)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := s.authenticate(kp, homeDomain)
+	if err != nil {
+		return nil, err
+	}
+	var response sep6Response
+	err = s.anchorRequest(http.MethodGet, server+path+"?"+query.Encode(), auth.Token, nil, &response)
+	var anchorErr *anchorResponseError
+	if errors.As(err, &anchorErr) && anchorErr.StatusCode == http.StatusForbidden {
+		var info sep6CustomerInfo
+		if jsonErr := json.Unmarshal(anchorErr.Body, &info); jsonErr == nil {
+			switch info.Type {
+			case "non_interactive_customer_info_needed":
+				return nil, errors.New("customer information required by anchor: " + strings.Join(info.Fields, ", "))
+			case "customer_info_status":
+				message := "customer information " + info.Status + " at anchor"
+				if info.MoreInfoURL != "" {
+					message += ", see " + info.MoreInfoURL
+				}
+				return nil, errors.New(message)
+			}
+		}
+	}
+	if err != nil {
+		return nil, errors.New("anchor rejected the request: " + err.Error())
+	}
+	if response.ID == "" {
+		return nil, errors.New("anchor rejected the request: no transaction id returned")
+	}
+	return &response, nil
+}
+
+// Deposit starts a SEP-6 deposit of an asset into one of a tenant's wallets. The returned transaction holds
+// the anchor's instructions for the off-chain deposit, and is tracked until the anchor completes it.
+func (s *AnchorService) Deposit(tenantID, publicKey string, req models.AnchorDepositRequest) (*models.AnchorTransaction, error) {
+	kp, err := s.ownedWallet(tenantID, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	asset, err := parseAsset(req.Asset)
+	if err != nil {
+		return nil, errors.New("invalid asset: " + err.Error())
+	}
+	if req.Amount != "" {
+		if stroops, err := amount.ParseInt64(req.Amount); err != nil || stroops <= 0 {
+			return nil, errors.New("invalid amount: " + req.Amount)
+		}
+	}
+	var quote *models.AnchorQuote
+	if req.QuoteID != "" {
+		if quote, err = s.openQuote(publicKey, req.QuoteID, models.AnchorQuoteContextSEP6); err != nil {
+			return nil, err
+		}
+		if quote.BuyAsset != sep38StellarAsset(asset) {
+			return nil, errors.New("invalid quote_id: the quote does not buy " + assetString(asset))
+		}
+		if req.Amount != "" && !sameAmount(req.Amount, quote.SellAmount) {
+			return nil, errors.New("invalid amount: the quote sells " + quote.SellAmount)
+		}
+		if req.HomeDomain == "" {
+			req.HomeDomain = quote.HomeDomain
+		}
+	}
+	homeDomain, err := s.anchorForAsset(asset, req.HomeDomain)
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]string{
+		"asset_code":                  sep6AssetCode(asset),
+		"account":                     publicKey,
+		"amount":                      req.Amount,
+		"type":                        req.Type,
+		"claimable_balance_supported": "true",
+	}
+	path := "/deposit"
+	if quote != nil {
+		if quote.HomeDomain != homeDomain {
+			return nil, errors.New("invalid quote_id: the quote is from " + quote.HomeDomain)
+		}
+		// A deposit at a quote's rate names the off-chain asset deposited and the asset received instead
+		path = "/deposit-exchange"
+		delete(params, "asset_code")
+		params["destination_asset"] = sep6AssetCode(asset)
+		params["source_asset"] = quote.SellAsset
+		params["amount"] = quote.SellAmount
+		params["quote_id"] = quote.AnchorQuoteID
+	}
+	query, err := sep6Query(req.Fields, params)
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.sep6Start(kp, homeDomain, path, query)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	tx := models.AnchorTransaction{
+		ID:                  newID(),
+		TenantID:            tenantID,
+		Wallet:              publicKey,
+		HomeDomain:          homeDomain,
+		Protocol:            models.AnchorProtocolSEP6,
+		Kind:                models.AnchorKindDeposit,
+		Asset:               assetString(asset),
+		Amount:              params["amount"],
+		AnchorTransactionID: response.ID,
+		Status:              models.AnchorStatusPendingUserTransferStart,
+		How:                 response.How,
+		Instructions:        response.Instructions,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	if quote != nil {
+		tx.QuoteID = quote.ID
+	}
+	if err := s.putTransaction(tx); err != nil {
+		return nil, err
+	}
+	if quote != nil {
+		if err := s.useQuote(quote.ID, tx.ID); err != nil {
+			return nil, err
+		}
+	}
+	s.Wallets.Audit.Record("tenant:"+tenantID, "anchor.deposit_started", homeDomain, map[string]string{
+		"wallet":                publicKey,
+		"asset":                 tx.Asset,
+		"anchor_transaction_id": response.ID,
+	})
+	return &tx, nil
+}
+
+// Withdraw starts a SEP-6 withdrawal of an asset from one of a tenant's wallets. Once the anchor names the
+// account and memo to pay, immediately or later through its transaction endpoint, the wallet's payment is
+// sent; the transaction is tracked until the anchor completes it.
+func (s *AnchorService) Withdraw(tenantID, publicKey string, req models.AnchorWithdrawRequest) (*models.AnchorTransaction, error) {
+	kp, err := s.ownedWallet(tenantID, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	asset, err := parseAsset(req.Asset)
+	if err != nil {
+		return nil, errors.New("invalid asset: " + err.Error())
+	}
+	if stroops, err := amount.ParseInt64(req.Amount); err != nil || stroops <= 0 {
+		return nil, errors.New("invalid amount: " + req.Amount)
+	}
+	var quote *models.AnchorQuote
+	if req.QuoteID != "" {
+		if quote, err = s.openQuote(publicKey, req.QuoteID, models.AnchorQuoteContextSEP6); err != nil {
+			return nil, err
+		}
+		if quote.SellAsset != sep38StellarAsset(asset) {
+			return nil, errors.New("invalid quote_id: the quote does not sell " + assetString(asset))
+		}
+		if !sameAmount(req.Amount, quote.SellAmount) {
+			return nil, errors.New("invalid amount: the quote sells " + quote.SellAmount)
+		}
+		if req.HomeDomain == "" {
+			req.HomeDomain = quote.HomeDomain
+		}
+	}
+	homeDomain, err := s.anchorForAsset(asset, req.HomeDomain)
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]string{
+		"asset_code": sep6AssetCode(asset),
+		"account":    publicKey,
+		"amount":     req.Amount,
+		"type":       req.Type,
+		"dest":       req.Dest,
+		"dest_extra": req.DestExtra,
+	}
+	path := "/withdraw"
+	if quote != nil {
+		if quote.HomeDomain != homeDomain {
+			return nil, errors.New("invalid quote_id: the quote is from " + quote.HomeDomain)
+		}
+		path = "/withdraw-exchange"
+		delete(params, "asset_code")
+		params["source_asset"] = sep6AssetCode(asset)
+		params["destination_asset"] = quote.BuyAsset
+		params["quote_id"] = quote.AnchorQuoteID
+	}
+	query, err := sep6Query(req.Fields, params)
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.sep6Start(kp, homeDomain, path, query)
+	if err != nil {
+		return nil, err
+	}
+	if response.AccountID != "" {
+		if _, err := keypair.ParseAddress(response.AccountID); err != nil {
+			return nil, errors.New("anchor returned an invalid withdrawal account: " + response.AccountID)
+		}
+	}
+
+	now := time.Now().UTC()
+	tx := models.AnchorTransaction{
+		ID:                    newID(),
+		TenantID:              tenantID,
+		Wallet:                publicKey,
+		HomeDomain:            homeDomain,
+		Protocol:              models.AnchorProtocolSEP6,
+		Kind:                  models.AnchorKindWithdrawal,
+		Asset:                 assetString(asset),
+		Amount:                req.Amount,
+		AnchorTransactionID:   response.ID,
+		Status:                models.AnchorStatusPendingUserTransferStart,
+		WithdrawAnchorAccount: response.AccountID,
+		WithdrawMemo:          response.Memo,
+		WithdrawMemoType:      response.MemoType,
+		CreatedAt:             now,
+		UpdatedAt:             now,
+	}
+	if quote != nil {
+		tx.QuoteID = quote.ID
+	}
+	if err := s.putTransaction(tx); err != nil {
+		return nil, err
+	}
+	if quote != nil {
+		if err := s.useQuote(quote.ID, tx.ID); err != nil {
+			return nil, err
+		}
+	}
+	s.Wallets.Audit.Record("tenant:"+tenantID, "anchor.withdrawal_started", homeDomain, map[string]string{
+		"wallet":                publicKey,
+		"asset":                 tx.Asset,
+		"amount":                req.Amount,
+		"anchor_transaction_id": response.ID,
+	})
+	if tx.WithdrawAnchorAccount == "" {
+		return &tx, nil
+	}
+	return s.payAnchor(kp, tx.ID)
+}
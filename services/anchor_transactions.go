@@ -0,0 +1,486 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
+)
+
+const anchorTransactionsKey = "anchors/transactions.json"
+
+// anchorTerminalStatuses are the anchor transaction statuses the anchor no longer changes
+var anchorTerminalStatuses = map[string]bool{
+	models.AnchorStatusCompleted: true,
+	models.AnchorStatusRefunded:  true,
+	models.AnchorStatusExpired:   true,
+	models.AnchorStatusError:     true,
+	models.AnchorStatusNoMarket:  true,
+	models.AnchorStatusTooSmall:  true,
+	models.AnchorStatusTooLarge:  true,
+}
+
+// anchorTransactionStatus is a transaction as an anchor's transaction endpoint reports it
+type anchorTransactionStatus struct {
+	ID                    string `json:"id"`
+	Status                string `json:"status"`
+	Message               string `json:"message"`
+	MoreInfoURL           string `json:"more_info_url"`
+	AmountIn              string `json:"amount_in"`
+	AmountOut             string `json:"amount_out"`
+	AmountFee             string `json:"amount_fee"`
+	StellarTransactionID  string `json:"stellar_transaction_id"`
+	WithdrawAnchorAccount string `json:"withdraw_anchor_account"`
+	WithdrawMemo          string `json:"withdraw_memo"`
+	WithdrawMemoType      string `json:"withdraw_memo_type"`
+	// StellarAccountID and StellarMemo are where SEP-31 anchors are paid
+	StellarAccountID string                              `json:"stellar_account_id"`
+	StellarMemo      string                              `json:"stellar_memo"`
+	StellarMemoType  string                              `json:"stellar_memo_type"`
+	Instructions     map[string]models.AnchorInstruction `json:"instructions"`
+}
+
+// loadTransactionsLocked reads the anchor transactions from the archive store the first time they are
+// needed; s.txMu must be held
+func (s *AnchorService) loadTransactionsLocked() error {
+	if s.txLoaded {
+		return nil
+	}
+	transactions := make(map[string]models.AnchorTransaction)
+	if s.Wallets.Archive != nil {
+		data, err := s.Wallets.Archive.Get(anchorTransactionsKey)
+		switch {
+		case errors.Is(err, errArchiveNotFound):
+		case err != nil:
+			return errors.New("failed to read anchor transactions: " + err.Error())
+		default:
+			if err := json.Unmarshal(data, &transactions); err != nil {
+				return errors.New("failed to decode anchor transactions: " + err.Error())
+			}
+		}
+	}
+	s.transactions = transactions
+	s.txLoaded = true
+	return nil
+}
+
+// putTransaction persists a new or updated anchor transaction
+func (s *AnchorService) putTransaction(tx models.AnchorTransaction) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+	return s.putTransactionLocked(tx)
+}
+
+// putTransactionLocked persists a new or updated anchor transaction; s.txMu must be held
+func (s *AnchorService) putTransactionLocked(tx models.AnchorTransaction) error {
+	if err := s.loadTransactionsLocked(); err != nil {
+		return err
+	}
+	transactions := make(map[string]models.AnchorTransaction, len(s.transactions)+1)
+	for id, existing := range s.transactions {
+		transactions[id] = existing
+	}
+	transactions[tx.ID] = tx
+	if s.Wallets.Archive != nil {
+		data, err := json.Marshal(transactions)
+		if err != nil {
+			return errors.New("failed to encode anchor transactions: " + err.Error())
+		}
+		if err := s.Wallets.Archive.Put(anchorTransactionsKey, data); err != nil {
+			return errors.New("failed to persist anchor transactions: " + err.Error())
+		}
+	}
+	s.transactions = transactions
+	return nil
+}
+
+// transaction returns an anchor transaction by ID
+func (s *AnchorService) transaction(id string) (models.AnchorTransaction, bool, error) {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+	if err := s.loadTransactionsLocked(); err != nil {
+		return models.AnchorTransaction{}, false, err
+	}
+	tx, ok := s.transactions[id]
+	return tx, ok, nil
+}
+
+// ListTransactions returns the anchor transactions of one of a tenant's wallets, newest first
+func (s *AnchorService) ListTransactions(tenantID, publicKey string) (*models.AnchorTransactionsResponse, error) {
+	if _, err := s.ownedWallet(tenantID, publicKey); err != nil {
+		return nil, err
+	}
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+	if err := s.loadTransactionsLocked(); err != nil {
+		return nil, err
+	}
+	response := &models.AnchorTransactionsResponse{Transactions: []models.AnchorTransaction{}}
+	for _, tx := range s.transactions {
+		if tx.Wallet == publicKey {
+			response.Transactions = append(response.Transactions, tx)
+		}
+	}
+	sort.Slice(response.Transactions, func(i, j int) bool {
+		return response.Transactions[i].CreatedAt.After(response.Transactions[j].CreatedAt)
+	})
+	return response, nil
+}
+
+// GetTransaction returns one of a wallet's anchor transactions
+func (s *AnchorService) GetTransaction(tenantID, publicKey, id string) (*models.AnchorTransaction, error) {
+	if _, err := s.ownedWallet(tenantID, publicKey); err != nil {
+		return nil, err
+	}
+	tx, ok, err := s.transaction(id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || tx.Wallet != publicKey {
+		return nil, errors.New("anchor transaction not found")
+	}
+	return &tx, nil
+}
+
+// Run polls the anchors of unfinished anchor transactions every interval until ctx is cancelled
+func (s *AnchorService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Poll()
+		}
+	}
+}
+
+// Poll refreshes every unfinished anchor transaction from its anchor, publishing an
+// anchor.transaction_updated event for each status change, and sends the payments of withdrawals and SEP-31
+// sends once their anchors are ready for them
+func (s *AnchorService) Poll() {
+	s.txMu.Lock()
+	if err := s.loadTransactionsLocked(); err != nil {
+		s.txMu.Unlock()
+		log.Printf("anchor tracker: %v", err)
+		return
+	}
+	var pending []models.AnchorTransaction
+	for _, tx := range s.transactions {
+		if !anchorTerminalStatuses[tx.Status] {
+			pending = append(pending, tx)
+		}
+	}
+	s.txMu.Unlock()
+
+	for _, tx := range pending {
+		if err := s.refreshTransaction(tx); err != nil {
+			log.Printf("anchor tracker: %s %s: %v", tx.HomeDomain, tx.AnchorTransactionID, err)
+		}
+	}
+}
+
+// refreshTransaction fetches a transaction's status from its anchor and records any change
+func (s *AnchorService) refreshTransaction(tx models.AnchorTransaction) error {
+	kp, ok := s.Wallets.Registry.Get(tx.Wallet)
+	if !ok {
+		return errors.New("wallet not found")
+	}
+	status, err := s.fetchTransactionStatus(kp, tx)
+	if err != nil {
+		return err
+	}
+
+	s.txMu.Lock()
+	current, ok := s.transactions[tx.ID]
+	if !ok {
+		s.txMu.Unlock()
+		return nil
+	}
+	updated := current
+	applyAnchorStatus(&updated, status)
+	statusChanged := updated.Status != current.Status
+	changed := statusChanged || updated.Message != current.Message || updated.MoreInfoURL != current.MoreInfoURL ||
+		updated.StellarTransactionID != current.StellarTransactionID || updated.AmountIn != current.AmountIn ||
+		updated.AmountOut != current.AmountOut || updated.WithdrawAnchorAccount != current.WithdrawAnchorAccount ||
+		updated.WithdrawMemo != current.WithdrawMemo
+	if changed {
+		updated.UpdatedAt = time.Now().UTC()
+		// The event is staged before the change is saved, so a crash in between restages it on the next poll
+		if statusChanged {
+			if err := s.Wallets.stageEvent(models.EventAnchorTransactionUpdated, updated.Wallet, updated.ID+":"+updated.Status, anchorEventData(updated)); err != nil {
+				s.txMu.Unlock()
+				return err
+			}
+		}
+		if err := s.putTransactionLocked(updated); err != nil {
+			s.txMu.Unlock()
+			return err
+		}
+	}
+	s.txMu.Unlock()
+
+	if awaitingPayment(updated) {
+		_, err := s.payAnchor(kp, updated.ID)
+		return err
+	}
+	return nil
+}
+
+// awaitingPayment reports whether the anchor is waiting for the wallet's payment of a withdrawal or send
+func awaitingPayment(tx models.AnchorTransaction) bool {
+	switch tx.Kind {
+	case models.AnchorKindWithdrawal:
+		return tx.Status == models.AnchorStatusPendingUserTransferStart
+	case models.AnchorKindSend:
+		return tx.Status == models.AnchorStatusPendingSender
+	}
+	return false
+}
+
+// anchorEventData is the payload of an anchor transaction's events
+func anchorEventData(tx models.AnchorTransaction) map[string]string {
+	data := map[string]string{
+		"id":                    tx.ID,
+		"home_domain":           tx.HomeDomain,
+		"protocol":              tx.Protocol,
+		"kind":                  tx.Kind,
+		"asset":                 tx.Asset,
+		"anchor_transaction_id": tx.AnchorTransactionID,
+		"status":                tx.Status,
+	}
+	for key, value := range map[string]string{
+		"message":                tx.Message,
+		"amount_in":              tx.AmountIn,
+		"amount_out":             tx.AmountOut,
+		"amount_fee":             tx.AmountFee,
+		"stellar_transaction_id": tx.StellarTransactionID,
+		"more_info_url":          tx.MoreInfoURL,
+	} {
+		if value != "" {
+			data[key] = value
+		}
+	}
+	return data
+}
+
+// fetchTransactionStatus asks a transaction's anchor for its current status
+func (s *AnchorService) fetchTransactionStatus(kp *keypair.Full, tx models.AnchorTransaction) (*anchorTransactionStatus, error) {
+	toml, err := s.anchorToml(tx.HomeDomain)
+	if err != nil {
+		return nil, err
+	}
+	var endpoint string
+	switch tx.Protocol {
+	case models.AnchorProtocolSEP6:
+		server, err := sep6Server(toml)
+		if err != nil {
+			return nil, err
+		}
+		endpoint = server + "/transaction?" + url.Values{"id": {tx.AnchorTransactionID}}.Encode()
+	case models.AnchorProtocolSEP24:
+		server, err := sep24Server(toml)
+		if err != nil {
+			return nil, err
+		}
+		endpoint = server + "/transaction?" + url.Values{"id": {tx.AnchorTransactionID}}.Encode()
+	case models.AnchorProtocolSEP31:
+		server, err := sep31Server(toml)
+		if err != nil {
+			return nil, err
+		}
+		endpoint = server + "/transactions/" + url.PathEscape(tx.AnchorTransactionID)
+	default:
+		return nil, errors.New("unsupported anchor protocol: " + tx.Protocol)
+	}
+	auth, err := s.authenticate(kp, tx.HomeDomain)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Transaction anchorTransactionStatus `json:"transaction"`
+	}
+	if err := s.anchorRequest(http.MethodGet, endpoint, auth.Token, nil, &response); err != nil {
+		return nil, errors.New("failed to fetch anchor transaction: " + err.Error())
+	}
+	return &response.Transaction, nil
+}
+
+// applyAnchorStatus copies the fields an anchor reported onto a transaction, keeping known values the
+// anchor left out
+func applyAnchorStatus(tx *models.AnchorTransaction, status *anchorTransactionStatus) {
+	if status.Status != "" {
+		tx.Status = status.Status
+	}
+	tx.Message = status.Message
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&tx.MoreInfoURL, status.MoreInfoURL},
+		{&tx.AmountIn, status.AmountIn},
+		{&tx.AmountOut, status.AmountOut},
+		{&tx.AmountFee, status.AmountFee},
+		{&tx.StellarTransactionID, status.StellarTransactionID},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+	// Where the wallet pays is fixed once the anchor names it, so a later status cannot redirect the payment
+	if tx.WithdrawAnchorAccount == "" {
+		switch {
+		case status.WithdrawAnchorAccount != "":
+			tx.WithdrawAnchorAccount, tx.WithdrawMemo, tx.WithdrawMemoType = status.WithdrawAnchorAccount, status.WithdrawMemo, status.WithdrawMemoType
+		case status.StellarAccountID != "":
+			tx.WithdrawAnchorAccount, tx.WithdrawMemo, tx.WithdrawMemoType = status.StellarAccountID, status.StellarMemo, status.StellarMemoType
+		}
+	}
+	if len(status.Instructions) > 0 {
+		tx.Instructions = status.Instructions
+	}
+}
+
+// checkAnchorPayment verifies what an anchor asks a wallet to pay before it is paid: the account must be a
+// Stellar account, and the amount must be the sell amount of the transaction's firm quote or, without one,
+// no more than the amount the transaction was started with
+func (s *AnchorService) checkAnchorPayment(tx models.AnchorTransaction, paymentAmount string) error {
+	if _, err := keypair.ParseAddress(tx.WithdrawAnchorAccount); err != nil {
+		return errors.New("anchor payment refused: invalid anchor account " + tx.WithdrawAnchorAccount)
+	}
+	stroops, err := amount.ParseInt64(paymentAmount)
+	if err != nil || stroops <= 0 {
+		return errors.New("anchor payment refused: invalid amount " + paymentAmount)
+	}
+	if tx.QuoteID != "" {
+		s.quoteMu.Lock()
+		defer s.quoteMu.Unlock()
+		if err := s.loadQuotesLocked(); err != nil {
+			return err
+		}
+		quote, ok := s.quotes[tx.QuoteID]
+		if !ok {
+			return errors.New("anchor payment refused: quote " + tx.QuoteID + " not found")
+		}
+		if !sameAmount(paymentAmount, quote.SellAmount) {
+			return errors.New("anchor payment refused: the anchor asks for " + paymentAmount + " but the quote sells " + quote.SellAmount)
+		}
+		return nil
+	}
+	requested, err := amount.ParseInt64(tx.Amount)
+	if err != nil {
+		return errors.New("anchor payment refused: no requested amount to check " + paymentAmount + " against")
+	}
+	if stroops > requested {
+		return errors.New("anchor payment refused: the anchor asks for " + paymentAmount + ", more than the requested " + tx.Amount)
+	}
+	return nil
+}
+
+// payAnchor sends a withdrawal's or SEP-31 send's payment to the anchor's account with the anchor's memo,
+// once checkAnchorPayment accepts what the anchor asks for. The payment is marked submitted before it is
+// sent, so it is sent at most once even across restarts; a payment interrupted by a restart keeps neither a
+// hash nor an error, for an operator to reconcile. The mark is cleared again when the payment failed before
+// it was submitted, so the next poll retries it.
+func (s *AnchorService) payAnchor(kp *keypair.Full, id string) (*models.AnchorTransaction, error) {
+	s.txMu.Lock()
+	if err := s.loadTransactionsLocked(); err != nil {
+		s.txMu.Unlock()
+		return nil, err
+	}
+	tx, ok := s.transactions[id]
+	if !ok {
+		s.txMu.Unlock()
+		return nil, errors.New("anchor transaction not found")
+	}
+	// The anchor's amount_in is what it expects to receive, which a SEP-24 user may have changed
+	paymentAmount := tx.AmountIn
+	if paymentAmount == "" {
+		paymentAmount = tx.Amount
+	}
+	if tx.PaymentSubmittedAt != nil || tx.WithdrawAnchorAccount == "" || paymentAmount == "" {
+		s.txMu.Unlock()
+		return &tx, nil
+	}
+	if err := s.checkAnchorPayment(tx, paymentAmount); err != nil {
+		defer s.txMu.Unlock()
+		if tx.PaymentError != err.Error() {
+			log.Printf("anchor %s %s: %v", tx.Kind, tx.ID, err)
+			tx.PaymentError, tx.UpdatedAt = err.Error(), time.Now().UTC()
+			if err := s.putTransactionLocked(tx); err != nil {
+				return nil, err
+			}
+		}
+		return &tx, nil
+	}
+	submittedAt := time.Now().UTC()
+	tx.PaymentSubmittedAt = &submittedAt
+	tx.UpdatedAt = submittedAt
+	if err := s.putTransactionLocked(tx); err != nil {
+		s.txMu.Unlock()
+		return nil, err
+	}
+	s.txMu.Unlock()
+
+	memo, memoType := tx.WithdrawMemo, tx.WithdrawMemoType
+	var err error
+	if memoType == "hash" {
+		memo, err = hexHashMemo(memo)
+	}
+	var response *models.TransferResponse
+	if err == nil {
+		response, err = s.Wallets.TransferFunds(models.TransferRequest{
+			FromSecretKey: kp.Seed(),
+			ToPublicKey:   tx.WithdrawAnchorAccount,
+			Amount:        paymentAmount,
+			SourceAsset:   tx.Asset,
+			Memo:          memo,
+			MemoType:      memoType,
+			ExternalID:    "anchor-" + tx.ID,
+		})
+	}
+
+	switch {
+	case err != nil:
+		tx.PaymentError = err.Error()
+		if failedBeforeSubmission(err) {
+			tx.PaymentSubmittedAt = nil
+		}
+	case response.TransactionHash == "":
+		tx.PaymentError = "payment not submitted: " + response.Status
+	default:
+		// A refusal recorded on an earlier poll no longer applies
+		tx.PaymentTransactionHash, tx.PaymentError = response.TransactionHash, ""
+	}
+	tx.UpdatedAt = time.Now().UTC()
+	s.txMu.Lock()
+	// Keep status changes the tracker recorded while the payment was in flight
+	if current, ok := s.transactions[tx.ID]; ok {
+		current.PaymentSubmittedAt, current.PaymentTransactionHash, current.PaymentError = tx.PaymentSubmittedAt, tx.PaymentTransactionHash, tx.PaymentError
+		current.UpdatedAt = tx.UpdatedAt
+		tx = current
+	}
+	saveErr := s.putTransactionLocked(tx)
+	s.txMu.Unlock()
+	if saveErr != nil {
+		return nil, saveErr
+	}
+	if err != nil {
+		log.Printf("anchor %s %s: payment to %s failed: %v", tx.Kind, tx.ID, tx.WithdrawAnchorAccount, err)
+		return &tx, nil
+	}
+	s.Wallets.Audit.Record("wallet:"+tx.Wallet, "anchor."+tx.Kind+"_paid", tx.HomeDomain, map[string]string{
+		"anchor_transaction_id": tx.AnchorTransactionID,
+		"transaction_hash":      tx.PaymentTransactionHash,
+	})
+	return &tx, nil
+}
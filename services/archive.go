@@ -0,0 +1,297 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+const (
+	// maxArchiveAttempts and archiveRetryDelay bound the retries of storing an archived transaction; the
+	// delay doubles after every failed attempt
+	maxArchiveAttempts = 5
+	archiveRetryDelay  = time.Second
+)
+
+// errArchiveNotFound is returned by ArchiveStore.Get for unknown keys
+var errArchiveNotFound = errors.New("archived transaction not found")
+
+// ArchiveStore persists transaction artifacts for long-term retention
+type ArchiveStore interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+}
+
+func archiveKey(hash string) string {
+	return "transactions/" + hash + ".json"
+}
+
+// archiveTransaction stores the signed envelope, Horizon result and receipt of a submission, retrying
+// with a doubling delay when the archive store fails
+func (s *WalletService) archiveTransaction(tx *txnbuild.Transaction, resp hProtocol.Transaction, submitErr error) {
+	if s.Archive == nil {
+		return
+	}
+	hash, err := tx.HashHex(s.networkPassphrase())
+	if err != nil {
+		log.Printf("archive: failed to hash transaction: %v", err)
+		return
+	}
+	envelope, err := tx.Base64()
+	if err != nil {
+		log.Printf("archive: failed to encode envelope %s: %v", hash, err)
+		return
+	}
+
+	record := models.ArchivedTransaction{
+		Hash:        hash,
+		EnvelopeXDR: envelope,
+		ResultXDR:   resp.ResultXdr,
+		Successful:  submitErr == nil && resp.Successful,
+		Ledger:      resp.Ledger,
+		ArchivedAt:  time.Now().UTC(),
+	}
+	record.Operations = s.operationResults(hash, submitErr)
+	if submitErr != nil {
+		record.Error = submitErr.Error()
+	} else if receipt, err := json.Marshal(resp); err == nil {
+		record.Receipt = receipt
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("archive: failed to encode record %s: %v", hash, err)
+		return
+	}
+	delay := archiveRetryDelay
+	for attempt := 1; attempt <= maxArchiveAttempts; attempt++ {
+		if err = s.Archive.Put(archiveKey(hash), data); err == nil {
+			return
+		}
+		if attempt < maxArchiveAttempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	log.Printf("archive: failed to store %s after %d attempts: %v", hash, maxArchiveAttempts, err)
+}
+
+// GetArchivedTransaction returns the archived artifacts of a transaction
+func (s *WalletService) GetArchivedTransaction(hash string) (*models.ArchivedTransaction, error) {
+	if s.Archive == nil {
+		return nil, errors.New("transaction archive is not configured")
+	}
+	if len(hash) != 64 {
+		return nil, errors.New("invalid transaction hash")
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return nil, errors.New("invalid transaction hash")
+	}
+
+	data, err := s.Archive.Get(archiveKey(strings.ToLower(hash)))
+	if err != nil {
+		return nil, err
+	}
+	var record models.ArchivedTransaction
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, errors.New("failed to decode archived transaction: " + err.Error())
+	}
+	return &record, nil
+}
+
+// LocalArchiveStore keeps archived artifacts on the local filesystem, for development
+type LocalArchiveStore struct {
+	Dir string
+}
+
+// Put writes data under key
+func (l *LocalArchiveStore) Put(key string, data []byte) error {
+	path := filepath.Join(l.Dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o640)
+}
+
+// Get reads the data stored under key
+func (l *LocalArchiveStore) Get(key string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(l.Dir, filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil, errArchiveNotFound
+	}
+	return data, err
+}
+
+// Delete removes the data stored under key; deleting an unknown key is not an error
+func (l *LocalArchiveStore) Delete(key string) error {
+	err := os.Remove(filepath.Join(l.Dir, filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// S3ArchiveStore keeps archived artifacts in an S3-compatible bucket using path-style requests
+// signed with AWS Signature Version 4
+type S3ArchiveStore struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Client    *http.Client
+}
+
+// Put uploads data under key
+func (s3 *S3ArchiveStore) Put(key string, data []byte) error {
+	resp, err := s3.do(http.MethodPut, "/"+key, "", data, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 put returned %s: %s", resp.Status, body)
+	}
+	return nil
+}
+
+// Get downloads the data stored under key
+func (s3 *S3ArchiveStore) Get(key string) ([]byte, error) {
+	resp, err := s3.do(http.MethodGet, "/"+key, "", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errArchiveNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("s3 get returned %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Delete removes the data stored under key; deleting an unknown key is not an error
+func (s3 *S3ArchiveStore) Delete(key string) error {
+	resp, err := s3.do(http.MethodDelete, "/"+key, "", nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 delete returned %s: %s", resp.Status, body)
+	}
+	return nil
+}
+
+// EnsureLifecycle installs a bucket lifecycle rule that expires archived transactions after retentionDays
+// and, when transitionDays is positive, moves them to storageClass after transitionDays
+func (s3 *S3ArchiveStore) EnsureLifecycle(retentionDays, transitionDays int, storageClass string) error {
+	transition := ""
+	if transitionDays > 0 && storageClass != "" {
+		transition = fmt.Sprintf("<Transition><Days>%d</Days><StorageClass>%s</StorageClass></Transition>", transitionDays, storageClass)
+	}
+	body := []byte(fmt.Sprintf(`<LifecycleConfiguration><Rule><ID>transaction-archive-retention</ID>`+
+		`<Filter><Prefix>transactions/</Prefix></Filter><Status>Enabled</Status>%s`+
+		`<Expiration><Days>%d</Days></Expiration></Rule></LifecycleConfiguration>`, transition, retentionDays))
+	sum := md5.Sum(body)
+
+	resp, err := s3.do(http.MethodPut, "", "lifecycle=", body, map[string]string{
+		"Content-MD5": base64.StdEncoding.EncodeToString(sum[:]),
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 lifecycle returned %s: %s", resp.Status, msg)
+	}
+	return nil
+}
+
+func (s3 *S3ArchiveStore) do(method, key, query string, body []byte, headers map[string]string) (*http.Response, error) {
+	uri := "/" + s3.Bucket + key
+	url := strings.TrimRight(s3.Endpoint, "/") + uri
+	if query != "" {
+		url += "?" + query
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	s3.sign(req, uri, query, body)
+
+	client := s3.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return client.Do(req)
+}
+
+// sign adds AWS Signature Version 4 headers to req
+func (s3 *S3ArchiveStore) sign(req *http.Request, uri, query string, body []byte) {
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	if md5Header := req.Header.Get("Content-MD5"); md5Header != "" {
+		signed = []string{"content-md5", "host", "x-amz-content-sha256", "x-amz-date"}
+		canonicalHeaders = "content-md5:" + md5Header + "\n" + canonicalHeaders
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	canonicalRequest := strings.Join([]string{req.Method, uri, query, canonicalHeaders, signedHeaders, payloadHash}, "\n")
+	scope := date + "/" + s3.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s3.SecretKey), date)
+	key = hmacSHA256(key, s3.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s3.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/clients/stellartoml"
+	"github.com/stellar/go/keypair"
+)
+
+// assetMetadataCacheTTL is how long a resolved stellar.toml entry is reused before refetching
+const assetMetadataCacheTTL = time.Hour
+
+var assetCodePattern = regexp.MustCompile(`^[a-zA-Z0-9]{1,12}$`)
+
+type assetMetadataCacheEntry struct {
+	metadata  *models.AssetMetadataResponse
+	expiresAt time.Time
+}
+
+// AssetService resolves asset metadata from issuers' stellar.toml files
+type AssetService struct {
+	Config     Config
+	TomlClient stellartoml.ClientInterface
+
+	mu    sync.Mutex
+	cache map[string]assetMetadataCacheEntry
+}
+
+// NewAssetService creates a new AssetService instance
+func NewAssetService(config Config) *AssetService {
+	return &AssetService{
+		Config:     config,
+		TomlClient: stellartoml.DefaultClient,
+		cache:      make(map[string]assetMetadataCacheEntry),
+	}
+}
+
+// GetAssetMetadata returns display metadata for an asset as published in its issuer's stellar.toml (SEP-1)
+func (s *AssetService) GetAssetMetadata(code, issuer string) (*models.AssetMetadataResponse, error) {
+	if !assetCodePattern.MatchString(code) {
+		return nil, errors.New("invalid asset code")
+	}
+	if _, err := keypair.ParseAddress(issuer); err != nil {
+		return nil, errors.New("invalid issuer public key")
+	}
+
+	cacheKey := code + ":" + issuer
+	s.mu.Lock()
+	entry, ok := s.cache[cacheKey]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.metadata, nil
+	}
+
+	accountRequest := horizonclient.AccountRequest{AccountID: issuer}
+	issuerAccount, err := s.Config.HorizonClient.AccountDetail(accountRequest)
+	if err != nil {
+		return nil, errors.New("failed to fetch issuer account details: " + err.Error())
+	}
+	if issuerAccount.HomeDomain == "" {
+		return nil, errors.New("issuer has no home domain")
+	}
+
+	toml, err := s.TomlClient.GetStellarToml(issuerAccount.HomeDomain)
+	if err != nil {
+		return nil, errors.New("failed to fetch stellar.toml: " + err.Error())
+	}
+
+	var currency *stellartoml.Currency
+	for i := range toml.Currencies {
+		if toml.Currencies[i].Code == code && toml.Currencies[i].Issuer == issuer {
+			currency = &toml.Currencies[i]
+			break
+		}
+	}
+	if currency == nil {
+		return nil, errors.New("asset not listed in issuer stellar.toml")
+	}
+
+	metadata := &models.AssetMetadataResponse{
+		Code:                   code,
+		Issuer:                 issuer,
+		HomeDomain:             issuerAccount.HomeDomain,
+		Name:                   currency.Name,
+		Description:            currency.Desc,
+		DisplayDecimals:        currency.DisplayDecimals,
+		Image:                  currency.Image,
+		Status:                 currency.Status,
+		OrgName:                toml.OrgName,
+		IsAssetAnchored:        currency.IsAssetAnchored,
+		AnchorAsset:            currency.AnchorAsset,
+		RedemptionInstructions: currency.RedemptionInstructions,
+	}
+
+	s.mu.Lock()
+	s.cache[cacheKey] = assetMetadataCacheEntry{metadata: metadata, expiresAt: time.Now().Add(assetMetadataCacheTTL)}
+	s.mu.Unlock()
+
+	return metadata, nil
+}
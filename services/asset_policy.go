@@ -0,0 +1,37 @@
+package services
+
+import (
+	"errors"
+	"strings"
+)
+
+// assetPermitted reports whether the operator's asset policy lets wallets transact in or trust asset.
+// XLM is always permitted; blocklisted assets never are; when an allowlist is configured only the assets
+// on it are.
+func (s *WalletService) assetPermitted(asset string) bool {
+	if asset == "native" {
+		return true
+	}
+	for _, blocked := range s.Config.AssetBlocklist {
+		if strings.EqualFold(blocked, asset) {
+			return false
+		}
+	}
+	if len(s.Config.AssetAllowlist) == 0 {
+		return true
+	}
+	for _, allowed := range s.Config.AssetAllowlist {
+		if strings.EqualFold(allowed, asset) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAssetPermitted returns an error for assets the operator's asset policy forbids
+func (s *WalletService) checkAssetPermitted(asset string) error {
+	if !s.assetPermitted(asset) {
+		return errors.New("asset not permitted: " + asset)
+	}
+	return nil
+}
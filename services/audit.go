@@ -0,0 +1,268 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/keypair"
+)
+
+// auditGenesisHash is the PrevHash of the first audit entry
+var auditGenesisHash = strings.Repeat("0", 64)
+
+// auditHeadKey is the archive key holding the sequence of the last persisted audit entry
+const auditHeadKey = "audit/head.json"
+
+// auditEntryKey is the archive key an audit entry is persisted under
+func auditEntryKey(sequence int64) string {
+	return fmt.Sprintf("audit/entries/%012d.json", sequence)
+}
+
+// auditHead records how many audit entries were persisted
+type auditHead struct {
+	Sequence int64 `json:"sequence"`
+}
+
+// AuditLog is an append-only log whose entries are chained by SHA-256 hashes, so altering, removing or
+// reordering any entry breaks every hash after it. With a Store, every entry is persisted under its own
+// key and the log is reloaded from it on first use.
+type AuditLog struct {
+	Store ArchiveStore
+
+	mu      sync.Mutex
+	loaded  bool
+	entries []models.AuditEntry
+	// persistedHead is the sequence of the persisted head; every entry up to it is persisted
+	persistedHead int64
+}
+
+// NewAuditLog creates a new AuditLog instance
+func NewAuditLog() *AuditLog {
+	return &AuditLog{}
+}
+
+// auditEntryHash returns the chained hash of an entry, computed over its PrevHash and its fields
+// other than Hash
+func auditEntryHash(entry models.AuditEntry) (string, error) {
+	entry.Hash = ""
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(entry.PrevHash+"\n"), data...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// loadLocked reads the persisted entries the first time the log is used; l.mu must be held
+func (l *AuditLog) loadLocked() error {
+	if l.loaded {
+		return nil
+	}
+	if l.Store == nil {
+		l.loaded = true
+		return nil
+	}
+	var head auditHead
+	data, err := l.Store.Get(auditHeadKey)
+	switch {
+	case errors.Is(err, errArchiveNotFound):
+	case err != nil:
+		return errors.New("failed to read audit log head: " + err.Error())
+	default:
+		if err := json.Unmarshal(data, &head); err != nil {
+			return errors.New("failed to decode audit log head: " + err.Error())
+		}
+	}
+	entries := make([]models.AuditEntry, 0, head.Sequence)
+	for sequence := int64(1); sequence <= head.Sequence; sequence++ {
+		data, err := l.Store.Get(auditEntryKey(sequence))
+		if err != nil {
+			return errors.New("failed to read audit entry " + strconv.FormatInt(sequence, 10) + ": " + err.Error())
+		}
+		var entry models.AuditEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return errors.New("failed to decode audit entry " + strconv.FormatInt(sequence, 10) + ": " + err.Error())
+		}
+		entries = append(entries, entry)
+	}
+	l.entries, l.loaded = entries, true
+	l.persistedHead = head.Sequence
+	return nil
+}
+
+// persistLocked writes every entry after the persisted head under its own key, in sequence order, and then
+// advances the head to the last one written. An entry that cannot be written holds the head back and is
+// retried when the next entry is recorded. l.mu must be held.
+func (l *AuditLog) persistLocked() {
+	if l.Store == nil {
+		return
+	}
+	written := l.persistedHead
+	for _, entry := range l.entries[l.persistedHead:] {
+		data, err := json.Marshal(entry)
+		if err == nil {
+			err = l.Store.Put(auditEntryKey(entry.Sequence), data)
+		}
+		if err != nil {
+			log.Printf("audit: failed to persist entry %d: %v", entry.Sequence, err)
+			break
+		}
+		written = entry.Sequence
+	}
+	if written == l.persistedHead {
+		return
+	}
+	data, err := json.Marshal(auditHead{Sequence: written})
+	if err == nil {
+		err = l.Store.Put(auditHeadKey, data)
+	}
+	if err != nil {
+		log.Printf("audit: failed to persist head %d: %v", written, err)
+		return
+	}
+	l.persistedHead = written
+}
+
+// Record appends an entry for an action taken by actor on subject. An entry that cannot be chained to the
+// persisted log is logged and dropped rather than restarting the chain.
+func (l *AuditLog) Record(actor, action, subject string, details map[string]string) {
+	l.mu.Lock()
+	if err := l.loadLocked(); err != nil {
+		l.mu.Unlock()
+		log.Printf("audit: dropped %s by %s on %s: %v", action, actor, subject, err)
+		return
+	}
+
+	entry := models.AuditEntry{
+		Sequence: int64(len(l.entries)) + 1,
+		Time:     time.Now().UTC(),
+		Actor:    actor,
+		Action:   action,
+		Subject:  subject,
+		Details:  details,
+		PrevHash: auditGenesisHash,
+	}
+	if len(l.entries) > 0 {
+		entry.PrevHash = l.entries[len(l.entries)-1].Hash
+	}
+	hash, err := auditEntryHash(entry)
+	if err != nil {
+		l.mu.Unlock()
+		return
+	}
+	entry.Hash = hash
+	l.entries = append(l.entries, entry)
+	l.persistLocked()
+	l.mu.Unlock()
+}
+
+// auditBundleDigest returns the message signed for a bundle: the range, the chain anchor, the number of
+// entries and the hash of the last one
+func auditBundleDigest(bundle models.AuditBundle) []byte {
+	last := bundle.PrevHash
+	if len(bundle.Entries) > 0 {
+		last = bundle.Entries[len(bundle.Entries)-1].Hash
+	}
+	message := strings.Join([]string{
+		bundle.From.UTC().Format(time.RFC3339Nano),
+		bundle.To.UTC().Format(time.RFC3339Nano),
+		bundle.PrevHash,
+		strconv.Itoa(len(bundle.Entries)),
+		last,
+	}, "\n")
+	sum := sha256.Sum256([]byte(message))
+	return sum[:]
+}
+
+// ExportAudit returns the audit entries recorded in [from, to), signed with the dedicated audit signing key
+func (s *WalletService) ExportAudit(from, to time.Time) (*models.AuditBundle, error) {
+	if !from.Before(to) {
+		return nil, errors.New("invalid range: from must be before to")
+	}
+	if s.Config.AuditSigningSecret == "" {
+		return nil, errors.New("audit signing key is not configured")
+	}
+	if s.Config.AuditSigningSecret == s.Config.MasterSecret {
+		return nil, errors.New("invalid audit signing key: must not be the master key")
+	}
+	signer, err := keypair.ParseFull(s.Config.AuditSigningSecret)
+	if err != nil {
+		return nil, errors.New("invalid audit signing key: " + err.Error())
+	}
+
+	bundle := models.AuditBundle{From: from.UTC(), To: to.UTC(), PrevHash: auditGenesisHash, Entries: []models.AuditEntry{}}
+	s.Audit.mu.Lock()
+	defer s.Audit.mu.Unlock()
+	if err := s.Audit.loadLocked(); err != nil {
+		return nil, err
+	}
+	for _, entry := range s.Audit.entries {
+		if entry.Time.Before(from) {
+			bundle.PrevHash = entry.Hash
+			continue
+		}
+		if !entry.Time.Before(to) {
+			break
+		}
+		bundle.Entries = append(bundle.Entries, entry)
+	}
+
+	signature, err := signer.Sign(auditBundleDigest(bundle))
+	if err != nil {
+		return nil, errors.New("failed to sign audit bundle: " + err.Error())
+	}
+	bundle.Signer = signer.Address()
+	bundle.Signature = base64.StdEncoding.EncodeToString(signature)
+	return &bundle, nil
+}
+
+// VerifyAuditBundle checks that a bundle is signed by expectedSigner, that its entries form an unbroken hash
+// chain from its PrevHash and that its signature is valid
+func VerifyAuditBundle(bundle models.AuditBundle, expectedSigner string) error {
+	if expectedSigner == "" {
+		return errors.New("expected signer is required")
+	}
+	if bundle.Signer != expectedSigner {
+		return errors.New("bundle signed by " + bundle.Signer + ", expected " + expectedSigner)
+	}
+	prev := bundle.PrevHash
+	for _, entry := range bundle.Entries {
+		if entry.PrevHash != prev {
+			return errors.New("audit chain broken at sequence " + strconv.FormatInt(entry.Sequence, 10) + ": prev_hash mismatch")
+		}
+		hash, err := auditEntryHash(entry)
+		if err != nil {
+			return err
+		}
+		if hash != entry.Hash {
+			return errors.New("audit chain broken at sequence " + strconv.FormatInt(entry.Sequence, 10) + ": entry was altered")
+		}
+		if entry.Time.Before(bundle.From) || !entry.Time.Before(bundle.To) {
+			return errors.New("audit entry " + strconv.FormatInt(entry.Sequence, 10) + " lies outside the bundle range")
+		}
+		prev = entry.Hash
+	}
+
+	signer, err := keypair.ParseAddress(bundle.Signer)
+	if err != nil {
+		return errors.New("invalid bundle signer: " + err.Error())
+	}
+	signature, err := base64.StdEncoding.DecodeString(bundle.Signature)
+	if err != nil {
+		return errors.New("invalid bundle signature encoding")
+	}
+	if err := signer.Verify(auditBundleDigest(bundle), signature); err != nil {
+		return errors.New("bundle signature is invalid")
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package services_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+	"github.com/saif727/stellar-wallet-backend/testsupport"
+)
+
+func TestExportAudit(t *testing.T) {
+	auditKey := testsupport.NewKeypair()
+	tests := []struct {
+		name string
+		// signingSecret is the configured audit signing key; "master" uses the master key
+		signingSecret string
+		tamper        func(bundle *models.AuditBundle)
+		signer        string
+		wantExportErr string
+		wantVerifyErr string
+	}{
+		{name: "verifies an untouched bundle", signingSecret: auditKey.Seed(), signer: auditKey.Address()},
+		{name: "detects an altered entry", signingSecret: auditKey.Seed(), signer: auditKey.Address(),
+			tamper:        func(bundle *models.AuditBundle) { bundle.Entries[0].Subject = "someone-else" },
+			wantVerifyErr: "entry was altered"},
+		{name: "detects a removed entry", signingSecret: auditKey.Seed(), signer: auditKey.Address(),
+			tamper:        func(bundle *models.AuditBundle) { bundle.Entries = bundle.Entries[1:] },
+			wantVerifyErr: "prev_hash mismatch"},
+		{name: "requires the expected signer", signingSecret: auditKey.Seed(), signer: testsupport.NewKeypair().Address(),
+			wantVerifyErr: "expected"},
+		{name: "requires an expected signer", signingSecret: auditKey.Seed(), wantVerifyErr: "expected signer is required"},
+		{name: "refuses to export without a signing key", wantExportErr: "audit signing key is not configured"},
+		{name: "refuses to sign with the master key", signingSecret: "master", wantExportErr: "must not be the master key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, service := newFundedService(t, func(config *services.Config) {
+				config.AuditSigningSecret = tt.signingSecret
+				if tt.signingSecret == "master" {
+					config.AuditSigningSecret = config.MasterSecret
+				}
+			})
+			from := time.Now().Add(-time.Minute)
+			for _, subject := range []string{"alice", "bob", "carol"} {
+				service.Audit.Record("admin", "wallet.frozen", subject, nil)
+			}
+
+			bundle, err := service.ExportAudit(from, time.Now().Add(time.Minute))
+			if tt.wantExportErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantExportErr) {
+					t.Fatalf("ExportAudit() error = %v, want %q", err, tt.wantExportErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExportAudit() error = %v", err)
+			}
+			if len(bundle.Entries) != 3 {
+				t.Fatalf("exported %d entries, want 3", len(bundle.Entries))
+			}
+			if tt.tamper != nil {
+				tt.tamper(bundle)
+			}
+			err = services.VerifyAuditBundle(*bundle, tt.signer)
+			switch {
+			case tt.wantVerifyErr == "" && err != nil:
+				t.Errorf("VerifyAuditBundle() error = %v", err)
+			case tt.wantVerifyErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantVerifyErr)):
+				t.Errorf("VerifyAuditBundle() error = %v, want %q", err, tt.wantVerifyErr)
+			}
+		})
+	}
+}
+
+func TestAuditLogPersistence(t *testing.T) {
+	store := &services.LocalArchiveStore{Dir: t.TempDir()}
+	first := &services.AuditLog{Store: store}
+	first.Record("admin", "wallet.frozen", "alice", nil)
+	first.Record("admin", "wallet.unfrozen", "alice", nil)
+
+	// A restarted service continues the persisted chain instead of starting a new one
+	auditKey := testsupport.NewKeypair()
+	_, service := newFundedService(t, func(config *services.Config) { config.AuditSigningSecret = auditKey.Seed() })
+	service.Audit.Store = store
+	service.Audit.Record("admin", "wallet.frozen", "bob", nil)
+
+	bundle, err := service.ExportAudit(time.Now().Add(-time.Minute), time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("ExportAudit() error = %v", err)
+	}
+	if len(bundle.Entries) != 3 || bundle.Entries[2].Sequence != 3 || bundle.Entries[2].Subject != "bob" {
+		t.Fatalf("exported %+v, want the two persisted entries followed by bob's", bundle.Entries)
+	}
+	if err := services.VerifyAuditBundle(*bundle, auditKey.Address()); err != nil {
+		t.Errorf("VerifyAuditBundle() error = %v", err)
+	}
+}
+
+// failingStore refuses writes to one key until fail is cleared
+type failingStore struct {
+	*services.LocalArchiveStore
+	key  string
+	fail bool
+}
+
+func (s *failingStore) Put(key string, data []byte) error {
+	if s.fail && key == s.key {
+		return errors.New("store unavailable")
+	}
+	return s.LocalArchiveStore.Put(key, data)
+}
+
+func TestAuditLogPersistenceRetry(t *testing.T) {
+	dir := t.TempDir()
+	store := &failingStore{LocalArchiveStore: &services.LocalArchiveStore{Dir: dir}, key: "audit/entries/000000000002.json", fail: true}
+	first := &services.AuditLog{Store: store}
+	first.Record("admin", "wallet.frozen", "alice", nil)
+	first.Record("admin", "wallet.unfrozen", "alice", nil)
+
+	// The head must not cover the entry that could not be written
+	reloaded := &services.AuditLog{Store: &services.LocalArchiveStore{Dir: dir}}
+	if entries := auditEntries(t, reloaded); len(entries) != 1 {
+		t.Fatalf("reloaded %+v, want only the first entry", entries)
+	}
+
+	// The unwritten entry is written before the next one, and the head then covers both
+	store.fail = false
+	first.Record("admin", "wallet.frozen", "bob", nil)
+	reloaded = &services.AuditLog{Store: &services.LocalArchiveStore{Dir: dir}}
+	entries := auditEntries(t, reloaded)
+	if len(entries) != 3 || entries[1].Action != "wallet.unfrozen" || entries[2].Subject != "bob" {
+		t.Fatalf("reloaded %+v, want alice's two entries followed by bob's", entries)
+	}
+}
+
+// auditEntries exports every entry of an audit log through a service using it
+func auditEntries(t *testing.T, audit *services.AuditLog) []models.AuditEntry {
+	t.Helper()
+	auditKey := testsupport.NewKeypair()
+	_, service := newFundedService(t, func(config *services.Config) { config.AuditSigningSecret = auditKey.Seed() })
+	service.Audit = audit
+	bundle, err := service.ExportAudit(time.Now().Add(-time.Minute), time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("ExportAudit() error = %v", err)
+	}
+	return bundle.Entries
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+)
+
+// maxBalanceSnapshots is how many snapshots are kept per wallet; at the default daily interval that is
+// almost three years
+const maxBalanceSnapshots = 1000
+
+// walletBalanceSnapshots caches the persisted balance history of wallets
+type walletBalanceSnapshots struct {
+	mu        sync.Mutex
+	histories map[string][]models.BalanceSnapshot
+}
+
+func balanceSnapshotKey(publicKey string) string {
+	return "balance-snapshots/" + publicKey + ".json"
+}
+
+// accountBalances maps each balance of an account to its "native", CODE:ISSUER or pool:<id> key
+func accountBalances(account hProtocol.Account) map[string]string {
+	balances := make(map[string]string, len(account.Balances))
+	for _, balance := range account.Balances {
+		switch balance.Type {
+		case "native":
+			balances["native"] = balance.Balance
+		case "liquidity_pool_shares":
+			balances["pool:"+balance.LiquidityPoolId] = balance.Balance
+		default:
+			balances[balance.Code+":"+balance.Issuer] = balance.Balance
+		}
+	}
+	return balances
+}
+
+// balanceHistory returns a wallet's snapshots, loading them from the archive store the first time
+func (s *WalletService) balanceHistory(publicKey string) ([]models.BalanceSnapshot, error) {
+	s.balanceSnapshots.mu.Lock()
+	history, ok := s.balanceSnapshots.histories[publicKey]
+	s.balanceSnapshots.mu.Unlock()
+	if ok || s.Archive == nil {
+		return history, nil
+	}
+
+	data, err := s.Archive.Get(balanceSnapshotKey(publicKey))
+	switch {
+	case errors.Is(err, errArchiveNotFound):
+	case err != nil:
+		return nil, errors.New("failed to read balance history: " + err.Error())
+	default:
+		if err := json.Unmarshal(data, &history); err != nil {
+			return nil, errors.New("failed to decode balance history: " + err.Error())
+		}
+	}
+
+	s.balanceSnapshots.mu.Lock()
+	defer s.balanceSnapshots.mu.Unlock()
+	if cached, ok := s.balanceSnapshots.histories[publicKey]; ok {
+		return cached, nil
+	}
+	s.balanceSnapshots.histories[publicKey] = history
+	return history, nil
+}
+
+// SnapshotBalances records the current balances of every managed wallet
+func (s *WalletService) SnapshotBalances() {
+	for _, publicKey := range s.Registry.PublicKeys() {
+		if err := s.snapshotWallet(publicKey); err != nil {
+			log.Printf("balance snapshot: %s: %v", publicKey, err)
+		}
+	}
+}
+
+// snapshotWallet appends a wallet's current balances to its history, persisting it before it is cached
+func (s *WalletService) snapshotWallet(publicKey string) error {
+	account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: publicKey})
+	if err != nil {
+		return errors.New("failed to fetch account details: " + err.Error())
+	}
+	history, err := s.balanceHistory(publicKey)
+	if err != nil {
+		return err
+	}
+
+	next := append([]models.BalanceSnapshot{}, history...)
+	next = append(next, models.BalanceSnapshot{TakenAt: time.Now().UTC(), Balances: accountBalances(account)})
+	if len(next) > maxBalanceSnapshots {
+		next = next[len(next)-maxBalanceSnapshots:]
+	}
+	if s.Archive != nil {
+		data, err := json.Marshal(next)
+		if err != nil {
+			return errors.New("failed to encode balance history: " + err.Error())
+		}
+		if err := s.Archive.Put(balanceSnapshotKey(publicKey), data); err != nil {
+			return errors.New("failed to persist balance history: " + err.Error())
+		}
+	}
+	s.balanceSnapshots.mu.Lock()
+	s.balanceSnapshots.histories[publicKey] = next
+	s.balanceSnapshots.mu.Unlock()
+	return nil
+}
+
+// BalanceHistory returns a managed wallet's balance snapshots taken between from and to, RFC 3339
+// timestamps that are both optional, limited to one asset when asset is set
+func (s *WalletService) BalanceHistory(publicKey, from, to, asset string) (*models.BalanceHistoryResponse, error) {
+	if _, managed := s.Registry.Get(publicKey); !managed {
+		return nil, errors.New("wallet not found")
+	}
+	var start, end time.Time
+	var err error
+	if from != "" {
+		if start, err = time.Parse(time.RFC3339, from); err != nil {
+			return nil, errors.New("invalid from: must be an RFC 3339 timestamp")
+		}
+	}
+	if to != "" {
+		if end, err = time.Parse(time.RFC3339, to); err != nil {
+			return nil, errors.New("invalid to: must be an RFC 3339 timestamp")
+		}
+	}
+	if asset != "" && asset != "native" {
+		parsed, err := parseAsset(asset)
+		if err != nil {
+			return nil, errors.New("invalid asset")
+		}
+		asset = assetString(parsed)
+	}
+
+	history, err := s.balanceHistory(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	response := &models.BalanceHistoryResponse{PublicKey: publicKey, Snapshots: []models.BalanceSnapshot{}}
+	for _, snapshot := range history {
+		if (!start.IsZero() && snapshot.TakenAt.Before(start)) || (!end.IsZero() && snapshot.TakenAt.After(end)) {
+			continue
+		}
+		if asset != "" {
+			balance, ok := snapshot.Balances[asset]
+			if !ok {
+				balance = "0.0000000"
+			}
+			snapshot = models.BalanceSnapshot{TakenAt: snapshot.TakenAt, Balances: map[string]string{asset: balance}}
+		}
+		response.Snapshots = append(response.Snapshots, snapshot)
+	}
+	return response, nil
+}
+
+// BalanceSnapshotter periodically snapshots the balances of managed wallets
+type BalanceSnapshotter struct {
+	Wallets  *WalletService
+	Interval time.Duration
+}
+
+// NewBalanceSnapshotter creates a new BalanceSnapshotter instance
+func NewBalanceSnapshotter(wallets *WalletService, interval time.Duration) *BalanceSnapshotter {
+	return &BalanceSnapshotter{Wallets: wallets, Interval: interval}
+}
+
+// Run snapshots every Interval until ctx is cancelled
+func (b *BalanceSnapshotter) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.Wallets.SnapshotBalances()
+		}
+	}
+}
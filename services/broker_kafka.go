@@ -0,0 +1,88 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+)
+
+// KafkaBroker publishes events to a Kafka topic through a Confluent-compatible Kafka REST Proxy. Records are
+// keyed by wallet, so each wallet's events stay in order on one partition.
+type KafkaBroker struct {
+	// URL is the REST proxy's base URL
+	URL   string
+	Topic string
+	// Username and Password, when set, authenticate with the proxy using basic auth
+	Username string
+	Password string
+	Client   *http.Client
+}
+
+// NewKafkaBroker creates a KafkaBroker publishing to topic through the REST proxy at proxyURL
+func NewKafkaBroker(proxyURL, topic string) (*KafkaBroker, error) {
+	parsed, err := url.Parse(proxyURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return nil, errors.New("invalid Kafka REST proxy URL: must be an absolute http or https URL")
+	}
+	if topic == "" {
+		return nil, errors.New("invalid Kafka topic: must not be empty")
+	}
+	broker := &KafkaBroker{URL: strings.TrimSuffix(proxyURL, "/"), Topic: topic, Client: &http.Client{Timeout: 10 * time.Second}}
+	if parsed.User != nil {
+		broker.Username = parsed.User.Username()
+		broker.Password, _ = parsed.User.Password()
+		parsed.User = nil
+		broker.URL = strings.TrimSuffix(parsed.String(), "/")
+	}
+	return broker, nil
+}
+
+// Publish produces one record and checks the proxy accepted it
+func (k *KafkaBroker) Publish(envelope models.EventEnvelope) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"records": []map[string]interface{}{{"key": envelope.Wallet, "value": envelope}},
+	})
+	if err != nil {
+		return errors.New("failed to encode event: " + err.Error())
+	}
+	req, err := http.NewRequest(http.MethodPost, k.URL+"/topics/"+url.PathEscape(k.Topic), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	if k.Username != "" {
+		req.SetBasicAuth(k.Username, k.Password)
+	}
+	resp, err := k.Client.Do(req)
+	if err != nil {
+		return errors.New("failed to reach Kafka REST proxy: " + err.Error())
+	}
+	defer resp.Body.Close()
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("Kafka REST proxy responded " + resp.Status + ": " + strings.TrimSpace(string(detail)))
+	}
+	// The proxy answers 200 even when a record failed, reporting it in the record's offset
+	var result struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.Unmarshal(detail, &result); err == nil {
+		for _, offset := range result.Offsets {
+			if offset.ErrorCode != nil && *offset.ErrorCode != 0 {
+				return errors.New("Kafka rejected the event: " + offset.Error)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+)
+
+// natsTimeout bounds connecting to NATS and waiting for it to acknowledge a flush
+const natsTimeout = 5 * time.Second
+
+// NATSBroker publishes events to NATS on the subject "<Subject>.<event type>", e.g.
+// stellar.wallet.events.payment.received, so consumers can subscribe to some event types with wildcards.
+// It speaks the core NATS text protocol and flushes every message with a PING, so a publish only succeeds
+// once the server has read it.
+type NATSBroker struct {
+	URL     string
+	Subject string
+
+	// mu serializes publishes and guards the connection; writeMu guards writes, which the reader also makes
+	// to answer server PINGs while a publish waits
+	mu      sync.Mutex
+	writeMu sync.Mutex
+	conn    net.Conn
+	writer  *bufio.Writer
+	pongs   chan struct{}
+	failed  chan error
+}
+
+// NewNATSBroker creates a NATSBroker for a nats:// or tls:// server URL, optionally with user:password or a
+// token as the URL's user info; it connects on first publish
+func NewNATSBroker(serverURL, subject string) (*NATSBroker, error) {
+	parsed, err := url.Parse(serverURL)
+	if err != nil || (parsed.Scheme != "nats" && parsed.Scheme != "tls") || parsed.Host == "" {
+		return nil, errors.New("invalid NATS URL: must be a nats:// or tls:// URL")
+	}
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n*>") {
+		return nil, errors.New("invalid NATS subject: " + subject)
+	}
+	return &NATSBroker{URL: serverURL, Subject: subject}, nil
+}
+
+// Publish sends one event and waits for the server to acknowledge it, reconnecting first if needed
+func (n *NATSBroker) Publish(envelope models.EventEnvelope) error {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return errors.New("failed to encode event: " + err.Error())
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.conn == nil {
+		if err := n.connectLocked(); err != nil {
+			return err
+		}
+	}
+	subject := n.Subject + "." + envelope.Type
+	if err := n.write("PUB " + subject + " " + strconv.Itoa(len(payload)) + "\r\n" + string(payload) + "\r\nPING\r\n"); err != nil {
+		n.closeLocked()
+		return errors.New("failed to publish to NATS: " + err.Error())
+	}
+	if err := n.awaitPongLocked(); err != nil {
+		n.closeLocked()
+		return err
+	}
+	return nil
+}
+
+// connectLocked dials the server, reads its INFO, sends CONNECT and waits for the handshake PONG; n.mu must
+// be held
+func (n *NATSBroker) connectLocked() error {
+	parsed, _ := url.Parse(n.URL)
+	host := parsed.Host
+	if parsed.Port() == "" {
+		host = net.JoinHostPort(parsed.Hostname(), "4222")
+	}
+	dialer := &net.Dialer{Timeout: natsTimeout}
+	var conn net.Conn
+	var err error
+	if parsed.Scheme == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, &tls.Config{ServerName: parsed.Hostname()})
+	} else {
+		conn, err = dialer.Dial("tcp", host)
+	}
+	if err != nil {
+		return errors.New("failed to connect to NATS: " + err.Error())
+	}
+	reader := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(natsTimeout))
+	info, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(info, "INFO ") {
+		conn.Close()
+		return errors.New("failed to connect to NATS: no INFO from server")
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	options := map[string]interface{}{"verbose": false, "pedantic": false, "name": eventSource, "lang": "go", "protocol": 0}
+	if user := parsed.User; user != nil {
+		if password, ok := user.Password(); ok {
+			options["user"], options["pass"] = user.Username(), password
+		} else {
+			options["auth_token"] = user.Username()
+		}
+	}
+	connect, _ := json.Marshal(options)
+	n.conn, n.writer = conn, bufio.NewWriter(conn)
+	n.pongs, n.failed = make(chan struct{}, 16), make(chan error, 1)
+	go n.read(reader, n.writer, n.pongs, n.failed)
+
+	if err := n.write("CONNECT " + string(connect) + "\r\nPING\r\n"); err != nil {
+		n.closeLocked()
+		return errors.New("failed to connect to NATS: " + err.Error())
+	}
+	if err := n.awaitPongLocked(); err != nil {
+		n.closeLocked()
+		return err
+	}
+	return nil
+}
+
+// read handles what the server sends: PONGs are handed to the publisher, PINGs answered and errors reported
+func (n *NATSBroker) read(reader *bufio.Reader, writer *bufio.Writer, pongs chan<- struct{}, failed chan<- error) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			select {
+			case failed <- errors.New("NATS connection lost: " + err.Error()):
+			default:
+			}
+			return
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			pongs <- struct{}{}
+		case line == "PING":
+			n.writeMu.Lock()
+			writer.WriteString("PONG\r\n")
+			writer.Flush()
+			n.writeMu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			select {
+			case failed <- errors.New("NATS error: " + strings.TrimSpace(strings.TrimPrefix(line, "-ERR"))):
+			default:
+			}
+		}
+	}
+}
+
+// write sends protocol data on the current connection; n.mu must be held
+func (n *NATSBroker) write(data string) error {
+	n.writeMu.Lock()
+	defer n.writeMu.Unlock()
+	n.writer.WriteString(data)
+	return n.writer.Flush()
+}
+
+// awaitPongLocked waits for the server to answer the last PING; n.mu must be held
+func (n *NATSBroker) awaitPongLocked() error {
+	select {
+	case <-n.pongs:
+		return nil
+	case err := <-n.failed:
+		return err
+	case <-time.After(natsTimeout):
+		return errors.New("NATS did not acknowledge in time")
+	}
+}
+
+// closeLocked drops the connection so the next publish reconnects; n.mu must be held
+func (n *NATSBroker) closeLocked() {
+	if n.conn != nil {
+		n.conn.Close()
+		n.conn, n.writer = nil, nil
+	}
+}
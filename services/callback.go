@@ -0,0 +1,252 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/webhookverify"
+)
+
+// Callback delivery is retried with a doubling delay, so a receiver has about a minute to recover
+const (
+	maxCallbackAttempts = 5
+	callbackRetryDelay  = 2 * time.Second
+)
+
+// callbackClient posts transfer callbacks and webhooks. It refuses to connect to addresses that are not
+// public, whatever the URL's host resolves to when it is dialed, redirects included.
+var callbackClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         (&net.Dialer{Timeout: 10 * time.Second, Control: dialPublicOnly}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+}
+
+// nonPublicPrefixes are the reserved ranges that callbacks may not reach, besides loopback, private,
+// link-local, multicast and unspecified addresses
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// callbackAddressAllowed reports whether callbacks may connect to addr
+var callbackAddressAllowed = func(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// dialPublicOnly is the dialer Control of callbackClient, checking the address actually connected to
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !callbackAddressAllowed(addr) {
+		return errors.New("callback address " + addr.String() + " is not public")
+	}
+	return nil
+}
+
+// checkCallbackHost resolves the host of a callback URL and returns an error when it has an address that
+// callbacks may not reach. It rejects bad URLs early; dialPublicOnly still checks every connection, since
+// the host may resolve differently later.
+func checkCallbackHost(host string) error {
+	var addrs []netip.Addr
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = append(addrs, addr)
+	} else {
+		ips, err := net.LookupIP(host)
+		if err != nil {
+			return errors.New("host " + host + " does not resolve")
+		}
+		for _, ip := range ips {
+			if addr, ok := netip.AddrFromSlice(ip); ok {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	for _, addr := range addrs {
+		if !callbackAddressAllowed(addr) {
+			return errors.New("host " + host + " resolves to " + addr.Unmap().String() + ", which is not public")
+		}
+	}
+	return nil
+}
+
+// validateCallbackURL checks that a transfer's callback_url is an absolute http(s) URL of a public host and
+// that callbacks are enabled
+func (s *WalletService) validateCallbackURL(callbackURL string) error {
+	if callbackURL == "" {
+		return nil
+	}
+	if s.Config.CallbackSecret == "" {
+		return errors.New("invalid callback_url: callbacks are not enabled")
+	}
+	parsed, err := url.Parse(callbackURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return errors.New("invalid callback_url: must be an absolute http or https URL")
+	}
+	if err := checkCallbackHost(parsed.Hostname()); err != nil {
+		return errors.New("invalid callback_url: " + err.Error())
+	}
+	return nil
+}
+
+// transferOutcome posts the callback for the result of running a transfer. Held transfers call back once
+// reviewed, and replays and external_id conflicts do not call back at all.
+func (s *WalletService) transferOutcome(transfer *preparedTransfer, response *models.TransferResponse, err error) {
+	switch {
+	case err != nil && strings.HasPrefix(err.Error(), "external_id "):
+	case err != nil:
+		s.transferCallback(transfer, models.CallbackTransferFailed, nil, "", err)
+	case response.Replayed || response.Status == models.TransferPendingReview:
+	default:
+		s.transferCallback(transfer, models.CallbackTransferCompleted, response, "", nil)
+	}
+}
+
+// transferCallback posts the final outcome of a transfer to its callback_url, if it has one. Delivery
+// happens in the background.
+func (s *WalletService) transferCallback(transfer *preparedTransfer, event string, response *models.TransferResponse, reviewID string, transferErr error) {
+	req := transfer.request
+	if req.CallbackURL == "" {
+		return
+	}
+	callback := models.TransferCallback{
+		ID:               newID(),
+		Event:            event,
+		ExternalID:       req.ExternalID,
+		ReviewID:         reviewID,
+		From:             transfer.senderKP.Address(),
+		To:               req.ToPublicKey,
+		Amount:           req.Amount,
+		SourceAsset:      assetString(transfer.sendAsset),
+		DestinationAsset: assetString(transfer.destAsset),
+		CreatedAt:        time.Now().UTC(),
+	}
+	switch {
+	case response != nil:
+		callback.Status = response.Status
+		callback.TransactionHash = response.TransactionHash
+		callback.InternalTransferID = response.InternalTransferID
+	case event == models.CallbackTransferRejected:
+		callback.Status = models.ReviewRejected
+	default:
+		callback.Status = models.TransferFailed
+	}
+	if transferErr != nil {
+		callback.Error = transferErr.Error()
+		callback.ErrorDetail = ExplainError(transferErr)
+	}
+	go s.deliverCallback(req.CallbackURL, callback)
+}
+
+// deliverCallback signs and posts a callback, retrying until the receiver answers with a 2xx status
+func (s *WalletService) deliverCallback(callbackURL string, callback models.TransferCallback) {
+	body, err := json.Marshal(callback)
+	if err != nil {
+		log.Printf("transfer callback %s: failed to encode: %v", callback.ID, err)
+		return
+	}
+	s.deliverSigned("transfer callback "+callback.ID, callbackURL, body)
+}
+
+// deliverSigned signs and posts body the way callbacks are; what names the delivery in logs
+func (s *WalletService) deliverSigned(what, callbackURL string, body []byte) {
+	if err := deliverSignedWith(callbackURL, s.callbackSecrets(), body, maxCallbackAttempts, nil); err != nil {
+		log.Printf("%s to %s failed after %d attempts: %v", what, callbackURL, maxCallbackAttempts, err)
+	}
+}
+
+// deliveryAttemptFunc observes one attempt of a signed delivery: the receiver's status code, 0 when it could
+// not be reached, how long the attempt took and its error
+type deliveryAttemptFunc func(attempt, statusCode int, latency time.Duration, err error)
+
+// deliverSignedWith signs body with secret and posts it, retrying with a doubling delay until the receiver
+// answers with a 2xx status or attempts run out; it returns the last attempt's error. onAttempt, when set,
+// is called after every attempt.
+func deliverSignedWith(callbackURL string, secrets []string, body []byte, attempts int, onAttempt deliveryAttemptFunc) error {
+	var err error
+	delay := callbackRetryDelay
+	for attempt := 1; attempt <= attempts; attempt++ {
+		start := time.Now()
+		var statusCode int
+		statusCode, err = postCallback(callbackURL, secrets, body)
+		if onAttempt != nil {
+			onAttempt(attempt, statusCode, time.Since(start), err)
+		}
+		if err == nil {
+			return nil
+		}
+		if attempt < attempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return err
+}
+
+// callbackSecrets returns the secrets callbacks are signed with: CallbackSecret and, while it is rotated
+// out, PreviousCallbackSecret
+func (s *WalletService) callbackSecrets() []string {
+	if s.Config.PreviousCallbackSecret == "" {
+		return []string{s.Config.CallbackSecret}
+	}
+	return []string{s.Config.CallbackSecret, s.Config.PreviousCallbackSecret}
+}
+
+// postCallback signs body with every secret and posts it once, returning the receiver's status code
+func postCallback(callbackURL string, secrets []string, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookverify.TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(webhookverify.SignatureHeader, webhookverify.SignAll(secrets, now, body))
+
+	resp, err := callbackClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, errors.New("callback returned " + resp.Status)
+	}
+	return resp.StatusCode, nil
+}
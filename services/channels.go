@@ -0,0 +1,149 @@
+package services
+
+import (
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+const (
+	// channelStartingBalance is the XLM each channel account is created with to pay transaction fees
+	channelStartingBalance = "5"
+	// channelLeaseTimeout bounds how long a submission waits for a free channel
+	channelLeaseTimeout = 30 * time.Second
+)
+
+// ChannelPool leases channel accounts to master-account submissions. A leased channel is the source of the
+// transaction, paying its fee and supplying its sequence number, while the operations keep the master as
+// their source; master transactions therefore no longer serialize on one sequence number.
+type ChannelPool struct {
+	channels  chan *keypair.Full
+	size      int
+	addresses []string
+}
+
+// NewChannelPool creates a pool of the given channel accounts
+func NewChannelPool(channels []*keypair.Full) *ChannelPool {
+	pool := &ChannelPool{channels: make(chan *keypair.Full, len(channels)), size: len(channels)}
+	for _, channel := range channels {
+		pool.channels <- channel
+		pool.addresses = append(pool.addresses, channel.Address())
+	}
+	return pool
+}
+
+// Size returns the number of channel accounts in the pool
+func (p *ChannelPool) Size() int {
+	return p.size
+}
+
+// Addresses returns the public keys of the pool's channel accounts
+func (p *ChannelPool) Addresses() []string {
+	return append([]string{}, p.addresses...)
+}
+
+// Lease takes a free channel, waiting up to channelLeaseTimeout; the caller must Return it
+func (p *ChannelPool) Lease() (*keypair.Full, error) {
+	select {
+	case channel := <-p.channels:
+		return channel, nil
+	case <-time.After(channelLeaseTimeout):
+		return nil, errors.New("no channel account available")
+	}
+}
+
+// Return gives a leased channel back to the pool
+func (p *ChannelPool) Return(channel *keypair.Full) {
+	p.channels <- channel
+}
+
+// channelKeypair derives the i-th channel account from the master secret, so the same channels are reused
+// across restarts without storing their keys
+func channelKeypair(masterKP *keypair.Full, i int) (*keypair.Full, error) {
+	seed := sha256.Sum256([]byte(masterKP.Seed() + "/channel/" + strconv.Itoa(i)))
+	return keypair.FromRawSeed(seed)
+}
+
+// ProvisionChannels derives count channel accounts, creates the ones that do not exist yet from the master
+// account and installs them as the service's channel pool
+func (s *WalletService) ProvisionChannels(count int) error {
+	masterKP, err := keypair.ParseFull(s.Config.MasterSecret)
+	if err != nil {
+		return errors.New("invalid master secret key: " + err.Error())
+	}
+
+	channels := make([]*keypair.Full, 0, count)
+	var missing []txnbuild.Operation
+	for i := 0; i < count; i++ {
+		channel, err := channelKeypair(masterKP, i)
+		if err != nil {
+			return errors.New("failed to derive channel account: " + err.Error())
+		}
+		channels = append(channels, channel)
+
+		_, err = s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: channel.Address()})
+		if err == nil {
+			continue
+		}
+		if herr, ok := err.(*horizonclient.Error); !ok || herr.Response.StatusCode != http.StatusNotFound {
+			return errors.New("failed to fetch channel account details: " + err.Error())
+		}
+		missing = append(missing, &txnbuild.CreateAccount{Destination: channel.Address(), Amount: channelStartingBalance})
+	}
+
+	for start := 0; start < len(missing); start += maxOperationsPerTransaction {
+		end := min(start+maxOperationsPerTransaction, len(missing))
+		tx, err := s.buildTransaction(masterKP.Address(), txnbuild.TransactionParams{
+			Operations:    missing[start:end],
+			Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+		}, masterKP)
+		if err != nil {
+			return err
+		}
+		if _, err := s.submitTransaction(tx, masterKP); err != nil {
+			return errors.New("failed to create channel accounts: " + err.Error())
+		}
+	}
+
+	s.Channels = NewChannelPool(channels)
+	return nil
+}
+
+// submitMasterOperations builds, signs and submits a transaction of operations paid for by the master
+// account. With a channel pool the transaction's source is a leased channel, so every operation must name
+// its source account; without one the master is the source. signers are the accounts the operations act
+// for; the transaction source always signs, and each key signs once.
+func (s *WalletService) submitMasterOperations(masterKP *keypair.Full, ops []txnbuild.Operation, signers ...*keypair.Full) (submittedTransaction, error) {
+	source := masterKP
+	if s.Channels != nil && s.Channels.Size() > 0 {
+		channel, err := s.Channels.Lease()
+		if err != nil {
+			return submittedTransaction{}, err
+		}
+		defer s.Channels.Return(channel)
+		source = channel
+	}
+	keys := []*keypair.Full{source}
+	signed := map[string]bool{source.Address(): true}
+	for _, signer := range signers {
+		if !signed[signer.Address()] {
+			keys = append(keys, signer)
+			signed[signer.Address()] = true
+		}
+	}
+
+	tx, err := s.buildTransaction(source.Address(), txnbuild.TransactionParams{
+		Operations:    ops,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+	}, keys...)
+	if err != nil {
+		return submittedTransaction{}, err
+	}
+	return s.submitTransaction(tx, keys...)
+}
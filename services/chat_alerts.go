@@ -0,0 +1,150 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Chat webhook flavours a ChatAlerter can post to
+const (
+	ChatSlack   = "slack"
+	ChatDiscord = "discord"
+)
+
+const (
+	// defaultChatBatchInterval is the least time between two posts to the same chat webhook
+	defaultChatBatchInterval = time.Minute
+	// maxChatBatchAlerts caps the alerts listed in one post; the rest are counted
+	maxChatBatchAlerts = 20
+	// maxDiscordMessageLength is Discord's limit on a message's content
+	maxDiscordMessageLength = 2000
+)
+
+type chatAlert struct {
+	title   string
+	message string
+	raised  time.Time
+}
+
+// ChatAlerter posts operational alerts to a Slack or Discord incoming webhook. Alerts are batched: at most
+// one message is posted per Interval, listing every alert raised since the last one, so an incident cannot
+// flood the channel or get the webhook rate limited.
+type ChatAlerter struct {
+	URL      string
+	Kind     string
+	Interval time.Duration
+	Client   *http.Client
+
+	mu      sync.Mutex
+	pending []chatAlert
+	dropped int
+}
+
+// NewChatAlerter creates a ChatAlerter for a Slack or Discord webhook URL, posting at most once per interval
+func NewChatAlerter(kind, webhookURL string, interval time.Duration) (*ChatAlerter, error) {
+	if kind != ChatSlack && kind != ChatDiscord {
+		return nil, errors.New("invalid chat kind: " + kind)
+	}
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return nil, errors.New("invalid " + kind + " webhook URL: must be an absolute https URL")
+	}
+	if interval <= 0 {
+		interval = defaultChatBatchInterval
+	}
+	return &ChatAlerter{URL: webhookURL, Kind: kind, Interval: interval, Client: callbackClient}, nil
+}
+
+// Alert queues an alert for the next batch
+func (a *ChatAlerter) Alert(title, message string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.pending) >= maxChatBatchAlerts {
+		a.dropped++
+		return
+	}
+	a.pending = append(a.pending, chatAlert{title: title, message: message, raised: time.Now().UTC()})
+}
+
+// Run posts queued alerts every Interval until ctx is cancelled
+func (a *ChatAlerter) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			a.Flush()
+			return
+		case <-ticker.C:
+			a.Flush()
+		}
+	}
+}
+
+// Flush posts the queued alerts now, if there are any. A batch that cannot be posted is logged and dropped
+// rather than retried, so a stale incident is not reported late.
+func (a *ChatAlerter) Flush() {
+	a.mu.Lock()
+	alerts, dropped := a.pending, a.dropped
+	a.pending, a.dropped = nil, 0
+	a.mu.Unlock()
+	if len(alerts) == 0 {
+		return
+	}
+
+	if err := a.post(chatBatchText(alerts, dropped)); err != nil {
+		log.Printf("%s alerts: failed to post %d alerts: %v", a.Kind, len(alerts)+dropped, err)
+	}
+}
+
+// chatBatchText formats a batch of alerts as one chat message
+func chatBatchText(alerts []chatAlert, dropped int) string {
+	var b strings.Builder
+	for i, alert := range alerts {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "*%s* (%s)\n%s\n", alert.title, alert.raised.Format("15:04:05 UTC"), alert.message)
+	}
+	if dropped > 0 {
+		fmt.Fprintf(&b, "\n…and %d more alerts\n", dropped)
+	}
+	return b.String()
+}
+
+// post sends one message in the webhook's payload format
+func (a *ChatAlerter) post(text string) error {
+	var payload map[string]string
+	if a.Kind == ChatDiscord {
+		// Discord marks bold with double asterisks and counts its limit in characters
+		text = strings.ReplaceAll(text, "*", "**")
+		if runes := []rune(text); len(runes) > maxDiscordMessageLength {
+			text = string(runes[:maxDiscordMessageLength-1]) + "…"
+		}
+		payload = map[string]string{"content": text}
+	} else {
+		payload = map[string]string{"text": text}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := a.Client.Post(a.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return errors.New("webhook responded " + resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+)
+
+// needsClaimableBalance reports whether a same-asset transfer must fall back to a claimable balance
+// because the destination cannot receive the asset directly
+func (s *WalletService) needsClaimableBalance(transfer *preparedTransfer) (bool, error) {
+	if !transfer.request.ClaimableFallback || !claimableFallbackPossible(transfer) {
+		return false, nil
+	}
+	destination, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: transfer.destination})
+	if err != nil {
+		if herr, ok := err.(*horizonclient.Error); ok && herr.Response.StatusCode == http.StatusNotFound {
+			return true, nil
+		}
+		return false, errors.New("failed to fetch recipient account details: " + err.Error())
+	}
+	return !hasTrustline(destination, assetString(transfer.sendAsset)), nil
+}
+
+// claimableBalanceOp builds a CreateClaimableBalance paying the transfer to its recipient. The recipient may
+// claim after ClaimableAfterSeconds; when ReclaimAfterSeconds is set the sender may reclaim after that window.
+func claimableBalanceOp(transfer *preparedTransfer) *txnbuild.CreateClaimableBalance {
+	req := transfer.request
+
+	recipientPredicate := txnbuild.UnconditionalPredicate
+	if req.ClaimableAfterSeconds > 0 {
+		recipientPredicate = txnbuild.NotPredicate(txnbuild.BeforeRelativeTimePredicate(req.ClaimableAfterSeconds))
+	}
+	claimants := []txnbuild.Claimant{txnbuild.NewClaimant(req.ToPublicKey, &recipientPredicate)}
+
+	if req.ReclaimAfterSeconds > 0 {
+		var senderPredicate xdr.ClaimPredicate = txnbuild.NotPredicate(txnbuild.BeforeRelativeTimePredicate(req.ReclaimAfterSeconds))
+		claimants = append(claimants, txnbuild.NewClaimant(transfer.senderKP.Address(), &senderPredicate))
+	}
+
+	return &txnbuild.CreateClaimableBalance{
+		Amount:       req.Amount,
+		Asset:        transfer.sendAsset,
+		Destinations: claimants,
+	}
+}
+
+// claimBalance submits a ClaimClaimableBalance, preceded by a ChangeTrust when the wallet lacks the trustline
+// and allowTrust permits adding one. It returns an empty hash when the balance is skipped.
+func (s *WalletService) claimBalance(kp *keypair.Full, balance hProtocol.ClaimableBalance, allowTrust func(asset string) bool) (string, error) {
+	accountRequest := horizonclient.AccountRequest{AccountID: kp.Address()}
+	sourceAccount, err := s.Config.HorizonClient.AccountDetail(accountRequest)
+	if err != nil {
+		return "", errors.New("failed to fetch wallet account details: " + err.Error())
+	}
+
+	if err := s.checkAssetPermitted(balance.Asset); err != nil {
+		return "", err
+	}
+
+	var ops []txnbuild.Operation
+	if balance.Asset != "native" && !hasTrustline(sourceAccount, balance.Asset) {
+		if !allowTrust(balance.Asset) {
+			return "", nil
+		}
+		asset, err := txnbuild.ParseAssetString(balance.Asset)
+		if err != nil {
+			return "", errors.New("failed to parse claimable asset: " + err.Error())
+		}
+		line, err := asset.ToChangeTrustAsset()
+		if err != nil {
+			return "", errors.New("failed to create trustline asset: " + err.Error())
+		}
+		ops = append(ops, &txnbuild.ChangeTrust{Line: line})
+	}
+	ops = append(ops, &txnbuild.ClaimClaimableBalance{BalanceID: balance.BalanceID})
+
+	tx, err := s.buildTransaction(kp.Address(), txnbuild.TransactionParams{
+		Operations:    ops,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+	}, kp)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.submitTransaction(tx, kp)
+	if err != nil {
+		return "", err
+	}
+	return resp.Hash, nil
+}
+
+// hasTrustline reports whether account holds a trustline for the canonical asset string CODE:ISSUER
+func hasTrustline(account hProtocol.Account, asset string) bool {
+	for _, balance := range account.Balances {
+		if balance.Code+":"+balance.Issuer == asset {
+			return true
+		}
+	}
+	return false
+}
+
+func claimableBalanceResponse(balance hProtocol.ClaimableBalance) models.ClaimableBalanceResponse {
+	response := models.ClaimableBalanceResponse{
+		ID:        balance.BalanceID,
+		Asset:     balance.Asset,
+		Amount:    balance.Amount,
+		Sponsor:   balance.Sponsor,
+		Claimants: []models.ClaimableBalanceClaimant{},
+	}
+	for _, claimant := range balance.Claimants {
+		predicate, _ := json.Marshal(claimant.Predicate)
+		response.Claimants = append(response.Claimants, models.ClaimableBalanceClaimant{
+			Destination: claimant.Destination,
+			Predicate:   predicate,
+		})
+	}
+	return response
+}
+
+// maxClaimableBalancePages caps how many pages of a wallet's claimable balances are read, as anyone can
+// create claimable balances for any account
+const maxClaimableBalancePages = 10
+
+// claimableBalances returns the claimable balances a wallet is a claimant of, up to maxClaimableBalancePages
+// pages of them
+func (s *WalletService) claimableBalances(publicKey string) ([]models.ClaimableBalanceResponse, error) {
+	responses := []models.ClaimableBalanceResponse{}
+	cursor := ""
+	for page := 0; page < maxClaimableBalancePages; page++ {
+		balances, err := s.Config.HorizonClient.ClaimableBalances(horizonclient.ClaimableBalanceRequest{
+			Claimant: publicKey,
+			Cursor:   cursor,
+			Limit:    200,
+		})
+		if err != nil {
+			return nil, errors.New("failed to fetch claimable balances: " + err.Error())
+		}
+		for _, balance := range balances.Embedded.Records {
+			cursor = balance.PagingToken()
+			responses = append(responses, claimableBalanceResponse(balance))
+		}
+		if len(balances.Embedded.Records) < 200 {
+			break
+		}
+	}
+	return responses, nil
+}
+
+// ListClaimableBalances returns the claimable balances a wallet is a claimant of
+func (s *WalletService) ListClaimableBalances(publicKey string) ([]models.ClaimableBalanceResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	return s.claimableBalances(publicKey)
+}
+
+// ClaimBalance claims a claimable balance for a wallet, adding the asset's trustline first if needed. tenantID
+// is the authenticated tenant, or empty when the request must supply the wallet's secret key.
+func (s *WalletService) ClaimBalance(tenantID, publicKey, balanceID string, req models.ClaimBalanceRequest) (*models.ClaimBalanceResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	kp, err := s.authorizedSigner(tenantID, publicKey, req.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := s.Config.HorizonClient.ClaimableBalance(balanceID)
+	if err != nil {
+		if herr, ok := err.(*horizonclient.Error); ok && herr.Response.StatusCode == http.StatusNotFound {
+			return nil, errors.New("claimable balance not found")
+		}
+		return nil, errors.New("failed to fetch claimable balance: " + err.Error())
+	}
+	isClaimant := false
+	for _, claimant := range balance.Claimants {
+		if claimant.Destination == publicKey {
+			isClaimant = true
+		}
+	}
+	if !isClaimant {
+		return nil, errors.New("wallet is not a claimant of this balance")
+	}
+
+	hash, err := s.claimBalance(kp, balance, func(string) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	return &models.ClaimBalanceResponse{
+		BalanceID:       balance.BalanceID,
+		Asset:           balance.Asset,
+		Amount:          balance.Amount,
+		TransactionHash: hash,
+		Message:         "Claimable balance claimed successfully",
+	}, nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/horizonclient"
+)
+
+// ClaimableBalanceSweeper periodically claims claimable balances addressed to managed wallets, trusting
+// incoming assets on the wallet's behalf when its trust policy allows
+type ClaimableBalanceSweeper struct {
+	Wallets  *WalletService
+	Interval time.Duration
+}
+
+// NewClaimableBalanceSweeper creates a new ClaimableBalanceSweeper instance
+func NewClaimableBalanceSweeper(wallets *WalletService, interval time.Duration) *ClaimableBalanceSweeper {
+	return &ClaimableBalanceSweeper{Wallets: wallets, Interval: interval}
+}
+
+// Run sweeps all managed wallets every Interval until ctx is cancelled
+func (w *ClaimableBalanceSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Sweep()
+		}
+	}
+}
+
+// Sweep claims every pending claimable balance for every managed wallet once
+func (w *ClaimableBalanceSweeper) Sweep() {
+	for _, publicKey := range w.Wallets.Registry.PublicKeys() {
+		kp, ok := w.Wallets.Registry.Get(publicKey)
+		if !ok {
+			continue
+		}
+
+		balances, err := w.Wallets.Config.HorizonClient.ClaimableBalances(horizonclient.ClaimableBalanceRequest{Claimant: publicKey})
+		if err != nil {
+			log.Printf("claimable sweep: failed to list balances for %s: %v", publicKey, err)
+			continue
+		}
+
+		for _, balance := range balances.Embedded.Records {
+			if !w.Wallets.assetPermitted(balance.Asset) {
+				continue
+			}
+			hash, err := w.Wallets.claimBalance(kp, balance, func(asset string) bool {
+				return w.Wallets.Config.AutoTrustIncoming && w.Wallets.autoTrustAllowed(publicKey, asset)
+			})
+			if err != nil {
+				log.Printf("claimable sweep: failed to claim %s for %s: %v", balance.BalanceID, publicKey, err)
+				continue
+			}
+			if hash == "" {
+				continue
+			}
+			w.Wallets.Events.Publish(models.EventPaymentReceived, publicKey, map[string]string{
+				"asset":            balance.Asset,
+				"amount":           balance.Amount,
+				"balance_id":       balance.BalanceID,
+				"transaction_hash": hash,
+			})
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// errWalletSecretRequired is returned when a request neither supplies a wallet's secret key nor is
+// authenticated as the tenant that owns the managed wallet
+var errWalletSecretRequired = errors.New("invalid wallet secret key: secret_key is required unless the request is authenticated as the wallet's tenant")
+
+// authorizedSigner returns the keypair that signs for a wallet: the supplied secret key if given, otherwise
+// the custodied key of a managed wallet owned by tenantID. tenantID must come from a verified tenant
+// credential; it is empty for unauthenticated requests, which never unlock a custodied key.
+func (s *WalletService) authorizedSigner(tenantID, publicKey, secretKey string) (*keypair.Full, error) {
+	if secretKey != "" {
+		kp, err := keypair.ParseFull(secretKey)
+		if err != nil || kp.Address() != publicKey {
+			return nil, errors.New("invalid wallet secret key")
+		}
+		return kp, nil
+	}
+	if tenantID == "" {
+		return nil, errWalletSecretRequired
+	}
+	if owner, ok := s.Registry.TenantOf(publicKey); !ok || owner != tenantID {
+		return nil, errWalletSecretRequired
+	}
+	kp, ok := s.Registry.Get(publicKey)
+	if !ok {
+		return nil, errWalletSecretRequired
+	}
+	return kp, nil
+}
+
+// CloseWallet sweeps a wallet's asset balances to a destination, removes its trustlines and merges the
+// remaining XLM into the master account in a single transaction. tenantID is the authenticated tenant, or
+// empty when the request is not authenticated and must supply the wallet's secret key.
+func (s *WalletService) CloseWallet(tenantID, publicKey string, req models.CloseWalletRequest) (*models.CloseWalletResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	if _, err := keypair.ParseAddress(req.Destination); err != nil {
+		return nil, errors.New("invalid destination public key")
+	}
+	walletKP, err := s.authorizedSigner(tenantID, publicKey, req.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	if s.isDeactivated(publicKey) {
+		return nil, errors.New("wallet is deactivated; restore it before closing")
+	}
+	masterKP, err := keypair.ParseFull(s.Config.MasterSecret)
+	if err != nil {
+		return nil, errors.New("invalid master secret key: " + err.Error())
+	}
+
+	accountRequest := horizonclient.AccountRequest{AccountID: publicKey}
+	account, err := s.Config.HorizonClient.AccountDetail(accountRequest)
+	if err != nil {
+		return nil, errors.New("failed to fetch wallet account details: " + err.Error())
+	}
+
+	ops, swept, sweptOps, err := sweepTrustlines(account, req.Destination)
+	if err != nil {
+		return nil, err
+	}
+	trustlines := 0
+	for _, balance := range account.Balances {
+		if balance.Type != "native" {
+			trustlines++
+		}
+	}
+	if int(account.SubentryCount) > trustlines {
+		return nil, errors.New("wallet has open offers, data entries or signers; remove them before closing")
+	}
+	ops = append(ops, &txnbuild.AccountMerge{Destination: masterKP.Address()})
+
+	tx, err := s.buildTransaction(publicKey, txnbuild.TransactionParams{
+		Operations:    ops,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+	}, walletKP)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.submitTransaction(tx, walletKP)
+	if err != nil {
+		return nil, s.attributeFailure(err, swept, sweptOps)
+	}
+	// A merged account that is later recreated starts from a new sequence number
+	s.Sequences.Resync(publicKey)
+	s.Registry.Remove(publicKey)
+	s.Audit.Record("wallet:"+publicKey, "wallet.closed", publicKey, map[string]string{
+		"destination":      req.Destination,
+		"transaction_hash": resp.Hash,
+	})
+	attributeOperations(swept, sweptOps, s.operationResults(resp.Hash, nil))
+
+	return &models.CloseWalletResponse{
+		PublicKey:       publicKey,
+		TransactionHash: resp.Hash,
+		Swept:           swept,
+		MergedInto:      masterKP.Address(),
+		Message:         "Wallet closed and reserves reclaimed",
+	}, nil
+}
+
+// sweepTrustlines returns the operations that pay each of an account's non-zero asset balances to a
+// destination and remove its trustlines, with the swept balances and the operation indexes of each
+func sweepTrustlines(account hProtocol.Account, destination string) ([]txnbuild.Operation, []models.SweptBalance, [][]int, error) {
+	var ops []txnbuild.Operation
+	swept := []models.SweptBalance{}
+	var sweptOps [][]int
+	for _, balance := range account.Balances {
+		switch balance.Type {
+		case "native":
+			continue
+		case "liquidity_pool_shares":
+			return nil, nil, nil, errors.New("wallet holds liquidity pool shares; withdraw them first")
+		}
+
+		asset := txnbuild.CreditAsset{Code: balance.Code, Issuer: balance.Issuer}
+		sweeping := false
+		if stroops, err := amount.ParseInt64(balance.Balance); err == nil && stroops > 0 {
+			ops = append(ops, &txnbuild.Payment{Destination: destination, Amount: balance.Balance, Asset: asset})
+			swept = append(swept, models.SweptBalance{Asset: assetString(asset), Amount: balance.Balance, To: destination})
+			sweptOps = append(sweptOps, []int{len(ops) - 1})
+			sweeping = true
+		}
+		line, err := asset.ToChangeTrustAsset()
+		if err != nil {
+			return nil, nil, nil, errors.New("failed to create trustline asset: " + err.Error())
+		}
+		removeTrust := txnbuild.RemoveTrustlineOp(line)
+		ops = append(ops, &removeTrust)
+		if sweeping {
+			sweptOps[len(sweptOps)-1] = append(sweptOps[len(sweptOps)-1], len(ops)-1)
+		}
+	}
+	return ops, swept, sweptOps, nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// maxDataLength is the protocol limit on both the name and the value of a data entry, in bytes
+const maxDataLength = 64
+
+// dataEntry decodes a data entry value as Horizon reports it, in base64
+func dataEntry(name, encoded string) models.DataEntry {
+	entry := models.DataEntry{Name: name, ValueBase64: encoded}
+	if raw, err := base64.StdEncoding.DecodeString(encoded); err == nil && utf8.Valid(raw) {
+		entry.Value = string(raw)
+	}
+	return entry
+}
+
+// dataEntries decodes an account's data entries, sorted by name
+func dataEntries(data map[string]string) []models.DataEntry {
+	entries := make([]models.DataEntry, 0, len(data))
+	for name, encoded := range data {
+		entries = append(entries, dataEntry(name, encoded))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// manageData submits a ManageData operation on a wallet; a nil value deletes the entry. tenantID is the
+// authenticated tenant, or empty when secretKey must be the wallet's secret key.
+func (s *WalletService) manageData(tenantID, publicKey, secretKey, name string, value []byte) (string, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return "", errors.New("invalid public key format")
+	}
+	if name == "" || len(name) > maxDataLength {
+		return "", errors.New("invalid name: must be 1 to 64 bytes")
+	}
+	walletKP, err := s.authorizedSigner(tenantID, publicKey, secretKey)
+	if err != nil {
+		return "", err
+	}
+	if s.isDeactivated(publicKey) {
+		return "", errors.New("wallet is deactivated; restore it before changing its data entries")
+	}
+
+	tx, err := s.buildTransaction(publicKey, txnbuild.TransactionParams{
+		Operations:    []txnbuild.Operation{&txnbuild.ManageData{Name: name, Value: value}},
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+	}, walletKP)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.submitTransaction(tx, walletKP)
+	if err != nil {
+		return "", err
+	}
+	s.Registry.RecordUpdate(publicKey, "data_entry_updated")
+	return resp.Hash, nil
+}
+
+// SetDataEntry creates or replaces a data entry on a wallet
+func (s *WalletService) SetDataEntry(tenantID, publicKey, name string, req models.DataEntryRequest) (*models.DataEntryResponse, error) {
+	var value []byte
+	switch {
+	case req.Value != "" && req.ValueBase64 != "":
+		return nil, errors.New("invalid value: give value or value_base64, not both")
+	case req.ValueBase64 != "":
+		decoded, err := base64.StdEncoding.DecodeString(req.ValueBase64)
+		if err != nil {
+			return nil, errors.New("invalid value_base64: " + err.Error())
+		}
+		value = decoded
+	default:
+		value = []byte(req.Value)
+	}
+	if len(value) == 0 || len(value) > maxDataLength {
+		return nil, errors.New("invalid value: must be 1 to 64 bytes")
+	}
+
+	hash, err := s.manageData(tenantID, publicKey, req.SecretKey, name, value)
+	if err != nil {
+		return nil, err
+	}
+	s.Audit.Record("wallet:"+publicKey, "wallet.data_set", name, map[string]string{"transaction_hash": hash})
+	return &models.DataEntryResponse{
+		PublicKey:       publicKey,
+		TransactionHash: hash,
+		DataEntry:       dataEntry(name, base64.StdEncoding.EncodeToString(value)),
+	}, nil
+}
+
+// DeleteDataEntry removes a data entry from a wallet
+func (s *WalletService) DeleteDataEntry(tenantID, publicKey, name string, req models.DeleteDataEntryRequest) (*models.DataEntryResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: publicKey})
+	if err != nil {
+		return nil, errors.New("failed to fetch wallet account details: " + err.Error())
+	}
+	encoded, ok := account.Data[name]
+	if !ok {
+		return nil, errors.New("data entry not found")
+	}
+
+	hash, err := s.manageData(tenantID, publicKey, req.SecretKey, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.Audit.Record("wallet:"+publicKey, "wallet.data_deleted", name, map[string]string{"transaction_hash": hash})
+	return &models.DataEntryResponse{
+		PublicKey:       publicKey,
+		TransactionHash: hash,
+		DataEntry:       dataEntry(name, encoded),
+		Deleted:         true,
+	}, nil
+}
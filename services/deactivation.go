@@ -0,0 +1,251 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// quarantineStartingBalance funds a new quarantine account with its base reserve and room for the
+// trustlines of quarantined assets
+const quarantineStartingBalance = "10"
+
+// deactivations stores per-tenant quarantine accounts and the deactivation record of each wallet
+type deactivations struct {
+	mu          sync.Mutex
+	quarantines map[string]*keypair.Full
+	records     map[string]*models.WalletDeactivationResponse
+}
+
+// isDeactivated reports whether a wallet is currently held in quarantine
+func (s *WalletService) isDeactivated(publicKey string) bool {
+	s.deactivations.mu.Lock()
+	defer s.deactivations.mu.Unlock()
+	record, ok := s.deactivations.records[publicKey]
+	return ok && record.State == models.DeactivationActive
+}
+
+// quarantineAccount returns a tenant's quarantine account, creating and funding it from the master
+// account on first use
+func (s *WalletService) quarantineAccount(tenantID string) (*keypair.Full, error) {
+	s.deactivations.mu.Lock()
+	defer s.deactivations.mu.Unlock()
+	if kp, ok := s.deactivations.quarantines[tenantID]; ok {
+		return kp, nil
+	}
+
+	kp, err := keypair.Random()
+	if err != nil {
+		return nil, errors.New("failed to generate keypair: " + err.Error())
+	}
+	masterKP, err := keypair.ParseFull(s.Config.MasterSecret)
+	if err != nil {
+		return nil, errors.New("invalid master secret key: " + err.Error())
+	}
+
+	tx, err := s.buildTransaction(masterKP.Address(), txnbuild.TransactionParams{
+		Operations:    []txnbuild.Operation{&txnbuild.CreateAccount{Destination: kp.Address(), Amount: quarantineStartingBalance}},
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+	}, masterKP)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.submitTransaction(tx, masterKP); err != nil {
+		return nil, errors.New("failed to create quarantine account: " + err.Error())
+	}
+
+	s.deactivations.quarantines[tenantID] = kp
+	return kp, nil
+}
+
+// DeactivateWallet moves a wallet's spendable balances into its tenant's quarantine account and blocks
+// further transfers from it. The wallet account and its trustlines are kept so it can be restored. tenantID
+// is the authenticated tenant, or empty when the request must supply the wallet's secret key.
+func (s *WalletService) DeactivateWallet(tenantID, publicKey string, req models.DeactivateWalletRequest) (*models.WalletDeactivationResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	walletKP, err := s.authorizedSigner(tenantID, publicKey, req.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	if s.isDeactivated(publicKey) {
+		return nil, errors.New("wallet is already deactivated")
+	}
+	tenantID, ok := s.Registry.TenantOf(publicKey)
+	if !ok {
+		tenantID = DefaultTenantID
+	}
+
+	quarantineKP, err := s.quarantineAccount(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: publicKey})
+	if err != nil {
+		return nil, errors.New("failed to fetch wallet account details: " + err.Error())
+	}
+	quarantine, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: quarantineKP.Address()})
+	if err != nil {
+		return nil, errors.New("failed to fetch quarantine account details: " + err.Error())
+	}
+	baseReserve, err := s.baseReserveStroops()
+	if err != nil {
+		return nil, err
+	}
+	baseFee := s.Fees.BaseFee()
+
+	var ops []txnbuild.Operation
+	quarantined := []models.SweptBalance{}
+	var quarantinedOps [][]int
+	signers := []*keypair.Full{walletKP}
+	for _, balance := range account.Balances {
+		if balance.Type == "native" || balance.Type == "liquidity_pool_shares" {
+			continue
+		}
+		available := availableBalance(balance, account, baseReserve)
+		if stroops, err := amount.ParseInt64(available); err != nil || stroops <= 0 {
+			continue
+		}
+
+		asset := txnbuild.CreditAsset{Code: balance.Code, Issuer: balance.Issuer}
+		var itemOps []int
+		if !hasTrustline(quarantine, assetString(asset)) {
+			line, err := asset.ToChangeTrustAsset()
+			if err != nil {
+				return nil, errors.New("failed to create trustline asset: " + err.Error())
+			}
+			ops = append(ops, &txnbuild.ChangeTrust{Line: line, Limit: txnbuild.MaxTrustlineLimit, SourceAccount: quarantineKP.Address()})
+			itemOps = append(itemOps, len(ops)-1)
+			if len(signers) == 1 {
+				signers = append(signers, quarantineKP)
+			}
+		}
+		ops = append(ops, &txnbuild.Payment{Destination: quarantineKP.Address(), Amount: available, Asset: asset})
+		quarantined = append(quarantined, models.SweptBalance{Asset: assetString(asset), Amount: available, To: quarantineKP.Address()})
+		quarantinedOps = append(quarantinedOps, append(itemOps, len(ops)-1))
+	}
+	for _, balance := range account.Balances {
+		if balance.Type != "native" {
+			continue
+		}
+		// Keep enough XLM behind to pay this transaction's fee
+		stroops, _ := amount.ParseInt64(availableBalance(balance, account, baseReserve))
+		stroops -= baseFee * int64(len(ops)+1)
+		if stroops > 0 {
+			xlm := amount.StringFromInt64(stroops)
+			ops = append(ops, &txnbuild.Payment{Destination: quarantineKP.Address(), Amount: xlm, Asset: txnbuild.NativeAsset{}})
+			quarantined = append(quarantined, models.SweptBalance{Asset: "native", Amount: xlm, To: quarantineKP.Address()})
+			quarantinedOps = append(quarantinedOps, []int{len(ops) - 1})
+		}
+	}
+
+	hash := ""
+	if len(ops) > 0 {
+		tx, err := s.buildTransaction(publicKey, txnbuild.TransactionParams{
+			Operations:    ops,
+			BaseFee:       baseFee,
+			Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+		}, signers...)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.submitTransaction(tx, signers...)
+		if err != nil {
+			return nil, s.attributeFailure(err, quarantined, quarantinedOps)
+		}
+		hash = resp.Hash
+		attributeOperations(quarantined, quarantinedOps, s.operationResults(hash, nil))
+	}
+
+	record := &models.WalletDeactivationResponse{
+		PublicKey:         publicKey,
+		TenantID:          tenantID,
+		State:             models.DeactivationActive,
+		Reason:            req.Reason,
+		QuarantineAccount: quarantineKP.Address(),
+		Quarantined:       quarantined,
+		TransactionHash:   hash,
+		DeactivatedAt:     time.Now().UTC(),
+	}
+	s.deactivations.mu.Lock()
+	s.deactivations.records[publicKey] = record
+	s.deactivations.mu.Unlock()
+	s.Registry.RecordUpdate(publicKey, "deactivated")
+
+	result := *record
+	return &result, nil
+}
+
+// RestoreWallet returns a deactivated wallet's quarantined balances and lifts the transfer block
+func (s *WalletService) RestoreWallet(publicKey string) (*models.WalletDeactivationResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+
+	s.deactivations.mu.Lock()
+	record, ok := s.deactivations.records[publicKey]
+	if !ok || record.State != models.DeactivationActive {
+		s.deactivations.mu.Unlock()
+		return nil, errors.New("wallet is not deactivated")
+	}
+	quarantineKP := s.deactivations.quarantines[record.TenantID]
+	pending := *record
+	s.deactivations.mu.Unlock()
+
+	hash := ""
+	if len(pending.Quarantined) > 0 {
+		ops := make([]txnbuild.Operation, 0, len(pending.Quarantined))
+		for _, held := range pending.Quarantined {
+			asset, err := parseAsset(held.Asset)
+			if err != nil {
+				return nil, errors.New("failed to parse quarantined asset: " + err.Error())
+			}
+			ops = append(ops, &txnbuild.Payment{Destination: publicKey, Amount: held.Amount, Asset: asset})
+		}
+
+		tx, err := s.buildTransaction(quarantineKP.Address(), txnbuild.TransactionParams{
+			Operations:    ops,
+			Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+		}, quarantineKP)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.submitTransaction(tx, quarantineKP)
+		if err != nil {
+			return nil, err
+		}
+		hash = resp.Hash
+	}
+
+	s.deactivations.mu.Lock()
+	defer s.deactivations.mu.Unlock()
+	now := time.Now().UTC()
+	record.State = models.DeactivationRestored
+	record.RestoreTransactionHash = hash
+	record.RestoredAt = &now
+	s.Registry.RecordUpdate(publicKey, "restored")
+	result := *record
+	return &result, nil
+}
+
+// GetWalletDeactivation returns the latest deactivation record of a wallet
+func (s *WalletService) GetWalletDeactivation(publicKey string) (*models.WalletDeactivationResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	s.deactivations.mu.Lock()
+	defer s.deactivations.mu.Unlock()
+	record, ok := s.deactivations.records[publicKey]
+	if !ok {
+		return nil, errors.New("wallet deactivation not found")
+	}
+	result := *record
+	return &result, nil
+}
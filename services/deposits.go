@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/horizonclient"
+)
+
+// DepositService records the payments custodied wallets receive as deposits, exactly once each, and
+// notifies tenants of them. Each wallet's deposits and ingestion cursor are persisted to the archive store
+// together, so ingestion resumes where it stopped after a restart without crediting anything twice.
+type DepositService struct {
+	Wallets *WalletService
+
+	mu   sync.Mutex
+	logs map[string]*depositLog
+}
+
+// depositLog is the persisted deposit state of one wallet
+type depositLog struct {
+	// Cursor is the paging token of the last payment ingested; Started is false until the wallet's first
+	// poll set it, since history from before then is never ingested
+	Cursor   string           `json:"cursor"`
+	Started  bool             `json:"started"`
+	Deposits []models.Deposit `json:"deposits"`
+}
+
+// NewDepositService creates a new DepositService instance
+func NewDepositService(wallets *WalletService) *DepositService {
+	s := &DepositService{Wallets: wallets, logs: make(map[string]*depositLog)}
+	wallets.Events.Subscribe(func(event models.Event) {
+		if event.Type == models.EventDepositDetected {
+			s.postCallback(event)
+		}
+	})
+	return s
+}
+
+func depositLogKey(publicKey string) string {
+	return "deposits/" + publicKey + ".json"
+}
+
+// walletLog returns a wallet's deposit log, loading it from the archive store the first time
+func (s *DepositService) walletLog(publicKey string) (*depositLog, error) {
+	s.mu.Lock()
+	entry, ok := s.logs[publicKey]
+	s.mu.Unlock()
+	if ok {
+		return entry, nil
+	}
+
+	entry = &depositLog{}
+	if s.Wallets.Archive != nil {
+		data, err := s.Wallets.Archive.Get(depositLogKey(publicKey))
+		switch {
+		case errors.Is(err, errArchiveNotFound):
+		case err != nil:
+			return nil, errors.New("failed to read deposits: " + err.Error())
+		default:
+			if err := json.Unmarshal(data, entry); err != nil {
+				return nil, errors.New("failed to decode deposits: " + err.Error())
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cached, ok := s.logs[publicKey]; ok {
+		return cached, nil
+	}
+	s.logs[publicKey] = entry
+	return entry, nil
+}
+
+// saveLog persists a wallet's deposit log before it replaces the cached one
+func (s *DepositService) saveLog(publicKey string, entry *depositLog) error {
+	if s.Wallets.Archive != nil {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return errors.New("failed to encode deposits: " + err.Error())
+		}
+		if err := s.Wallets.Archive.Put(depositLogKey(publicKey), data); err != nil {
+			return errors.New("failed to persist deposits: " + err.Error())
+		}
+	}
+	s.mu.Lock()
+	s.logs[publicKey] = entry
+	s.mu.Unlock()
+	return nil
+}
+
+// Run ingests deposits every interval until ctx is cancelled
+func (s *DepositService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Ingest()
+		}
+	}
+}
+
+// Ingest records the deposits each custodied wallet received since the previous poll
+func (s *DepositService) Ingest() {
+	for _, publicKey := range s.Wallets.Registry.PublicKeys() {
+		if err := s.ingestWallet(publicKey); err != nil {
+			log.Printf("deposit ingester: %s: %v", publicKey, err)
+		}
+	}
+}
+
+// ingestWallet records one page of a wallet's new deposits. Wallets seen for the first time only record
+// their latest payment as the cursor, so history is never replayed as deposits.
+func (s *DepositService) ingestWallet(publicKey string) error {
+	current, err := s.walletLog(publicKey)
+	if err != nil {
+		return err
+	}
+	if !current.Started {
+		latest, err := s.Wallets.Config.HorizonClient.Payments(horizonclient.OperationRequest{
+			ForAccount: publicKey,
+			Order:      horizonclient.OrderDesc,
+			Limit:      1,
+		})
+		if err != nil {
+			return errors.New("failed to fetch payments: " + err.Error())
+		}
+		next := &depositLog{Started: true, Deposits: current.Deposits}
+		if len(latest.Embedded.Records) > 0 {
+			next.Cursor = latest.Embedded.Records[0].PagingToken()
+		}
+		return s.saveLog(publicKey, next)
+	}
+
+	payments, err := s.Wallets.Config.HorizonClient.Payments(horizonclient.OperationRequest{
+		ForAccount: publicKey,
+		Cursor:     current.Cursor,
+		Order:      horizonclient.OrderAsc,
+		Limit:      200,
+		Join:       "transactions",
+	})
+	if err != nil {
+		return errors.New("failed to fetch payments: " + err.Error())
+	}
+	if len(payments.Embedded.Records) == 0 {
+		return nil
+	}
+
+	tenantID, _ := s.Wallets.Registry.TenantOf(publicKey)
+	seen := make(map[string]bool, len(current.Deposits))
+	for _, deposit := range current.Deposits {
+		seen[deposit.ID] = true
+	}
+	next := &depositLog{Started: true, Cursor: current.Cursor, Deposits: append([]models.Deposit{}, current.Deposits...)}
+	var added []models.Deposit
+	now := time.Now().UTC()
+	for _, op := range payments.Embedded.Records {
+		next.Cursor = op.PagingToken()
+		record, ok := paymentRecord(op, publicKey)
+		if !ok || !op.IsTransactionSuccessful() || record.Direction != models.DirectionReceived || seen[record.ID] {
+			continue
+		}
+		seen[record.ID] = true
+		deposit := models.Deposit{
+			ID:              record.ID,
+			TenantID:        tenantID,
+			Wallet:          publicKey,
+			Type:            record.Type,
+			From:            record.From,
+			Asset:           record.Asset,
+			Amount:          record.Amount,
+			TransactionHash: record.TransactionHash,
+			CreatedAt:       record.CreatedAt,
+			DetectedAt:      now,
+		}
+		if tx := op.GetBase().Transaction; tx != nil && tx.MemoType != "none" {
+			deposit.Memo, deposit.MemoType = tx.Memo, tx.MemoType
+		}
+		if muxedID, muxed := paymentMuxedID(op); muxed {
+			deposit.MuxedID = strconv.FormatUint(muxedID, 10)
+		}
+		next.Deposits = append(next.Deposits, deposit)
+		added = append(added, deposit)
+	}
+	// With an outbox, the deposits' events are staged before the deposits are recorded, so a recorded deposit
+	// is never missing its notifications; if recording fails, the page is replayed and restages the same
+	// events. Without one, notifications only go out once the deposits are durable.
+	if outbox := s.Wallets.Outbox; outbox != nil {
+		for _, deposit := range added {
+			if err := outbox.Stage(models.EventDepositDetected, publicKey, deposit.ID, depositEventData(deposit)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := s.saveLog(publicKey, next); err != nil {
+		return err
+	}
+	if s.Wallets.Outbox == nil {
+		for _, deposit := range added {
+			s.Wallets.Events.Publish(models.EventDepositDetected, publicKey, depositEventData(deposit))
+		}
+	}
+	return nil
+}
+
+// depositEventData is the data of a deposit.detected event, carrying the whole deposit
+func depositEventData(deposit models.Deposit) map[string]string {
+	data := map[string]string{
+		"deposit_id":       deposit.ID,
+		"operation_type":   deposit.Type,
+		"from":             deposit.From,
+		"asset":            deposit.Asset,
+		"amount":           deposit.Amount,
+		"transaction_hash": deposit.TransactionHash,
+		"created_at":       deposit.CreatedAt.Format(time.RFC3339),
+	}
+	if deposit.Memo != "" {
+		data["memo"], data["memo_type"] = deposit.Memo, deposit.MemoType
+	}
+	if deposit.MuxedID != "" {
+		data["muxed_id"] = deposit.MuxedID
+	}
+	return data
+}
+
+// postCallback posts a deposit.detected event to its tenant's deposit webhook, if it has one. The callback
+// carries the event's ID, so receivers can dedupe redelivered events on it.
+func (s *DepositService) postCallback(event models.Event) {
+	tenantID, _ := s.Wallets.Registry.TenantOf(event.PublicKey)
+	webhookURL := s.Wallets.Config.TenantDepositWebhooks[tenantID]
+	if webhookURL == "" || s.Wallets.Config.CallbackSecret == "" {
+		return
+	}
+	createdAt, _ := time.Parse(time.RFC3339, event.Data["created_at"])
+	callback := models.DepositCallback{
+		ID:    event.ID,
+		Event: models.CallbackDepositReceived,
+		Deposit: models.Deposit{
+			ID:              event.Data["deposit_id"],
+			TenantID:        tenantID,
+			Wallet:          event.PublicKey,
+			Type:            event.Data["operation_type"],
+			From:            event.Data["from"],
+			Asset:           event.Data["asset"],
+			Amount:          event.Data["amount"],
+			Memo:            event.Data["memo"],
+			MemoType:        event.Data["memo_type"],
+			MuxedID:         event.Data["muxed_id"],
+			TransactionHash: event.Data["transaction_hash"],
+			CreatedAt:       createdAt,
+			DetectedAt:      event.CreatedAt,
+		},
+		CreatedAt: time.Now().UTC(),
+	}
+	body, err := json.Marshal(callback)
+	if err != nil {
+		log.Printf("deposit callback %s: failed to encode: %v", callback.ID, err)
+		return
+	}
+	go s.Wallets.deliverSigned("deposit callback "+callback.ID, webhookURL, body)
+}
+
+// ListDeposits returns a tenant's deposits, newest first, optionally of one wallet and detected after since,
+// an RFC 3339 timestamp
+func (s *DepositService) ListDeposits(tenantID, wallet, since string) (*models.DepositsResponse, error) {
+	var after time.Time
+	if since != "" {
+		var err error
+		if after, err = time.Parse(time.RFC3339, since); err != nil {
+			return nil, errors.New("invalid since: must be an RFC 3339 timestamp")
+		}
+	}
+	publicKeys := s.Wallets.Registry.TenantPublicKeys(tenantID)
+	if wallet != "" {
+		if owner, ok := s.Wallets.Registry.TenantOf(wallet); !ok || owner != tenantID {
+			return nil, errors.New("invalid wallet: not a custodied wallet of this tenant")
+		}
+		publicKeys = []string{wallet}
+	}
+	response := &models.DepositsResponse{Deposits: []models.Deposit{}}
+	for _, publicKey := range publicKeys {
+		entry, err := s.walletLog(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		for _, deposit := range entry.Deposits {
+			if deposit.DetectedAt.After(after) {
+				response.Deposits = append(response.Deposits, deposit)
+			}
+		}
+	}
+	sort.Slice(response.Deposits, func(i, j int) bool {
+		return response.Deposits[i].CreatedAt.After(response.Deposits[j].CreatedAt)
+	})
+	return response, nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/stellar/go/clients/horizonclient"
+)
+
+// Destination readiness codes reported in DestinationError
+const (
+	DestAccountMissing = "DEST_ACCOUNT_MISSING"
+	DestNoTrustline    = "DEST_NO_TRUSTLINE"
+	DestNotAuthorized  = "DEST_NOT_AUTHORIZED"
+)
+
+// DestinationError is returned when a transfer's recipient cannot receive it, before anything is
+// submitted, instead of Horizon's op_no_destination or op_no_trust
+type DestinationError struct {
+	Code    string
+	Message string
+	// ClaimableFallbackAvailable is set when retrying with claimable_fallback would deliver the transfer
+	ClaimableFallbackAvailable bool
+}
+
+// Error implements error
+func (e *DestinationError) Error() string {
+	return e.Message
+}
+
+// claimableFallbackPossible reports whether a transfer could be delivered as a claimable balance, which
+// needs a single non-native asset, a single source balance and a plain account destination, since a
+// claimant cannot carry a muxed ID
+func claimableFallbackPossible(transfer *preparedTransfer) bool {
+	return !transfer.sendAsset.IsNative() && transfer.muxedID == "" && len(transfer.sources) == 0 &&
+		assetString(transfer.sendAsset) == assetString(transfer.destAsset)
+}
+
+// checkDestination verifies before any transaction is built that the recipient exists, holds an authorized
+// trustline for the delivered asset and, under SEP-29, that a memo is attached when it requires one.
+// Transfers that will fall back to a claimable balance skip the existence and trustline checks.
+func (s *WalletService) checkDestination(transfer *preparedTransfer) error {
+	destAsset := assetString(transfer.destAsset)
+	fallback := claimableFallbackPossible(transfer)
+	willFallBack := fallback && transfer.request.ClaimableFallback
+
+	account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: transfer.destination})
+	if err != nil {
+		herr, ok := err.(*horizonclient.Error)
+		if !ok || herr.Response.StatusCode != http.StatusNotFound {
+			return errors.New("failed to fetch recipient account details: " + err.Error())
+		}
+		if willFallBack {
+			return nil
+		}
+		return &DestinationError{
+			Code:                       DestAccountMissing,
+			Message:                    "recipient account " + transfer.destination + " does not exist",
+			ClaimableFallbackAvailable: fallback,
+		}
+	}
+
+	if memoRequired(account) && transfer.memo == nil && transfer.muxedID == "" {
+		return errMemoRequired
+	}
+	if destAsset == "native" || willFallBack {
+		return nil
+	}
+	balance, ok := balanceOf(account, destAsset)
+	if !ok {
+		return &DestinationError{
+			Code:                       DestNoTrustline,
+			Message:                    "recipient has no trustline for " + destAsset,
+			ClaimableFallbackAvailable: fallback,
+		}
+	}
+	if balance.IsAuthorized != nil && !*balance.IsAuthorized {
+		return &DestinationError{
+			Code:    DestNotAuthorized,
+			Message: "recipient is not authorized by the issuer to hold " + destAsset,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+)
+
+// Email templates, each rendered as a "<name>.subject" and a "<name>.body" template
+const (
+	emailTemplateLargePayment      = models.EventPaymentReceived
+	emailTemplateTransactionFailed = models.EventTransactionFailed
+	emailTemplateAlert             = "alert"
+)
+
+// defaultEmailTemplates are used for any template not overridden from the template directory
+var defaultEmailTemplates = map[string][2]string{
+	emailTemplateLargePayment: {
+		`Large payment of {{.Event.Data.amount}} {{.Event.Data.asset}} received`,
+		`Wallet {{.Event.PublicKey}}{{if .TenantID}} of tenant {{.TenantID}}{{end}} received {{.Event.Data.amount}} {{.Event.Data.asset}}{{if .Event.Data.counterparty}} from {{.Event.Data.counterparty}}{{end}}, at or above the alert threshold of {{.Threshold}}.
+
+{{if .Event.Data.transaction_hash}}Transaction: {{.Event.Data.transaction_hash}}
+{{end}}Event: {{.Event.ID}} at {{.Event.CreatedAt.Format "2006-01-02 15:04:05 UTC"}}
+`,
+	},
+	emailTemplateTransactionFailed: {
+		`Transaction failed for wallet {{.Event.PublicKey}}`,
+		`A transaction from wallet {{.Event.PublicKey}}{{if .TenantID}} of tenant {{.TenantID}}{{end}} failed.
+
+Error: {{.Event.Data.error}}
+{{if .Event.Data.transaction_hash}}Transaction: {{.Event.Data.transaction_hash}}
+{{end}}Event: {{.Event.ID}} at {{.Event.CreatedAt.Format "2006-01-02 15:04:05 UTC"}}
+`,
+	},
+	emailTemplateAlert: {
+		`[alert] {{.Title}}`,
+		`{{.Message}}
+
+Raised at {{.CreatedAt.Format "2006-01-02 15:04:05 UTC"}}
+`,
+	},
+}
+
+// SMTPConfig is the mail server and addresses operator emails are sent with
+type SMTPConfig struct {
+	Host string
+	// Port defaults to 587
+	Port int
+	// Username and Password authenticate with PLAIN auth when Username is set
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// emailData is what email templates render: Event for wallet events, with the wallet's TenantID and, for
+// large payments, the Threshold crossed; Title and Message for alerts
+type emailData struct {
+	Event     models.Event
+	TenantID  string
+	Threshold string
+	Title     string
+	Message   string
+	CreatedAt time.Time
+}
+
+// EmailNotifier emails operators about large incoming payments, failed transactions and operational alerts
+// such as master account balance warnings. It is an Alerter and handles EventBus events.
+type EmailNotifier struct {
+	SMTP SMTPConfig
+	// Registry resolves the tenant of an event's wallet, when set
+	Registry *WalletRegistry
+	// largePayments maps "native" or CODE:ISSUER to the incoming amount, in stroops, that triggers an email
+	largePayments map[string]int64
+	templates     *template.Template
+	// send is smtp.SendMail, replaceable for tests
+	send func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailNotifier creates an EmailNotifier. largePayments maps assets to the incoming payment amount that
+// triggers an email; assets without a threshold never do. Templates named "<name>.subject.tmpl" and
+// "<name>.body.tmpl" in templateDir, for the names payment.received, transaction.failed and alert, replace
+// the defaults.
+func NewEmailNotifier(config SMTPConfig, largePayments map[string]string, templateDir string) (*EmailNotifier, error) {
+	if config.Host == "" || config.From == "" || len(config.To) == 0 {
+		return nil, errors.New("invalid email configuration: host, from and at least one recipient are required")
+	}
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	thresholds := make(map[string]int64, len(largePayments))
+	for asset, threshold := range largePayments {
+		parsed, err := parseAsset(asset)
+		if err != nil {
+			return nil, errors.New("invalid large payment threshold asset: " + asset)
+		}
+		stroops, err := amount.ParseInt64(threshold)
+		if err != nil || stroops <= 0 {
+			return nil, errors.New("invalid large payment threshold for " + asset + ": " + threshold)
+		}
+		thresholds[assetString(parsed)] = stroops
+	}
+
+	templates := template.New("email")
+	for name, defaults := range defaultEmailTemplates {
+		for i, part := range []string{"subject", "body"} {
+			text := defaults[i]
+			if templateDir != "" {
+				override, err := os.ReadFile(filepath.Join(templateDir, name+"."+part+".tmpl"))
+				switch {
+				case err == nil:
+					text = string(override)
+				case !errors.Is(err, os.ErrNotExist):
+					return nil, errors.New("failed to read email template: " + err.Error())
+				}
+			}
+			if _, err := templates.New(name + "." + part).Option("missingkey=zero").Parse(text); err != nil {
+				return nil, errors.New("invalid email template " + name + "." + part + ": " + err.Error())
+			}
+		}
+	}
+	return &EmailNotifier{SMTP: config, largePayments: thresholds, templates: templates, send: smtp.SendMail}, nil
+}
+
+// Alert emails an operational alert in the background
+func (n *EmailNotifier) Alert(title, message string) {
+	go n.deliver(emailTemplateAlert, emailData{Title: title, Message: message, CreatedAt: time.Now().UTC()})
+}
+
+// HandleEvent emails large incoming payments and failed transactions of managed wallets in the background
+func (n *EmailNotifier) HandleEvent(event models.Event) {
+	data := emailData{Event: event, CreatedAt: event.CreatedAt}
+	if n.Registry != nil {
+		data.TenantID, _ = n.Registry.TenantOf(event.PublicKey)
+	}
+	switch event.Type {
+	case models.EventPaymentReceived:
+		threshold, ok := n.largePayments[event.Data["asset"]]
+		if !ok {
+			return
+		}
+		stroops, err := amount.ParseInt64(event.Data["amount"])
+		if err != nil || stroops < threshold {
+			return
+		}
+		data.Threshold = amount.StringFromInt64(threshold)
+		go n.deliver(emailTemplateLargePayment, data)
+	case models.EventTransactionFailed:
+		go n.deliver(emailTemplateTransactionFailed, data)
+	}
+}
+
+// deliver renders a template and sends it to the operators
+func (n *EmailNotifier) deliver(name string, data emailData) {
+	var subject, body bytes.Buffer
+	if err := n.templates.ExecuteTemplate(&subject, name+".subject", data); err != nil {
+		log.Printf("email %s: failed to render subject: %v", name, err)
+		return
+	}
+	if err := n.templates.ExecuteTemplate(&body, name+".body", data); err != nil {
+		log.Printf("email %s: failed to render body: %v", name, err)
+		return
+	}
+
+	var auth smtp.Auth
+	if n.SMTP.Username != "" {
+		auth = smtp.PlainAuth("", n.SMTP.Username, n.SMTP.Password, n.SMTP.Host)
+	}
+	addr := net.JoinHostPort(n.SMTP.Host, strconv.Itoa(n.SMTP.Port))
+	if err := n.send(addr, auth, n.SMTP.From, n.SMTP.To, n.message(subject.String(), body.String())); err != nil {
+		log.Printf("email %s to %s failed: %v", name, strings.Join(n.SMTP.To, ", "), err)
+	}
+}
+
+// message formats a plain text email; line breaks are stripped from the subject so it cannot add headers
+func (n *EmailNotifier) message(subject, body string) []byte {
+	subject = strings.Join(strings.Fields(subject), " ")
+	var msg bytes.Buffer
+	msg.WriteString("From: " + n.SMTP.From + "\r\n")
+	msg.WriteString("To: " + strings.Join(n.SMTP.To, ", ") + "\r\n")
+	msg.WriteString("Subject: " + subject + "\r\n")
+	msg.WriteString("Date: " + time.Now().UTC().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return msg.Bytes()
+}
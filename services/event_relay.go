@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+)
+
+const (
+	// eventSource identifies this service in published event envelopes
+	eventSource = "stellar-wallet-backend"
+	// eventRelayQueueSize bounds the events waiting to be published; further events are dropped and logged
+	eventRelayQueueSize = 10000
+	// Publishing is retried with a doubling delay before an event is given up on
+	maxEventPublishAttempts = 3
+	eventPublishRetryDelay  = 500 * time.Millisecond
+)
+
+// EventBroker publishes event envelopes to an external message broker such as Kafka or NATS
+type EventBroker interface {
+	Publish(envelope models.EventEnvelope) error
+}
+
+// EventRelay publishes every event on the wallet service's EventBus to an EventBroker, in order, as
+// versioned EventEnvelopes, so downstream systems can consume the event stream
+type EventRelay struct {
+	Wallets *WalletService
+	Broker  EventBroker
+
+	queue chan models.EventEnvelope
+}
+
+// NewEventRelay creates a new EventRelay instance; events are queued from now on and published by Run
+func NewEventRelay(wallets *WalletService, broker EventBroker) *EventRelay {
+	r := &EventRelay{Wallets: wallets, Broker: broker, queue: make(chan models.EventEnvelope, eventRelayQueueSize)}
+	wallets.Events.Subscribe(func(event models.Event) {
+		select {
+		case r.queue <- eventEnvelope(wallets, event):
+		default:
+			log.Printf("event relay: queue full, dropping event %s %s", event.ID, event.Type)
+		}
+	})
+	return r
+}
+
+// eventEnvelope wraps an event in the published schema
+func eventEnvelope(wallets *WalletService, event models.Event) models.EventEnvelope {
+	tenantID, _ := wallets.Registry.TenantOf(event.PublicKey)
+	return models.EventEnvelope{
+		SchemaVersion: models.EventSchemaVersion,
+		ID:            event.ID,
+		Type:          event.Type,
+		Source:        eventSource,
+		TenantID:      tenantID,
+		Wallet:        event.PublicKey,
+		Data:          event.Data,
+		OccurredAt:    event.CreatedAt,
+	}
+}
+
+// Run publishes queued events until ctx is cancelled. With an Outbox, events are handed to it instead, so
+// they are published durably and in order with staged events.
+func (r *EventRelay) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case envelope := <-r.queue:
+			if outbox := r.Wallets.Outbox; outbox != nil {
+				err := outbox.Enqueue(envelope)
+				if err == nil {
+					continue
+				}
+				log.Printf("event relay: failed to add event %s to the outbox, publishing directly: %v", envelope.ID, err)
+			}
+			r.publish(envelope)
+		}
+	}
+}
+
+// publish sends one envelope, retrying with a doubling delay, and logs it if every attempt fails
+func (r *EventRelay) publish(envelope models.EventEnvelope) {
+	var err error
+	delay := eventPublishRetryDelay
+	for attempt := 1; attempt <= maxEventPublishAttempts; attempt++ {
+		if err = r.Broker.Publish(envelope); err == nil {
+			return
+		}
+		if attempt < maxEventPublishAttempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	log.Printf("event relay: failed to publish event %s %s after %d attempts: %v", envelope.ID, envelope.Type, maxEventPublishAttempts, err)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+)
+
+const (
+	// eventStreamBuffer is how many events a stream subscriber may fall behind before it is dropped
+	eventStreamBuffer = 256
+	// maxStreamWallets bounds how many wallets one stream subscribes to
+	maxStreamWallets = 100
+)
+
+// EventSubscription delivers the events of a changing set of a tenant's wallets on one channel
+type EventSubscription struct {
+	// Events receives the events of the subscribed wallets. It is closed by Close, or when the subscriber
+	// fell eventStreamBuffer events behind, in which case Dropped reports true.
+	Events <-chan models.Event
+
+	tenantID    string
+	wallets     *WalletService
+	unsubscribe func()
+
+	mu      sync.Mutex
+	watched map[string]bool
+	events  chan models.Event
+	closed  bool
+	dropped bool
+}
+
+// SubscribeEvents opens an event subscription for a tenant, initially watching no wallet
+func (s *WalletService) SubscribeEvents(tenantID string) *EventSubscription {
+	events := make(chan models.Event, eventStreamBuffer)
+	sub := &EventSubscription{
+		Events:   events,
+		tenantID: tenantID,
+		wallets:  s,
+		watched:  make(map[string]bool),
+		events:   events,
+	}
+	sub.unsubscribe = s.Events.Subscribe(sub.deliver)
+	return sub
+}
+
+// deliver queues an event of a watched wallet, dropping the subscription rather than blocking the publisher
+func (sub *EventSubscription) deliver(event models.Event) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	if sub.closed || !sub.watched[event.PublicKey] {
+		return
+	}
+	select {
+	case sub.events <- event:
+	default:
+		sub.dropped = true
+		sub.closeLocked()
+	}
+}
+
+// Watch adds wallets to the subscription; each must be a custodied wallet of the subscription's tenant.
+// It returns the wallets now watched.
+func (sub *EventSubscription) Watch(publicKeys []string) ([]string, error) {
+	for _, publicKey := range publicKeys {
+		if owner, ok := sub.wallets.Registry.TenantOf(publicKey); !ok || owner != sub.tenantID {
+			return nil, errors.New("invalid wallet: " + publicKey + " is not a custodied wallet of this tenant")
+		}
+	}
+
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	added := 0
+	for _, publicKey := range publicKeys {
+		if !sub.watched[publicKey] {
+			added++
+		}
+	}
+	if len(sub.watched)+added > maxStreamWallets {
+		return nil, errors.New("invalid wallets: a stream watches at most 100 wallets")
+	}
+	for _, publicKey := range publicKeys {
+		sub.watched[publicKey] = true
+	}
+	return sub.watchedLocked(), nil
+}
+
+// Unwatch removes wallets from the subscription and returns the wallets still watched
+func (sub *EventSubscription) Unwatch(publicKeys []string) []string {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	for _, publicKey := range publicKeys {
+		delete(sub.watched, publicKey)
+	}
+	return sub.watchedLocked()
+}
+
+func (sub *EventSubscription) watchedLocked() []string {
+	watched := make([]string, 0, len(sub.watched))
+	for publicKey := range sub.watched {
+		watched = append(watched, publicKey)
+	}
+	sort.Strings(watched)
+	return watched
+}
+
+// Dropped reports whether the subscription was closed because the subscriber fell behind
+func (sub *EventSubscription) Dropped() bool {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	return sub.dropped
+}
+
+// Close ends the subscription and closes Events
+func (sub *EventSubscription) Close() {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	sub.closeLocked()
+}
+
+func (sub *EventSubscription) closeLocked() {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	close(sub.events)
+	// The bus releases its lock before calling handlers, so this is safe from within deliver
+	sub.unsubscribe()
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+)
+
+// EventHandler receives events published on an EventBus
+type EventHandler func(event models.Event)
+
+// EventBus fans out wallet events to in-process subscribers
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers map[uint64]EventHandler
+	nextID   uint64
+}
+
+// NewEventBus creates a new EventBus instance
+func NewEventBus() *EventBus {
+	return &EventBus{handlers: make(map[uint64]EventHandler)}
+}
+
+// Subscribe registers a handler that is called for every published event until the returned function is
+// called. Handlers run on the publisher's goroutine, so they must not block.
+func (b *EventBus) Subscribe(handler EventHandler) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}
+}
+
+// Publish builds an event and delivers it synchronously to all subscribers
+func (b *EventBus) Publish(eventType, publicKey string, data map[string]string) models.Event {
+	event := models.Event{
+		ID:        newID(),
+		Type:      eventType,
+		PublicKey: publicKey,
+		Data:      data,
+		CreatedAt: time.Now().UTC(),
+	}
+	b.Deliver(event)
+	return event
+}
+
+// Deliver delivers an already built event, such as one staged in the Outbox, synchronously to all subscribers
+func (b *EventBus) Deliver(event models.Event) {
+	log.Printf("event %s %s for %s", event.ID, event.Type, event.PublicKey)
+
+	b.mu.RLock()
+	handlers := make([]EventHandler, 0, len(b.handlers))
+	for _, handler := range b.handlers {
+		handlers = append(handlers, handler)
+	}
+	b.mu.RUnlock()
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+func newID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/txnbuild"
+)
+
+// maxExternalIDLength bounds the client reference attached to a transfer
+const maxExternalIDLength = 64
+
+// externalTransfers remembers every transfer submitted with an external_id, by ID and sender, so a client
+// retry returns the original result instead of paying twice. Each ID's transfers are kept in the archive
+// store, so a retry after a restart is deduplicated too.
+type externalTransfers struct {
+	mu sync.Mutex
+	// records caches the transfers of each external_id read from or written to the archive store
+	records map[string]map[string]externalTransfer
+}
+
+// externalTransfer is the request fingerprint and result of a transfer. Response is nil until the transfer's
+// outcome is final; until then TransactionHash and MaxTime identify the transaction once it is about to be
+// submitted, and ReviewID the review a held transfer awaits.
+type externalTransfer struct {
+	Fingerprint     string                   `json:"fingerprint"`
+	Response        *models.TransferResponse `json:"response,omitempty"`
+	TransactionHash string                   `json:"transaction_hash,omitempty"`
+	MaxTime         int64                    `json:"max_time,omitempty"`
+	ReviewID        string                   `json:"review_id,omitempty"`
+}
+
+// externalIDKey is where the transfers of an external_id are archived; IDs are hex-encoded as they may
+// contain any character
+func externalIDKey(externalID string) string {
+	return "transfers/external-ids/" + hex.EncodeToString([]byte(externalID)) + ".json"
+}
+
+// transferFingerprint captures the fields a repeat submission must match to be treated as the same transfer
+func transferFingerprint(req models.TransferRequest) string {
+	return strings.Join([]string{req.ToPublicKey, req.Amount, req.SourceAsset, req.DestinationAsset, req.Memo, req.MemoType}, "\x00")
+}
+
+// validateExternalID checks the format of a client reference
+func validateExternalID(externalID string) error {
+	if len(externalID) > maxExternalIDLength || strings.TrimSpace(externalID) != externalID {
+		return errors.New("invalid external_id: must be at most 64 characters without surrounding whitespace")
+	}
+	return nil
+}
+
+// externalIDRecordLocked returns the transfers made with an external_id by sender, reading them from the
+// archive store the first time they are needed; s.externalTransfers.mu must be held
+func (s *WalletService) externalIDRecordLocked(externalID string) (map[string]externalTransfer, error) {
+	if record, ok := s.externalTransfers.records[externalID]; ok {
+		return record, nil
+	}
+	record := make(map[string]externalTransfer)
+	if s.Archive != nil {
+		data, err := s.Archive.Get(externalIDKey(externalID))
+		switch {
+		case errors.Is(err, errArchiveNotFound):
+		case err != nil:
+			return nil, errors.New("failed to read external_id transfers: " + err.Error())
+		default:
+			if err := json.Unmarshal(data, &record); err != nil {
+				return nil, errors.New("failed to decode external_id transfers: " + err.Error())
+			}
+		}
+	}
+	s.externalTransfers.records[externalID] = record
+	return record, nil
+}
+
+// updateExternalIDLocked persists the transfers of an external_id with sender's entry replaced, or removed
+// when entry is nil, and only then caches them; s.externalTransfers.mu must be held
+func (s *WalletService) updateExternalIDLocked(externalID, sender string, entry *externalTransfer) error {
+	record, err := s.externalIDRecordLocked(externalID)
+	if err != nil {
+		return err
+	}
+	updated := make(map[string]externalTransfer, len(record)+1)
+	for existing, transfer := range record {
+		updated[existing] = transfer
+	}
+	if entry != nil {
+		updated[sender] = *entry
+	} else {
+		delete(updated, sender)
+	}
+	if s.Archive != nil {
+		data, err := json.Marshal(updated)
+		if err != nil {
+			return errors.New("failed to encode external_id transfers: " + err.Error())
+		}
+		if err := s.Archive.Put(externalIDKey(externalID), data); err != nil {
+			return errors.New("failed to persist external_id transfers: " + err.Error())
+		}
+	}
+	s.externalTransfers.records[externalID] = updated
+	return nil
+}
+
+// claimExternalID records a transfer's external_id before it is executed. It returns the stored result when
+// the same transfer already completed, the review when it is held, and an error when the ID is in flight or
+// was used for another transfer. A claim whose transfer had an unknown outcome is resolved first.
+func (s *WalletService) claimExternalID(transfer *preparedTransfer) (*models.TransferResponse, error) {
+	if err := s.resolveExternalID(transfer); err != nil {
+		return nil, err
+	}
+	externalID, sender := transfer.request.ExternalID, transfer.senderKP.Address()
+	fingerprint := transferFingerprint(transfer.request)
+
+	s.externalTransfers.mu.Lock()
+	defer s.externalTransfers.mu.Unlock()
+	record, err := s.externalIDRecordLocked(externalID)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := record[sender]
+	if !ok {
+		return nil, s.updateExternalIDLocked(externalID, sender, &externalTransfer{Fingerprint: fingerprint})
+	}
+	if entry.Fingerprint != fingerprint {
+		return nil, errors.New("external_id already used for a different transfer")
+	}
+	if entry.Response == nil {
+		if review := s.pendingReview(entry.ReviewID); review != nil {
+			return &models.TransferResponse{
+				Status:           models.TransferPendingReview,
+				ReviewID:         review.ID,
+				Message:          "Transfer held for manual review",
+				SourceAsset:      review.SourceAsset,
+				DestinationAsset: review.DestinationAsset,
+				ExternalID:       externalID,
+				Replayed:         true,
+			}, nil
+		}
+		return nil, errors.New("external_id transfer already in progress")
+	}
+	replay := *entry.Response
+	replay.Replayed = true
+	return &replay, nil
+}
+
+// resolveExternalID settles the claim of a transfer that was submitted without a definite outcome by looking
+// its transaction up on Horizon: an applied transaction completes the claim, and one that failed or can no
+// longer be applied releases it. A held transfer whose review was lost in a restart never ran, so its claim
+// is released too. Claims that are still in flight are left as they are.
+func (s *WalletService) resolveExternalID(transfer *preparedTransfer) error {
+	externalID, sender := transfer.request.ExternalID, transfer.senderKP.Address()
+	s.externalTransfers.mu.Lock()
+	record, err := s.externalIDRecordLocked(externalID)
+	entry, ok := record[sender]
+	s.externalTransfers.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if !ok || entry.Response != nil || entry.Fingerprint != transferFingerprint(transfer.request) {
+		return nil
+	}
+
+	var resolved *externalTransfer
+	switch {
+	case entry.TransactionHash != "":
+		tx, err := s.Config.HorizonClient.TransactionDetail(entry.TransactionHash)
+		herr, ok := err.(*horizonclient.Error)
+		notFound := ok && herr.Response.StatusCode == http.StatusNotFound
+		switch {
+		case err == nil && tx.Successful:
+			resolved = &externalTransfer{Fingerprint: entry.Fingerprint, Response: &models.TransferResponse{
+				Status:             models.TransferCompleted,
+				TransactionHash:    entry.TransactionHash,
+				Message:            "Transfer completed successfully",
+				SourceAsset:        assetString(transfer.sendAsset),
+				DestinationAsset:   assetString(transfer.destAsset),
+				DestinationMuxedID: transfer.muxedID,
+				FederationAddress:  transfer.federationAddress,
+				ExternalID:         externalID,
+			}}
+		case err == nil:
+		case notFound && entry.MaxTime != 0 && time.Now().Add(-settlementExpiryMargin).Unix() > entry.MaxTime:
+		case notFound:
+			return nil
+		default:
+			return errors.New("failed to look up external_id transfer " + entry.TransactionHash + ": " + err.Error())
+		}
+	case entry.ReviewID != "":
+		s.reviews.mu.Lock()
+		_, held := s.reviews.pending[entry.ReviewID]
+		s.reviews.mu.Unlock()
+		if held {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	s.externalTransfers.mu.Lock()
+	defer s.externalTransfers.mu.Unlock()
+	// Another request may have resolved the claim meanwhile
+	if record, err = s.externalIDRecordLocked(externalID); err != nil || record[sender] != entry {
+		return err
+	}
+	return s.updateExternalIDLocked(externalID, sender, resolved)
+}
+
+// pendingReview returns the review a held transfer awaits, or nil when reviewID is not pending
+func (s *WalletService) pendingReview(reviewID string) *models.TransferReviewResponse {
+	if reviewID == "" {
+		return nil
+	}
+	s.reviews.mu.Lock()
+	defer s.reviews.mu.Unlock()
+	held, ok := s.reviews.pending[reviewID]
+	if !ok || held.review.Status != models.ReviewPending {
+		return nil
+	}
+	review := held.review
+	return &review
+}
+
+// noteExternalID records how to resolve a claimed transfer that has no final result yet; update edits the
+// claim, which is left alone if it no longer belongs to the transfer
+func (s *WalletService) noteExternalID(transfer *preparedTransfer, update func(entry *externalTransfer)) error {
+	externalID, sender := transfer.request.ExternalID, transfer.senderKP.Address()
+	s.externalTransfers.mu.Lock()
+	defer s.externalTransfers.mu.Unlock()
+	record, err := s.externalIDRecordLocked(externalID)
+	if err != nil {
+		return err
+	}
+	entry, ok := record[sender]
+	if !ok || entry.Response != nil || entry.Fingerprint != transferFingerprint(transfer.request) {
+		return nil
+	}
+	update(&entry)
+	return s.updateExternalIDLocked(externalID, sender, &entry)
+}
+
+// submittingExternalID records the transaction of a claimed transfer, so a retry after an unknown outcome
+// can look it up
+func (s *WalletService) submittingExternalID(transfer *preparedTransfer, tx *txnbuild.Transaction) error {
+	hash, err := tx.HashHex(s.networkPassphrase())
+	if err != nil {
+		return errors.New("failed to hash transaction: " + err.Error())
+	}
+	return s.noteExternalID(transfer, func(entry *externalTransfer) {
+		entry.TransactionHash, entry.MaxTime = hash, tx.Timebounds().MaxTime
+	})
+}
+
+// completeExternalID stores the result of a claimed transfer for later retries
+func (s *WalletService) completeExternalID(transfer *preparedTransfer, response *models.TransferResponse) {
+	externalID, sender := transfer.request.ExternalID, transfer.senderKP.Address()
+	stored := *response
+	s.externalTransfers.mu.Lock()
+	defer s.externalTransfers.mu.Unlock()
+	err := s.updateExternalIDLocked(externalID, sender, &externalTransfer{
+		Fingerprint: transferFingerprint(transfer.request),
+		Response:    &stored,
+	})
+	if err != nil {
+		log.Printf("failed to record result of external_id %q of %s: %v", externalID, sender, err)
+	}
+}
+
+// releaseExternalID forgets a claimed transfer that definitely did not happen, so the client may retry it
+func (s *WalletService) releaseExternalID(transfer *preparedTransfer) {
+	externalID, sender := transfer.request.ExternalID, transfer.senderKP.Address()
+	s.externalTransfers.mu.Lock()
+	defer s.externalTransfers.mu.Unlock()
+	if err := s.updateExternalIDLocked(externalID, sender, nil); err != nil {
+		log.Printf("failed to release external_id %q of %s: %v", externalID, sender, err)
+	}
+}
+
+// externalIDTransfers returns the sender and result of every completed transfer submitted with externalID
+func (s *WalletService) externalIDTransfers(externalID string) (map[string]models.TransferResponse, error) {
+	s.externalTransfers.mu.Lock()
+	defer s.externalTransfers.mu.Unlock()
+	record, err := s.externalIDRecordLocked(externalID)
+	if err != nil {
+		return nil, err
+	}
+	transfers := make(map[string]models.TransferResponse)
+	for sender, entry := range record {
+		if entry.Response != nil {
+			transfers[sender] = *entry.Response
+		}
+	}
+	return transfers, nil
+}
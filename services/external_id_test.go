@@ -0,0 +1,130 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+	"github.com/saif727/stellar-wallet-backend/testsupport"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+)
+
+func TestTransferExternalID(t *testing.T) {
+	tests := []struct {
+		name string
+		// first and second are the amounts of two transfers with the same external_id
+		first, second string
+		rejectFirst   bool
+		wantErr       string
+		wantReplay    bool
+		wantSubmitted int
+	}{
+		{name: "repeat returns the first result", first: "5", second: "5", wantReplay: true, wantSubmitted: 1},
+		{name: "different transfer is refused", first: "5", second: "6", wantErr: "external_id already used for a different transfer", wantSubmitted: 1},
+		{name: "rejected transfer can be retried", first: "5", second: "5", rejectFirst: true, wantSubmitted: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTransferFixture(t)
+			request := func(value string) models.TransferRequest {
+				req := testsupport.NewTransferRequest(f.sender, f.recipient.Address(), value)
+				req.ExternalID = "order-42"
+				return req
+			}
+			if tt.rejectFirst {
+				f.horizon.FailNextSubmission(hProtocol.TransactionResultCodes{TransactionCode: "tx_failed", OperationCodes: []string{"op_underfunded"}})
+			}
+
+			first, err := f.service.TransferFunds(request(tt.first))
+			if tt.rejectFirst != (err != nil) {
+				t.Fatalf("first transfer: error = %v", err)
+			}
+			second, err := f.service.TransferFunds(request(tt.second))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("second transfer: error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("second transfer: error = %v", err)
+			}
+
+			if got := len(f.horizon.Submitted()); got != tt.wantSubmitted {
+				t.Errorf("submitted %d transactions, want %d", got, tt.wantSubmitted)
+			}
+			if tt.wantReplay && (!second.Replayed || second.TransactionHash != first.TransactionHash) {
+				t.Errorf("second transfer = %+v, want a replay of %s", second, first.TransactionHash)
+			}
+			if !tt.wantReplay && second != nil && second.Replayed {
+				t.Errorf("second transfer was replayed, want it executed")
+			}
+		})
+	}
+}
+
+// flagAllScorer holds every transfer for review
+type flagAllScorer struct{}
+
+func (flagAllScorer) Score(services.TransferContext) (services.FraudAssessment, error) {
+	return services.FraudAssessment{Score: 1, Reasons: []string{"test"}}, nil
+}
+
+func TestTransferExternalIDUnsettled(t *testing.T) {
+	tests := []struct {
+		name string
+		// loseResponse loses the first submission's response; hold holds the first transfer for review,
+		// which decide then approves or rejects when set
+		loseResponse  bool
+		hold          bool
+		decide        string
+		wantStatus    string
+		wantReplay    bool
+		wantSubmitted int
+	}{
+		{name: "lost response is resolved from the ledger", loseResponse: true, wantStatus: models.TransferCompleted, wantReplay: true, wantSubmitted: 1},
+		{name: "held transfer replays its review", hold: true, wantStatus: models.TransferPendingReview, wantReplay: true},
+		{name: "approved transfer replays its result", hold: true, decide: models.ReviewApproved, wantStatus: models.TransferCompleted, wantReplay: true, wantSubmitted: 1},
+		{name: "rejected transfer can be retried", hold: true, decide: models.ReviewRejected, wantStatus: models.TransferCompleted, wantSubmitted: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTransferFixture(t)
+			req := testsupport.NewTransferRequest(f.sender, f.recipient.Address(), "5")
+			req.ExternalID = "order-42"
+			if tt.hold {
+				f.service.FraudScorer = flagAllScorer{}
+			}
+			f.horizon.LoseResponses(tt.loseResponse)
+
+			first, err := f.service.TransferFunds(req)
+			if tt.loseResponse != (err != nil) {
+				t.Fatalf("first transfer: error = %v", err)
+			}
+			f.horizon.LoseResponses(false)
+			switch tt.decide {
+			case models.ReviewApproved:
+				_, err = f.service.ApproveTransferReview(first.ReviewID)
+			case models.ReviewRejected:
+				_, err = f.service.RejectTransferReview(first.ReviewID)
+				f.service.FraudScorer = nil
+			}
+			if tt.decide != "" && err != nil {
+				t.Fatalf("deciding review: %v", err)
+			}
+
+			second, err := f.service.TransferFunds(req)
+			if err != nil {
+				t.Fatalf("second transfer: error = %v", err)
+			}
+			if second.Status != tt.wantStatus || second.Replayed != tt.wantReplay {
+				t.Errorf("second transfer = %+v, want status %s replayed %v", second, tt.wantStatus, tt.wantReplay)
+			}
+			if tt.hold && tt.decide == "" && second.ReviewID != first.ReviewID {
+				t.Errorf("second transfer review = %s, want %s", second.ReviewID, first.ReviewID)
+			}
+			if got := len(f.horizon.Submitted()); got != tt.wantSubmitted {
+				t.Errorf("submitted %d transactions, want %d", got, tt.wantSubmitted)
+			}
+		})
+	}
+}
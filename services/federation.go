@@ -0,0 +1,325 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+)
+
+const federationNamesKey = "federation/names.json"
+
+// federationNamePattern restricts names to characters that need no escaping in addresses or URLs
+var federationNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._+-]{0,63}$`)
+
+// Federation request types (SEP-2)
+const (
+	FederationTypeName = "name"
+	FederationTypeID   = "id"
+)
+
+// FederationService is a SEP-2 federation server for managed wallets: tenants give their wallets names, and
+// name*Domain resolves to the wallet, with the name's memo if it has one
+type FederationService struct {
+	Wallets *WalletService
+	// Domain is the domain names are served under, e.g. example.com for alice*example.com
+	Domain string
+
+	mu     sync.Mutex
+	loaded bool
+	// names maps each name to its record
+	names map[string]models.FederationName
+}
+
+// NewFederationService creates a FederationService serving names under domain
+func NewFederationService(wallets *WalletService, domain string) (*FederationService, error) {
+	if !homeDomainPattern.MatchString(domain) {
+		return nil, errors.New("invalid federation domain: " + domain)
+	}
+	return &FederationService{Wallets: wallets, Domain: strings.ToLower(domain)}, nil
+}
+
+// loadLocked reads the names from the archive store the first time they are needed; s.mu must be held
+func (s *FederationService) loadLocked() error {
+	if s.loaded {
+		return nil
+	}
+	names := make(map[string]models.FederationName)
+	if s.Wallets.Archive != nil {
+		data, err := s.Wallets.Archive.Get(federationNamesKey)
+		switch {
+		case errors.Is(err, errArchiveNotFound):
+		case err != nil:
+			return errors.New("failed to read federation names: " + err.Error())
+		default:
+			if err := json.Unmarshal(data, &names); err != nil {
+				return errors.New("failed to decode federation names: " + err.Error())
+			}
+		}
+	}
+	s.names = names
+	s.loaded = true
+	return nil
+}
+
+// saveLocked persists and swaps in a new set of names; s.mu must be held
+func (s *FederationService) saveLocked(names map[string]models.FederationName) error {
+	if s.Wallets.Archive != nil {
+		data, err := json.Marshal(names)
+		if err != nil {
+			return errors.New("failed to encode federation names: " + err.Error())
+		}
+		if err := s.Wallets.Archive.Put(federationNamesKey, data); err != nil {
+			return errors.New("failed to persist federation names: " + err.Error())
+		}
+	}
+	s.names = names
+	return nil
+}
+
+// ownedWallet checks that a wallet is custodied for the tenant
+func (s *FederationService) ownedWallet(tenantID, publicKey string) error {
+	if owner, ok := s.Wallets.Registry.TenantOf(publicKey); !ok || owner != tenantID {
+		return errors.New("wallet not found")
+	}
+	return nil
+}
+
+// SetName gives one of a tenant's wallets a federation name, or changes the memo of a name it already has
+func (s *FederationService) SetName(tenantID, publicKey string, req models.FederationNameRequest) (*models.FederationName, error) {
+	if err := s.ownedWallet(tenantID, publicKey); err != nil {
+		return nil, err
+	}
+	name := strings.ToLower(req.Name)
+	if !federationNamePattern.MatchString(name) {
+		return nil, errors.New("invalid federation name: use up to 64 letters, digits, '.', '_', '+' or '-'")
+	}
+	if _, err := parseMemo(req.Memo, req.MemoType); err != nil {
+		return nil, err
+	}
+	memoType := req.MemoType
+	if req.Memo != "" && memoType == "" {
+		memoType = "text"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	record, exists := s.names[name]
+	if exists && record.Wallet != publicKey {
+		return nil, errors.New("federation name already taken: " + name)
+	}
+	if !exists {
+		record = models.FederationName{
+			Name:           name,
+			StellarAddress: name + "*" + s.Domain,
+			Wallet:         publicKey,
+			TenantID:       tenantID,
+			CreatedAt:      time.Now().UTC(),
+		}
+	}
+	record.Memo, record.MemoType = req.Memo, memoType
+
+	names := make(map[string]models.FederationName, len(s.names)+1)
+	for key, existing := range s.names {
+		names[key] = existing
+	}
+	names[name] = record
+	if err := s.saveLocked(names); err != nil {
+		return nil, err
+	}
+	s.Wallets.Audit.Record("tenant:"+tenantID, "federation.name_set", record.StellarAddress, map[string]string{"wallet": publicKey})
+	return &record, nil
+}
+
+// ListNames returns the federation names of one of a tenant's wallets, ordered by name
+func (s *FederationService) ListNames(tenantID, publicKey string) (*models.FederationNamesResponse, error) {
+	if err := s.ownedWallet(tenantID, publicKey); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	response := &models.FederationNamesResponse{Names: []models.FederationName{}}
+	for _, record := range s.names {
+		if record.Wallet == publicKey {
+			response.Names = append(response.Names, record)
+		}
+	}
+	sort.Slice(response.Names, func(i, j int) bool { return response.Names[i].Name < response.Names[j].Name })
+	return response, nil
+}
+
+// RemoveName removes a federation name from one of a tenant's wallets
+func (s *FederationService) RemoveName(tenantID, publicKey, name string) error {
+	if err := s.ownedWallet(tenantID, publicKey); err != nil {
+		return err
+	}
+	name = strings.ToLower(name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	record, ok := s.names[name]
+	if !ok || record.Wallet != publicKey {
+		return errors.New("federation name not found")
+	}
+	names := make(map[string]models.FederationName, len(s.names))
+	for key, existing := range s.names {
+		if key != name {
+			names[key] = existing
+		}
+	}
+	if err := s.saveLocked(names); err != nil {
+		return err
+	}
+	s.Wallets.Audit.Record("tenant:"+tenantID, "federation.name_removed", record.StellarAddress, map[string]string{"wallet": publicKey})
+	return nil
+}
+
+// Resolve answers a federation request: type name resolves name*domain to its wallet and memo, and type id
+// returns the first name of a wallet. Other request types are not supported.
+func (s *FederationService) Resolve(query, requestType string) (*models.FederationRecord, error) {
+	switch requestType {
+	case FederationTypeName:
+		record, ok, err := s.lookup(query)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("federation address not found")
+		}
+		return record, nil
+	case FederationTypeID:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if err := s.loadLocked(); err != nil {
+			return nil, err
+		}
+		var first *models.FederationName
+		for _, record := range s.names {
+			if record.Wallet == query && (first == nil || record.Name < first.Name) {
+				first = &record
+			}
+		}
+		if first == nil {
+			return nil, errors.New("federation address not found")
+		}
+		return &models.FederationRecord{StellarAddress: first.StellarAddress, AccountID: first.Wallet}, nil
+	case "":
+		return nil, errors.New("invalid federation request: type is required")
+	}
+	return nil, errors.New("federation request type not supported: " + requestType)
+}
+
+// lookup resolves an address on this server's domain; ok is false for other domains and unknown names
+func (s *FederationService) lookup(address string) (*models.FederationRecord, bool, error) {
+	name, domain, found := cutFederationAddress(address)
+	if !found {
+		return nil, false, errors.New("invalid federation address: " + address)
+	}
+	if domain != s.Domain {
+		return nil, false, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, false, err
+	}
+	record, ok := s.names[name]
+	if !ok {
+		return nil, false, nil
+	}
+	return &models.FederationRecord{
+		StellarAddress: record.StellarAddress,
+		AccountID:      record.Wallet,
+		MemoType:       record.MemoType,
+		Memo:           record.Memo,
+	}, true, nil
+}
+
+// cutFederationAddress splits name*domain at its last "*", lowercasing both parts
+func cutFederationAddress(address string) (string, string, bool) {
+	i := strings.LastIndex(address, "*")
+	if i <= 0 || i == len(address)-1 {
+		return "", "", false
+	}
+	return strings.ToLower(address[:i]), strings.ToLower(address[i+1:]), true
+}
+
+// isFederationAddress reports whether a transfer destination is a federation address
+func isFederationAddress(address string) bool {
+	return strings.Contains(address, "*")
+}
+
+// resolveFederationAddress resolves a federation address to the account and memo payments to it must use.
+// Names on this service's own federation domain are resolved locally; others through their domain's
+// federation server. Hash memos, base64 in federation responses, are returned hex encoded as transfers take
+// them.
+func (s *WalletService) resolveFederationAddress(address string) (*models.FederationRecord, error) {
+	if _, _, ok := cutFederationAddress(address); !ok {
+		return nil, errors.New("invalid recipient federation address: " + address)
+	}
+	if s.Federation != nil {
+		record, ok, err := s.Federation.lookup(address)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return record, nil
+		}
+		if _, domain, _ := cutFederationAddress(address); domain == s.Federation.Domain {
+			return nil, errors.New("invalid recipient federation address: " + address + " not found")
+		}
+	}
+	resp, err := s.FederationClient.LookupByAddress(address)
+	if err != nil {
+		return nil, errors.New("invalid recipient federation address: " + address + ": " + err.Error())
+	}
+	record := &models.FederationRecord{StellarAddress: address, AccountID: resp.AccountID, MemoType: resp.MemoType, Memo: resp.Memo.Value}
+	if record.MemoType == "hash" {
+		memo, err := hexHashMemo(record.Memo)
+		if err != nil {
+			return nil, errors.New("invalid recipient federation address: " + address + " returned an invalid hash memo")
+		}
+		record.Memo = memo
+	}
+	return record, nil
+}
+
+// applyFederationAddress replaces a transfer request's federation address with the account it resolves to,
+// and its memo with the address's memo. A memo given alongside an address that has one must match it.
+func (s *WalletService) applyFederationAddress(req *models.TransferRequest) (string, error) {
+	address := req.ToPublicKey
+	record, err := s.resolveFederationAddress(address)
+	if err != nil {
+		return "", err
+	}
+	req.ToPublicKey = record.AccountID
+	if record.Memo == "" {
+		return address, nil
+	}
+	memoType := record.MemoType
+	if memoType == "" {
+		memoType = "text"
+	}
+	requestType := req.MemoType
+	if requestType == "" {
+		requestType = "text"
+	}
+	if req.Memo != "" && (req.Memo != record.Memo || requestType != memoType) {
+		return "", errors.New("invalid memo: federation address " + address + " requires " + memoType + " memo " + record.Memo)
+	}
+	req.Memo, req.MemoType = record.Memo, memoType
+	return address, nil
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// maxUnconfirmedTransactions bounds how many failed or timed-out submissions are kept for fee bumping
+const maxUnconfirmedTransactions = 1000
+
+// maxFeeBumpBaseFee caps the per-operation fee the master account will pay, 0.1 XLM
+const maxFeeBumpBaseFee = 1000000
+
+// unconfirmedTransactions remembers signed transactions whose submission failed or timed out, so they can
+// be resubmitted inside a fee bump without the archive
+type unconfirmedTransactions struct {
+	mu    sync.Mutex
+	txs   map[string]*unconfirmedTransaction
+	order []string
+}
+
+// unconfirmedTransaction is a signed transaction and the error its last submission returned
+type unconfirmedTransaction struct {
+	tx        *txnbuild.Transaction
+	submitErr error
+}
+
+// add records a transaction under its hash, evicting the oldest when full
+func (u *unconfirmedTransactions) add(hash string, tx *txnbuild.Transaction, submitErr error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if entry, ok := u.txs[hash]; ok {
+		entry.submitErr = submitErr
+		return
+	}
+	if len(u.order) >= maxUnconfirmedTransactions {
+		delete(u.txs, u.order[0])
+		u.order = u.order[1:]
+	}
+	u.txs[hash] = &unconfirmedTransaction{tx: tx, submitErr: submitErr}
+	u.order = append(u.order, hash)
+}
+
+func (u *unconfirmedTransactions) get(hash string) (unconfirmedTransaction, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	entry, ok := u.txs[hash]
+	if !ok {
+		return unconfirmedTransaction{}, false
+	}
+	return *entry, true
+}
+
+func (u *unconfirmedTransactions) remove(hash string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if _, ok := u.txs[hash]; !ok {
+		return
+	}
+	delete(u.txs, hash)
+	for i, h := range u.order {
+		if h == hash {
+			u.order = append(u.order[:i], u.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// rememberUnconfirmed keeps a transaction whose submission failed for a later fee bump
+func (s *WalletService) rememberUnconfirmed(tx *txnbuild.Transaction, submitErr error) {
+	hash, err := tx.HashHex(s.networkPassphrase())
+	if err != nil {
+		return
+	}
+	s.unconfirmed.add(hash, tx, submitErr)
+}
+
+// stuckTransaction finds the signed envelope of an unconfirmed transaction, from memory or the archive
+func (s *WalletService) stuckTransaction(hash string) (*txnbuild.Transaction, error) {
+	if entry, ok := s.unconfirmed.get(hash); ok {
+		return entry.tx, nil
+	}
+	if s.Archive == nil {
+		return nil, errors.New("transaction not found")
+	}
+	record, err := s.GetArchivedTransaction(hash)
+	if err != nil {
+		if err == errArchiveNotFound {
+			return nil, errors.New("transaction not found")
+		}
+		return nil, err
+	}
+	if record.Successful {
+		return nil, errors.New("transaction is already confirmed")
+	}
+	parsed, err := txnbuild.TransactionFromXDR(record.EnvelopeXDR)
+	if err != nil {
+		return nil, errors.New("failed to decode archived envelope: " + err.Error())
+	}
+	tx, ok := parsed.Transaction()
+	if !ok {
+		return nil, errors.New("invalid transaction: already a fee bump")
+	}
+	return tx, nil
+}
+
+// FeeBumpTransaction wraps a previously submitted but unconfirmed transaction in a fee bump paid by the
+// master account and resubmits it, to get it included during surge pricing
+func (s *WalletService) FeeBumpTransaction(hash string, req models.FeeBumpRequest) (*models.FeeBumpResponse, error) {
+	hash = strings.ToLower(hash)
+	if _, err := hex.DecodeString(hash); err != nil || len(hash) != 64 {
+		return nil, errors.New("invalid transaction hash")
+	}
+	if req.BaseFee < 0 || req.BaseFee > maxFeeBumpBaseFee {
+		return nil, errors.New("invalid base_fee: must be at most 1000000 stroops")
+	}
+
+	inner, err := s.stuckTransaction(hash)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNotFrozen(inner.SourceAccount().AccountID); err != nil {
+		return nil, err
+	}
+	if _, err := s.Config.HorizonClient.TransactionDetail(hash); err == nil {
+		s.unconfirmed.remove(hash)
+		return nil, errors.New("transaction is already confirmed")
+	} else if herr, ok := err.(*horizonclient.Error); !ok || herr.Problem.Status != http.StatusNotFound {
+		return nil, errors.New("failed to check transaction status: " + err.Error())
+	}
+	if bounds := inner.Timebounds(); bounds.MaxTime != 0 && time.Now().Unix() > bounds.MaxTime {
+		return nil, errors.New("transaction has expired; a fee bump cannot revive it, submit a new transaction")
+	}
+
+	baseFee := req.BaseFee
+	if baseFee == 0 {
+		stats, err := s.Config.HorizonClient.FeeStats()
+		if err != nil {
+			return nil, errors.New("failed to fetch fee stats: " + err.Error())
+		}
+		baseFee = stats.MaxFee.P95
+		if baseFee > maxFeeBumpBaseFee {
+			baseFee = maxFeeBumpBaseFee
+		}
+	}
+	if baseFee <= inner.BaseFee() {
+		// A fee bump must offer strictly more than the inner transaction to replace it in the queue
+		baseFee = inner.BaseFee() + 1
+	}
+	if baseFee > maxFeeBumpBaseFee {
+		return nil, errors.New("transaction already offers the maximum fee bump base fee")
+	}
+
+	masterKP, err := keypair.ParseFull(s.Config.MasterSecret)
+	if err != nil {
+		return nil, errors.New("invalid master secret key: " + err.Error())
+	}
+	feeBump, err := s.signedFeeBump(inner, masterKP, baseFee)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.Config.HorizonClient.SubmitFeeBumpTransaction(feeBump)
+	if err != nil {
+		if herr, ok := err.(*horizonclient.Error); ok {
+			return nil, newTransactionError(herr)
+		}
+		return nil, errors.New("failed to submit transaction: " + err.Error())
+	}
+	s.unconfirmed.remove(hash)
+	s.Audit.Record("master", "transaction.fee_bumped", hash, map[string]string{
+		"fee_bump_hash": resp.Hash,
+		"max_fee":       amount.StringFromInt64(feeBump.MaxFee()),
+	})
+
+	return &models.FeeBumpResponse{
+		InnerTransactionHash:   hash,
+		FeeBumpTransactionHash: resp.Hash,
+		FeeAccount:             masterKP.Address(),
+		BaseFee:                baseFee,
+		MaxFee:                 amount.StringFromInt64(feeBump.MaxFee()),
+		Ledger:                 resp.Ledger,
+	}, nil
+}
+
+// signedFeeBump wraps inner in a fee bump paid and signed by feeAccount
+func (s *WalletService) signedFeeBump(inner *txnbuild.Transaction, feeAccount *keypair.Full, baseFee int64) (*txnbuild.FeeBumpTransaction, error) {
+	feeBump, err := txnbuild.NewFeeBumpTransaction(txnbuild.FeeBumpTransactionParams{
+		Inner:      inner,
+		FeeAccount: feeAccount.Address(),
+		BaseFee:    baseFee,
+	})
+	if err != nil {
+		return nil, errors.New("failed to build fee bump transaction: " + err.Error())
+	}
+	feeBump, err = feeBump.Sign(s.networkPassphrase(), feeAccount)
+	if err != nil {
+		return nil, errors.New("failed to sign fee bump transaction: " + err.Error())
+	}
+	return feeBump, nil
+}
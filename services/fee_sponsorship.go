@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// Fee sponsorship policies for the transfers of custodied wallets
+const (
+	// FeeSponsorshipOff leaves every wallet to pay its own fees
+	FeeSponsorshipOff = "off"
+	// FeeSponsorshipAuto has the master account pay when the wallet cannot cover the fee in XLM
+	FeeSponsorshipAuto = "auto"
+	// FeeSponsorshipAlways has the master account pay every fee within the wallet's budget
+	FeeSponsorshipAlways = "always"
+)
+
+// feeCharge is a fee the master account paid, or reserved for a transfer in flight, on a wallet's behalf
+type feeCharge struct {
+	stroops int64
+	at      time.Time
+}
+
+// feeSponsorships tracks the fees sponsored per wallet, against the daily fee budget
+type feeSponsorships struct {
+	mu      sync.Mutex
+	charges map[string][]*feeCharge
+}
+
+// sponsorFee decides whether the master account pays the fee of a transfer of ops operations and, when it
+// does, reserves the fee against the sender's daily budget. It returns the fee account, or nil when the
+// wallet pays.
+func (s *WalletService) sponsorFee(transfer *preparedTransfer, ops int) (*keypair.Full, *feeCharge, error) {
+	policy := s.Config.FeeSponsorshipPolicy
+	if policy != FeeSponsorshipAuto && policy != FeeSponsorshipAlways {
+		return nil, nil, nil
+	}
+	sender := transfer.senderKP.Address()
+	if _, custodied := s.Registry.Get(sender); !custodied {
+		return nil, nil, nil
+	}
+	// The fee bump pays for the inner operations and for itself
+	fee := s.Fees.BaseFee() * int64(ops+1)
+
+	if policy == FeeSponsorshipAuto {
+		account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: sender})
+		if err != nil {
+			return nil, nil, errors.New("failed to fetch sender account details: " + err.Error())
+		}
+		baseReserve, err := s.baseReserveStroops()
+		if err != nil {
+			return nil, nil, err
+		}
+		needed := fee
+		if _, native := transfer.sendAsset.(txnbuild.NativeAsset); native {
+			if sent, err := amount.ParseInt64(transfer.request.Amount); err == nil {
+				needed += sent
+			}
+		}
+		for _, balance := range account.Balances {
+			if balance.Type != "native" {
+				continue
+			}
+			if available, err := amount.ParseInt64(availableBalance(balance, account, baseReserve)); err == nil && available >= needed {
+				return nil, nil, nil
+			}
+		}
+	}
+
+	masterKP, err := keypair.ParseFull(s.Config.MasterSecret)
+	if err != nil {
+		return nil, nil, errors.New("invalid master secret key")
+	}
+	charge, ok := s.reserveFeeCharge(sender, fee)
+	if !ok {
+		if policy == FeeSponsorshipAlways {
+			return nil, nil, nil
+		}
+		return nil, nil, errors.New("fee sponsorship budget exhausted: daily budget of " + s.Config.FeeSponsorshipDailyBudget +
+			" XLM is spent and the wallet holds no XLM for fees")
+	}
+	return masterKP, charge, nil
+}
+
+// reserveFeeCharge records a sponsored fee when it fits within the wallet's budget for the current UTC day
+func (s *WalletService) reserveFeeCharge(wallet string, stroops int64) (*feeCharge, bool) {
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	s.feeSponsorships.mu.Lock()
+	defer s.feeSponsorships.mu.Unlock()
+	// Charges from earlier days no longer count against the budget
+	kept := s.feeSponsorships.charges[wallet][:0]
+	var spent int64
+	for _, charge := range s.feeSponsorships.charges[wallet] {
+		if !charge.at.Before(dayStart) {
+			kept = append(kept, charge)
+			spent += charge.stroops
+		}
+	}
+	s.feeSponsorships.charges[wallet] = kept
+	if s.Config.FeeSponsorshipDailyBudget != "" {
+		budget, err := amount.ParseInt64(s.Config.FeeSponsorshipDailyBudget)
+		if err != nil || spent+stroops > budget {
+			return nil, false
+		}
+	}
+	charge := &feeCharge{stroops: stroops, at: now}
+	s.feeSponsorships.charges[wallet] = append(kept, charge)
+	return charge, true
+}
+
+// settleFeeCharge replaces a reserved fee with the fee actually charged, or removes it when the transaction
+// was rejected before it could be charged
+func (s *WalletService) settleFeeCharge(wallet string, charge *feeCharge, resp submittedTransaction, submitErr error) {
+	if charge == nil {
+		return
+	}
+	s.feeSponsorships.mu.Lock()
+	defer s.feeSponsorships.mu.Unlock()
+	if submitErr == nil {
+		if resp.FeeCharged > 0 {
+			charge.stroops = resp.FeeCharged
+		}
+		return
+	}
+	// Only a transaction rejected before it was applied went uncharged; when the outcome is unknown the
+	// reservation stands
+	var txErr *TransactionError
+	if !errors.As(submitErr, &txErr) || txErr.TransactionCode == "tx_failed" {
+		return
+	}
+	charges := s.feeSponsorships.charges[wallet]
+	for i, c := range charges {
+		if c == charge {
+			s.feeSponsorships.charges[wallet] = append(charges[:i], charges[i+1:]...)
+			break
+		}
+	}
+}
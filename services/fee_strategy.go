@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// fee strategy defaults used when the corresponding Config fields are zero
+const (
+	defaultFeePercentile    = 70
+	defaultMaxBaseFee       = 10000
+	defaultFeeStatsInterval = 30 * time.Second
+)
+
+// feePercentiles maps each percentile Horizon reports in /fee_stats to its fee_charged value
+var feePercentiles = map[int]func(stats hProtocol.FeeStats) int64{
+	10: func(stats hProtocol.FeeStats) int64 { return stats.FeeCharged.P10 },
+	20: func(stats hProtocol.FeeStats) int64 { return stats.FeeCharged.P20 },
+	30: func(stats hProtocol.FeeStats) int64 { return stats.FeeCharged.P30 },
+	40: func(stats hProtocol.FeeStats) int64 { return stats.FeeCharged.P40 },
+	50: func(stats hProtocol.FeeStats) int64 { return stats.FeeCharged.P50 },
+	60: func(stats hProtocol.FeeStats) int64 { return stats.FeeCharged.P60 },
+	70: func(stats hProtocol.FeeStats) int64 { return stats.FeeCharged.P70 },
+	80: func(stats hProtocol.FeeStats) int64 { return stats.FeeCharged.P80 },
+	90: func(stats hProtocol.FeeStats) int64 { return stats.FeeCharged.P90 },
+	95: func(stats hProtocol.FeeStats) int64 { return stats.FeeCharged.P95 },
+	99: func(stats hProtocol.FeeStats) int64 { return stats.FeeCharged.P99 },
+}
+
+// ValidFeePercentile reports whether Horizon's fee stats include the given percentile
+func ValidFeePercentile(percentile int) bool {
+	_, ok := feePercentiles[percentile]
+	return ok
+}
+
+// FeeStrategy picks the base fee of new transactions from Horizon's recent fee stats: the configured
+// percentile of fees charged, never below the last ledger's base fee nor above MaxBaseFee. Until the first
+// poll succeeds it falls back to the network minimum.
+type FeeStrategy struct {
+	Percentile int
+	MaxBaseFee int64
+	Interval   time.Duration
+
+	client *horizonclient.Client
+
+	mu      sync.Mutex
+	baseFee int64
+}
+
+// NewFeeStrategy creates a new FeeStrategy, applying defaults for zero values
+func NewFeeStrategy(client *horizonclient.Client, percentile int, maxBaseFee int64, interval time.Duration) *FeeStrategy {
+	if !ValidFeePercentile(percentile) {
+		percentile = defaultFeePercentile
+	}
+	if maxBaseFee < txnbuild.MinBaseFee {
+		maxBaseFee = defaultMaxBaseFee
+	}
+	if interval <= 0 {
+		interval = defaultFeeStatsInterval
+	}
+	return &FeeStrategy{
+		Percentile: percentile,
+		MaxBaseFee: maxBaseFee,
+		Interval:   interval,
+		client:     client,
+		baseFee:    txnbuild.MinBaseFee,
+	}
+}
+
+// BaseFee returns the per-operation fee, in stroops, to offer on a new transaction
+func (f *FeeStrategy) BaseFee() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.baseFee
+}
+
+// Run refreshes the base fee every Interval until ctx is cancelled
+func (f *FeeStrategy) Run(ctx context.Context) {
+	f.Refresh()
+	ticker := time.NewTicker(f.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			f.Refresh()
+		}
+	}
+}
+
+// Refresh fetches Horizon's fee stats and recomputes the base fee; on failure the previous fee is kept
+func (f *FeeStrategy) Refresh() {
+	stats, err := f.client.FeeStats()
+	if err != nil {
+		log.Printf("fee strategy: failed to fetch fee stats: %v", err)
+		return
+	}
+	fee := max(feePercentiles[f.Percentile](stats), int64(stats.LastLedgerBaseFee), txnbuild.MinBaseFee)
+	fee = min(fee, f.MaxBaseFee)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.baseFee = fee
+}
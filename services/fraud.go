@@ -0,0 +1,250 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/horizonclient"
+)
+
+// recentPaymentsForScoring is how many of the sender's latest payments are passed to the fraud scorer
+const recentPaymentsForScoring = 20
+
+// TransferContext is everything a FraudScorer knows about a transfer before it is signed
+type TransferContext struct {
+	SenderPublicKey       string                 `json:"sender_public_key"`
+	DestinationPublicKey  string                 `json:"destination_public_key"`
+	Amount                string                 `json:"amount"`
+	SourceAsset           string                 `json:"source_asset"`
+	DestinationAsset      string                 `json:"destination_asset"`
+	Device                models.DeviceInfo      `json:"device"`
+	SenderManaged         bool                   `json:"sender_managed"`
+	RecentPayments        []models.PaymentRecord `json:"recent_payments"`
+	DestinationExists     bool                   `json:"destination_exists"`
+	DestinationManaged    bool                   `json:"destination_managed"`
+	DestinationPaidBefore bool                   `json:"destination_paid_before"`
+}
+
+// FraudAssessment is a fraud score in [0, 1] with optional human-readable reasons
+type FraudAssessment struct {
+	Score   float64
+	Reasons []string
+}
+
+// FraudScorer scores transfers before they are signed; implementations are supplied by the operator
+type FraudScorer interface {
+	Score(ctx TransferContext) (FraudAssessment, error)
+}
+
+// HTTPFraudScorer delegates scoring to an external service that accepts the TransferContext as JSON
+// and responds with {"score": 0.42, "reasons": ["..."]}
+type HTTPFraudScorer struct {
+	URL    string
+	Client *http.Client
+}
+
+// Score posts the transfer context to the scoring service
+func (h *HTTPFraudScorer) Score(ctx TransferContext) (FraudAssessment, error) {
+	body, err := json.Marshal(ctx)
+	if err != nil {
+		return FraudAssessment{}, err
+	}
+	client := h.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	resp, err := client.Post(h.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return FraudAssessment{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return FraudAssessment{}, errors.New("fraud scorer returned " + resp.Status)
+	}
+
+	var result struct {
+		Score   float64  `json:"score"`
+		Reasons []string `json:"reasons"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return FraudAssessment{}, errors.New("invalid fraud scorer response: " + err.Error())
+	}
+	return FraudAssessment{Score: result.Score, Reasons: result.Reasons}, nil
+}
+
+type heldTransfer struct {
+	review   models.TransferReviewResponse
+	transfer *preparedTransfer
+}
+
+// transferReviews holds transfers awaiting a manual decision
+type transferReviews struct {
+	mu      sync.Mutex
+	pending map[string]*heldTransfer
+}
+
+// transferContext gathers sender history and destination risk signals for a prepared transfer
+func (s *WalletService) transferContext(transfer *preparedTransfer) TransferContext {
+	return s.scoringContext(TransferContext{
+		SenderPublicKey:      transfer.senderKP.Address(),
+		DestinationPublicKey: transfer.destination,
+		Amount:               transfer.request.Amount,
+		SourceAsset:          assetString(transfer.sendAsset),
+		DestinationAsset:     assetString(transfer.destAsset),
+		Device:               transfer.request.Device,
+	})
+}
+
+// scoringContext completes the context of a payment with the sender's recent payments and the
+// destination's risk signals
+func (s *WalletService) scoringContext(ctx TransferContext) TransferContext {
+	sender := ctx.SenderPublicKey
+	_, ctx.SenderManaged = s.Registry.Get(sender)
+	_, ctx.DestinationManaged = s.Registry.Get(ctx.DestinationPublicKey)
+
+	payments, err := s.Config.HorizonClient.Payments(horizonclient.OperationRequest{
+		ForAccount: sender,
+		Order:      horizonclient.OrderDesc,
+		Limit:      recentPaymentsForScoring,
+	})
+	if err == nil {
+		for _, op := range payments.Embedded.Records {
+			if record, ok := paymentRecord(op, sender); ok {
+				ctx.RecentPayments = append(ctx.RecentPayments, record)
+				if record.Direction == models.DirectionSent && record.To == ctx.DestinationPublicKey {
+					ctx.DestinationPaidBefore = true
+				}
+			}
+		}
+	}
+
+	_, err = s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: ctx.DestinationPublicKey})
+	ctx.DestinationExists = err == nil
+	return ctx
+}
+
+// assessTransfer scores a transfer and reports whether the score exceeds the configured threshold
+func (s *WalletService) assessTransfer(transfer *preparedTransfer) (FraudAssessment, bool) {
+	if s.FraudScorer == nil {
+		return FraudAssessment{}, false
+	}
+	return s.assess(s.transferContext(transfer))
+}
+
+// assess scores a payment and reports whether the score exceeds the configured threshold. Scoring failures
+// count as flagged so that an unavailable scorer never lets payments through unchecked.
+func (s *WalletService) assess(ctx TransferContext) (FraudAssessment, bool) {
+	assessment, err := s.FraudScorer.Score(ctx)
+	if err != nil {
+		assessment = FraudAssessment{Score: 1, Reasons: []string{"fraud scoring failed: " + err.Error()}}
+	}
+	return assessment, assessment.Score > s.Config.FraudScoreThreshold
+}
+
+// screenTransfer scores a transfer and, when the score exceeds the configured threshold, holds it for review.
+func (s *WalletService) screenTransfer(transfer *preparedTransfer) (*models.TransferReviewResponse, error) {
+	assessment, flagged := s.assessTransfer(transfer)
+	if !flagged {
+		return nil, nil
+	}
+
+	held := &heldTransfer{
+		review: models.TransferReviewResponse{
+			ID:               newID(),
+			Status:           models.ReviewPending,
+			Score:            assessment.Score,
+			Reasons:          assessment.Reasons,
+			From:             transfer.senderKP.Address(),
+			To:               transfer.request.ToPublicKey,
+			Amount:           transfer.request.Amount,
+			SourceAsset:      assetString(transfer.sendAsset),
+			DestinationAsset: assetString(transfer.destAsset),
+			CreatedAt:        time.Now().UTC(),
+		},
+		transfer: transfer,
+	}
+	s.reviews.mu.Lock()
+	s.reviews.pending[held.review.ID] = held
+	s.reviews.mu.Unlock()
+
+	review := held.review
+	return &review, nil
+}
+
+// ListTransferReviews returns all transfers that have been held for review
+func (s *WalletService) ListTransferReviews() []models.TransferReviewResponse {
+	s.reviews.mu.Lock()
+	defer s.reviews.mu.Unlock()
+	reviews := []models.TransferReviewResponse{}
+	for _, held := range s.reviews.pending {
+		reviews = append(reviews, held.review)
+	}
+	return reviews
+}
+
+// ApproveTransferReview submits a held transfer without rescoring it
+func (s *WalletService) ApproveTransferReview(id string) (*models.TransferReviewResponse, error) {
+	s.reviews.mu.Lock()
+	held, ok := s.reviews.pending[id]
+	if !ok {
+		s.reviews.mu.Unlock()
+		return nil, errors.New("transfer review not found")
+	}
+	if held.review.Status != models.ReviewPending {
+		s.reviews.mu.Unlock()
+		return nil, errors.New("transfer review already decided")
+	}
+	held.review.Status = models.ReviewApproved
+	held.review.DecidedAt = time.Now().UTC()
+	s.reviews.mu.Unlock()
+
+	response, err := s.executeTransfer(held.transfer)
+	if err == nil && held.transfer.request.ExternalID != "" {
+		response.ExternalID = held.transfer.request.ExternalID
+		s.completeExternalID(held.transfer, response)
+	}
+
+	s.reviews.mu.Lock()
+	defer s.reviews.mu.Unlock()
+	if err != nil {
+		held.review.Status = models.ReviewPending
+		held.review.DecidedAt = time.Time{}
+		return nil, err
+	}
+	held.review.TransactionHash = response.TransactionHash
+	s.transferCallback(held.transfer, models.CallbackTransferCompleted, response, id, nil)
+	held.transfer = nil
+	review := held.review
+	return &review, nil
+}
+
+// RejectTransferReview discards a held transfer. Its external_id is released, as the transfer never ran.
+func (s *WalletService) RejectTransferReview(id string) (*models.TransferReviewResponse, error) {
+	s.reviews.mu.Lock()
+	held, ok := s.reviews.pending[id]
+	if !ok {
+		s.reviews.mu.Unlock()
+		return nil, errors.New("transfer review not found")
+	}
+	if held.review.Status != models.ReviewPending {
+		s.reviews.mu.Unlock()
+		return nil, errors.New("transfer review already decided")
+	}
+	held.review.Status = models.ReviewRejected
+	held.review.DecidedAt = time.Now().UTC()
+	s.transferCallback(held.transfer, models.CallbackTransferRejected, nil, id, nil)
+	transfer := held.transfer
+	held.transfer = nil
+	review := held.review
+	s.reviews.mu.Unlock()
+
+	if transfer.request.ExternalID != "" {
+		s.releaseExternalID(transfer)
+	}
+	return &review, nil
+}
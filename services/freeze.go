@@ -0,0 +1,152 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/keypair"
+)
+
+// walletFreezes caches the freeze state of wallets. A nil record means the wallet has never been frozen.
+type walletFreezes struct {
+	mu      sync.Mutex
+	records map[string]*models.WalletFreezeResponse
+}
+
+func freezeKey(publicKey string) string {
+	return "freezes/" + publicKey + ".json"
+}
+
+// freezeRecord returns a wallet's freeze record, loading it from the archive store the first time so a
+// freeze outlives restarts
+func (s *WalletService) freezeRecord(publicKey string) (*models.WalletFreezeResponse, error) {
+	s.freezes.mu.Lock()
+	record, ok := s.freezes.records[publicKey]
+	s.freezes.mu.Unlock()
+	if ok || s.Archive == nil {
+		return record, nil
+	}
+
+	data, err := s.Archive.Get(freezeKey(publicKey))
+	switch {
+	case errors.Is(err, errArchiveNotFound):
+		record = nil
+	case err != nil:
+		return nil, errors.New("failed to read wallet freeze state: " + err.Error())
+	default:
+		record = &models.WalletFreezeResponse{}
+		if err := json.Unmarshal(data, record); err != nil {
+			return nil, errors.New("failed to decode wallet freeze state: " + err.Error())
+		}
+	}
+
+	s.freezes.mu.Lock()
+	defer s.freezes.mu.Unlock()
+	if cached, ok := s.freezes.records[publicKey]; ok {
+		return cached, nil
+	}
+	s.freezes.records[publicKey] = record
+	return record, nil
+}
+
+// checkNotFrozen refuses a wallet that is frozen, or whose freeze state cannot be read
+func (s *WalletService) checkNotFrozen(publicKey string) error {
+	record, err := s.freezeRecord(publicKey)
+	if err != nil {
+		return err
+	}
+	if record != nil && record.Frozen {
+		return errors.New("wallet is frozen: " + publicKey)
+	}
+	return nil
+}
+
+// saveFreeze persists a freeze record before it takes effect
+func (s *WalletService) saveFreeze(record *models.WalletFreezeResponse) error {
+	if s.Archive != nil {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return errors.New("failed to encode wallet freeze state: " + err.Error())
+		}
+		if err := s.Archive.Put(freezeKey(record.PublicKey), data); err != nil {
+			return errors.New("failed to persist wallet freeze state: " + err.Error())
+		}
+	}
+	s.freezes.mu.Lock()
+	s.freezes.records[record.PublicKey] = record
+	s.freezes.mu.Unlock()
+	return nil
+}
+
+// FreezeWallet stops the service from signing anything for a wallet until it is unfrozen. Unlike
+// deactivation no balances move; transfers from the wallet are refused before any signing occurs.
+func (s *WalletService) FreezeWallet(publicKey string, req models.FreezeWalletRequest) (*models.WalletFreezeResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	if masterKP, err := keypair.ParseFull(s.Config.MasterSecret); err == nil && masterKP.Address() == publicKey {
+		return nil, errors.New("invalid public key: the master account cannot be frozen")
+	}
+	current, err := s.freezeRecord(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && current.Frozen {
+		return nil, errors.New("wallet is already frozen")
+	}
+
+	now := time.Now().UTC()
+	record := &models.WalletFreezeResponse{PublicKey: publicKey, Frozen: true, Reason: req.Reason, FrozenAt: &now}
+	if err := s.saveFreeze(record); err != nil {
+		return nil, err
+	}
+	s.Registry.RecordUpdate(publicKey, "frozen")
+	s.Audit.Record("admin", "wallet.frozen", publicKey, map[string]string{"reason": req.Reason})
+	result := *record
+	return &result, nil
+}
+
+// UnfreezeWallet lifts a wallet's freeze
+func (s *WalletService) UnfreezeWallet(publicKey string) (*models.WalletFreezeResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	current, err := s.freezeRecord(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil || !current.Frozen {
+		return nil, errors.New("wallet is not frozen")
+	}
+
+	now := time.Now().UTC()
+	record := *current
+	record.Frozen = false
+	record.UnfrozenAt = &now
+	if err := s.saveFreeze(&record); err != nil {
+		return nil, err
+	}
+	s.Registry.RecordUpdate(publicKey, "unfrozen")
+	s.Audit.Record("admin", "wallet.unfrozen", publicKey, map[string]string{"reason": current.Reason})
+	result := record
+	return &result, nil
+}
+
+// GetWalletFreeze returns a wallet's freeze state
+func (s *WalletService) GetWalletFreeze(publicKey string) (*models.WalletFreezeResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	record, err := s.freezeRecord(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return &models.WalletFreezeResponse{PublicKey: publicKey}, nil
+	}
+	result := *record
+	return &result, nil
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+)
+
+// FundWallet creates an account on testnet funded by friendbot, at no cost to the master account.
+// Friendbot only creates accounts, so an account that already exists is refused.
+func (s *WalletService) FundWallet(publicKey string) (*models.FundWalletResponse, error) {
+	if s.Config.Network != "testnet" {
+		return nil, errors.New("friendbot funding is only available on testnet")
+	}
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	_, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: publicKey})
+	if err == nil {
+		return nil, errors.New("wallet already exists; friendbot only funds new accounts")
+	}
+	if herr, ok := err.(*horizonclient.Error); !ok || herr.Response.StatusCode != http.StatusNotFound {
+		return nil, errors.New("failed to fetch wallet account details: " + err.Error())
+	}
+
+	resp, err := s.Config.HorizonClient.Fund(publicKey)
+	if err != nil {
+		return nil, errors.New("failed to fund wallet via friendbot: " + err.Error())
+	}
+	s.Audit.Record("wallet:"+publicKey, "wallet.friendbot_funded", publicKey, map[string]string{"transaction_hash": resp.Hash})
+	return &models.FundWalletResponse{
+		PublicKey:       publicKey,
+		TransactionHash: resp.Hash,
+		Message:         "Wallet created and funded by friendbot",
+	}, nil
+}
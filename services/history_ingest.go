@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/protocols/horizon/operations"
+)
+
+const (
+	// historyIngestPageLimit is the size of each Horizon page the history ingester reads
+	historyIngestPageLimit = 200
+	// maxHistoryIngestPages caps the network pages read per run, so a long catch-up yields between runs
+	maxHistoryIngestPages = 50
+	// maxHistoryBackfillPages caps the pages of one wallet's past operations backfilled per run
+	maxHistoryBackfillPages = 10
+)
+
+// HistoryIngester follows the network's operations as ledgers close and copies those touching managed wallets
+// into a HistoryStore. Wallets are first backfilled from their Horizon history; once a wallet's backfill is
+// done its payment history and operations are served from the store.
+type HistoryIngester struct {
+	Wallets *WalletService
+	Store   *HistoryStore
+}
+
+// NewHistoryIngester creates a new HistoryIngester instance
+func NewHistoryIngester(wallets *WalletService, store *HistoryStore) *HistoryIngester {
+	return &HistoryIngester{Wallets: wallets, Store: store}
+}
+
+// Run ingests every interval until ctx is cancelled
+func (i *HistoryIngester) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			i.Ingest()
+		}
+	}
+}
+
+// Ingest follows the network and then backfills managed wallets not yet in the store. The network cursor is
+// started first so no operation falls between a wallet's backfill and the network follower.
+func (i *HistoryIngester) Ingest() {
+	managed := make(map[string]bool)
+	for _, publicKey := range i.Wallets.Registry.PublicKeys() {
+		managed[publicKey] = true
+	}
+	if err := i.followNetwork(managed); err != nil {
+		log.Printf("history ingester: %v", err)
+		return
+	}
+	for publicKey := range managed {
+		if err := i.backfill(publicKey); err != nil {
+			log.Printf("history ingester: backfill %s: %v", publicKey, err)
+		}
+	}
+}
+
+// followNetwork stores the network operations since the last run that touch a managed wallet
+func (i *HistoryIngester) followNetwork(managed map[string]bool) error {
+	client := i.Wallets.Config.HorizonClient
+	cursor, err := i.Store.networkCursor()
+	if err != nil {
+		return err
+	}
+	if cursor == "" {
+		// History from before the first run is left to wallet backfills
+		latest, err := client.Operations(horizonclient.OperationRequest{Order: horizonclient.OrderDesc, Limit: 1})
+		if err != nil {
+			return errors.New("failed to fetch operations: " + err.Error())
+		}
+		if len(latest.Embedded.Records) == 0 {
+			return nil
+		}
+		return i.Store.recordNetwork(nil, latest.Embedded.Records[0].PagingToken())
+	}
+
+	for page := 0; page < maxHistoryIngestPages; page++ {
+		ops, err := client.Operations(horizonclient.OperationRequest{
+			Cursor:        cursor,
+			Order:         horizonclient.OrderAsc,
+			Limit:         historyIngestPageLimit,
+			IncludeFailed: true,
+			Join:          "transactions",
+		})
+		if err != nil {
+			return errors.New("failed to fetch operations: " + err.Error())
+		}
+		if len(ops.Embedded.Records) == 0 {
+			return nil
+		}
+		var stored []historyOperation
+		for _, op := range ops.Embedded.Records {
+			cursor = op.PagingToken()
+			for _, publicKey := range operationAccounts(op) {
+				if managed[publicKey] {
+					stored = append(stored, newHistoryOperation(op, publicKey))
+				}
+			}
+		}
+		if err := i.Store.recordNetwork(stored, cursor); err != nil {
+			return err
+		}
+		if len(ops.Embedded.Records) < historyIngestPageLimit {
+			return nil
+		}
+	}
+	return nil
+}
+
+// backfill stores a page run of a wallet's past operations, oldest first, until its history is exhausted
+func (i *HistoryIngester) backfill(publicKey string) error {
+	cursor, done, err := i.Store.walletBackfill(publicKey)
+	if err != nil || done {
+		return err
+	}
+	for page := 0; page < maxHistoryBackfillPages; page++ {
+		ops, err := i.Wallets.Config.HorizonClient.Operations(horizonclient.OperationRequest{
+			ForAccount:    publicKey,
+			Cursor:        cursor,
+			Order:         horizonclient.OrderAsc,
+			Limit:         historyIngestPageLimit,
+			IncludeFailed: true,
+			Join:          "transactions",
+		})
+		if herr, ok := err.(*horizonclient.Error); ok && herr.Response.StatusCode == http.StatusNotFound {
+			// The account is not funded yet; everything it does from now on is followed from the network
+			return i.Store.recordBackfill(publicKey, nil, cursor, true)
+		}
+		if err != nil {
+			return errors.New("failed to fetch operations: " + err.Error())
+		}
+		stored := make([]historyOperation, 0, len(ops.Embedded.Records))
+		for _, op := range ops.Embedded.Records {
+			cursor = op.PagingToken()
+			stored = append(stored, newHistoryOperation(op, publicKey))
+		}
+		done := len(ops.Embedded.Records) < historyIngestPageLimit
+		if err := i.Store.recordBackfill(publicKey, stored, cursor, done); err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+	return nil
+}
+
+// operationAccounts returns the G... accounts an operation names: its source and every account-valued field
+// of its details, such as from, to, funder, trustor or sponsor. Asset issuers are left out; an operation
+// merely moving an issuer's asset does not touch the issuer's account.
+func operationAccounts(op operations.Operation) []string {
+	accounts := []string{op.GetBase().SourceAccount}
+	for field, value := range activityDetails(op, operationBaseFields) {
+		address, ok := value.(string)
+		if !ok || strings.HasSuffix(field, "issuer") || slices.Contains(accounts, address) {
+			continue
+		}
+		if _, err := keypair.ParseAddress(address); err == nil {
+			accounts = append(accounts, address)
+		}
+	}
+	return accounts
+}
+
+// newHistoryOperation prepares an operation to be stored for one wallet
+func newHistoryOperation(op operations.Operation, publicKey string) historyOperation {
+	stored := historyOperation{wallet: publicKey, operation: operationRecord(op)}
+	if record, ok := paymentRecord(op, publicKey); ok {
+		stored.payment = &record
+	}
+	if tx := op.GetBase().Transaction; tx != nil && tx.MemoType != "none" {
+		stored.memoType, stored.memo = tx.MemoType, tx.Memo
+	}
+	return stored
+}
+
+// historyReady reports whether a wallet's history can be served from the local store
+func (s *WalletService) historyReady(publicKey string) bool {
+	if s.History == nil {
+		return false
+	}
+	_, done, err := s.History.walletBackfill(publicKey)
+	if err != nil {
+		log.Printf("history store: %v; falling back to Horizon", err)
+		return false
+	}
+	return done
+}
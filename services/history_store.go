@@ -0,0 +1,310 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+)
+
+// historySchema creates the local history tables. history_operations holds one row per operation and managed
+// wallet it touched, so an operation between two managed wallets is stored for both.
+const historySchema = `
+CREATE TABLE IF NOT EXISTS history_cursors (
+	name   TEXT PRIMARY KEY,
+	cursor TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS history_wallets (
+	wallet          TEXT PRIMARY KEY,
+	backfill_cursor TEXT NOT NULL DEFAULT '',
+	backfilled      BOOLEAN NOT NULL DEFAULT FALSE
+);
+CREATE TABLE IF NOT EXISTS history_operations (
+	wallet           TEXT NOT NULL,
+	id               BIGINT NOT NULL,
+	type             TEXT NOT NULL,
+	successful       BOOLEAN NOT NULL,
+	created_at       TIMESTAMPTZ NOT NULL,
+	transaction_hash TEXT NOT NULL,
+	memo_type        TEXT NOT NULL DEFAULT '',
+	memo             TEXT NOT NULL DEFAULT '',
+	asset            TEXT,
+	amount           BIGINT,
+	direction        TEXT,
+	counterparty     TEXT,
+	operation        JSONB NOT NULL,
+	payment          JSONB,
+	PRIMARY KEY (wallet, id)
+);
+CREATE INDEX IF NOT EXISTS history_operations_type ON history_operations (wallet, type, id DESC);
+CREATE INDEX IF NOT EXISTS history_operations_payments ON history_operations (wallet, id DESC) WHERE payment IS NOT NULL;
+CREATE INDEX IF NOT EXISTS history_operations_memo ON history_operations (memo) WHERE memo <> '';
+`
+
+// networkHistoryCursor names the paging token of the last network operation ingested
+const networkHistoryCursor = "network"
+
+// HistoryStore is a Postgres copy of the operations touching managed wallets, filled by the HistoryIngester
+// and used to serve history without querying Horizon
+type HistoryStore struct {
+	db *sql.DB
+}
+
+// historyOperation is one operation as stored for one wallet
+type historyOperation struct {
+	wallet    string
+	operation models.OperationRecord
+	// payment is set for operations that move funds
+	payment  *models.PaymentRecord
+	memoType string
+	memo     string
+}
+
+// NewHistoryStore connects to Postgres and creates the history tables if needed
+func NewHistoryStore(databaseURL string) (*HistoryStore, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, errors.New("failed to open history database: " + err.Error())
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, errors.New("failed to connect to history database: " + err.Error())
+	}
+	if _, err := db.Exec(historySchema); err != nil {
+		db.Close()
+		return nil, errors.New("failed to migrate history database: " + err.Error())
+	}
+	return &HistoryStore{db: db}, nil
+}
+
+// networkCursor returns the paging token network ingestion resumes after; it is empty before the first run
+func (h *HistoryStore) networkCursor() (string, error) {
+	var cursor string
+	err := h.db.QueryRow(`SELECT cursor FROM history_cursors WHERE name = $1`, networkHistoryCursor).Scan(&cursor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.New("failed to read history cursor: " + err.Error())
+	}
+	return cursor, nil
+}
+
+// walletBackfill returns where a wallet's backfill resumes and whether it is done
+func (h *HistoryStore) walletBackfill(wallet string) (string, bool, error) {
+	var cursor string
+	var done bool
+	err := h.db.QueryRow(`SELECT backfill_cursor, backfilled FROM history_wallets WHERE wallet = $1`, wallet).Scan(&cursor, &done)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, errors.New("failed to read history backfill: " + err.Error())
+	}
+	return cursor, done, nil
+}
+
+// recordNetwork stores a page of network operations and advances the network cursor in one transaction, so
+// a crash replays the page rather than skipping it
+func (h *HistoryStore) recordNetwork(operations []historyOperation, cursor string) error {
+	return h.record(operations, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO history_cursors (name, cursor) VALUES ($1, $2)
+			ON CONFLICT (name) DO UPDATE SET cursor = EXCLUDED.cursor`, networkHistoryCursor, cursor)
+		return err
+	})
+}
+
+// recordBackfill stores a page of a wallet's past operations and its backfill progress in one transaction
+func (h *HistoryStore) recordBackfill(wallet string, operations []historyOperation, cursor string, done bool) error {
+	return h.record(operations, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO history_wallets (wallet, backfill_cursor, backfilled) VALUES ($1, $2, $3)
+			ON CONFLICT (wallet) DO UPDATE SET backfill_cursor = EXCLUDED.backfill_cursor, backfilled = EXCLUDED.backfilled`,
+			wallet, cursor, done)
+		return err
+	})
+}
+
+// record inserts operations, skipping ones already stored, and runs progress in the same transaction
+func (h *HistoryStore) record(operations []historyOperation, progress func(*sql.Tx) error) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return errors.New("failed to store history: " + err.Error())
+	}
+	defer tx.Rollback()
+
+	for _, op := range operations {
+		id, err := strconv.ParseInt(op.operation.ID, 10, 64)
+		if err != nil {
+			return errors.New("failed to store history: invalid operation ID " + op.operation.ID)
+		}
+		operation, err := json.Marshal(op.operation)
+		if err != nil {
+			return errors.New("failed to encode operation: " + err.Error())
+		}
+		// JSON is passed as text: lib/pq would send []byte as bytea
+		var payment, asset, direction, counterparty sql.NullString
+		var stroops sql.NullInt64
+		if op.payment != nil {
+			raw, err := json.Marshal(op.payment)
+			if err != nil {
+				return errors.New("failed to encode payment: " + err.Error())
+			}
+			payment = sql.NullString{String: string(raw), Valid: true}
+			asset = sql.NullString{String: op.payment.Asset, Valid: true}
+			direction = sql.NullString{String: op.payment.Direction, Valid: true}
+			counterparty = sql.NullString{String: op.payment.Counterparty, Valid: true}
+			if value, err := amount.ParseInt64(op.payment.Amount); err == nil {
+				stroops = sql.NullInt64{Int64: value, Valid: true}
+			}
+		}
+		_, err = tx.Exec(`INSERT INTO history_operations
+			(wallet, id, type, successful, created_at, transaction_hash, memo_type, memo, asset, amount, direction, counterparty, operation, payment)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			ON CONFLICT (wallet, id) DO NOTHING`,
+			op.wallet, id, op.operation.Type, op.operation.Successful, op.operation.CreatedAt, op.operation.TransactionHash,
+			op.memoType, op.memo, asset, stroops, direction, counterparty, string(operation), payment)
+		if err != nil {
+			return errors.New("failed to store history: " + err.Error())
+		}
+	}
+	if err := progress(tx); err != nil {
+		return errors.New("failed to store history progress: " + err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.New("failed to store history: " + err.Error())
+	}
+	return nil
+}
+
+// historyQuery builds the WHERE clause of a wallet's history query
+type historyQuery struct {
+	conditions []string
+	args       []interface{}
+}
+
+func (q *historyQuery) where(condition string, arg interface{}) {
+	q.args = append(q.args, arg)
+	q.conditions = append(q.conditions, strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(q.args))))
+}
+
+// pageAfter restricts a descending query to rows before a paging token
+func (q *historyQuery) pageAfter(cursor string) error {
+	if cursor == "" {
+		return nil
+	}
+	id, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil {
+		return errors.New("invalid cursor")
+	}
+	q.where("id < ?", id)
+	return nil
+}
+
+// payments returns a page of a wallet's stored payments, newest first, matching a validated filter
+func (h *HistoryStore) payments(wallet string, filter paymentHistoryFilter, cursor string, limit int) (*models.PaymentHistoryResponse, error) {
+	query := &historyQuery{}
+	query.where("wallet = ?", wallet)
+	query.conditions = append(query.conditions, "payment IS NOT NULL", "successful")
+	if err := query.pageAfter(cursor); err != nil {
+		return nil, err
+	}
+	if filter.asset != "" {
+		query.where("asset = ?", filter.asset)
+	}
+	if filter.direction != "" {
+		query.where("direction = ?", filter.direction)
+	}
+	if filter.counterparty != "" {
+		query.where("counterparty = ?", filter.counterparty)
+	}
+	if !filter.from.IsZero() {
+		query.where("created_at >= ?", filter.from)
+	}
+	if !filter.to.IsZero() {
+		query.where("created_at <= ?", filter.to)
+	}
+	if filter.minAmount > 0 {
+		query.where("amount >= ?", filter.minAmount)
+	}
+	if filter.maxAmount > 0 {
+		query.where("amount <= ?", filter.maxAmount)
+	}
+	query.args = append(query.args, limit)
+
+	rows, err := h.db.Query(`SELECT payment FROM history_operations WHERE `+strings.Join(query.conditions, " AND ")+
+		` ORDER BY id DESC LIMIT $`+strconv.Itoa(len(query.args)), query.args...)
+	if err != nil {
+		return nil, errors.New("failed to query payment history: " + err.Error())
+	}
+	defer rows.Close()
+
+	response := &models.PaymentHistoryResponse{Payments: []models.PaymentRecord{}}
+	for rows.Next() {
+		var raw []byte
+		var record models.PaymentRecord
+		if err := rows.Scan(&raw); err != nil {
+			return nil, errors.New("failed to read payment history: " + err.Error())
+		}
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, errors.New("failed to decode payment history: " + err.Error())
+		}
+		response.Payments = append(response.Payments, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("failed to read payment history: " + err.Error())
+	}
+	if len(response.Payments) == limit {
+		response.NextCursor = response.Payments[limit-1].ID
+	}
+	return response, nil
+}
+
+// operations returns a page of a wallet's stored operations, newest first, optionally only of the given types
+func (h *HistoryStore) operations(wallet string, types []string, cursor string, limit int) (*models.OperationsResponse, error) {
+	query := &historyQuery{}
+	query.where("wallet = ?", wallet)
+	if err := query.pageAfter(cursor); err != nil {
+		return nil, err
+	}
+	if len(types) > 0 {
+		query.where("type = ANY(?)", pq.Array(types))
+	}
+	query.args = append(query.args, limit)
+
+	rows, err := h.db.Query(`SELECT operation FROM history_operations WHERE `+strings.Join(query.conditions, " AND ")+
+		` ORDER BY id DESC LIMIT $`+strconv.Itoa(len(query.args)), query.args...)
+	if err != nil {
+		return nil, errors.New("failed to query operations: " + err.Error())
+	}
+	defer rows.Close()
+
+	response := &models.OperationsResponse{Operations: []models.OperationRecord{}}
+	for rows.Next() {
+		var raw []byte
+		var record models.OperationRecord
+		if err := rows.Scan(&raw); err != nil {
+			return nil, errors.New("failed to read operations: " + err.Error())
+		}
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, errors.New("failed to decode operations: " + err.Error())
+		}
+		response.Operations = append(response.Operations, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("failed to read operations: " + err.Error())
+	}
+	if len(response.Operations) == limit {
+		response.NextCursor = response.Operations[limit-1].ID
+	}
+	return response, nil
+}
+
+// Close closes the database connection
+func (h *HistoryStore) Close() error {
+	return h.db.Close()
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// horizonOutageThreshold is the number of consecutive failed health checks that makes an outage
+const horizonOutageThreshold = 3
+
+// HorizonHealthMonitor checks that Horizon answers every Interval and alerts when it has failed
+// horizonOutageThreshold checks in a row, and again when it answers after an outage
+type HorizonHealthMonitor struct {
+	Wallets  *WalletService
+	Interval time.Duration
+	Alerter  Alerter
+
+	mu       sync.Mutex
+	failures int
+	since    time.Time
+	down     bool
+}
+
+// NewHorizonHealthMonitor creates a new HorizonHealthMonitor instance
+func NewHorizonHealthMonitor(wallets *WalletService, interval time.Duration, alerter Alerter) *HorizonHealthMonitor {
+	return &HorizonHealthMonitor{Wallets: wallets, Interval: interval, Alerter: alerter}
+}
+
+// Run checks Horizon every Interval until ctx is cancelled
+func (m *HorizonHealthMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check()
+		}
+	}
+}
+
+// Check fetches Horizon's root once and records whether it answered
+func (m *HorizonHealthMonitor) Check() {
+	_, err := m.Wallets.Config.HorizonClient.Root()
+
+	m.mu.Lock()
+	var title, message string
+	switch {
+	case err == nil && m.down:
+		title = "Horizon recovered"
+		message = "Horizon is answering again after an outage of " + time.Since(m.since).Round(time.Second).String()
+		m.down = false
+	case err != nil:
+		if m.failures == 0 {
+			m.since = time.Now()
+		}
+		m.failures++
+		log.Printf("horizon health: check %d failed: %v", m.failures, err)
+		if !m.down && m.failures >= horizonOutageThreshold {
+			m.down = true
+			title = "Horizon unreachable"
+			message = strconv.Itoa(m.failures) + " consecutive Horizon health checks failed since " +
+				m.since.UTC().Format(time.RFC3339) + "; transfers and wallet creation will fail. Last error: " + err.Error()
+		}
+	}
+	if err == nil {
+		m.failures = 0
+	}
+	m.mu.Unlock()
+
+	if title != "" {
+		m.Alerter.Alert(title, message)
+	}
+}
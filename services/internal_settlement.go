@@ -0,0 +1,596 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// maxOperationsPerTransaction is the protocol limit on operations in one transaction
+const maxOperationsPerTransaction = 100
+
+const (
+	internalPositionsKey = "settlement/internal-positions.json"
+	internalInFlightKey  = "settlement/internal-in-flight.json"
+	// settlementExpiryMargin is how long past its time bounds a settlement missing from Horizon is still
+	// looked for, in case Horizon has not ingested its ledger yet
+	settlementExpiryMargin = time.Minute
+)
+
+// InternalLedger tracks transfers settled off-chain between managed wallets of the same tenant as net
+// per-wallet, per-asset positions awaiting on-chain settlement. The positions are kept in the archive
+// store so that transfers booked before a restart are still settled after it.
+type InternalLedger struct {
+	mu     sync.Mutex
+	loaded bool
+	// positions maps tenant -> wallet -> asset -> net stroops owed to (positive) or by (negative) the wallet
+	positions map[string]map[string]map[string]int64
+	// inFlight holds the settlement batches submitted without a definite outcome, by transaction hash
+	inFlight map[string]inFlightSettlement
+}
+
+// inFlightSettlement is a settlement batch whose submission may or may not have been applied. Its legs
+// stay in the positions until the transaction is found on the ledger or can no longer be applied.
+type inFlightSettlement struct {
+	TenantID string `json:"tenant_id"`
+	// MaxTime is the end of the transaction's time bounds, as a Unix time
+	MaxTime int64           `json:"max_time"`
+	Legs    []settlementLeg `json:"legs"`
+}
+
+// NewInternalLedger creates a new InternalLedger instance
+func NewInternalLedger() *InternalLedger {
+	return &InternalLedger{
+		positions: make(map[string]map[string]map[string]int64),
+		inFlight:  make(map[string]inFlightSettlement),
+	}
+}
+
+// positionChange moves a wallet's net position in an asset by delta stroops
+type positionChange struct {
+	tenantID, wallet, asset string
+	delta                   int64
+}
+
+// loadInternalPositionsLocked reads the unsettled positions and in-flight settlements from the archive
+// store the first time they are needed; s.Internal.mu must be held
+func (s *WalletService) loadInternalPositionsLocked() error {
+	if s.Internal.loaded {
+		return nil
+	}
+	positions := make(map[string]map[string]map[string]int64)
+	inFlight := make(map[string]inFlightSettlement)
+	if s.Archive != nil {
+		data, err := s.Archive.Get(internalPositionsKey)
+		switch {
+		case errors.Is(err, errArchiveNotFound):
+		case err != nil:
+			return errors.New("failed to read internal positions: " + err.Error())
+		default:
+			if err := json.Unmarshal(data, &positions); err != nil {
+				return errors.New("failed to decode internal positions: " + err.Error())
+			}
+		}
+		data, err = s.Archive.Get(internalInFlightKey)
+		switch {
+		case errors.Is(err, errArchiveNotFound):
+		case err != nil:
+			return errors.New("failed to read in-flight settlements: " + err.Error())
+		default:
+			if err := json.Unmarshal(data, &inFlight); err != nil {
+				return errors.New("failed to decode in-flight settlements: " + err.Error())
+			}
+		}
+	}
+	s.Internal.positions = positions
+	s.Internal.inFlight = inFlight
+	s.Internal.loaded = true
+	return nil
+}
+
+// persistInternalPositions writes positions to the archive store
+func (s *WalletService) persistInternalPositions(positions map[string]map[string]map[string]int64) error {
+	if s.Archive == nil {
+		return nil
+	}
+	data, err := json.Marshal(positions)
+	if err != nil {
+		return errors.New("failed to encode internal positions: " + err.Error())
+	}
+	if err := s.Archive.Put(internalPositionsKey, data); err != nil {
+		return errors.New("failed to persist internal positions: " + err.Error())
+	}
+	return nil
+}
+
+// persistInFlightSettlements writes the in-flight settlements to the archive store
+func (s *WalletService) persistInFlightSettlements(inFlight map[string]inFlightSettlement) error {
+	if s.Archive == nil {
+		return nil
+	}
+	data, err := json.Marshal(inFlight)
+	if err != nil {
+		return errors.New("failed to encode in-flight settlements: " + err.Error())
+	}
+	if err := s.Archive.Put(internalInFlightKey, data); err != nil {
+		return errors.New("failed to persist in-flight settlements: " + err.Error())
+	}
+	return nil
+}
+
+// withPositionChanges returns a copy of positions with changes applied, dropping positions that net to zero
+func withPositionChanges(positions map[string]map[string]map[string]int64, changes ...positionChange) map[string]map[string]map[string]int64 {
+	updated := make(map[string]map[string]map[string]int64, len(positions))
+	for tenantID, wallets := range positions {
+		updated[tenantID] = make(map[string]map[string]int64, len(wallets))
+		for wallet, assets := range wallets {
+			updated[tenantID][wallet] = make(map[string]int64, len(assets))
+			for asset, position := range assets {
+				updated[tenantID][wallet][asset] = position
+			}
+		}
+	}
+	for _, change := range changes {
+		if updated[change.tenantID] == nil {
+			updated[change.tenantID] = make(map[string]map[string]int64)
+		}
+		if updated[change.tenantID][change.wallet] == nil {
+			updated[change.tenantID][change.wallet] = make(map[string]int64)
+		}
+		assets := updated[change.tenantID][change.wallet]
+		assets[change.asset] += change.delta
+		if assets[change.asset] == 0 {
+			delete(assets, change.asset)
+		}
+		if len(assets) == 0 {
+			delete(updated[change.tenantID], change.wallet)
+		}
+		if len(updated[change.tenantID]) == 0 {
+			delete(updated, change.tenantID)
+		}
+	}
+	return updated
+}
+
+// internalPositions returns a copy of every tenant's unsettled positions
+func (s *WalletService) internalPositions() (map[string]map[string]map[string]int64, error) {
+	s.Internal.mu.Lock()
+	defer s.Internal.mu.Unlock()
+	if err := s.loadInternalPositionsLocked(); err != nil {
+		return nil, err
+	}
+	return withPositionChanges(s.Internal.positions), nil
+}
+
+// internalPosition returns a wallet's unsettled net position in an asset
+func (s *WalletService) internalPosition(tenantID, publicKey, asset string) (int64, error) {
+	s.Internal.mu.Lock()
+	defer s.Internal.mu.Unlock()
+	if err := s.loadInternalPositionsLocked(); err != nil {
+		return 0, err
+	}
+	return s.Internal.positions[tenantID][publicKey][asset], nil
+}
+
+// availableStroops returns an account's spendable balance of an asset, above its reserve and selling
+// liabilities, or zero without a balance of it
+func availableStroops(account hProtocol.Account, asset string, baseReserve int64) int64 {
+	for _, balance := range account.Balances {
+		if (balance.Type == "native" && asset == "native") || balance.Code+":"+balance.Issuer == asset {
+			available, _ := amount.ParseInt64(availableBalance(balance, account, baseReserve))
+			return available
+		}
+	}
+	return 0
+}
+
+// checkPendingOutflows refuses payments that would spend what their senders already owe through internal
+// transfers awaiting settlement: those outflows leave on-chain with the next settlement, so they are not
+// available to pay anything else
+func (s *WalletService) checkPendingOutflows(debits []paymentDebit) error {
+	type senderAsset struct{ sender, asset string }
+	totals := make(map[senderAsset]int64)
+	var order []senderAsset
+	for _, debit := range debits {
+		key := senderAsset{debit.sender, debit.asset}
+		if _, ok := totals[key]; !ok {
+			order = append(order, key)
+		}
+		totals[key] += debit.stroops
+	}
+
+	var baseReserve int64
+	for _, key := range order {
+		tenantID, ok := s.Registry.TenantOf(key.sender)
+		if !ok {
+			continue
+		}
+		position, err := s.internalPosition(tenantID, key.sender, key.asset)
+		if err != nil {
+			return err
+		}
+		if position >= 0 {
+			continue
+		}
+		account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: key.sender})
+		if err != nil {
+			return errors.New("failed to fetch sender account details: " + err.Error())
+		}
+		if baseReserve == 0 {
+			if baseReserve, err = s.baseReserveStroops(); err != nil {
+				return err
+			}
+		}
+		if available := availableStroops(account, key.asset, baseReserve) + position; available < totals[key] {
+			return errors.New("insufficient balance: " + amount.StringFromInt64(max(available, 0)) + " " + key.asset +
+				" available after pending internal transfers")
+		}
+	}
+	return nil
+}
+
+// settlesInternally reports whether a transfer is between two managed wallets of a tenant that settles
+// internally, in a single asset
+func (s *WalletService) settlesInternally(transfer *preparedTransfer) (string, bool) {
+	if assetString(transfer.sendAsset) != assetString(transfer.destAsset) || len(transfer.sources) > 0 {
+		return "", false
+	}
+	senderTenant, ok := s.Registry.TenantOf(transfer.senderKP.Address())
+	if !ok {
+		return "", false
+	}
+	recipientTenant, ok := s.Registry.TenantOf(transfer.destination)
+	if !ok || recipientTenant != senderTenant {
+		return "", false
+	}
+	for _, tenant := range s.Config.InternalSettlementTenants {
+		if tenant == senderTenant {
+			return senderTenant, true
+		}
+	}
+	return "", false
+}
+
+// transferInternally books a transfer on the internal ledger without submitting a transaction
+func (s *WalletService) transferInternally(tenantID string, transfer *preparedTransfer) (*models.TransferResponse, error) {
+	sender := transfer.senderKP.Address()
+	asset := assetString(transfer.sendAsset)
+	stroops, err := amount.ParseInt64(transfer.request.Amount)
+	if err != nil {
+		return nil, errors.New("invalid amount: must be a positive number")
+	}
+
+	sourceAccount, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: sender})
+	if err != nil {
+		return nil, errors.New("failed to fetch sender account details: " + err.Error())
+	}
+	recipientAccount, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: transfer.destination})
+	if err != nil {
+		return nil, errors.New("failed to fetch recipient account details: " + err.Error())
+	}
+	if asset != "native" && !hasTrustline(recipientAccount, asset) {
+		return nil, errors.New("recipient has no trustline for " + asset)
+	}
+	baseReserve, err := s.baseReserveStroops()
+	if err != nil {
+		return nil, err
+	}
+
+	onChain := availableStroops(sourceAccount, asset, baseReserve)
+
+	// The transfer is booked, not submitted, so it counts against the spend limits once booked. Pending
+	// outflows are checked below under the ledger lock rather than by reservePayments.
+	debits, err := paymentDebits(sender, []txnbuild.Operation{
+		&txnbuild.Payment{Destination: transfer.destination, Amount: transfer.request.Amount, Asset: transfer.sendAsset},
+	})
+	if err != nil {
+		return nil, err
+	}
+	reservation, err := s.reserveDebits(debits)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pending inflows are not on-chain until the next settlement, so only pending outflows count against the
+	// sender's balance
+	s.Internal.mu.Lock()
+	defer s.Internal.mu.Unlock()
+	if err := s.loadInternalPositionsLocked(); err != nil {
+		s.releasePayments(reservation)
+		return nil, err
+	}
+	if onChain+min(s.Internal.positions[tenantID][sender][asset], 0) < stroops {
+		s.releasePayments(reservation)
+		return nil, errors.New("insufficient balance for internal transfer")
+	}
+	positions := withPositionChanges(s.Internal.positions,
+		positionChange{tenantID: tenantID, wallet: sender, asset: asset, delta: -stroops},
+		positionChange{tenantID: tenantID, wallet: transfer.destination, asset: asset, delta: stroops})
+	if err := s.persistInternalPositions(positions); err != nil {
+		s.releasePayments(reservation)
+		return nil, err
+	}
+	s.Internal.positions = positions
+
+	return &models.TransferResponse{
+		Status:             models.TransferInternal,
+		InternalTransferID: newID(),
+		Message:            "Transfer settled internally; it will be included in the next net on-chain settlement",
+		SourceAsset:        asset,
+		DestinationAsset:   asset,
+	}, nil
+}
+
+// settlementLeg is one on-chain payment of a net settlement
+type settlementLeg struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Asset   string `json:"asset"`
+	Stroops int64  `json:"stroops"`
+}
+
+// netSettlementLegs pairs wallets that owe with wallets that are owed, per asset, so that each tenant
+// settles with as few payments as a greedy match allows
+func netSettlementLegs(wallets map[string]map[string]int64) []settlementLeg {
+	byAsset := make(map[string]map[string]int64)
+	for wallet, assets := range wallets {
+		for asset, position := range assets {
+			if position == 0 {
+				continue
+			}
+			if byAsset[asset] == nil {
+				byAsset[asset] = make(map[string]int64)
+			}
+			byAsset[asset][wallet] = position
+		}
+	}
+
+	var legs []settlementLeg
+	for asset, positions := range byAsset {
+		var debtors, creditors []string
+		for wallet, position := range positions {
+			if position < 0 {
+				debtors = append(debtors, wallet)
+			} else {
+				creditors = append(creditors, wallet)
+			}
+		}
+		sort.Strings(debtors)
+		sort.Strings(creditors)
+
+		for len(debtors) > 0 && len(creditors) > 0 {
+			debtor, creditor := debtors[0], creditors[0]
+			owed := -positions[debtor]
+			if positions[creditor] < owed {
+				owed = positions[creditor]
+			}
+			legs = append(legs, settlementLeg{From: debtor, To: creditor, Asset: asset, Stroops: owed})
+			positions[debtor] += owed
+			positions[creditor] -= owed
+			if positions[debtor] == 0 {
+				debtors = debtors[1:]
+			}
+			if positions[creditor] == 0 {
+				creditors = creditors[1:]
+			}
+		}
+	}
+	return legs
+}
+
+// SettleInternalTransfers submits the net of every tenant's internal transfers on-chain. Fees are paid by
+// the master account or one of its channels; each leg is signed by its paying wallet. A failed batch does
+// not stop the others from settling; the errors of every failed batch are returned together. A tenant with
+// a batch whose outcome is still unknown is not settled again until that batch is reconciled, so that no
+// leg is paid twice.
+func (s *WalletService) SettleInternalTransfers() error {
+	masterKP, err := keypair.ParseFull(s.Config.MasterSecret)
+	if err != nil {
+		return errors.New("invalid master secret key: " + err.Error())
+	}
+
+	unresolved, errs := s.reconcileSettlements()
+	positions, err := s.internalPositions()
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	tenants := make(map[string][]settlementLeg)
+	for tenantID, wallets := range positions {
+		if unresolved[tenantID] {
+			continue
+		}
+		if legs := netSettlementLegs(wallets); len(legs) > 0 {
+			tenants[tenantID] = legs
+		}
+	}
+
+	for _, tenantID := range slices.Sorted(maps.Keys(tenants)) {
+		legs, settled := tenants[tenantID], 0
+		for start := 0; start < len(legs); start += maxOperationsPerTransaction {
+			end := min(start+maxOperationsPerTransaction, len(legs))
+			resp, err := s.submitSettlement(masterKP, legs[start:end])
+			if err != nil && !definitelyNotApplied(err) {
+				if recordErr := s.recordInFlightSettlement(tenantID, resp.Tx, legs[start:end]); recordErr != nil {
+					errs = append(errs, recordErr)
+				}
+				errs = append(errs, errors.New("net settlement for tenant "+tenantID+" has an unknown outcome and will be reconciled: "+err.Error()))
+				continue
+			}
+			if err != nil {
+				var txErr *TransactionError
+				if errors.As(err, &txErr) {
+					for i, code := range txErr.OperationCodes {
+						if code != "op_success" && i < end-start {
+							leg := legs[start+i]
+							log.Printf("net settlement: leg %s -> %s of %s %s failed: %s", leg.From, leg.To,
+								amount.StringFromInt64(leg.Stroops), leg.Asset, code)
+						}
+					}
+				}
+				errs = append(errs, errors.New("net settlement failed for tenant "+tenantID+": "+err.Error()))
+				continue
+			}
+
+			if err := s.finishSettlement(tenantID, "", legs[start:end], true); err != nil {
+				errs = append(errs, errors.New("net settlement for tenant "+tenantID+" is on-chain but "+err.Error()))
+			}
+			settled += end - start
+		}
+		log.Printf("net settlement: settled %d of %d legs for tenant %s", settled, len(legs), tenantID)
+	}
+	return errors.Join(errs...)
+}
+
+// recordInFlightSettlement remembers a settlement batch submitted as tx whose outcome is unknown. It is
+// kept in memory even if it cannot be persisted, so that this process does not pay its legs again.
+func (s *WalletService) recordInFlightSettlement(tenantID string, tx *txnbuild.Transaction, legs []settlementLeg) error {
+	hash, err := tx.HashHex(s.networkPassphrase())
+	if err != nil {
+		return errors.New("failed to hash settlement transaction: " + err.Error())
+	}
+	s.Internal.mu.Lock()
+	defer s.Internal.mu.Unlock()
+	if err := s.loadInternalPositionsLocked(); err != nil {
+		return err
+	}
+	inFlight := maps.Clone(s.Internal.inFlight)
+	inFlight[hash] = inFlightSettlement{TenantID: tenantID, MaxTime: tx.Timebounds().MaxTime, Legs: slices.Clone(legs)}
+	s.Internal.inFlight = inFlight
+	return s.persistInFlightSettlements(inFlight)
+}
+
+// reconcileSettlements looks every in-flight settlement up on Horizon by hash. Applied batches move the
+// positions of their legs, and failed or expired ones are dropped so that their legs are settled again.
+// It returns the tenants with batches whose outcome is still unknown.
+func (s *WalletService) reconcileSettlements() (map[string]bool, []error) {
+	s.Internal.mu.Lock()
+	err := s.loadInternalPositionsLocked()
+	inFlight := maps.Clone(s.Internal.inFlight)
+	s.Internal.mu.Unlock()
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	unresolved := make(map[string]bool)
+	var errs []error
+	for _, hash := range slices.Sorted(maps.Keys(inFlight)) {
+		batch := inFlight[hash]
+		tx, err := s.Config.HorizonClient.TransactionDetail(hash)
+		herr, ok := err.(*horizonclient.Error)
+		notFound := ok && herr.Response.StatusCode == http.StatusNotFound
+		var finishErr error
+		switch {
+		case err == nil:
+			finishErr = s.finishSettlement(batch.TenantID, hash, batch.Legs, tx.Successful)
+		case notFound && time.Now().Add(-settlementExpiryMargin).Unix() > batch.MaxTime:
+			finishErr = s.finishSettlement(batch.TenantID, hash, batch.Legs, false)
+		case notFound:
+			unresolved[batch.TenantID] = true
+		default:
+			unresolved[batch.TenantID] = true
+			errs = append(errs, errors.New("failed to look up net settlement "+hash+": "+err.Error()))
+		}
+		if finishErr != nil {
+			unresolved[batch.TenantID] = true
+			errs = append(errs, errors.New("failed to reconcile net settlement "+hash+": "+finishErr.Error()))
+		}
+	}
+	return unresolved, errs
+}
+
+// finishSettlement moves the positions of a settlement batch's legs when it was applied, and forgets the
+// batch if it was in flight under hash. The positions move even if they cannot be persisted, as the
+// payments are on-chain.
+func (s *WalletService) finishSettlement(tenantID, hash string, legs []settlementLeg, applied bool) error {
+	s.Internal.mu.Lock()
+	defer s.Internal.mu.Unlock()
+	var errs []error
+	if applied {
+		changes := make([]positionChange, 0, 2*len(legs))
+		for _, leg := range legs {
+			changes = append(changes,
+				positionChange{tenantID: tenantID, wallet: leg.From, asset: leg.Asset, delta: leg.Stroops},
+				positionChange{tenantID: tenantID, wallet: leg.To, asset: leg.Asset, delta: -leg.Stroops})
+		}
+		s.Internal.positions = withPositionChanges(s.Internal.positions, changes...)
+		errs = append(errs, s.persistInternalPositions(s.Internal.positions))
+	}
+	if _, ok := s.Internal.inFlight[hash]; ok {
+		inFlight := maps.Clone(s.Internal.inFlight)
+		delete(inFlight, hash)
+		if err := s.persistInFlightSettlements(inFlight); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		s.Internal.inFlight = inFlight
+	}
+	return errors.Join(errs...)
+}
+
+func (s *WalletService) submitSettlement(masterKP *keypair.Full, legs []settlementLeg) (submittedTransaction, error) {
+	ops := make([]txnbuild.Operation, 0, len(legs))
+	var signers []*keypair.Full
+	signed := make(map[string]bool)
+	for _, leg := range legs {
+		asset, err := parseAsset(leg.Asset)
+		if err != nil {
+			return submittedTransaction{}, errors.New("failed to parse settlement asset: " + err.Error())
+		}
+		ops = append(ops, &txnbuild.Payment{
+			Destination:   leg.To,
+			Amount:        amount.StringFromInt64(leg.Stroops),
+			Asset:         asset,
+			SourceAccount: leg.From,
+		})
+		if !signed[leg.From] {
+			kp, ok := s.Registry.Get(leg.From)
+			if !ok {
+				return submittedTransaction{}, errors.New("settling wallet is no longer managed: " + leg.From)
+			}
+			signers = append(signers, kp)
+			signed[leg.From] = true
+		}
+	}
+
+	return s.submitMasterOperations(masterKP, ops, signers...)
+}
+
+// NetSettler periodically settles internal transfers on-chain
+type NetSettler struct {
+	Wallets  *WalletService
+	Interval time.Duration
+}
+
+// NewNetSettler creates a new NetSettler instance
+func NewNetSettler(wallets *WalletService, interval time.Duration) *NetSettler {
+	return &NetSettler{Wallets: wallets, Interval: interval}
+}
+
+// Run settles every Interval until ctx is cancelled
+func (n *NetSettler) Run(ctx context.Context) {
+	ticker := time.NewTicker(n.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := n.Wallets.SettleInternalTransfers(); err != nil {
+				log.Printf("net settlement: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+	"github.com/saif727/stellar-wallet-backend/testsupport"
+)
+
+func TestSettleInternalTransfers(t *testing.T) {
+	type transfer struct {
+		fromA  bool
+		amount string
+	}
+	tests := []struct {
+		name      string
+		transfers []transfer
+		wantErr   string
+		// onChain, when set, is then paid on-chain from A to a wallet outside the tenant
+		onChain        string
+		wantOnChainErr string
+		// loseSettlement loses the response to the first settlement, which must be reconciled, not repeated
+		loseSettlement bool
+		// wantLeg is the net amount A pays B on-chain, empty when nothing is settled
+		wantLeg string
+	}{
+		{name: "nets opposite transfers into one payment", transfers: []transfer{{true, "5"}, {false, "2"}}, wantLeg: "3"},
+		{name: "settles nothing when transfers cancel out", transfers: []transfer{{true, "4"}, {false, "4"}}},
+		{name: "refuses pending outflows beyond the balance", transfers: []transfer{{true, "8"}, {true, "3"}},
+			wantErr: "insufficient balance for internal transfer", wantLeg: "8"},
+		{name: "refuses on-chain payments of pending outflows", transfers: []transfer{{true, "8"}},
+			onChain: "3", wantOnChainErr: "available after pending internal transfers", wantLeg: "8"},
+		{name: "allows on-chain payments beside pending outflows", transfers: []transfer{{true, "8"}}, onChain: "2", wantLeg: "8"},
+		{name: "reconciles a settlement whose response was lost", transfers: []transfer{{true, "5"}},
+			loseSettlement: true, wantLeg: "5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			horizon, service := newFundedService(t, func(config *services.Config) {
+				config.InternalSettlementTenants = []string{"acme"}
+			})
+			a, b := newTenantWallet(t, horizon, service, "acme", "10"), newTenantWallet(t, horizon, service, "acme", "10")
+			created := len(horizon.Submitted())
+
+			for i, tr := range tt.transfers {
+				from, to := a, b
+				if !tr.fromA {
+					from, to = b, a
+				}
+				response, err := service.TransferFunds(testsupport.NewTransferRequest(from, to.Address(), tr.amount))
+				if i == len(tt.transfers)-1 && tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("transfer %d: error = %v, want %q", i+1, err, tt.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("transfer %d: error = %v", i+1, err)
+				}
+				if response.Status != models.TransferInternal {
+					t.Fatalf("transfer %d: status = %s, want %s", i+1, response.Status, models.TransferInternal)
+				}
+			}
+			if got := len(horizon.Submitted()); got != created {
+				t.Fatalf("internal transfers submitted %d transactions, want none", got-created)
+			}
+			if tt.onChain != "" {
+				outsider := testsupport.NewKeypair()
+				horizon.SetAccount(testsupport.NewAccount(outsider.Address(), 1,
+					testsupport.NativeBalance("10"), testsupport.CreditBalance(service.Config.USDCAsset, "0")))
+				_, err := service.TransferFunds(testsupport.NewTransferRequest(a, outsider.Address(), tt.onChain))
+				switch {
+				case tt.wantOnChainErr == "" && err != nil:
+					t.Fatalf("on-chain transfer: error = %v", err)
+				case tt.wantOnChainErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantOnChainErr)):
+					t.Fatalf("on-chain transfer: error = %v, want %q", err, tt.wantOnChainErr)
+				}
+				created = len(horizon.Submitted())
+			}
+
+			horizon.LoseResponses(tt.loseSettlement)
+			err := service.SettleInternalTransfers()
+			if tt.loseSettlement != (err != nil) {
+				t.Fatalf("SettleInternalTransfers() error = %v", err)
+			}
+			horizon.LoseResponses(false)
+			if err := service.SettleInternalTransfers(); err != nil {
+				t.Fatalf("second SettleInternalTransfers() error = %v", err)
+			}
+			var legs []string
+			for _, envelope := range horizon.Submitted()[created:] {
+				for _, payment := range decodePayments(t, envelope) {
+					if payment.SourceAccount != a.Address() || payment.Destination != b.Address() {
+						t.Errorf("settlement leg from %s to %s, want from A to B", payment.SourceAccount, payment.Destination)
+					}
+					legs = append(legs, payment.Amount)
+				}
+			}
+			switch {
+			case tt.wantLeg == "" && len(legs) != 0:
+				t.Errorf("settled %v, want nothing", legs)
+			case tt.wantLeg != "" && (len(legs) != 1 || !sameAmount(legs[0], tt.wantLeg)):
+				t.Errorf("settled %v, want one leg of %s", legs, tt.wantLeg)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+)
+
+// InvoiceService issues payment requests for custodied wallets and settles them from incoming payments
+type InvoiceService struct {
+	Wallets *WalletService
+
+	mu       sync.Mutex
+	invoices map[string]*models.InvoiceResponse
+}
+
+// NewInvoiceService creates a new InvoiceService instance
+func NewInvoiceService(wallets *WalletService) *InvoiceService {
+	return &InvoiceService{Wallets: wallets, invoices: make(map[string]*models.InvoiceResponse)}
+}
+
+// sep7PaymentURI builds a SEP-7 "web+stellar:pay" URI for an invoice
+func sep7PaymentURI(invoice *models.InvoiceResponse, asset txnbuild.Asset) string {
+	params := url.Values{}
+	params.Set("destination", invoice.Destination)
+	params.Set("amount", invoice.Amount)
+	if !asset.IsNative() {
+		params.Set("asset_code", asset.GetCode())
+		params.Set("asset_issuer", asset.GetIssuer())
+	}
+	if invoice.Memo != "" {
+		params.Set("memo", invoice.Memo)
+		params.Set("memo_type", "MEMO_TEXT")
+	}
+	params.Set("msg", "Invoice "+invoice.ID)
+	// SEP-7 values are percent-encoded, so spaces must not use the form encoding "+"
+	return "web+stellar:pay?" + strings.ReplaceAll(params.Encode(), "+", "%20")
+}
+
+// CreateInvoice issues an invoice payable to one of the tenant's custodied wallets
+func (s *InvoiceService) CreateInvoice(tenantID string, req models.CreateInvoiceRequest) (*models.InvoiceResponse, error) {
+	if owner, ok := s.Wallets.Registry.TenantOf(req.Wallet); !ok || owner != tenantID {
+		return nil, errors.New("invalid wallet: invoices require a custodied wallet of this tenant")
+	}
+	if s.Wallets.isDeactivated(req.Wallet) {
+		return nil, errors.New("wallet is deactivated")
+	}
+	asset := txnbuild.Asset(s.Wallets.Config.USDCAsset)
+	if req.Asset != "" {
+		var err error
+		if asset, err = parseAsset(req.Asset); err != nil {
+			return nil, errors.New("invalid asset")
+		}
+	}
+	if err := s.Wallets.checkAssetPermitted(assetString(asset)); err != nil {
+		return nil, err
+	}
+	if err := s.Wallets.checkTransferAmount(req.Amount, assetString(asset)); err != nil {
+		return nil, err
+	}
+	if req.UseMuxedID && req.Memo != "" {
+		return nil, errors.New("invalid request: memo and use_muxed_id are mutually exclusive")
+	}
+	if len(req.Memo) > maxMemoTextLength {
+		return nil, errors.New("invalid memo: must be at most 28 bytes")
+	}
+	now := time.Now().UTC()
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return nil, errors.New("invalid expires_at: must be in the future")
+	}
+
+	invoice := &models.InvoiceResponse{
+		ID:          newID(),
+		TenantID:    tenantID,
+		Wallet:      req.Wallet,
+		Destination: req.Wallet,
+		Memo:        req.Memo,
+		Amount:      req.Amount,
+		Asset:       assetString(asset),
+		Received:    "0.0000000",
+		Status:      models.InvoicePending,
+		Payments:    []string{},
+		ExpiresAt:   req.ExpiresAt,
+		CreatedAt:   now,
+	}
+	if req.UseMuxedID {
+		var buf [8]byte
+		if _, err := rand.Read(buf[:]); err != nil {
+			return nil, errors.New("failed to generate muxed ID: " + err.Error())
+		}
+		id := binary.BigEndian.Uint64(buf[:])
+		muxed, err := xdr.MuxedAccountFromAccountId(req.Wallet, id)
+		if err != nil {
+			return nil, errors.New("failed to build muxed address: " + err.Error())
+		}
+		invoice.Destination = muxed.Address()
+		invoice.MuxedID = strconv.FormatUint(id, 10)
+	} else if invoice.Memo == "" {
+		// Invoice IDs are 32 hex characters; the first 28 are unique enough to identify a payment
+		invoice.Memo = invoice.ID[:maxMemoTextLength]
+	}
+	invoice.PaymentURI = sep7PaymentURI(invoice, asset)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.invoices[invoice.ID] = invoice
+	result := *invoice
+	return &result, nil
+}
+
+// expireLocked marks a pending invoice expired once its deadline has passed
+func expireLocked(invoice *models.InvoiceResponse, now time.Time) {
+	if invoice.Status == models.InvoicePending && invoice.ExpiresAt != nil && now.After(*invoice.ExpiresAt) {
+		invoice.Status = models.InvoiceExpired
+	}
+}
+
+func snapshotInvoice(invoice *models.InvoiceResponse) *models.InvoiceResponse {
+	result := *invoice
+	result.Payments = append([]string{}, invoice.Payments...)
+	return &result
+}
+
+// GetInvoice returns a tenant's invoice
+func (s *InvoiceService) GetInvoice(tenantID, id string) (*models.InvoiceResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	invoice, ok := s.invoices[id]
+	if !ok || invoice.TenantID != tenantID {
+		return nil, errors.New("invoice not found")
+	}
+	expireLocked(invoice, time.Now().UTC())
+	return snapshotInvoice(invoice), nil
+}
+
+// ListInvoices returns a tenant's invoices, newest first
+func (s *InvoiceService) ListInvoices(tenantID string) []models.InvoiceResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	invoices := []models.InvoiceResponse{}
+	for _, invoice := range s.invoices {
+		if invoice.TenantID == tenantID {
+			expireLocked(invoice, now)
+			invoices = append(invoices, *snapshotInvoice(invoice))
+		}
+	}
+	sort.Slice(invoices, func(i, j int) bool { return invoices[i].CreatedAt.After(invoices[j].CreatedAt) })
+	return invoices
+}
+
+// paymentMuxedID returns the muxed ID a payment was sent to, if any
+func paymentMuxedID(op operations.Operation) (uint64, bool) {
+	switch payment := op.(type) {
+	case operations.Payment:
+		return payment.ToMuxedID, payment.ToMuxed != ""
+	case operations.PathPayment:
+		return payment.ToMuxedID, payment.ToMuxed != ""
+	case operations.PathPaymentStrictSend:
+		return payment.ToMuxedID, payment.ToMuxed != ""
+	}
+	return 0, false
+}
+
+// Settle credits a payment received by a custodied wallet to the pending invoice it identifies by memo or
+// muxed ID, marking the invoice paid and publishing an invoice.paid event once the full amount has arrived.
+// op must have been fetched with its transaction joined so the memo is available.
+func (s *InvoiceService) Settle(op operations.Operation, payment models.PaymentRecord) {
+	memo := ""
+	if tx := op.GetBase().Transaction; tx != nil && tx.MemoType == "text" {
+		memo = tx.Memo
+	}
+	muxedID, muxed := paymentMuxedID(op)
+	if memo == "" && !muxed {
+		return
+	}
+	received, err := amount.ParseInt64(payment.Amount)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	now := time.Now().UTC()
+	var paid *models.InvoiceResponse
+	for _, invoice := range s.invoices {
+		if invoice.Wallet != payment.To || invoice.Asset != payment.Asset {
+			continue
+		}
+		if muxed {
+			if invoice.MuxedID != strconv.FormatUint(muxedID, 10) {
+				continue
+			}
+		} else if invoice.MuxedID != "" || invoice.Memo != memo {
+			continue
+		}
+		expireLocked(invoice, now)
+		if invoice.Status != models.InvoicePending {
+			break
+		}
+		total, _ := amount.ParseInt64(invoice.Received)
+		due, _ := amount.ParseInt64(invoice.Amount)
+		total += received
+		invoice.Received = amount.StringFromInt64(total)
+		invoice.Payments = append(invoice.Payments, payment.ID)
+		if total >= due {
+			invoice.Status = models.InvoicePaid
+			invoice.PaidAt = &now
+			paid = snapshotInvoice(invoice)
+		}
+		break
+	}
+	s.mu.Unlock()
+
+	if paid == nil {
+		return
+	}
+	s.Wallets.Events.Publish(models.EventInvoicePaid, paid.Wallet, map[string]string{
+		"invoice_id":       paid.ID,
+		"amount":           paid.Amount,
+		"received":         paid.Received,
+		"asset":            paid.Asset,
+		"transaction_hash": payment.TransactionHash,
+	})
+	s.Wallets.Audit.Record("tenant:"+paid.TenantID, "invoice.paid", paid.ID, map[string]string{
+		"wallet": paid.Wallet, "received": paid.Received, "transaction_hash": payment.TransactionHash,
+	})
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+)
+
+// Jobs are retried with a doubling delay while Horizon reports transient failures
+const (
+	maxJobAttempts = 4
+	jobRetryDelay  = 2 * time.Second
+	// maxQueuedJobs bounds the jobs waiting for a worker
+	maxQueuedJobs = 1000
+)
+
+// retryableTransactionCodes are the result codes of transactions that were not applied and can be rebuilt
+var retryableTransactionCodes = map[string]bool{
+	"tx_bad_seq":          true,
+	"tx_too_late":         true,
+	"tx_insufficient_fee": true,
+}
+
+// job is a queued wallet creation or transfer
+type job struct {
+	response models.JobResponse
+	create   *models.CreateWalletRequest
+	transfer *preparedTransfer
+}
+
+// JobService runs wallet creations and transfers asynchronously on a pool of workers, retrying transient
+// Horizon failures
+type JobService struct {
+	Wallets *WalletService
+
+	mu    sync.Mutex
+	jobs  map[string]*job
+	queue chan *job
+}
+
+// NewJobService creates a new JobService instance
+func NewJobService(wallets *WalletService) *JobService {
+	return &JobService{
+		Wallets: wallets,
+		jobs:    make(map[string]*job),
+		queue:   make(chan *job, maxQueuedJobs),
+	}
+}
+
+// Run processes queued jobs on the given number of workers until ctx is cancelled
+func (s *JobService) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j := <-s.queue:
+					s.process(ctx, j)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// EnqueueCreateWallet queues a wallet creation
+func (s *JobService) EnqueueCreateWallet(tenantID string, req models.CreateWalletRequest) (*models.JobResponse, error) {
+	return s.enqueue(&job{
+		response: models.JobResponse{TenantID: tenantID, Type: models.JobCreateWallet},
+		create:   &req,
+	})
+}
+
+// EnqueueTransfer validates a transfer and queues it; invalid requests are rejected without a job
+func (s *JobService) EnqueueTransfer(tenantID string, req models.TransferRequest) (*models.JobResponse, error) {
+	transfer, err := s.Wallets.prepareTransfer(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.enqueue(&job{
+		response: models.JobResponse{TenantID: tenantID, Type: models.JobTransfer},
+		transfer: transfer,
+	})
+}
+
+func (s *JobService) enqueue(j *job) (*models.JobResponse, error) {
+	now := time.Now().UTC()
+	j.response.ID = newID()
+	j.response.Status = models.JobQueued
+	j.response.CreatedAt = now
+	j.response.UpdatedAt = now
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case s.queue <- j:
+	default:
+		return nil, errors.New("job queue is full")
+	}
+	s.jobs[j.response.ID] = j
+	response := j.response
+	return &response, nil
+}
+
+// GetJob returns a tenant's job
+func (s *JobService) GetJob(tenantID, id string) (*models.JobResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok || j.response.TenantID != tenantID {
+		return nil, errors.New("job not found")
+	}
+	response := j.response
+	return &response, nil
+}
+
+// update applies fn to a job's response under the lock
+func (s *JobService) update(j *job, fn func(response *models.JobResponse)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&j.response)
+	j.response.UpdatedAt = time.Now().UTC()
+}
+
+// process submits a job, retrying transient failures until it is confirmed, held or failed
+func (s *JobService) process(ctx context.Context, j *job) {
+	delay := jobRetryDelay
+	for attempt := 1; ; attempt++ {
+		s.update(j, func(response *models.JobResponse) {
+			response.Status = models.JobSubmitting
+			response.Attempts = attempt
+		})
+
+		var err error
+		if j.create != nil {
+			var wallet *models.WalletResponse
+			if wallet, err = s.Wallets.CreateWallet(j.response.TenantID, *j.create); err == nil {
+				s.update(j, func(response *models.JobResponse) {
+					response.Status = models.JobConfirmed
+					response.Error = ""
+					response.ErrorDetail = nil
+					response.Wallet = wallet
+				})
+				return
+			}
+		} else {
+			var transfer *models.TransferResponse
+			if transfer, err = s.Wallets.runTransfer(j.transfer); err == nil {
+				s.Wallets.transferOutcome(j.transfer, transfer, nil)
+				s.update(j, func(response *models.JobResponse) {
+					response.Status = models.JobConfirmed
+					if transfer.Status == models.TransferPendingReview {
+						response.Status = models.JobPendingReview
+					}
+					response.Error = ""
+					response.ErrorDetail = nil
+					response.TransactionHash = transfer.TransactionHash
+					response.Transfer = transfer
+				})
+				return
+			}
+		}
+
+		retry := attempt < maxJobAttempts && jobRetryable(err)
+		s.update(j, func(response *models.JobResponse) {
+			response.Error = err.Error()
+			response.ErrorDetail = ExplainError(err)
+			if !retry {
+				response.Status = models.JobFailed
+			}
+		})
+		if !retry {
+			if j.transfer != nil {
+				s.Wallets.transferOutcome(j.transfer, nil, err)
+			}
+			log.Printf("job %s failed after %d attempts: %v", j.response.ID, attempt, err)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			s.update(j, func(response *models.JobResponse) { response.Status = models.JobFailed })
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// jobRetryable reports whether a failed job can safely be submitted again: Horizon was unreachable before
+// anything was submitted, or it rejected the transaction without applying it. Submissions whose outcome
+// is unknown are not retried, so a transfer is never paid twice.
+func jobRetryable(err error) bool {
+	var txErr *TransactionError
+	if errors.As(err, &txErr) {
+		return retryableTransactionCodes[txErr.TransactionCode]
+	}
+	return strings.HasPrefix(err.Error(), "failed to fetch")
+}
@@ -0,0 +1,693 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/stellartoml"
+	"github.com/stellar/go/keypair"
+)
+
+const (
+	// kycMaxFileSize bounds an uploaded KYC document
+	kycMaxFileSize = 10 << 20
+	// kycMaxFieldLength bounds a text SEP-9 field
+	kycMaxFieldLength = 1024
+)
+
+// sep9FieldPattern matches SEP-9 field names, e.g. first_name or organization.registered_address
+var sep9FieldPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*(\.[a-z][a-z0-9_]*)?$`)
+
+// sep9BinaryFields are the SEP-9 fields whose values are documents, uploaded as files
+var sep9BinaryFields = map[string]bool{
+	"photo_id_front":                       true,
+	"photo_id_back":                        true,
+	"notary_approval_of_photo_id":          true,
+	"photo_proof_residence":                true,
+	"proof_of_income":                      true,
+	"proof_of_liveness":                    true,
+	"organization.photo_incorporation_doc": true,
+	"organization.photo_proof_address":     true,
+}
+
+// sep12Field is a field as an anchor's GET /customer describes it
+type sep12Field struct {
+	Optional bool   `json:"optional"`
+	Status   string `json:"status"`
+	Error    string `json:"error"`
+}
+
+// sep12Customer is an anchor's GET /customer response
+type sep12Customer struct {
+	ID             string                `json:"id"`
+	Status         string                `json:"status"`
+	Message        string                `json:"message"`
+	Fields         map[string]sep12Field `json:"fields"`
+	ProvidedFields map[string]sep12Field `json:"provided_fields"`
+}
+
+// KYCService stores the SEP-9 customer information of custodied wallets and submits it to anchors over
+// SEP-12, tracking the customer's status at each anchor
+type KYCService struct {
+	Anchors *AnchorService
+	// Store, when set, persists customer records and documents; it is kept apart from the transaction
+	// archive and should encrypt what it stores. Without it they are kept in memory.
+	Store KYCStore
+
+	mu sync.Mutex
+	// customers caches the customer records read from the store, by wallet
+	customers map[string]models.KYCCustomer
+	// files holds uploaded documents when there is no store, by kycFileKey
+	files map[string][]byte
+}
+
+// NewKYCService creates a new KYCService instance
+func NewKYCService(anchors *AnchorService) *KYCService {
+	return &KYCService{
+		Anchors:   anchors,
+		customers: make(map[string]models.KYCCustomer),
+		files:     make(map[string][]byte),
+	}
+}
+
+func kycCustomerKey(publicKey string) string {
+	return "kyc/customers/" + publicKey + ".json"
+}
+
+func kycFileKey(publicKey, field string) string {
+	return "kyc/files/" + publicKey + "/" + field
+}
+
+// customerLocked returns a wallet's customer record, reading it from the store the first time it
+// is needed; a wallet without one gets an empty record. s.mu must be held.
+func (s *KYCService) customerLocked(tenantID, publicKey string) (models.KYCCustomer, error) {
+	if customer, ok := s.customers[publicKey]; ok {
+		return customer, nil
+	}
+	customer := models.KYCCustomer{Wallet: publicKey, TenantID: tenantID}
+	if s.Store != nil {
+		data, err := s.Store.Get(kycCustomerKey(publicKey))
+		switch {
+		case errors.Is(err, errArchiveNotFound):
+		case err != nil:
+			return customer, errors.New("failed to read KYC customer: " + err.Error())
+		case len(data) > 0:
+			if err := json.Unmarshal(data, &customer); err != nil {
+				return customer, errors.New("failed to decode KYC customer: " + err.Error())
+			}
+		}
+	}
+	if customer.Fields == nil {
+		customer.Fields = make(map[string]string)
+	}
+	if customer.Files == nil {
+		customer.Files = make(map[string]models.KYCFile)
+	}
+	if customer.Anchors == nil {
+		customer.Anchors = make(map[string]models.KYCAnchorCustomer)
+	}
+	s.customers[publicKey] = customer
+	return customer, nil
+}
+
+// saveLocked persists and caches a customer record; s.mu must be held
+func (s *KYCService) saveLocked(customer *models.KYCCustomer) error {
+	customer.UpdatedAt = time.Now().UTC()
+	if s.Store != nil {
+		data, err := json.Marshal(customer)
+		if err != nil {
+			return errors.New("failed to encode KYC customer: " + err.Error())
+		}
+		if err := s.Store.Put(kycCustomerKey(customer.Wallet), data); err != nil {
+			return errors.New("failed to persist KYC customer: " + err.Error())
+		}
+	}
+	s.customers[customer.Wallet] = *customer
+	return nil
+}
+
+// putFile stores an uploaded document; an empty document erases it
+func (s *KYCService) putFile(key string, data []byte) error {
+	if s.Store != nil {
+		if len(data) == 0 {
+			if err := s.Store.Delete(key); err != nil {
+				return errors.New("failed to erase KYC document: " + err.Error())
+			}
+			return nil
+		}
+		if err := s.Store.Put(key, data); err != nil {
+			return errors.New("failed to persist KYC document: " + err.Error())
+		}
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(data) == 0 {
+		delete(s.files, key)
+	} else {
+		s.files[key] = data
+	}
+	return nil
+}
+
+// file reads an uploaded document
+func (s *KYCService) file(key string) ([]byte, error) {
+	if s.Store != nil {
+		data, err := s.Store.Get(key)
+		if err != nil {
+			return nil, errors.New("failed to read KYC document: " + err.Error())
+		}
+		return data, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.files[key], nil
+}
+
+// copyCustomer returns a customer record whose maps can be changed without affecting the original
+func copyCustomer(customer models.KYCCustomer) models.KYCCustomer {
+	fields := make(map[string]string, len(customer.Fields))
+	for key, value := range customer.Fields {
+		fields[key] = value
+	}
+	files := make(map[string]models.KYCFile, len(customer.Files))
+	for key, value := range customer.Files {
+		files[key] = value
+	}
+	anchors := make(map[string]models.KYCAnchorCustomer, len(customer.Anchors))
+	for key, value := range customer.Anchors {
+		anchors[key] = value
+	}
+	customer.Fields, customer.Files, customer.Anchors = fields, files, anchors
+	return customer
+}
+
+// GetCustomer returns the customer information stored for one of a tenant's wallets
+func (s *KYCService) GetCustomer(tenantID, publicKey string) (*models.KYCCustomer, error) {
+	if _, err := s.Anchors.ownedWallet(tenantID, publicKey); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	customer, err := s.customerLocked(tenantID, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+// SetFields stores SEP-9 text fields for one of a tenant's wallets, removing fields set to ""
+func (s *KYCService) SetFields(tenantID, publicKey string, req models.KYCFieldsRequest) (*models.KYCCustomer, error) {
+	if _, err := s.Anchors.ownedWallet(tenantID, publicKey); err != nil {
+		return nil, err
+	}
+	for name, value := range req.Fields {
+		if !sep9FieldPattern.MatchString(name) {
+			return nil, errors.New("invalid field name: " + name)
+		}
+		if sep9BinaryFields[name] {
+			return nil, errors.New("invalid field: " + name + " is a document, upload it as a file")
+		}
+		if len(value) > kycMaxFieldLength {
+			return nil, errors.New("invalid field: " + name + " is longer than 1024 bytes")
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	customer, err := s.customerLocked(tenantID, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	customer = copyCustomer(customer)
+	for name, value := range req.Fields {
+		if value == "" {
+			delete(customer.Fields, name)
+		} else {
+			customer.Fields[name] = value
+		}
+	}
+	if err := s.saveLocked(&customer); err != nil {
+		return nil, err
+	}
+	s.Anchors.Wallets.Audit.Record("tenant:"+tenantID, "kyc.fields_updated", publicKey, nil)
+	return &customer, nil
+}
+
+// UploadFile stores a document for one of a wallet's binary SEP-9 fields, e.g. photo_id_front
+func (s *KYCService) UploadFile(tenantID, publicKey, field, fileName, contentType string, content io.Reader) (*models.KYCCustomer, error) {
+	if _, err := s.Anchors.ownedWallet(tenantID, publicKey); err != nil {
+		return nil, err
+	}
+	if !sep9BinaryFields[field] {
+		return nil, errors.New("invalid field: " + field + " is not a SEP-9 document field")
+	}
+	data, err := io.ReadAll(io.LimitReader(content, kycMaxFileSize+1))
+	if err != nil {
+		return nil, errors.New("failed to read KYC document: " + err.Error())
+	}
+	if len(data) == 0 {
+		return nil, errors.New("invalid file: the document is empty")
+	}
+	if len(data) > kycMaxFileSize {
+		return nil, errors.New("invalid file: documents are at most 10 MB")
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	if err := s.putFile(kycFileKey(publicKey, field), data); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	customer, err := s.customerLocked(tenantID, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	customer = copyCustomer(customer)
+	customer.Files[field] = models.KYCFile{
+		Field:       field,
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		SHA256:      hex.EncodeToString(sum[:]),
+		UploadedAt:  time.Now().UTC(),
+	}
+	if err := s.saveLocked(&customer); err != nil {
+		return nil, err
+	}
+	s.Anchors.Wallets.Audit.Record("tenant:"+tenantID, "kyc.file_uploaded", publicKey, map[string]string{"field": field})
+	return &customer, nil
+}
+
+// kycServer returns an anchor's SEP-12 KYC_SERVER, which defaults to its TRANSFER_SERVER
+func kycServer(toml *stellartoml.Response) (string, error) {
+	server := toml.KycServer
+	if server == "" {
+		server = toml.TransferServer
+	}
+	if server == "" {
+		return "", errors.New("invalid anchor: its stellar.toml has no KYC_SERVER or TRANSFER_SERVER")
+	}
+	return strings.TrimSuffix(server, "/"), nil
+}
+
+// Submit sends a wallet's stored customer information to an anchor with SEP-12 PUT /customer, creating or
+// updating the customer there, and records the status the anchor reports
+func (s *KYCService) Submit(tenantID, publicKey string, req models.KYCSubmitRequest) (*models.KYCAnchorCustomer, error) {
+	kp, err := s.Anchors.ownedWallet(tenantID, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	homeDomain := strings.ToLower(req.HomeDomain)
+	s.mu.Lock()
+	customer, err := s.customerLocked(tenantID, publicKey)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	fields, files := customer.Fields, customer.Files
+	if len(req.Fields) > 0 {
+		fields, files = make(map[string]string), make(map[string]models.KYCFile)
+		for _, name := range req.Fields {
+			value, isField := customer.Fields[name]
+			file, isFile := customer.Files[name]
+			switch {
+			case isField:
+				fields[name] = value
+			case isFile:
+				files[name] = file
+			default:
+				return nil, errors.New("invalid fields: " + name + " is not stored for this wallet")
+			}
+		}
+	}
+	if len(fields) == 0 && len(files) == 0 {
+		return nil, errors.New("invalid request: no customer information to submit")
+	}
+
+	server, token, err := s.anchorSession(kp, homeDomain)
+	if err != nil {
+		return nil, err
+	}
+	previous := customer.Anchors[homeDomain]
+	customerType := req.Type
+	if customerType == "" {
+		customerType = previous.Type
+	}
+
+	customerID, err := s.putCustomer(server, token, publicKey, previous.CustomerID, customerType, fields, files)
+	if err != nil {
+		return nil, err
+	}
+
+	record := models.KYCAnchorCustomer{
+		HomeDomain:  homeDomain,
+		CustomerID:  customerID,
+		Type:        customerType,
+		Status:      models.KYCStatusProcessing,
+		SubmittedAt: time.Now().UTC(),
+	}
+	if status, err := s.fetchStatus(server, token, record); err == nil {
+		applyKYCStatus(&record, status)
+	} else {
+		log.Printf("kyc: %s %s: %v", homeDomain, record.CustomerID, err)
+	}
+	if err := s.recordAnchor(tenantID, publicKey, record, previous); err != nil {
+		return nil, err
+	}
+	s.Anchors.Wallets.Audit.Record("tenant:"+tenantID, "kyc.submitted", homeDomain, map[string]string{
+		"wallet":      publicKey,
+		"customer_id": record.CustomerID,
+		"fields":      strings.Join(append(slices.Sorted(maps.Keys(fields)), slices.Sorted(maps.Keys(files))...), ","),
+	})
+	return &record, nil
+}
+
+// putCustomer creates or, given its ID, updates a customer at an anchor with SEP-12 PUT /customer. files
+// are documents stored for the wallet publicKey.
+func (s *KYCService) putCustomer(server, token, publicKey, customerID, customerType string, fields map[string]string, files map[string]models.KYCFile) (string, error) {
+	// SEP-12 requires binary fields to follow all text fields
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	params := map[string]string{"id": customerID, "type": customerType}
+	for name, value := range fields {
+		params[name] = value
+	}
+	for _, name := range slices.Sorted(maps.Keys(params)) {
+		if params[name] != "" {
+			writer.WriteField(name, params[name])
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		data, err := s.file(kycFileKey(publicKey, name))
+		if err != nil {
+			return "", err
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", `form-data; name="`+name+`"; filename="`+strings.ReplaceAll(files[name].FileName, `"`, "")+`"`)
+		header.Set("Content-Type", files[name].ContentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return "", err
+		}
+		part.Write(data)
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPut, server+"/customer", &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := s.Anchors.doAnchorRequest(req, token, &result); err != nil {
+		return "", errors.New("anchor rejected the customer information: " + err.Error())
+	}
+	if result.ID == "" {
+		return "", errors.New("anchor rejected the customer information: no customer id returned")
+	}
+	return result.ID, nil
+}
+
+// registerReceiver registers the receiver of a wallet's SEP-31 payment at the receiving anchor, returning the
+// anchor's customer ID. Receivers' fields are not stored.
+func (s *KYCService) registerReceiver(kp *keypair.Full, homeDomain, customerType string, fields map[string]string) (string, error) {
+	for name, value := range fields {
+		if !sep9FieldPattern.MatchString(name) || sep9BinaryFields[name] {
+			return "", errors.New("invalid receiver field: " + name)
+		}
+		if len(value) > kycMaxFieldLength {
+			return "", errors.New("invalid receiver field: " + name + " is longer than 1024 bytes")
+		}
+	}
+	server, token, err := s.anchorSession(kp, homeDomain)
+	if err != nil {
+		return "", err
+	}
+	return s.putCustomer(server, token, kp.Address(), "", customerType, fields, nil)
+}
+
+// anchorSession returns an anchor's KYC server and a JWT for the wallet
+func (s *KYCService) anchorSession(kp *keypair.Full, homeDomain string) (string, string, error) {
+	toml, err := s.Anchors.anchorToml(homeDomain)
+	if err != nil {
+		return "", "", err
+	}
+	server, err := kycServer(toml)
+	if err != nil {
+		return "", "", err
+	}
+	auth, err := s.Anchors.authenticate(kp, homeDomain)
+	if err != nil {
+		return "", "", err
+	}
+	return server, auth.Token, nil
+}
+
+// fetchStatus asks an anchor for a customer's status with SEP-12 GET /customer
+func (s *KYCService) fetchStatus(server, token string, record models.KYCAnchorCustomer) (*sep12Customer, error) {
+	query := url.Values{"id": {record.CustomerID}}
+	if record.Type != "" {
+		query.Set("type", record.Type)
+	}
+	var status sep12Customer
+	if err := s.Anchors.anchorRequest(http.MethodGet, server+"/customer?"+query.Encode(), token, nil, &status); err != nil {
+		return nil, errors.New("failed to fetch customer status: " + err.Error())
+	}
+	return &status, nil
+}
+
+// applyKYCStatus records an anchor's GET /customer response on a customer record
+func applyKYCStatus(record *models.KYCAnchorCustomer, status *sep12Customer) {
+	if status.Status != "" {
+		record.Status = status.Status
+	}
+	record.Message = status.Message
+	record.MissingFields = nil
+	for _, name := range slices.Sorted(maps.Keys(status.Fields)) {
+		if !status.Fields[name].Optional {
+			record.MissingFields = append(record.MissingFields, name)
+		}
+	}
+	record.ProvidedFields = nil
+	if len(status.ProvidedFields) > 0 {
+		record.ProvidedFields = make(map[string]models.KYCProvidedField, len(status.ProvidedFields))
+		for name, field := range status.ProvidedFields {
+			record.ProvidedFields[name] = models.KYCProvidedField{Status: field.Status, Error: field.Error}
+		}
+	}
+	record.CheckedAt = time.Now().UTC()
+}
+
+// recordAnchor saves a customer's record at an anchor, publishing a kyc.status_changed event when its
+// status differs from the previous record's
+func (s *KYCService) recordAnchor(tenantID, publicKey string, record, previous models.KYCAnchorCustomer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	customer, err := s.customerLocked(tenantID, publicKey)
+	if err != nil {
+		return err
+	}
+	if record.Status != previous.Status || record.CustomerID != previous.CustomerID {
+		key := publicKey + "@" + record.HomeDomain + ":" + record.CustomerID + ":" + record.SubmittedAt.Format(time.RFC3339Nano) + ":" + record.Status
+		data := map[string]string{
+			"home_domain": record.HomeDomain,
+			"customer_id": record.CustomerID,
+			"status":      record.Status,
+		}
+		if record.Message != "" {
+			data["message"] = record.Message
+		}
+		if len(record.MissingFields) > 0 {
+			data["missing_fields"] = strings.Join(record.MissingFields, ",")
+		}
+		if err := s.Anchors.Wallets.stageEvent(models.EventKYCStatusChanged, publicKey, key, data); err != nil {
+			return err
+		}
+	}
+	customer = copyCustomer(customer)
+	customer.Anchors[record.HomeDomain] = record
+	return s.saveLocked(&customer)
+}
+
+// RefreshStatus fetches a wallet's current customer status from an anchor it was submitted to
+func (s *KYCService) RefreshStatus(tenantID, publicKey, homeDomain string) (*models.KYCAnchorCustomer, error) {
+	kp, err := s.Anchors.ownedWallet(tenantID, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return s.refresh(kp, tenantID, strings.ToLower(homeDomain))
+}
+
+// refresh fetches and records a customer's status at an anchor
+func (s *KYCService) refresh(kp *keypair.Full, tenantID, homeDomain string) (*models.KYCAnchorCustomer, error) {
+	s.mu.Lock()
+	customer, err := s.customerLocked(tenantID, kp.Address())
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	previous, ok := customer.Anchors[homeDomain]
+	if !ok {
+		return nil, errors.New("KYC customer not found")
+	}
+	server, token, err := s.anchorSession(kp, homeDomain)
+	if err != nil {
+		return nil, err
+	}
+	status, err := s.fetchStatus(server, token, previous)
+	if err != nil {
+		return nil, err
+	}
+	record := previous
+	applyKYCStatus(&record, status)
+	if err := s.recordAnchor(tenantID, kp.Address(), record, previous); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// DeleteFromAnchor deletes a wallet's customer information at an anchor with SEP-12 DELETE /customer
+func (s *KYCService) DeleteFromAnchor(tenantID, publicKey, homeDomain string) error {
+	kp, err := s.Anchors.ownedWallet(tenantID, publicKey)
+	if err != nil {
+		return err
+	}
+	return s.deleteFromAnchor(kp, tenantID, strings.ToLower(homeDomain))
+}
+
+// deleteFromAnchor deletes a customer at an anchor and forgets the anchor's record
+func (s *KYCService) deleteFromAnchor(kp *keypair.Full, tenantID, homeDomain string) error {
+	s.mu.Lock()
+	customer, err := s.customerLocked(tenantID, kp.Address())
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if _, ok := customer.Anchors[homeDomain]; !ok {
+		return errors.New("KYC customer not found")
+	}
+	server, token, err := s.anchorSession(kp, homeDomain)
+	if err != nil {
+		return err
+	}
+	err = s.Anchors.anchorRequest(http.MethodDelete, server+"/customer/"+kp.Address(), token, nil, nil)
+	var anchorErr *anchorResponseError
+	if err != nil && !(errors.As(err, &anchorErr) && anchorErr.StatusCode == http.StatusNotFound) {
+		return errors.New("anchor rejected the customer deletion: " + err.Error())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	customer, err = s.customerLocked(tenantID, kp.Address())
+	if err != nil {
+		return err
+	}
+	customer = copyCustomer(customer)
+	delete(customer.Anchors, homeDomain)
+	if err := s.saveLocked(&customer); err != nil {
+		return err
+	}
+	s.Anchors.Wallets.Audit.Record("tenant:"+tenantID, "kyc.deleted_at_anchor", homeDomain, map[string]string{"wallet": kp.Address()})
+	return nil
+}
+
+// DeleteCustomer deletes a wallet's customer information at every anchor it was submitted to, then erases
+// the stored fields and documents. Anchors that fail to delete it are kept, so the request can be retried.
+func (s *KYCService) DeleteCustomer(tenantID, publicKey string) error {
+	kp, err := s.Anchors.ownedWallet(tenantID, publicKey)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	customer, err := s.customerLocked(tenantID, publicKey)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, homeDomain := range slices.Sorted(maps.Keys(customer.Anchors)) {
+		if err := s.deleteFromAnchor(kp, tenantID, homeDomain); err != nil {
+			return err
+		}
+	}
+	for field := range customer.Files {
+		if err := s.putFile(kycFileKey(publicKey, field), nil); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Store != nil {
+		if err := s.Store.Delete(kycCustomerKey(publicKey)); err != nil {
+			return errors.New("failed to erase KYC customer: " + err.Error())
+		}
+	}
+	delete(s.customers, publicKey)
+	s.Anchors.Wallets.Audit.Record("tenant:"+tenantID, "kyc.deleted", publicKey, nil)
+	return nil
+}
+
+// Run polls anchors for the status of customers they are processing every interval until ctx is cancelled
+func (s *KYCService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Poll()
+		}
+	}
+}
+
+// Poll refreshes the status of every custodied wallet's customer records that anchors are still processing
+func (s *KYCService) Poll() {
+	registry := s.Anchors.Wallets.Registry
+	for _, publicKey := range registry.PublicKeys() {
+		tenantID, _ := registry.TenantOf(publicKey)
+		kp, ok := registry.Get(publicKey)
+		if !ok {
+			continue
+		}
+		s.mu.Lock()
+		customer, err := s.customerLocked(tenantID, publicKey)
+		s.mu.Unlock()
+		if err != nil {
+			log.Printf("kyc: %s: %v", publicKey, err)
+			continue
+		}
+		for homeDomain, record := range customer.Anchors {
+			if record.Status != models.KYCStatusProcessing {
+				continue
+			}
+			if _, err := s.refresh(kp, tenantID, homeDomain); err != nil {
+				log.Printf("kyc: %s at %s: %v", publicKey, homeDomain, err)
+			}
+		}
+	}
+}
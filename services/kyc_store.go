@@ -0,0 +1,69 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+)
+
+// KYCStore persists customers' KYC information. Unlike archived transactions, personal data must be
+// erasable, so the store supports deletion.
+type KYCStore interface {
+	ArchiveStore
+	Delete(key string) error
+}
+
+// EncryptedStore seals everything it stores in another store with AES-256-GCM, binding each value to its
+// key so that values cannot be swapped between keys
+type EncryptedStore struct {
+	Store KYCStore
+	aead  cipher.AEAD
+}
+
+// NewEncryptedStore returns an EncryptedStore over store using a 32-byte key
+func NewEncryptedStore(store KYCStore, key []byte) (*EncryptedStore, error) {
+	if len(key) != 32 {
+		return nil, errors.New("invalid encryption key: must be 32 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedStore{Store: store, aead: aead}, nil
+}
+
+// Put encrypts data and stores it under key
+func (e *EncryptedStore) Put(key string, data []byte) error {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	return e.Store.Put(key, e.aead.Seal(nonce, nonce, data, []byte(key)))
+}
+
+// Get reads and decrypts the data stored under key
+func (e *EncryptedStore) Get(key string) ([]byte, error) {
+	sealed, err := e.Store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < e.aead.NonceSize() {
+		return nil, errors.New("encrypted value is truncated")
+	}
+	nonce, ciphertext := sealed[:e.aead.NonceSize()], sealed[e.aead.NonceSize():]
+	data, err := e.aead.Open(nil, nonce, ciphertext, []byte(key))
+	if err != nil {
+		return nil, errors.New("failed to decrypt stored value: " + err.Error())
+	}
+	return data, nil
+}
+
+// Delete removes the data stored under key
+func (e *EncryptedStore) Delete(key string) error {
+	return e.Store.Delete(key)
+}
@@ -0,0 +1,62 @@
+package services_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/saif727/stellar-wallet-backend/services"
+)
+
+func TestEncryptedStore(t *testing.T) {
+	plain := []byte(`{"first_name":"Ada"}`)
+	tests := []struct {
+		name string
+		// prepare acts on the underlying store after plain was stored under "kyc/a"
+		prepare func(t *testing.T, raw *services.LocalArchiveStore, store *services.EncryptedStore)
+		wantErr bool
+	}{
+		{name: "reads back what it stored", prepare: func(*testing.T, *services.LocalArchiveStore, *services.EncryptedStore) {}},
+		{name: "refuses a value moved from another key", wantErr: true,
+			prepare: func(t *testing.T, raw *services.LocalArchiveStore, _ *services.EncryptedStore) {
+				sealed, _ := raw.Get("kyc/b")
+				if err := raw.Put("kyc/a", sealed); err != nil {
+					t.Fatal(err)
+				}
+			}},
+		{name: "erases deleted values", wantErr: true,
+			prepare: func(t *testing.T, _ *services.LocalArchiveStore, store *services.EncryptedStore) {
+				if err := store.Delete("kyc/a"); err != nil {
+					t.Fatalf("Delete() error = %v", err)
+				}
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := &services.LocalArchiveStore{Dir: t.TempDir()}
+			store, err := services.NewEncryptedStore(raw, bytes.Repeat([]byte{7}, 32))
+			if err != nil {
+				t.Fatalf("NewEncryptedStore() error = %v", err)
+			}
+			for _, key := range []string{"kyc/a", "kyc/b"} {
+				if err := store.Put(key, plain); err != nil {
+					t.Fatalf("Put() error = %v", err)
+				}
+			}
+			if sealed, _ := raw.Get("kyc/a"); bytes.Contains(sealed, []byte("Ada")) {
+				t.Fatalf("stored value %q is not encrypted", sealed)
+			}
+			tt.prepare(t, raw, store)
+
+			got, err := store.Get("kyc/a")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Get() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(got, plain) {
+				t.Fatalf("Get() = %q, %v, want %q", got, err, plain)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+)
+
+// liquidityPoolShare resolves a pool share balance into the pool's reserves and the wallet's claim on them
+func (s *WalletService) liquidityPoolShare(poolID, shares string) (*models.LiquidityPoolShare, error) {
+	pool, err := s.Config.HorizonClient.LiquidityPoolDetail(horizonclient.LiquidityPoolRequest{LiquidityPoolID: poolID})
+	if err != nil {
+		return nil, errors.New("failed to fetch liquidity pool details: " + err.Error())
+	}
+
+	shareStroops, err := amount.ParseInt64(shares)
+	if err != nil {
+		return nil, errors.New("failed to parse pool shares: " + err.Error())
+	}
+	totalStroops, err := amount.ParseInt64(pool.TotalShares)
+	if err != nil {
+		return nil, errors.New("failed to parse pool total shares: " + err.Error())
+	}
+
+	share := &models.LiquidityPoolShare{
+		PoolID:      pool.ID,
+		FeeBP:       pool.FeeBP,
+		TotalShares: pool.TotalShares,
+		Reserves:    []models.LiquidityPoolReserve{},
+	}
+	for _, reserve := range pool.Reserves {
+		owned := "0.0000000"
+		if totalStroops > 0 {
+			reserveStroops, err := amount.ParseInt64(reserve.Amount)
+			if err != nil {
+				return nil, errors.New("failed to parse pool reserve: " + err.Error())
+			}
+			// reserve * shares / total, in big.Int to avoid overflowing int64
+			value := new(big.Int).Mul(big.NewInt(reserveStroops), big.NewInt(shareStroops))
+			value.Quo(value, big.NewInt(totalStroops))
+			owned = amount.StringFromInt64(value.Int64())
+		}
+		share.Reserves = append(share.Reserves, models.LiquidityPoolReserve{
+			Asset:       reserve.Asset,
+			PoolAmount:  reserve.Amount,
+			ShareAmount: owned,
+		})
+	}
+	return share, nil
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// MasterBalanceStatus is the last observed master account balance of one monitored asset
+type MasterBalanceStatus struct {
+	// Asset is "native" or CODE:ISSUER
+	Asset     string
+	Balance   string
+	Threshold string
+	Low       bool
+	CheckedAt time.Time
+}
+
+// MasterBalanceMonitor warns operators when the master account, which funds new wallets with XLM and
+// USDC, runs low on an asset. It alerts once when a balance falls below its threshold and again only after
+// the balance has recovered.
+type MasterBalanceMonitor struct {
+	Wallets *WalletService
+	// Thresholds maps "native" or CODE:ISSUER to the balance, in stroops, below which the monitor alerts
+	Thresholds map[string]int64
+	Interval   time.Duration
+	Alerter    Alerter
+
+	mu       sync.Mutex
+	statuses map[string]MasterBalanceStatus
+}
+
+// NewMasterBalanceMonitor creates a new MasterBalanceMonitor instance
+func NewMasterBalanceMonitor(wallets *WalletService, thresholds map[string]int64, interval time.Duration, alerter Alerter) *MasterBalanceMonitor {
+	return &MasterBalanceMonitor{
+		Wallets:    wallets,
+		Thresholds: thresholds,
+		Interval:   interval,
+		Alerter:    alerter,
+		statuses:   make(map[string]MasterBalanceStatus),
+	}
+}
+
+// Run checks the master balances every Interval until ctx is cancelled
+func (m *MasterBalanceMonitor) Run(ctx context.Context) {
+	m.Check()
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check()
+		}
+	}
+}
+
+// Check reads the master account's balances once and alerts for each asset that has just fallen below its
+// threshold. A missing trustline counts as a zero balance.
+func (m *MasterBalanceMonitor) Check() {
+	masterKP, err := keypair.Parse(m.Wallets.Config.MasterSecret)
+	if err != nil {
+		log.Printf("master balance monitor: invalid master secret key: %v", err)
+		return
+	}
+	account, err := m.Wallets.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: masterKP.Address()})
+	if err != nil {
+		log.Printf("master balance monitor: failed to fetch master account: %v", err)
+		return
+	}
+	balances := accountBalances(account)
+
+	type alert struct{ title, message string }
+	var alerts []alert
+	now := time.Now().UTC()
+	m.mu.Lock()
+	for asset, threshold := range m.Thresholds {
+		held := balances[asset]
+		if held == "" {
+			held = "0"
+		}
+		balance, err := amount.ParseInt64(held)
+		if err != nil {
+			log.Printf("master balance monitor: invalid %s balance %q: %v", asset, held, err)
+			continue
+		}
+		low := balance < threshold
+		wasLow := m.statuses[asset].Low
+		m.statuses[asset] = MasterBalanceStatus{
+			Asset:     asset,
+			Balance:   amount.StringFromInt64(balance),
+			Threshold: amount.StringFromInt64(threshold),
+			Low:       low,
+			CheckedAt: now,
+		}
+		if low && !wasLow {
+			alerts = append(alerts, alert{
+				title: "Master account " + assetDisplayCode(asset) + " balance low",
+				message: "Master account " + masterKP.Address() + " holds " + amount.StringFromInt64(balance) + " " +
+					assetDisplayCode(asset) + ", below the warning threshold of " + amount.StringFromInt64(threshold) +
+					". " + m.walletsLeft(account, asset, balance),
+			})
+		}
+	}
+	m.mu.Unlock()
+
+	for _, a := range alerts {
+		m.Alerter.Alert(a.title, a.message)
+	}
+}
+
+// walletsLeft estimates how many more wallets a balance can fund before wallet creation fails with
+// op_underfunded
+func (m *MasterBalanceMonitor) walletsLeft(account hProtocol.Account, asset string, balance int64) string {
+	var perWallet int64
+	switch asset {
+	case "native":
+		// Each wallet costs its starting balance plus the fee, and the master must keep its own reserve
+		baseReserve, err := m.Wallets.baseReserveStroops()
+		if err != nil {
+			return ""
+		}
+		perWallet = int64(amount.MustParse(m.Wallets.walletStartingBalance())) + txnbuild.MinBaseFee
+		balance -= minimumBalanceStroops(account, baseReserve)
+	case m.Wallets.Config.USDCAsset.Code + ":" + m.Wallets.Config.USDCAsset.Issuer:
+		perWallet = int64(amount.MustParse(walletUSDCGrant))
+	default:
+		return ""
+	}
+	if balance < 0 {
+		balance = 0
+	}
+	return "It can fund about " + strconv.FormatInt(balance/perWallet, 10) + " more wallets."
+}
+
+// Statuses returns the last observed balance of every monitored asset, ordered by asset
+func (m *MasterBalanceMonitor) Statuses() []MasterBalanceStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	statuses := make([]MasterBalanceStatus, 0, len(m.statuses))
+	for _, status := range m.statuses {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Asset < statuses[j].Asset })
+	return statuses
+}
+
+// assetDisplayCode returns the code of "native" or a CODE:ISSUER asset, for messages
+func assetDisplayCode(asset string) string {
+	if asset == "native" {
+		return "XLM"
+	}
+	code, _, _ := strings.Cut(asset, ":")
+	return code
+}
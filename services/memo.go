@@ -0,0 +1,67 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// maxMemoTextLength is the protocol limit on MEMO_TEXT in bytes
+const maxMemoTextLength = 28
+
+// memoRequiredValue is the base64 "1" a SEP-29 account stores under config.memo_required
+const memoRequiredValue = "MQ=="
+
+// errMemoRequired is returned for memo-less transfers to accounts that require a memo
+var errMemoRequired = errors.New("recipient requires a memo (SEP-29): set memo or send to the recipient's muxed address")
+
+// parseMemo builds a transaction memo from a transfer's memo and memo type; an empty memo means none
+func parseMemo(value, memoType string) (txnbuild.Memo, error) {
+	if value == "" {
+		if memoType != "" {
+			return nil, errors.New("invalid memo: memo_type requires a memo")
+		}
+		return nil, nil
+	}
+	switch memoType {
+	case "", "text":
+		if len(value) > maxMemoTextLength {
+			return nil, errors.New("invalid memo: text memos are at most 28 bytes")
+		}
+		return txnbuild.MemoText(value), nil
+	case "id":
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, errors.New("invalid memo: id memos must be an unsigned 64-bit integer")
+		}
+		return txnbuild.MemoID(id), nil
+	case "hash":
+		decoded, err := hex.DecodeString(value)
+		if err != nil || len(decoded) != 32 {
+			return nil, errors.New("invalid memo: hash memos must be 32 hex-encoded bytes")
+		}
+		var hash txnbuild.MemoHash
+		copy(hash[:], decoded)
+		return hash, nil
+	}
+	return nil, errors.New("invalid memo: memo_type must be text, id or hash")
+}
+
+// hexHashMemo converts a base64 hash memo, as federation servers and anchors return them, to the hex
+// encoding transfers take
+func hexHashMemo(value string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(decoded) != 32 {
+		return "", errors.New("invalid hash memo: must be 32 base64-encoded bytes")
+	}
+	return hex.EncodeToString(decoded), nil
+}
+
+// memoRequired reports whether an account requires incoming payments to carry a memo
+func memoRequired(account hProtocol.Account) bool {
+	return account.Data["config.memo_required"] == memoRequiredValue
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sort"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+)
+
+// MergeWallet merges a wallet into a destination account in a single transaction: it cancels the wallet's
+// offers, deletes its data entries, sweeps its asset balances to the destination, removes its trustlines
+// and finally merges the remaining XLM, reserves included, into the destination
+func (s *WalletService) MergeWallet(tenantID, publicKey string, req models.MergeWalletRequest) (*models.MergeWalletResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	destination, _, err := parseDestination(req.Destination)
+	if err != nil {
+		return nil, errors.New("invalid destination public key")
+	}
+	if destination == publicKey {
+		return nil, errors.New("invalid destination: a wallet cannot be merged into itself")
+	}
+	walletKP, err := s.authorizedSigner(tenantID, publicKey, req.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	if s.isDeactivated(publicKey) {
+		return nil, errors.New("wallet is deactivated; restore it before merging")
+	}
+
+	account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: publicKey})
+	if err != nil {
+		return nil, errors.New("failed to fetch wallet account details: " + err.Error())
+	}
+	if account.NumSponsoring > 0 {
+		return nil, errors.New("wallet has sponsored reserves of other accounts; revoke or transfer them before merging")
+	}
+	offers, err := s.Config.HorizonClient.Offers(horizonclient.OfferRequest{ForAccount: publicKey, Limit: 200})
+	if err != nil {
+		return nil, errors.New("failed to fetch wallet offers: " + err.Error())
+	}
+
+	// Offers go first so the liabilities they hold do not block the sweep
+	var ops []txnbuild.Operation
+	for _, offer := range offers.Embedded.Records {
+		cancel, err := txnbuild.DeleteOfferOp(offer.ID)
+		if err != nil {
+			return nil, errors.New("failed to create offer cancellation: " + err.Error())
+		}
+		ops = append(ops, &cancel)
+	}
+	names := make([]string, 0, len(account.Data))
+	for name := range account.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ops = append(ops, &txnbuild.ManageData{Name: name})
+	}
+	sweepOps, swept, sweptOps, err := sweepTrustlines(account, req.Destination)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sweptOps {
+		for j := range sweptOps[i] {
+			sweptOps[i][j] += len(ops)
+		}
+	}
+	ops = append(ops, sweepOps...)
+
+	trustlines := 0
+	for _, balance := range account.Balances {
+		if balance.Type != "native" {
+			trustlines++
+		}
+	}
+	if int(account.SubentryCount) > trustlines+len(offers.Embedded.Records)+len(names) {
+		return nil, errors.New("wallet has additional signers; remove them before merging")
+	}
+	ops = append(ops, &txnbuild.AccountMerge{Destination: req.Destination})
+	if len(ops) > maxOperationsPerTransaction {
+		return nil, errors.New("wallet has too many offers, data entries and trustlines to merge in one transaction")
+	}
+
+	tx, err := s.buildTransaction(publicKey, txnbuild.TransactionParams{
+		Operations:    ops,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+	}, walletKP)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.submitTransaction(tx, walletKP)
+	if err != nil {
+		return nil, s.attributeFailure(err, swept, sweptOps)
+	}
+	// A merged account that is later recreated starts from a new sequence number
+	s.Sequences.Resync(publicKey)
+	s.Registry.Remove(publicKey)
+	attributeOperations(swept, sweptOps, s.operationResults(resp.Hash, nil))
+
+	reclaimed, err := mergedBalance(resp.ResultXdr)
+	if err != nil {
+		log.Printf("merge %s: failed to read merged balance: %v", resp.Hash, err)
+	}
+	s.Audit.Record("wallet:"+publicKey, "wallet.merged", publicKey, map[string]string{
+		"destination":      req.Destination,
+		"reclaimed_xlm":    reclaimed,
+		"transaction_hash": resp.Hash,
+	})
+
+	return &models.MergeWalletResponse{
+		PublicKey:          publicKey,
+		TransactionHash:    resp.Hash,
+		MergedInto:         req.Destination,
+		ReclaimedXLM:       reclaimed,
+		CancelledOffers:    len(offers.Embedded.Records),
+		RemovedDataEntries: names,
+		Swept:              swept,
+		Message:            "Wallet merged into destination",
+	}, nil
+}
+
+// mergedBalance reads the XLM an account merge credited to its destination from the transaction result,
+// which holds the merge as its last operation
+func mergedBalance(resultXDR string) (string, error) {
+	var result xdr.TransactionResult
+	if err := xdr.SafeUnmarshalBase64(resultXDR, &result); err != nil {
+		return "", err
+	}
+	results, ok := result.OperationResults()
+	if !ok || len(results) == 0 {
+		return "", errors.New("transaction result has no operation results")
+	}
+	last := results[len(results)-1]
+	if last.Tr == nil || last.Tr.AccountMergeResult == nil {
+		return "", errors.New("last operation is not an account merge")
+	}
+	balance, ok := last.Tr.AccountMergeResult.GetSourceAccountBalance()
+	if !ok {
+		return "", errors.New("account merge result has no balance")
+	}
+	return amount.String(balance), nil
+}
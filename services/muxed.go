@@ -0,0 +1,39 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+)
+
+// parseDestination validates a G... account or M... muxed address and returns the underlying G... account
+// and, for muxed addresses, the decimal muxed ID
+func parseDestination(address string) (string, string, error) {
+	if !strings.HasPrefix(address, "M") {
+		if _, err := keypair.ParseAddress(address); err != nil {
+			return "", "", err
+		}
+		return address, "", nil
+	}
+	muxed, err := xdr.AddressToMuxedAccount(address)
+	if err != nil {
+		return "", "", err
+	}
+	med25519, ok := muxed.GetMed25519()
+	if !ok {
+		return "", "", errors.New("not a muxed address")
+	}
+	base := muxed.ToAccountId()
+	return base.Address(), strconv.FormatUint(uint64(med25519.Id), 10), nil
+}
+
+// muxedAddress returns address when it is muxed, and an empty string otherwise
+func muxedAddress(address, muxedID string) string {
+	if muxedID == "" {
+		return ""
+	}
+	return address
+}
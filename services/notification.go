@@ -0,0 +1,121 @@
+package services
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/keypair"
+)
+
+// DefaultTenantID is used when a request does not identify a tenant
+const DefaultTenantID = "default"
+
+// NotificationService manages per-wallet notification preferences
+type NotificationService struct {
+	Config Config
+
+	mu          sync.RWMutex
+	preferences map[string]map[string]string
+}
+
+// NewNotificationService creates a new NotificationService instance
+func NewNotificationService(config Config) *NotificationService {
+	return &NotificationService{
+		Config:      config,
+		preferences: make(map[string]map[string]string),
+	}
+}
+
+// AvailableChannels returns the notification channels operators have enabled, plus "none"
+func (s *NotificationService) AvailableChannels() []string {
+	channels := append([]string{}, s.Config.NotificationChannels...)
+	return append(channels, models.ChannelNone)
+}
+
+func (s *NotificationService) channelAvailable(channel string) bool {
+	for _, c := range s.AvailableChannels() {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// tenantDefaults returns the channel each event uses for a tenant before any wallet overrides
+func (s *NotificationService) tenantDefaults(tenantID string) map[string]string {
+	defaults := make(map[string]string, len(models.EventTypes))
+	for _, event := range models.EventTypes {
+		defaults[event] = models.ChannelWebhook
+	}
+	for event, channel := range s.Config.TenantNotificationDefaults[tenantID] {
+		defaults[event] = channel
+	}
+	for event, channel := range defaults {
+		if !s.channelAvailable(channel) {
+			defaults[event] = models.ChannelNone
+		}
+	}
+	return defaults
+}
+
+// GetPreferences returns the effective notification preferences of a wallet
+func (s *NotificationService) GetPreferences(tenantID, publicKey string) (*models.NotificationPreferencesResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+
+	effective := s.tenantDefaults(tenantID)
+	s.mu.RLock()
+	for event, channel := range s.preferences[publicKey] {
+		effective[event] = channel
+	}
+	s.mu.RUnlock()
+
+	return &models.NotificationPreferencesResponse{
+		PublicKey:         publicKey,
+		TenantID:          tenantID,
+		Preferences:       effective,
+		AvailableChannels: s.AvailableChannels(),
+	}, nil
+}
+
+// UpdatePreferences stores per-event channel overrides for a wallet
+func (s *NotificationService) UpdatePreferences(tenantID, publicKey string, req models.NotificationPreferencesRequest) (*models.NotificationPreferencesResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+
+	known := make(map[string]bool, len(models.EventTypes))
+	for _, event := range models.EventTypes {
+		known[event] = true
+	}
+	for event, channel := range req.Preferences {
+		if !known[event] {
+			return nil, errors.New("unknown event type: " + event)
+		}
+		if !s.channelAvailable(channel) {
+			return nil, errors.New("notification channel not available: " + channel)
+		}
+	}
+
+	s.mu.Lock()
+	overrides, ok := s.preferences[publicKey]
+	if !ok {
+		overrides = make(map[string]string)
+		s.preferences[publicKey] = overrides
+	}
+	for event, channel := range req.Preferences {
+		overrides[event] = channel
+	}
+	s.mu.Unlock()
+
+	return s.GetPreferences(tenantID, publicKey)
+}
+
+// RemovePreferences discards a wallet's preference overrides
+func (s *NotificationService) RemovePreferences(publicKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.preferences, publicKey)
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+)
+
+// TransactionError is returned when Horizon rejects a transaction. It keeps Horizon's result codes so
+// failures of composed transactions can be attributed to the request items that caused them.
+type TransactionError struct {
+	Message         string
+	TransactionCode string
+	OperationCodes  []string
+	// Items, when set by a batch flow, carries the request items with their operations' result codes
+	Items []models.SweptBalance
+}
+
+// Error implements error
+func (e *TransactionError) Error() string {
+	return e.Message
+}
+
+// newTransactionError converts a Horizon submission error into a TransactionError
+func newTransactionError(herr *horizonclient.Error) *TransactionError {
+	txErr := &TransactionError{Message: "transaction failed: " + herr.Problem.Detail}
+	if codes, err := herr.ResultCodes(); err == nil {
+		txErr.TransactionCode = transactionCode(codes)
+		txErr.OperationCodes = codes.OperationCodes
+	}
+	return txErr
+}
+
+// transactionCode returns the transaction result code of a submission, looking through a fee bump to the
+// inner transaction that failed
+func transactionCode(codes *hProtocol.TransactionResultCodes) string {
+	if codes.TransactionCode == "tx_fee_bump_inner_failed" && codes.InnerTransactionCode != "" {
+		return codes.InnerTransactionCode
+	}
+	return codes.TransactionCode
+}
+
+// operationResults returns the per-operation outcome of a transaction: Horizon's result codes when
+// submission failed, otherwise each operation's ID and effects
+func (s *WalletService) operationResults(hash string, submitErr error) []models.OperationResult {
+	if submitErr != nil {
+		var txErr *TransactionError
+		if !errors.As(submitErr, &txErr) {
+			return nil
+		}
+		results := make([]models.OperationResult, 0, len(txErr.OperationCodes))
+		for i, code := range txErr.OperationCodes {
+			results = append(results, models.OperationResult{Index: i, ResultCode: code})
+		}
+		return results
+	}
+
+	ops, err := s.Config.HorizonClient.Operations(horizonclient.OperationRequest{ForTransaction: hash, Limit: 200})
+	if err != nil {
+		return nil
+	}
+	results := make([]models.OperationResult, 0, len(ops.Embedded.Records))
+	byID := make(map[string]int, len(ops.Embedded.Records))
+	for i, op := range ops.Embedded.Records {
+		byID[op.GetID()] = i
+		results = append(results, models.OperationResult{Index: i, OperationID: op.GetID(), Type: op.GetType()})
+	}
+
+	effects, err := s.Config.HorizonClient.Effects(horizonclient.EffectRequest{ForTransaction: hash, Limit: 200})
+	if err != nil {
+		return results
+	}
+	for _, effect := range effects.Embedded.Records {
+		raw, err := json.Marshal(effect)
+		if err != nil {
+			continue
+		}
+		var links struct {
+			Links struct {
+				Operation struct {
+					Href string `json:"href"`
+				} `json:"operation"`
+			} `json:"_links"`
+		}
+		if json.Unmarshal(raw, &links) != nil {
+			continue
+		}
+		href := links.Links.Operation.Href
+		if i, ok := byID[href[strings.LastIndex(href, "/")+1:]]; ok {
+			results[i].Effects = append(results[i].Effects, raw)
+		}
+	}
+	return results
+}
+
+// attributeOperations attaches to each batch item the results of the operations it produced; itemOps[i]
+// lists the operation indexes of items[i]
+func attributeOperations(items []models.SweptBalance, itemOps [][]int, results []models.OperationResult) {
+	for i := range items {
+		for _, index := range itemOps[i] {
+			if index < len(results) {
+				items[i].Operations = append(items[i].Operations, results[index])
+			}
+		}
+	}
+}
+
+// attributeFailure attributes a failed batch submission's result codes to its items
+func (s *WalletService) attributeFailure(err error, items []models.SweptBalance, itemOps [][]int) error {
+	var txErr *TransactionError
+	if !errors.As(err, &txErr) {
+		return err
+	}
+	attributeOperations(items, itemOps, s.operationResults("", err))
+	txErr.Items = items
+	return txErr
+}
@@ -0,0 +1,368 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+)
+
+const (
+	outboxKey = "outbox/events.json"
+	// outboxDoneLimit is how many relayed event IDs are remembered, so restaging a state change that was
+	// already relayed is a no-op
+	outboxDoneLimit = 10000
+	// Failed publications are retried with a doubling delay of at most outboxMaxRetryDelay
+	outboxRetryDelay    = time.Second
+	outboxMaxRetryDelay = 5 * time.Minute
+	// outboxStatusEntries caps the pending entries listed by Status
+	outboxStatusEntries = 100
+)
+
+// outboxState is the persisted outbox
+type outboxState struct {
+	// Pending lists the staged events not yet fully relayed, in the order they were staged
+	Pending []models.OutboxEntry `json:"pending"`
+	// Done lists the IDs of the most recently relayed events, oldest first
+	Done []string `json:"done"`
+}
+
+// Outbox is a transactional outbox: services stage the events of a state change, durably, before they
+// commit the change, and a relay delivers staged events to in-process subscribers such as webhooks and push
+// notifications and publishes them to the external event bus. Event IDs are derived from the state change,
+// so a change replayed after a crash restages the same event instead of a new one, and state and events
+// never diverge. Delivery is at least once: receivers dedupe on the event ID.
+type Outbox struct {
+	Wallets *WalletService
+	// Broker, when set, is where staged events and events published directly on the EventBus are published
+	Broker EventBroker
+	// Interval is how often the relay retries pending events; staging wakes it immediately
+	Interval time.Duration
+	// MaxAge is how old the oldest pending event may get before the outbox is reported backlogged
+	MaxAge  time.Duration
+	Alerter Alerter
+
+	mu         sync.Mutex
+	loaded     bool
+	pending    []models.OutboxEntry
+	done       []string
+	doneSet    map[string]bool
+	backlogged bool
+	relayed    uint64
+	// relayMu makes relay passes run one at a time
+	relayMu sync.Mutex
+	wake    chan struct{}
+}
+
+// NewOutbox creates a new Outbox instance
+func NewOutbox(wallets *WalletService, interval, maxAge time.Duration, alerter Alerter) *Outbox {
+	return &Outbox{
+		Wallets:  wallets,
+		Interval: interval,
+		MaxAge:   maxAge,
+		Alerter:  alerter,
+		doneSet:  make(map[string]bool),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// outboxEventID derives an event's ID from the state change it records, e.g. a deposit or transaction
+func outboxEventID(eventType, key string) string {
+	sum := sha256.Sum256([]byte(eventType + ":" + key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// loadLocked reads the outbox from the archive store the first time it is needed; o.mu must be held
+func (o *Outbox) loadLocked() error {
+	if o.loaded {
+		return nil
+	}
+	if o.Wallets.Archive != nil {
+		data, err := o.Wallets.Archive.Get(outboxKey)
+		switch {
+		case errors.Is(err, errArchiveNotFound):
+		case err != nil:
+			return errors.New("failed to read outbox: " + err.Error())
+		default:
+			var state outboxState
+			if err := json.Unmarshal(data, &state); err != nil {
+				return errors.New("failed to decode outbox: " + err.Error())
+			}
+			o.pending, o.done = state.Pending, state.Done
+			for _, id := range o.done {
+				o.doneSet[id] = true
+			}
+		}
+	}
+	o.loaded = true
+	return nil
+}
+
+// saveLocked persists and swaps in a new outbox state; o.mu must be held
+func (o *Outbox) saveLocked(pending []models.OutboxEntry, done []string) error {
+	if o.Wallets.Archive != nil {
+		data, err := json.Marshal(outboxState{Pending: pending, Done: done})
+		if err != nil {
+			return errors.New("failed to encode outbox: " + err.Error())
+		}
+		if err := o.Wallets.Archive.Put(outboxKey, data); err != nil {
+			return errors.New("failed to save outbox: " + err.Error())
+		}
+	}
+	o.pending, o.done = pending, done
+	o.doneSet = make(map[string]bool, len(done))
+	for _, id := range done {
+		o.doneSet[id] = true
+	}
+	return nil
+}
+
+// knownLocked reports whether an event is pending or was relayed recently; o.mu must be held
+func (o *Outbox) knownLocked(id string) bool {
+	if o.doneSet[id] {
+		return true
+	}
+	for _, entry := range o.pending {
+		if entry.Envelope.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Stage durably records an event of a state change before the change is committed. key identifies the
+// change, e.g. a deposit's operation ID, so staging it again is a no-op. The event is delivered to
+// in-process subscribers and published to the broker by the relay.
+func (o *Outbox) Stage(eventType, publicKey, key string, data map[string]string) error {
+	event := models.Event{
+		ID:        outboxEventID(eventType, key),
+		Type:      eventType,
+		PublicKey: publicKey,
+		Data:      data,
+		CreatedAt: time.Now().UTC(),
+	}
+	return o.add(models.OutboxEntry{
+		Envelope: eventEnvelope(o.Wallets, event),
+		Dispatch: true,
+		Publish:  o.Broker != nil,
+		StagedAt: event.CreatedAt,
+	})
+}
+
+// Enqueue records an event that was already delivered in-process, so the relay publishes it to the broker
+// in order with staged events. Events already in the outbox are ignored.
+func (o *Outbox) Enqueue(envelope models.EventEnvelope) error {
+	if o.Broker == nil {
+		return nil
+	}
+	return o.add(models.OutboxEntry{Envelope: envelope, Publish: true, StagedAt: time.Now().UTC()})
+}
+
+// add persists a new entry unless its event is already known, and wakes the relay
+func (o *Outbox) add(entry models.OutboxEntry) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.loadLocked(); err != nil {
+		return err
+	}
+	if o.knownLocked(entry.Envelope.ID) {
+		return nil
+	}
+	pending := append(append(make([]models.OutboxEntry, 0, len(o.pending)+1), o.pending...), entry)
+	if err := o.saveLocked(pending, o.done); err != nil {
+		return err
+	}
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run relays pending events whenever events are staged, and every Interval, until ctx is cancelled
+func (o *Outbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.Interval)
+	defer ticker.Stop()
+	for {
+		o.Relay()
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Relay makes one pass over the pending events, in the order they were staged: each is delivered to
+// in-process subscribers, then published to the broker. Publication stops at the first event that fails or
+// is waiting for a retry, so the broker receives events in order.
+func (o *Outbox) Relay() {
+	o.relayMu.Lock()
+	defer o.relayMu.Unlock()
+
+	o.mu.Lock()
+	if err := o.loadLocked(); err != nil {
+		o.mu.Unlock()
+		log.Printf("outbox: %v", err)
+		return
+	}
+	batch := append([]models.OutboxEntry{}, o.pending...)
+	o.mu.Unlock()
+
+	now := time.Now().UTC()
+	blocked := false
+	for i := range batch {
+		entry := &batch[i]
+		if entry.Dispatch {
+			o.Wallets.Events.Deliver(models.Event{
+				ID:        entry.Envelope.ID,
+				Type:      entry.Envelope.Type,
+				PublicKey: entry.Envelope.Wallet,
+				Data:      entry.Envelope.Data,
+				CreatedAt: entry.Envelope.OccurredAt,
+			})
+			entry.Dispatch = false
+		}
+		if !entry.Publish || blocked {
+			continue
+		}
+		if o.Broker == nil {
+			entry.Publish = false
+			continue
+		}
+		if now.Before(entry.NextAttemptAt) {
+			blocked = true
+			continue
+		}
+		if err := o.Broker.Publish(entry.Envelope); err != nil {
+			entry.Attempts++
+			entry.LastError = err.Error()
+			delay := outboxRetryDelay << min(entry.Attempts-1, 16)
+			if delay > outboxMaxRetryDelay {
+				delay = outboxMaxRetryDelay
+			}
+			entry.NextAttemptAt = now.Add(delay)
+			log.Printf("outbox: failed to publish event %s %s (attempt %d): %v", entry.Envelope.ID, entry.Envelope.Type, entry.Attempts, err)
+			blocked = true
+			continue
+		}
+		entry.Publish = false
+	}
+
+	o.mu.Lock()
+	err := o.mergeLocked(batch)
+	o.mu.Unlock()
+	if err != nil {
+		// Dispatched events stay pending and are delivered again on the next pass
+		log.Printf("outbox: %v", err)
+	}
+	o.checkBacklog()
+}
+
+// mergeLocked applies a relay pass to the pending events, keeping events staged during the pass and moving
+// relayed events to the done list; o.mu must be held
+func (o *Outbox) mergeLocked(batch []models.OutboxEntry) error {
+	processed := make(map[string]models.OutboxEntry, len(batch))
+	for _, entry := range batch {
+		processed[entry.Envelope.ID] = entry
+	}
+	pending := make([]models.OutboxEntry, 0, len(o.pending))
+	done := append([]string{}, o.done...)
+	var relayed uint64
+	for _, entry := range o.pending {
+		if updated, ok := processed[entry.Envelope.ID]; ok {
+			entry = updated
+		}
+		if !entry.Dispatch && !entry.Publish {
+			done = append(done, entry.Envelope.ID)
+			relayed++
+			continue
+		}
+		pending = append(pending, entry)
+	}
+	if len(done) > outboxDoneLimit {
+		done = done[len(done)-outboxDoneLimit:]
+	}
+	if err := o.saveLocked(pending, done); err != nil {
+		return err
+	}
+	atomic.AddUint64(&o.relayed, relayed)
+	return nil
+}
+
+// checkBacklog alerts once when the oldest pending event gets older than MaxAge, and again when the
+// backlog has drained
+func (o *Outbox) checkBacklog() {
+	status := o.Status()
+	o.mu.Lock()
+	backlogged := status.Backlogged
+	changed := backlogged != o.backlogged
+	o.backlogged = backlogged
+	o.mu.Unlock()
+	if !changed {
+		return
+	}
+	if backlogged {
+		o.Alerter.Alert("Event outbox backlogged", strconv.Itoa(status.Pending)+" events are waiting to be relayed, the oldest for "+
+			time.Duration(status.OldestAgeSeconds*float64(time.Second)).Round(time.Second).String()+". Last error: "+o.lastError()+".")
+	} else {
+		o.Alerter.Alert("Event outbox recovered", "The event outbox backlog has drained; "+strconv.Itoa(status.Pending)+" events are pending.")
+	}
+}
+
+// lastError returns the error of the oldest failing pending event
+func (o *Outbox) lastError() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, entry := range o.pending {
+		if entry.LastError != "" {
+			return entry.LastError
+		}
+	}
+	return "none"
+}
+
+// Status reports the outbox backlog and its oldest pending events
+func (o *Outbox) Status() models.OutboxStatusResponse {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	status := models.OutboxStatusResponse{
+		Pending: len(o.pending),
+		Relayed: atomic.LoadUint64(&o.relayed),
+		Entries: []models.OutboxEntry{},
+	}
+	for i, entry := range o.pending {
+		if entry.LastError != "" {
+			status.Failing++
+		}
+		if i < outboxStatusEntries {
+			status.Entries = append(status.Entries, entry)
+		}
+	}
+	if len(o.pending) > 0 {
+		oldest := o.pending[0].StagedAt
+		status.OldestStagedAt = &oldest
+		status.OldestAgeSeconds = time.Since(oldest).Seconds()
+		status.Backlogged = o.MaxAge > 0 && time.Since(oldest) > o.MaxAge
+	}
+	return status
+}
+
+// stageEvent stages an event of a state change in the Outbox when one is configured, and otherwise
+// publishes it directly
+func (s *WalletService) stageEvent(eventType, publicKey, key string, data map[string]string) error {
+	if s.Outbox == nil {
+		s.Events.Publish(eventType, publicKey, data)
+		return nil
+	}
+	return s.Outbox.Stage(eventType, publicKey, key, data)
+}
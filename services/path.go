@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/txnbuild"
+)
+
+// defaultPathSlippagePercent bounds how much more than the quoted source amount a path payment may spend
+const defaultPathSlippagePercent = 1.0
+
+// assetCode matches the codes the protocol allows: 1 to 12 ASCII letters or digits
+var assetCode = regexp.MustCompile(`^[A-Za-z0-9]{1,12}$`)
+
+// parseAsset parses "native" or a canonical CODE:ISSUER asset string, checking the code's format and the
+// issuer's checksum
+func parseAsset(canonical string) (txnbuild.Asset, error) {
+	if canonical == "native" || strings.EqualFold(canonical, "xlm") {
+		return txnbuild.NativeAsset{}, nil
+	}
+	code, issuer, ok := strings.Cut(canonical, ":")
+	if !ok {
+		return nil, errors.New("asset must be native or CODE:ISSUER")
+	}
+	if !assetCode.MatchString(code) {
+		return nil, errors.New("asset code must be 1 to 12 letters or digits")
+	}
+	if !strkey.IsValidEd25519PublicKey(issuer) {
+		return nil, errors.New("asset issuer must be a G... account")
+	}
+	return txnbuild.ParseAssetString(canonical)
+}
+
+// assetString returns the canonical "native" or CODE:ISSUER form of an asset
+func assetString(asset txnbuild.Asset) string {
+	if asset.IsNative() {
+		return "native"
+	}
+	return asset.GetCode() + ":" + asset.GetIssuer()
+}
+
+// pathAssets converts the intermediate hops of a Horizon path into txnbuild assets
+func pathAssets(path []hProtocol.Asset) []txnbuild.Asset {
+	assets := make([]txnbuild.Asset, 0, len(path))
+	for _, hop := range path {
+		if hop.Type == "native" {
+			assets = append(assets, txnbuild.NativeAsset{})
+			continue
+		}
+		assets = append(assets, txnbuild.CreditAsset{Code: hop.Code, Issuer: hop.Issuer})
+	}
+	return assets
+}
+
+// withSlippage returns value increased by percent, rounded up to the next stroop
+func withSlippage(value string, percent float64) (string, error) {
+	stroops, err := amount.ParseInt64(value)
+	if err != nil {
+		return "", err
+	}
+	return amount.StringFromInt64(int64(math.Ceil(float64(stroops) * (1 + percent/100)))), nil
+}
+
+// withoutSlippage returns value decreased by percent, rounded down to the previous stroop
+func withoutSlippage(value string, percent float64) (string, error) {
+	stroops, err := amount.ParseInt64(value)
+	if err != nil {
+		return "", err
+	}
+	return amount.StringFromInt64(int64(math.Floor(float64(stroops) * (1 - percent/100)))), nil
+}
+
+// horizonAssetType returns the Horizon asset type of an asset
+func horizonAssetType(asset txnbuild.Asset) horizonclient.AssetType {
+	if asset.IsNative() {
+		return horizonclient.AssetTypeNative
+	}
+	if len(asset.GetCode()) > 4 {
+		return horizonclient.AssetType12
+	}
+	return horizonclient.AssetType4
+}
+
+// findStrictReceivePath asks Horizon for the cheapest path that delivers destAmount of destAsset
+// while spending sendAsset
+func (s *WalletService) findStrictReceivePath(sendAsset, destAsset txnbuild.Asset, destAmount string) (hProtocol.Path, error) {
+	request := horizonclient.PathsRequest{
+		DestinationAssetType: horizonAssetType(destAsset),
+		DestinationAmount:    destAmount,
+		SourceAssets:         assetString(sendAsset),
+	}
+	if !destAsset.IsNative() {
+		request.DestinationAssetCode = destAsset.GetCode()
+		request.DestinationAssetIssuer = destAsset.GetIssuer()
+	}
+
+	paths, err := s.Config.HorizonClient.StrictReceivePaths(request)
+	if err != nil {
+		return hProtocol.Path{}, errors.New("failed to find payment path: " + err.Error())
+	}
+
+	var best *hProtocol.Path
+	var bestAmount int64
+	for i, path := range paths.Embedded.Records {
+		sourceAmount, err := amount.ParseInt64(path.SourceAmount)
+		if err != nil {
+			continue
+		}
+		if best == nil || sourceAmount < bestAmount {
+			best = &paths.Embedded.Records[i]
+			bestAmount = sourceAmount
+		}
+	}
+	if best == nil {
+		return hProtocol.Path{}, errors.New("no payment path found")
+	}
+	return *best, nil
+}
+
+// findStrictSendPath asks Horizon for the path that delivers the most destAsset for exactly sendAmount
+// of sendAsset
+func (s *WalletService) findStrictSendPath(sendAsset, destAsset txnbuild.Asset, sendAmount string) (hProtocol.Path, error) {
+	request := horizonclient.StrictSendPathsRequest{
+		SourceAssetType:   horizonAssetType(sendAsset),
+		SourceAmount:      sendAmount,
+		DestinationAssets: assetString(destAsset),
+	}
+	if !sendAsset.IsNative() {
+		request.SourceAssetCode = sendAsset.GetCode()
+		request.SourceAssetIssuer = sendAsset.GetIssuer()
+	}
+
+	paths, err := s.Config.HorizonClient.StrictSendPaths(request)
+	if err != nil {
+		return hProtocol.Path{}, errors.New("failed to find payment path: " + err.Error())
+	}
+
+	var best *hProtocol.Path
+	var bestAmount int64
+	for i, path := range paths.Embedded.Records {
+		destinationAmount, err := amount.ParseInt64(path.DestinationAmount)
+		if err != nil {
+			continue
+		}
+		if best == nil || destinationAmount > bestAmount {
+			best = &paths.Embedded.Records[i]
+			bestAmount = destinationAmount
+		}
+	}
+	if best == nil {
+		return hProtocol.Path{}, errors.New("no payment path found")
+	}
+	return *best, nil
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// pathPaymentParties validates the sender, recipient and assets shared by both path payment flavours
+func (s *WalletService) pathPaymentParties(fromSecretKey, toPublicKey, sourceAsset, destinationAsset string) (*keypair.Full, txnbuild.Asset, txnbuild.Asset, error) {
+	senderKP, err := keypair.ParseFull(fromSecretKey)
+	if err != nil {
+		return nil, nil, nil, errors.New("invalid sender secret key")
+	}
+	if s.isDeactivated(senderKP.Address()) {
+		return nil, nil, nil, errors.New("sender wallet is deactivated")
+	}
+	if err := s.checkNotFrozen(senderKP.Address()); err != nil {
+		return nil, nil, nil, err
+	}
+	if _, _, err := parseDestination(toPublicKey); err != nil {
+		return nil, nil, nil, errors.New("invalid recipient public key")
+	}
+	sendAsset, err := parseAsset(sourceAsset)
+	if err != nil {
+		return nil, nil, nil, errors.New("invalid source asset: " + err.Error())
+	}
+	destAsset, err := parseAsset(destinationAsset)
+	if err != nil {
+		return nil, nil, nil, errors.New("invalid destination asset: " + err.Error())
+	}
+	for _, asset := range []txnbuild.Asset{sendAsset, destAsset} {
+		if err := s.checkAssetPermitted(assetString(asset)); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return senderKP, sendAsset, destAsset, nil
+}
+
+// submitOperation screens, signs and submits a single payment operation from senderKP with memo, made from
+// device when a client asked for it
+func (s *WalletService) submitOperation(senderKP *keypair.Full, op txnbuild.Operation, memo txnbuild.Memo, device models.DeviceInfo) (string, error) {
+	ops := []txnbuild.Operation{op}
+	reservation, err := s.screenPayments(senderKP.Address(), ops, memo, device)
+	if err != nil {
+		return "", err
+	}
+	tx, err := s.buildTransaction(senderKP.Address(), txnbuild.TransactionParams{
+		Operations:    ops,
+		Memo:          memo,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+	}, senderKP)
+	if err != nil {
+		s.releasePayments(reservation)
+		return "", err
+	}
+
+	resp, err := s.submitPayments(reservation, tx, nil, []*keypair.Full{senderKP})
+	if err != nil {
+		return "", err
+	}
+	return resp.Hash, nil
+}
+
+// parseSlippage parses an optional maximum slippage percentage, defaulting to defaultPathSlippagePercent
+func parseSlippage(value string) (float64, error) {
+	if value == "" {
+		return defaultPathSlippagePercent, nil
+	}
+	slippage, err := strconv.ParseFloat(value, 64)
+	if err != nil || slippage < 0 || slippage > 100 {
+		return 0, errors.New("invalid max slippage: must be between 0 and 100")
+	}
+	return slippage, nil
+}
+
+// pathStrings returns the canonical form of each intermediate asset of a path
+func pathStrings(path []txnbuild.Asset) []string {
+	hops := make([]string, 0, len(path))
+	for _, hop := range path {
+		hops = append(hops, assetString(hop))
+	}
+	return hops
+}
+
+// PathPaymentStrictSend spends exactly SendAmount of the source asset and delivers at least DestMin of
+// the destination asset along the best path Horizon quotes
+func (s *WalletService) PathPaymentStrictSend(req models.StrictSendRequest) (*models.PathPaymentResponse, error) {
+	senderKP, sendAsset, destAsset, err := s.pathPaymentParties(req.FromSecretKey, req.ToPublicKey, req.SourceAsset, req.DestinationAsset)
+	if err != nil {
+		return nil, err
+	}
+	memo, err := parseMemo(req.Memo, req.MemoType)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTransferAmount(req.SendAmount, assetString(sendAsset)); err != nil {
+		return nil, err
+	}
+	slippage, err := parseSlippage(req.MaxSlippagePercent)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := s.findStrictSendPath(sendAsset, destAsset, req.SendAmount)
+	if err != nil {
+		return nil, err
+	}
+	destMin := req.DestMin
+	if destMin == "" {
+		if destMin, err = withoutSlippage(path.DestinationAmount, slippage); err != nil {
+			return nil, errors.New("failed to compute minimum destination amount: " + err.Error())
+		}
+	}
+	if _, err := parseAmount("dest_min", destMin); err != nil {
+		return nil, err
+	}
+
+	hops := pathAssets(path.Path)
+	hash, err := s.submitOperation(senderKP, &txnbuild.PathPaymentStrictSend{
+		SendAsset:   sendAsset,
+		SendAmount:  req.SendAmount,
+		Destination: req.ToPublicKey,
+		DestAsset:   destAsset,
+		DestMin:     destMin,
+		Path:        hops,
+	}, memo, req.Device)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PathPaymentResponse{
+		TransactionHash:  hash,
+		SourceAsset:      assetString(sendAsset),
+		DestinationAsset: assetString(destAsset),
+		SendAmount:       req.SendAmount,
+		DestMin:          destMin,
+		Path:             pathStrings(hops),
+		Message:          "Path payment completed successfully",
+	}, nil
+}
+
+// PathPaymentStrictReceive delivers exactly DestAmount of the destination asset while spending at most
+// SendMax of the source asset along the cheapest path Horizon quotes
+func (s *WalletService) PathPaymentStrictReceive(req models.StrictReceiveRequest) (*models.PathPaymentResponse, error) {
+	senderKP, sendAsset, destAsset, err := s.pathPaymentParties(req.FromSecretKey, req.ToPublicKey, req.SourceAsset, req.DestinationAsset)
+	if err != nil {
+		return nil, err
+	}
+	memo, err := parseMemo(req.Memo, req.MemoType)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTransferAmount(req.DestAmount, assetString(destAsset)); err != nil {
+		return nil, err
+	}
+	slippage, err := parseSlippage(req.MaxSlippagePercent)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := s.findStrictReceivePath(sendAsset, destAsset, req.DestAmount)
+	if err != nil {
+		return nil, err
+	}
+	sendMax := req.SendMax
+	if sendMax == "" {
+		if sendMax, err = withSlippage(path.SourceAmount, slippage); err != nil {
+			return nil, errors.New("failed to compute max send amount: " + err.Error())
+		}
+	}
+	if _, err := parseAmount("send_max", sendMax); err != nil {
+		return nil, err
+	}
+
+	hops := pathAssets(path.Path)
+	hash, err := s.submitOperation(senderKP, &txnbuild.PathPaymentStrictReceive{
+		SendAsset:   sendAsset,
+		SendMax:     sendMax,
+		Destination: req.ToPublicKey,
+		DestAsset:   destAsset,
+		DestAmount:  req.DestAmount,
+		Path:        hops,
+	}, memo, req.Device)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PathPaymentResponse{
+		TransactionHash:  hash,
+		SourceAsset:      assetString(sendAsset),
+		DestinationAsset: assetString(destAsset),
+		DestAmount:       req.DestAmount,
+		SendMax:          sendMax,
+		Path:             pathStrings(hops),
+		Message:          "Path payment completed successfully",
+	}, nil
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+)
+
+const (
+	// defaultPaymentHistoryLimit and maxPaymentHistoryLimit bound a page of payment history
+	defaultPaymentHistoryLimit = 50
+	maxPaymentHistoryLimit     = 200
+	// maxPaymentHistoryPages caps how many Horizon pages one request scans for matching payments, so a
+	// narrow filter over a long history returns a partial page with a cursor instead of scanning it all
+	maxPaymentHistoryPages = 10
+)
+
+// paymentHistoryFilter is a validated PaymentHistoryFilter
+type paymentHistoryFilter struct {
+	asset, direction, counterparty string
+	from, to                       time.Time
+	// minAmount and maxAmount are in stroops; zero leaves the bound open
+	minAmount, maxAmount int64
+}
+
+// parsePaymentHistoryFilter validates a payment history filter
+func parsePaymentHistoryFilter(filter models.PaymentHistoryFilter) (paymentHistoryFilter, error) {
+	parsed := paymentHistoryFilter{direction: filter.Direction, counterparty: filter.Counterparty}
+	if filter.Asset != "" {
+		asset, err := parseAsset(filter.Asset)
+		if err != nil {
+			return parsed, errors.New("invalid asset")
+		}
+		parsed.asset = assetString(asset)
+	}
+	if filter.Direction != "" && filter.Direction != models.DirectionSent && filter.Direction != models.DirectionReceived {
+		return parsed, errors.New("invalid direction: must be sent or received")
+	}
+	if filter.Counterparty != "" {
+		if _, err := keypair.ParseAddress(filter.Counterparty); err != nil {
+			return parsed, errors.New("invalid counterparty: must be a G... account")
+		}
+	}
+	var err error
+	if filter.From != "" {
+		if parsed.from, err = time.Parse(time.RFC3339, filter.From); err != nil {
+			return parsed, errors.New("invalid from: must be an RFC 3339 timestamp")
+		}
+	}
+	if filter.To != "" {
+		if parsed.to, err = time.Parse(time.RFC3339, filter.To); err != nil {
+			return parsed, errors.New("invalid to: must be an RFC 3339 timestamp")
+		}
+	}
+	if !parsed.from.IsZero() && !parsed.to.IsZero() && parsed.to.Before(parsed.from) {
+		return parsed, errors.New("invalid date range: from must not be after to")
+	}
+	if filter.MinAmount != "" {
+		if parsed.minAmount, err = amount.ParseInt64(filter.MinAmount); err != nil || parsed.minAmount <= 0 {
+			return parsed, errors.New("invalid min_amount: must be a positive amount")
+		}
+	}
+	if filter.MaxAmount != "" {
+		if parsed.maxAmount, err = amount.ParseInt64(filter.MaxAmount); err != nil || parsed.maxAmount <= 0 {
+			return parsed, errors.New("invalid max_amount: must be a positive amount")
+		}
+	}
+	if parsed.minAmount > 0 && parsed.maxAmount > 0 && parsed.maxAmount < parsed.minAmount {
+		return parsed, errors.New("invalid amount range: min_amount must not exceed max_amount")
+	}
+	return parsed, nil
+}
+
+// matches reports whether a payment passes the filter
+func (f paymentHistoryFilter) matches(record models.PaymentRecord) bool {
+	switch {
+	case f.asset != "" && record.Asset != f.asset:
+		return false
+	case f.direction != "" && record.Direction != f.direction:
+		return false
+	case f.counterparty != "" && record.Counterparty != f.counterparty:
+		return false
+	case !f.from.IsZero() && record.CreatedAt.Before(f.from):
+		return false
+	case !f.to.IsZero() && record.CreatedAt.After(f.to):
+		return false
+	}
+	if f.minAmount > 0 || f.maxAmount > 0 {
+		stroops, err := amount.ParseInt64(record.Amount)
+		if err != nil || (f.minAmount > 0 && stroops < f.minAmount) || (f.maxAmount > 0 && stroops > f.maxAmount) {
+			return false
+		}
+	}
+	return true
+}
+
+// WalletPayments returns a page of the payments, path payments and account creations touching a wallet,
+// newest first and narrowed by filter. Horizon cannot filter payments itself, so pages are scanned until
+// the page is full, the history is older than the date range or maxPaymentHistoryPages were read. Wallets
+// backfilled into the local history store are queried there instead.
+func (s *WalletService) WalletPayments(publicKey string, filter models.PaymentHistoryFilter) (*models.PaymentHistoryResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultPaymentHistoryLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxPaymentHistoryLimit {
+		return nil, errors.New("invalid limit: must be between 1 and 200")
+	}
+	parsed, err := parsePaymentHistoryFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if s.historyReady(publicKey) {
+		return s.History.payments(publicKey, parsed, filter.Cursor, filter.Limit)
+	}
+
+	response := &models.PaymentHistoryResponse{Payments: []models.PaymentRecord{}}
+	cursor := filter.Cursor
+	for page := 0; page < maxPaymentHistoryPages; page++ {
+		payments, err := s.Config.HorizonClient.Payments(horizonclient.OperationRequest{
+			ForAccount: publicKey,
+			Cursor:     cursor,
+			Order:      horizonclient.OrderDesc,
+			Limit:      maxPaymentHistoryLimit,
+		})
+		if err != nil {
+			if herr, ok := err.(*horizonclient.Error); ok && herr.Response.StatusCode == http.StatusNotFound {
+				return nil, errors.New("wallet not found")
+			}
+			return nil, errors.New("failed to fetch payments: " + err.Error())
+		}
+		for _, op := range payments.Embedded.Records {
+			cursor = op.PagingToken()
+			record, ok := paymentRecord(op, publicKey)
+			if !ok || !op.IsTransactionSuccessful() {
+				continue
+			}
+			if !parsed.from.IsZero() && record.CreatedAt.Before(parsed.from) {
+				// Everything further back is older still
+				return response, nil
+			}
+			if !parsed.matches(record) {
+				continue
+			}
+			response.Payments = append(response.Payments, record)
+			if len(response.Payments) == filter.Limit {
+				response.NextCursor = cursor
+				return response, nil
+			}
+		}
+		if len(payments.Embedded.Records) < maxPaymentHistoryLimit {
+			return response, nil
+		}
+	}
+	response.NextCursor = cursor
+	return response, nil
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// paymentDebit is what one payment-type operation takes from its sender
+type paymentDebit struct {
+	sender, destination, asset string
+	stroops                    int64
+	// muxedID is set when the operation pays a muxed address of destination
+	muxedID   string
+	destAsset txnbuild.Asset
+	claimable bool
+}
+
+// paymentDebits returns the debits of the payments, path payments and claimable balances among the operations
+// of a transaction from sourceID, each in the asset it takes from its sender: a strict-receive path payment
+// debits up to its send max. Payments a wallet makes to itself move nothing out of it and are left out.
+func paymentDebits(sourceID string, ops []txnbuild.Operation) ([]paymentDebit, error) {
+	var debits []paymentDebit
+	for _, op := range ops {
+		var value, destination string
+		var asset, destAsset txnbuild.Asset
+		claimable := false
+		switch op := op.(type) {
+		case *txnbuild.Payment:
+			value, asset, destAsset, destination = op.Amount, op.Asset, op.Asset, op.Destination
+		case *txnbuild.PathPaymentStrictReceive:
+			value, asset, destAsset, destination = op.SendMax, op.SendAsset, op.DestAsset, op.Destination
+		case *txnbuild.PathPaymentStrictSend:
+			value, asset, destAsset, destination = op.SendAmount, op.SendAsset, op.DestAsset, op.Destination
+		case *txnbuild.CreateClaimableBalance:
+			value, asset, destAsset, claimable = op.Amount, op.Asset, op.Asset, true
+			if len(op.Destinations) > 0 {
+				destination = op.Destinations[0].Destination
+			}
+		default:
+			continue
+		}
+		sender := sourceID
+		if source := op.GetSourceAccount(); source != "" {
+			sender = source
+		}
+		// Muxed senders and recipients are accounted to their underlying account
+		if base, _, err := parseDestination(sender); err == nil {
+			sender = base
+		}
+		var muxedID string
+		if base, id, err := parseDestination(destination); err == nil {
+			destination, muxedID = base, id
+		}
+		if sender == destination {
+			continue
+		}
+		stroops, err := amount.ParseInt64(value)
+		if err != nil {
+			return nil, errors.New("invalid amount: " + err.Error())
+		}
+		debits = append(debits, paymentDebit{
+			sender:      sender,
+			destination: destination,
+			asset:       assetString(asset),
+			stroops:     stroops,
+			muxedID:     muxedID,
+			destAsset:   destAsset,
+			claimable:   claimable,
+		})
+	}
+	return debits, nil
+}
+
+// paymentReservation is what screenPayments reserved for the payments of one transaction
+type paymentReservation struct {
+	spent []*spendEntry
+}
+
+// screenPayments applies the checks every payment out of a wallet must pass, whichever flow builds it, to
+// the operations of a transaction from sourceID with memo before it is built: each recipient must be ready
+// to receive its payment, as checkDestination verifies, the fraud scorer must not flag any payment made
+// from device, the senders must hold the debits on top of what they owe through pending internal
+// transfers, and the debits must fit their senders' spend limits. The reservation is given back with
+// releasePayments when the transaction is not submitted after all; submitPayments gives it back when the
+// transaction failed.
+func (s *WalletService) screenPayments(sourceID string, ops []txnbuild.Operation, memo txnbuild.Memo, device models.DeviceInfo) (*paymentReservation, error) {
+	debits, err := paymentDebits(sourceID, ops)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkPaymentDestinations(debits, memo); err != nil {
+		return nil, err
+	}
+	if err := s.scorePayments(debits, device); err != nil {
+		return nil, err
+	}
+	if err := s.checkPendingOutflows(debits); err != nil {
+		return nil, err
+	}
+	return s.reserveDebits(debits)
+}
+
+// reservePayments only checks the payments of a transaction from sourceID against pending internal
+// outflows and reserves them against the spend limits, for the transfer flows that check and score their
+// transfers before building the operations, so that a flagged transfer can be held for review
+func (s *WalletService) reservePayments(sourceID string, ops []txnbuild.Operation) (*paymentReservation, error) {
+	debits, err := paymentDebits(sourceID, ops)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkPendingOutflows(debits); err != nil {
+		return nil, err
+	}
+	return s.reserveDebits(debits)
+}
+
+// scorePayments scores every payment with the fraud scorer and refuses them all if any is flagged
+func (s *WalletService) scorePayments(debits []paymentDebit, device models.DeviceInfo) error {
+	if s.FraudScorer == nil {
+		return nil
+	}
+	for _, debit := range debits {
+		assessment, flagged := s.assess(s.scoringContext(TransferContext{
+			SenderPublicKey:      debit.sender,
+			DestinationPublicKey: debit.destination,
+			Amount:               amount.StringFromInt64(debit.stroops),
+			SourceAsset:          debit.asset,
+			DestinationAsset:     assetString(debit.destAsset),
+			Device:               device,
+		}))
+		if flagged {
+			return errors.New("payment flagged by fraud screening: " + strings.Join(assessment.Reasons, "; "))
+		}
+	}
+	return nil
+}
+
+func (s *WalletService) reserveDebits(debits []paymentDebit) (*paymentReservation, error) {
+	spent, err := s.reserveSpend(debits)
+	if err != nil {
+		return nil, err
+	}
+	return &paymentReservation{spent: spent}, nil
+}
+
+// checkPaymentDestinations runs checkDestination for the recipient of every payment, once per recipient and
+// asset. Claimable balances need no account or trustline on the recipient's side and are skipped.
+func (s *WalletService) checkPaymentDestinations(debits []paymentDebit, memo txnbuild.Memo) error {
+	checked := make(map[string]bool)
+	for _, debit := range debits {
+		key := debit.destination + "/" + debit.muxedID + "/" + assetString(debit.destAsset)
+		if debit.claimable || checked[key] {
+			continue
+		}
+		checked[key] = true
+		err := s.checkDestination(&preparedTransfer{
+			destination: debit.destination,
+			muxedID:     debit.muxedID,
+			memo:        memo,
+			sendAsset:   debit.destAsset,
+			destAsset:   debit.destAsset,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releasePayments gives back the reservation of payments that were not applied
+func (s *WalletService) releasePayments(reservation *paymentReservation) {
+	s.releaseSpend(reservation.spent)
+}
+
+// submitPayments submits a transaction whose payments screenPayments reserved. The reservation is kept
+// unless the transaction definitely was not applied.
+func (s *WalletService) submitPayments(reservation *paymentReservation, tx *txnbuild.Transaction, feeAccount *keypair.Full, signers []*keypair.Full) (submittedTransaction, error) {
+	resp, err := s.submitFeeBumped(tx, feeAccount, signers)
+	if err != nil && definitelyNotApplied(err) {
+		s.releasePayments(reservation)
+	}
+	return resp, err
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/protocols/horizon/operations"
+)
+
+// canonicalAsset returns the "native" or CODE:ISSUER form of a Horizon asset
+func canonicalAsset(asset base.Asset) string {
+	if asset.Type == "native" {
+		return "native"
+	}
+	return asset.Code + ":" + asset.Issuer
+}
+
+// paymentRecord normalizes a Horizon payment-like operation into a PaymentRecord seen from account.
+// It reports false for operations that do not move funds.
+func paymentRecord(op operations.Operation, account string) (models.PaymentRecord, bool) {
+	var record models.PaymentRecord
+	var opBase operations.Base
+	switch payment := op.(type) {
+	case operations.Payment:
+		opBase = payment.Base
+		record.From, record.To, record.Asset, record.Amount = payment.From, payment.To, canonicalAsset(payment.Asset), payment.Amount
+	case operations.PathPayment:
+		opBase = payment.Base
+		record.From, record.To, record.Asset, record.Amount = payment.From, payment.To, canonicalAsset(payment.Asset), payment.Amount
+	case operations.PathPaymentStrictSend:
+		opBase = payment.Base
+		record.From, record.To, record.Asset, record.Amount = payment.From, payment.To, canonicalAsset(payment.Asset), payment.Amount
+	case operations.CreateAccount:
+		opBase = payment.Base
+		record.From, record.To, record.Asset, record.Amount = payment.Funder, payment.Account, "native", payment.StartingBalance
+	default:
+		return record, false
+	}
+
+	record.ID = opBase.ID
+	record.Type = opBase.Type
+	record.TransactionHash = opBase.TransactionHash
+	record.CreatedAt = opBase.LedgerCloseTime
+	record.Direction, record.Counterparty = models.DirectionReceived, record.From
+	if record.From == account {
+		record.Direction, record.Counterparty = models.DirectionSent, record.To
+	}
+	return record, true
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// maxPayoutRows bounds the size of a single payout CSV
+const maxPayoutRows = 10000
+
+// PayoutService validates payout CSVs and executes them asynchronously in chunked transactions
+type PayoutService struct {
+	Wallets *WalletService
+
+	mu      sync.Mutex
+	batches map[string]*models.PayoutBatchResponse
+}
+
+// NewPayoutService creates a new PayoutService instance
+func NewPayoutService(wallets *WalletService) *PayoutService {
+	return &PayoutService{
+		Wallets: wallets,
+		batches: make(map[string]*models.PayoutBatchResponse),
+	}
+}
+
+// parsePayoutCSV reads destination,amount[,asset] rows, skipping an optional header line, and validates
+// each row; invalid rows are kept with their error so the caller can see every problem at once
+func (s *PayoutService) parsePayoutCSV(r io.Reader) ([]models.PayoutRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows := []models.PayoutRow{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("invalid payout CSV: " + err.Error())
+		}
+		if line == 1 && len(record) > 0 && strings.EqualFold(strings.TrimSpace(record[0]), "destination") {
+			continue
+		}
+		if len(rows) == maxPayoutRows {
+			return nil, errors.New("invalid payout CSV: more than " + strconv.Itoa(maxPayoutRows) + " rows")
+		}
+
+		row := models.PayoutRow{Line: line, Status: models.PayoutRowQueued}
+		if len(record) < 2 || len(record) > 3 {
+			row.Status, row.Error = models.PayoutRowInvalid, "expected destination,amount[,asset]"
+			rows = append(rows, row)
+			continue
+		}
+		row.Destination, row.Amount = strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		row.Asset = assetString(s.Wallets.Config.USDCAsset)
+		if len(record) == 3 && strings.TrimSpace(record[2]) != "" {
+			row.Asset = strings.TrimSpace(record[2])
+		}
+
+		if _, err := keypair.ParseAddress(row.Destination); err != nil {
+			row.Status, row.Error = models.PayoutRowInvalid, "invalid destination public key"
+		} else if asset, err := parseAsset(row.Asset); err != nil {
+			row.Status, row.Error = models.PayoutRowInvalid, "invalid asset"
+		} else if err := s.Wallets.checkAssetPermitted(assetString(asset)); err != nil {
+			row.Status, row.Error = models.PayoutRowInvalid, err.Error()
+		} else if err := s.Wallets.checkTransferAmount(row.Amount, assetString(asset)); err != nil {
+			row.Status, row.Error = models.PayoutRowInvalid, err.Error()
+		} else {
+			row.Asset = assetString(asset)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("invalid payout CSV: no rows")
+	}
+	return rows, nil
+}
+
+// CreatePayoutBatch validates a payout CSV and queues its valid rows for asynchronous execution. Without the
+// sender's secret key, the request must be authenticated as the tenant of the managed sender wallet.
+func (s *PayoutService) CreatePayoutBatch(tenantID string, authenticated bool, req models.CreatePayoutRequest, file io.Reader) (*models.PayoutBatchResponse, error) {
+	if _, err := keypair.ParseAddress(req.FromPublicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	signerTenant := ""
+	if authenticated {
+		signerTenant = tenantID
+	}
+	signer, err := s.Wallets.authorizedSigner(signerTenant, req.FromPublicKey, req.FromSecretKey)
+	if err != nil {
+		return nil, err
+	}
+	if s.Wallets.isDeactivated(req.FromPublicKey) {
+		return nil, errors.New("sender wallet is deactivated")
+	}
+	rows, err := s.parsePayoutCSV(file)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &models.PayoutBatchResponse{
+		ID:           newID(),
+		TenantID:     tenantID,
+		From:         req.FromPublicKey,
+		Status:       models.PayoutBatchQueued,
+		TotalRows:    len(rows),
+		Transactions: []string{},
+		Rows:         rows,
+		CreatedAt:    time.Now().UTC(),
+	}
+	for _, row := range rows {
+		if row.Status == models.PayoutRowInvalid {
+			batch.InvalidRows++
+		}
+	}
+
+	s.mu.Lock()
+	s.batches[batch.ID] = batch
+	result := s.snapshotLocked(batch)
+	s.mu.Unlock()
+
+	go s.execute(batch, signer)
+	return result, nil
+}
+
+// execute submits a batch's queued rows in transactions of up to maxOperationsPerTransaction payments
+func (s *PayoutService) execute(batch *models.PayoutBatchResponse, signer *keypair.Full) {
+	s.mu.Lock()
+	batch.Status = models.PayoutBatchProcessing
+	var queued []int
+	for i, row := range batch.Rows {
+		if row.Status == models.PayoutRowQueued {
+			queued = append(queued, i)
+		}
+	}
+	s.mu.Unlock()
+
+	for start := 0; start < len(queued); start += maxOperationsPerTransaction {
+		end := start + maxOperationsPerTransaction
+		if end > len(queued) {
+			end = len(queued)
+		}
+		s.executeChunk(batch, signer, queued[start:end])
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	batch.CompletedAt = &now
+	switch {
+	case batch.Failed == 0 && batch.InvalidRows == 0:
+		batch.Status = models.PayoutBatchCompleted
+	case batch.Succeeded == 0:
+		batch.Status = models.PayoutBatchFailed
+	default:
+		batch.Status = models.PayoutBatchCompletedWithErrors
+	}
+}
+
+// executeChunk submits the rows at indexes as one transaction and records each row's outcome
+func (s *PayoutService) executeChunk(batch *models.PayoutBatchResponse, signer *keypair.Full, indexes []int) {
+	s.mu.Lock()
+	rows := make([]models.PayoutRow, len(indexes))
+	for i, index := range indexes {
+		rows[i] = batch.Rows[index]
+	}
+	s.mu.Unlock()
+
+	hash, results, err := s.submitChunk(signer, rows)
+	if err != nil {
+		log.Printf("payout batch %s: chunk failed: %v", batch.ID, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if hash != "" && err == nil {
+		batch.Transactions = append(batch.Transactions, hash)
+	}
+	for i, index := range indexes {
+		row := &batch.Rows[index]
+		if i < len(results) {
+			row.OperationID, row.ResultCode = results[i].OperationID, results[i].ResultCode
+		}
+		if err != nil {
+			row.Status, row.Error = models.PayoutRowFailed, err.Error()
+			if row.ResultCode != "" {
+				row.ErrorDetail = ExplainResultCodes("", []string{row.ResultCode})
+			} else {
+				row.ErrorDetail = ExplainError(err)
+			}
+			batch.Failed++
+			continue
+		}
+		row.Status, row.TransactionHash = models.PayoutRowSucceeded, hash
+		batch.Succeeded++
+	}
+}
+
+func (s *PayoutService) submitChunk(signer *keypair.Full, rows []models.PayoutRow) (string, []models.OperationResult, error) {
+	ops := make([]txnbuild.Operation, 0, len(rows))
+	for _, row := range rows {
+		asset, err := parseAsset(row.Asset)
+		if err != nil {
+			return "", nil, errors.New("failed to parse payout asset: " + err.Error())
+		}
+		ops = append(ops, &txnbuild.Payment{Destination: row.Destination, Amount: row.Amount, Asset: asset})
+	}
+
+	reservation, err := s.Wallets.screenPayments(signer.Address(), ops, nil, models.DeviceInfo{})
+	if err != nil {
+		return "", nil, err
+	}
+	tx, err := s.Wallets.buildTransaction(signer.Address(), txnbuild.TransactionParams{
+		Operations:    ops,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+	}, signer)
+	if err != nil {
+		s.Wallets.releasePayments(reservation)
+		return "", nil, err
+	}
+
+	resp, err := s.Wallets.submitPayments(reservation, tx, nil, []*keypair.Full{signer})
+	if err != nil {
+		return "", s.Wallets.operationResults("", err), err
+	}
+	return resp.Hash, s.Wallets.operationResults(resp.Hash, nil), nil
+}
+
+// GetPayoutBatch returns a payout batch of a tenant with the current status of every row
+func (s *PayoutService) GetPayoutBatch(tenantID, id string) (*models.PayoutBatchResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	batch, ok := s.batches[id]
+	if !ok || batch.TenantID != tenantID {
+		return nil, errors.New("payout batch not found")
+	}
+	return s.snapshotLocked(batch), nil
+}
+
+func (s *PayoutService) snapshotLocked(batch *models.PayoutBatchResponse) *models.PayoutBatchResponse {
+	result := *batch
+	result.Rows = append([]models.PayoutRow(nil), batch.Rows...)
+	result.Transactions = append([]string{}, batch.Transactions...)
+	return &result
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+)
+
+// PoolService lets one custodied wallet serve many users through muxed (M...) sub-accounts. The funds stay
+// in the pooled account, so users cost no reserve of their own; the service attributes them to sub-accounts
+// from the muxed ID payments are sent to and from.
+type PoolService struct {
+	Wallets *WalletService
+
+	mu sync.Mutex
+	// subAccounts maps muxed address -> sub-account
+	subAccounts map[string]*subAccount
+}
+
+// subAccount is a muxed sub-account with its balances in stroops per asset
+type subAccount struct {
+	models.SubAccountResponse
+	balances map[string]int64
+}
+
+// NewPoolService creates a new PoolService instance
+func NewPoolService(wallets *WalletService) *PoolService {
+	return &PoolService{Wallets: wallets, subAccounts: make(map[string]*subAccount)}
+}
+
+// snapshotLocked returns a sub-account with its balances formatted
+func (a *subAccount) snapshotLocked() *models.SubAccountResponse {
+	result := a.SubAccountResponse
+	result.Balances = make(map[string]string, len(a.balances))
+	for asset, stroops := range a.balances {
+		result.Balances[asset] = amount.StringFromInt64(stroops)
+	}
+	return &result
+}
+
+// CreateSubAccount allocates a muxed address on one of the tenant's custodied wallets for a user
+func (s *PoolService) CreateSubAccount(tenantID string, req models.CreateSubAccountRequest) (*models.SubAccountResponse, error) {
+	if owner, ok := s.Wallets.Registry.TenantOf(req.PoolWallet); !ok || owner != tenantID {
+		return nil, errors.New("invalid pool_wallet: sub-accounts require a custodied wallet of this tenant")
+	}
+	if s.Wallets.isDeactivated(req.PoolWallet) {
+		return nil, errors.New("wallet is deactivated")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, account := range s.subAccounts {
+		if account.PoolWallet == req.PoolWallet && account.UserID == req.UserID {
+			return nil, errors.New("sub-account already exists for user " + req.UserID)
+		}
+	}
+	var address string
+	var id uint64
+	for address == "" || s.subAccounts[address] != nil {
+		var buf [8]byte
+		if _, err := rand.Read(buf[:]); err != nil {
+			return nil, errors.New("failed to generate muxed ID: " + err.Error())
+		}
+		id = binary.BigEndian.Uint64(buf[:])
+		muxed, err := xdr.MuxedAccountFromAccountId(req.PoolWallet, id)
+		if err != nil {
+			return nil, errors.New("failed to build muxed address: " + err.Error())
+		}
+		address = muxed.Address()
+	}
+	account := &subAccount{
+		SubAccountResponse: models.SubAccountResponse{
+			Address:    address,
+			MuxedID:    strconv.FormatUint(id, 10),
+			PoolWallet: req.PoolWallet,
+			TenantID:   tenantID,
+			UserID:     req.UserID,
+			CreatedAt:  time.Now().UTC(),
+		},
+		balances: make(map[string]int64),
+	}
+	s.subAccounts[address] = account
+	s.Wallets.Audit.Record("tenant:"+tenantID, "pool.sub_account_created", address, map[string]string{
+		"pool_wallet": req.PoolWallet, "user_id": req.UserID,
+	})
+	return account.snapshotLocked(), nil
+}
+
+// GetSubAccount returns a tenant's sub-account
+func (s *PoolService) GetSubAccount(tenantID, address string) (*models.SubAccountResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account, ok := s.subAccounts[address]
+	if !ok || account.TenantID != tenantID {
+		return nil, errors.New("sub-account not found")
+	}
+	return account.snapshotLocked(), nil
+}
+
+// ListSubAccounts returns a tenant's sub-accounts, optionally of one pooled wallet, oldest first
+func (s *PoolService) ListSubAccounts(tenantID, poolWallet string) []models.SubAccountResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	accounts := []models.SubAccountResponse{}
+	for _, account := range s.subAccounts {
+		if account.TenantID == tenantID && (poolWallet == "" || account.PoolWallet == poolWallet) {
+			accounts = append(accounts, *account.snapshotLocked())
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].CreatedAt.Before(accounts[j].CreatedAt) })
+	return accounts
+}
+
+// Transfer moves funds out of a sub-account. Transfers to another sub-account of the same pool only move
+// the attribution; any other destination is paid on-chain from the pooled account with the sub-account's
+// muxed address as the operation source, so the recipient sees which user paid. Both move the pooled
+// account's funds with its custodied key, so tenantID must be authenticated; it is empty otherwise.
+func (s *PoolService) Transfer(tenantID, address string, req models.SubAccountTransferRequest) (*models.SubAccountTransferResponse, error) {
+	if tenantID == "" {
+		return nil, errors.New("pool transfers require an authenticated tenant")
+	}
+	asset := txnbuild.Asset(s.Wallets.Config.USDCAsset)
+	if req.Asset != "" {
+		var err error
+		if asset, err = parseAsset(req.Asset); err != nil {
+			return nil, errors.New("invalid asset")
+		}
+	}
+	assetKey := assetString(asset)
+	if err := s.Wallets.checkAssetPermitted(assetKey); err != nil {
+		return nil, err
+	}
+	if err := s.Wallets.checkTransferAmount(req.Amount, assetKey); err != nil {
+		return nil, err
+	}
+	stroops, _ := amount.ParseInt64(req.Amount)
+	memo, err := parseMemo(req.Memo, req.MemoType)
+	if err != nil {
+		return nil, err
+	}
+	destination, _, err := parseDestination(req.Destination)
+	if err != nil {
+		return nil, errors.New("invalid destination: " + err.Error())
+	}
+
+	s.mu.Lock()
+	account, ok := s.subAccounts[address]
+	if !ok || account.TenantID != tenantID {
+		s.mu.Unlock()
+		return nil, errors.New("sub-account not found")
+	}
+	poolKP, err := s.Wallets.authorizedSigner(tenantID, account.PoolWallet, "")
+	if err != nil {
+		s.mu.Unlock()
+		return nil, errors.New("failed to load pool wallet: " + account.PoolWallet)
+	}
+	if err := s.Wallets.checkNotFrozen(account.PoolWallet); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	if s.Wallets.isDeactivated(account.PoolWallet) {
+		s.mu.Unlock()
+		return nil, errors.New("wallet is deactivated")
+	}
+	var recipient *subAccount
+	if destination == account.PoolWallet {
+		recipient = s.subAccounts[req.Destination]
+		if recipient == nil || recipient == account {
+			s.mu.Unlock()
+			return nil, errors.New("invalid destination: not another sub-account of this pool")
+		}
+	}
+	if account.balances[assetKey] < stroops {
+		s.mu.Unlock()
+		return nil, errors.New("insufficient sub-account balance: " + amount.StringFromInt64(account.balances[assetKey]) + " " + assetKey + " available")
+	}
+	account.balances[assetKey] -= stroops
+	response := &models.SubAccountTransferResponse{
+		From:        address,
+		Destination: req.Destination,
+		Amount:      amount.StringFromInt64(stroops),
+		Asset:       assetKey,
+	}
+	if recipient != nil {
+		recipient.balances[assetKey] += stroops
+		response.Status = models.SubAccountTransferInternal
+		response.Balance = amount.StringFromInt64(account.balances[assetKey])
+		s.mu.Unlock()
+		s.Wallets.Audit.Record("tenant:"+tenantID, "pool.transfer", address, map[string]string{
+			"destination": req.Destination, "amount": response.Amount, "asset": assetKey,
+		})
+		return response, nil
+	}
+	s.mu.Unlock()
+
+	// The balance is held back while the payment is in flight and restored if it fails
+	hash, err := s.Wallets.submitOperation(poolKP, &txnbuild.Payment{
+		Destination:   req.Destination,
+		Amount:        response.Amount,
+		Asset:         asset,
+		SourceAccount: address,
+	}, memo, req.Device)
+	s.mu.Lock()
+	if err != nil {
+		account.balances[assetKey] += stroops
+		s.mu.Unlock()
+		return nil, err
+	}
+	response.Balance = amount.StringFromInt64(account.balances[assetKey])
+	s.mu.Unlock()
+
+	response.Status = models.SubAccountTransferSubmitted
+	response.TransactionHash = hash
+	s.Wallets.Audit.Record("tenant:"+tenantID, "pool.transfer", address, map[string]string{
+		"destination": req.Destination, "amount": response.Amount, "asset": assetKey, "transaction_hash": hash,
+	})
+	return response, nil
+}
+
+// Credit attributes a payment received by a pooled wallet to the sub-account whose muxed address it was
+// sent to. It reports whether the payment belonged to a sub-account.
+func (s *PoolService) Credit(op operations.Operation, payment models.PaymentRecord) bool {
+	muxedID, muxed := paymentMuxedID(op)
+	if !muxed {
+		return false
+	}
+	received, err := amount.ParseInt64(payment.Amount)
+	if err != nil {
+		return false
+	}
+	muxedAccount, err := xdr.MuxedAccountFromAccountId(payment.To, muxedID)
+	if err != nil {
+		return false
+	}
+
+	s.mu.Lock()
+	account, ok := s.subAccounts[muxedAccount.Address()]
+	if !ok {
+		s.mu.Unlock()
+		return false
+	}
+	account.balances[payment.Asset] += received
+	s.mu.Unlock()
+
+	s.Wallets.Audit.Record("tenant:"+account.TenantID, "pool.credited", account.Address, map[string]string{
+		"amount": payment.Amount, "asset": payment.Asset, "transaction_hash": payment.TransactionHash,
+	})
+	return true
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+)
+
+// portfolioValueDecimals is the precision of fiat values and totals
+const portfolioValueDecimals = 2
+
+// WalletPortfolio values each balance of a wallet in a fiat currency, given as an ISO 4217 code, using the
+// configured PriceSource. Assets the source cannot price are listed without a value and left out of the
+// total rather than failing the whole portfolio.
+func (s *WalletService) WalletPortfolio(publicKey, currency string) (*models.PortfolioResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	currency = strings.ToUpper(currency)
+	if len(currency) != 3 || strings.Trim(currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return nil, errors.New("invalid currency: must be a three-letter ISO 4217 code")
+	}
+	if s.PriceSource == nil {
+		return nil, errors.New("price source is not configured")
+	}
+
+	account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: publicKey})
+	if err != nil {
+		return nil, activityError("account details", err)
+	}
+
+	response := &models.PortfolioResponse{
+		PublicKey: publicKey,
+		Currency:  currency,
+		Assets:    []models.PortfolioAsset{},
+		Complete:  true,
+		ValuedAt:  time.Now().UTC(),
+	}
+	total := new(big.Rat)
+	values := make(map[string]*big.Rat)
+	for asset, balance := range accountBalances(account) {
+		entry := models.PortfolioAsset{Asset: asset, Balance: balance}
+		value, price, err := s.valueBalance(asset, balance, currency)
+		if err != nil {
+			entry.Error = err.Error()
+			response.Complete = false
+		} else {
+			entry.Price, entry.Value = price, value.FloatString(portfolioValueDecimals)
+			values[asset] = value
+			total.Add(total, value)
+		}
+		response.Assets = append(response.Assets, entry)
+	}
+	response.Total = total.FloatString(portfolioValueDecimals)
+
+	for i := range response.Assets {
+		if value, ok := values[response.Assets[i].Asset]; ok && total.Sign() > 0 {
+			share := new(big.Rat).Quo(value, total)
+			response.Assets[i].Share = share.Mul(share, big.NewRat(100, 1)).FloatString(portfolioValueDecimals)
+		}
+	}
+	// Largest holdings first, then unpriced assets by name
+	sort.Slice(response.Assets, func(i, j int) bool {
+		vi, iok := values[response.Assets[i].Asset]
+		vj, jok := values[response.Assets[j].Asset]
+		if iok != jok {
+			return iok
+		}
+		if iok && vi.Cmp(vj) != 0 {
+			return vi.Cmp(vj) > 0
+		}
+		return response.Assets[i].Asset < response.Assets[j].Asset
+	})
+	return response, nil
+}
+
+// valueBalance prices a balance, returning its exact value and the price used
+func (s *WalletService) valueBalance(asset, balance, currency string) (*big.Rat, string, error) {
+	if strings.HasPrefix(asset, "pool:") {
+		return nil, "", errors.New("liquidity pool shares cannot be priced")
+	}
+	price, err := s.PriceSource.Price(asset, currency)
+	if err != nil {
+		return nil, "", errors.New("failed to fetch price: " + err.Error())
+	}
+	unitPrice, ok := new(big.Rat).SetString(price)
+	if !ok || unitPrice.Sign() < 0 {
+		return nil, "", errors.New("invalid price from price source: " + price)
+	}
+	held, ok := new(big.Rat).SetString(balance)
+	if !ok {
+		return nil, "", errors.New("invalid balance: " + balance)
+	}
+	return held.Mul(held, unitPrice), price, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+)
+
+// Bounds of the time a transaction built by the service stays valid
+const (
+	defaultTransactionTimeout = 300
+	maxTransactionTimeout     = 3600
+)
+
+// maxExtraSigners is the protocol limit on a transaction's extra signers
+const maxExtraSigners = 2
+
+// transactionPreconditions validates the preconditions of a request and converts them, returning when the
+// transaction expires
+func transactionPreconditions(req models.TransactionPreconditions) (txnbuild.Preconditions, time.Time, error) {
+	timeout := req.TimeoutSeconds
+	if timeout == 0 {
+		timeout = defaultTransactionTimeout
+	}
+	if timeout < 0 || timeout > maxTransactionTimeout {
+		return txnbuild.Preconditions{}, time.Time{}, errors.New("invalid timeout_seconds: must be between 1 and 3600")
+	}
+	expiresAt := time.Unix(time.Now().Unix()+timeout, 0).UTC()
+	preconditions := txnbuild.Preconditions{
+		TimeBounds:                 txnbuild.NewTimebounds(0, expiresAt.Unix()),
+		MinSequenceNumberAge:       req.MinSequenceAge,
+		MinSequenceNumberLedgerGap: req.MinSequenceLedgerGap,
+	}
+	if req.MinLedger != 0 || req.MaxLedger != 0 {
+		if req.MaxLedger != 0 && req.MaxLedger <= req.MinLedger {
+			return txnbuild.Preconditions{}, time.Time{}, errors.New("invalid max_ledger: must be above min_ledger")
+		}
+		preconditions.LedgerBounds = &txnbuild.LedgerBounds{MinLedger: req.MinLedger, MaxLedger: req.MaxLedger}
+	}
+	if len(req.ExtraSigners) > maxExtraSigners {
+		return txnbuild.Preconditions{}, time.Time{}, errors.New("invalid extra_signers: a transaction has at most 2")
+	}
+	for _, signer := range req.ExtraSigners {
+		var key xdr.SignerKey
+		if err := key.SetAddress(signer); err != nil {
+			return txnbuild.Preconditions{}, time.Time{}, errors.New("invalid extra_signers: " + signer + " is not a signer key")
+		}
+	}
+	preconditions.ExtraSigners = req.ExtraSigners
+	return preconditions, expiresAt, nil
+}
+
+// extraSignerKeys returns the keypairs of a transfer's extra signers, each of which must be the master
+// account or a managed wallet, since the service signs transfers itself
+func (s *WalletService) extraSignerKeys(signers []string) ([]*keypair.Full, error) {
+	keys := make([]*keypair.Full, 0, len(signers))
+	for _, signer := range signers {
+		if strkey.IsValidEd25519PublicKey(signer) {
+			if kp, ok := s.accountSigner(signer); ok {
+				keys = append(keys, kp)
+				continue
+			}
+		}
+		return nil, errors.New("invalid extra_signers: " + signer + " is not a wallet this service signs for")
+	}
+	return keys, nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// PriceSource quotes the price of one unit of an asset ("native" or CODE:ISSUER) in a fiat currency such as
+// USD, as a decimal string; implementations are supplied by the operator
+type PriceSource interface {
+	Price(asset, currency string) (string, error)
+}
+
+// HTTPPriceSource fetches prices from an external service answering GET URL?asset=...&currency=... with
+// {"price": "0.1123"}
+type HTTPPriceSource struct {
+	URL    string
+	Client *http.Client
+}
+
+// Price queries the pricing service
+func (h *HTTPPriceSource) Price(asset, currency string) (string, error) {
+	endpoint, err := url.Parse(h.URL)
+	if err != nil {
+		return "", errors.New("invalid price source URL: " + err.Error())
+	}
+	query := endpoint.Query()
+	query.Set("asset", asset)
+	query.Set("currency", currency)
+	endpoint.RawQuery = query.Encode()
+
+	client := h.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	resp, err := client.Get(endpoint.String())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New("price source returned " + resp.Status)
+	}
+
+	var result struct {
+		Price string `json:"price"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", errors.New("invalid price source response: " + err.Error())
+	}
+	return result.Price, nil
+}
+
+// StaticPriceSource serves fixed prices keyed by currency and then asset, e.g.
+// {"USD": {"native": "0.11", "USDC:GA5Z...": "1"}}; it suits testnet and development
+type StaticPriceSource map[string]map[string]string
+
+// Price looks the asset up in the table
+func (p StaticPriceSource) Price(asset, currency string) (string, error) {
+	price, ok := p[currency][asset]
+	if !ok {
+		return "", errors.New("no " + currency + " price for " + asset)
+	}
+	return price, nil
+}
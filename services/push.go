@@ -0,0 +1,301 @@
+package services
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+)
+
+const (
+	// pushDevicesKey is the archive key all push devices are persisted under
+	pushDevicesKey = "push/devices.json"
+	// maxPushDevicesPerWallet caps the devices linked to one wallet
+	maxPushDevicesPerWallet = 10
+	// maxPushTokenLength bounds the device tokens accepted; FCM tokens run to a few hundred characters
+	maxPushTokenLength = 4096
+)
+
+// errPushTokenInvalid is returned by a PushSender when the provider no longer accepts a device token, after
+// which the device is unlinked
+var errPushTokenInvalid = errors.New("push token is no longer valid")
+
+// PushNotification is a notification shown on a device, with data passed to the app
+type PushNotification struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// PushSender delivers notifications to the devices of one platform
+type PushSender interface {
+	Send(token string, notification PushNotification) error
+}
+
+// PushService links device tokens to managed wallets and pushes a notification to them whenever the wallet
+// receives a payment
+type PushService struct {
+	Wallets *WalletService
+	// Senders maps a platform, fcm or apns, to its sender; devices can only be linked for configured platforms
+	Senders map[string]PushSender
+
+	mu sync.Mutex
+	// devices is keyed by ID and loaded from the archive store on first use
+	devices map[string]models.PushDevice
+}
+
+// NewPushService creates a new PushService instance pushing the wallet service's payment events
+func NewPushService(wallets *WalletService, senders map[string]PushSender) *PushService {
+	s := &PushService{Wallets: wallets, Senders: senders}
+	wallets.Events.Subscribe(func(event models.Event) {
+		if event.Type == models.EventPaymentReceived {
+			// Event handlers must not block
+			go s.notify(event)
+		}
+	})
+	return s
+}
+
+// loadLocked reads the persisted devices the first time they are needed; s.mu must be held
+func (s *PushService) loadLocked() error {
+	if s.devices != nil {
+		return nil
+	}
+	devices := make(map[string]models.PushDevice)
+	if s.Wallets.Archive != nil {
+		data, err := s.Wallets.Archive.Get(pushDevicesKey)
+		switch {
+		case errors.Is(err, errArchiveNotFound):
+		case err != nil:
+			return errors.New("failed to read push devices: " + err.Error())
+		default:
+			if err := json.Unmarshal(data, &devices); err != nil {
+				return errors.New("failed to decode push devices: " + err.Error())
+			}
+		}
+	}
+	s.devices = devices
+	return nil
+}
+
+// saveLocked persists devices before they replace the loaded ones; s.mu must be held
+func (s *PushService) saveLocked(devices map[string]models.PushDevice) error {
+	if s.Wallets.Archive != nil {
+		data, err := json.Marshal(devices)
+		if err != nil {
+			return errors.New("failed to encode push devices: " + err.Error())
+		}
+		if err := s.Wallets.Archive.Put(pushDevicesKey, data); err != nil {
+			return errors.New("failed to persist push devices: " + err.Error())
+		}
+	}
+	s.devices = devices
+	return nil
+}
+
+// ownedWallet checks that a wallet is custodied for the tenant
+func (s *PushService) ownedWallet(tenantID, publicKey string) error {
+	if owner, ok := s.Wallets.Registry.TenantOf(publicKey); !ok || owner != tenantID {
+		return errors.New("wallet not found")
+	}
+	return nil
+}
+
+// RegisterDevice links a device token to one of a tenant's wallets. Registering a token the wallet already
+// has returns the existing device.
+func (s *PushService) RegisterDevice(tenantID, publicKey string, req models.PushDeviceRequest) (*models.PushDevice, error) {
+	if err := s.ownedWallet(tenantID, publicKey); err != nil {
+		return nil, err
+	}
+	if req.Platform != models.PushPlatformFCM && req.Platform != models.PushPlatformAPNs {
+		return nil, errors.New("invalid platform: must be fcm or apns")
+	}
+	if s.Senders[req.Platform] == nil {
+		return nil, errors.New("push notifications are not configured for " + req.Platform)
+	}
+	token := strings.TrimSpace(req.Token)
+	if token == "" || len(token) > maxPushTokenLength {
+		return nil, errors.New("invalid token: must be a non-empty device token")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	next := make(map[string]models.PushDevice, len(s.devices)+1)
+	count := 0
+	for id, device := range s.devices {
+		if device.Wallet == publicKey {
+			if device.Platform == req.Platform && device.Token == token {
+				return &device, nil
+			}
+			count++
+		}
+		next[id] = device
+	}
+	if count >= maxPushDevicesPerWallet {
+		return nil, errors.New("push device limit reached: a wallet may have at most 10 devices")
+	}
+	device := models.PushDevice{
+		ID:        newID(),
+		Wallet:    publicKey,
+		TenantID:  tenantID,
+		Platform:  req.Platform,
+		Token:     token,
+		CreatedAt: time.Now().UTC(),
+	}
+	next[device.ID] = device
+	if err := s.saveLocked(next); err != nil {
+		return nil, err
+	}
+	s.Wallets.Audit.Record("tenant:"+tenantID, "push.device_registered", publicKey, map[string]string{"device_id": device.ID, "platform": device.Platform})
+	return &device, nil
+}
+
+// ListDevices returns the devices linked to one of a tenant's wallets, oldest first
+func (s *PushService) ListDevices(tenantID, publicKey string) (*models.PushDevicesResponse, error) {
+	if err := s.ownedWallet(tenantID, publicKey); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	response := &models.PushDevicesResponse{Devices: []models.PushDevice{}}
+	for _, device := range s.devices {
+		if device.Wallet == publicKey {
+			response.Devices = append(response.Devices, device)
+		}
+	}
+	sort.Slice(response.Devices, func(i, j int) bool {
+		return response.Devices[i].CreatedAt.Before(response.Devices[j].CreatedAt)
+	})
+	return response, nil
+}
+
+// UnregisterDevice unlinks a device from one of a tenant's wallets
+func (s *PushService) UnregisterDevice(tenantID, publicKey, id string) error {
+	if err := s.ownedWallet(tenantID, publicKey); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	if device, ok := s.devices[id]; !ok || device.Wallet != publicKey {
+		return errors.New("push device not found")
+	}
+	if err := s.removeLocked(id); err != nil {
+		return err
+	}
+	s.Wallets.Audit.Record("tenant:"+tenantID, "push.device_unregistered", publicKey, map[string]string{"device_id": id})
+	return nil
+}
+
+// removeLocked unlinks a device; s.mu must be held
+func (s *PushService) removeLocked(id string) error {
+	next := make(map[string]models.PushDevice, len(s.devices))
+	for key, device := range s.devices {
+		if key != id {
+			next[key] = device
+		}
+	}
+	return s.saveLocked(next)
+}
+
+// notify pushes a received payment to every device linked to the receiving wallet, unlinking devices whose
+// token the provider rejects as no longer valid
+func (s *PushService) notify(event models.Event) {
+	s.mu.Lock()
+	if err := s.loadLocked(); err != nil {
+		s.mu.Unlock()
+		log.Printf("push event %s: %v", event.ID, err)
+		return
+	}
+	var devices []models.PushDevice
+	for _, device := range s.devices {
+		if device.Wallet == event.PublicKey {
+			devices = append(devices, device)
+		}
+	}
+	s.mu.Unlock()
+	if len(devices) == 0 {
+		return
+	}
+
+	notification := paymentNotification(event)
+	for _, device := range devices {
+		sender := s.Senders[device.Platform]
+		if sender == nil {
+			continue
+		}
+		err := sender.Send(device.Token, notification)
+		switch {
+		case errors.Is(err, errPushTokenInvalid):
+			log.Printf("push device %s: token no longer valid, unlinking", device.ID)
+			s.mu.Lock()
+			if err := s.removeLocked(device.ID); err != nil {
+				log.Printf("push device %s: %v", device.ID, err)
+			}
+			s.mu.Unlock()
+		case err != nil:
+			log.Printf("push event %s to device %s failed: %v", event.ID, device.ID, err)
+		}
+	}
+}
+
+// paymentNotification describes a received payment, e.g. "You received 25 USDC"
+func paymentNotification(event models.Event) PushNotification {
+	value := event.Data["amount"]
+	if strings.Contains(value, ".") {
+		value = strings.TrimRight(strings.TrimRight(value, "0"), ".")
+	}
+	data := map[string]string{"event_id": event.ID, "type": event.Type, "wallet": event.PublicKey}
+	for _, key := range []string{"asset", "amount", "transaction_hash"} {
+		if event.Data[key] != "" {
+			data[key] = event.Data[key]
+		}
+	}
+	return PushNotification{
+		Title: "Payment received",
+		Body:  "You received " + value + " " + assetDisplayCode(event.Data["asset"]),
+		Data:  data,
+	}
+}
+
+// signJWT returns a compact JWT of claims, signed over its SHA-256 digest by sign with the given algorithm
+func signJWT(header, claims map[string]interface{}, sign func(digest []byte) ([]byte, error)) (string, error) {
+	encode := func(v interface{}) (string, error) {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return base64.RawURLEncoding.EncodeToString(raw), nil
+	}
+	encodedHeader, err := encode(header)
+	if err != nil {
+		return "", err
+	}
+	encodedClaims, err := encode(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := encodedHeader + "." + encodedClaims
+	hash := crypto.SHA256.New()
+	hash.Write([]byte(signingInput))
+	signature, err := sign(hash.Sum(nil))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	apnsProductionURL = "https://api.push.apple.com"
+	apnsSandboxURL    = "https://api.sandbox.push.apple.com"
+	// apnsTokenLifetime is how long a provider token is reused; Apple rejects tokens older than an hour
+	apnsTokenLifetime = 30 * time.Minute
+)
+
+// APNsSender pushes to iOS devices through the Apple Push Notification service, authorized with a token
+// signing key (.p8) from the Apple developer account
+type APNsSender struct {
+	KeyID  string
+	TeamID string
+	// Topic is the app's bundle ID
+	Topic string
+	// URL is the APNs endpoint, production or sandbox
+	URL    string
+	Client *http.Client
+
+	key      *ecdsa.PrivateKey
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+// NewAPNsSender creates an APNsSender from a .p8 signing key's contents, using the sandbox endpoint unless
+// production is set
+func NewAPNsSender(signingKey []byte, keyID, teamID, topic string, production bool) (*APNsSender, error) {
+	if keyID == "" || teamID == "" || topic == "" {
+		return nil, errors.New("invalid APNs configuration: key ID, team ID and topic are required")
+	}
+	block, _ := pem.Decode(signingKey)
+	if block == nil {
+		return nil, errors.New("invalid APNs signing key: not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("invalid APNs signing key: " + err.Error())
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid APNs signing key: not an ECDSA key")
+	}
+	endpoint := apnsSandboxURL
+	if production {
+		endpoint = apnsProductionURL
+	}
+	return &APNsSender{
+		KeyID:  keyID,
+		TeamID: teamID,
+		Topic:  topic,
+		URL:    endpoint,
+		Client: &http.Client{Timeout: 10 * time.Second},
+		key:    key,
+	}, nil
+}
+
+// Send pushes a notification to one device
+func (a *APNsSender) Send(token string, notification PushNotification) error {
+	providerToken, err := a.authorize()
+	if err != nil {
+		return err
+	}
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{"title": notification.Title, "body": notification.Body},
+			"sound": "default",
+		},
+	}
+	for key, value := range notification.Data {
+		if key != "aps" {
+			payload[key] = value
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.New("failed to encode APNs payload: " + err.Error())
+	}
+	req, err := http.NewRequest(http.MethodPost, a.URL+"/3/device/"+url.PathEscape(token), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+providerToken)
+	req.Header.Set("apns-topic", a.Topic)
+	req.Header.Set("apns-push-type", "alert")
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return errors.New("failed to reach APNs: " + err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	var reason struct {
+		Reason string `json:"reason"`
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	json.Unmarshal(detail, &reason)
+	// APNs answers 410 for tokens of uninstalled apps and BadDeviceToken for tokens it never issued
+	if resp.StatusCode == http.StatusGone || reason.Reason == "BadDeviceToken" || reason.Reason == "Unregistered" {
+		return errPushTokenInvalid
+	}
+	return errors.New("APNs responded " + resp.Status + ": " + strings.TrimSpace(reason.Reason))
+}
+
+// authorize returns the current provider token, signing a new one once it is apnsTokenLifetime old
+func (a *APNsSender) authorize() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Since(a.issuedAt) < apnsTokenLifetime {
+		return a.token, nil
+	}
+	now := time.Now()
+	token, err := signJWT(
+		map[string]interface{}{"alg": "ES256", "kid": a.KeyID},
+		map[string]interface{}{"iss": a.TeamID, "iat": now.Unix()},
+		func(digest []byte) ([]byte, error) {
+			r, s, err := ecdsa.Sign(rand.Reader, a.key, digest)
+			if err != nil {
+				return nil, err
+			}
+			// JWS ES256 signatures are r and s as fixed-width 32-byte big-endian integers
+			signature := make([]byte, 64)
+			r.FillBytes(signature[:32])
+			s.FillBytes(signature[32:])
+			return signature, nil
+		},
+	)
+	if err != nil {
+		return "", errors.New("failed to sign APNs token: " + err.Error())
+	}
+	a.token, a.issuedAt = token, now
+	return token, nil
+}
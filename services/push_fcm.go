@@ -0,0 +1,167 @@
+package services
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fcmScope       = "https://www.googleapis.com/auth/firebase.messaging"
+	fcmProjectsURL = "https://fcm.googleapis.com/v1/projects/"
+	googleTokenURL = "https://oauth2.googleapis.com/token"
+)
+
+// FCMSender pushes to Android and web devices through the Firebase Cloud Messaging HTTP v1 API, authorized
+// as a Google service account
+type FCMSender struct {
+	ProjectID   string
+	ClientEmail string
+	TokenURL    string
+	// SendURL overrides the FCM endpoint; it defaults to the project's messages:send URL
+	SendURL string
+	Client  *http.Client
+
+	key         *rsa.PrivateKey
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewFCMSender creates an FCMSender from a service account's JSON key file contents
+func NewFCMSender(credentials []byte) (*FCMSender, error) {
+	var account struct {
+		ProjectID   string `json:"project_id"`
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+		TokenURI    string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(credentials, &account); err != nil {
+		return nil, errors.New("invalid FCM credentials: " + err.Error())
+	}
+	if account.ProjectID == "" || account.ClientEmail == "" {
+		return nil, errors.New("invalid FCM credentials: project_id and client_email are required")
+	}
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, errors.New("invalid FCM credentials: private_key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("invalid FCM credentials: " + err.Error())
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid FCM credentials: private_key is not an RSA key")
+	}
+	tokenURL := account.TokenURI
+	if tokenURL == "" {
+		tokenURL = googleTokenURL
+	}
+	return &FCMSender{
+		ProjectID:   account.ProjectID,
+		ClientEmail: account.ClientEmail,
+		TokenURL:    tokenURL,
+		SendURL:     fcmProjectsURL + url.PathEscape(account.ProjectID) + "/messages:send",
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		key:         key,
+	}, nil
+}
+
+// Send pushes a notification to one device
+func (f *FCMSender) Send(token string, notification PushNotification) error {
+	accessToken, err := f.authorize()
+	if err != nil {
+		return err
+	}
+	message := map[string]interface{}{
+		"token":        token,
+		"notification": map[string]string{"title": notification.Title, "body": notification.Body},
+	}
+	if len(notification.Data) > 0 {
+		message["data"] = notification.Data
+	}
+	body, err := json.Marshal(map[string]interface{}{"message": message})
+	if err != nil {
+		return errors.New("failed to encode FCM message: " + err.Error())
+	}
+	req, err := http.NewRequest(http.MethodPost, f.SendURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return errors.New("failed to reach FCM: " + err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	// FCM answers 404 UNREGISTERED for tokens of uninstalled apps
+	if resp.StatusCode == http.StatusNotFound || strings.Contains(string(detail), "UNREGISTERED") {
+		return errPushTokenInvalid
+	}
+	return errors.New("FCM responded " + resp.Status + ": " + strings.TrimSpace(string(detail)))
+}
+
+// authorize returns a cached OAuth access token, exchanging a freshly signed service account assertion for a
+// new one shortly before the current one expires
+func (f *FCMSender) authorize() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.accessToken != "" && time.Now().Before(f.expiresAt) {
+		return f.accessToken, nil
+	}
+	now := time.Now()
+	assertion, err := signJWT(
+		map[string]interface{}{"alg": "RS256", "typ": "JWT"},
+		map[string]interface{}{
+			"iss":   f.ClientEmail,
+			"scope": fcmScope,
+			"aud":   f.TokenURL,
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Hour).Unix(),
+		},
+		func(digest []byte) ([]byte, error) {
+			return rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, digest)
+		},
+	)
+	if err != nil {
+		return "", errors.New("failed to sign FCM assertion: " + err.Error())
+	}
+	resp, err := f.Client.PostForm(f.TokenURL, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	})
+	if err != nil {
+		return "", errors.New("failed to authorize with FCM: " + err.Error())
+	}
+	defer resp.Body.Close()
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New("failed to authorize with FCM: token endpoint responded " + resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil || token.AccessToken == "" {
+		return "", errors.New("failed to authorize with FCM: invalid token response")
+	}
+	f.accessToken = token.AccessToken
+	f.expiresAt = now.Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return f.accessToken, nil
+}
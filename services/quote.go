@@ -0,0 +1,229 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// quoteValidity is how long a quote is indicative for; order books and pools move every ledger
+const quoteValidity = 30 * time.Second
+
+// resolveAsset parses "native", XLM or CODE:ISSUER, or resolves a bare code against USDC and the asset
+// allowlist; a bare code that matches no asset, or several, is rejected
+func (s *WalletService) resolveAsset(value string) (txnbuild.Asset, error) {
+	if value == "" {
+		return nil, errors.New("invalid quote asset: missing")
+	}
+	if strings.Contains(value, ":") || value == "native" || strings.EqualFold(value, "xlm") {
+		asset, err := parseAsset(value)
+		if err != nil {
+			return nil, errors.New("invalid quote asset: " + value)
+		}
+		return asset, nil
+	}
+
+	var match string
+	for _, known := range append([]string{assetString(s.Config.USDCAsset)}, s.Config.AssetAllowlist...) {
+		code, _, _ := strings.Cut(known, ":")
+		if !strings.EqualFold(code, value) || strings.EqualFold(known, match) {
+			continue
+		}
+		if match != "" {
+			return nil, errors.New("invalid quote asset: " + value + " is ambiguous, use CODE:ISSUER")
+		}
+		match = known
+	}
+	if match == "" {
+		return nil, errors.New("invalid quote asset: unknown code " + value + ", use CODE:ISSUER")
+	}
+	return parseAsset(match)
+}
+
+// rate returns dest/source to seven decimal places
+func rate(sourceStroops, destStroops int64) string {
+	if sourceStroops <= 0 {
+		return "0.0000000"
+	}
+	return new(big.Rat).SetFrac64(destStroops, sourceStroops).FloatString(7)
+}
+
+// quotePath finds the best path for a quote in either mode and returns its source and destination stroops
+func (s *WalletService) quotePath(mode string, from, to txnbuild.Asset, value string) (hProtocol.Path, int64, int64, error) {
+	var path hProtocol.Path
+	var err error
+	if mode == models.QuoteModeReceive {
+		path, err = s.findStrictReceivePath(from, to, value)
+	} else {
+		path, err = s.findStrictSendPath(from, to, value)
+	}
+	if err != nil {
+		return hProtocol.Path{}, 0, 0, err
+	}
+	source, err := amount.ParseInt64(path.SourceAmount)
+	if err != nil {
+		return hProtocol.Path{}, 0, 0, errors.New("failed to parse quoted source amount: " + err.Error())
+	}
+	dest, err := amount.ParseInt64(path.DestinationAmount)
+	if err != nil {
+		return hProtocol.Path{}, 0, 0, errors.New("failed to parse quoted destination amount: " + err.Error())
+	}
+	return path, source, dest, nil
+}
+
+// Quote returns an indicative rate, path and slippage estimate for converting between two assets. The price
+// impact compares the quoted rate with that of one hundredth of the amount.
+func (s *WalletService) Quote(req models.QuoteRequest) (*models.QuoteResponse, error) {
+	mode := req.Mode
+	if mode == "" {
+		mode = models.QuoteModeSend
+	}
+	if mode != models.QuoteModeSend && mode != models.QuoteModeReceive {
+		return nil, errors.New("invalid quote mode: must be send or receive")
+	}
+	from, err := s.resolveAsset(req.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.resolveAsset(req.To)
+	if err != nil {
+		return nil, err
+	}
+	if assetString(from) == assetString(to) {
+		return nil, errors.New("invalid quote: from and to must be different assets")
+	}
+	for _, asset := range []txnbuild.Asset{from, to} {
+		if err := s.checkAssetPermitted(assetString(asset)); err != nil {
+			return nil, err
+		}
+	}
+	stroops, err := parseAmount("amount", req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	slippage, err := parseSlippage(req.MaxSlippagePercent)
+	if err != nil {
+		return nil, err
+	}
+
+	path, source, dest, err := s.quotePath(mode, from, to, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	quote := &models.QuoteResponse{
+		From:               assetString(from),
+		To:                 assetString(to),
+		Mode:               mode,
+		SourceAmount:       amount.StringFromInt64(source),
+		DestinationAmount:  amount.StringFromInt64(dest),
+		Rate:               rate(source, dest),
+		Path:               pathStrings(pathAssets(path.Path)),
+		PriceImpactPercent: "0.00",
+		SlippagePercent:    strconv.FormatFloat(slippage, 'f', -1, 64),
+		QuotedAt:           now,
+		ExpiresAt:          now.Add(quoteValidity),
+	}
+	if mode == models.QuoteModeReceive {
+		if quote.SendMax, err = withSlippage(quote.SourceAmount, slippage); err != nil {
+			return nil, errors.New("failed to compute max send amount: " + err.Error())
+		}
+	} else if quote.DestMin, err = withoutSlippage(quote.DestinationAmount, slippage); err != nil {
+		return nil, errors.New("failed to compute minimum destination amount: " + err.Error())
+	}
+
+	if marginal := stroops / 100; marginal > 0 && source > 0 {
+		if _, mSource, mDest, err := s.quotePath(mode, from, to, amount.StringFromInt64(marginal)); err == nil && mSource > 0 && mDest > 0 {
+			// impact = (marginal rate - rate) / marginal rate = 1 - (dest * mSource) / (source * mDest)
+			ratio := new(big.Rat).SetFrac(
+				new(big.Int).Mul(big.NewInt(dest), big.NewInt(mSource)),
+				new(big.Int).Mul(big.NewInt(source), big.NewInt(mDest)),
+			)
+			impact := new(big.Rat).Sub(big.NewRat(1, 1), ratio)
+			if impact.Sign() > 0 {
+				quote.PriceImpactPercent = impact.Mul(impact, big.NewRat(100, 1)).FloatString(2)
+			}
+		}
+	}
+
+	if req.IncludePools {
+		pool, err := s.poolQuote(mode, from, to, stroops)
+		if err != nil {
+			return nil, err
+		}
+		quote.LiquidityPool = pool
+	}
+	return quote, nil
+}
+
+// poolQuote prices a trade against the constant-product pool of exactly from and to, returning nil when
+// there is no such pool or it cannot fill the trade
+func (s *WalletService) poolQuote(mode string, from, to txnbuild.Asset, stroops int64) (*models.LiquidityPoolQuote, error) {
+	pools, err := s.Config.HorizonClient.LiquidityPools(horizonclient.LiquidityPoolsRequest{
+		Reserves: []string{assetString(from), assetString(to)},
+	})
+	if err != nil {
+		return nil, errors.New("failed to fetch liquidity pools: " + err.Error())
+	}
+	for _, pool := range pools.Embedded.Records {
+		var reserveFrom, reserveTo int64
+		for _, reserve := range pool.Reserves {
+			value, err := amount.ParseInt64(reserve.Amount)
+			if err != nil {
+				continue
+			}
+			switch reserve.Asset {
+			case assetString(from):
+				reserveFrom = value
+			case assetString(to):
+				reserveTo = value
+			}
+		}
+		if reserveFrom <= 0 || reserveTo <= 0 {
+			continue
+		}
+
+		// out = reserveTo * in * (10000 - fee) / (reserveFrom * 10000 + in * (10000 - fee))
+		feeFactor := big.NewInt(int64(10000 - pool.FeeBP))
+		x, y := big.NewInt(reserveFrom), big.NewInt(reserveTo)
+		var source, dest int64
+		if mode == models.QuoteModeReceive {
+			if stroops >= reserveTo {
+				return nil, nil
+			}
+			// in = reserveFrom * out * 10000 / ((reserveTo - out) * (10000 - fee)), rounded up
+			num := new(big.Int).Mul(x, big.NewInt(stroops))
+			num.Mul(num, big.NewInt(10000))
+			den := new(big.Int).Mul(new(big.Int).Sub(y, big.NewInt(stroops)), feeFactor)
+			quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+			if remainder.Sign() > 0 {
+				quotient.Add(quotient, big.NewInt(1))
+			}
+			if !quotient.IsInt64() {
+				return nil, nil
+			}
+			source, dest = quotient.Int64(), stroops
+		} else {
+			in := new(big.Int).Mul(big.NewInt(stroops), feeFactor)
+			num := new(big.Int).Mul(y, in)
+			den := new(big.Int).Add(new(big.Int).Mul(x, big.NewInt(10000)), in)
+			source, dest = stroops, new(big.Int).Quo(num, den).Int64()
+		}
+		return &models.LiquidityPoolQuote{
+			PoolID:            pool.ID,
+			FeeBP:             pool.FeeBP,
+			SourceAmount:      amount.StringFromInt64(source),
+			DestinationAmount: amount.StringFromInt64(dest),
+			Rate:              rate(source, dest),
+		}, nil
+	}
+	return nil, nil
+}
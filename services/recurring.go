@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// maxRecurringAttempts is how many times a charge is tried before its cycle is marked failed
+const maxRecurringAttempts = 3
+
+// recurringRetryDelay is the wait before the first retry of a failed charge; it doubles on each retry
+const recurringRetryDelay = 5 * time.Minute
+
+// recurringPlan is a plan with the state of its current cycle
+type recurringPlan struct {
+	plan models.RecurringPlanResponse
+	// nextAttemptAt is when the current cycle is next tried; it differs from NextChargeAt while retrying
+	nextAttemptAt time.Time
+}
+
+// RecurringService manages recurring payment plans of custodied wallets and charges them when due
+type RecurringService struct {
+	Wallets *WalletService
+
+	mu    sync.Mutex
+	plans map[string]*recurringPlan
+}
+
+// NewRecurringService creates a new RecurringService instance
+func NewRecurringService(wallets *WalletService) *RecurringService {
+	return &RecurringService{Wallets: wallets, plans: make(map[string]*recurringPlan)}
+}
+
+// nextCycle returns the charge time following t for an interval
+func nextCycle(t time.Time, interval string) (time.Time, error) {
+	switch interval {
+	case models.IntervalDaily:
+		return t.AddDate(0, 0, 1), nil
+	case models.IntervalWeekly:
+		return t.AddDate(0, 0, 7), nil
+	case models.IntervalMonthly:
+		return t.AddDate(0, 1, 0), nil
+	}
+	d, err := time.ParseDuration(interval)
+	if err != nil || d < time.Minute {
+		return time.Time{}, errors.New("invalid interval: use daily, weekly, monthly or a duration of at least 1m")
+	}
+	return t.Add(d), nil
+}
+
+// CreatePlan validates and schedules a recurring payment plan. Its charges are signed with the wallet's
+// custodied key, so the request must be authenticated as the wallet's tenant or supply the wallet's secret key.
+func (s *RecurringService) CreatePlan(tenantID string, authenticated bool, req models.CreateRecurringPlanRequest) (*models.RecurringPlanResponse, error) {
+	if owner, ok := s.Wallets.Registry.TenantOf(req.Wallet); !ok || owner != tenantID {
+		return nil, errors.New("invalid wallet: recurring payments require a custodied wallet of this tenant")
+	}
+	signerTenant := ""
+	if authenticated {
+		signerTenant = tenantID
+	}
+	if _, err := s.Wallets.authorizedSigner(signerTenant, req.Wallet, req.SecretKey); err != nil {
+		return nil, err
+	}
+	if _, err := keypair.ParseAddress(req.Destination); err != nil {
+		return nil, errors.New("invalid destination public key")
+	}
+	asset := txnbuild.Asset(s.Wallets.Config.USDCAsset)
+	if req.Asset != "" {
+		var err error
+		if asset, err = parseAsset(req.Asset); err != nil {
+			return nil, errors.New("invalid asset")
+		}
+	}
+	if err := s.Wallets.checkAssetPermitted(assetString(asset)); err != nil {
+		return nil, err
+	}
+	if err := s.Wallets.checkTransferAmount(req.Amount, assetString(asset)); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if _, err := nextCycle(now, req.Interval); err != nil {
+		return nil, err
+	}
+	start := now
+	if req.StartAt != nil && req.StartAt.After(now) {
+		start = req.StartAt.UTC()
+	}
+	if req.EndAt != nil && req.EndAt.Before(start) {
+		return nil, errors.New("invalid end_at: must not be before the first charge")
+	}
+
+	plan := &recurringPlan{
+		plan: models.RecurringPlanResponse{
+			ID:           newID(),
+			TenantID:     tenantID,
+			Wallet:       req.Wallet,
+			Destination:  req.Destination,
+			Amount:       req.Amount,
+			Asset:        assetString(asset),
+			Interval:     req.Interval,
+			Status:       models.RecurringActive,
+			NextChargeAt: &start,
+			EndAt:        req.EndAt,
+			Charges:      []models.RecurringCharge{},
+			CreatedAt:    now,
+		},
+		nextAttemptAt: start,
+	}
+
+	s.mu.Lock()
+	s.plans[plan.plan.ID] = plan
+	result := snapshotPlan(plan)
+	s.mu.Unlock()
+	s.Wallets.Audit.Record("tenant:"+tenantID, "recurring.created", plan.plan.ID, map[string]string{
+		"wallet": req.Wallet, "destination": req.Destination, "amount": req.Amount, "interval": req.Interval,
+	})
+	return result, nil
+}
+
+func snapshotPlan(plan *recurringPlan) *models.RecurringPlanResponse {
+	result := plan.plan
+	result.Charges = append([]models.RecurringCharge{}, plan.plan.Charges...)
+	return &result
+}
+
+// GetPlan returns a tenant's recurring payment plan with its charges
+func (s *RecurringService) GetPlan(tenantID, id string) (*models.RecurringPlanResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	plan, ok := s.plans[id]
+	if !ok || plan.plan.TenantID != tenantID {
+		return nil, errors.New("recurring plan not found")
+	}
+	return snapshotPlan(plan), nil
+}
+
+// ListPlans returns a tenant's recurring payment plans, oldest first
+func (s *RecurringService) ListPlans(tenantID string) []models.RecurringPlanResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	plans := []models.RecurringPlanResponse{}
+	for _, plan := range s.plans {
+		if plan.plan.TenantID == tenantID {
+			plans = append(plans, *snapshotPlan(plan))
+		}
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].CreatedAt.Before(plans[j].CreatedAt) })
+	return plans
+}
+
+// CancelPlan stops an active plan; charges already made are kept
+func (s *RecurringService) CancelPlan(tenantID, id string) (*models.RecurringPlanResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	plan, ok := s.plans[id]
+	if !ok || plan.plan.TenantID != tenantID {
+		return nil, errors.New("recurring plan not found")
+	}
+	if plan.plan.Status != models.RecurringActive {
+		return nil, errors.New("recurring plan is not active")
+	}
+	plan.plan.Status = models.RecurringCancelled
+	plan.plan.NextChargeAt = nil
+	s.Wallets.Audit.Record("tenant:"+tenantID, "recurring.cancelled", id, nil)
+	return snapshotPlan(plan), nil
+}
+
+// Run charges due plans every interval until ctx is cancelled
+func (s *RecurringService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.ChargeDue(time.Now().UTC())
+		}
+	}
+}
+
+// ChargeDue attempts every charge due at now, retrying failed charges with backoff before giving up on
+// the cycle and moving to the next one
+func (s *RecurringService) ChargeDue(now time.Time) {
+	s.mu.Lock()
+	var due []*recurringPlan
+	for _, plan := range s.plans {
+		if plan.plan.Status == models.RecurringActive && !plan.nextAttemptAt.After(now) {
+			due = append(due, plan)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, plan := range due {
+		s.charge(plan, now)
+	}
+}
+
+func (s *RecurringService) charge(plan *recurringPlan, now time.Time) {
+	s.mu.Lock()
+	if plan.plan.Status != models.RecurringActive || plan.plan.NextChargeAt == nil {
+		s.mu.Unlock()
+		return
+	}
+	scheduled := *plan.plan.NextChargeAt
+	if plan.plan.EndAt != nil && scheduled.After(*plan.plan.EndAt) {
+		plan.plan.Status = models.RecurringCompleted
+		plan.plan.NextChargeAt = nil
+		s.mu.Unlock()
+		return
+	}
+	if len(plan.plan.Charges) == 0 || !plan.plan.Charges[len(plan.plan.Charges)-1].ScheduledAt.Equal(scheduled) {
+		plan.plan.Charges = append(plan.plan.Charges, models.RecurringCharge{
+			Cycle:       len(plan.plan.Charges) + 1,
+			ScheduledAt: scheduled,
+		})
+	}
+	snapshot := plan.plan
+	s.mu.Unlock()
+
+	hash, err := s.pay(snapshot)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := &plan.plan.Charges[len(plan.plan.Charges)-1]
+	current.Attempts++
+	data := map[string]string{
+		"plan_id":     snapshot.ID,
+		"cycle":       strconv.Itoa(current.Cycle),
+		"destination": snapshot.Destination,
+		"amount":      snapshot.Amount,
+		"asset":       snapshot.Asset,
+	}
+	if err != nil {
+		current.Error = err.Error()
+		current.ErrorDetail = ExplainError(err)
+		if current.Attempts < maxRecurringAttempts {
+			current.Status = models.ChargeRetrying
+			plan.nextAttemptAt = now.Add(recurringRetryDelay << (current.Attempts - 1))
+			return
+		}
+		current.Status = models.ChargeFailed
+		data["error"] = current.Error
+		s.Wallets.Events.Publish(models.EventRecurringChargeFailed, snapshot.Wallet, data)
+	} else {
+		chargedAt := now
+		current.Status, current.TransactionHash, current.Error, current.ChargedAt = models.ChargeSucceeded, hash, "", &chargedAt
+		current.ErrorDetail = nil
+		data["transaction_hash"] = hash
+		s.Wallets.Events.Publish(models.EventRecurringChargeSucceeded, snapshot.Wallet, data)
+	}
+	s.Wallets.Audit.Record("recurring:"+snapshot.ID, "recurring."+current.Status, snapshot.Wallet, data)
+
+	next, _ := nextCycle(scheduled, snapshot.Interval)
+	for !next.After(now) {
+		// Skip cycles missed while the service was down rather than charging them all at once
+		next, _ = nextCycle(next, snapshot.Interval)
+	}
+	plan.plan.NextChargeAt = &next
+	plan.nextAttemptAt = next
+	if plan.plan.EndAt != nil && next.After(*plan.plan.EndAt) {
+		plan.plan.Status = models.RecurringCompleted
+		plan.plan.NextChargeAt = nil
+	}
+}
+
+// pay submits one charge of a plan from its custodied wallet
+func (s *RecurringService) pay(plan models.RecurringPlanResponse) (string, error) {
+	kp, ok := s.Wallets.Registry.Get(plan.Wallet)
+	if owner, owned := s.Wallets.Registry.TenantOf(plan.Wallet); !ok || !owned || owner != plan.TenantID {
+		return "", errors.New("wallet is no longer custodied for the plan's tenant")
+	}
+	if s.Wallets.isDeactivated(plan.Wallet) {
+		return "", errors.New("sender wallet is deactivated")
+	}
+	if err := s.Wallets.checkAssetPermitted(plan.Asset); err != nil {
+		return "", err
+	}
+	asset, err := parseAsset(plan.Asset)
+	if err != nil {
+		return "", errors.New("failed to parse asset: " + err.Error())
+	}
+	return s.Wallets.submitOperation(kp, &txnbuild.Payment{Destination: plan.Destination, Amount: plan.Amount, Asset: asset}, nil, models.DeviceInfo{})
+}
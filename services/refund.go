@@ -0,0 +1,371 @@
+package services
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stellar/go/txnbuild"
+)
+
+// RefundPolicy controls which refunds a tenant accepts
+type RefundPolicy struct {
+	// WindowHours is how long after the original payment a refund may be requested
+	WindowHours int `json:"window_hours"`
+	// AllowPartial permits refunding less than the full remaining amount
+	AllowPartial bool `json:"allow_partial"`
+	// Initiators lists who may request a refund: sender, recipient and/or operator
+	Initiators []string `json:"initiators"`
+	// RequireApproval holds new refunds in the requested state until an operator approves them
+	RequireApproval bool `json:"require_approval"`
+}
+
+// DefaultRefundPolicy applies to tenants without a configured policy
+var DefaultRefundPolicy = RefundPolicy{
+	WindowHours:  30 * 24,
+	AllowPartial: true,
+	Initiators:   []string{models.RefundInitiatorRecipient, models.RefundInitiatorOperator},
+}
+
+// refundTransitions lists the states each refund state may move to
+var refundTransitions = map[string][]string{
+	models.RefundRequested: {models.RefundApproved, models.RefundRejected},
+	models.RefundApproved:  {models.RefundExecuted},
+	models.RefundExecuted:  {models.RefundSettled},
+}
+
+// RefundService requests, approves and executes refunds of earlier payments under tenant policies
+type RefundService struct {
+	Wallets *WalletService
+
+	mu        sync.Mutex
+	refunds   map[string]*models.RefundResponse
+	refunded  map[string]int64
+	executing map[string]bool
+}
+
+// NewRefundService creates a new RefundService instance
+func NewRefundService(wallets *WalletService) *RefundService {
+	return &RefundService{
+		Wallets:   wallets,
+		refunds:   make(map[string]*models.RefundResponse),
+		refunded:  make(map[string]int64),
+		executing: make(map[string]bool),
+	}
+}
+
+func (s *RefundService) policy(tenantID string) RefundPolicy {
+	if policy, ok := s.Wallets.Config.TenantRefundPolicies[tenantID]; ok {
+		return policy
+	}
+	return DefaultRefundPolicy
+}
+
+// originalPayment looks up the payment operation of a transaction via Horizon
+func (s *RefundService) originalPayment(hash string) (*operations.Payment, error) {
+	ops, err := s.Wallets.Config.HorizonClient.Operations(horizonclient.OperationRequest{ForTransaction: hash})
+	if err != nil {
+		if herr, ok := err.(*horizonclient.Error); ok && herr.Response.StatusCode == http.StatusNotFound {
+			return nil, errors.New("original payment not found")
+		}
+		return nil, errors.New("failed to fetch original payment: " + err.Error())
+	}
+	for _, op := range ops.Embedded.Records {
+		if payment, ok := op.(operations.Payment); ok && payment.TransactionSuccessful {
+			return &payment, nil
+		}
+	}
+	return nil, errors.New("original payment not found")
+}
+
+// RequestRefund validates a refund against the tenant's policy and records it. The refund is paid from the
+// original recipient's wallet, so without its secret key the request must be authenticated as the tenant
+// of that managed wallet.
+func (s *RefundService) RequestRefund(tenantID string, authenticated bool, req models.CreateRefundRequest) (*models.RefundResponse, error) {
+	refund, _, err := s.requestRefund(tenantID, authenticated, req)
+	return refund, err
+}
+
+// requestRefund records a refund and returns it with the keypair that signs for the original recipient
+func (s *RefundService) requestRefund(tenantID string, authenticated bool, req models.CreateRefundRequest) (*models.RefundResponse, *keypair.Full, error) {
+	policy := s.policy(tenantID)
+
+	allowedInitiator := false
+	for _, initiator := range policy.Initiators {
+		if initiator == req.Initiator {
+			allowedInitiator = true
+		}
+	}
+	if !allowedInitiator {
+		return nil, nil, errors.New("refund initiator not permitted by policy")
+	}
+
+	payment, err := s.originalPayment(req.TransactionHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	signerTenant := ""
+	if authenticated {
+		signerTenant = tenantID
+	}
+	signer, err := s.Wallets.authorizedSigner(signerTenant, payment.To, req.FromSecretKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if time.Since(payment.LedgerCloseTime) > time.Duration(policy.WindowHours)*time.Hour {
+		return nil, nil, errors.New("refund window has expired")
+	}
+
+	originalStroops, err := amount.ParseInt64(payment.Amount)
+	if err != nil {
+		return nil, nil, errors.New("failed to parse original amount: " + err.Error())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	remaining := originalStroops - s.refunded[req.TransactionHash]
+	refundStroops := remaining
+	if req.Amount != "" {
+		if refundStroops, err = parseAmount("amount", req.Amount); err != nil {
+			return nil, nil, err
+		}
+	}
+	if remaining <= 0 || refundStroops > remaining {
+		return nil, nil, errors.New("refund exceeds refundable amount")
+	}
+	if refundStroops < remaining && !policy.AllowPartial {
+		return nil, nil, errors.New("partial refunds not permitted by policy")
+	}
+
+	asset := "native"
+	if payment.Asset.Type != "native" {
+		asset = payment.Asset.Code + ":" + payment.Asset.Issuer
+	}
+	now := time.Now().UTC()
+	refund := &models.RefundResponse{
+		ID:                      newID(),
+		TenantID:                tenantID,
+		State:                   models.RefundRequested,
+		OriginalTransactionHash: req.TransactionHash,
+		OriginalAmount:          payment.Amount,
+		Asset:                   asset,
+		Amount:                  amount.StringFromInt64(refundStroops),
+		From:                    payment.To,
+		To:                      payment.From,
+		Initiator:               req.Initiator,
+		Reason:                  req.Reason,
+		CreatedAt:               now,
+		UpdatedAt:               now,
+	}
+	if !policy.RequireApproval {
+		refund.State = models.RefundApproved
+	}
+	s.refunds[refund.ID] = refund
+	s.refunded[req.TransactionHash] += refundStroops
+
+	result := *refund
+	return &result, signer, nil
+}
+
+// transitionLocked moves a refund to state if the state machine allows it
+func (s *RefundService) transitionLocked(refund *models.RefundResponse, state string) error {
+	for _, next := range refundTransitions[refund.State] {
+		if next == state {
+			refund.State = state
+			refund.UpdatedAt = time.Now().UTC()
+			return nil
+		}
+	}
+	return errors.New("invalid refund state transition: " + refund.State + " to " + state)
+}
+
+// ApproveRefund approves a requested refund
+func (s *RefundService) ApproveRefund(id string) (*models.RefundResponse, error) {
+	return s.decide(id, models.RefundApproved)
+}
+
+// RejectRefund rejects a requested refund and releases its amount
+func (s *RefundService) RejectRefund(id string) (*models.RefundResponse, error) {
+	return s.decide(id, models.RefundRejected)
+}
+
+func (s *RefundService) decide(id, state string) (*models.RefundResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refund, ok := s.refunds[id]
+	if !ok {
+		return nil, errors.New("refund not found")
+	}
+	if err := s.transitionLocked(refund, state); err != nil {
+		return nil, err
+	}
+	if state == models.RefundRejected {
+		if stroops, err := amount.ParseInt64(refund.Amount); err == nil {
+			s.refunded[refund.OriginalTransactionHash] -= stroops
+		}
+	}
+	result := *refund
+	return &result, nil
+}
+
+// ExecuteRefund pays an approved refund back from the original recipient to the original sender. Without
+// the recipient's secret key, the request must be authenticated as the tenant of that managed wallet.
+func (s *RefundService) ExecuteRefund(tenantID string, authenticated bool, id string, req models.ExecuteRefundRequest) (*models.RefundResponse, error) {
+	s.mu.Lock()
+	refund, ok := s.refunds[id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, errors.New("refund not found")
+	}
+	from := refund.From
+	s.mu.Unlock()
+
+	signerTenant := ""
+	if authenticated {
+		signerTenant = tenantID
+	}
+	signer, err := s.Wallets.authorizedSigner(signerTenant, from, req.FromSecretKey)
+	if err != nil {
+		return nil, err
+	}
+	return s.executeRefund(id, signer)
+}
+
+// executeRefund pays an approved refund, signing for the original recipient with signer
+func (s *RefundService) executeRefund(id string, signer *keypair.Full) (*models.RefundResponse, error) {
+	s.mu.Lock()
+	refund, ok := s.refunds[id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, errors.New("refund not found")
+	}
+	if refund.State != models.RefundApproved || s.executing[id] {
+		s.mu.Unlock()
+		return nil, errors.New("invalid refund state transition: " + refund.State + " to " + models.RefundExecuted)
+	}
+	s.executing[id] = true
+	pending := *refund
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.executing, id)
+		s.mu.Unlock()
+	}()
+
+	asset, err := parseAsset(pending.Asset)
+	if err != nil {
+		return nil, errors.New("failed to parse refund asset: " + err.Error())
+	}
+
+	ops := []txnbuild.Operation{&txnbuild.Payment{Destination: pending.To, Amount: pending.Amount, Asset: asset}}
+	memo := returnMemo(pending.OriginalTransactionHash)
+	reservation, err := s.Wallets.screenPayments(pending.From, ops, memo, models.DeviceInfo{})
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.Wallets.buildTransaction(pending.From, txnbuild.TransactionParams{
+		Operations:    ops,
+		Memo:          memo,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+	}, signer)
+	if err != nil {
+		s.Wallets.releasePayments(reservation)
+		return nil, err
+	}
+
+	resp, err := s.Wallets.submitPayments(reservation, tx, nil, []*keypair.Full{signer})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.transitionLocked(refund, models.RefundExecuted); err != nil {
+		return nil, err
+	}
+	refund.RefundTransactionHash = resp.Hash
+	result := *refund
+	return &result, nil
+}
+
+// returnMemo references the refunded transaction in a MEMO_RETURN, or no memo if the hash is malformed
+func returnMemo(hash string) txnbuild.Memo {
+	raw, err := hex.DecodeString(hash)
+	if err != nil || len(raw) != 32 {
+		return nil
+	}
+	var memo txnbuild.MemoReturn
+	copy(memo[:], raw)
+	return memo
+}
+
+// RefundPayment refunds a payment straight from its transaction hash: the refund is requested on behalf
+// of the original recipient and, unless the tenant's policy holds it for approval, executed immediately
+func (s *RefundService) RefundPayment(tenantID string, authenticated bool, hash string, req models.RefundPaymentRequest) (*models.RefundResponse, error) {
+	refund, signer, err := s.requestRefund(tenantID, authenticated, models.CreateRefundRequest{
+		TransactionHash: hash,
+		Amount:          req.Amount,
+		Initiator:       models.RefundInitiatorRecipient,
+		Reason:          req.Reason,
+		FromSecretKey:   req.FromSecretKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if refund.State != models.RefundApproved {
+		return refund, nil
+	}
+	return s.executeRefund(refund.ID, signer)
+}
+
+// GetRefund returns a refund, settling executed refunds whose transaction Horizon reports as successful
+func (s *RefundService) GetRefund(id string) (*models.RefundResponse, error) {
+	s.mu.Lock()
+	refund, ok := s.refunds[id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, errors.New("refund not found")
+	}
+	executed := refund.State == models.RefundExecuted
+	hash := refund.RefundTransactionHash
+	s.mu.Unlock()
+
+	if executed {
+		if tx, err := s.Wallets.Config.HorizonClient.TransactionDetail(hash); err == nil && tx.Successful {
+			s.mu.Lock()
+			s.transitionLocked(refund, models.RefundSettled)
+			s.mu.Unlock()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := *refund
+	return &result, nil
+}
+
+// PurgeTenant discards every refund recorded for a tenant
+func (s *RefundService) PurgeTenant(tenantID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, refund := range s.refunds {
+		if refund.TenantID != tenantID {
+			continue
+		}
+		if refund.State != models.RefundRejected {
+			if stroops, err := amount.ParseInt64(refund.Amount); err == nil {
+				s.refunded[refund.OriginalTransactionHash] -= stroops
+			}
+		}
+		delete(s.refunds, id)
+	}
+}
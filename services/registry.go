@@ -0,0 +1,195 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/keypair"
+)
+
+// maxRegistryChanges bounds how many change feed entries are retained; older cursors expire
+const maxRegistryChanges = 100000
+
+type managedWallet struct {
+	keypair  *keypair.Full
+	tenantID string
+	// labels and metadata are the client's own annotations of the wallet
+	labels   []string
+	metadata map[string]string
+}
+
+// WalletRegistry keeps the keypairs of wallets created and custodied by this service
+type WalletRegistry struct {
+	mu      sync.RWMutex
+	wallets map[string]managedWallet
+	changes []models.WalletChange
+	seq     int64
+}
+
+// NewWalletRegistry creates a new WalletRegistry instance
+func NewWalletRegistry() *WalletRegistry {
+	return &WalletRegistry{wallets: make(map[string]managedWallet)}
+}
+
+// Add registers a managed wallet owned by a tenant
+func (r *WalletRegistry) Add(tenantID string, kp *keypair.Full) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.wallets[kp.Address()] = managedWallet{keypair: kp, tenantID: tenantID}
+	r.recordLocked(models.WalletChangeCreated, kp.Address(), tenantID, "")
+}
+
+// Remove stops custodying a wallet
+func (r *WalletRegistry) Remove(publicKey string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if wallet, ok := r.wallets[publicKey]; ok {
+		r.recordLocked(models.WalletChangeClosed, publicKey, wallet.tenantID, "")
+	}
+	delete(r.wallets, publicKey)
+}
+
+// RecordUpdate appends an update of a managed wallet to the change feed
+func (r *WalletRegistry) RecordUpdate(publicKey, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if wallet, ok := r.wallets[publicKey]; ok {
+		r.recordLocked(models.WalletChangeUpdated, publicKey, wallet.tenantID, reason)
+	}
+}
+
+func (r *WalletRegistry) recordLocked(changeType, publicKey, tenantID, reason string) {
+	r.seq++
+	r.changes = append(r.changes, models.WalletChange{
+		Cursor:     strconv.FormatInt(r.seq, 10),
+		Type:       changeType,
+		PublicKey:  publicKey,
+		TenantID:   tenantID,
+		Reason:     reason,
+		OccurredAt: time.Now().UTC(),
+	})
+	if len(r.changes) > maxRegistryChanges {
+		r.changes = append([]models.WalletChange(nil), r.changes[len(r.changes)-maxRegistryChanges:]...)
+	}
+}
+
+// SetMetadata replaces the labels and metadata of a managed wallet; it reports false for unknown wallets
+func (r *WalletRegistry) SetMetadata(publicKey string, labels []string, metadata map[string]string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	wallet, ok := r.wallets[publicKey]
+	if !ok {
+		return false
+	}
+	wallet.labels = append([]string(nil), labels...)
+	wallet.metadata = make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		wallet.metadata[key] = value
+	}
+	r.wallets[publicKey] = wallet
+	return true
+}
+
+// Metadata returns copies of a managed wallet's labels and metadata
+func (r *WalletRegistry) Metadata(publicKey string) ([]string, map[string]string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	wallet, ok := r.wallets[publicKey]
+	if !ok {
+		return nil, nil, false
+	}
+	metadata := make(map[string]string, len(wallet.metadata))
+	for key, value := range wallet.metadata {
+		metadata[key] = value
+	}
+	return append([]string(nil), wallet.labels...), metadata, true
+}
+
+// Get returns the keypair of a managed wallet, if the service custodies it
+func (r *WalletRegistry) Get(publicKey string) (*keypair.Full, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	wallet, ok := r.wallets[publicKey]
+	return wallet.keypair, ok
+}
+
+// TenantOf returns the tenant owning a managed wallet
+func (r *WalletRegistry) TenantOf(publicKey string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	wallet, ok := r.wallets[publicKey]
+	return wallet.tenantID, ok
+}
+
+// PublicKeys returns the addresses of all managed wallets in sorted order
+func (r *WalletRegistry) PublicKeys() []string {
+	return r.filter(func(managedWallet) bool { return true })
+}
+
+// TenantPublicKeys returns the addresses of a tenant's managed wallets in sorted order
+func (r *WalletRegistry) TenantPublicKeys(tenantID string) []string {
+	return r.filter(func(wallet managedWallet) bool { return wallet.tenantID == tenantID })
+}
+
+func (r *WalletRegistry) filter(match func(managedWallet) bool) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys := make([]string, 0, len(r.wallets))
+	for publicKey, wallet := range r.wallets {
+		if match(wallet) {
+			keys = append(keys, publicKey)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Changes returns up to limit of a tenant's changes recorded after the since cursor, oldest first, with
+// the cursor to resume from. expired reports that since is older than the retained feed.
+func (r *WalletRegistry) Changes(tenantID string, since int64, limit int) (changes []models.WalletChange, next int64, expired bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	oldest := r.seq - int64(len(r.changes))
+	if since < oldest {
+		return nil, since, true
+	}
+	if since > r.seq {
+		since = r.seq
+	}
+	changes = []models.WalletChange{}
+	next = r.seq
+	for i, change := range r.changes[since-oldest:] {
+		if len(changes) == limit {
+			next = since + int64(i)
+			break
+		}
+		if change.TenantID == tenantID {
+			changes = append(changes, change)
+		}
+	}
+	return changes, next, false
+}
+
+// WalletChanges returns a page of a tenant's wallet registry change feed after the since cursor
+func (s *WalletService) WalletChanges(tenantID, since string, limit int) (*models.WalletChangesResponse, error) {
+	cursor := int64(0)
+	if since != "" {
+		var err error
+		if cursor, err = strconv.ParseInt(since, 10, 64); err != nil || cursor < 0 {
+			return nil, errors.New("invalid cursor")
+		}
+	}
+	if limit <= 0 || limit > 1000 {
+		return nil, errors.New("invalid limit: must be between 1 and 1000")
+	}
+
+	changes, next, expired := s.Registry.Changes(tenantID, cursor, limit)
+	if expired {
+		return nil, errors.New("cursor has expired; resync the registry and restart the feed")
+	}
+	return &models.WalletChangesResponse{Changes: changes, NextCursor: strconv.FormatInt(next, 10)}, nil
+}
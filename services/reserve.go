@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// baseReserveStroops returns the network base reserve from the most recently closed ledger
+func (s *WalletService) baseReserveStroops() (int64, error) {
+	ledgers, err := s.Config.HorizonClient.Ledgers(horizonclient.LedgerRequest{Order: horizonclient.OrderDesc, Limit: 1})
+	if err != nil {
+		return 0, errors.New("failed to fetch latest ledger: " + err.Error())
+	}
+	if len(ledgers.Embedded.Records) == 0 {
+		return 0, errors.New("failed to fetch latest ledger: no ledgers returned")
+	}
+	return int64(ledgers.Embedded.Records[0].BaseReserve), nil
+}
+
+// minimumBalanceStroops returns the XLM an account must keep locked: two base reserves plus one per
+// subentry, adjusted for reserves the account sponsors for others or has sponsored by others
+func minimumBalanceStroops(account hProtocol.Account, baseReserve int64) int64 {
+	entries := 2 + int64(account.SubentryCount) + int64(account.NumSponsoring) - int64(account.NumSponsored)
+	return entries * baseReserve
+}
+
+// availableBalance returns the spendable part of a balance after selling liabilities and, for XLM,
+// the account's minimum balance. The result is never negative.
+func availableBalance(balance hProtocol.Balance, account hProtocol.Account, baseReserve int64) string {
+	available, err := amount.ParseInt64(balance.Balance)
+	if err != nil {
+		return balance.Balance
+	}
+	if balance.SellingLiabilities != "" {
+		if liabilities, err := amount.ParseInt64(balance.SellingLiabilities); err == nil {
+			available -= liabilities
+		}
+	}
+	if balance.Type == "native" {
+		available -= minimumBalanceStroops(account, baseReserve)
+	}
+	if available < 0 {
+		available = 0
+	}
+	return amount.StringFromInt64(available)
+}
+
+// GetWalletReserve returns a wallet's minimum balance and the XLM it can send above it
+func (s *WalletService) GetWalletReserve(publicKey string) (*models.WalletReserveResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: publicKey})
+	if err != nil {
+		if herr, ok := err.(*horizonclient.Error); ok && herr.Response.StatusCode == http.StatusNotFound {
+			return nil, errors.New("wallet not found")
+		}
+		return nil, errors.New("failed to fetch wallet account details: " + err.Error())
+	}
+	baseReserve, err := s.baseReserveStroops()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.WalletReserveResponse{
+		PublicKey:      publicKey,
+		BaseReserve:    amount.StringFromInt64(baseReserve),
+		SubentryCount:  account.SubentryCount,
+		NumSponsoring:  account.NumSponsoring,
+		NumSponsored:   account.NumSponsored,
+		MinimumBalance: amount.StringFromInt64(minimumBalanceStroops(account, baseReserve)),
+		NativeBalance:  "0.0000000",
+		Available:      "0.0000000",
+	}
+	for _, balance := range account.Balances {
+		if balance.Type == "native" {
+			response.NativeBalance = balance.Balance
+			response.Available = availableBalance(balance, account, baseReserve)
+		}
+	}
+	return response, nil
+}
+
+// checkReserve refuses a transfer whose XLM debits, with the fee when the wallet pays it, would take the
+// sender below its minimum balance. A claimable balance also raises the minimum by a base reserve, whatever
+// asset it holds, as the sender sponsors it. Transfers that neither send XLM nor add entries are left to the
+// network, which only needs the fee from them.
+func (s *WalletService) checkReserve(transfer *preparedTransfer, ops []txnbuild.Operation) error {
+	var debit, addedEntries int64
+	for _, op := range ops {
+		var value string
+		var asset txnbuild.Asset
+		switch op := op.(type) {
+		case *txnbuild.Payment:
+			value, asset = op.Amount, op.Asset
+		case *txnbuild.PathPaymentStrictReceive:
+			value, asset = op.SendMax, op.SendAsset
+		case *txnbuild.PathPaymentStrictSend:
+			value, asset = op.SendAmount, op.SendAsset
+		case *txnbuild.CreateClaimableBalance:
+			value, asset = op.Amount, op.Asset
+			addedEntries++
+		default:
+			continue
+		}
+		if asset == nil || !asset.IsNative() {
+			continue
+		}
+		stroops, err := amount.ParseInt64(value)
+		if err != nil {
+			return errors.New("invalid amount: " + err.Error())
+		}
+		debit += stroops
+	}
+	if debit == 0 && addedEntries == 0 {
+		return nil
+	}
+
+	sender := transfer.senderKP.Address()
+	account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: sender})
+	if err != nil {
+		return errors.New("failed to fetch sender account details: " + err.Error())
+	}
+	baseReserve, err := s.baseReserveStroops()
+	if err != nil {
+		return err
+	}
+	needed := debit + addedEntries*baseReserve
+	policy := s.Config.FeeSponsorshipPolicy
+	if _, custodied := s.Registry.Get(sender); !custodied || (policy != FeeSponsorshipAuto && policy != FeeSponsorshipAlways) {
+		needed += s.Fees.BaseFee() * int64(len(ops))
+	}
+	var available int64
+	for _, balance := range account.Balances {
+		if balance.Type == "native" {
+			available, _ = amount.ParseInt64(availableBalance(balance, account, baseReserve))
+		}
+	}
+	if needed > available {
+		return errors.New("insufficient XLM above reserve: the transfer needs " + amount.StringFromInt64(needed) +
+			" XLM but only " + amount.StringFromInt64(available) + " XLM is spendable above the minimum balance of " +
+			amount.StringFromInt64(minimumBalanceStroops(account, baseReserve)) + " XLM")
+	}
+	return nil
+}
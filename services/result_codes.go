@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/stellar/go/xdr"
+)
+
+// resultCodeOverrides maps the XDR result codes whose Horizon names do not follow from their XDR names
+var resultCodeOverrides = map[string]string{
+	"TransactionResultCodeTxNoAccount":                                         "tx_no_source_account",
+	"TransactionResultCodeTxBadMinSeqAgeOrGap":                                 "tx_bad_minseq_age_or_gap",
+	"OperationResultCodeOpNoAccount":                                           "op_no_source_account",
+	"AccountMergeResultCodeAccountMergeSeqnumTooFar":                           "op_seq_num_too_far",
+	"AccountMergeResultCodeAccountMergeNoAccount":                              "op_no_account",
+	"AllowTrustResultCodeAllowTrustTrustNotRequired":                           "op_not_required",
+	"AllowTrustResultCodeAllowTrustNoTrustLine":                                "op_no_trust",
+	"ChangeTrustResultCodeChangeTrustNotAuthMaintainLiabilities":               "op_not_aut_maintain_liabilities",
+	"ClawbackClaimableBalanceResultCodeClawbackClaimableBalanceNotIssuer":      "op_no_issuer",
+	"CreateAccountResultCodeCreateAccountAlreadyExist":                         "op_already_exists",
+	"ExtendFootprintTtlResultCodeExtendFootprintTtlInsufficientRefundableFee":  "insufficient_refundable_fee",
+	"ExtendFootprintTtlResultCodeExtendFootprintTtlResourceLimitExceeded":      "resource_limit_exceeded",
+	"InvokeHostFunctionResultCodeInvokeHostFunctionInsufficientRefundableFee":  "insufficient_refundable_fee",
+	"InvokeHostFunctionResultCodeInvokeHostFunctionEntryArchived":              "entry_archived",
+	"InvokeHostFunctionResultCodeInvokeHostFunctionResourceLimitExceeded":      "resource_limit_exceeded",
+	"InvokeHostFunctionResultCodeInvokeHostFunctionTrapped":                    "function_trapped",
+	"ManageBuyOfferResultCodeManageBuyOfferNotFound":                           "op_offer_not_found",
+	"ManageBuyOfferResultCodeManageBuyOfferBuyNoIssuer":                        "buy_no_issuer",
+	"ManageBuyOfferResultCodeManageBuyOfferBuyNotAuthorized":                   "buy_not_authorized",
+	"ManageBuyOfferResultCodeManageBuyOfferSellNotAuthorized":                  "sell_not_authorized",
+	"ManageDataResultCodeManageDataInvalidName":                                "op_data_invalid_name",
+	"ManageDataResultCodeManageDataNameNotFound":                               "op_data_name_not_found",
+	"ManageSellOfferResultCodeManageSellOfferNotFound":                         "op_offer_not_found",
+	"ManageSellOfferResultCodeManageSellOfferBuyNoIssuer":                      "buy_no_issuer",
+	"ManageSellOfferResultCodeManageSellOfferBuyNotAuthorized":                 "buy_not_authorized",
+	"ManageSellOfferResultCodeManageSellOfferSellNotAuthorized":                "sell_not_authorized",
+	"PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveOfferCrossSelf": "op_cross_self",
+	"PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveOverSendmax":    "op_over_source_max",
+	"PathPaymentStrictSendResultCodePathPaymentStrictSendOfferCrossSelf":       "op_cross_self",
+	"PathPaymentStrictSendResultCodePathPaymentStrictSendUnderDestmin":         "op_under_dest_min",
+	"RestoreFootprintResultCodeRestoreFootprintInsufficientRefundableFee":      "insufficient_refundable_fee",
+	"RestoreFootprintResultCodeRestoreFootprintResourceLimitExceeded":          "resource_limit_exceeded",
+	"SetTrustLineFlagsResultCodeSetTrustLineFlagsNoTrustLine":                  "op_no_trust",
+}
+
+// resultCodeName converts an XDR result code such as PaymentResultCodePaymentUnderfunded into the name
+// Horizon gives it, op_underfunded; prefix is prepended to operation-specific codes
+func resultCodeName(code fmt.Stringer, prefix string) string {
+	name := code.String()
+	if override, ok := resultCodeOverrides[name]; ok {
+		return override
+	}
+	i := strings.Index(name, "ResultCode")
+	if i < 0 {
+		return ""
+	}
+	kind, name := name[:i], name[i+len("ResultCode"):]
+	switch kind {
+	case "Transaction", "Operation":
+		// TxBadSeq and OpBadAuth carry their own prefix
+	default:
+		name = strings.TrimPrefix(name, kind)
+	}
+	var b strings.Builder
+	b.WriteString(prefix)
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// operationResultCode returns the Horizon name of one operation's result code
+func operationResultCode(result xdr.OperationResult) string {
+	if result.Code != xdr.OperationResultCodeOpInner || result.Tr == nil {
+		return resultCodeName(result.Code, "")
+	}
+	// The inner result is the one non-nil arm of the union; every arm has a Code
+	tr := reflect.ValueOf(*result.Tr)
+	for i := 0; i < tr.NumField(); i++ {
+		arm := tr.Field(i)
+		if arm.Kind() != reflect.Ptr || arm.IsNil() {
+			continue
+		}
+		if code, ok := arm.Elem().FieldByName("Code").Interface().(fmt.Stringer); ok {
+			return resultCodeName(code, "op_")
+		}
+	}
+	return ""
+}
+
+// decodeResultCodes decodes a base64 TransactionResult into Horizon's transaction and operation result
+// codes. For a fee bump the codes are those of the inner transaction.
+func decodeResultCodes(resultXDR string) (string, []string, error) {
+	var result xdr.TransactionResult
+	if err := xdr.SafeUnmarshalBase64(resultXDR, &result); err != nil {
+		return "", nil, errors.New("failed to decode transaction result: " + err.Error())
+	}
+	txCode := resultCodeName(result.Result.Code, "")
+	var opResults []xdr.OperationResult
+	if inner, ok := result.Result.GetInnerResultPair(); ok {
+		txCode = resultCodeName(inner.Result.Result.Code, "")
+		if results, ok := inner.Result.Result.GetResults(); ok {
+			opResults = results
+		}
+	} else if results, ok := result.OperationResults(); ok {
+		opResults = results
+	}
+	opCodes := make([]string, 0, len(opResults))
+	for _, op := range opResults {
+		opCodes = append(opCodes, operationResultCode(op))
+	}
+	return txCode, opCodes, nil
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+)
+
+// resultExplanation is the translation of one Stellar result code
+type resultExplanation struct {
+	code        string
+	explanation string
+	remediation string
+}
+
+// resultExplanations translates the Stellar result codes clients run into into stable error codes. The
+// codes are part of the API: change an explanation freely, but never a code.
+var resultExplanations = map[string]resultExplanation{
+	"tx_failed": {"transaction_failed",
+		"One of the transaction's operations failed, so none of them were applied.",
+		"Check the operation result codes for the operation that failed."},
+	"tx_too_early": {"transaction_not_yet_valid",
+		"The transaction's time bounds start in the future.",
+		"Submit the transaction once its minimum time has passed."},
+	"tx_too_late": {"transaction_expired",
+		"The transaction's time bounds expired before it was included in a ledger.",
+		"Build, sign and submit a new transaction."},
+	"tx_missing_operation": {"transaction_empty",
+		"The transaction has no operations.",
+		"Add at least one operation to the transaction."},
+	"tx_bad_seq": {"sequence_mismatch",
+		"The transaction's sequence number is not the source account's next one, usually because another transaction from the account was submitted first.",
+		"Rebuild the transaction with the account's current sequence number and submit it again."},
+	"tx_bad_auth": {"signature_invalid",
+		"The transaction's signatures do not meet the source account's threshold, or were made for another network.",
+		"Sign with the account's keys for this network, enough to meet its thresholds."},
+	"tx_bad_auth_extra": {"signature_unused",
+		"The transaction carries signatures that no operation needs.",
+		"Remove the extra signatures and submit again."},
+	"tx_insufficient_balance": {"fee_unaffordable",
+		"The source account cannot pay the transaction fee without dropping below its minimum XLM reserve.",
+		"Fund the source account with more XLM."},
+	"tx_no_source_account": {"source_account_missing",
+		"The transaction's source account does not exist on the network.",
+		"Create and fund the source account first."},
+	"tx_insufficient_fee": {"fee_too_low",
+		"The fee offered is below what the network currently charges; it is congested.",
+		"Retry with a higher base fee, or fee-bump the transaction."},
+	"tx_internal_error": {"network_internal_error",
+		"The network hit an internal error applying the transaction.",
+		"Retry later; contact support if it persists."},
+	"tx_bad_sponsorship": {"sponsorship_unclosed",
+		"A sponsorship of future reserves was begun but not ended within the transaction.",
+		"Pair every begin sponsoring operation with an end sponsoring operation."},
+	"tx_malformed": {"transaction_malformed",
+		"The transaction is malformed.",
+		"Rebuild the transaction."},
+
+	"op_malformed": {"operation_invalid",
+		"An operation has invalid parameters, such as a negative amount or an invalid asset.",
+		"Correct the operation's parameters."},
+	"op_underfunded": {"insufficient_balance",
+		"The sending account does not hold enough of the asset, after its reserves and open offers, to cover the amount.",
+		"Lower the amount or fund the account."},
+	"op_low_reserve": {"insufficient_reserve",
+		"The account would drop below its minimum XLM reserve; each trustline, offer, signer and data entry raises the reserve.",
+		"Fund the account with more XLM or remove unused trustlines, offers or data entries."},
+	"op_no_trust": {"trustline_missing",
+		"An account involved does not have a trustline to the asset.",
+		"Have the account add a trustline to the asset first."},
+	"op_src_no_trust": {"source_trustline_missing",
+		"The sending account does not have a trustline to the asset.",
+		"Add a trustline to the asset to the sending account."},
+	"op_not_authorized": {"trustline_not_authorized",
+		"The issuer has not authorized the account to hold the asset.",
+		"Ask the issuer to authorize the account's trustline."},
+	"op_src_not_authorized": {"source_not_authorized",
+		"The issuer has not authorized the sending account to hold the asset.",
+		"Ask the issuer to authorize the sending account's trustline."},
+	"op_line_full": {"trustline_limit_exceeded",
+		"The payment would take the recipient over its trustline limit.",
+		"Lower the amount, or have the recipient raise its trustline limit."},
+	"op_no_destination": {"destination_missing",
+		"The destination account does not exist.",
+		"Create the destination account first, or send the funds as a claimable balance."},
+	"op_no_issuer": {"issuer_missing",
+		"The asset's issuing account does not exist.",
+		"Check the asset issuer."},
+	"op_already_exists": {"account_exists",
+		"The account to create already exists.",
+		"Send a payment to the account instead of creating it."},
+	"op_bad_auth": {"operation_signature_invalid",
+		"An operation's source account has not signed the transaction with enough weight.",
+		"Add the signatures of every operation source account."},
+	"op_no_source_account": {"operation_source_missing",
+		"An operation's source account does not exist.",
+		"Create the operation's source account first."},
+	"op_not_supported": {"operation_not_supported",
+		"The network does not support this operation.",
+		"Use a supported operation."},
+	"op_too_many_subentries": {"too_many_subentries",
+		"The account has reached the limit of 1000 trustlines, offers, signers and data entries.",
+		"Remove unused trustlines, offers, signers or data entries."},
+	"op_too_many_sponsoring": {"too_many_sponsoring",
+		"The sponsoring account sponsors the maximum number of entries.",
+		"Revoke unused sponsorships or use another sponsor."},
+	"op_too_few_offers": {"no_path",
+		"There is not enough liquidity on the order books to convert between the assets.",
+		"Retry with a smaller amount or another path."},
+	"op_over_source_max": {"slippage_exceeded",
+		"Converting the assets would cost more than the maximum allowed.",
+		"Request a fresh quote or allow more slippage."},
+	"op_under_dest_min": {"slippage_exceeded",
+		"Converting the assets would deliver less than the minimum allowed.",
+		"Request a fresh quote or allow more slippage."},
+	"op_cross_self": {"offer_crosses_self",
+		"The operation would trade against one of the account's own offers.",
+		"Cancel the account's crossing offer first."},
+	"op_offer_not_found": {"offer_missing",
+		"The offer to update or cancel does not exist.",
+		"Refresh the account's offers and retry."},
+	"op_invalid_limit": {"trustline_limit_invalid",
+		"The trustline limit is below the account's balance or liabilities in the asset.",
+		"Raise the limit, or reduce the balance before lowering it."},
+	"op_has_sub_entries": {"account_has_subentries",
+		"The account still has trustlines, offers, signers or data entries, so it cannot be merged.",
+		"Remove the account's subentries before merging it."},
+	"op_immutable_set": {"account_immutable",
+		"The account's authorization flags are immutable.",
+		"This cannot be changed; use another account."},
+	"op_dest_full": {"destination_balance_full",
+		"Merging would overflow the destination's XLM balance.",
+		"Merge into another destination."},
+	"op_seq_num_too_far": {"sequence_too_far",
+		"The account's sequence number is too high for it to be merged in this ledger.",
+		"Retry the merge in a later ledger."},
+	"op_is_sponsor": {"account_is_sponsor",
+		"The account sponsors reserves of other accounts, so it cannot be merged.",
+		"Revoke or transfer the account's sponsorships first."},
+	"op_data_name_not_found": {"data_entry_missing",
+		"The data entry to delete does not exist.",
+		"Refresh the account's data entries and retry."},
+	"op_does_not_exist": {"entry_missing",
+		"The claimable balance or sponsored entry does not exist.",
+		"Refresh and retry; it may already have been claimed or revoked."},
+	"op_cannot_claim": {"claim_not_allowed",
+		"The account is not a claimant of the claimable balance, or its predicate does not allow claiming now.",
+		"Check the balance's claimants and predicates."},
+	"op_not_required": {"authorization_not_required",
+		"The asset issuer does not require authorization.",
+		"No authorization is needed."},
+	"op_cant_revoke": {"authorization_not_revocable",
+		"The asset issuer cannot revoke authorization.",
+		"This cannot be changed by the issuer."},
+	"op_too_many_signers": {"too_many_signers",
+		"The account has the maximum of 20 signers.",
+		"Remove a signer before adding another."},
+	"op_bad_flags": {"flags_invalid",
+		"The combination of account flags is invalid.",
+		"Correct the flags; clawback requires revocable."},
+	"op_not_sponsor": {"not_sponsor",
+		"The account revoking the sponsorship is not the entry's sponsor.",
+		"Revoke the sponsorship from the sponsoring account."},
+}
+
+// ExplainResultCodes translates a failed transaction's result codes. The first failed operation explains
+// a tx_failed transaction; otherwise the transaction code does. Codes without a translation are reported
+// under transaction_failed with the raw code.
+func ExplainResultCodes(transactionCode string, operationCodes []string) *models.ResultExplanation {
+	resultCode := transactionCode
+	if transactionCode == "tx_failed" || transactionCode == "" {
+		for _, code := range operationCodes {
+			if code != "op_success" && code != "" {
+				resultCode = code
+				break
+			}
+		}
+	}
+	if resultCode == "" {
+		return nil
+	}
+	if translation, ok := resultExplanations[resultCode]; ok {
+		return &models.ResultExplanation{
+			Code:        translation.code,
+			ResultCode:  resultCode,
+			Explanation: translation.explanation,
+			Remediation: translation.remediation,
+		}
+	}
+	return &models.ResultExplanation{
+		Code:        "transaction_failed",
+		ResultCode:  resultCode,
+		Explanation: "The network rejected the transaction with result code " + resultCode + ".",
+		Remediation: "Check the result codes; retrying unchanged will fail again.",
+	}
+}
+
+// ExplainError translates the result codes of a transaction the network rejected; it returns nil for
+// errors that did not come from the network
+func ExplainError(err error) *models.ResultExplanation {
+	var txErr *TransactionError
+	if !errors.As(err, &txErr) {
+		return nil
+	}
+	return ExplainResultCodes(txErr.TransactionCode, txErr.OperationCodes)
+}
@@ -0,0 +1,391 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// maxRoutingExecutions bounds how many rule executions are kept per tenant
+const maxRoutingExecutions = 1000
+
+// RoutingService stores tenants' inbound payment routing rules and applies them to received payments
+type RoutingService struct {
+	Wallets *WalletService
+
+	mu         sync.RWMutex
+	rules      map[string][]models.RoutingRule
+	executions map[string][]models.RoutingExecution
+}
+
+// NewRoutingService creates a new RoutingService instance
+func NewRoutingService(wallets *WalletService) *RoutingService {
+	return &RoutingService{
+		Wallets:    wallets,
+		rules:      make(map[string][]models.RoutingRule),
+		executions: make(map[string][]models.RoutingExecution),
+	}
+}
+
+// GetRules returns a tenant's routing rules
+func (s *RoutingService) GetRules(tenantID string) *models.RoutingRulesResponse {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &models.RoutingRulesResponse{TenantID: tenantID, Rules: append([]models.RoutingRule{}, s.rules[tenantID]...)}
+}
+
+// SetRules validates and replaces a tenant's routing rules. Rules forward and convert inbound payments with
+// the custodied keys of the tenant's wallets, so tenantID must be authenticated; it is empty otherwise.
+func (s *RoutingService) SetRules(tenantID string, req models.RoutingRulesRequest) (*models.RoutingRulesResponse, error) {
+	if tenantID == "" {
+		return nil, errors.New("routing rules require an authenticated tenant")
+	}
+	rules := make([]models.RoutingRule, 0, len(req.Rules))
+	for _, rule := range req.Rules {
+		if rule.Wallet != "" {
+			if owner, ok := s.Wallets.Registry.TenantOf(rule.Wallet); !ok || owner != tenantID {
+				return nil, errors.New("invalid rule: wallet is not managed for this tenant")
+			}
+		}
+		if rule.Asset != "" {
+			asset, err := parseAsset(rule.Asset)
+			if err != nil {
+				return nil, errors.New("invalid rule: invalid asset " + rule.Asset)
+			}
+			rule.Asset = assetString(asset)
+		}
+		switch rule.Action {
+		case models.RoutingActionForward:
+			if rule.Percent <= 0 || rule.Percent > 100 {
+				return nil, errors.New("invalid rule: forward percent must be between 0 and 100")
+			}
+			if _, err := keypair.ParseAddress(rule.Destination); err != nil {
+				return nil, errors.New("invalid rule: invalid forward destination")
+			}
+		case models.RoutingActionConvert:
+			asset, err := parseAsset(rule.TargetAsset)
+			if err != nil {
+				return nil, errors.New("invalid rule: invalid target asset")
+			}
+			if err := s.Wallets.checkAssetPermitted(assetString(asset)); err != nil {
+				return nil, errors.New("invalid rule: " + err.Error())
+			}
+			rule.TargetAsset = assetString(asset)
+		case models.RoutingActionBounce:
+			for i, allowed := range rule.AllowedAssets {
+				asset, err := parseAsset(allowed)
+				if err != nil {
+					return nil, errors.New("invalid rule: invalid allowed asset " + allowed)
+				}
+				rule.AllowedAssets[i] = assetString(asset)
+			}
+		default:
+			return nil, errors.New("invalid rule: unknown action " + rule.Action)
+		}
+		if rule.ID == "" {
+			rule.ID = newID()
+		}
+		rules = append(rules, rule)
+	}
+
+	s.mu.Lock()
+	s.rules[tenantID] = rules
+	s.mu.Unlock()
+	s.Wallets.Audit.Record("tenant:"+tenantID, "routing_rules.updated", tenantID, nil)
+	return s.GetRules(tenantID), nil
+}
+
+// ListExecutions returns the actions routing rules took for a tenant, newest first
+func (s *RoutingService) ListExecutions(tenantID string) []models.RoutingExecution {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	executions := s.executions[tenantID]
+	result := make([]models.RoutingExecution, 0, len(executions))
+	for i := len(executions) - 1; i >= 0; i-- {
+		result = append(result, executions[i])
+	}
+	return result
+}
+
+func (s *RoutingService) recordExecution(execution models.RoutingExecution) {
+	execution.ID = newID()
+	execution.ExecutedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	executions := append(s.executions[execution.TenantID], execution)
+	if len(executions) > maxRoutingExecutions {
+		executions = executions[len(executions)-maxRoutingExecutions:]
+	}
+	s.executions[execution.TenantID] = executions
+	s.mu.Unlock()
+
+	details := map[string]string{
+		"rule_id":    execution.RuleID,
+		"payment_id": execution.PaymentID,
+		"asset":      execution.Asset,
+		"amount":     execution.Amount,
+	}
+	if execution.TransactionHash != "" {
+		details["transaction_hash"] = execution.TransactionHash
+	}
+	if execution.Error != "" {
+		details["error"] = execution.Error
+	}
+	s.Wallets.Audit.Record("routing:"+execution.TenantID, "routing."+execution.Action, execution.Wallet, details)
+}
+
+// ruleApplies reports whether a rule matches a payment of asset received by wallet
+func ruleApplies(rule models.RoutingRule, wallet, asset string) bool {
+	return (rule.Wallet == "" || rule.Wallet == wallet) && (rule.Asset == "" || rule.Asset == asset)
+}
+
+// Route applies a tenant's routing rules, in order, to a payment received by one of its managed wallets.
+// A bounce stops evaluation; forwards take their percentage of the received amount; a convert converts
+// whatever has not been forwarded.
+func (s *RoutingService) Route(tenantID string, walletKP *keypair.Full, payment models.PaymentRecord) {
+	s.mu.RLock()
+	rules := append([]models.RoutingRule{}, s.rules[tenantID]...)
+	s.mu.RUnlock()
+
+	received, err := amount.ParseInt64(payment.Amount)
+	if err != nil || received <= 0 {
+		return
+	}
+	remaining := received
+	wallet := walletKP.Address()
+
+	for _, rule := range rules {
+		if !ruleApplies(rule, wallet, payment.Asset) || remaining <= 0 {
+			continue
+		}
+		execution := models.RoutingExecution{
+			TenantID:  tenantID,
+			RuleID:    rule.ID,
+			Action:    rule.Action,
+			Wallet:    wallet,
+			PaymentID: payment.ID,
+			Asset:     payment.Asset,
+		}
+
+		switch rule.Action {
+		case models.RoutingActionBounce:
+			allowed := false
+			for _, asset := range rule.AllowedAssets {
+				if asset == payment.Asset {
+					allowed = true
+				}
+			}
+			if allowed {
+				continue
+			}
+			execution.Amount, execution.Destination = payment.Amount, payment.From
+			execution.TransactionHash, err = s.pay(walletKP, payment.From, payment.Asset, payment.Amount)
+			if err != nil {
+				execution.Error = err.Error()
+				execution.ErrorDetail = ExplainError(err)
+			}
+			s.recordExecution(execution)
+			return
+		case models.RoutingActionForward:
+			share := int64(math.Floor(float64(received) * rule.Percent / 100))
+			if share > remaining {
+				share = remaining
+			}
+			if share <= 0 {
+				continue
+			}
+			execution.Amount, execution.Destination = amount.StringFromInt64(share), rule.Destination
+			execution.TransactionHash, err = s.pay(walletKP, rule.Destination, payment.Asset, execution.Amount)
+			if err != nil {
+				execution.Error = err.Error()
+				execution.ErrorDetail = ExplainError(err)
+			} else {
+				remaining -= share
+			}
+		case models.RoutingActionConvert:
+			if rule.TargetAsset == payment.Asset {
+				continue
+			}
+			execution.Amount, execution.TargetAsset = amount.StringFromInt64(remaining), rule.TargetAsset
+			execution.TransactionHash, err = s.convert(walletKP, payment.Asset, rule.TargetAsset, execution.Amount)
+			if err != nil {
+				execution.Error = err.Error()
+				execution.ErrorDetail = ExplainError(err)
+			} else {
+				remaining = 0
+			}
+		}
+		s.recordExecution(execution)
+	}
+}
+
+// pay sends a payment from a managed wallet
+func (s *RoutingService) pay(walletKP *keypair.Full, destination, assetCode, value string) (string, error) {
+	asset, err := parseAsset(assetCode)
+	if err != nil {
+		return "", errors.New("failed to parse asset: " + err.Error())
+	}
+	return s.Wallets.submitOperation(walletKP, &txnbuild.Payment{Destination: destination, Amount: value, Asset: asset}, nil, models.DeviceInfo{})
+}
+
+// convert exchanges value of one asset for another within a managed wallet using a strict-send path payment
+func (s *RoutingService) convert(walletKP *keypair.Full, from, to, value string) (string, error) {
+	sendAsset, err := parseAsset(from)
+	if err != nil {
+		return "", errors.New("failed to parse asset: " + err.Error())
+	}
+	destAsset, err := parseAsset(to)
+	if err != nil {
+		return "", errors.New("failed to parse asset: " + err.Error())
+	}
+	path, err := s.Wallets.findStrictSendPath(sendAsset, destAsset, value)
+	if err != nil {
+		return "", err
+	}
+	destMin, err := withoutSlippage(path.DestinationAmount, defaultPathSlippagePercent)
+	if err != nil {
+		return "", errors.New("failed to compute minimum destination amount: " + err.Error())
+	}
+	return s.Wallets.submitOperation(walletKP, &txnbuild.PathPaymentStrictSend{
+		SendAsset:   sendAsset,
+		SendAmount:  value,
+		Destination: walletKP.Address(),
+		DestAsset:   destAsset,
+		DestMin:     destMin,
+		Path:        pathAssets(path.Path),
+	}, nil, models.DeviceInfo{})
+}
+
+// PaymentWatcher periodically polls Horizon for payments received by managed wallets, settles the invoices
+// they pay, credits the pooled sub-accounts they were sent to and hands the rest to the routing rules of the
+// wallet's tenant. It publishes every payment a wallet sends or receives, and its balances after them.
+type PaymentWatcher struct {
+	Wallets  *WalletService
+	Routing  *RoutingService
+	Invoices *InvoiceService
+	Pools    *PoolService
+	Interval time.Duration
+
+	// cursors holds the paging token of the last payment seen per wallet; it is only touched by Poll
+	cursors map[string]string
+}
+
+// NewPaymentWatcher creates a new PaymentWatcher instance
+func NewPaymentWatcher(wallets *WalletService, routing *RoutingService, invoices *InvoiceService, pools *PoolService, interval time.Duration) *PaymentWatcher {
+	return &PaymentWatcher{
+		Wallets:  wallets,
+		Routing:  routing,
+		Invoices: invoices,
+		Pools:    pools,
+		Interval: interval,
+		cursors:  make(map[string]string),
+	}
+}
+
+// Run polls every Interval until ctx is cancelled
+func (w *PaymentWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Poll()
+		}
+	}
+}
+
+// Poll settles invoices with and routes the payments each managed wallet received since the previous poll. Wallets seen for the
+// first time only record their latest payment, so history is never replayed.
+func (w *PaymentWatcher) Poll() {
+	for _, publicKey := range w.Wallets.Registry.PublicKeys() {
+		cursor, seen := w.cursors[publicKey]
+		if !seen {
+			latest, err := w.Wallets.Config.HorizonClient.Payments(horizonclient.OperationRequest{
+				ForAccount: publicKey,
+				Order:      horizonclient.OrderDesc,
+				Limit:      1,
+			})
+			if err != nil {
+				log.Printf("payment watcher: failed to fetch payments for %s: %v", publicKey, err)
+				continue
+			}
+			w.cursors[publicKey] = ""
+			if len(latest.Embedded.Records) > 0 {
+				w.cursors[publicKey] = latest.Embedded.Records[0].PagingToken()
+			}
+			continue
+		}
+		payments, err := w.Wallets.Config.HorizonClient.Payments(horizonclient.OperationRequest{
+			ForAccount: publicKey,
+			Cursor:     cursor,
+			Order:      horizonclient.OrderAsc,
+			Limit:      200,
+			Join:       "transactions",
+		})
+		if err != nil {
+			log.Printf("payment watcher: failed to fetch payments for %s: %v", publicKey, err)
+			continue
+		}
+
+		kp, managed := w.Wallets.Registry.Get(publicKey)
+		tenantID, _ := w.Wallets.Registry.TenantOf(publicKey)
+		moved := false
+		for _, op := range payments.Embedded.Records {
+			w.cursors[publicKey] = op.PagingToken()
+			record, ok := paymentRecord(op, publicKey)
+			if !ok || !op.IsTransactionSuccessful() {
+				continue
+			}
+			moved = true
+			eventType := models.EventPaymentReceived
+			if record.Direction == models.DirectionSent {
+				eventType = models.EventPaymentSent
+			}
+			w.Wallets.Events.Publish(eventType, publicKey, map[string]string{
+				"operation_id":     record.ID,
+				"counterparty":     record.Counterparty,
+				"asset":            record.Asset,
+				"amount":           record.Amount,
+				"transaction_hash": record.TransactionHash,
+			})
+			if record.Direction != models.DirectionReceived {
+				continue
+			}
+			if w.Invoices != nil {
+				w.Invoices.Settle(op, record)
+			}
+			// Funds attributed to a user of a pooled wallet are not the wallet's to route
+			if w.Pools != nil && w.Pools.Credit(op, record) {
+				continue
+			}
+			if !managed || w.Wallets.isDeactivated(publicKey) {
+				continue
+			}
+			w.Routing.Route(tenantID, kp, record)
+		}
+		if moved {
+			w.publishBalances(publicKey)
+		}
+	}
+}
+
+// publishBalances publishes a wallet's current balances after payments moved them
+func (w *PaymentWatcher) publishBalances(publicKey string) {
+	account, err := w.Wallets.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: publicKey})
+	if err != nil {
+		log.Printf("payment watcher: failed to fetch balances for %s: %v", publicKey, err)
+		return
+	}
+	w.Wallets.Events.Publish(models.EventBalanceChanged, publicKey, accountBalances(account))
+}
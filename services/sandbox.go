@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/keypair"
+)
+
+// SandboxTenantID is the built-in tenant external developers use to experiment against testnet
+const SandboxTenantID = "sandbox"
+
+// defaultSandboxWalletCount is how many friendbot-funded wallets each reset provisions
+const defaultSandboxWalletCount = 3
+
+// SandboxService provisions and nightly resets the developer sandbox tenant
+type SandboxService struct {
+	Wallets       *WalletService
+	Notifications *NotificationService
+	Refunds       *RefundService
+
+	mu          sync.Mutex
+	lastResetAt *time.Time
+}
+
+// NewSandboxService creates a new SandboxService instance
+func NewSandboxService(wallets *WalletService, notifications *NotificationService, refunds *RefundService) *SandboxService {
+	return &SandboxService{Wallets: wallets, Notifications: notifications, Refunds: refunds}
+}
+
+// nextReset returns the next midnight UTC after now
+func nextReset(now time.Time) time.Time {
+	return now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+}
+
+// Run resets the sandbox immediately and then every night at midnight UTC until ctx is cancelled
+func (s *SandboxService) Run(ctx context.Context) {
+	for {
+		if err := s.Reset(); err != nil {
+			log.Printf("sandbox reset failed: %v", err)
+		}
+		timer := time.NewTimer(time.Until(nextReset(time.Now())))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// Reset discards all sandbox wallets and data and provisions fresh wallets funded by friendbot
+func (s *SandboxService) Reset() error {
+	if s.Wallets.Config.Network != "testnet" {
+		return errors.New("sandbox is only available on testnet")
+	}
+
+	for _, publicKey := range s.Wallets.Registry.TenantPublicKeys(SandboxTenantID) {
+		s.Notifications.RemovePreferences(publicKey)
+		s.Wallets.Registry.Remove(publicKey)
+	}
+	s.Refunds.PurgeTenant(SandboxTenantID)
+
+	count := s.Wallets.Config.SandboxWalletCount
+	if count <= 0 {
+		count = defaultSandboxWalletCount
+	}
+	for i := 0; i < count; i++ {
+		kp, err := keypair.Random()
+		if err != nil {
+			return errors.New("failed to generate keypair: " + err.Error())
+		}
+		if _, err := s.Wallets.Config.HorizonClient.Fund(kp.Address()); err != nil {
+			return errors.New("failed to fund sandbox wallet via friendbot: " + err.Error())
+		}
+		s.Wallets.Registry.Add(SandboxTenantID, kp)
+	}
+
+	now := time.Now().UTC()
+	s.mu.Lock()
+	s.lastResetAt = &now
+	s.mu.Unlock()
+	log.Printf("sandbox reset: provisioned %d wallets", count)
+	return nil
+}
+
+// GetSandbox returns the sandbox tenant's credentials and reset schedule
+func (s *SandboxService) GetSandbox() *models.SandboxResponse {
+	wallets := []models.SandboxWallet{}
+	for _, publicKey := range s.Wallets.Registry.TenantPublicKeys(SandboxTenantID) {
+		if kp, ok := s.Wallets.Registry.Get(publicKey); ok {
+			wallets = append(wallets, models.SandboxWallet{PublicKey: kp.Address(), SecretKey: kp.Seed()})
+		}
+	}
+
+	s.mu.Lock()
+	lastResetAt := s.lastResetAt
+	s.mu.Unlock()
+
+	return &models.SandboxResponse{
+		TenantID:    SandboxTenantID,
+		Header:      "X-Tenant-ID: " + SandboxTenantID,
+		Network:     s.Wallets.Config.Network,
+		Wallets:     wallets,
+		LastResetAt: lastResetAt,
+		NextResetAt: nextReset(time.Now()),
+		Message:     "Sandbox wallets, preferences and refunds are wiped and re-funded from friendbot every night at 00:00 UTC",
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/horizonclient"
+)
+
+const (
+	// defaultPaymentSearchWindow is how far back memos are searched when no since is given
+	defaultPaymentSearchWindow = 30 * 24 * time.Hour
+	// maxPaymentSearchResults caps the results of one search
+	maxPaymentSearchResults = 100
+)
+
+// SearchPayments finds the payments touching a tenant's wallets whose transaction carries the given memo, or
+// that were submitted by this service with the given external ID. Memos are matched by scanning each
+// wallet's payments back to since, at most maxPaymentHistoryPages pages per wallet; external IDs are looked
+// up among the transfers this process completed and are not bounded by since.
+func (s *WalletService) SearchPayments(tenantID string, query models.PaymentSearchQuery) (*models.PaymentSearchResponse, error) {
+	if query.Memo == "" && query.ExternalID == "" {
+		return nil, errors.New("invalid query: memo or external_id is required")
+	}
+	since := time.Now().UTC().Add(-defaultPaymentSearchWindow)
+	if query.Since != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, query.Since); err != nil {
+			return nil, errors.New("invalid since: must be an RFC 3339 timestamp")
+		}
+	}
+	publicKeys := s.Registry.TenantPublicKeys(tenantID)
+	if query.Wallet != "" {
+		if owner, ok := s.Registry.TenantOf(query.Wallet); !ok || owner != tenantID {
+			return nil, errors.New("invalid wallet: not a custodied wallet of this tenant")
+		}
+		publicKeys = []string{query.Wallet}
+	}
+
+	response := &models.PaymentSearchResponse{Results: []models.PaymentSearchResult{}}
+	seen := make(map[string]bool)
+	add := func(result models.PaymentSearchResult) {
+		key := result.Wallet + "/" + result.Payment.ID
+		if !seen[key] {
+			seen[key] = true
+			response.Results = append(response.Results, result)
+		}
+	}
+
+	if query.ExternalID != "" {
+		transfers, err := s.externalIDTransfers(query.ExternalID)
+		if err != nil {
+			return nil, err
+		}
+		for _, publicKey := range publicKeys {
+			transfer, ok := transfers[publicKey]
+			if !ok {
+				continue
+			}
+			results, err := s.transactionPayments(publicKey, transfer.TransactionHash)
+			if err != nil {
+				return nil, err
+			}
+			for _, result := range results {
+				result.MatchedOn, result.ExternalID = models.SearchMatchExternalID, query.ExternalID
+				add(result)
+			}
+		}
+	}
+
+	if query.Memo != "" {
+		for _, publicKey := range publicKeys {
+			results, truncated, err := s.memoPayments(publicKey, query.Memo, since)
+			if err != nil {
+				return nil, err
+			}
+			response.Truncated = response.Truncated || truncated
+			for _, result := range results {
+				add(result)
+			}
+		}
+	}
+
+	sort.SliceStable(response.Results, func(i, j int) bool {
+		return response.Results[i].Payment.CreatedAt.After(response.Results[j].Payment.CreatedAt)
+	})
+	if len(response.Results) > maxPaymentSearchResults {
+		response.Results = response.Results[:maxPaymentSearchResults]
+		response.Truncated = true
+	}
+	return response, nil
+}
+
+// transactionPayments returns the payments of one transaction that touched a wallet
+func (s *WalletService) transactionPayments(publicKey, hash string) ([]models.PaymentSearchResult, error) {
+	payments, err := s.Config.HorizonClient.Payments(horizonclient.OperationRequest{
+		ForTransaction: hash,
+		Limit:          maxPaymentHistoryLimit,
+		Join:           "transactions",
+	})
+	if err != nil {
+		return nil, activityError("payments", err)
+	}
+	var results []models.PaymentSearchResult
+	for _, op := range payments.Embedded.Records {
+		record, ok := paymentRecord(op, publicKey)
+		if !ok || !op.IsTransactionSuccessful() {
+			continue
+		}
+		result := models.PaymentSearchResult{Wallet: publicKey, Payment: record}
+		if tx := op.GetBase().Transaction; tx != nil && tx.MemoType != "none" {
+			result.Memo, result.MemoType = tx.Memo, tx.MemoType
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// memoPayments scans a wallet's payments back to since for those whose transaction is a text or ID memo
+// equal to memo. truncated is set when the scan stopped at
+// maxPaymentHistoryPages before reaching since.
+func (s *WalletService) memoPayments(publicKey, memo string, since time.Time) ([]models.PaymentSearchResult, bool, error) {
+	var results []models.PaymentSearchResult
+	cursor := ""
+	for page := 0; page < maxPaymentHistoryPages; page++ {
+		payments, err := s.Config.HorizonClient.Payments(horizonclient.OperationRequest{
+			ForAccount: publicKey,
+			Cursor:     cursor,
+			Order:      horizonclient.OrderDesc,
+			Limit:      maxPaymentHistoryLimit,
+			Join:       "transactions",
+		})
+		if herr, ok := err.(*horizonclient.Error); ok && herr.Response.StatusCode == http.StatusNotFound {
+			// The wallet's account is not funded yet, so it has no payments
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, activityError("payments", err)
+		}
+		for _, op := range payments.Embedded.Records {
+			cursor = op.PagingToken()
+			record, ok := paymentRecord(op, publicKey)
+			if !ok || !op.IsTransactionSuccessful() {
+				continue
+			}
+			if record.CreatedAt.Before(since) {
+				return results, false, nil
+			}
+			tx := op.GetBase().Transaction
+			if tx == nil || (tx.MemoType != "text" && tx.MemoType != "id") || tx.Memo != memo {
+				continue
+			}
+			results = append(results, models.PaymentSearchResult{
+				Wallet:    publicKey,
+				MatchedOn: models.SearchMatchMemo,
+				Memo:      tx.Memo,
+				MemoType:  tx.MemoType,
+				Payment:   record,
+			})
+		}
+		if len(payments.Embedded.Records) < maxPaymentHistoryLimit {
+			return results, false, nil
+		}
+	}
+	return results, true, nil
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// accountSequence is the last sequence number handed out for one source account
+type accountSequence struct {
+	mu     sync.Mutex
+	seq    int64
+	loaded bool
+}
+
+// SequenceManager allocates sequence numbers for the accounts this service submits from. Allocation is
+// serialized per account and incremented locally, so concurrent transactions from one account no longer
+// all build on the same Horizon snapshot and race to tx_bad_seq. An account is reloaded from Horizon the
+// first time it is used and again after any submission error.
+type SequenceManager struct {
+	client *horizonclient.Client
+
+	mu       sync.Mutex
+	accounts map[string]*accountSequence
+}
+
+// NewSequenceManager creates a SequenceManager backed by the given Horizon client
+func NewSequenceManager(client *horizonclient.Client) *SequenceManager {
+	return &SequenceManager{client: client, accounts: make(map[string]*accountSequence)}
+}
+
+func (m *SequenceManager) account(accountID string) *accountSequence {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.accounts[accountID]
+	if !ok {
+		entry = &accountSequence{}
+		m.accounts[accountID] = entry
+	}
+	return entry
+}
+
+// Next allocates the next sequence number for accountID and returns the account ready to be used, without
+// incrementing, as a transaction source
+func (m *SequenceManager) Next(accountID string) (*txnbuild.SimpleAccount, error) {
+	entry := m.account(accountID)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if !entry.loaded {
+		account, err := m.client.AccountDetail(horizonclient.AccountRequest{AccountID: accountID})
+		if err != nil {
+			return nil, err
+		}
+		if entry.seq, err = account.GetSequenceNumber(); err != nil {
+			return nil, err
+		}
+		entry.loaded = true
+	}
+	entry.seq++
+	return &txnbuild.SimpleAccount{AccountID: accountID, Sequence: entry.seq}, nil
+}
+
+// Release gives back a sequence number that was allocated but never submitted. Only the latest number can
+// be reused; releasing an earlier one leaves a gap, so the account is resynced instead.
+func (m *SequenceManager) Release(accountID string, seq int64) {
+	entry := m.account(accountID)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.loaded && entry.seq == seq {
+		entry.seq--
+		return
+	}
+	entry.loaded = false
+}
+
+// Resync discards the local sequence number of accountID so the next allocation reloads it from Horizon
+func (m *SequenceManager) Resync(accountID string) {
+	entry := m.account(accountID)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	entry.loaded = false
+}
+
+// buildTransaction allocates the next sequence number of sourceID, then builds and signs a transaction from
+// params with it, offering the fee strategy's base fee unless params sets one. The sequence number is
+// released when the transaction cannot be built or signed.
+func (s *WalletService) buildTransaction(sourceID string, params txnbuild.TransactionParams, signers ...*keypair.Full) (*txnbuild.Transaction, error) {
+	// Nothing is signed for a frozen wallet, whichever flow asks
+	for _, signer := range signers {
+		if err := s.checkNotFrozen(signer.Address()); err != nil {
+			return nil, err
+		}
+	}
+	source, err := s.Sequences.Next(sourceID)
+	if err != nil {
+		return nil, errors.New("failed to fetch source account details: " + err.Error())
+	}
+	params.SourceAccount = source
+	params.IncrementSequenceNum = false
+	if params.BaseFee == 0 {
+		params.BaseFee = s.Fees.BaseFee()
+	}
+
+	tx, err := txnbuild.NewTransaction(params)
+	if err != nil {
+		s.Sequences.Release(sourceID, source.Sequence)
+		return nil, errors.New("failed to build transaction: " + err.Error())
+	}
+	if tx, err = tx.Sign(s.networkPassphrase(), signers...); err != nil {
+		s.Sequences.Release(sourceID, source.Sequence)
+		return nil, errors.New("failed to sign transaction: " + err.Error())
+	}
+	return tx, nil
+}
+
+// releaseSequence gives back the sequence number of a transaction from buildTransaction that will not be
+// submitted after all
+func (s *WalletService) releaseSequence(tx *txnbuild.Transaction) {
+	source := tx.SourceAccount()
+	s.Sequences.Release(source.AccountID, source.Sequence)
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// balanceOf returns an account's balance of a canonical asset
+func balanceOf(account hProtocol.Account, asset string) (hProtocol.Balance, bool) {
+	for _, balance := range account.Balances {
+		if (balance.Type == "native" && asset == "native") || balance.Code+":"+balance.Issuer == asset {
+			return balance, true
+		}
+	}
+	return hProtocol.Balance{}, false
+}
+
+// SimulateTransfer builds the transaction a transfer would submit and checks it against current balances,
+// trustlines, memo requirements and reserves without submitting it or screening it for fraud
+func (s *WalletService) SimulateTransfer(req models.TransferRequest) (*models.TransferSimulation, error) {
+	transfer, err := s.prepareTransfer(req)
+	if err != nil {
+		return nil, err
+	}
+	sender := transfer.senderKP.Address()
+	sendAsset, destAsset := assetString(transfer.sendAsset), assetString(transfer.destAsset)
+
+	sourceAccount, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: sender})
+	if err != nil {
+		if herr, ok := err.(*horizonclient.Error); ok && herr.Response.StatusCode == http.StatusNotFound {
+			return nil, errors.New("sender account not found")
+		}
+		return nil, errors.New("failed to fetch sender account details: " + err.Error())
+	}
+	recipientAccount, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: transfer.destination})
+	recipientExists := err == nil
+	if err != nil {
+		if herr, ok := err.(*horizonclient.Error); !ok || herr.Response.StatusCode != http.StatusNotFound {
+			return nil, errors.New("failed to fetch recipient account details: " + err.Error())
+		}
+	}
+	baseReserve, err := s.baseReserveStroops()
+	if err != nil {
+		return nil, err
+	}
+
+	ops, response, claimable, err := s.transferOperations(transfer)
+	if err != nil {
+		return nil, err
+	}
+	baseFee := s.Fees.BaseFee()
+	tx, err := txnbuild.NewTransaction(
+		txnbuild.TransactionParams{
+			SourceAccount:        &sourceAccount,
+			Operations:           ops,
+			BaseFee:              baseFee,
+			Memo:                 transfer.memo,
+			Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+			IncrementSequenceNum: true,
+		},
+	)
+	if err != nil {
+		return nil, errors.New("failed to build transaction: " + err.Error())
+	}
+	envelope, err := tx.Base64()
+	if err != nil {
+		return nil, errors.New("failed to encode transaction: " + err.Error())
+	}
+
+	simulation := &models.TransferSimulation{
+		Problems:          []string{},
+		SourceAsset:       sendAsset,
+		DestinationAsset:  destAsset,
+		DeliveredAs:       response.DeliveredAs,
+		SendMax:           response.SendMax,
+		Sources:           response.Sources,
+		EnvelopeXDR:       envelope,
+		Operations:        len(ops),
+		BaseFee:           baseFee,
+		Fee:               amount.StringFromInt64(tx.MaxFee()),
+		SenderBalances:    []models.ProjectedBalance{},
+		RecipientBalances: []models.ProjectedBalance{},
+	}
+	_, simulation.SettlesInternally = s.settlesInternally(transfer)
+	problem := func(msg string) { simulation.Problems = append(simulation.Problems, msg) }
+
+	// debits holds the most the transfer can take from each sent asset, in the order the assets are spent
+	debits := make(map[string]int64)
+	var debited []string
+	if len(response.Sources) > 0 {
+		for _, source := range response.Sources {
+			spend := source.Amount
+			if source.SendMax != "" {
+				spend = source.SendMax
+			}
+			stroops, _ := amount.ParseInt64(spend)
+			debits[source.Asset] += stroops
+			debited = append(debited, source.Asset)
+		}
+	} else {
+		debit, _ := amount.ParseInt64(req.Amount)
+		if response.SendMax != "" {
+			debit, _ = amount.ParseInt64(response.SendMax)
+		}
+		debits[sendAsset] = debit
+		debited = append(debited, sendAsset)
+	}
+	credit, _ := amount.ParseInt64(req.Amount)
+	fee := tx.MaxFee()
+
+	// Sender: the sent asset, then XLM for the fee and any reserve the transfer adds
+	minimum := minimumBalanceStroops(sourceAccount, baseReserve)
+	projectedMinimum := minimum
+	if claimable {
+		// The sender sponsors the claimable balance entry
+		projectedMinimum += baseReserve
+	}
+	simulation.SenderMinimumBalance = amount.StringFromInt64(minimum)
+	simulation.ProjectedSenderMinimumBalance = amount.StringFromInt64(projectedMinimum)
+
+	nativeDebit := fee + debits["native"]
+	for _, asset := range debited {
+		if asset == "native" {
+			continue
+		}
+		debit := debits[asset]
+		balance, ok := balanceOf(sourceAccount, asset)
+		if !ok {
+			problem("sender has no trustline for " + asset)
+			continue
+		}
+		current, _ := amount.ParseInt64(balance.Balance)
+		available, _ := amount.ParseInt64(availableBalance(balance, sourceAccount, baseReserve))
+		if balance.IsAuthorized != nil && !*balance.IsAuthorized {
+			problem("sender is not authorized to send " + asset)
+		}
+		if available < debit {
+			problem("insufficient " + asset + " balance: available " + amount.StringFromInt64(available) + ", needs " + amount.StringFromInt64(debit))
+		}
+		simulation.SenderBalances = append(simulation.SenderBalances, models.ProjectedBalance{
+			Asset:     asset,
+			Current:   balance.Balance,
+			Projected: amount.StringFromInt64(current - debit),
+		})
+	}
+	if native, ok := balanceOf(sourceAccount, "native"); ok {
+		current, _ := amount.ParseInt64(native.Balance)
+		var liabilities int64
+		if native.SellingLiabilities != "" {
+			liabilities, _ = amount.ParseInt64(native.SellingLiabilities)
+		}
+		projected := current - nativeDebit
+		if projected-liabilities < projectedMinimum {
+			if debits["native"] > 0 {
+				problem("insufficient XLM balance to cover the amount, fee and minimum balance of " + amount.StringFromInt64(projectedMinimum))
+			} else {
+				problem("insufficient XLM to cover the fee and minimum balance of " + amount.StringFromInt64(projectedMinimum))
+			}
+		}
+		simulation.SenderBalances = append(simulation.SenderBalances, models.ProjectedBalance{
+			Asset:     "native",
+			Current:   native.Balance,
+			Projected: amount.StringFromInt64(projected),
+		})
+	}
+
+	// Recipient: claimable balances are credited only when claimed, so only direct deliveries project
+	if recipientExists {
+		simulation.MemoRequired = memoRequired(recipientAccount)
+		if simulation.MemoRequired && transfer.memo == nil && transfer.muxedID == "" {
+			problem(errMemoRequired.Error())
+		}
+	}
+	switch {
+	case claimable:
+	case !recipientExists:
+		problem("recipient account does not exist")
+	default:
+		balance, ok := balanceOf(recipientAccount, destAsset)
+		if !ok {
+			problem("recipient has no trustline for " + destAsset)
+			break
+		}
+		current, _ := amount.ParseInt64(balance.Balance)
+		if balance.IsAuthorized != nil && !*balance.IsAuthorized {
+			problem("recipient is not authorized to hold " + destAsset)
+		}
+		if balance.Limit != "" {
+			limit, _ := amount.ParseInt64(balance.Limit)
+			var buying int64
+			if balance.BuyingLiabilities != "" {
+				buying, _ = amount.ParseInt64(balance.BuyingLiabilities)
+			}
+			if current+buying+credit > limit {
+				problem("recipient trustline limit for " + destAsset + " would be exceeded")
+			}
+		}
+		simulation.RecipientBalances = append(simulation.RecipientBalances, models.ProjectedBalance{
+			Asset:     destAsset,
+			Current:   balance.Balance,
+			Projected: amount.StringFromInt64(current + credit),
+		})
+	}
+
+	simulation.Valid = len(simulation.Problems) == 0
+	return simulation, nil
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// slo defaults used when the corresponding Config fields are zero
+const (
+	defaultSLOThreshold  = 10 * time.Second
+	defaultSLOTarget     = 0.95
+	defaultSLOWindow     = time.Hour
+	sloMinSamplesToAlert = 20
+)
+
+type latencySample struct {
+	hash       string
+	latency    time.Duration
+	recordedAt time.Time
+}
+
+// LatencySLO tracks submission-to-ledger latency and rolling compliance with the latency objective
+type LatencySLO struct {
+	Threshold time.Duration
+	Target    float64
+	Window    time.Duration
+	Alerter   Alerter
+
+	mu       sync.Mutex
+	samples  []latencySample
+	total    int64
+	breached bool
+}
+
+// SLOStatus is a point-in-time view of latency SLO compliance
+type SLOStatus struct {
+	Threshold      time.Duration
+	Target         float64
+	Window         time.Duration
+	Samples        int
+	WithinTarget   int
+	Compliance     float64
+	Breached       bool
+	TotalRecorded  int64
+	LastLatency    time.Duration
+	LastHash       string
+	AverageLatency time.Duration
+}
+
+// NewLatencySLO creates a new LatencySLO, applying defaults for zero values
+func NewLatencySLO(threshold time.Duration, target float64, window time.Duration, alerter Alerter) *LatencySLO {
+	if threshold <= 0 {
+		threshold = defaultSLOThreshold
+	}
+	if target <= 0 || target > 1 {
+		target = defaultSLOTarget
+	}
+	if window <= 0 {
+		window = defaultSLOWindow
+	}
+	return &LatencySLO{Threshold: threshold, Target: target, Window: window, Alerter: alerter}
+}
+
+// Record adds the latency of an included transaction and alerts when compliance crosses the target
+func (t *LatencySLO) Record(hash string, latency time.Duration) {
+	t.mu.Lock()
+	now := time.Now()
+	t.samples = append(t.samples, latencySample{hash: hash, latency: latency, recordedAt: now})
+	t.total++
+	t.pruneLocked(now)
+	status := t.statusLocked()
+
+	var title, message string
+	switch {
+	case !t.breached && status.Samples >= sloMinSamplesToAlert && status.Compliance < t.Target:
+		t.breached = true
+		title = "Ledger latency SLO breached"
+	case t.breached && status.Compliance >= t.Target:
+		t.breached = false
+		title = "Ledger latency SLO recovered"
+	}
+	if title != "" {
+		message = fmt.Sprintf("%.1f%% of %d transactions in the last %s were included within %s (target %.1f%%)",
+			status.Compliance*100, status.Samples, t.Window, t.Threshold, t.Target*100)
+	}
+	t.mu.Unlock()
+
+	if title != "" && t.Alerter != nil {
+		t.Alerter.Alert(title, message)
+	}
+}
+
+// Status returns the current rolling compliance
+func (t *LatencySLO) Status() SLOStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pruneLocked(time.Now())
+	return t.statusLocked()
+}
+
+func (t *LatencySLO) pruneLocked(now time.Time) {
+	cutoff := now.Add(-t.Window)
+	i := 0
+	for i < len(t.samples) && t.samples[i].recordedAt.Before(cutoff) {
+		i++
+	}
+	t.samples = t.samples[i:]
+}
+
+func (t *LatencySLO) statusLocked() SLOStatus {
+	status := SLOStatus{
+		Threshold:     t.Threshold,
+		Target:        t.Target,
+		Window:        t.Window,
+		Samples:       len(t.samples),
+		Compliance:    1,
+		Breached:      t.breached,
+		TotalRecorded: t.total,
+	}
+	if len(t.samples) == 0 {
+		return status
+	}
+
+	var sum time.Duration
+	for _, sample := range t.samples {
+		sum += sample.latency
+		if sample.latency <= t.Threshold {
+			status.WithinTarget++
+		}
+	}
+	last := t.samples[len(t.samples)-1]
+	status.LastLatency = last.latency
+	status.LastHash = last.hash
+	status.AverageLatency = sum / time.Duration(len(t.samples))
+	status.Compliance = float64(status.WithinTarget) / float64(status.Samples)
+	return status
+}
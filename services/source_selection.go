@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/txnbuild"
+)
+
+// sourceLeg is the part of an auto-selected transfer delivered from one of the sender's balances
+type sourceLeg struct {
+	asset txnbuild.Asset
+	// destStroops is the amount of the destination asset this leg delivers
+	destStroops int64
+}
+
+// selectSourceAssets chooses which of the sender's balances pay a transfer whose source asset is "any".
+// The destination asset itself is preferred, then whichever balance can deliver the most of it through a
+// path payment. When no single balance covers the amount, balances are combined in that order and the
+// transfer is sent as one payment per balance in a single transaction.
+func (s *WalletService) selectSourceAssets(transfer *preparedTransfer) error {
+	target, err := amount.ParseInt64(transfer.request.Amount)
+	if err != nil {
+		return errors.New("invalid amount: must be a positive number")
+	}
+	destAsset := assetString(transfer.destAsset)
+
+	account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: transfer.senderKP.Address()})
+	if err != nil {
+		return errors.New("failed to fetch sender account details: " + err.Error())
+	}
+	baseReserve, err := s.baseReserveStroops()
+	if err != nil {
+		return err
+	}
+
+	var candidates []sourceLeg
+	for _, balance := range account.Balances {
+		if balance.Type == "liquidity_pool_shares" || (balance.IsAuthorized != nil && !*balance.IsAuthorized) {
+			continue
+		}
+		canonical := "native"
+		if balance.Type != "native" {
+			canonical = balance.Code + ":" + balance.Issuer
+		}
+		if s.checkAssetPermitted(canonical) != nil {
+			continue
+		}
+		available, err := amount.ParseInt64(availableBalance(balance, account, baseReserve))
+		if err != nil {
+			continue
+		}
+		if balance.Type == "native" {
+			// Leave XLM for the fee of a transaction with one operation per balance
+			available -= int64(len(account.Balances)) * s.Fees.BaseFee()
+		}
+		if available <= 0 {
+			continue
+		}
+		asset, err := parseAsset(canonical)
+		if err != nil {
+			continue
+		}
+
+		deliverable := available
+		if canonical != destAsset {
+			// Quote only what remains after slippage so the leg's send max stays within the balance
+			budget, err := withoutSlippage(amount.StringFromInt64(available), transfer.slippage)
+			if err != nil {
+				continue
+			}
+			path, err := s.findStrictSendPath(asset, transfer.destAsset, budget)
+			if err != nil {
+				continue
+			}
+			if deliverable, err = amount.ParseInt64(path.DestinationAmount); err != nil || deliverable <= 0 {
+				continue
+			}
+		}
+		candidates = append(candidates, sourceLeg{asset: asset, destStroops: deliverable})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		iDirect := assetString(candidates[i].asset) == destAsset
+		jDirect := assetString(candidates[j].asset) == destAsset
+		if iDirect != jDirect {
+			return iDirect
+		}
+		return candidates[i].destStroops > candidates[j].destStroops
+	})
+
+	for _, candidate := range candidates {
+		if candidate.destStroops >= target {
+			transfer.sendAsset = candidate.asset
+			return nil
+		}
+	}
+
+	var legs []sourceLeg
+	remaining := target
+	for _, candidate := range candidates {
+		if remaining == 0 {
+			break
+		}
+		stroops := min(candidate.destStroops, remaining)
+		legs = append(legs, sourceLeg{asset: candidate.asset, destStroops: stroops})
+		remaining -= stroops
+	}
+	if remaining > 0 {
+		return errors.New("insufficient balance: sender balances cannot deliver " + transfer.request.Amount + " " + destAsset)
+	}
+	transfer.sendAsset = legs[0].asset
+	transfer.sources = legs
+	return nil
+}
+
+// sourceOperations builds one operation per source leg of a combined transfer: a payment for the leg in
+// the destination asset and strict-receive path payments for the rest
+func (s *WalletService) sourceOperations(transfer *preparedTransfer) ([]txnbuild.Operation, []models.TransferSource, error) {
+	ops := make([]txnbuild.Operation, 0, len(transfer.sources))
+	sources := make([]models.TransferSource, 0, len(transfer.sources))
+	for _, leg := range transfer.sources {
+		destAmount := amount.StringFromInt64(leg.destStroops)
+		source := models.TransferSource{Asset: assetString(leg.asset), Amount: destAmount}
+		if source.Asset == assetString(transfer.destAsset) {
+			ops = append(ops, &txnbuild.Payment{
+				Destination: transfer.request.ToPublicKey,
+				Amount:      destAmount,
+				Asset:       leg.asset,
+			})
+			sources = append(sources, source)
+			continue
+		}
+
+		path, err := s.findStrictReceivePath(leg.asset, transfer.destAsset, destAmount)
+		if err != nil {
+			return nil, nil, err
+		}
+		sendMax, err := withSlippage(path.SourceAmount, transfer.slippage)
+		if err != nil {
+			return nil, nil, errors.New("failed to compute max send amount: " + err.Error())
+		}
+		ops = append(ops, &txnbuild.PathPaymentStrictReceive{
+			SendAsset:   leg.asset,
+			SendMax:     sendMax,
+			Destination: transfer.request.ToPublicKey,
+			DestAsset:   transfer.destAsset,
+			DestAmount:  destAmount,
+			Path:        pathAssets(path.Path),
+		})
+		source.SendMax = sendMax
+		sources = append(sources, source)
+	}
+	return ops, sources, nil
+}
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
+)
+
+// spendEntry is an amount a wallet has sent, or reserved for a payment in flight
+type spendEntry struct {
+	Sender  string    `json:"sender"`
+	Asset   string    `json:"asset"`
+	Stroops int64     `json:"stroops"`
+	At      time.Time `json:"at"`
+}
+
+// spendLimits stores per-wallet limit overrides and the spending they are enforced against. Each wallet's
+// state is kept in the archive store, so limits hold across restarts.
+type spendLimits struct {
+	mu        sync.Mutex
+	overrides map[string]map[string]models.SpendLimit
+	// spent holds each wallet's entries since the start of the previous month
+	spent map[string][]*spendEntry
+	// loaded marks the wallets whose state has been read from the archive store
+	loaded map[string]bool
+}
+
+// spendRecord is the archived spend limit state of a wallet; Override is nil when the wallet has none
+type spendRecord struct {
+	Override map[string]models.SpendLimit `json:"override"`
+	Spent    []*spendEntry                `json:"spent"`
+}
+
+func spendLimitsKey(publicKey string) string {
+	return "spend-limits/" + publicKey + ".json"
+}
+
+// loadSpendLimitsLocked reads a wallet's override and spending from the archive store the first time they
+// are needed; the lock must be held
+func (s *WalletService) loadSpendLimitsLocked(publicKey string) error {
+	if s.Archive == nil || s.spendLimits.loaded[publicKey] {
+		return nil
+	}
+	var record spendRecord
+	data, err := s.Archive.Get(spendLimitsKey(publicKey))
+	switch {
+	case errors.Is(err, errArchiveNotFound):
+	case err != nil:
+		return errors.New("failed to read spend limits: " + err.Error())
+	default:
+		if err := json.Unmarshal(data, &record); err != nil {
+			return errors.New("failed to decode spend limits: " + err.Error())
+		}
+	}
+	if record.Override != nil {
+		s.spendLimits.overrides[publicKey] = record.Override
+	}
+	if len(record.Spent) > 0 {
+		s.spendLimits.spent[publicKey] = record.Spent
+	}
+	s.spendLimits.loaded[publicKey] = true
+	return nil
+}
+
+// saveSpendLimitsLocked persists a wallet's override and spending; they only take effect once the caller
+// swaps them in. The lock must be held.
+func (s *WalletService) saveSpendLimitsLocked(publicKey string, override map[string]models.SpendLimit, spent []*spendEntry) error {
+	if s.Archive == nil {
+		return nil
+	}
+	data, err := json.Marshal(spendRecord{Override: override, Spent: spent})
+	if err != nil {
+		return errors.New("failed to encode spend limits: " + err.Error())
+	}
+	if err := s.Archive.Put(spendLimitsKey(publicKey), data); err != nil {
+		return errors.New("failed to persist spend limits: " + err.Error())
+	}
+	return nil
+}
+
+// NormalizeSpendLimits validates every asset and amount of a limits map and returns it keyed by canonical
+// asset strings
+func NormalizeSpendLimits(limits map[string]models.SpendLimit) (map[string]models.SpendLimit, error) {
+	normalized := make(map[string]models.SpendLimit, len(limits))
+	for asset, limit := range limits {
+		parsed, err := parseAsset(asset)
+		if err != nil {
+			return nil, errors.New("invalid asset in spend limits: " + asset)
+		}
+		for _, value := range []string{limit.PerTransaction, limit.Daily, limit.Monthly} {
+			if value == "" {
+				continue
+			}
+			if stroops, err := amount.ParseInt64(value); err != nil || stroops <= 0 {
+				return nil, errors.New("invalid spend limit for " + asset + ": " + value)
+			}
+		}
+		normalized[assetString(parsed)] = limit
+	}
+	return normalized, nil
+}
+
+// effectiveSpendLimitsLocked returns a wallet's override, or the service-wide limits; the lock must be held
+// and the wallet's state loaded
+func (s *WalletService) effectiveSpendLimitsLocked(publicKey string) (map[string]models.SpendLimit, bool) {
+	if override, ok := s.spendLimits.overrides[publicKey]; ok {
+		return override, false
+	}
+	return s.Config.SpendLimits, true
+}
+
+// spentSinceLocked sums a wallet's entries of an asset at or after since; the lock must be held
+func (s *WalletService) spentSinceLocked(publicKey, asset string, since time.Time) int64 {
+	var total int64
+	for _, entry := range s.spendLimits.spent[publicKey] {
+		if entry.Asset == asset && !entry.At.Before(since) {
+			total += entry.Stroops
+		}
+	}
+	return total
+}
+
+// reserveSpend checks debits against their senders' limits for the debited asset and, when all of them fit,
+// records them so concurrent payments see them. Limits are measured in the asset that leaves the sender's
+// account; the per-transaction limit applies to everything a transaction debits a sender in an asset.
+func (s *WalletService) reserveSpend(debits []paymentDebit) ([]*spendEntry, error) {
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var totals []*spendEntry
+	for _, debit := range debits {
+		i := slices.IndexFunc(totals, func(entry *spendEntry) bool {
+			return entry.Sender == debit.sender && entry.Asset == debit.asset
+		})
+		if i < 0 {
+			totals = append(totals, &spendEntry{Sender: debit.sender, Asset: debit.asset, At: now})
+			i = len(totals) - 1
+		}
+		totals[i].Stroops += debit.stroops
+	}
+
+	s.spendLimits.mu.Lock()
+	defer s.spendLimits.mu.Unlock()
+	var reserved []*spendEntry
+	for _, total := range totals {
+		if err := s.loadSpendLimitsLocked(total.Sender); err != nil {
+			return nil, err
+		}
+		limits, _ := s.effectiveSpendLimitsLocked(total.Sender)
+		limit, ok := limits[total.Asset]
+		if !ok {
+			continue
+		}
+		checks := []struct {
+			name, value string
+			spent       int64
+		}{
+			{"per-transaction", limit.PerTransaction, 0},
+			{"daily", limit.Daily, s.spentSinceLocked(total.Sender, total.Asset, dayStart)},
+			{"monthly", limit.Monthly, s.spentSinceLocked(total.Sender, total.Asset, monthStart)},
+		}
+		for _, check := range checks {
+			if check.value == "" {
+				continue
+			}
+			max, err := amount.ParseInt64(check.value)
+			if err != nil {
+				continue
+			}
+			if check.spent+total.Stroops > max {
+				msg := "spend limit exceeded: " + check.name + " limit of " + check.value + " " + total.Asset
+				if check.spent > 0 {
+					msg += " (already spent " + amount.StringFromInt64(check.spent) + ")"
+				}
+				return nil, errors.New(msg)
+			}
+		}
+		reserved = append(reserved, total)
+	}
+
+	// Entries from before the previous month can no longer count against any window
+	updated := make(map[string][]*spendEntry)
+	for _, entry := range reserved {
+		spent, ok := updated[entry.Sender]
+		if !ok {
+			for _, existing := range s.spendLimits.spent[entry.Sender] {
+				if !existing.At.Before(monthStart.AddDate(0, -1, 0)) {
+					spent = append(spent, existing)
+				}
+			}
+		}
+		updated[entry.Sender] = append(spent, entry)
+	}
+	if err := s.swapSpentLocked(updated); err != nil {
+		return nil, err
+	}
+	return reserved, nil
+}
+
+// swapSpentLocked persists the spending of every wallet in updated and then swaps it in. If a wallet
+// cannot be persisted, those already written are restored and nothing changes. The lock must be held.
+func (s *WalletService) swapSpentLocked(updated map[string][]*spendEntry) error {
+	var saved []string
+	for _, publicKey := range slices.Sorted(maps.Keys(updated)) {
+		if err := s.saveSpendLimitsLocked(publicKey, s.spendLimits.overrides[publicKey], updated[publicKey]); err != nil {
+			for _, restored := range saved {
+				if err := s.saveSpendLimitsLocked(restored, s.spendLimits.overrides[restored], s.spendLimits.spent[restored]); err != nil {
+					log.Printf("failed to restore spend limits of %s: %v", restored, err)
+				}
+			}
+			return err
+		}
+		saved = append(saved, publicKey)
+	}
+	for publicKey, spent := range updated {
+		s.spendLimits.spent[publicKey] = spent
+	}
+	return nil
+}
+
+// releaseSpend removes the reservations of payments that failed or were never submitted. If the release
+// cannot be persisted it still takes effect here, and the archived spending counts the payments until the
+// wallet's next update.
+func (s *WalletService) releaseSpend(entries []*spendEntry) {
+	s.spendLimits.mu.Lock()
+	defer s.spendLimits.mu.Unlock()
+	updated := make(map[string][]*spendEntry)
+	for _, released := range entries {
+		spent, ok := updated[released.Sender]
+		if !ok {
+			spent = s.spendLimits.spent[released.Sender]
+		}
+		updated[released.Sender] = slices.DeleteFunc(slices.Clone(spent), func(entry *spendEntry) bool { return entry == released })
+	}
+	if err := s.swapSpentLocked(updated); err != nil {
+		log.Printf("failed to release spend limit reservations: %v", err)
+		for publicKey, spent := range updated {
+			s.spendLimits.spent[publicKey] = spent
+		}
+	}
+}
+
+// GetSpendLimits returns a wallet's effective spend limits and its spending in the current day and month
+func (s *WalletService) GetSpendLimits(publicKey string) (*models.SpendLimitsResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	s.spendLimits.mu.Lock()
+	defer s.spendLimits.mu.Unlock()
+	if err := s.loadSpendLimitsLocked(publicKey); err != nil {
+		return nil, err
+	}
+	limits, isDefault := s.effectiveSpendLimitsLocked(publicKey)
+	response := &models.SpendLimitsResponse{
+		PublicKey: publicKey,
+		Limits:    make(map[string]models.SpendLimit, len(limits)),
+		Usage:     []models.SpendUsage{},
+		Default:   isDefault,
+	}
+	assets := make(map[string]bool)
+	for asset, limit := range limits {
+		response.Limits[asset] = limit
+		assets[asset] = true
+	}
+	for _, entry := range s.spendLimits.spent[publicKey] {
+		assets[entry.Asset] = true
+	}
+	for asset := range assets {
+		response.Usage = append(response.Usage, models.SpendUsage{
+			Asset:          asset,
+			SpentToday:     amount.StringFromInt64(s.spentSinceLocked(publicKey, asset, dayStart)),
+			SpentThisMonth: amount.StringFromInt64(s.spentSinceLocked(publicKey, asset, monthStart)),
+		})
+	}
+	sort.Slice(response.Usage, func(i, j int) bool { return response.Usage[i].Asset < response.Usage[j].Asset })
+	return response, nil
+}
+
+// SetSpendLimits overrides a wallet's spend limits; assets left out of the override are unlimited
+func (s *WalletService) SetSpendLimits(publicKey string, req models.SpendLimitsRequest) (*models.SpendLimitsResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	override, err := NormalizeSpendLimits(req.Limits)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setSpendOverride(publicKey, override); err != nil {
+		return nil, err
+	}
+	s.Registry.RecordUpdate(publicKey, "spend_limits_updated")
+	return s.GetSpendLimits(publicKey)
+}
+
+// ResetSpendLimits removes a wallet's override so the service-wide limits apply again
+func (s *WalletService) ResetSpendLimits(publicKey string) (*models.SpendLimitsResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	if err := s.setSpendOverride(publicKey, nil); err != nil {
+		return nil, err
+	}
+	s.Registry.RecordUpdate(publicKey, "spend_limits_updated")
+	return s.GetSpendLimits(publicKey)
+}
+
+// setSpendOverride persists a wallet's override, or its removal when override is nil, before it takes effect
+func (s *WalletService) setSpendOverride(publicKey string, override map[string]models.SpendLimit) error {
+	s.spendLimits.mu.Lock()
+	defer s.spendLimits.mu.Unlock()
+	if err := s.loadSpendLimitsLocked(publicKey); err != nil {
+		return err
+	}
+	if err := s.saveSpendLimitsLocked(publicKey, override, s.spendLimits.spent[publicKey]); err != nil {
+		return err
+	}
+	if override == nil {
+		delete(s.spendLimits.overrides, publicKey)
+	} else {
+		s.spendLimits.overrides[publicKey] = override
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+	"github.com/saif727/stellar-wallet-backend/testsupport"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+)
+
+func TestSpendLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit models.SpendLimit
+		// amounts are sent in order; wantErrs holds the expected error of each, empty for success
+		amounts  []string
+		wantErrs []string
+		// rejectFirst makes the network reject the first transfer, which must not count against the limits
+		rejectFirst bool
+	}{
+		{
+			name:     "per transaction limit",
+			limit:    models.SpendLimit{PerTransaction: "10"},
+			amounts:  []string{"10", "10.0000001"},
+			wantErrs: []string{"", "spend limit exceeded: per-transaction"},
+		},
+		{
+			name:     "daily limit counts earlier transfers",
+			limit:    models.SpendLimit{Daily: "12"},
+			amounts:  []string{"5", "5", "5"},
+			wantErrs: []string{"", "", "spend limit exceeded: daily"},
+		},
+		{
+			name:        "rejected transfers are released",
+			limit:       models.SpendLimit{Daily: "6"},
+			amounts:     []string{"5", "5"},
+			wantErrs:    []string{"transaction failed", ""},
+			rejectFirst: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTransferFixture(t)
+			usdc := f.service.Config.USDCAsset
+			if _, err := f.service.SetSpendLimits(f.sender.Address(), models.SpendLimitsRequest{
+				Limits: map[string]models.SpendLimit{usdc.Code + ":" + usdc.Issuer: tt.limit},
+			}); err != nil {
+				t.Fatalf("SetSpendLimits() error = %v", err)
+			}
+			if tt.rejectFirst {
+				f.horizon.FailNextSubmission(hProtocol.TransactionResultCodes{TransactionCode: "tx_failed", OperationCodes: []string{"op_underfunded"}})
+			}
+
+			for i, value := range tt.amounts {
+				_, err := f.service.TransferFunds(testsupport.NewTransferRequest(f.sender, f.recipient.Address(), value))
+				switch {
+				case tt.wantErrs[i] == "" && err != nil:
+					t.Fatalf("transfer %d of %s: error = %v", i+1, value, err)
+				case tt.wantErrs[i] != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErrs[i])):
+					t.Fatalf("transfer %d of %s: error = %v, want %q", i+1, value, err, tt.wantErrs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSpendLimitsPerTransactionTotal(t *testing.T) {
+	f := newTransferFixture(t)
+	usdc := f.service.Config.USDCAsset
+	asset := usdc.Code + ":" + usdc.Issuer
+	if _, err := f.service.SetSpendLimits(f.sender.Address(), models.SpendLimitsRequest{
+		Limits: map[string]models.SpendLimit{asset: {PerTransaction: "10"}},
+	}); err != nil {
+		t.Fatalf("SetSpendLimits() error = %v", err)
+	}
+
+	// Each share is within the limit, but the transaction debits more than it
+	_, err := f.service.SplitTransfer(models.SplitTransferRequest{
+		FromSecretKey: f.sender.Seed(),
+		Amount:        "12",
+		Asset:         asset,
+		Splits: []models.SplitShare{
+			{Destination: f.recipient.Address(), Amount: "6"},
+			{Destination: f.recipient.Address(), Amount: "6"},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "spend limit exceeded: per-transaction") {
+		t.Fatalf("SplitTransfer() error = %v, want the per-transaction limit exceeded", err)
+	}
+}
+
+func TestSpendLimitsPersistence(t *testing.T) {
+	store := &services.LocalArchiveStore{Dir: t.TempDir()}
+	f := newTransferFixture(t)
+	f.service.Archive = store
+	usdc := f.service.Config.USDCAsset
+	asset := usdc.Code + ":" + usdc.Issuer
+	if _, err := f.service.SetSpendLimits(f.sender.Address(), models.SpendLimitsRequest{
+		Limits: map[string]models.SpendLimit{asset: {Daily: "8"}},
+	}); err != nil {
+		t.Fatalf("SetSpendLimits() error = %v", err)
+	}
+	if _, err := f.service.TransferFunds(testsupport.NewTransferRequest(f.sender, f.recipient.Address(), "5")); err != nil {
+		t.Fatalf("TransferFunds() error = %v", err)
+	}
+
+	// A restarted service keeps both the override and the spending it is enforced against
+	restarted := services.NewWalletService(f.service.Config)
+	restarted.Archive = store
+	limits, err := restarted.GetSpendLimits(f.sender.Address())
+	if err != nil {
+		t.Fatalf("GetSpendLimits() error = %v", err)
+	}
+	if limits.Default || limits.Limits[asset].Daily != "8" {
+		t.Errorf("limits after restart = %+v, want the daily override of 8", limits)
+	}
+	_, err = restarted.TransferFunds(testsupport.NewTransferRequest(f.sender, f.recipient.Address(), "5"))
+	if err == nil || !strings.Contains(err.Error(), "spend limit exceeded: daily") {
+		t.Fatalf("TransferFunds() after restart: error = %v, want the daily limit exceeded", err)
+	}
+}
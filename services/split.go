@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// splitAmounts resolves each share of a split to stroops. Percentage shares are taken of the total and
+// rounded down; the stroops lost to rounding go to the first percentage share so the shares add up exactly.
+func splitAmounts(total int64, splits []models.SplitShare) ([]int64, error) {
+	amounts := make([]int64, len(splits))
+	var allocated int64
+	firstPercent := -1
+	percentTotal := new(big.Rat)
+	for i, share := range splits {
+		switch {
+		case share.Percent != "" && share.Amount != "":
+			return nil, errors.New("invalid split: set either percent or amount for " + share.Destination)
+		case share.Amount != "":
+			stroops, err := parseAmount("split amount for "+share.Destination, share.Amount)
+			if err != nil {
+				return nil, err
+			}
+			amounts[i] = stroops
+		case share.Percent != "":
+			percent, ok := new(big.Rat).SetString(share.Percent)
+			if !ok || percent.Sign() <= 0 || percent.Cmp(big.NewRat(100, 1)) > 0 {
+				return nil, errors.New("invalid split percent for " + share.Destination)
+			}
+			percentTotal.Add(percentTotal, percent)
+			stroops := new(big.Rat).Mul(new(big.Rat).SetInt64(total), percent)
+			stroops.Quo(stroops, big.NewRat(100, 1))
+			amounts[i] = new(big.Int).Quo(stroops.Num(), stroops.Denom()).Int64()
+			if firstPercent < 0 {
+				firstPercent = i
+			}
+		default:
+			return nil, errors.New("invalid split: set percent or amount for " + share.Destination)
+		}
+		allocated += amounts[i]
+	}
+	if percentTotal.Cmp(big.NewRat(100, 1)) > 0 {
+		return nil, errors.New("invalid splits: percentages exceed 100")
+	}
+	if firstPercent >= 0 {
+		amounts[firstPercent] += total - allocated
+		allocated = total
+	}
+	if allocated != total {
+		return nil, errors.New("invalid splits: shares add up to " + amount.StringFromInt64(allocated) +
+			", not the total of " + amount.StringFromInt64(total))
+	}
+	for i, stroops := range amounts {
+		if stroops <= 0 {
+			return nil, errors.New("invalid splits: share for " + splits[i].Destination + " rounds to zero")
+		}
+	}
+	return amounts, nil
+}
+
+// SplitTransfer debits the sender once and pays every share in a single atomic transaction, so either all
+// recipients are paid or none are. Each share is validated like a transfer of its own; a share the fraud
+// scorer flags fails the whole split rather than holding part of it for review.
+func (s *WalletService) SplitTransfer(req models.SplitTransferRequest) (*models.SplitTransferResponse, error) {
+	if len(req.Splits) == 0 || len(req.Splits) > maxOperationsPerTransaction {
+		return nil, errors.New("invalid splits: must have between 1 and 100 recipients")
+	}
+	if req.Asset == models.SourceAssetAny {
+		return nil, errors.New("invalid split: the asset must be given explicitly")
+	}
+	total, err := parseAmount("amount", req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	amounts, err := splitAmounts(total, req.Splits)
+	if err != nil {
+		return nil, err
+	}
+
+	legs := make([]*preparedTransfer, 0, len(req.Splits))
+	for i, share := range req.Splits {
+		leg, err := s.prepareTransfer(models.TransferRequest{
+			FromSecretKey: req.FromSecretKey,
+			ToPublicKey:   share.Destination,
+			Amount:        amount.StringFromInt64(amounts[i]),
+			SourceAsset:   req.Asset,
+			Memo:          req.Memo,
+			MemoType:      req.MemoType,
+			Device:        req.Device,
+		})
+		if err == nil {
+			err = s.checkDestination(leg)
+		}
+		if err != nil {
+			return nil, err
+		}
+		legs = append(legs, leg)
+		if assessment, flagged := s.assessTransfer(leg); flagged {
+			return nil, errors.New("split transfer flagged by fraud screening: " + strings.Join(assessment.Reasons, "; "))
+		}
+	}
+
+	senderKP, asset := legs[0].senderKP, legs[0].sendAsset
+	ops := make([]txnbuild.Operation, 0, len(legs))
+	for _, leg := range legs {
+		ops = append(ops, &txnbuild.Payment{Destination: leg.request.ToPublicKey, Amount: leg.request.Amount, Asset: asset})
+	}
+	reservation, err := s.reservePayments(senderKP.Address(), ops)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.buildTransaction(senderKP.Address(), txnbuild.TransactionParams{
+		Operations:    ops,
+		Memo:          legs[0].memo,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+	}, senderKP)
+	if err != nil {
+		s.releasePayments(reservation)
+		return nil, err
+	}
+	resp, err := s.submitPayments(reservation, tx, nil, []*keypair.Full{senderKP})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.SplitTransferResponse{
+		TransactionHash: resp.Hash,
+		Asset:           assetString(asset),
+		Amount:          amount.StringFromInt64(total),
+		Splits:          make([]models.SplitResult, 0, len(legs)),
+		Message:         "Split transfer completed successfully",
+	}
+	results := s.operationResults(resp.Hash, nil)
+	for i, leg := range legs {
+		result := models.SplitResult{Destination: leg.request.ToPublicKey, Amount: leg.request.Amount}
+		if i < len(results) {
+			result.OperationID = results[i].OperationID
+		}
+		response.Splits = append(response.Splits, result)
+	}
+	s.Audit.Record("wallet:"+senderKP.Address(), "transfer.split", resp.Hash, map[string]string{
+		"amount":     response.Amount,
+		"asset":      response.Asset,
+		"recipients": strconv.Itoa(len(legs)),
+	})
+	return response, nil
+}
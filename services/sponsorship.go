@@ -0,0 +1,221 @@
+package services
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// sponsoredEntries lists an account's own entry, trustlines and additional signers with their sponsors
+func sponsoredEntries(account hProtocol.Account) []models.SponsoredEntry {
+	entries := []models.SponsoredEntry{{Type: models.SponsoredEntryAccount, ID: account.AccountID, Sponsor: account.Sponsor}}
+	for _, balance := range account.Balances {
+		switch balance.Type {
+		case "native":
+			continue
+		case "liquidity_pool_shares":
+			entries = append(entries, models.SponsoredEntry{Type: models.SponsoredEntryTrustline, ID: balance.LiquidityPoolId, Sponsor: balance.Sponsor})
+		default:
+			entries = append(entries, models.SponsoredEntry{Type: models.SponsoredEntryTrustline, ID: balance.Code + ":" + balance.Issuer, Sponsor: balance.Sponsor})
+		}
+	}
+	for _, signer := range account.Signers {
+		if signer.Key != account.AccountID {
+			entries = append(entries, models.SponsoredEntry{Type: models.SponsoredEntrySigner, ID: signer.Key, Sponsor: signer.Sponsor})
+		}
+	}
+	return entries
+}
+
+// sponsoredReserves returns the base reserves an entry's sponsor pays: two for an account, one otherwise
+func sponsoredReserves(entry models.SponsoredEntry) int64 {
+	if entry.Type == models.SponsoredEntryAccount {
+		return 2
+	}
+	return 1
+}
+
+// revokeSponsorshipOp returns the operation revoking the sponsorship of one of an account's entries
+func revokeSponsorshipOp(account hProtocol.Account, entry models.SponsoredEntry, sponsor string) (txnbuild.Operation, error) {
+	op := &txnbuild.RevokeSponsorship{SourceAccount: sponsor}
+	switch entry.Type {
+	case models.SponsoredEntryAccount:
+		op.SponsorshipType = txnbuild.RevokeSponsorshipTypeAccount
+		op.Account = &account.AccountID
+	case models.SponsoredEntryTrustline:
+		var line txnbuild.TrustLineAsset
+		if raw, err := hex.DecodeString(entry.ID); err == nil && len(raw) == 32 {
+			var poolID txnbuild.LiquidityPoolId
+			copy(poolID[:], raw)
+			line = txnbuild.LiquidityPoolShareTrustLineAsset{LiquidityPoolID: poolID}
+		} else {
+			asset, err := parseAsset(entry.ID)
+			if err != nil {
+				return nil, errors.New("invalid entry: " + err.Error())
+			}
+			if line, err = asset.ToTrustLineAsset(); err != nil {
+				return nil, errors.New("invalid entry: " + err.Error())
+			}
+		}
+		op.SponsorshipType = txnbuild.RevokeSponsorshipTypeTrustLine
+		op.TrustLine = &txnbuild.TrustLineID{Account: account.AccountID, Asset: line}
+	case models.SponsoredEntrySigner:
+		op.SponsorshipType = txnbuild.RevokeSponsorshipTypeSigner
+		op.Signer = &txnbuild.SignerID{AccountID: account.AccountID, SignerAddress: entry.ID}
+	}
+	return op, nil
+}
+
+// GetSponsorship reports which account pays the reserve of each of a wallet's ledger entries
+func (s *WalletService) GetSponsorship(publicKey string) (*models.SponsorshipStatusResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: publicKey})
+	if err != nil {
+		if herr, ok := err.(*horizonclient.Error); ok && herr.Response.StatusCode == http.StatusNotFound {
+			return nil, errors.New("wallet not found")
+		}
+		return nil, errors.New("failed to fetch wallet account details: " + err.Error())
+	}
+	return &models.SponsorshipStatusResponse{
+		PublicKey:     publicKey,
+		NumSponsored:  account.NumSponsored,
+		NumSponsoring: account.NumSponsoring,
+		Entries:       sponsoredEntries(account),
+	}, nil
+}
+
+// RevokeSponsorship ends the master account's sponsorship of a wallet's entries. Without a new sponsor
+// the wallet takes over the reserves and must hold the XLM for them; with one, the new sponsor takes
+// them over in the same transaction and must hold the XLM instead. An account entry costs two base reserves.
+func (s *WalletService) RevokeSponsorship(publicKey string, req models.RevokeSponsorshipRequest) (*models.RevokeSponsorshipResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	masterKP, err := keypair.ParseFull(s.Config.MasterSecret)
+	if err != nil {
+		return nil, errors.New("invalid master secret key: " + err.Error())
+	}
+	var newSponsorKP *keypair.Full
+	if req.NewSponsorSecretKey != "" {
+		if newSponsorKP, err = keypair.ParseFull(req.NewSponsorSecretKey); err != nil {
+			return nil, errors.New("invalid new sponsor secret key")
+		}
+		if newSponsorKP.Address() == masterKP.Address() || newSponsorKP.Address() == publicKey {
+			return nil, errors.New("invalid new sponsor: must differ from the master account and the wallet")
+		}
+	}
+
+	account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: publicKey})
+	if err != nil {
+		if herr, ok := err.(*horizonclient.Error); ok && herr.Response.StatusCode == http.StatusNotFound {
+			return nil, errors.New("wallet not found")
+		}
+		return nil, errors.New("failed to fetch wallet account details: " + err.Error())
+	}
+	current := sponsoredEntries(account)
+	var targets []models.SponsoredEntry
+	if len(req.Entries) == 0 {
+		for _, entry := range current {
+			if entry.Sponsor == masterKP.Address() {
+				targets = append(targets, entry)
+			}
+		}
+		if len(targets) == 0 {
+			return nil, errors.New("wallet has no entries sponsored by the master account")
+		}
+	}
+	for _, requested := range req.Entries {
+		found := false
+		for _, entry := range current {
+			if entry.Type == requested.Type && entry.ID == requested.ID {
+				if entry.Sponsor != masterKP.Address() {
+					return nil, errors.New("invalid entry: " + entry.Type + " " + entry.ID + " is not sponsored by the master account")
+				}
+				targets = append(targets, entry)
+				found = true
+			}
+		}
+		if !found {
+			return nil, errors.New("invalid entry: wallet has no " + requested.Type + " " + requested.ID)
+		}
+	}
+
+	// Whoever takes the entries over, the wallet or the new sponsor, must hold the XLM for their reserves
+	baseReserve, err := s.baseReserveStroops()
+	if err != nil {
+		return nil, err
+	}
+	var needed int64
+	for _, entry := range targets {
+		needed += sponsoredReserves(entry) * baseReserve
+	}
+	payer, payerName := account, "wallet"
+	if newSponsorKP != nil {
+		payerName = "new sponsor"
+		if payer, err = s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: newSponsorKP.Address()}); err != nil {
+			if herr, ok := err.(*horizonclient.Error); ok && herr.Response.StatusCode == http.StatusNotFound {
+				return nil, errors.New("invalid new sponsor: account not found")
+			}
+			return nil, errors.New("failed to fetch new sponsor account details: " + err.Error())
+		}
+	}
+	var available int64
+	for _, balance := range payer.Balances {
+		if balance.Type == "native" {
+			available, _ = amount.ParseInt64(availableBalance(balance, payer, baseReserve))
+		}
+	}
+	if available < needed {
+		return nil, errors.New(payerName + " has insufficient XLM to pay the reserves: needs " + amount.StringFromInt64(needed) + ", has " + amount.StringFromInt64(available) + " available")
+	}
+
+	var ops []txnbuild.Operation
+	signers := []*keypair.Full{masterKP}
+	if newSponsorKP != nil {
+		// Revoking while the new sponsor sponsors the master's future reserves transfers the sponsorship
+		ops = append(ops, &txnbuild.BeginSponsoringFutureReserves{SponsoredID: masterKP.Address(), SourceAccount: newSponsorKP.Address()})
+		signers = append(signers, newSponsorKP)
+	}
+	for _, entry := range targets {
+		op, err := revokeSponsorshipOp(account, entry, masterKP.Address())
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	if newSponsorKP != nil {
+		ops = append(ops, &txnbuild.EndSponsoringFutureReserves{SourceAccount: masterKP.Address()})
+	}
+
+	resp, err := s.submitMasterOperations(masterKP, ops, signers...)
+	if err != nil {
+		return nil, err
+	}
+	s.Registry.RecordUpdate(publicKey, "sponsorship_revoked")
+	details := map[string]string{"transaction_hash": resp.Hash, "entries": strconv.Itoa(len(targets))}
+	if newSponsorKP != nil {
+		details["new_sponsor"] = newSponsorKP.Address()
+	}
+	s.Audit.Record("master", "wallet.sponsorship_revoked", publicKey, details)
+
+	response := &models.RevokeSponsorshipResponse{PublicKey: publicKey, TransactionHash: resp.Hash}
+	for _, entry := range targets {
+		entry.Sponsor = ""
+		if newSponsorKP != nil {
+			entry.Sponsor = newSponsorKP.Address()
+			response.NewSponsor = newSponsorKP.Address()
+		}
+		response.Entries = append(response.Entries, entry)
+	}
+	return response, nil
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+)
+
+// statementMonthLayout is the YYYY-MM form of a statement's month
+const statementMonthLayout = "2006-01"
+
+// WalletStatement aggregates a wallet's payments, network fees and ending balances over a calendar month
+// given as YYYY-MM
+func (s *WalletService) WalletStatement(publicKey, month string) (*models.Statement, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	start, err := time.Parse(statementMonthLayout, month)
+	if err != nil {
+		return nil, errors.New("invalid month: must be YYYY-MM")
+	}
+	end := start.AddDate(0, 1, 0)
+	now := time.Now().UTC()
+	if start.After(now) {
+		return nil, errors.New("invalid month: the period has not started")
+	}
+
+	statement := &models.Statement{
+		PublicKey:   publicKey,
+		Month:       month,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Payments:    []models.PaymentRecord{},
+		GeneratedAt: now,
+	}
+	// The period end is exclusive, while the payment history filter is inclusive
+	filter := models.PaymentHistoryFilter{
+		From:  start.Format(time.RFC3339),
+		To:    end.Add(-time.Second).Format(time.RFC3339),
+		Limit: maxPaymentHistoryLimit,
+	}
+	for {
+		page, err := s.WalletPayments(publicKey, filter)
+		if err != nil {
+			return nil, err
+		}
+		statement.Payments = append(statement.Payments, page.Payments...)
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	// Statements read oldest first
+	sort.SliceStable(statement.Payments, func(i, j int) bool {
+		return statement.Payments[i].CreatedAt.Before(statement.Payments[j].CreatedAt)
+	})
+
+	fees, count, err := s.feesPaid(publicKey, start, end)
+	if err != nil {
+		return nil, err
+	}
+	statement.FeesPaid, statement.FeeTransactions = amount.StringFromInt64(fees), count
+
+	balances, asOf, err := s.endingBalances(publicKey, end, now)
+	if err != nil {
+		return nil, err
+	}
+	statement.BalancesAsOf = asOf
+	statement.Assets = statementAssets(statement.Payments, balances)
+	return statement, nil
+}
+
+// feesPaid sums the network fees a wallet's account was charged for transactions in [start, end),
+// including failed ones, which are charged too
+func (s *WalletService) feesPaid(publicKey string, start, end time.Time) (int64, int, error) {
+	var total int64
+	var count int
+	cursor := ""
+	for {
+		page, err := s.Config.HorizonClient.Transactions(horizonclient.TransactionRequest{
+			ForAccount:    publicKey,
+			Order:         horizonclient.OrderDesc,
+			Cursor:        cursor,
+			Limit:         maxPaymentHistoryLimit,
+			IncludeFailed: true,
+		})
+		if err != nil {
+			return 0, 0, activityError("transactions", err)
+		}
+		for _, tx := range page.Embedded.Records {
+			cursor = tx.PagingToken()
+			if !tx.LedgerCloseTime.Before(end) {
+				continue
+			}
+			if tx.LedgerCloseTime.Before(start) {
+				return total, count, nil
+			}
+			if tx.FeeAccount == publicKey {
+				total += tx.FeeCharged
+				count++
+			}
+		}
+		if len(page.Embedded.Records) < maxPaymentHistoryLimit {
+			return total, count, nil
+		}
+	}
+}
+
+// endingBalances returns a wallet's balances at the end of a period: its current balances while the period
+// is still running, and otherwise the last balance snapshot taken within it, if any
+func (s *WalletService) endingBalances(publicKey string, end, now time.Time) (map[string]string, *time.Time, error) {
+	if end.After(now) {
+		account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: publicKey})
+		if err != nil {
+			return nil, nil, activityError("account details", err)
+		}
+		return accountBalances(account), &now, nil
+	}
+	history, err := s.balanceHistory(publicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].TakenAt.Before(end) {
+			takenAt := history[i].TakenAt
+			return history[i].Balances, &takenAt, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// statementAssets totals payments per asset alongside the ending balance of every asset held or moved
+func statementAssets(payments []models.PaymentRecord, balances map[string]string) []models.StatementAssetSummary {
+	received, sent := map[string]int64{}, map[string]int64{}
+	assets := map[string]bool{}
+	for _, payment := range payments {
+		stroops, err := amount.ParseInt64(payment.Amount)
+		if err != nil {
+			continue
+		}
+		assets[payment.Asset] = true
+		if payment.Direction == models.DirectionSent {
+			sent[payment.Asset] += stroops
+		} else {
+			received[payment.Asset] += stroops
+		}
+	}
+	for asset := range balances {
+		assets[asset] = true
+	}
+
+	summaries := make([]models.StatementAssetSummary, 0, len(assets))
+	for asset := range assets {
+		summary := models.StatementAssetSummary{
+			Asset:    asset,
+			Received: amount.StringFromInt64(received[asset]),
+			Sent:     amount.StringFromInt64(sent[asset]),
+			Net:      amount.StringFromInt64(received[asset] - sent[asset]),
+		}
+		if balances != nil {
+			summary.EndingBalance = "0.0000000"
+			if balance, ok := balances[asset]; ok {
+				summary.EndingBalance = balance
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Asset < summaries[j].Asset })
+	return summaries
+}
+
+// statementLines returns a statement as rows of cells: a summary followed by every payment
+func statementLines(statement *models.Statement) [][]string {
+	balancesAsOf := "unavailable"
+	if statement.BalancesAsOf != nil {
+		balancesAsOf = statement.BalancesAsOf.Format(time.RFC3339)
+	}
+	lines := [][]string{
+		{"wallet", statement.PublicKey},
+		{"month", statement.Month},
+		{"period_start", statement.PeriodStart.Format(time.RFC3339)},
+		{"period_end", statement.PeriodEnd.Format(time.RFC3339)},
+		{"fees_paid", statement.FeesPaid, "native"},
+		{"fee_transactions", strconv.Itoa(statement.FeeTransactions)},
+		{"balances_as_of", balancesAsOf},
+		{},
+		{"asset", "received", "sent", "net", "ending_balance"},
+	}
+	for _, summary := range statement.Assets {
+		lines = append(lines, []string{summary.Asset, summary.Received, summary.Sent, summary.Net, summary.EndingBalance})
+	}
+	lines = append(lines, []string{}, []string{"date", "type", "direction", "counterparty", "asset", "amount", "transaction_hash"})
+	for _, payment := range statement.Payments {
+		lines = append(lines, []string{
+			payment.CreatedAt.Format(time.RFC3339), payment.Type, payment.Direction, payment.Counterparty,
+			payment.Asset, payment.Amount, payment.TransactionHash,
+		})
+	}
+	return lines
+}
+
+// StatementCSV renders a statement as CSV
+func StatementCSV(statement *models.Statement) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(statementLines(statement)); err != nil {
+		return nil, errors.New("failed to render statement: " + err.Error())
+	}
+	return buf.Bytes(), nil
+}
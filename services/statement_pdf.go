@@ -0,0 +1,124 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+)
+
+// Statement PDFs are landscape A4 pages of monospaced text, so columns line up without a layout engine
+const (
+	pdfPageWidth    = 842
+	pdfPageHeight   = 595
+	pdfMargin       = 36
+	pdfFontSize     = 7
+	pdfLineHeight   = 9
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+)
+
+// shortAsset abbreviates a CODE:ISSUER asset to fit a PDF column
+func shortAsset(asset string) string {
+	code, issuer, ok := strings.Cut(asset, ":")
+	if !ok || len(issuer) < 8 {
+		return asset
+	}
+	return code + ":" + issuer[:4] + ".." + issuer[len(issuer)-4:]
+}
+
+// statementText lays a statement out as lines of monospaced text
+func statementText(statement *models.Statement) []string {
+	balancesAsOf := "unavailable: no balance snapshot was taken in this period"
+	if statement.BalancesAsOf != nil {
+		balancesAsOf = statement.BalancesAsOf.Format(time.RFC3339)
+	}
+	lines := []string{
+		"Wallet statement " + statement.Month,
+		"",
+		"Wallet:          " + statement.PublicKey,
+		"Period:          " + statement.PeriodStart.Format(time.RFC3339) + " to " + statement.PeriodEnd.Format(time.RFC3339),
+		"Network fees:    " + statement.FeesPaid + " XLM over " + fmt.Sprint(statement.FeeTransactions) + " transactions",
+		"Balances as of:  " + balancesAsOf,
+		"Generated at:    " + statement.GeneratedAt.Format(time.RFC3339),
+		"",
+		fmt.Sprintf("%-22s %20s %20s %20s %20s", "Asset", "Received", "Sent", "Net", "Ending balance"),
+	}
+	for _, summary := range statement.Assets {
+		lines = append(lines, fmt.Sprintf("%-22s %20s %20s %20s %20s",
+			shortAsset(summary.Asset), summary.Received, summary.Sent, summary.Net, summary.EndingBalance))
+	}
+	lines = append(lines, "", fmt.Sprintf("%-20s %-26s %-8s %-56s %-22s %20s %-16s",
+		"Date", "Type", "Dir", "Counterparty", "Asset", "Amount", "Transaction"))
+	for _, payment := range statement.Payments {
+		hash := payment.TransactionHash
+		if len(hash) > 16 {
+			hash = hash[:16]
+		}
+		lines = append(lines, fmt.Sprintf("%-20s %-26s %-8s %-56s %-22s %20s %-16s",
+			payment.CreatedAt.Format(time.RFC3339), payment.Type, payment.Direction, payment.Counterparty,
+			shortAsset(payment.Asset), payment.Amount, hash))
+	}
+	if len(statement.Payments) == 0 {
+		lines = append(lines, "No payments in this period.")
+	}
+	return lines
+}
+
+// pdfEscape escapes text for a PDF string literal
+func pdfEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(text)
+}
+
+// StatementPDF renders a statement as a PDF document
+func StatementPDF(statement *models.Statement) []byte {
+	lines := statementText(statement)
+	var pages [][]string
+	for len(lines) > pdfLinesPerPage {
+		pages = append(pages, lines[:pdfLinesPerPage])
+		lines = lines[pdfLinesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	// Objects 1-3 are the catalog, the page tree and the font; each page then takes a page object and a
+	// content stream object
+	objects := make([]string, 3, 3+2*len(pages))
+	kids := make([]string, len(pages))
+	for i, page := range pages {
+		pageID, contentID := 4+2*i, 5+2*i
+		kids[i] = fmt.Sprintf("%d 0 R", pageID)
+
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) '\n", pdfEscape(line))
+		}
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(pages))
+		fmt.Fprintf(&content, "ET\nBT /F1 %d Tf %d %d Td (%s) Tj ET\n", pdfFontSize, pdfPageWidth-pdfMargin-60, pdfMargin/2, footer)
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, contentID),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R >>"
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+	objects[2] = "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>"
+
+	var doc bytes.Buffer
+	doc.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = doc.Len()
+		fmt.Fprintf(&doc, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := doc.Len()
+	fmt.Fprintf(&doc, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&doc, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&doc, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return doc.Bytes()
+}
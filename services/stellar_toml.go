@@ -0,0 +1,299 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stellar/go/keypair"
+)
+
+// defaultStellarTomlMaxAge is how long clients may cache the stellar.toml, and how long it is served before
+// being rendered again
+const defaultStellarTomlMaxAge = time.Hour
+
+// StellarTomlConfig is the operator-maintained part of the stellar.toml (SEP-1): documentation, the
+// endpoints of the SEP services the operator runs and the currencies it issues. The network, Horizon URL,
+// master account and channel accounts are filled in from the service configuration.
+type StellarTomlConfig struct {
+	Documentation       StellarTomlDocumentation `json:"documentation"`
+	SigningKey          string                   `json:"signing_key"`
+	WebAuthEndpoint     string                   `json:"web_auth_endpoint"`
+	FederationServer    string                   `json:"federation_server"`
+	TransferServer      string                   `json:"transfer_server"`
+	TransferServer0024  string                   `json:"transfer_server_sep0024"`
+	KYCServer           string                   `json:"kyc_server"`
+	DirectPaymentServer string                   `json:"direct_payment_server"`
+	AnchorQuoteServer   string                   `json:"anchor_quote_server"`
+	// Accounts lists accounts the operator controls besides the master and channel accounts
+	Accounts   []string              `json:"accounts"`
+	Currencies []StellarTomlCurrency `json:"currencies"`
+	// CacheMaxAgeSeconds defaults to an hour
+	CacheMaxAgeSeconds int `json:"cache_max_age_seconds"`
+}
+
+// StellarTomlDocumentation is the [DOCUMENTATION] table describing the operator
+type StellarTomlDocumentation struct {
+	OrgName            string `json:"org_name"`
+	OrgDBA             string `json:"org_dba"`
+	OrgURL             string `json:"org_url"`
+	OrgLogo            string `json:"org_logo"`
+	OrgDescription     string `json:"org_description"`
+	OrgPhysicalAddress string `json:"org_physical_address"`
+	OrgOfficialEmail   string `json:"org_official_email"`
+	OrgSupportEmail    string `json:"org_support_email"`
+	OrgTwitter         string `json:"org_twitter"`
+	OrgGithub          string `json:"org_github"`
+}
+
+// StellarTomlCurrency is a [[CURRENCIES]] entry describing an asset the operator issues
+type StellarTomlCurrency struct {
+	Code            string `json:"code"`
+	Issuer          string `json:"issuer"`
+	Status          string `json:"status"`
+	DisplayDecimals *int   `json:"display_decimals"`
+	Name            string `json:"name"`
+	Desc            string `json:"desc"`
+	Conditions      string `json:"conditions"`
+	Image           string `json:"image"`
+	IsUnlimited     *bool  `json:"is_unlimited"`
+	IsAssetAnchored *bool  `json:"is_asset_anchored"`
+	// AnchorAssetType is fiat, crypto, nft, stock, bond, commodity, realestate or other
+	AnchorAssetType        string `json:"anchor_asset_type"`
+	AnchorAsset            string `json:"anchor_asset"`
+	AttestationOfReserve   string `json:"attestation_of_reserve"`
+	RedemptionInstructions string `json:"redemption_instructions"`
+}
+
+var (
+	stellarTomlCurrencyStatuses = []string{"live", "dead", "test", "private"}
+	stellarTomlAnchorAssetTypes = []string{"fiat", "crypto", "nft", "stock", "bond", "commodity", "realestate", "other"}
+)
+
+// StellarTomlService serves the operator's stellar.toml, rendered from its configuration
+type StellarTomlService struct {
+	Wallets *WalletService
+	Config  StellarTomlConfig
+
+	mu         sync.Mutex
+	body       []byte
+	etag       string
+	renderedAt time.Time
+}
+
+// LoadStellarTomlConfig reads a StellarTomlConfig from a JSON file, rejecting unknown fields so typos are
+// not silently dropped from the stellar.toml
+func LoadStellarTomlConfig(path string) (StellarTomlConfig, error) {
+	var config StellarTomlConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, errors.New("failed to read stellar.toml configuration: " + err.Error())
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return config, errors.New("invalid stellar.toml configuration: " + err.Error())
+	}
+	return config, nil
+}
+
+// NewStellarTomlService creates a StellarTomlService after validating its configuration
+func NewStellarTomlService(wallets *WalletService, config StellarTomlConfig) (*StellarTomlService, error) {
+	if config.SigningKey != "" {
+		if _, err := keypair.ParseAddress(config.SigningKey); err != nil {
+			return nil, errors.New("invalid stellar.toml signing_key: " + config.SigningKey)
+		}
+	}
+	endpoints := map[string]string{
+		"web_auth_endpoint":       config.WebAuthEndpoint,
+		"federation_server":       config.FederationServer,
+		"transfer_server":         config.TransferServer,
+		"transfer_server_sep0024": config.TransferServer0024,
+		"kyc_server":              config.KYCServer,
+		"direct_payment_server":   config.DirectPaymentServer,
+		"anchor_quote_server":     config.AnchorQuoteServer,
+	}
+	for name, endpoint := range endpoints {
+		if endpoint == "" {
+			continue
+		}
+		parsed, err := url.Parse(endpoint)
+		if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return nil, errors.New("invalid stellar.toml " + name + ": must be an https URL")
+		}
+	}
+	if config.WebAuthEndpoint != "" && config.SigningKey == "" {
+		return nil, errors.New("invalid stellar.toml configuration: web_auth_endpoint requires signing_key")
+	}
+	for _, account := range config.Accounts {
+		if _, err := keypair.ParseAddress(account); err != nil {
+			return nil, errors.New("invalid stellar.toml account: " + account)
+		}
+	}
+	for _, currency := range config.Currencies {
+		if !assetCodePattern.MatchString(currency.Code) {
+			return nil, errors.New("invalid stellar.toml currency code: " + currency.Code)
+		}
+		if _, err := keypair.ParseAddress(currency.Issuer); err != nil {
+			return nil, errors.New("invalid stellar.toml issuer of " + currency.Code + ": " + currency.Issuer)
+		}
+		if currency.Status != "" && !slices.Contains(stellarTomlCurrencyStatuses, currency.Status) {
+			return nil, errors.New("invalid stellar.toml status of " + currency.Code + ": " + currency.Status)
+		}
+		if currency.AnchorAssetType != "" && !slices.Contains(stellarTomlAnchorAssetTypes, currency.AnchorAssetType) {
+			return nil, errors.New("invalid stellar.toml anchor_asset_type of " + currency.Code + ": " + currency.AnchorAssetType)
+		}
+		if currency.DisplayDecimals != nil && (*currency.DisplayDecimals < 0 || *currency.DisplayDecimals > 7) {
+			return nil, errors.New("invalid stellar.toml display_decimals of " + currency.Code + ": must be 0 to 7")
+		}
+	}
+	if config.CacheMaxAgeSeconds < 0 {
+		return nil, errors.New("invalid stellar.toml cache_max_age_seconds: must not be negative")
+	}
+	return &StellarTomlService{Wallets: wallets, Config: config}, nil
+}
+
+// MaxAge is how long clients may cache the stellar.toml
+func (s *StellarTomlService) MaxAge() time.Duration {
+	if s.Config.CacheMaxAgeSeconds > 0 {
+		return time.Duration(s.Config.CacheMaxAgeSeconds) * time.Second
+	}
+	return defaultStellarTomlMaxAge
+}
+
+// Render returns the stellar.toml and its ETag. It is rendered again once it is older than MaxAge, so
+// changes such as provisioned channel accounts are picked up.
+func (s *StellarTomlService) Render() ([]byte, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.body != nil && time.Since(s.renderedAt) < s.MaxAge() {
+		return s.body, s.etag
+	}
+	s.body = s.render()
+	sum := sha256.Sum256(s.body)
+	s.etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+	s.renderedAt = time.Now()
+	return s.body, s.etag
+}
+
+// render writes the stellar.toml: global fields, then [DOCUMENTATION], then one [[CURRENCIES]] per asset
+func (s *StellarTomlService) render() []byte {
+	var b bytes.Buffer
+	config := s.Config
+	b.WriteString("# Generated by stellar-wallet-backend from its configuration; do not edit\n")
+	writeTomlString(&b, "NETWORK_PASSPHRASE", s.Wallets.networkPassphrase())
+	if client := s.Wallets.Config.HorizonClient; client != nil {
+		writeTomlString(&b, "HORIZON_URL", strings.TrimSuffix(client.HorizonURL, "/"))
+	}
+	writeTomlString(&b, "SIGNING_KEY", config.SigningKey)
+	writeTomlString(&b, "WEB_AUTH_ENDPOINT", config.WebAuthEndpoint)
+	writeTomlString(&b, "FEDERATION_SERVER", config.FederationServer)
+	writeTomlString(&b, "TRANSFER_SERVER", config.TransferServer)
+	writeTomlString(&b, "TRANSFER_SERVER_SEP0024", config.TransferServer0024)
+	writeTomlString(&b, "KYC_SERVER", config.KYCServer)
+	writeTomlString(&b, "DIRECT_PAYMENT_SERVER", config.DirectPaymentServer)
+	writeTomlString(&b, "ANCHOR_QUOTE_SERVER", config.AnchorQuoteServer)
+
+	var accounts []string
+	if master, err := keypair.Parse(s.Wallets.Config.MasterSecret); err == nil {
+		accounts = append(accounts, master.Address())
+	}
+	if s.Wallets.Channels != nil {
+		accounts = append(accounts, s.Wallets.Channels.Addresses()...)
+	}
+	for _, account := range config.Accounts {
+		if !slices.Contains(accounts, account) {
+			accounts = append(accounts, account)
+		}
+	}
+	if len(accounts) > 0 {
+		quoted := make([]string, len(accounts))
+		for i, account := range accounts {
+			quoted[i] = tomlQuote(account)
+		}
+		b.WriteString("ACCOUNTS = [" + strings.Join(quoted, ", ") + "]\n")
+	}
+
+	doc := config.Documentation
+	if doc != (StellarTomlDocumentation{}) {
+		b.WriteString("\n[DOCUMENTATION]\n")
+		writeTomlString(&b, "ORG_NAME", doc.OrgName)
+		writeTomlString(&b, "ORG_DBA", doc.OrgDBA)
+		writeTomlString(&b, "ORG_URL", doc.OrgURL)
+		writeTomlString(&b, "ORG_LOGO", doc.OrgLogo)
+		writeTomlString(&b, "ORG_DESCRIPTION", doc.OrgDescription)
+		writeTomlString(&b, "ORG_PHYSICAL_ADDRESS", doc.OrgPhysicalAddress)
+		writeTomlString(&b, "ORG_OFFICIAL_EMAIL", doc.OrgOfficialEmail)
+		writeTomlString(&b, "ORG_SUPPORT_EMAIL", doc.OrgSupportEmail)
+		writeTomlString(&b, "ORG_TWITTER", doc.OrgTwitter)
+		writeTomlString(&b, "ORG_GITHUB", doc.OrgGithub)
+	}
+
+	for _, currency := range config.Currencies {
+		b.WriteString("\n[[CURRENCIES]]\n")
+		writeTomlString(&b, "code", currency.Code)
+		writeTomlString(&b, "issuer", currency.Issuer)
+		writeTomlString(&b, "status", currency.Status)
+		if currency.DisplayDecimals != nil {
+			b.WriteString("display_decimals = " + strconv.Itoa(*currency.DisplayDecimals) + "\n")
+		}
+		writeTomlString(&b, "name", currency.Name)
+		writeTomlString(&b, "desc", currency.Desc)
+		writeTomlString(&b, "conditions", currency.Conditions)
+		writeTomlString(&b, "image", currency.Image)
+		if currency.IsUnlimited != nil {
+			b.WriteString("is_unlimited = " + strconv.FormatBool(*currency.IsUnlimited) + "\n")
+		}
+		if currency.IsAssetAnchored != nil {
+			b.WriteString("is_asset_anchored = " + strconv.FormatBool(*currency.IsAssetAnchored) + "\n")
+		}
+		writeTomlString(&b, "anchor_asset_type", currency.AnchorAssetType)
+		writeTomlString(&b, "anchor_asset", currency.AnchorAsset)
+		writeTomlString(&b, "attestation_of_reserve", currency.AttestationOfReserve)
+		writeTomlString(&b, "redemption_instructions", currency.RedemptionInstructions)
+	}
+	return b.Bytes()
+}
+
+// writeTomlString writes a string key, skipping empty values
+func writeTomlString(b *bytes.Buffer, key, value string) {
+	if value != "" {
+		b.WriteString(key + " = " + tomlQuote(value) + "\n")
+	}
+}
+
+// tomlQuote encodes a TOML basic string
+func tomlQuote(value string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r < 0x20 || r == 0x7f || r == utf8.RuneError:
+			b.WriteString(`\u` + strings.ToUpper(strconv.FormatInt(int64(r)|0x10000, 16)[1:]))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// submission failure spike defaults used when the corresponding Config fields are zero
+const (
+	defaultSubmissionFailureWindow = 5 * time.Minute
+	defaultSubmissionFailureRate   = 0.5
+	submissionMinFailuresToAlert   = 10
+)
+
+type submissionSample struct {
+	failed     bool
+	recordedAt time.Time
+}
+
+// SubmissionMonitor tracks the outcome of transaction submissions and alerts when failures spike: when at
+// least submissionMinFailuresToAlert submissions failed within Window and they make up at least Rate of all
+// submissions in it. It alerts again once the failure rate falls back below Rate.
+type SubmissionMonitor struct {
+	Window  time.Duration
+	Rate    float64
+	Alerter Alerter
+
+	mu      sync.Mutex
+	samples []submissionSample
+	spiking bool
+}
+
+// NewSubmissionMonitor creates a new SubmissionMonitor, applying defaults for zero values
+func NewSubmissionMonitor(window time.Duration, rate float64, alerter Alerter) *SubmissionMonitor {
+	if window <= 0 {
+		window = defaultSubmissionFailureWindow
+	}
+	if rate <= 0 || rate > 1 {
+		rate = defaultSubmissionFailureRate
+	}
+	return &SubmissionMonitor{Window: window, Rate: rate, Alerter: alerter}
+}
+
+// Record adds the outcome of a submission and alerts when a failure spike starts or ends
+func (m *SubmissionMonitor) Record(err error) {
+	m.mu.Lock()
+	now := time.Now()
+	m.samples = append(m.samples, submissionSample{failed: err != nil, recordedAt: now})
+	cutoff := now.Add(-m.Window)
+	i := 0
+	for i < len(m.samples) && m.samples[i].recordedAt.Before(cutoff) {
+		i++
+	}
+	m.samples = m.samples[i:]
+
+	failures := 0
+	for _, sample := range m.samples {
+		if sample.failed {
+			failures++
+		}
+	}
+	rate := float64(failures) / float64(len(m.samples))
+
+	var title string
+	switch {
+	case !m.spiking && failures >= submissionMinFailuresToAlert && rate >= m.Rate:
+		m.spiking = true
+		title = "Transaction submission failures spiking"
+	case m.spiking && rate < m.Rate:
+		m.spiking = false
+		title = "Transaction submission failures recovered"
+	}
+	message := fmt.Sprintf("%d of %d submissions in the last %s failed (alert rate %.0f%%)",
+		failures, len(m.samples), m.Window, m.Rate*100)
+	if err != nil {
+		message += "; latest error: " + err.Error()
+	}
+	m.mu.Unlock()
+
+	if title != "" && m.Alerter != nil {
+		m.Alerter.Alert(title, message)
+	}
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// persistSubmission archives a transaction as pending just before it is sent, so an envelope whose
+// submission is cut short by a crash or timeout can still be found by hash and recovered
+func (s *WalletService) persistSubmission(tx *txnbuild.Transaction) {
+	if s.Archive == nil {
+		return
+	}
+	hash, err := tx.HashHex(s.networkPassphrase())
+	if err != nil {
+		log.Printf("archive: failed to hash transaction: %v", err)
+		return
+	}
+	envelope, err := tx.Base64()
+	if err != nil {
+		log.Printf("archive: failed to encode envelope %s: %v", hash, err)
+		return
+	}
+	data, err := json.Marshal(models.ArchivedTransaction{
+		Hash:        hash,
+		EnvelopeXDR: envelope,
+		Pending:     true,
+		ArchivedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("archive: failed to encode record %s: %v", hash, err)
+		return
+	}
+	if err := s.Archive.Put(archiveKey(hash), data); err != nil {
+		log.Printf("archive: failed to store pending %s: %v", hash, err)
+	}
+}
+
+// appliedOnLedger looks a transaction up by hash and returns it when it is already on the ledger
+func (s *WalletService) appliedOnLedger(tx *txnbuild.Transaction) (hProtocol.Transaction, bool) {
+	hash, err := tx.HashHex(s.networkPassphrase())
+	if err != nil {
+		return hProtocol.Transaction{}, false
+	}
+	applied, err := s.Config.HorizonClient.TransactionDetail(hash)
+	return applied, err == nil
+}
+
+// appliedEarlier reports whether a transaction about to be submitted was already applied by an earlier
+// submission whose result was lost. Only envelopes this service failed to confirm before are looked up.
+func (s *WalletService) appliedEarlier(tx *txnbuild.Transaction) (hProtocol.Transaction, bool) {
+	hash, err := tx.HashHex(s.networkPassphrase())
+	if err != nil {
+		return hProtocol.Transaction{}, false
+	}
+	if _, ok := s.unconfirmed.get(hash); !ok {
+		return hProtocol.Transaction{}, false
+	}
+	return s.appliedOnLedger(tx)
+}
+
+// rejectedSubmission reports whether Horizon definitively rejected a transaction's last submission, as
+// opposed to its outcome being lost. Its sender was told it failed, so it must not be revived.
+func (s *WalletService) rejectedSubmission(hash string) bool {
+	if entry, ok := s.unconfirmed.get(hash); ok {
+		var txErr *TransactionError
+		return errors.As(entry.submitErr, &txErr)
+	}
+	if s.Archive == nil {
+		return false
+	}
+	record, err := s.GetArchivedTransaction(hash)
+	return err == nil && !record.Pending && strings.HasPrefix(record.Error, "transaction failed:")
+}
+
+// accountSigner returns the keypair of an account the service signs for: a managed wallet or the master
+func (s *WalletService) accountSigner(publicKey string) (*keypair.Full, bool) {
+	if kp, ok := s.Registry.Get(publicKey); ok {
+		return kp, true
+	}
+	if masterKP, err := keypair.ParseFull(s.Config.MasterSecret); err == nil && masterKP.Address() == publicKey {
+		return masterKP, true
+	}
+	return nil, false
+}
+
+// RecoverTransaction settles a transaction whose submission result was lost, for example to a timeout. It
+// is looked up by hash before anything is resubmitted: one already on the ledger is reported confirmed,
+// one within its time bounds is resubmitted unchanged, and an expired one from an account the service
+// signs for is rebuilt with a new sequence number and fresh time bounds. An expired transaction the
+// service cannot sign is reported expired for the client to rebuild. Transactions Horizon rejected are
+// refused, since their senders already saw them fail.
+func (s *WalletService) RecoverTransaction(hash string) (*models.RecoverTransactionResponse, error) {
+	hash = strings.ToLower(hash)
+	if _, err := hex.DecodeString(hash); err != nil || len(hash) != 64 {
+		return nil, errors.New("invalid transaction hash")
+	}
+
+	applied, err := s.Config.HorizonClient.TransactionDetail(hash)
+	if err == nil {
+		s.unconfirmed.remove(hash)
+		return &models.RecoverTransactionResponse{
+			Hash:            hash,
+			Status:          models.RecoveryConfirmed,
+			TransactionHash: hash,
+			Ledger:          applied.Ledger,
+			Message:         "Transaction is already on the ledger",
+		}, nil
+	}
+	if herr, ok := err.(*horizonclient.Error); !ok || herr.Problem.Status != http.StatusNotFound {
+		return nil, errors.New("failed to check transaction status: " + err.Error())
+	}
+	tx, err := s.stuckTransaction(hash)
+	if err != nil {
+		return nil, err
+	}
+	if s.rejectedSubmission(hash) {
+		return nil, errors.New("transaction was rejected by the network; submit a new transaction instead")
+	}
+	source := tx.SourceAccount().AccountID
+	if err := s.checkNotFrozen(source); err != nil {
+		return nil, err
+	}
+
+	if bounds := tx.Timebounds(); bounds.MaxTime == 0 || time.Now().Unix() <= bounds.MaxTime {
+		// The same envelope can be applied at most once, so resubmitting it is always safe
+		resp, err := s.submitTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+		s.Audit.Record("master", "transaction.recovered", hash, map[string]string{"outcome": models.RecoveryResubmitted})
+		return &models.RecoverTransactionResponse{
+			Hash:            hash,
+			Status:          models.RecoveryResubmitted,
+			TransactionHash: resp.Hash,
+			Ledger:          resp.Ledger,
+			Message:         "Transaction resubmitted unchanged",
+		}, nil
+	}
+
+	var preconditions txnbuild.Preconditions
+	if err := preconditions.FromXDR(tx.ToXDR().Preconditions()); err != nil {
+		return nil, errors.New("failed to read transaction preconditions: " + err.Error())
+	}
+	signer, ok := s.accountSigner(source)
+	cosigners, cosignErr := s.extraSignerKeys(preconditions.ExtraSigners)
+	if !ok || cosignErr != nil {
+		s.unconfirmed.remove(hash)
+		go s.archiveTransaction(tx, hProtocol.Transaction{}, errors.New("transaction expired before it was applied"))
+		return &models.RecoverTransactionResponse{
+			Hash:    hash,
+			Status:  models.RecoveryExpired,
+			Message: "Transaction expired without being applied; build and sign a new one",
+		}, nil
+	}
+
+	// The expired transaction can no longer be applied, so a replacement cannot pay twice
+	preconditions.TimeBounds = txnbuild.NewTimeout(defaultTransactionTimeout)
+	s.Sequences.Resync(source)
+	signers := append([]*keypair.Full{signer}, cosigners...)
+	rebuilt, err := s.buildTransaction(source, txnbuild.TransactionParams{
+		Operations:    tx.Operations(),
+		BaseFee:       tx.BaseFee(),
+		Memo:          tx.Memo(),
+		Preconditions: preconditions,
+	}, signers...)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.submitTransaction(rebuilt, signers...)
+	if err != nil {
+		return nil, err
+	}
+	s.unconfirmed.remove(hash)
+	go s.archiveTransaction(tx, hProtocol.Transaction{}, errors.New("transaction expired before it was applied; replaced by "+resp.Hash))
+	s.Audit.Record("master", "transaction.recovered", hash, map[string]string{
+		"outcome":          models.RecoveryRebuilt,
+		"transaction_hash": resp.Hash,
+	})
+	return &models.RecoverTransactionResponse{
+		Hash:            hash,
+		Status:          models.RecoveryRebuilt,
+		TransactionHash: resp.Hash,
+		Ledger:          resp.Ledger,
+		Message:         "Transaction expired and was rebuilt with a new sequence number",
+	}, nil
+}
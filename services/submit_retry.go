@@ -0,0 +1,200 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// Submissions are retried with a doubling delay while Horizon is throttling, unavailable or timing out
+const (
+	maxSubmitAttempts = 4
+	submitRetryDelay  = 500 * time.Millisecond
+)
+
+// retryableSubmitStatuses are the Horizon responses after which the same envelope is submitted again
+var retryableSubmitStatuses = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// SubmitRetryStats counts submission retries since startup
+type SubmitRetryStats struct {
+	// Retries is the number of resubmissions of any kind
+	Retries int64
+	// Resequenced is the number of transactions rebuilt with a fresh sequence number after tx_bad_seq
+	Resequenced int64
+	// Exhausted is the number of submissions that still failed transiently after the last attempt
+	Exhausted int64
+}
+
+// submitRetries holds the counters behind SubmitRetryStats
+type submitRetries struct {
+	retries     atomic.Int64
+	resequenced atomic.Int64
+	exhausted   atomic.Int64
+}
+
+// SubmitRetryStats returns the submission retry counters
+func (s *WalletService) SubmitRetryStats() SubmitRetryStats {
+	return SubmitRetryStats{
+		Retries:     s.submitRetries.retries.Load(),
+		Resequenced: s.submitRetries.resequenced.Load(),
+		Exhausted:   s.submitRetries.exhausted.Load(),
+	}
+}
+
+// submittedTransaction is Horizon's result for a submission together with the transaction that was
+// finally accepted, which differs from the one passed in when it had to be rebuilt
+type submittedTransaction struct {
+	hProtocol.Transaction
+	Tx *txnbuild.Transaction
+	// Retries is the number of resubmissions before the final attempt
+	Retries int
+}
+
+// transientSubmitError reports whether err is a Horizon throttling, availability or timeout response, or
+// a failure to reach Horizon at all. The submission may still be applied, so only the same envelope is
+// resubmitted.
+func transientSubmitError(err error) bool {
+	herr, ok := err.(*horizonclient.Error)
+	if !ok {
+		return true
+	}
+	return retryableSubmitStatuses[herr.Response.StatusCode]
+}
+
+// outcomeUnknownError is a submission that failed without a definite answer from the network, so the
+// transaction may still be applied
+type outcomeUnknownError struct {
+	err error
+}
+
+// Error implements error
+func (e *outcomeUnknownError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the submission error
+func (e *outcomeUnknownError) Unwrap() error {
+	return e.err
+}
+
+// definitelyNotApplied reports whether a transaction whose flow failed with err is known not to be on the
+// ledger: it failed before it was submitted or the network rejected it. Only then may what it paid for be
+// retried as if it had never been sent.
+func definitelyNotApplied(err error) bool {
+	var unknown *outcomeUnknownError
+	return !errors.As(err, &unknown)
+}
+
+// failedBeforeSubmission reports whether a flow that failed with err never submitted its transaction, so
+// it may simply be run again
+func failedBeforeSubmission(err error) bool {
+	var txErr *TransactionError
+	return definitelyNotApplied(err) && !errors.As(err, &txErr)
+}
+
+// badSequence reports whether Horizon rejected a submission for its sequence number
+func badSequence(err error) bool {
+	herr, ok := err.(*horizonclient.Error)
+	if !ok {
+		return false
+	}
+	codes, err := herr.ResultCodes()
+	return err == nil && transactionCode(codes) == "tx_bad_seq"
+}
+
+// resequence rebuilds tx with the next sequence number of its source account and signs it again
+func (s *WalletService) resequence(tx *txnbuild.Transaction, signers []*keypair.Full) (*txnbuild.Transaction, error) {
+	sourceID := tx.SourceAccount().AccountID
+	var preconditions txnbuild.Preconditions
+	if err := preconditions.FromXDR(tx.ToXDR().Preconditions()); err != nil {
+		return nil, errors.New("failed to read transaction preconditions: " + err.Error())
+	}
+	s.Sequences.Resync(sourceID)
+	return s.buildTransaction(sourceID, txnbuild.TransactionParams{
+		Operations:    tx.Operations(),
+		BaseFee:       tx.BaseFee(),
+		Memo:          tx.Memo(),
+		Preconditions: preconditions,
+	}, signers...)
+}
+
+// submitWithRetry submits tx, resubmitting the same envelope after transient failures and, when signers
+// are given, rebuilding it with a refreshed sequence number after tx_bad_seq. Every envelope is persisted
+// before it is sent, and one that may already have been applied, by an earlier attempt or an earlier call
+// whose result was lost, is looked up by hash first so it is never applied twice. When feeAccount is set
+// every attempt is wrapped in a fee bump it pays for.
+func (s *WalletService) submitWithRetry(tx *txnbuild.Transaction, feeAccount *keypair.Full, signers []*keypair.Full) (submittedTransaction, error) {
+	result := submittedTransaction{Tx: tx}
+	delay := submitRetryDelay
+	newEnvelope := true
+	for attempt := 1; ; attempt++ {
+		if newEnvelope {
+			if applied, ok := s.appliedEarlier(result.Tx); ok {
+				result.Transaction = applied
+				return result, nil
+			}
+			s.persistSubmission(result.Tx)
+			newEnvelope = false
+		}
+		var resp hProtocol.Transaction
+		var err error
+		if feeAccount != nil {
+			feeBump, bumpErr := s.signedFeeBump(result.Tx, feeAccount, max(s.Fees.BaseFee(), result.Tx.BaseFee()))
+			if bumpErr != nil {
+				return result, bumpErr
+			}
+			resp, err = s.Config.HorizonClient.SubmitFeeBumpTransaction(feeBump)
+		} else {
+			resp, err = s.Config.HorizonClient.SubmitTransaction(result.Tx)
+		}
+		result.Transaction = resp
+		if err == nil {
+			return result, nil
+		}
+		if attempt == maxSubmitAttempts {
+			if transientSubmitError(err) || badSequence(err) {
+				s.submitRetries.exhausted.Add(1)
+			}
+			return result, err
+		}
+
+		switch {
+		case transientSubmitError(err):
+			log.Printf("transaction submission failed transiently (attempt %d): %v", attempt, err)
+			time.Sleep(delay)
+			delay *= 2
+		case badSequence(err):
+			// The sequence number may have been consumed by this very transaction, applied by an attempt
+			// whose response was lost
+			if applied, ok := s.appliedOnLedger(result.Tx); ok {
+				result.Transaction = applied
+				return result, nil
+			}
+			if len(signers) == 0 {
+				return result, err
+			}
+			rebuilt, rebuildErr := s.resequence(result.Tx, signers)
+			if rebuildErr != nil {
+				return result, errors.New("failed to rebuild transaction after tx_bad_seq: " + rebuildErr.Error())
+			}
+			s.submitRetries.resequenced.Add(1)
+			result.Tx = rebuilt
+			newEnvelope = true
+		default:
+			return result, err
+		}
+		result.Retries++
+		s.submitRetries.retries.Add(1)
+	}
+}
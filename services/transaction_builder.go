@@ -0,0 +1,185 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// BuildUnsignedTransaction builds a transaction from the given source account for a non-custodial client to
+// sign locally. The sequence number is the account's current one plus one, so building another transaction
+// from the same account before this one is submitted yields the same sequence number.
+func (s *WalletService) BuildUnsignedTransaction(req models.BuildTransactionRequest) (*models.BuildTransactionResponse, error) {
+	if _, err := keypair.ParseAddress(req.SourcePublicKey); err != nil {
+		return nil, errors.New("invalid source_public_key")
+	}
+	if len(req.Operations) == 0 || len(req.Operations) > maxOperationsPerTransaction {
+		return nil, errors.New("invalid operations: a transaction has between 1 and 100 operations")
+	}
+	preconditions, expiresAt, err := transactionPreconditions(req.TransactionPreconditions)
+	if err != nil {
+		return nil, err
+	}
+	baseFee := req.BaseFee
+	if baseFee == 0 {
+		baseFee = s.Fees.BaseFee()
+	}
+	if baseFee < txnbuild.MinBaseFee || baseFee > maxFeeBumpBaseFee {
+		return nil, errors.New("invalid base_fee: must be between 100 and 1000000 stroops")
+	}
+	memo, err := parseMemo(req.Memo, req.MemoType)
+	if err != nil {
+		return nil, err
+	}
+
+	ops := make([]txnbuild.Operation, 0, len(req.Operations))
+	for i, spec := range req.Operations {
+		op, err := s.buildOperation(spec)
+		if err != nil && strings.HasPrefix(err.Error(), "asset not permitted") {
+			return nil, err
+		} else if err != nil {
+			return nil, errors.New("invalid operations[" + strconv.Itoa(i) + "]: " + err.Error())
+		}
+		ops = append(ops, op)
+	}
+
+	account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: req.SourcePublicKey})
+	if err != nil {
+		if herr, ok := err.(*horizonclient.Error); ok && herr.Response.StatusCode == http.StatusNotFound {
+			return nil, errors.New("source account not found")
+		}
+		return nil, errors.New("failed to fetch source account details: " + err.Error())
+	}
+	tx, err := txnbuild.NewTransaction(
+		txnbuild.TransactionParams{
+			SourceAccount:        &account,
+			Operations:           ops,
+			BaseFee:              baseFee,
+			Memo:                 memo,
+			Preconditions:        preconditions,
+			IncrementSequenceNum: true,
+		},
+	)
+	if err != nil {
+		return nil, errors.New("invalid transaction: " + err.Error())
+	}
+	envelope, err := tx.Base64()
+	if err != nil {
+		return nil, errors.New("failed to encode transaction: " + err.Error())
+	}
+	hash, err := tx.HashHex(s.networkPassphrase())
+	if err != nil {
+		return nil, errors.New("failed to hash transaction: " + err.Error())
+	}
+
+	return &models.BuildTransactionResponse{
+		EnvelopeXDR:       envelope,
+		Hash:              hash,
+		NetworkPassphrase: s.networkPassphrase(),
+		SourcePublicKey:   req.SourcePublicKey,
+		Sequence:          tx.SequenceNumber(),
+		Operations:        len(ops),
+		BaseFee:           baseFee,
+		MaxFee:            amount.StringFromInt64(tx.MaxFee()),
+		ExpiresAt:         expiresAt,
+	}, nil
+}
+
+// buildOperation converts one requested operation, applying the asset policy to the assets it moves or trusts
+func (s *WalletService) buildOperation(spec models.TransactionOperation) (txnbuild.Operation, error) {
+	if spec.SourceAccount != "" {
+		if _, err := keypair.ParseAddress(spec.SourceAccount); err != nil {
+			return nil, errors.New("invalid source_account")
+		}
+	}
+	validAddress := func(field, address string) error {
+		if _, err := keypair.ParseAddress(address); err != nil {
+			return errors.New("invalid " + field)
+		}
+		return nil
+	}
+	validAmount := func(field, value string) error {
+		_, err := parseAmount(field, value)
+		return err
+	}
+	permittedAsset := func() (txnbuild.Asset, error) {
+		asset, err := parseAsset(spec.Asset)
+		if err != nil {
+			return nil, errors.New("invalid asset: " + err.Error())
+		}
+		if err := s.checkAssetPermitted(assetString(asset)); err != nil {
+			return nil, err
+		}
+		return asset, nil
+	}
+
+	switch spec.Type {
+	case models.OperationCreateAccount:
+		if err := validAddress("destination", spec.Destination); err != nil {
+			return nil, err
+		}
+		if err := validAmount("amount", spec.Amount); err != nil {
+			return nil, err
+		}
+		return &txnbuild.CreateAccount{Destination: spec.Destination, Amount: spec.Amount, SourceAccount: spec.SourceAccount}, nil
+
+	case models.OperationPayment:
+		if _, _, err := parseDestination(spec.Destination); err != nil {
+			return nil, errors.New("invalid destination")
+		}
+		if err := validAmount("amount", spec.Amount); err != nil {
+			return nil, err
+		}
+		asset, err := permittedAsset()
+		if err != nil {
+			return nil, err
+		}
+		return &txnbuild.Payment{Destination: spec.Destination, Amount: spec.Amount, Asset: asset, SourceAccount: spec.SourceAccount}, nil
+
+	case models.OperationChangeTrust:
+		asset, err := permittedAsset()
+		if err != nil {
+			return nil, err
+		}
+		if asset.IsNative() {
+			return nil, errors.New("invalid asset: native assets cannot be trusted")
+		}
+		if spec.Limit != "" && spec.Limit != "0" {
+			if err := validAmount("limit", spec.Limit); err != nil {
+				return nil, err
+			}
+		}
+		line, err := asset.ToChangeTrustAsset()
+		if err != nil {
+			return nil, errors.New("invalid asset: " + err.Error())
+		}
+		return &txnbuild.ChangeTrust{Line: line, Limit: spec.Limit, SourceAccount: spec.SourceAccount}, nil
+
+	case models.OperationAccountMerge:
+		if _, _, err := parseDestination(spec.Destination); err != nil {
+			return nil, errors.New("invalid destination")
+		}
+		return &txnbuild.AccountMerge{Destination: spec.Destination, SourceAccount: spec.SourceAccount}, nil
+
+	case models.OperationManageData:
+		if spec.Name == "" || len(spec.Name) > 64 {
+			return nil, errors.New("invalid name: must be 1 to 64 bytes")
+		}
+		if len(spec.Value) > 64 {
+			return nil, errors.New("invalid value: must be at most 64 bytes")
+		}
+		var value []byte
+		if spec.Value != "" {
+			value = []byte(spec.Value)
+		}
+		return &txnbuild.ManageData{Name: spec.Name, Value: value, SourceAccount: spec.SourceAccount}, nil
+	}
+	return nil, errors.New("invalid type: must be create_account, payment, change_trust, account_merge or manage_data")
+}
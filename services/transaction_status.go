@@ -0,0 +1,136 @@
+package services
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/txnbuild"
+)
+
+// operationCodeResults pairs result codes with their operation indexes
+func operationCodeResults(codes []string) []models.OperationResult {
+	results := make([]models.OperationResult, 0, len(codes))
+	for i, code := range codes {
+		results = append(results, models.OperationResult{Index: i, ResultCode: code})
+	}
+	return results
+}
+
+// GetTransactionStatus reports whether a transaction is pending, succeeded or failed, with its result codes
+// decoded. Horizon is consulted first; transactions it does not know are looked up in this service's record
+// of failed and timed-out submissions and then in the archive.
+func (s *WalletService) GetTransactionStatus(hash string) (*models.TransactionStatusResponse, error) {
+	hash = strings.ToLower(hash)
+	if _, err := hex.DecodeString(hash); err != nil || len(hash) != 64 {
+		return nil, errors.New("invalid transaction hash")
+	}
+
+	tx, err := s.Config.HorizonClient.TransactionDetail(hash)
+	if err == nil {
+		status := &models.TransactionStatusResponse{
+			Hash:            hash,
+			Status:          models.TransactionFailed,
+			Source:          "horizon",
+			SourcePublicKey: tx.Account,
+			Ledger:          tx.Ledger,
+			CreatedAt:       &tx.LedgerCloseTime,
+			FeeCharged:      amount.StringFromInt64(tx.FeeCharged),
+		}
+		if tx.Successful {
+			status.Status = models.TransactionSuccess
+		}
+		if tx.ResultXdr != "" {
+			txCode, opCodes, err := decodeResultCodes(tx.ResultXdr)
+			if err != nil {
+				return nil, err
+			}
+			status.ResultCode = txCode
+			status.Operations = operationCodeResults(opCodes)
+			if !tx.Successful {
+				status.Explanation = ExplainResultCodes(txCode, opCodes)
+			}
+		}
+		s.unconfirmed.remove(hash)
+		return status, nil
+	}
+	if herr, ok := err.(*horizonclient.Error); !ok || herr.Problem.Status != http.StatusNotFound {
+		return nil, errors.New("failed to fetch transaction: " + err.Error())
+	}
+
+	if entry, ok := s.unconfirmed.get(hash); ok {
+		status := localTransactionStatus(hash, entry.tx)
+		var txErr *TransactionError
+		if errors.As(entry.submitErr, &txErr) {
+			// Horizon rejected the submission outright, so the transaction never reached a ledger
+			status.Status = models.TransactionFailed
+			status.ResultCode = txErr.TransactionCode
+			status.Operations = operationCodeResults(txErr.OperationCodes)
+			status.Explanation = ExplainResultCodes(txErr.TransactionCode, txErr.OperationCodes)
+			status.ExpiresAt = nil
+		}
+		return status, nil
+	}
+	if s.Archive != nil {
+		record, err := s.GetArchivedTransaction(hash)
+		if err != nil && err != errArchiveNotFound {
+			return nil, err
+		}
+		if err == nil {
+			parsed, err := txnbuild.TransactionFromXDR(record.EnvelopeXDR)
+			if err != nil {
+				return nil, errors.New("failed to decode archived envelope: " + err.Error())
+			}
+			if archived, ok := parsed.Transaction(); ok {
+				status := localTransactionStatus(hash, archived)
+				if record.Successful {
+					status.Status = models.TransactionSuccess
+					status.Ledger = record.Ledger
+					status.ResultCode = "tx_success"
+					status.ExpiresAt = nil
+					return status, nil
+				}
+				var opCodes []string
+				for _, op := range record.Operations {
+					if op.ResultCode != "" {
+						opCodes = append(opCodes, op.ResultCode)
+						status.Operations = append(status.Operations, models.OperationResult{Index: op.Index, ResultCode: op.ResultCode})
+					}
+				}
+				if len(opCodes) > 0 {
+					status.Status = models.TransactionFailed
+					status.Explanation = ExplainResultCodes("", opCodes)
+					status.ExpiresAt = nil
+				}
+				return status, nil
+			}
+		}
+	}
+	return nil, errors.New("transaction not found")
+}
+
+// localTransactionStatus describes a submitted transaction Horizon has not included: pending until its time
+// bounds expire, after which the network rejects it as tx_too_late
+func localTransactionStatus(hash string, tx *txnbuild.Transaction) *models.TransactionStatusResponse {
+	status := &models.TransactionStatusResponse{
+		Hash:            hash,
+		Status:          models.TransactionPending,
+		Source:          "local",
+		SourcePublicKey: tx.SourceAccount().AccountID,
+	}
+	if maxTime := tx.Timebounds().MaxTime; maxTime != 0 {
+		expiresAt := time.Unix(maxTime, 0).UTC()
+		status.ExpiresAt = &expiresAt
+		if time.Now().After(expiresAt) {
+			status.Status = models.TransactionFailed
+			status.ResultCode = "tx_too_late"
+			status.Explanation = ExplainResultCodes(status.ResultCode, nil)
+		}
+	}
+	return status
+}
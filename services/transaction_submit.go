@@ -0,0 +1,96 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+)
+
+// SubmitSignedTransaction submits a transaction built and signed by the client. Before anything reaches
+// Horizon the envelope must be signed for this service's network by one of the source account's ed25519
+// signers, offer a base fee within bounds, and carry time bounds that are currently valid.
+func (s *WalletService) SubmitSignedTransaction(req models.SubmitTransactionRequest) (*models.SubmitTransactionResponse, error) {
+	parsed, err := txnbuild.TransactionFromXDR(req.EnvelopeXDR)
+	if err != nil {
+		return nil, errors.New("invalid envelope_xdr: " + err.Error())
+	}
+	tx, ok := parsed.Transaction()
+	if !ok {
+		return nil, errors.New("invalid envelope: fee bump transactions are not accepted")
+	}
+	if len(tx.Signatures()) == 0 {
+		return nil, errors.New("invalid envelope: transaction is not signed")
+	}
+
+	if fee := tx.BaseFee(); fee < txnbuild.MinBaseFee || fee > maxFeeBumpBaseFee {
+		return nil, errors.New("invalid envelope: base fee must be between 100 and 1000000 stroops, got " + strconv.FormatInt(fee, 10))
+	}
+	now := time.Now().Unix()
+	bounds := tx.Timebounds()
+	switch {
+	case bounds.MaxTime == 0:
+		return nil, errors.New("invalid envelope: transaction must expire (set a max time)")
+	case bounds.MaxTime <= now:
+		return nil, errors.New("invalid envelope: transaction has expired")
+	case bounds.MinTime > now:
+		return nil, errors.New("invalid envelope: transaction is not valid before " + time.Unix(bounds.MinTime, 0).UTC().Format(time.RFC3339))
+	}
+
+	source, _, err := parseDestination(tx.SourceAccount().AccountID)
+	if err != nil {
+		return nil, errors.New("invalid envelope: unreadable source account")
+	}
+	if err := s.checkNotFrozen(source); err != nil {
+		return nil, err
+	}
+	account, err := s.Config.HorizonClient.AccountDetail(horizonclient.AccountRequest{AccountID: source})
+	if err != nil {
+		if herr, ok := err.(*horizonclient.Error); ok && herr.Response.StatusCode == http.StatusNotFound {
+			return nil, errors.New("source account not found")
+		}
+		return nil, errors.New("failed to fetch source account details: " + err.Error())
+	}
+	hash, err := tx.Hash(s.networkPassphrase())
+	if err != nil {
+		return nil, errors.New("failed to hash transaction: " + err.Error())
+	}
+	signed := false
+	for _, signer := range account.Signers {
+		if signer.Type != "ed25519_public_key" || signer.Weight == 0 {
+			continue
+		}
+		kp, err := keypair.ParseAddress(signer.Key)
+		if err != nil {
+			continue
+		}
+		for _, sig := range tx.Signatures() {
+			if sig.Hint == kp.Hint() && kp.Verify(hash[:], sig.Signature) == nil {
+				signed = true
+			}
+		}
+	}
+	if !signed {
+		return nil, errors.New("invalid envelope: no signature by the source account is valid for the " + s.Config.Network + " network")
+	}
+
+	resp, err := s.submitTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+	return &models.SubmitTransactionResponse{
+		Status:            models.TransferCompleted,
+		TransactionHash:   resp.Hash,
+		SourcePublicKey:   source,
+		Sequence:          tx.SequenceNumber(),
+		Ledger:            resp.Ledger,
+		FeeCharged:        amount.StringFromInt64(resp.FeeCharged),
+		SubmissionRetries: resp.Retries,
+	}, nil
+}
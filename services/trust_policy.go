@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/keypair"
+)
+
+// trustPolicies stores per-wallet automatic trustline policies
+type trustPolicies struct {
+	mu       sync.RWMutex
+	policies map[string]models.TrustPolicyRequest
+}
+
+// GetTrustPolicy returns a wallet's automatic trustline policy, falling back to the service-wide allowlist
+func (s *WalletService) GetTrustPolicy(publicKey string) (*models.TrustPolicyResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+
+	s.trustPolicies.mu.RLock()
+	policy, ok := s.trustPolicies.policies[publicKey]
+	s.trustPolicies.mu.RUnlock()
+	if !ok {
+		return &models.TrustPolicyResponse{
+			PublicKey: publicKey,
+			Mode:      models.TrustPolicyAllowlist,
+			Assets:    append([]string{}, s.Config.ClaimableAssetAllowlist...),
+			Default:   true,
+		}, nil
+	}
+	return &models.TrustPolicyResponse{
+		PublicKey: publicKey,
+		Mode:      policy.Mode,
+		Assets:    append([]string{}, policy.Assets...),
+	}, nil
+}
+
+// SetTrustPolicy replaces a wallet's automatic trustline policy
+func (s *WalletService) SetTrustPolicy(publicKey string, req models.TrustPolicyRequest) (*models.TrustPolicyResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	switch req.Mode {
+	case models.TrustPolicyAllowlist, models.TrustPolicyAll, models.TrustPolicyNone:
+	default:
+		return nil, errors.New("invalid trust policy mode")
+	}
+	for _, asset := range req.Assets {
+		if parsed, err := parseAsset(asset); err != nil || parsed.IsNative() {
+			return nil, errors.New("invalid asset in trust policy: " + asset)
+		}
+		if err := s.checkAssetPermitted(asset); err != nil {
+			return nil, err
+		}
+	}
+
+	s.trustPolicies.mu.Lock()
+	s.trustPolicies.policies[publicKey] = models.TrustPolicyRequest{Mode: req.Mode, Assets: append([]string{}, req.Assets...)}
+	s.trustPolicies.mu.Unlock()
+	s.Registry.RecordUpdate(publicKey, "trust_policy_updated")
+
+	return s.GetTrustPolicy(publicKey)
+}
+
+// autoTrustAllowed reports whether the service may add a trustline for asset to a wallet on its own
+func (s *WalletService) autoTrustAllowed(publicKey, asset string) bool {
+	if !s.assetPermitted(asset) {
+		return false
+	}
+	policy, err := s.GetTrustPolicy(publicKey)
+	if err != nil {
+		return false
+	}
+	switch policy.Mode {
+	case models.TrustPolicyAll:
+		return true
+	case models.TrustPolicyAllowlist:
+		for _, allowed := range policy.Assets {
+			if allowed == asset {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -2,36 +2,364 @@ package services
 
 import (
 	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/federation"
 	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/clients/stellartoml"
 	"github.com/stellar/go/keypair"
 	"github.com/stellar/go/network"
 	"github.com/stellar/go/txnbuild"
 )
 
+// walletUSDCGrant is the USDC amount every new wallet is funded with
+const walletUSDCGrant = "100"
+
+// walletStartingBalance is the XLM the master account sends to create each wallet
+const walletStartingBalance = "0.5"
+
+// walletSponsoredStartingBalance is the XLM a wallet with sponsored reserves is created with; it only
+// has to pay the wallet's own transaction fees
+const walletSponsoredStartingBalance = "0.1"
+
 // Config holds application configuration
 type Config struct {
 	Network       string
 	MasterSecret  string
 	HorizonClient *horizonclient.Client
 	USDCAsset     txnbuild.CreditAsset
+
+	// NotificationChannels lists the notification channels enabled for this deployment
+	NotificationChannels []string
+	// TenantNotificationDefaults maps tenant ID to the default channel for each event type
+	TenantNotificationDefaults map[string]map[string]string
+
+	// ClaimableSweepInterval controls how often managed wallets are swept for claimable balances; zero disables sweeping
+	ClaimableSweepInterval time.Duration
+	// ClaimableAssetAllowlist lists CODE:ISSUER assets trusted automatically for wallets without their own trust policy
+	ClaimableAssetAllowlist []string
+	// AutoTrustIncoming lets the claimable balance watcher add trustlines for incoming assets the wallet's
+	// trust policy allows; when false only balances of already-trusted assets are claimed
+	AutoTrustIncoming bool
+
+	// PaymentWatchInterval controls how often managed wallets are polled for incoming payments to route and
+	// invoices to settle; zero disables the payment watcher
+	PaymentWatchInterval time.Duration
+
+	// RecurringChargeInterval controls how often recurring payment plans are checked for due charges
+	RecurringChargeInterval time.Duration
+
+	// JobWorkers is how many asynchronous wallet creations and transfers are submitted in parallel
+	JobWorkers int
+
+	// SLOLatencyThreshold, SLOTarget and SLOWindow define the ledger inclusion latency objective,
+	// e.g. 95% of transactions included within 10s over the last hour
+	SLOLatencyThreshold time.Duration
+	SLOTarget           float64
+	SLOWindow           time.Duration
+
+	// TenantRefundPolicies maps tenant ID to its refund policy; tenants without one use DefaultRefundPolicy
+	TenantRefundPolicies map[string]RefundPolicy
+
+	// SandboxEnabled provisions the developer sandbox tenant; it requires the testnet network
+	SandboxEnabled bool
+	// SandboxWalletCount is how many friendbot-funded wallets the sandbox provides after each reset
+	SandboxWalletCount int
+
+	// FraudScoreThreshold is the fraud score above which transfers are held for manual review
+	FraudScoreThreshold float64
+
+	// InternalSettlementTenants lists tenants whose wallet-to-wallet transfers settle on an internal ledger,
+	// netted on-chain every NetSettlementInterval
+	InternalSettlementTenants []string
+	NetSettlementInterval     time.Duration
+
+	// FeePercentile is the percentile of recently charged fees offered on new transactions, capped at
+	// MaxBaseFee stroops per operation; fee stats are polled every FeeStatsInterval
+	FeePercentile    int
+	MaxBaseFee       int64
+	FeeStatsInterval time.Duration
+
+	// AssetAllowlist, when non-empty, limits transfers and trustlines to the listed CODE:ISSUER assets;
+	// AssetBlocklist forbids the listed assets outright, e.g. scam tokens imitating USDC
+	AssetAllowlist []string
+	AssetBlocklist []string
+
+	// MinTransferAmounts maps an asset ("native" or CODE:ISSUER) to the smallest amount a transfer may deliver
+	MinTransferAmounts map[string]string
+
+	// SpendLimits maps an asset to the per-transaction, daily and monthly limits of every wallet without an
+	// admin override
+	SpendLimits map[string]models.SpendLimit
+
+	// AuditSigningSecret is the dedicated Stellar secret key that signs audit log exports; exports are
+	// refused without it
+	AuditSigningSecret string
+
+	// AdminAPIKey authenticates requests to the admin API; the admin API is disabled when empty
+	AdminAPIKey string
+
+	// TenantAPIKeys maps each tenant to the API key its requests present as a bearer token. When set, every
+	// tenant request must authenticate, and only authenticated tenants can have the service sign with the
+	// custodied keys of their wallets; when empty, such operations require the wallet's secret key.
+	TenantAPIKeys map[string]string
+
+	// SponsorWalletReserves makes the master account sponsor the account and trustline reserves of new
+	// wallets instead of sending them the XLM to hold the reserves themselves
+	SponsorWalletReserves bool
+
+	// FriendbotFunding funds every new wallet's XLM from friendbot instead of the master account; it
+	// requires the testnet network
+	FriendbotFunding bool
+
+	// WalletHomeDomain is the home domain set on new wallets whose create request names none, pointing
+	// them at the operator's stellar.toml
+	WalletHomeDomain string
+
+	// FeeSponsorshipPolicy is off, auto or always: whether the master account pays the network fees of
+	// custodied wallets' transfers through fee bumps, when they hold no XLM for them or on every transfer.
+	// FeeSponsorshipDailyBudget caps the XLM sponsored per wallet per UTC day; empty means no cap.
+	FeeSponsorshipPolicy      string
+	FeeSponsorshipDailyBudget string
+
+	// CallbackSecret signs the status callbacks posted to a transfer's callback_url; callbacks are
+	// disabled when empty. While PreviousCallbackSecret is set, callbacks are signed with both, so
+	// receivers can switch secrets without rejecting any.
+	CallbackSecret         string
+	PreviousCallbackSecret string
+
+	// DepositIngestInterval controls how often managed wallets are polled for incoming deposits to record;
+	// zero disables deposit ingestion. TenantDepositWebhooks maps tenant ID to the URL each new deposit is
+	// posted to, signed with CallbackSecret.
+	DepositIngestInterval time.Duration
+	TenantDepositWebhooks map[string]string
+
+	// BalanceSnapshotInterval controls how often the balances of managed wallets are snapshotted into their
+	// balance history; zero disables snapshots
+	BalanceSnapshotInterval time.Duration
+
+	// HistoryIngestInterval controls how often the history ingester follows the network into the local
+	// history store
+	HistoryIngestInterval time.Duration
+
+	// AnchorPollInterval controls how often unfinished anchor deposits and withdrawals, and the status of
+	// KYC customers anchors are processing, are refreshed from their anchors; zero disables tracking
+	AnchorPollInterval time.Duration
+
+	// WebhookMaxAttempts is how many times a webhook delivery is attempted before it is dead-lettered;
+	// zero uses the callback default of five
+	WebhookMaxAttempts int
 }
 
+// WalletAPI is the wallet lifecycle and transfer surface of WalletService, for callers that want to
+// substitute a fake such as testsupport.FakeWalletService
+type WalletAPI interface {
+	CreateWallet(tenantID string, req models.CreateWalletRequest) (*models.WalletResponse, error)
+	GetWalletDetails(publicKey string) (*models.WalletDetailsResponse, error)
+	TransferFunds(req models.TransferRequest) (*models.TransferResponse, error)
+}
+
+var _ WalletAPI = (*WalletService)(nil)
+
 // WalletService provides methods for wallet operations
 type WalletService struct {
-	Config Config
+	Config   Config
+	Registry *WalletRegistry
+	Events   *EventBus
+	SLO      *LatencySLO
+	Internal *InternalLedger
+	Audit    *AuditLog
+
+	// Submissions alerts on spikes in failed transaction submissions
+	Submissions *SubmissionMonitor
+	// MasterBalance, when set, watches the master account's funding balances
+	MasterBalance *MasterBalanceMonitor
+	// Outbox, when set, stages the events of deposits and transfers so they are relayed exactly when the
+	// state change is recorded
+	Outbox *Outbox
+
+	// Archive, when set, retains every submitted envelope, result and receipt
+	Archive ArchiveStore
+
+	// FraudScorer, when set, scores every transfer before signing
+	FraudScorer FraudScorer
+	// PriceSource, when set, values wallet portfolios in fiat currencies
+	PriceSource PriceSource
+	// History, when set, serves payment history and operations of backfilled wallets from Postgres
+	History *HistoryStore
+	// Federation, when set, serves federation names of managed wallets and resolves them without a lookup;
+	// FederationClient resolves the federation addresses of other domains
+	Federation       *FederationService
+	FederationClient federation.ClientInterface
+
+	reviews       transferReviews
+	trustPolicies trustPolicies
+	deactivations deactivations
+	unconfirmed   unconfirmedTransactions
+	spendLimits   spendLimits
+	// feeSponsorships tracks the fees the master account paid for each wallet
+	feeSponsorships feeSponsorships
+	// externalTransfers deduplicates transfers by their client external_id
+	externalTransfers externalTransfers
+	// freezes holds the wallets the service must not sign for
+	freezes walletFreezes
+	// balanceSnapshots holds the balance history of managed wallets
+	balanceSnapshots walletBalanceSnapshots
+
+	// Sequences allocates the sequence numbers of every transaction this service submits
+	Sequences     *SequenceManager
+	Fees          *FeeStrategy
+	submitRetries submitRetries
+	// Channels, when set, supplies channel accounts as the sources of master-account transactions
+	Channels *ChannelPool
 }
 
 // NewWalletService creates a new WalletService instance
 func NewWalletService(config Config) *WalletService {
-	return &WalletService{Config: config}
+	return &WalletService{
+		Config:      config,
+		Registry:    NewWalletRegistry(),
+		Events:      NewEventBus(),
+		SLO:         NewLatencySLO(config.SLOLatencyThreshold, config.SLOTarget, config.SLOWindow, LogAlerter{}),
+		Submissions: NewSubmissionMonitor(0, 0, LogAlerter{}),
+		Internal:    NewInternalLedger(),
+		Audit:       NewAuditLog(),
+		Sequences:   NewSequenceManager(config.HorizonClient),
+		Fees:        NewFeeStrategy(config.HorizonClient, config.FeePercentile, config.MaxBaseFee, config.FeeStatsInterval),
+		FederationClient: &federation.Client{
+			HTTP:        &http.Client{Timeout: 10 * time.Second},
+			Horizon:     config.HorizonClient,
+			StellarTOML: stellartoml.DefaultClient,
+		},
+		reviews:       transferReviews{pending: make(map[string]*heldTransfer)},
+		trustPolicies: trustPolicies{policies: make(map[string]models.TrustPolicyRequest)},
+		deactivations: deactivations{
+			quarantines: make(map[string]*keypair.Full),
+			records:     make(map[string]*models.WalletDeactivationResponse),
+		},
+		unconfirmed: unconfirmedTransactions{txs: make(map[string]*unconfirmedTransaction)},
+		spendLimits: spendLimits{
+			overrides: make(map[string]map[string]models.SpendLimit),
+			spent:     make(map[string][]*spendEntry),
+			loaded:    make(map[string]bool),
+		},
+		externalTransfers: externalTransfers{records: make(map[string]map[string]externalTransfer)},
+		feeSponsorships:   feeSponsorships{charges: make(map[string][]*feeCharge)},
+		freezes:           walletFreezes{records: make(map[string]*models.WalletFreezeResponse)},
+		balanceSnapshots:  walletBalanceSnapshots{histories: make(map[string][]models.BalanceSnapshot)},
+	}
+}
+
+// networkPassphrase returns the passphrase of the configured Stellar network
+func (s *WalletService) networkPassphrase() string {
+	if s.Config.Network == "testnet" {
+		return network.TestNetworkPassphrase
+	}
+	return network.PublicNetworkPassphrase
+}
+
+// submitTransaction submits a signed transaction to Horizon, retrying transient failures, records its ledger
+// inclusion latency and archives it. signers, when given, let a transaction rejected with tx_bad_seq be
+// rebuilt with a fresh sequence number.
+func (s *WalletService) submitTransaction(tx *txnbuild.Transaction, signers ...*keypair.Full) (submittedTransaction, error) {
+	return s.submitFeeBumped(tx, nil, signers)
+}
+
+// submitFeeBumped is submitTransaction with the fee paid by feeAccount, when set, through a fee bump
+func (s *WalletService) submitFeeBumped(tx *txnbuild.Transaction, feeAccount *keypair.Full, signers []*keypair.Full) (submittedTransaction, error) {
+	start := time.Now()
+	resp, err := s.submitWithRetry(tx, feeAccount, signers)
+	if err != nil {
+		s.Sequences.Resync(resp.Tx.SourceAccount().AccountID)
+		if herr, ok := err.(*horizonclient.Error); ok && !transientSubmitError(err) {
+			err = newTransactionError(herr)
+		} else if ok {
+			err = &outcomeUnknownError{errors.New("failed to submit transaction: horizon responded " + strconv.Itoa(herr.Response.StatusCode) + " " + herr.Problem.Title)}
+		} else {
+			err = &outcomeUnknownError{errors.New("failed to submit transaction: " + err.Error())}
+		}
+		s.rememberUnconfirmed(resp.Tx, err)
+		s.Submissions.Record(err)
+		go s.archiveTransaction(resp.Tx, resp.Transaction, err)
+		s.publishTransactionStatus(resp, signers, err)
+		return resp, err
+	}
+	s.SLO.Record(resp.Hash, time.Since(start))
+	s.Submissions.Record(nil)
+	s.unconfirmed.remove(resp.Hash)
+	go s.archiveTransaction(resp.Tx, resp.Transaction, nil)
+	s.publishTransactionStatus(resp, signers, nil)
+	return resp, nil
 }
 
-// CreateWallet creates a new Stellar wallet and funds it with USDC
-func (s *WalletService) CreateWallet() (*models.WalletResponse, error) {
+// publishTransactionStatus publishes whether a submitted transaction was confirmed or failed to every
+// managed wallet that is its source or signed it
+func (s *WalletService) publishTransactionStatus(resp submittedTransaction, signers []*keypair.Full, err error) {
+	eventType, data := models.EventTransactionConfirmed, map[string]string{"transaction_hash": resp.Hash}
+	if err != nil {
+		eventType, data = models.EventTransactionFailed, map[string]string{"error": err.Error()}
+		if hash, hashErr := resp.Tx.HashHex(s.networkPassphrase()); hashErr == nil {
+			data["transaction_hash"] = hash
+		}
+	}
+	wallets := []string{resp.Tx.SourceAccount().AccountID}
+	for _, signer := range signers {
+		wallets = append(wallets, signer.Address())
+	}
+	published := map[string]bool{}
+	for _, publicKey := range wallets {
+		if _, managed := s.Registry.Get(publicKey); managed && !published[publicKey] {
+			published[publicKey] = true
+			s.Events.Publish(eventType, publicKey, data)
+		}
+	}
+}
+
+// CreateWallet creates a new Stellar wallet and, depending on the request's profile, trusts and funds it
+// with USDC
+func (s *WalletService) CreateWallet(tenantID string, req models.CreateWalletRequest) (*models.WalletResponse, error) {
+	profile, trust, fund, err := walletProfile(req.Profile)
+	if err != nil {
+		return nil, err
+	}
+	usdcKey := s.Config.USDCAsset.Code + ":" + s.Config.USDCAsset.Issuer
+	if len(req.TrustlineLimits) > 0 && !trust {
+		return nil, errors.New("invalid profile: " + profile + " adds no trustlines to limit")
+	}
+	for asset, limit := range req.TrustlineLimits {
+		if asset != usdcKey {
+			return nil, errors.New("trustline limit for untrusted asset: " + asset)
+		}
+		limitStroops, err := parseAmount("trustline limit for "+asset, limit)
+		if err != nil {
+			return nil, err
+		}
+		if fund && limitStroops < int64(amount.MustParse(walletUSDCGrant)) {
+			return nil, errors.New("trustline limit for " + asset + " is below the initial grant of " + walletUSDCGrant)
+		}
+	}
+
+	friendbot := req.Friendbot || s.Config.FriendbotFunding
+	if friendbot && s.Config.Network != "testnet" {
+		return nil, errors.New("invalid friendbot: funding from friendbot is only available on testnet")
+	}
+	homeDomain := req.HomeDomain
+	if homeDomain == "" {
+		homeDomain = s.Config.WalletHomeDomain
+	}
+	if len(homeDomain) > maxHomeDomainLength {
+		return nil, errors.New("invalid home_domain: must be at most 32 bytes")
+	}
+	labels, err := validateWalletMetadata(req.Labels, req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
 	kp, err := keypair.Random()
 	if err != nil {
 		return nil, errors.New("failed to generate keypair: " + err.Error())
@@ -46,8 +374,9 @@ func (s *WalletService) CreateWallet() (*models.WalletResponse, error) {
 	}
 
 	createAccountOp := txnbuild.CreateAccount{
-		Destination: publicKey,
-		Amount:      "0.5",
+		Destination:   publicKey,
+		Amount:        s.walletStartingBalance(),
+		SourceAccount: masterKP.Address(),
 	}
 
 	usdcChangeTrustAsset, err := s.Config.USDCAsset.ToChangeTrustAsset()
@@ -55,68 +384,149 @@ func (s *WalletService) CreateWallet() (*models.WalletResponse, error) {
 		return nil, errors.New("failed to create USDC trustline asset: " + err.Error())
 	}
 	trustOp := txnbuild.ChangeTrust{
-		Line: usdcChangeTrustAsset,
+		Line:          usdcChangeTrustAsset,
+		Limit:         req.TrustlineLimits[usdcKey],
+		SourceAccount: publicKey,
 	}
 
 	paymentOp := txnbuild.Payment{
-		Destination: publicKey,
-		Amount:      "100",
-		Asset:       s.Config.USDCAsset,
-	}
-
-	accountRequest := horizonclient.AccountRequest{AccountID: masterKP.Address()}
-	sourceAccount, err := s.Config.HorizonClient.AccountDetail(accountRequest)
-	if err != nil {
-		return nil, errors.New("failed to fetch master account details: " + err.Error())
-	}
-
-	tx, err := txnbuild.NewTransaction(
-		txnbuild.TransactionParams{
-			SourceAccount:        &sourceAccount,
-			Operations:           []txnbuild.Operation{&createAccountOp, &trustOp, &paymentOp},
-			BaseFee:              txnbuild.MinBaseFee,
-			Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
-			IncrementSequenceNum: true,
-		},
-	)
-	if err != nil {
-		return nil, errors.New("failed to build transaction: " + err.Error())
-	}
-
-	var networkPassphrase string
-	if s.Config.Network == "testnet" {
-		networkPassphrase = network.TestNetworkPassphrase
-	} else {
-		networkPassphrase = network.PublicNetworkPassphrase
+		Destination:   publicKey,
+		Amount:        walletUSDCGrant,
+		Asset:         s.Config.USDCAsset,
+		SourceAccount: masterKP.Address(),
 	}
 
 	masterFullKP, ok := masterKP.(*keypair.Full)
 	if !ok {
 		return nil, errors.New("master key is not a full keypair")
 	}
-	tx, err = tx.Sign(networkPassphrase, masterFullKP, kp)
-	if err != nil {
-		return nil, errors.New("failed to sign transaction: " + err.Error())
+	var ops []txnbuild.Operation
+	sponsored := s.Config.SponsorWalletReserves && !friendbot
+	switch {
+	case friendbot:
+		// Friendbot creates the account with enough XLM for its own reserves
+		funded, err := s.Config.HorizonClient.Fund(publicKey)
+		if err != nil {
+			return nil, errors.New("failed to fund wallet via friendbot: " + err.Error())
+		}
+		if !trust && homeDomain == "" {
+			s.Registry.Add(tenantID, kp)
+			s.Registry.SetMetadata(publicKey, labels, req.Metadata)
+			s.Audit.Record("tenant:"+tenantID, "wallet.created", publicKey, map[string]string{"transaction_hash": funded.Hash})
+			s.Events.Publish(models.EventWalletCreated, publicKey, map[string]string{"tenant_id": tenantID, "transaction_hash": funded.Hash})
+			return &models.WalletResponse{
+				PublicKey:       publicKey,
+				SecretKey:       secretKey,
+				Message:         "Wallet created successfully. Hash: " + funded.Hash,
+				FriendbotFunded: true,
+				Profile:         profile,
+				Labels:          labels,
+				Metadata:        req.Metadata,
+			}, nil
+		}
+	case sponsored:
+		// The master account pays the reserves of every entry the wallet creates between the two
+		ops = append(ops, &txnbuild.BeginSponsoringFutureReserves{SponsoredID: publicKey, SourceAccount: masterKP.Address()}, &createAccountOp)
+	default:
+		ops = append(ops, &createAccountOp)
 	}
-
-	resp, err := s.Config.HorizonClient.SubmitTransaction(tx)
+	if homeDomain != "" {
+		ops = append(ops, &txnbuild.SetOptions{HomeDomain: &homeDomain, SourceAccount: publicKey})
+	}
+	if trust {
+		ops = append(ops, &trustOp)
+	}
+	if sponsored {
+		ops = append(ops, &txnbuild.EndSponsoringFutureReserves{SourceAccount: publicKey})
+	}
+	if fund {
+		ops = append(ops, &paymentOp)
+	}
+	// The wallet signs only when it is the source of an operation; an unneeded signature fails the transaction
+	signers := []*keypair.Full{masterFullKP}
+	if trust || sponsored || homeDomain != "" {
+		signers = append(signers, kp)
+	}
+	resp, err := s.submitMasterOperations(masterFullKP, ops, signers...)
 	if err != nil {
-		if herr, ok := err.(*horizonclient.Error); ok {
-			return nil, errors.New("transaction failed: " + herr.Problem.Detail)
-		}
-		return nil, errors.New("failed to submit transaction: " + err.Error())
+		return nil, err
 	}
 
+	s.Registry.Add(tenantID, kp)
+	s.Registry.SetMetadata(publicKey, labels, req.Metadata)
+	s.Audit.Record("tenant:"+tenantID, "wallet.created", publicKey, map[string]string{"transaction_hash": resp.Hash})
+	s.Events.Publish(models.EventWalletCreated, publicKey, map[string]string{"tenant_id": tenantID, "transaction_hash": resp.Hash})
+
+	message := "Wallet created successfully. Hash: "
+	switch {
+	case fund:
+		message = "Wallet created, trusted USDC, and funded successfully. Hash: "
+	case trust:
+		message = "Wallet created and trusted USDC successfully. Hash: "
+	}
 	return &models.WalletResponse{
-		PublicKey: publicKey,
-		SecretKey: secretKey,
-		Message:   "Wallet created, trusted USDC, and funded successfully. Hash: " + resp.Hash,
+		PublicKey:       publicKey,
+		SecretKey:       secretKey,
+		Sponsored:       sponsored,
+		Message:         message + resp.Hash,
+		FriendbotFunded: friendbot,
+		Profile:         profile,
+		HomeDomain:      homeDomain,
+		Labels:          labels,
+		Metadata:        req.Metadata,
 	}, nil
 }
 
+// walletProfile validates a creation profile, defaulting to create_trust_fund, and reports whether it adds
+// the USDC trustline and whether it pays the USDC grant
+func walletProfile(profile string) (string, bool, bool, error) {
+	switch profile {
+	case models.WalletProfileCreate:
+		return profile, false, false, nil
+	case models.WalletProfileCreateTrust:
+		return profile, true, false, nil
+	case "", models.WalletProfileCreateTrustFund:
+		return models.WalletProfileCreateTrustFund, true, true, nil
+	}
+	return "", false, false, errors.New("invalid profile: must be create, create_trust or create_trust_fund")
+}
+
+// walletStartingBalance returns the XLM a new wallet is created with
+func (s *WalletService) walletStartingBalance() string {
+	if s.Config.SponsorWalletReserves {
+		return walletSponsoredStartingBalance
+	}
+	return walletStartingBalance
+}
+
 // GetWalletDetails retrieves details of a Stellar wallet
 func (s *WalletService) GetWalletDetails(publicKey string) (*models.WalletDetailsResponse, error) {
-	if _, err := keypair.ParseAddress(publicKey); err != nil {
+	return s.WalletDetails(publicKey, models.WalletDetailsOptions{})
+}
+
+// WalletDetails retrieves details of a Stellar wallet, including the optional parts opts selects
+func (s *WalletService) WalletDetails(publicKey string, opts models.WalletDetailsOptions) (*models.WalletDetailsResponse, error) {
+	details, err := s.accountDetails(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	claimable, err := s.claimableBalances(details.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	details.PendingClaimableBalances = len(claimable)
+	if opts.IncludeClaimableBalances {
+		details.ClaimableBalances = claimable
+	}
+	return details, nil
+}
+
+// accountDetails returns the account part of a wallet's details
+func (s *WalletService) accountDetails(publicKey string) (*models.WalletDetailsResponse, error) {
+	// A muxed address shares the balances of its underlying account
+	address := publicKey
+	publicKey, muxedID, err := parseDestination(address)
+	if err != nil {
 		return nil, errors.New("invalid public key format")
 	}
 
@@ -125,110 +535,431 @@ func (s *WalletService) GetWalletDetails(publicKey string) (*models.WalletDetail
 	if err != nil {
 		if herr, ok := err.(*horizonclient.Error); ok && herr.Response.StatusCode == http.StatusNotFound {
 			return &models.WalletDetailsResponse{
-				PublicKey: publicKey,
-				Exists:    false,
-				Balances: []struct {
-					AssetType string `json:"asset_type"`
-					AssetCode string `json:"asset_code,omitempty"`
-					Issuer    string `json:"issuer,omitempty"`
-					Balance   string `json:"balance"`
-				}{},
+				PublicKey:      publicKey,
+				MuxedAddress:   muxedAddress(address, muxedID),
+				MuxedID:        muxedID,
+				Exists:         false,
+				Balances:       []models.Balance{},
 				SequenceNumber: 0,
 			}, nil
 		}
 		return nil, errors.New("failed to fetch wallet details: " + err.Error())
 	}
 
-	var balances []struct {
-		AssetType string `json:"asset_type"`
-		AssetCode string `json:"asset_code,omitempty"`
-		Issuer    string `json:"issuer,omitempty"`
-		Balance   string `json:"balance"`
+	baseReserve, err := s.baseReserveStroops()
+	if err != nil {
+		return nil, err
 	}
+
+	var balances []models.Balance
 	for _, balance := range account.Balances {
-		balances = append(balances, struct {
-			AssetType string `json:"asset_type"`
-			AssetCode string `json:"asset_code,omitempty"`
-			Issuer    string `json:"issuer,omitempty"`
-			Balance   string `json:"balance"`
-		}{
-			AssetType: balance.Type,
-			AssetCode: balance.Code,
-			Issuer:    balance.Issuer,
-			Balance:   balance.Balance,
+		var pool *models.LiquidityPoolShare
+		if balance.Type == "liquidity_pool_shares" {
+			if pool, err = s.liquidityPoolShare(balance.LiquidityPoolId, balance.Balance); err != nil {
+				return nil, err
+			}
+		}
+		available, pending := availableBalance(balance, account, baseReserve), ""
+		if tenantID, ok := s.Registry.TenantOf(publicKey); ok && balance.Type != "liquidity_pool_shares" {
+			asset := "native"
+			if balance.Type != "native" {
+				asset = balance.Code + ":" + balance.Issuer
+			}
+			position, err := s.internalPosition(tenantID, publicKey, asset)
+			if err != nil {
+				return nil, err
+			}
+			if position != 0 {
+				pending = amount.StringFromInt64(position)
+				if availableStroops, err := amount.ParseInt64(available); err == nil {
+					available = amount.StringFromInt64(max(availableStroops+position, 0))
+				}
+			}
+		}
+		balances = append(balances, models.Balance{
+			AssetType:          balance.Type,
+			AssetCode:          balance.Code,
+			Issuer:             balance.Issuer,
+			Balance:            balance.Balance,
+			Limit:              balance.Limit,
+			Available:          available,
+			PendingSettlement:  pending,
+			SellingLiabilities: balance.SellingLiabilities,
+			BuyingLiabilities:  balance.BuyingLiabilities,
+			LiquidityPool:      pool,
 		})
 	}
 
+	labels, metadata, _ := s.Registry.Metadata(publicKey)
 	return &models.WalletDetailsResponse{
 		PublicKey:      publicKey,
+		MuxedAddress:   muxedAddress(address, muxedID),
+		MuxedID:        muxedID,
 		Exists:         true,
 		Balances:       balances,
 		SequenceNumber: account.Sequence,
+		Data:           dataEntries(account.Data),
+		Labels:         labels,
+		Metadata:       metadata,
 	}, nil
 }
 
-// TransferFunds transfers USDC between wallets
-func (s *WalletService) TransferFunds(req models.TransferRequest) (*models.TransferResponse, error) {
+// preparedTransfer is a validated transfer request with its assets resolved
+type preparedTransfer struct {
+	request  models.TransferRequest
+	senderKP *keypair.Full
+	// destination is the recipient's G... account; muxedID is set when ToPublicKey is an M... address
+	destination string
+	muxedID     string
+	// federationAddress is the recipient's federation address when it was given as one; request.ToPublicKey
+	// and the memo are then those it resolved to
+	federationAddress string
+	memo              txnbuild.Memo
+	sendAsset         txnbuild.Asset
+	// sources is set when an auto-selected transfer combines several of the sender's balances; sendAsset
+	// is then the first of them
+	sources   []sourceLeg
+	destAsset txnbuild.Asset
+	slippage  float64
+	// cosigners sign the transaction for the request's extra signers
+	cosigners []*keypair.Full
+}
+
+// prepareTransfer validates a transfer request and resolves its assets
+func (s *WalletService) prepareTransfer(req models.TransferRequest) (*preparedTransfer, error) {
 	senderKP, err := keypair.ParseFull(req.FromSecretKey)
 	if err != nil {
 		return nil, errors.New("invalid sender secret key")
 	}
+	if s.isDeactivated(senderKP.Address()) {
+		return nil, errors.New("sender wallet is deactivated")
+	}
+	if err := s.checkNotFrozen(senderKP.Address()); err != nil {
+		return nil, err
+	}
 
-	if _, err := keypair.ParseAddress(req.ToPublicKey); err != nil {
+	var federationAddress string
+	if isFederationAddress(req.ToPublicKey) {
+		if federationAddress, err = s.applyFederationAddress(&req); err != nil {
+			return nil, err
+		}
+	}
+	destination, muxedID, err := parseDestination(req.ToPublicKey)
+	if err != nil {
 		return nil, errors.New("invalid recipient public key")
 	}
 
-	if amountFloat, err := strconv.ParseFloat(req.Amount, 64); err != nil || amountFloat <= 0 {
-		return nil, errors.New("invalid amount: must be a positive number")
+	if _, err := parseAmount("amount", req.Amount); err != nil {
+		return nil, err
 	}
 
-	accountRequest := horizonclient.AccountRequest{AccountID: senderKP.Address()}
-	sourceAccount, err := s.Config.HorizonClient.AccountDetail(accountRequest)
+	autoSource := req.SourceAsset == models.SourceAssetAny
+	var sendAsset txnbuild.Asset = s.Config.USDCAsset
+	if req.SourceAsset != "" && !autoSource {
+		if sendAsset, err = parseAsset(req.SourceAsset); err != nil {
+			return nil, errors.New("invalid source asset: " + err.Error())
+		}
+	}
+	destAsset := sendAsset
+	if req.DestinationAsset != "" {
+		if destAsset, err = parseAsset(req.DestinationAsset); err != nil {
+			return nil, errors.New("invalid destination asset: " + err.Error())
+		}
+	}
+	slippage, err := parseSlippage(req.MaxSlippagePercent)
+	if err != nil {
+		return nil, err
+	}
+	memo, err := parseMemo(req.Memo, req.MemoType)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateExternalID(req.ExternalID); err != nil {
+		return nil, err
+	}
+	if err := s.validateCallbackURL(req.CallbackURL); err != nil {
+		return nil, err
+	}
+	assets := []txnbuild.Asset{destAsset}
+	if !autoSource {
+		assets = append(assets, sendAsset)
+	}
+	for _, asset := range assets {
+		if err := s.checkAssetPermitted(assetString(asset)); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.checkTransferAmount(req.Amount, assetString(destAsset)); err != nil {
+		return nil, err
+	}
+	if req.ClaimableAfterSeconds < 0 || req.ReclaimAfterSeconds < 0 {
+		return nil, errors.New("invalid claim predicate: windows must not be negative")
+	}
+	// The time bounds are set when the transaction is built, which may be after a review
+	if _, _, err := transactionPreconditions(req.TransactionPreconditions); err != nil {
+		return nil, err
+	}
+	cosigners, err := s.extraSignerKeys(req.ExtraSigners)
 	if err != nil {
-		return nil, errors.New("failed to fetch sender account details: " + err.Error())
+		return nil, err
 	}
 
-	paymentOp := txnbuild.Payment{
-		Destination: req.ToPublicKey,
-		Amount:      req.Amount,
-		Asset:       s.Config.USDCAsset,
-	}
-
-	tx, err := txnbuild.NewTransaction(
-		txnbuild.TransactionParams{
-			SourceAccount:        &sourceAccount,
-			Operations:           []txnbuild.Operation{&paymentOp},
-			BaseFee:              txnbuild.MinBaseFee,
-			Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
-			IncrementSequenceNum: true,
-		},
-	)
+	transfer := &preparedTransfer{
+		request:           req,
+		senderKP:          senderKP,
+		destination:       destination,
+		muxedID:           muxedID,
+		federationAddress: federationAddress,
+		memo:              memo,
+		sendAsset:         sendAsset,
+		destAsset:         destAsset,
+		slippage:          slippage,
+		cosigners:         cosigners,
+	}
+	if autoSource {
+		if err := s.selectSourceAssets(transfer); err != nil {
+			return nil, err
+		}
+	}
+	return transfer, nil
+}
+
+// TransferFunds transfers funds between wallets, using a path payment when the destination asset differs from the source asset.
+// Transfers the fraud scorer flags are held for manual review instead of being submitted. A transfer with an
+// external_id runs at most once per sender; repeats return the first result.
+func (s *WalletService) TransferFunds(req models.TransferRequest) (*models.TransferResponse, error) {
+	transfer, err := s.prepareTransfer(req)
 	if err != nil {
-		return nil, errors.New("failed to build transaction: " + err.Error())
+		return nil, err
 	}
+	response, err := s.runTransfer(transfer)
+	s.transferOutcome(transfer, response, err)
+	return response, err
+}
 
-	var networkPassphrase string
-	if s.Config.Network == "testnet" {
-		networkPassphrase = network.TestNetworkPassphrase
+// runTransfer executes a prepared transfer once per external_id
+func (s *WalletService) runTransfer(transfer *preparedTransfer) (*models.TransferResponse, error) {
+	req := transfer.request
+	if req.ExternalID == "" {
+		return s.transferFunds(transfer)
+	}
+
+	if replay, err := s.claimExternalID(transfer); err != nil || replay != nil {
+		return replay, err
+	}
+	// A transfer that may still be applied keeps its claim, so a retry cannot pay a second time
+	response, err := s.transferFunds(transfer)
+	if err != nil {
+		if definitelyNotApplied(err) {
+			s.releaseExternalID(transfer)
+		}
+		return nil, err
+	}
+	response.ExternalID = req.ExternalID
+	if response.Status == models.TransferPendingReview {
+		// A held transfer is not final until it is reviewed, so its claim only notes the review
+		if err := s.noteExternalID(transfer, func(entry *externalTransfer) { entry.ReviewID = response.ReviewID }); err != nil {
+			log.Printf("failed to record review of external_id %q of %s: %v", req.ExternalID, transfer.senderKP.Address(), err)
+		}
+		return response, nil
+	}
+	s.completeExternalID(transfer, response)
+	return response, nil
+}
+
+// transferFunds checks, screens and executes a prepared transfer
+func (s *WalletService) transferFunds(transfer *preparedTransfer) (*models.TransferResponse, error) {
+	if err := s.checkDestination(transfer); err != nil {
+		return nil, err
+	}
+
+	// A held transfer is checked against the spend limits when it is approved
+	if review, err := s.screenTransfer(transfer); err != nil {
+		return nil, err
+	} else if review != nil {
+		return &models.TransferResponse{
+			Status:           models.TransferPendingReview,
+			ReviewID:         review.ID,
+			Message:          "Transfer held for manual review",
+			SourceAsset:      assetString(transfer.sendAsset),
+			DestinationAsset: assetString(transfer.destAsset),
+		}, nil
+	}
+
+	if tenantID, ok := s.settlesInternally(transfer); ok {
+		return s.transferInternally(tenantID, transfer)
+	}
+	return s.executeTransfer(transfer)
+}
+
+// transferOperations builds the operations that deliver a prepared transfer: a payment, a path payment when
+// the assets differ, a claimable balance when the recipient cannot receive the asset directly, or one
+// operation per balance when an auto-selected transfer combines several
+func (s *WalletService) transferOperations(transfer *preparedTransfer) ([]txnbuild.Operation, *models.TransferResponse, bool, error) {
+	req := transfer.request
+	sendAsset, destAsset, slippage := transfer.sendAsset, transfer.destAsset, transfer.slippage
+
+	response := &models.TransferResponse{
+		SourceAsset:        assetString(sendAsset),
+		DestinationAsset:   assetString(destAsset),
+		DestinationMuxedID: transfer.muxedID,
+		FederationAddress:  transfer.federationAddress,
+	}
+
+	if len(transfer.sources) > 0 {
+		ops, sources, err := s.sourceOperations(transfer)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		response.Sources = sources
+		response.DeliveredAs = models.DeliveredAsPathPayment
+		return ops, response, false, nil
+	}
+
+	claimable, err := s.needsClaimableBalance(transfer)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	var op txnbuild.Operation
+	if claimable {
+		op = claimableBalanceOp(transfer)
+		response.DeliveredAs = models.DeliveredAsClaimableBalance
+	} else if assetString(sendAsset) == assetString(destAsset) {
+		response.DeliveredAs = models.DeliveredAsPayment
+		op = &txnbuild.Payment{
+			Destination: req.ToPublicKey,
+			Amount:      req.Amount,
+			Asset:       sendAsset,
+		}
 	} else {
-		networkPassphrase = network.PublicNetworkPassphrase
+		path, err := s.findStrictReceivePath(sendAsset, destAsset, req.Amount)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		sendMax, err := withSlippage(path.SourceAmount, slippage)
+		if err != nil {
+			return nil, nil, false, errors.New("failed to compute max send amount: " + err.Error())
+		}
+		op = &txnbuild.PathPaymentStrictReceive{
+			SendAsset:   sendAsset,
+			SendMax:     sendMax,
+			Destination: req.ToPublicKey,
+			DestAsset:   destAsset,
+			DestAmount:  req.Amount,
+			Path:        pathAssets(path.Path),
+		}
+		response.SendMax = sendMax
+		response.DeliveredAs = models.DeliveredAsPathPayment
 	}
 
-	tx, err = tx.Sign(networkPassphrase, senderKP)
+	return []txnbuild.Operation{op}, response, claimable, nil
+}
+
+// executeTransfer builds, signs and submits a prepared transfer
+func (s *WalletService) executeTransfer(transfer *preparedTransfer) (*models.TransferResponse, error) {
+	req := transfer.request
+	senderKP := transfer.senderKP
+
+	ops, response, claimable, err := s.transferOperations(transfer)
 	if err != nil {
-		return nil, errors.New("failed to sign transaction: " + err.Error())
+		return nil, err
+	}
+	if err := s.checkReserve(transfer, ops); err != nil {
+		return nil, err
 	}
 
-	resp, err := s.Config.HorizonClient.SubmitTransaction(tx)
+	preconditions, _, err := transactionPreconditions(req.TransactionPreconditions)
+	if err != nil {
+		return nil, err
+	}
+	reservation, err := s.reservePayments(senderKP.Address(), ops)
+	if err != nil {
+		return nil, err
+	}
+	signers := append([]*keypair.Full{senderKP}, transfer.cosigners...)
+	tx, err := s.buildTransaction(senderKP.Address(), txnbuild.TransactionParams{
+		Operations:    ops,
+		Memo:          transfer.memo,
+		Preconditions: preconditions,
+	}, signers...)
+	if err != nil {
+		s.releasePayments(reservation)
+		return nil, err
+	}
+
+	feeAccount, charge, err := s.sponsorFee(transfer, len(ops))
+	if err != nil {
+		s.releasePayments(reservation)
+		s.releaseSequence(tx)
+		return nil, err
+	}
+	eventData := map[string]string{
+		"to":                req.ToPublicKey,
+		"amount":            req.Amount,
+		"source_asset":      response.SourceAsset,
+		"destination_asset": response.DestinationAsset,
+	}
+	if req.ExternalID != "" {
+		eventData["external_id"] = req.ExternalID
+	}
+	// The transfer is only submitted once its event is staged, and its events are keyed by transaction hash
+	hash, err := tx.HashHex(s.networkPassphrase())
 	if err != nil {
-		if herr, ok := err.(*horizonclient.Error); ok {
-			return nil, errors.New("transaction failed: " + herr.Problem.Detail)
+		s.releasePayments(reservation)
+		s.releaseSequence(tx)
+		return nil, errors.New("failed to hash transaction: " + err.Error())
+	}
+	if err := s.stageEvent(models.EventTransferSubmitted, senderKP.Address(), hash, eventData); err != nil {
+		s.releasePayments(reservation)
+		s.releaseSequence(tx)
+		return nil, err
+	}
+	if req.ExternalID != "" {
+		if err := s.submittingExternalID(transfer, tx); err != nil {
+			s.releasePayments(reservation)
+			s.releaseSequence(tx)
+			return nil, err
 		}
-		return nil, errors.New("failed to submit transaction: " + err.Error())
 	}
+	resp, err := s.submitPayments(reservation, tx, feeAccount, signers)
+	s.settleFeeCharge(senderKP.Address(), charge, resp, err)
+	if err != nil {
+		// A rejected tx_bad_seq submission is rebuilt, so the claim keeps the transaction last submitted
+		if req.ExternalID != "" && !definitelyNotApplied(err) {
+			if err := s.submittingExternalID(transfer, resp.Tx); err != nil {
+				log.Printf("failed to record transaction of external_id %q of %s: %v", req.ExternalID, senderKP.Address(), err)
+			}
+		}
+		return nil, err
+	}
+	confirmed := map[string]string{"transaction_hash": resp.Hash}
+	for key, value := range eventData {
+		confirmed[key] = value
+	}
+	if err := s.stageEvent(models.EventTransferConfirmed, senderKP.Address(), resp.Hash, confirmed); err != nil {
+		// The transfer is already on the ledger, so its event is published without the outbox instead
+		log.Printf("failed to stage transfer.confirmed for %s, publishing directly: %v", resp.Hash, err)
+		s.Events.Publish(models.EventTransferConfirmed, senderKP.Address(), confirmed)
+	}
+	if claimable {
+		// The balance ID depends on the sequence number, so it is taken from the transaction that was applied
+		if response.ClaimableBalanceID, err = resp.Tx.ClaimableBalanceID(0); err != nil {
+			log.Printf("failed to compute claimable balance ID of %s: %v", resp.Hash, err)
+		}
+	}
+	response.SubmissionRetries = resp.Retries
+	response.FeeSponsored = feeAccount != nil
 
-	return &models.TransferResponse{
-		TransactionHash: resp.Hash,
-		Message:         "USDC transferred successfully",
-	}, nil
+	response.Status = models.TransferCompleted
+	response.TransactionHash = resp.Hash
+	response.Message = "Transfer completed successfully"
+	s.Audit.Record("wallet:"+senderKP.Address(), "transfer.completed", req.ToPublicKey, map[string]string{
+		"amount":            req.Amount,
+		"source_asset":      response.SourceAsset,
+		"destination_asset": response.DestinationAsset,
+		"transaction_hash":  resp.Hash,
+		"fee_sponsored":     strconv.FormatBool(response.FeeSponsored),
+	})
+	return response, nil
 }
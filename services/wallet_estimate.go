@@ -0,0 +1,70 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+)
+
+// sponsorshipOperations is the number of operations sponsoring the wallet's reserves adds: the begin and
+// end sponsoring operations around the account creation and trustline
+const sponsorshipOperations = 2
+
+// EstimateWalletCreation returns the XLM, reserves, fee and USDC a CreateWallet call with the given
+// profile would consume, without submitting anything
+func (s *WalletService) EstimateWalletCreation(profile string) (*models.WalletCreationEstimate, error) {
+	profile, trust, fund, err := walletProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+	ledgers, err := s.Config.HorizonClient.Ledgers(horizonclient.LedgerRequest{Order: horizonclient.OrderDesc, Limit: 1})
+	if err != nil {
+		return nil, errors.New("failed to fetch latest ledger: " + err.Error())
+	}
+	if len(ledgers.Embedded.Records) == 0 {
+		return nil, errors.New("failed to fetch latest ledger: no ledgers returned")
+	}
+	ledger := ledgers.Embedded.Records[0]
+
+	baseReserve := int64(ledger.BaseReserve)
+	// Every profile creates the account; the default home domain, trusting and funding add one operation each
+	operations := 1
+	if s.Config.WalletHomeDomain != "" {
+		operations++
+	}
+	trustlineReserve := int64(0)
+	if trust {
+		operations++
+		trustlineReserve = baseReserve
+	}
+	usdcGrant := "0"
+	if fund {
+		operations++
+		usdcGrant = walletUSDCGrant
+	}
+	sponsoredReserve := ""
+	if s.Config.SponsorWalletReserves {
+		operations += sponsorshipOperations
+		sponsoredReserve = amount.StringFromInt64(2*baseReserve + trustlineReserve)
+	}
+	fee := s.Fees.BaseFee() * int64(operations)
+	startingBalance := int64(amount.MustParse(s.walletStartingBalance()))
+
+	return &models.WalletCreationEstimate{
+		StartingBalance:  s.walletStartingBalance(),
+		AccountReserve:   amount.StringFromInt64(2 * baseReserve),
+		TrustlineReserve: amount.StringFromInt64(trustlineReserve),
+		NetworkFee:       amount.StringFromInt64(fee),
+		TotalXLM:         amount.StringFromInt64(startingBalance + fee),
+		SponsoredReserve: sponsoredReserve,
+		USDCGrant:        usdcGrant,
+		USDCAsset:        assetString(s.Config.USDCAsset),
+		Operations:       operations,
+		Profile:          profile,
+		BaseReserve:      ledger.BaseReserve,
+		BaseFee:          ledger.BaseFee,
+		Ledger:           ledger.Sequence,
+	}, nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/keypair"
+)
+
+// Limits on the labels and metadata a client attaches to a wallet
+const (
+	maxWalletLabels        = 20
+	maxWalletLabelLength   = 64
+	maxWalletMetadataKeys  = 20
+	maxWalletMetadataValue = 256
+)
+
+// metadataKey matches metadata keys such as user_id or cost.center
+var metadataKey = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// validateWalletMetadata checks labels and metadata against the limits, dropping duplicate labels
+func validateWalletMetadata(labels []string, metadata map[string]string) ([]string, error) {
+	if len(labels) > maxWalletLabels {
+		return nil, errors.New("invalid labels: at most " + strconv.Itoa(maxWalletLabels) + " labels")
+	}
+	unique := make([]string, 0, len(labels))
+	for _, label := range labels {
+		if label == "" || len(label) > maxWalletLabelLength {
+			return nil, errors.New("invalid labels: each label must be 1 to 64 bytes")
+		}
+		if !slices.Contains(unique, label) {
+			unique = append(unique, label)
+		}
+	}
+	if len(metadata) > maxWalletMetadataKeys {
+		return nil, errors.New("invalid metadata: at most " + strconv.Itoa(maxWalletMetadataKeys) + " keys")
+	}
+	for key, value := range metadata {
+		if !metadataKey.MatchString(key) {
+			return nil, errors.New("invalid metadata: key " + strconv.Quote(key) + " must be 1 to 64 letters, digits, '_', '.' or '-'")
+		}
+		if len(value) > maxWalletMetadataValue {
+			return nil, errors.New("invalid metadata: value of " + key + " exceeds 256 bytes")
+		}
+	}
+	return unique, nil
+}
+
+// SetWalletMetadata replaces the labels and metadata of a managed wallet
+func (s *WalletService) SetWalletMetadata(publicKey string, req models.WalletMetadataRequest) (*models.WalletMetadataResponse, error) {
+	if _, err := keypair.ParseAddress(publicKey); err != nil {
+		return nil, errors.New("invalid public key format")
+	}
+	labels, err := validateWalletMetadata(req.Labels, req.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	if !s.Registry.SetMetadata(publicKey, labels, req.Metadata) {
+		return nil, errors.New("wallet not found")
+	}
+	s.Registry.RecordUpdate(publicKey, "metadata_updated")
+
+	labels, metadata, _ := s.Registry.Metadata(publicKey)
+	return &models.WalletMetadataResponse{PublicKey: publicKey, Labels: labels, Metadata: metadata}, nil
+}
+
+// matchesMetadata reports whether a managed wallet carries every label and metadata value of a filter
+func (s *WalletService) matchesMetadata(publicKey string, labels []string, metadata map[string]string) bool {
+	if len(labels) == 0 && len(metadata) == 0 {
+		return true
+	}
+	walletLabels, walletMetadata, ok := s.Registry.Metadata(publicKey)
+	if !ok {
+		return false
+	}
+	for _, label := range labels {
+		if !slices.Contains(walletLabels, label) {
+			return false
+		}
+	}
+	for key, value := range metadata {
+		if walletValue, ok := walletMetadata[key]; !ok || walletValue != value {
+			return false
+		}
+	}
+	return true
+}
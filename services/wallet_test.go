@@ -0,0 +1,179 @@
+package services_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+	"github.com/saif727/stellar-wallet-backend/testsupport"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// transferFixture is a wallet service on a fake Horizon server with a sender holding 100 XLM and 50 USDC
+// and a recipient trusting USDC
+type transferFixture struct {
+	horizon   *testsupport.HorizonServer
+	service   *services.WalletService
+	sender    *keypair.Full
+	recipient *keypair.Full
+}
+
+func newTransferFixture(t *testing.T) *transferFixture {
+	t.Helper()
+	horizon, service := newFundedService(t, nil)
+	f := &transferFixture{
+		horizon:   horizon,
+		service:   service,
+		sender:    testsupport.NewKeypair(),
+		recipient: testsupport.NewKeypair(),
+	}
+	usdc := f.service.Config.USDCAsset
+	horizon.SetAccount(testsupport.NewAccount(f.sender.Address(), 100,
+		testsupport.NativeBalance("100"), testsupport.CreditBalance(usdc, "50")))
+	horizon.SetAccount(testsupport.NewAccount(f.recipient.Address(), 200,
+		testsupport.NativeBalance("10"), testsupport.CreditBalance(usdc, "0")))
+	return f
+}
+
+// newFundedService returns a wallet service on a fake Horizon server holding a funded master account;
+// configure, when set, adjusts the configuration before the service is built
+func newFundedService(t *testing.T, configure func(*services.Config)) (*testsupport.HorizonServer, *services.WalletService) {
+	t.Helper()
+	horizon := testsupport.NewHorizonServer()
+	t.Cleanup(horizon.Close)
+	config := testsupport.NewConfig(horizon.Client())
+	if configure != nil {
+		configure(&config)
+	}
+	master := keypair.MustParseFull(config.MasterSecret)
+	horizon.SetAccount(testsupport.NewAccount(master.Address(), 1, testsupport.NativeBalance("1000")))
+	return horizon, services.NewWalletService(config)
+}
+
+// newTenantWallet creates a wallet of tenantID holding 10 XLM and usdc USDC on the fake Horizon server
+func newTenantWallet(t *testing.T, horizon *testsupport.HorizonServer, service *services.WalletService, tenantID, usdc string) *keypair.Full {
+	t.Helper()
+	created, err := service.CreateWallet(tenantID, models.CreateWalletRequest{})
+	if err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+	horizon.SetAccount(testsupport.NewAccount(created.PublicKey, 1,
+		testsupport.NativeBalance("10"), testsupport.CreditBalance(service.Config.USDCAsset, usdc)))
+	return keypair.MustParseFull(created.SecretKey)
+}
+
+// submittedPayments decodes the payments of every transaction the fake Horizon server accepted
+func submittedPayments(t *testing.T, horizon *testsupport.HorizonServer) []*txnbuild.Payment {
+	t.Helper()
+	var payments []*txnbuild.Payment
+	for _, envelope := range horizon.Submitted() {
+		payments = append(payments, decodePayments(t, envelope)...)
+	}
+	return payments
+}
+
+// decodePayments decodes the payments of a transaction envelope, checking it is signed by its source account
+func decodePayments(t *testing.T, envelope string) []*txnbuild.Payment {
+	t.Helper()
+	parsed, err := txnbuild.TransactionFromXDR(envelope)
+	if err != nil {
+		t.Fatalf("decode envelope: %v", err)
+	}
+	tx, ok := parsed.Transaction()
+	if !ok {
+		feeBump, _ := parsed.FeeBump()
+		tx = feeBump.InnerTransaction()
+	}
+	hash, err := tx.Hash(network.TestNetworkPassphrase)
+	if err != nil {
+		t.Fatalf("hash transaction: %v", err)
+	}
+	source := keypair.MustParseAddress(tx.SourceAccount().AccountID)
+	signed := false
+	for _, signature := range tx.Signatures() {
+		if source.Verify(hash[:], signature.Signature) == nil {
+			signed = true
+		}
+	}
+	if !signed {
+		t.Errorf("transaction from %s is not signed by its source", source.Address())
+	}
+	var payments []*txnbuild.Payment
+	for _, op := range tx.Operations() {
+		if payment, ok := op.(*txnbuild.Payment); ok {
+			payments = append(payments, payment)
+		}
+	}
+	return payments
+}
+
+// sameAmount reports whether two decimal amounts are equal
+func sameAmount(a, b string) bool {
+	x, errX := amount.ParseInt64(a)
+	y, errY := amount.ParseInt64(b)
+	return errX == nil && errY == nil && x == y
+}
+
+func TestTransferFunds(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    string
+		rejectOps []string
+		wantErr   string
+		wantPaid  bool
+	}{
+		{name: "signs and submits a payment", amount: "12.5", wantPaid: true},
+		{name: "rejects a non-positive amount", amount: "0", wantErr: "invalid amount"},
+		{name: "reports a network rejection", amount: "5", rejectOps: []string{"op_underfunded"}, wantErr: "transaction failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTransferFixture(t)
+			if tt.rejectOps != nil {
+				f.horizon.FailNextSubmission(hProtocol.TransactionResultCodes{TransactionCode: "tx_failed", OperationCodes: tt.rejectOps})
+			}
+
+			response, err := f.service.TransferFunds(testsupport.NewTransferRequest(f.sender, f.recipient.Address(), tt.amount))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("TransferFunds() error = %v, want %q", err, tt.wantErr)
+				}
+				if tt.rejectOps != nil {
+					var txErr *services.TransactionError
+					if !errors.As(err, &txErr) || !slices.Equal(txErr.OperationCodes, tt.rejectOps) {
+						t.Errorf("TransferFunds() error = %#v, want a TransactionError with %v", err, tt.rejectOps)
+					}
+				}
+			} else if err != nil {
+				t.Fatalf("TransferFunds() error = %v", err)
+			}
+
+			if got := len(f.horizon.Rejected()); tt.rejectOps != nil && got != 1 {
+				t.Errorf("network rejected %d transactions, want 1", got)
+			}
+			payments := submittedPayments(t, f.horizon)
+			if !tt.wantPaid {
+				if len(payments) != 0 {
+					t.Fatalf("submitted %d payments, want none", len(payments))
+				}
+				return
+			}
+			if response.Status != models.TransferCompleted || response.TransactionHash == "" {
+				t.Errorf("TransferFunds() = %+v, want a completed transfer with a hash", response)
+			}
+			if len(payments) != 1 {
+				t.Fatalf("submitted %d payments, want 1", len(payments))
+			}
+			if payments[0].Destination != f.recipient.Address() || !sameAmount(payments[0].Amount, tt.amount) {
+				t.Errorf("payment = %s to %s, want %s to %s", payments[0].Amount, payments[0].Destination, tt.amount, f.recipient.Address())
+			}
+		})
+	}
+}
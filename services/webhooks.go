@@ -0,0 +1,691 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/url"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/stellar/go/amount"
+)
+
+const (
+	// webhookSubscriptionsKey and webhookDeadLettersKey are the archive keys all webhook subscriptions and
+	// dead letters are persisted under
+	webhookSubscriptionsKey = "webhooks/subscriptions.json"
+	webhookDeadLettersKey   = "webhooks/dead-letters.json"
+	// webhookDeliveryIndexKey indexes the logged delivery attempts, without their payloads; each attempt is
+	// persisted in full under webhookDeliveryKey
+	webhookDeliveryIndexKey = "webhooks/delivery-index.json"
+	// webhookLegacyDeliveryLogKey held the whole delivery log, payloads included, before attempts were
+	// persisted one by one; it is migrated on first load
+	webhookLegacyDeliveryLogKey = "webhooks/delivery-log.json"
+	// maxWebhookSubscriptions caps the subscriptions of one tenant
+	maxWebhookSubscriptions = 50
+	// maxWebhookDeadLetters caps the dead letters kept; the oldest are dropped first
+	maxWebhookDeadLetters = 1000
+	// maxWebhookDeliveryLog caps the delivery attempts logged; the oldest are dropped first
+	maxWebhookDeliveryLog = 5000
+	// defaultWebhookDeliveryLogLimit and maxWebhookDeliveryLogLimit bound a page of the delivery log
+	defaultWebhookDeliveryLogLimit = 100
+	maxWebhookDeliveryLogLimit     = 500
+	// minWebhookSecretLength is the shortest signing secret a subscriber may choose
+	minWebhookSecretLength = 32
+	// webhookSecretRotationGrace is how long a rotated-out secret keeps signing deliveries
+	webhookSecretRotationGrace = 24 * time.Hour
+)
+
+// WebhookService manages webhook subscriptions and delivers the wallet events they match. Each subscription
+// has its own signing secret; deliveries are signed like transfer callbacks and retried with a doubling
+// delay up to Config.WebhookMaxAttempts times, after which they are dead-lettered until redriven. Every
+// attempt is logged, and a logged event can be replayed to its subscription.
+type WebhookService struct {
+	Wallets *WalletService
+
+	mu sync.Mutex
+	// subscriptions and deadLetters are keyed by ID and loaded from the archive store on first use, with
+	// deliveryLog, oldest first and without payloads. deliveryLogVersion counts changes to deliveryLog.
+	subscriptions      map[string]models.WebhookSubscription
+	deadLetters        map[string]models.WebhookDeadLetter
+	deliveryLog        []models.WebhookDeliveryAttempt
+	deliveryLogVersion int
+
+	// indexMu serializes writes of the delivery index, outside mu; indexedVersion is the deliveryLogVersion
+	// last written
+	indexMu        sync.Mutex
+	indexedVersion int
+}
+
+// webhookDeliveryKey is the archive key a logged delivery attempt is persisted under
+func webhookDeliveryKey(id string) string {
+	return "webhooks/deliveries/" + id + ".json"
+}
+
+// NewWebhookService creates a new WebhookService instance delivering the wallet service's events
+func NewWebhookService(wallets *WalletService) *WebhookService {
+	s := &WebhookService{Wallets: wallets}
+	wallets.Events.Subscribe(func(event models.Event) {
+		// Event handlers must not block, and loading subscriptions may read the archive store
+		go s.dispatch(event)
+	})
+	return s
+}
+
+// loadLocked reads the persisted subscriptions, dead letters and delivery log the first time they are
+// needed; s.mu must be held
+func (s *WebhookService) loadLocked() error {
+	if s.subscriptions != nil {
+		return nil
+	}
+	subscriptions := make(map[string]models.WebhookSubscription)
+	deadLetters := make(map[string]models.WebhookDeadLetter)
+	if err := s.readArchive(webhookSubscriptionsKey, "webhook subscriptions", &subscriptions); err != nil {
+		return err
+	}
+	if err := s.readArchive(webhookDeadLettersKey, "webhook dead letters", &deadLetters); err != nil {
+		return err
+	}
+	deliveryLog, err := s.readDeliveryIndex()
+	if err != nil {
+		return err
+	}
+	s.subscriptions, s.deadLetters, s.deliveryLog = subscriptions, deadLetters, deliveryLog
+	return nil
+}
+
+// readDeliveryIndex reads the delivery index, migrating a delivery log persisted as a whole to one record
+// per attempt
+func (s *WebhookService) readDeliveryIndex() ([]models.WebhookDeliveryAttempt, error) {
+	var index []models.WebhookDeliveryAttempt
+	if err := s.readArchive(webhookDeliveryIndexKey, "webhook delivery index", &index); err != nil {
+		return nil, err
+	}
+	if index != nil {
+		return index, nil
+	}
+	legacy := []models.WebhookDeliveryAttempt{}
+	if err := s.readArchive(webhookLegacyDeliveryLogKey, "webhook delivery log", &legacy); err != nil {
+		return nil, err
+	}
+	index = make([]models.WebhookDeliveryAttempt, 0, len(legacy))
+	for _, attempt := range legacy {
+		if err := s.writeArchive(webhookDeliveryKey(attempt.ID), "webhook delivery attempt", attempt); err != nil {
+			return nil, err
+		}
+		attempt.Payload = nil
+		index = append(index, attempt)
+	}
+	if len(legacy) > 0 {
+		if err := s.writeArchive(webhookDeliveryIndexKey, "webhook delivery index", index); err != nil {
+			return nil, err
+		}
+	}
+	return index, nil
+}
+
+// deliveryPayload reads the payload of a logged delivery attempt
+func (s *WebhookService) deliveryPayload(attempt models.WebhookDeliveryAttempt) (json.RawMessage, error) {
+	var record models.WebhookDeliveryAttempt
+	if err := s.readArchive(webhookDeliveryKey(attempt.ID), "webhook delivery attempt", &record); err != nil {
+		return nil, err
+	}
+	return record.Payload, nil
+}
+
+// readArchive decodes a persisted value into v, leaving v as is when nothing was persisted
+func (s *WebhookService) readArchive(key, what string, v interface{}) error {
+	if s.Wallets.Archive == nil {
+		return nil
+	}
+	data, err := s.Wallets.Archive.Get(key)
+	switch {
+	case errors.Is(err, errArchiveNotFound):
+		return nil
+	case err != nil:
+		return errors.New("failed to read " + what + ": " + err.Error())
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.New("failed to decode " + what + ": " + err.Error())
+	}
+	return nil
+}
+
+// writeArchive persists v
+func (s *WebhookService) writeArchive(key, what string, v interface{}) error {
+	if s.Wallets.Archive == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.New("failed to encode " + what + ": " + err.Error())
+	}
+	if err := s.Wallets.Archive.Put(key, data); err != nil {
+		return errors.New("failed to persist " + what + ": " + err.Error())
+	}
+	return nil
+}
+
+// saveLocked persists subscriptions before they replace the loaded ones; s.mu must be held
+func (s *WebhookService) saveLocked(subscriptions map[string]models.WebhookSubscription) error {
+	if err := s.writeArchive(webhookSubscriptionsKey, "webhook subscriptions", subscriptions); err != nil {
+		return err
+	}
+	s.subscriptions = subscriptions
+	return nil
+}
+
+// saveDeadLettersLocked persists dead letters before they replace the loaded ones; s.mu must be held
+func (s *WebhookService) saveDeadLettersLocked(deadLetters map[string]models.WebhookDeadLetter) error {
+	if err := s.writeArchive(webhookDeadLettersKey, "webhook dead letters", deadLetters); err != nil {
+		return err
+	}
+	s.deadLetters = deadLetters
+	return nil
+}
+
+// newWebhookSecret returns a random signing secret for a subscription
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.New("failed to generate webhook secret: " + err.Error())
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// CreateSubscription subscribes a URL to some of a tenant's events, optionally of one wallet only and only
+// those of some assets or of at least some amount. The response carries the subscription's signing secret,
+// which is not returned again.
+func (s *WebhookService) CreateSubscription(tenantID string, req models.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return nil, errors.New("invalid url: must be an absolute http or https URL")
+	}
+	if err := checkCallbackHost(parsed.Hostname()); err != nil {
+		return nil, errors.New("invalid url: " + err.Error())
+	}
+	if len(req.EventTypes) == 0 {
+		return nil, errors.New("invalid event_types: at least one event type is required")
+	}
+	eventTypes := []string{}
+	for _, eventType := range req.EventTypes {
+		if !slices.Contains(models.EventTypes, eventType) {
+			return nil, errors.New("invalid event_types: unknown event type " + eventType)
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	if req.Wallet != "" {
+		if owner, ok := s.Wallets.Registry.TenantOf(req.Wallet); !ok || owner != tenantID {
+			return nil, errors.New("invalid wallet: not a custodied wallet of this tenant")
+		}
+	}
+	assets := []string{}
+	for _, asset := range req.Assets {
+		parsed, err := parseAsset(asset)
+		if err != nil {
+			return nil, errors.New("invalid assets: " + err.Error())
+		}
+		if canonical := assetString(parsed); !slices.Contains(assets, canonical) {
+			assets = append(assets, canonical)
+		}
+	}
+	if req.MinAmount != "" {
+		if stroops, err := amount.ParseInt64(req.MinAmount); err != nil || stroops <= 0 {
+			return nil, errors.New("invalid min_amount: must be a positive amount")
+		}
+	}
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, err
+		}
+	} else if len(secret) < minWebhookSecretLength {
+		return nil, errors.New("invalid secret: must be at least 32 characters")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	next := make(map[string]models.WebhookSubscription, len(s.subscriptions)+1)
+	count := 0
+	for id, subscription := range s.subscriptions {
+		next[id] = subscription
+		if subscription.TenantID == tenantID {
+			count++
+		}
+	}
+	if count >= maxWebhookSubscriptions {
+		return nil, errors.New("webhook subscription limit reached: a tenant may have at most 50 subscriptions")
+	}
+	subscription := models.WebhookSubscription{
+		ID:         newID(),
+		TenantID:   tenantID,
+		URL:        req.URL,
+		EventTypes: eventTypes,
+		Wallet:     req.Wallet,
+		Assets:     assets,
+		MinAmount:  req.MinAmount,
+		Secret:     secret,
+		CreatedAt:  time.Now().UTC(),
+	}
+	next[subscription.ID] = subscription
+	if err := s.saveLocked(next); err != nil {
+		return nil, err
+	}
+	s.Wallets.Audit.Record("tenant:"+tenantID, "webhook.subscribed", subscription.ID, map[string]string{"url": subscription.URL})
+	return &subscription, nil
+}
+
+// ListSubscriptions returns a tenant's subscriptions, oldest first and without their secrets, optionally only
+// those covering one wallet
+func (s *WebhookService) ListSubscriptions(tenantID, wallet string) (*models.WebhookSubscriptionsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	response := &models.WebhookSubscriptionsResponse{Subscriptions: []models.WebhookSubscription{}}
+	for _, subscription := range s.subscriptions {
+		if subscription.TenantID != tenantID || (wallet != "" && subscription.Wallet != "" && subscription.Wallet != wallet) {
+			continue
+		}
+		subscription.Secret, subscription.PreviousSecret = "", ""
+		response.Subscriptions = append(response.Subscriptions, subscription)
+	}
+	sort.Slice(response.Subscriptions, func(i, j int) bool {
+		return response.Subscriptions[i].CreatedAt.Before(response.Subscriptions[j].CreatedAt)
+	})
+	return response, nil
+}
+
+// DeleteSubscription removes one of a tenant's subscriptions
+func (s *WebhookService) DeleteSubscription(tenantID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	if subscription, ok := s.subscriptions[id]; !ok || subscription.TenantID != tenantID {
+		return errors.New("webhook subscription not found")
+	}
+	next := make(map[string]models.WebhookSubscription, len(s.subscriptions))
+	for key, subscription := range s.subscriptions {
+		if key != id {
+			next[key] = subscription
+		}
+	}
+	if err := s.saveLocked(next); err != nil {
+		return err
+	}
+	s.Wallets.Audit.Record("tenant:"+tenantID, "webhook.unsubscribed", id, nil)
+	return nil
+}
+
+// RotateSecret replaces a subscription's signing secret with a new random one, effective for the next
+// delivery. Deliveries are signed with the old secret too for webhookSecretRotationGrace, so the receiver
+// can switch over without rejecting any. The response carries the new secret, which is not returned again.
+func (s *WebhookService) RotateSecret(tenantID, id string) (*models.WebhookSubscription, error) {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	subscription, ok := s.subscriptions[id]
+	if !ok || subscription.TenantID != tenantID {
+		return nil, errors.New("webhook subscription not found")
+	}
+	expiresAt := time.Now().UTC().Add(webhookSecretRotationGrace)
+	subscription.PreviousSecret, subscription.PreviousSecretExpiresAt = subscription.Secret, &expiresAt
+	subscription.Secret = secret
+	next := make(map[string]models.WebhookSubscription, len(s.subscriptions))
+	for key, existing := range s.subscriptions {
+		next[key] = existing
+	}
+	next[id] = subscription
+	if err := s.saveLocked(next); err != nil {
+		return nil, err
+	}
+	s.Wallets.Audit.Record("tenant:"+tenantID, "webhook.secret_rotated", id, nil)
+	subscription.PreviousSecret = ""
+	return &subscription, nil
+}
+
+// subscriptionMatches reports whether a subscription of the event wallet's tenant wants an event. The asset
+// and amount filters only apply to events that carry an asset or amount, such as payments.
+func subscriptionMatches(subscription models.WebhookSubscription, event models.Event) bool {
+	if subscription.Wallet != "" && subscription.Wallet != event.PublicKey {
+		return false
+	}
+	if !slices.Contains(subscription.EventTypes, event.Type) {
+		return false
+	}
+	if asset, ok := event.Data["asset"]; ok && len(subscription.Assets) > 0 && !slices.Contains(subscription.Assets, asset) {
+		return false
+	}
+	if value, ok := event.Data["amount"]; ok && subscription.MinAmount != "" {
+		// MinAmount was validated when the subscription was created
+		minimum, _ := amount.ParseInt64(subscription.MinAmount)
+		stroops, err := amount.ParseInt64(value)
+		if err != nil || stroops < minimum {
+			return false
+		}
+	}
+	return true
+}
+
+// dispatch delivers an event of a custodied wallet to every subscription of its tenant that matches it
+func (s *WebhookService) dispatch(event models.Event) {
+	tenantID, ok := s.Wallets.Registry.TenantOf(event.PublicKey)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	if err := s.loadLocked(); err != nil {
+		s.mu.Unlock()
+		log.Printf("webhook event %s: %v", event.ID, err)
+		return
+	}
+	var matched []models.WebhookSubscription
+	for _, subscription := range s.subscriptions {
+		if subscription.TenantID == tenantID && subscriptionMatches(subscription, event) {
+			matched = append(matched, subscription)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, subscription := range matched {
+		body, err := json.Marshal(models.WebhookDelivery{
+			ID:             event.ID,
+			SubscriptionID: subscription.ID,
+			Type:           event.Type,
+			TenantID:       tenantID,
+			Wallet:         event.PublicKey,
+			Data:           event.Data,
+			CreatedAt:      event.CreatedAt,
+		})
+		if err != nil {
+			log.Printf("webhook event %s: failed to encode: %v", event.ID, err)
+			continue
+		}
+		go s.deliver(subscription, models.WebhookDeadLetter{
+			ID:             newID(),
+			SubscriptionID: subscription.ID,
+			TenantID:       tenantID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Body:           body,
+		}, models.WebhookTriggerEvent)
+	}
+}
+
+// maxAttempts returns how many times a delivery is attempted before it is dead-lettered
+func (s *WebhookService) maxAttempts() int {
+	if s.Wallets.Config.WebhookMaxAttempts > 0 {
+		return s.Wallets.Config.WebhookMaxAttempts
+	}
+	return maxCallbackAttempts
+}
+
+// subscriptionSecrets returns the secrets a subscription's deliveries are signed with: its secret and,
+// during the grace period after a rotation, the previous one
+func subscriptionSecrets(subscription models.WebhookSubscription) []string {
+	if subscription.PreviousSecret == "" || subscription.PreviousSecretExpiresAt == nil ||
+		!time.Now().Before(*subscription.PreviousSecretExpiresAt) {
+		return []string{subscription.Secret}
+	}
+	return []string{subscription.Secret, subscription.PreviousSecret}
+}
+
+// deliver posts a delivery to its subscription, logging every attempt and dead-lettering it when every
+// attempt fails. letter carries the delivery and, for redrives, the attempts made so far; trigger records
+// why it is delivered.
+func (s *WebhookService) deliver(subscription models.WebhookSubscription, letter models.WebhookDeadLetter, trigger string) {
+	attempts := s.maxAttempts()
+	err := deliverSignedWith(subscription.URL, subscriptionSecrets(subscription), letter.Body, attempts, func(attempt, statusCode int, latency time.Duration, err error) {
+		logged := models.WebhookDeliveryAttempt{
+			ID:             newID(),
+			SubscriptionID: subscription.ID,
+			TenantID:       subscription.TenantID,
+			EventID:        letter.EventID,
+			EventType:      letter.EventType,
+			URL:            subscription.URL,
+			Trigger:        trigger,
+			Attempt:        attempt,
+			Payload:        letter.Body,
+			StatusCode:     statusCode,
+			LatencyMS:      latency.Milliseconds(),
+			Success:        err == nil,
+			AttemptedAt:    time.Now().UTC(),
+		}
+		if err != nil {
+			logged.Error = err.Error()
+		}
+		s.logAttempt(logged)
+	})
+	if err == nil {
+		return
+	}
+	log.Printf("webhook event %s to %s failed after %d attempts, dead-lettering: %v", letter.EventID, subscription.URL, attempts, err)
+	letter.URL = subscription.URL
+	letter.Attempts += attempts
+	letter.LastError = err.Error()
+	letter.FailedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		log.Printf("webhook event %s: %v", letter.EventID, err)
+		return
+	}
+	next := make(map[string]models.WebhookDeadLetter, len(s.deadLetters)+1)
+	for id, existing := range s.deadLetters {
+		next[id] = existing
+	}
+	next[letter.ID] = letter
+	for len(next) > maxWebhookDeadLetters {
+		oldest := ""
+		for id, existing := range next {
+			if oldest == "" || existing.FailedAt.Before(next[oldest].FailedAt) {
+				oldest = id
+			}
+		}
+		delete(next, oldest)
+	}
+	if err := s.saveDeadLettersLocked(next); err != nil {
+		log.Printf("webhook event %s: %v", letter.EventID, err)
+	}
+}
+
+// logAttempt persists a delivery attempt under its own key and appends it to the log, dropping the oldest
+// entries beyond the cap. Nothing is written while s.mu is held.
+func (s *WebhookService) logAttempt(attempt models.WebhookDeliveryAttempt) {
+	if err := s.writeArchive(webhookDeliveryKey(attempt.ID), "webhook delivery attempt", attempt); err != nil {
+		log.Printf("webhook event %s: %v", attempt.EventID, err)
+		return
+	}
+	attempt.Payload = nil
+	s.mu.Lock()
+	if err := s.loadLocked(); err != nil {
+		s.mu.Unlock()
+		log.Printf("webhook event %s: %v", attempt.EventID, err)
+		return
+	}
+	next := append(make([]models.WebhookDeliveryAttempt, 0, len(s.deliveryLog)+1), s.deliveryLog...)
+	next = append(next, attempt)
+	if len(next) > maxWebhookDeliveryLog {
+		next = next[len(next)-maxWebhookDeliveryLog:]
+	}
+	s.deliveryLog = next
+	s.deliveryLogVersion++
+	s.mu.Unlock()
+	s.persistDeliveryIndex()
+}
+
+// persistDeliveryIndex writes the current delivery index unless a concurrent call already wrote it, or a
+// newer one
+func (s *WebhookService) persistDeliveryIndex() {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	s.mu.Lock()
+	// deliveryLog is replaced, never modified, so it is safe to encode after s.mu is released
+	index, version := s.deliveryLog, s.deliveryLogVersion
+	s.mu.Unlock()
+	if version <= s.indexedVersion {
+		return
+	}
+	if err := s.writeArchive(webhookDeliveryIndexKey, "webhook delivery index", index); err != nil {
+		log.Printf("webhook delivery index: %v", err)
+		return
+	}
+	s.indexedVersion = version
+}
+
+// ListDeliveries returns a tenant's logged delivery attempts, newest first, optionally only those of one
+// subscription or event
+func (s *WebhookService) ListDeliveries(tenantID, subscriptionID, eventID string, limit int) (*models.WebhookDeliveryLogResponse, error) {
+	if limit == 0 {
+		limit = defaultWebhookDeliveryLogLimit
+	}
+	if limit < 1 || limit > maxWebhookDeliveryLogLimit {
+		return nil, errors.New("invalid limit: must be between 1 and 500")
+	}
+	s.mu.Lock()
+	if err := s.loadLocked(); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	response := &models.WebhookDeliveryLogResponse{Attempts: []models.WebhookDeliveryAttempt{}}
+	for i := len(s.deliveryLog) - 1; i >= 0 && len(response.Attempts) < limit; i-- {
+		attempt := s.deliveryLog[i]
+		if attempt.TenantID == tenantID && (subscriptionID == "" || attempt.SubscriptionID == subscriptionID) &&
+			(eventID == "" || attempt.EventID == eventID) {
+			response.Attempts = append(response.Attempts, attempt)
+		}
+	}
+	s.mu.Unlock()
+	for i, attempt := range response.Attempts {
+		payload, err := s.deliveryPayload(attempt)
+		if err != nil {
+			return nil, err
+		}
+		response.Attempts[i].Payload = payload
+	}
+	return response, nil
+}
+
+// ReplayEvent delivers a logged event again, in the background, to the subscription it was delivered to,
+// at the subscription's current URL and signed with its current secret. The payload is unchanged, so the
+// receiver sees the same event ID.
+func (s *WebhookService) ReplayEvent(tenantID, subscriptionID, eventID string) (*models.WebhookReplayResponse, error) {
+	s.mu.Lock()
+	if err := s.loadLocked(); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	subscription, ok := s.subscriptions[subscriptionID]
+	if !ok || subscription.TenantID != tenantID {
+		s.mu.Unlock()
+		return nil, errors.New("webhook subscription not found")
+	}
+	var logged *models.WebhookDeliveryAttempt
+	for i := len(s.deliveryLog) - 1; i >= 0; i-- {
+		if s.deliveryLog[i].SubscriptionID == subscriptionID && s.deliveryLog[i].EventID == eventID {
+			logged = &s.deliveryLog[i]
+			break
+		}
+	}
+	s.mu.Unlock()
+	if logged == nil {
+		return nil, errors.New("webhook event not found in the delivery log")
+	}
+	payload, err := s.deliveryPayload(*logged)
+	if err != nil {
+		return nil, err
+	}
+	if payload == nil {
+		return nil, errors.New("webhook event not found in the delivery log")
+	}
+	go s.deliver(subscription, models.WebhookDeadLetter{
+		ID:             newID(),
+		SubscriptionID: subscriptionID,
+		TenantID:       tenantID,
+		EventID:        eventID,
+		EventType:      logged.EventType,
+		Body:           payload,
+	}, models.WebhookTriggerReplay)
+	s.Wallets.Audit.Record("tenant:"+tenantID, "webhook.replayed", subscriptionID, map[string]string{"event_id": eventID})
+	return &models.WebhookReplayResponse{
+		SubscriptionID: subscriptionID,
+		EventID:        eventID,
+		Status:         models.WebhookRedriveQueued,
+	}, nil
+}
+
+// ListDeadLetters returns a tenant's dead-lettered deliveries, newest first, optionally of one subscription
+func (s *WebhookService) ListDeadLetters(tenantID, subscriptionID string) (*models.WebhookDeadLettersResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	response := &models.WebhookDeadLettersResponse{DeadLetters: []models.WebhookDeadLetter{}}
+	for _, letter := range s.deadLetters {
+		if letter.TenantID == tenantID && (subscriptionID == "" || letter.SubscriptionID == subscriptionID) {
+			response.DeadLetters = append(response.DeadLetters, letter)
+		}
+	}
+	sort.Slice(response.DeadLetters, func(i, j int) bool {
+		return response.DeadLetters[i].FailedAt.After(response.DeadLetters[j].FailedAt)
+	})
+	return response, nil
+}
+
+// RedriveDeadLetter takes a dead-lettered delivery off the queue and delivers it again in the background to
+// its subscription's current URL. It is dead-lettered again if every attempt fails.
+func (s *WebhookService) RedriveDeadLetter(tenantID, id string) (*models.WebhookRedriveResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	letter, ok := s.deadLetters[id]
+	if !ok || letter.TenantID != tenantID {
+		return nil, errors.New("dead letter not found")
+	}
+	subscription, ok := s.subscriptions[letter.SubscriptionID]
+	if !ok {
+		return nil, errors.New("webhook subscription no longer exists")
+	}
+	next := make(map[string]models.WebhookDeadLetter, len(s.deadLetters))
+	for key, existing := range s.deadLetters {
+		if key != id {
+			next[key] = existing
+		}
+	}
+	if err := s.saveDeadLettersLocked(next); err != nil {
+		return nil, err
+	}
+	go s.deliver(subscription, letter, models.WebhookTriggerRedrive)
+	s.Wallets.Audit.Record("tenant:"+tenantID, "webhook.redriven", id, map[string]string{"event_id": letter.EventID})
+	return &models.WebhookRedriveResponse{
+		ID:             letter.ID,
+		SubscriptionID: letter.SubscriptionID,
+		EventID:        letter.EventID,
+		Status:         models.WebhookRedriveQueued,
+	}, nil
+}
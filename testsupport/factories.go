@@ -0,0 +1,141 @@
+// Package testsupport provides factories, a fake Horizon server and a fake wallet service for writing fast
+// unit tests against the client and service layers without a Stellar network.
+package testsupport
+
+import (
+	"time"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/txnbuild"
+)
+
+// DefaultBaseReserve is the base reserve, in stroops, reported by the fake Horizon server's ledgers
+const DefaultBaseReserve = 5000000
+
+// NewKeypair returns a fresh random keypair
+func NewKeypair() *keypair.Full {
+	return keypair.MustRandom()
+}
+
+// NewUSDCAsset returns a USDC asset issued by a fresh random account
+func NewUSDCAsset() txnbuild.CreditAsset {
+	return txnbuild.CreditAsset{Code: "USDC", Issuer: NewKeypair().Address()}
+}
+
+// NewConfig returns a testnet service configuration with a random master account, a random USDC issuer
+// and client as the Horizon client
+func NewConfig(client *horizonclient.Client) services.Config {
+	return services.Config{
+		Network:       "testnet",
+		MasterSecret:  NewKeypair().Seed(),
+		HorizonClient: client,
+		USDCAsset:     NewUSDCAsset(),
+	}
+}
+
+// NewWalletService returns a WalletService configured by NewConfig
+func NewWalletService(client *horizonclient.Client) *services.WalletService {
+	return services.NewWalletService(NewConfig(client))
+}
+
+// NewTransferRequest returns a transfer of value of the default asset from one keypair to a public key
+func NewTransferRequest(from *keypair.Full, to, value string) models.TransferRequest {
+	return models.TransferRequest{FromSecretKey: from.Seed(), ToPublicKey: to, Amount: value}
+}
+
+// NativeBalance returns a Horizon XLM balance
+func NativeBalance(value string) hProtocol.Balance {
+	return hProtocol.Balance{Balance: value, Asset: base.Asset{Type: "native"}}
+}
+
+// CreditBalance returns a Horizon trustline balance with the maximum limit
+func CreditBalance(asset txnbuild.CreditAsset, value string) hProtocol.Balance {
+	assetType := "credit_alphanum4"
+	if len(asset.Code) > 4 {
+		assetType = "credit_alphanum12"
+	}
+	return hProtocol.Balance{
+		Balance: value,
+		Limit:   "922337203685.4775807",
+		Asset:   base.Asset{Type: assetType, Code: asset.Code, Issuer: asset.Issuer},
+	}
+}
+
+// NewAccount returns a Horizon account with the given sequence number and balances; the subentry count
+// matches the number of trustlines
+func NewAccount(publicKey string, sequence int64, balances ...hProtocol.Balance) hProtocol.Account {
+	account := hProtocol.Account{
+		ID:        publicKey,
+		AccountID: publicKey,
+		Sequence:  sequence,
+		Balances:  balances,
+	}
+	for _, balance := range balances {
+		if balance.Type != "native" {
+			account.SubentryCount++
+		}
+	}
+	return account
+}
+
+// NewLedger returns a Horizon ledger with the default base fee and reserve
+func NewLedger(sequence int32) hProtocol.Ledger {
+	return hProtocol.Ledger{
+		Sequence:                   sequence,
+		BaseFee:                    txnbuild.MinBaseFee,
+		BaseReserve:                DefaultBaseReserve,
+		ClosedAt:                   time.Now().UTC(),
+		SuccessfulTransactionCount: 1,
+	}
+}
+
+// NewTransactionResponse returns a successful Horizon transaction response
+func NewTransactionResponse(hash, envelope string, ledger int32) hProtocol.Transaction {
+	return hProtocol.Transaction{
+		ID:              hash,
+		Hash:            hash,
+		Ledger:          ledger,
+		Successful:      true,
+		EnvelopeXdr:     envelope,
+		LedgerCloseTime: time.Now().UTC(),
+	}
+}
+
+// NewPayment returns a payment operation of value of asset to destination
+func NewPayment(destination, value string, asset txnbuild.Asset) *txnbuild.Payment {
+	return &txnbuild.Payment{Destination: destination, Amount: value, Asset: asset}
+}
+
+// SignedEnvelope builds a testnet transaction from source at sequence, signs it with source and returns it
+// with its base64 envelope and hex hash
+func SignedEnvelope(source *keypair.Full, sequence int64, ops ...txnbuild.Operation) (*txnbuild.Transaction, string, string, error) {
+	account := txnbuild.NewSimpleAccount(source.Address(), sequence)
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount:        &account,
+		Operations:           ops,
+		BaseFee:              txnbuild.MinBaseFee,
+		Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewTimeout(300)},
+		IncrementSequenceNum: true,
+	})
+	if err != nil {
+		return nil, "", "", err
+	}
+	if tx, err = tx.Sign(network.TestNetworkPassphrase, source); err != nil {
+		return nil, "", "", err
+	}
+	envelope, err := tx.Base64()
+	if err != nil {
+		return nil, "", "", err
+	}
+	hash, err := tx.HashHex(network.TestNetworkPassphrase)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return tx, envelope, hash, nil
+}
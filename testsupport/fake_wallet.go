@@ -0,0 +1,135 @@
+package testsupport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/saif727/stellar-wallet-backend/models"
+	"github.com/saif727/stellar-wallet-backend/services"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
+)
+
+var _ services.WalletAPI = (*FakeWalletService)(nil)
+
+// FakeWalletService is an in-memory services.WalletAPI. Wallets hold balances keyed by asset string
+// ("native" or CODE:ISSUER, or "USDC" for the default asset) and transfers move them without any network.
+type FakeWalletService struct {
+	// Err, when set, is returned by every call
+	Err error
+
+	mu        sync.Mutex
+	balances  map[string]map[string]int64
+	transfers []models.TransferRequest
+}
+
+// NewFakeWalletService creates an empty FakeWalletService
+func NewFakeWalletService() *FakeWalletService {
+	return &FakeWalletService{balances: make(map[string]map[string]int64)}
+}
+
+// Fund credits value of asset to a wallet, creating it if needed
+func (f *FakeWalletService) Fund(publicKey, asset, value string) error {
+	stroops, err := amount.ParseInt64(value)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.balances[publicKey] == nil {
+		f.balances[publicKey] = make(map[string]int64)
+	}
+	f.balances[publicKey][asset] += stroops
+	return nil
+}
+
+// Transfers returns every transfer that succeeded, in order
+func (f *FakeWalletService) Transfers() []models.TransferRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]models.TransferRequest{}, f.transfers...)
+}
+
+// CreateWallet creates a wallet holding no balances
+func (f *FakeWalletService) CreateWallet(tenantID string, req models.CreateWalletRequest) (*models.WalletResponse, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	kp := NewKeypair()
+	f.mu.Lock()
+	f.balances[kp.Address()] = make(map[string]int64)
+	f.mu.Unlock()
+	return &models.WalletResponse{
+		PublicKey: kp.Address(),
+		SecretKey: kp.Seed(),
+		Message:   "Wallet created successfully",
+	}, nil
+}
+
+// GetWalletDetails returns a wallet's balances; unknown wallets are reported as not existing
+func (f *FakeWalletService) GetWalletDetails(publicKey string) (*models.WalletDetailsResponse, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	balances, ok := f.balances[publicKey]
+	if !ok {
+		return &models.WalletDetailsResponse{PublicKey: publicKey, Exists: false, Balances: []models.Balance{}}, nil
+	}
+	details := &models.WalletDetailsResponse{PublicKey: publicKey, Exists: true, Balances: []models.Balance{}}
+	for asset, stroops := range balances {
+		balance := models.Balance{AssetType: "credit_alphanum4", AssetCode: asset, Balance: amount.StringFromInt64(stroops)}
+		if asset == "native" {
+			balance = models.Balance{AssetType: "native", Balance: balance.Balance}
+		}
+		balance.Available = balance.Balance
+		details.Balances = append(details.Balances, balance)
+	}
+	return details, nil
+}
+
+// TransferFunds moves Amount of SourceAsset (default "USDC") between wallets and returns a completed
+// transfer with a deterministic transaction hash
+func (f *FakeWalletService) TransferFunds(req models.TransferRequest) (*models.TransferResponse, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	kp, err := keypair.ParseFull(req.FromSecretKey)
+	if err != nil {
+		return nil, errors.New("invalid sender secret key")
+	}
+	stroops, err := amount.ParseInt64(req.Amount)
+	if err != nil || stroops <= 0 {
+		return nil, errors.New("invalid amount: must be a positive number")
+	}
+	asset := req.SourceAsset
+	if asset == "" {
+		asset = "USDC"
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.balances[kp.Address()][asset] < stroops {
+		return nil, errors.New("insufficient balance")
+	}
+	if f.balances[req.ToPublicKey] == nil {
+		return nil, errors.New("recipient account does not exist")
+	}
+	f.balances[kp.Address()][asset] -= stroops
+	f.balances[req.ToPublicKey][asset] += stroops
+	f.transfers = append(f.transfers, req)
+
+	sum := sha256.Sum256([]byte(kp.Address() + req.ToPublicKey + req.Amount + strconv.Itoa(len(f.transfers))))
+	return &models.TransferResponse{
+		Status:           models.TransferCompleted,
+		TransactionHash:  hex.EncodeToString(sum[:]),
+		Message:          "Transfer completed successfully",
+		SourceAsset:      asset,
+		DestinationAsset: asset,
+		DeliveredAs:      models.DeliveredAsPayment,
+	}, nil
+}
@@ -0,0 +1,202 @@
+package testsupport
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/network"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// HorizonServer is an in-process fake of the Horizon endpoints the service layer uses most: account
+// details, the latest ledger, transaction submission and transaction details. It holds no claimable
+// balances, so claimable balance listings are always empty. Submitted transactions
+// bump their source account's sequence number but do not move balances; submitting one again returns its
+// result. Other endpoints return 404.
+type HorizonServer struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	accounts     map[string]hProtocol.Account
+	transactions map[string]hProtocol.Transaction
+	submitted    []string
+	rejected     []string
+	ledger       int32
+	// failCodes, when set, makes the next submission fail with these result codes
+	failCodes *hProtocol.TransactionResultCodes
+	// loseResponses makes submissions apply but answer with a timeout
+	loseResponses bool
+}
+
+// NewHorizonServer starts a fake Horizon server; callers must Close it
+func NewHorizonServer() *HorizonServer {
+	h := &HorizonServer{
+		accounts:     make(map[string]hProtocol.Account),
+		transactions: make(map[string]hProtocol.Transaction),
+		ledger:       1,
+	}
+	h.Server = httptest.NewServer(http.HandlerFunc(h.serve))
+	return h
+}
+
+// Client returns a Horizon client pointed at the fake server
+func (h *HorizonServer) Client() *horizonclient.Client {
+	return &horizonclient.Client{HorizonURL: h.URL + "/", HTTP: h.Server.Client()}
+}
+
+// SetAccount adds or replaces an account
+func (h *HorizonServer) SetAccount(account hProtocol.Account) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.accounts[account.AccountID] = account
+}
+
+// Submitted returns the base64 envelopes of every successfully submitted transaction, in order
+func (h *HorizonServer) Submitted() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string{}, h.submitted...)
+}
+
+// Rejected returns the base64 envelopes of every submission failed by FailNextSubmission, in order
+func (h *HorizonServer) Rejected() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string{}, h.rejected...)
+}
+
+// FailNextSubmission makes the next transaction submission fail with a tx_failed problem carrying codes
+func (h *HorizonServer) FailNextSubmission(codes hProtocol.TransactionResultCodes) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failCodes = &codes
+}
+
+// LoseResponses makes submissions, while lose is set, apply their transaction but answer with a 504
+// timeout, as if the response had been lost on its way back
+func (h *HorizonServer) LoseResponses(lose bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.loseResponses = lose
+}
+
+func (h *HorizonServer) serve(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	path := strings.Trim(r.URL.Path, "/")
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "accounts/"):
+		account, ok := h.accounts[strings.TrimPrefix(path, "accounts/")]
+		if !ok {
+			writeProblem(w, http.StatusNotFound, "not_found", "Resource Missing", nil)
+			return
+		}
+		writeJSON(w, http.StatusOK, account)
+	case r.Method == http.MethodGet && path == "ledgers":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"_embedded": map[string]interface{}{"records": []hProtocol.Ledger{NewLedger(h.ledger)}},
+		})
+	case r.Method == http.MethodGet && path == "claimable_balances":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"_embedded": map[string]interface{}{"records": []hProtocol.ClaimableBalance{}},
+		})
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "transactions/"):
+		tx, ok := h.transactions[strings.TrimPrefix(path, "transactions/")]
+		if !ok {
+			writeProblem(w, http.StatusNotFound, "not_found", "Resource Missing", nil)
+			return
+		}
+		writeJSON(w, http.StatusOK, tx)
+	case r.Method == http.MethodPost && path == "transactions":
+		h.submit(w, r.FormValue("tx"))
+	default:
+		writeProblem(w, http.StatusNotFound, "not_found", "Resource Missing", nil)
+	}
+}
+
+// submit records a transaction envelope; h.mu must be held
+func (h *HorizonServer) submit(w http.ResponseWriter, envelope string) {
+	parsed, err := txnbuild.TransactionFromXDR(envelope)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "transaction_malformed", "Transaction Malformed", nil)
+		return
+	}
+	var hash, innerHash string
+	var source string
+	var sequence int64
+	if tx, ok := parsed.Transaction(); ok {
+		hash, err = tx.HashHex(network.TestNetworkPassphrase)
+		source, sequence = tx.SourceAccount().AccountID, tx.SequenceNumber()
+	} else if feeBump, ok := parsed.FeeBump(); ok {
+		hash, err = feeBump.HashHex(network.TestNetworkPassphrase)
+		inner := feeBump.InnerTransaction()
+		innerHash, _ = inner.HashHex(network.TestNetworkPassphrase)
+		source, sequence = inner.SourceAccount().AccountID, inner.SequenceNumber()
+	}
+	if err != nil || hash == "" {
+		writeProblem(w, http.StatusBadRequest, "transaction_malformed", "Transaction Malformed", nil)
+		return
+	}
+
+	// A transaction already on the ledger is answered with its result, as Horizon does
+	if response, ok := h.transactions[hash]; ok {
+		h.respond(w, response)
+		return
+	}
+	if h.failCodes != nil {
+		codes := *h.failCodes
+		h.failCodes = nil
+		h.rejected = append(h.rejected, envelope)
+		writeProblem(w, http.StatusBadRequest, "transaction_failed", "Transaction Failed", map[string]interface{}{
+			"envelope_xdr": envelope,
+			"result_codes": codes,
+		})
+		return
+	}
+
+	if account, ok := h.accounts[source]; ok && sequence > account.Sequence {
+		account.Sequence = sequence
+		h.accounts[source] = account
+	}
+	h.ledger++
+	response := NewTransactionResponse(hash, envelope, h.ledger)
+	h.transactions[hash] = response
+	if innerHash != "" {
+		// Horizon also serves fee bump transactions under their inner transaction's hash
+		h.transactions[innerHash] = response
+	}
+	h.submitted = append(h.submitted, envelope)
+	h.respond(w, response)
+}
+
+// respond answers a submission with its result, unless responses are being lost; h.mu must be held
+func (h *HorizonServer) respond(w http.ResponseWriter, response hProtocol.Transaction) {
+	if h.loseResponses {
+		writeProblem(w, http.StatusGatewayTimeout, "timeout", "Timeout", nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/hal+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeProblem(w http.ResponseWriter, status int, problemType, title string, extras map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"type":   "https://stellar.org/horizon-errors/" + problemType,
+		"title":  title,
+		"status": status,
+		"extras": extras,
+	})
+}
@@ -0,0 +1,117 @@
+// Package webhookverify validates the HMAC signatures and timestamps of webhooks sent by the wallet
+// backend. It depends only on the standard library so consumers can embed it without pulling in the
+// rest of the service.
+//
+// Every webhook carries two headers:
+//
+//	X-Webhook-Timestamp: 1700000000
+//	X-Webhook-Signature: v1=5257a869e7ecebeda32affa62cdca3fa51cad7e77a0e56ff536d0ce8e108d8bd
+//
+// The v1 signature is the hex-encoded HMAC-SHA256 of "<timestamp>.<raw request body>" keyed with the
+// webhook secret. During secret rotation the signature header lists one v1 entry per active secret,
+// separated by commas.
+package webhookverify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Header names carrying the webhook timestamp and signature
+const (
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// DefaultTolerance is how far a webhook timestamp may drift from the receiver's clock before the
+// webhook is rejected as a possible replay
+const DefaultTolerance = 5 * time.Minute
+
+// Verification errors
+var (
+	ErrMissingSignature  = errors.New("webhookverify: missing signature")
+	ErrInvalidTimestamp  = errors.New("webhookverify: invalid timestamp")
+	ErrTimestampExpired  = errors.New("webhookverify: timestamp outside tolerance")
+	ErrSignatureMismatch = errors.New("webhookverify: signature mismatch")
+)
+
+// Sign returns the signature header value for body sent at timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	return "v1=" + hex.EncodeToString(mac(secret, strconv.FormatInt(timestamp.Unix(), 10), body))
+}
+
+// SignAll returns the signature header value for body sent at timestamp with one v1 entry per secret, as
+// sent while a secret is rotated
+func SignAll(secrets []string, timestamp time.Time, body []byte) string {
+	entries := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		entries = append(entries, Sign(secret, timestamp, body))
+	}
+	return strings.Join(entries, ",")
+}
+
+// Verify checks that signature is a valid v1 signature of body at timestamp and that timestamp lies
+// within tolerance of the current time. A non-positive tolerance uses DefaultTolerance.
+func Verify(secret string, body []byte, timestamp, signature string, tolerance time.Duration) error {
+	return verifyAt(secret, body, timestamp, signature, tolerance, time.Now())
+}
+
+// VerifyRequest verifies an incoming webhook request and returns its body. The request body is
+// replaced so handlers can read it again.
+func VerifyRequest(r *http.Request, secret string, tolerance time.Duration) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := Verify(secret, body, r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader), tolerance); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func verifyAt(secret string, body []byte, timestamp, signature string, tolerance time.Duration, now time.Time) error {
+	if signature == "" {
+		return ErrMissingSignature
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	if drift := now.Sub(time.Unix(seconds, 0)); drift > tolerance || drift < -tolerance {
+		return ErrTimestampExpired
+	}
+
+	expected := mac(secret, timestamp, body)
+	for _, entry := range strings.Split(signature, ",") {
+		value := strings.TrimPrefix(strings.TrimSpace(entry), "v1=")
+		if value == strings.TrimSpace(entry) {
+			continue
+		}
+		if decoded, err := hex.DecodeString(value); err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return ErrSignatureMismatch
+}
+
+func mac(secret, timestamp string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
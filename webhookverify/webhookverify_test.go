@@ -0,0 +1,37 @@
+package webhookverify
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event":"transfer.completed"}`)
+	sent := time.Unix(1700000000, 0)
+	tests := []struct {
+		name      string
+		signature string
+		secret    string
+		now       time.Time
+		body      []byte
+		wantErr   error
+	}{
+		{name: "valid signature", signature: Sign("new", sent, body), secret: "new", now: sent, body: body},
+		{name: "rotation signs with the new secret", signature: SignAll([]string{"new", "old"}, sent, body), secret: "new", now: sent, body: body},
+		{name: "rotation signs with the old secret", signature: SignAll([]string{"new", "old"}, sent, body), secret: "old", now: sent, body: body},
+		{name: "wrong secret", signature: Sign("new", sent, body), secret: "other", now: sent, body: body, wantErr: ErrSignatureMismatch},
+		{name: "altered body", signature: Sign("new", sent, body), secret: "new", now: sent, body: []byte(`{}`), wantErr: ErrSignatureMismatch},
+		{name: "expired timestamp", signature: Sign("new", sent, body), secret: "new", now: sent.Add(DefaultTolerance + time.Second), body: body, wantErr: ErrTimestampExpired},
+		{name: "missing signature", secret: "new", now: sent, body: body, wantErr: ErrMissingSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyAt(tt.secret, tt.body, strconv.FormatInt(sent.Unix(), 10), tt.signature, 0, tt.now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("verifyAt() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}